@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/ack"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+)
+
+// ============== ACKNOWLEDGMENTS ==============
+
+// Nếu không có REDIS_URL thì ack chỉ lưu trong bộ nhớ, giống notification store
+func setupAckStore(cfg *config.Config) (ack.AckStore, func() error, error) {
+	if cfg.RedisURL == "" {
+		return ack.NewMemoryStore(), func() error { return nil }, nil
+	}
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ack.NewRedisStore(client), client.Close, nil
+}
+
+// setupAckConsumer tạo consumer group đọc acks topic. Với ack.MemoryStore,
+// mỗi instance chỉ thấy ack của các partition được gán cho nó.
+func setupAckConsumer(cfg *config.Config) (sarama.ConsumerGroup, error) {
+	config := sarama.NewConfig()
+	if err := kafka.ApplySecurity(config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to setup ack consumer: %w", err)
+	}
+	group, err := sarama.NewConsumerGroup(cfg.KafkaBrokers, cfg.AcksGroupID, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup ack consumer: %w", err)
+	}
+	return group, nil
+}
+
+// runAckConsumer lưu ack vào acks cho tới khi ctx bị huỷ.
+func runAckConsumer(ctx context.Context, group sarama.ConsumerGroup, topic string, acks ack.AckStore) {
+	handler := &ack.Handler{
+		Store: acks,
+		OnError: func(msg *sarama.ConsumerMessage, err error) {
+			log.Warn().Err(err).
+				Int32("partition", msg.Partition).
+				Int64("offset", msg.Offset).
+				Msg("skipping invalid ack")
+		},
+	}
+	for {
+		if err := group.Consume(ctx, []string{topic}, handler); err != nil {
+			log.Error().Err(err).Str("topic", topic).Msg("error from ack consumer")
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// ackNotificationHandler xử lý POST /notifications/:id/ack. Khi bật JWT, người ack là user trong token,
+// ngược lại lấy từ form userID. Ack được gửi qua Kafka nên client nhận 202 trước khi unread cập nhật.
+func ackNotificationHandler(publisher *ack.Publisher) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, ok := middleware.AuthedUserID(ctx)
+		if !ok {
+			parsed, err := strconv.Atoi(ctx.PostForm("userID"))
+			if err != nil || parsed <= 0 {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": "userID must be a positive number"})
+				return
+			}
+			userID = parsed
+		}
+
+		a := models.Ack{
+			NotificationID: ctx.Param("id"),
+			UserID:         userID,
+			AckedAt:        time.Now().UTC(),
+		}
+		if err := publisher.Publish(a); err != nil {
+			log.Error().Err(err).Str("notificationID", a.NotificationID).Msg("failed to publish ack")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusAccepted, a)
+	}
+}
+
+// notificationView là notification trả về trong GET /notifications kèm trạng thái đã đọc.
+type notificationView struct {
+	models.Notification
+	Acknowledged bool `json:"acknowledged"`
+}
+
+func withAcknowledged(notes []models.Notification, acked map[string]bool) []notificationView {
+	views := make([]notificationView, 0, len(notes))
+	for _, n := range notes {
+		views = append(views, notificationView{Notification: n, Acknowledged: n.ID != "" && acked[n.ID]})
+	}
+	return views
+}
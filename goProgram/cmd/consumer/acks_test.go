@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"kafka-notify/pkg/ack"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/gin-gonic/gin"
+)
+
+// postAck gọi POST /notifications/:id/ack và trả về message đã gửi sang acks topic
+func postAck(t *testing.T, notificationID, userID string) *sarama.ProducerMessage {
+	t.Helper()
+	var sent *sarama.ProducerMessage
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		sent = msg
+		return nil
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/notifications/:id/ack", ackNotificationHandler(ack.NewPublisher(producer, "notifications.acks")))
+	request := httptest.NewRequest(http.MethodPost, "/notifications/"+notificationID+"/ack",
+		strings.NewReader(url.Values{"userID": {userID}}.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("POST /notifications/%s/ack status = %d, want 202: %s", notificationID, recorder.Code, recorder.Body.String())
+	}
+	if err := producer.Close(); err != nil {
+		t.Fatalf("producer.Close() error = %v", err)
+	}
+	return sent
+}
+
+// consumeAcks cho ack consumer đọc các message đã gửi và lưu vào acks
+func consumeAcks(t *testing.T, acks ack.AckStore, sent ...*sarama.ProducerMessage) {
+	t.Helper()
+	msgs := make([]*sarama.ConsumerMessage, 0, len(sent))
+	for i, msg := range sent {
+		msgs = append(msgs, consumedMessage(t, msg, 0, int64(i)))
+	}
+	handler := &ack.Handler{Store: acks}
+	if err := handler.ConsumeClaim(newFakeSession(context.Background()), newFakeClaim(0, msgs...)); err != nil {
+		t.Fatalf("ConsumeClaim() error = %v", err)
+	}
+}
+
+// acknowledgedByID map ID của từng notification trong page sang trạng thái đã đọc
+func acknowledgedByID(page notificationsPage) map[string]bool {
+	ids := make(map[string]bool, len(page.Notifications))
+	for _, n := range page.Notifications {
+		ids[n.ID] = n.Acknowledged
+	}
+	return ids
+}
+
+func TestAckedNotificationsLeaveUnreadFilter(t *testing.T) {
+	notifications := storeUserNotifications(t, 5)
+	acks := ack.NewMemoryStore()
+
+	unread := decodePage(t, getNotifications(notifications, acks, "userID=2&unread=true"))
+	if unread.Total != 5 || len(unread.Notifications) != 5 {
+		t.Fatalf("unread before ack = %d of %d, want 5", len(unread.Notifications), unread.Total)
+	}
+
+	// ack của user khác không ảnh hưởng unread của user 2
+	consumeAcks(t, acks,
+		postAck(t, "notification-1", "2"),
+		postAck(t, "notification-3", "2"),
+		postAck(t, "notification-4", "3"),
+	)
+
+	unread = decodePage(t, getNotifications(notifications, acks, "userID=2&unread=true"))
+	ids := acknowledgedByID(unread)
+	if unread.Total != 3 || len(ids) != 3 {
+		t.Fatalf("unread after ack = %v (total %d), want 3", ids, unread.Total)
+	}
+	for _, id := range []string{"notification-0", "notification-2", "notification-4"} {
+		if acknowledged, ok := ids[id]; !ok || acknowledged {
+			t.Fatalf("unread %s = present %v, acknowledged %v, want present and not acknowledged", id, ok, acknowledged)
+		}
+	}
+
+	all := acknowledgedByID(decodePage(t, getNotifications(notifications, acks, "userID=2")))
+	if len(all) != 5 {
+		t.Fatalf("GET /notifications returned %d notifications, want 5", len(all))
+	}
+	for id, acknowledged := range all {
+		want := id == "notification-1" || id == "notification-3"
+		if acknowledged != want {
+			t.Fatalf("%s acknowledged = %v, want %v", id, acknowledged, want)
+		}
+	}
+
+	// ack lại notification đã đọc không làm thay đổi kết quả
+	consumeAcks(t, acks, postAck(t, "notification-1", "2"))
+	if page := decodePage(t, getNotifications(notifications, acks, "userID=2&unread=true")); page.Total != 3 {
+		t.Fatalf("unread after duplicate ack = %d, want 3", page.Total)
+	}
+}
+
+func TestListNotificationsHandlerInvalidUnread(t *testing.T) {
+	recorder := getNotifications(storeUserNotifications(t, 1), ack.NewMemoryStore(), "userID=2&unread=maybe")
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("GET /notifications?unread=maybe status = %d, want 422", recorder.Code)
+	}
+}
@@ -2,112 +2,467 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	models "kafka-notify/pkg"
-	"log"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/ack"
+	"kafka-notify/pkg/alerting"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/consumer"
+	"kafka-notify/pkg/dlq"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/flags"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/metrics"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/retry"
+	"kafka-notify/pkg/signing"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/tenant"
+	"kafka-notify/pkg/tracing"
+	"kafka-notify/pkg/worker"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/gin-gonic/gin"
-)
-
-const (
-	ConsumerGroup      = "notifications-group"
-	ConsumerTopic      = "notifications"
-	ConsumerPort       = ":8081"
-	KafkaServerAddress = "localhost:9092"
+	"github.com/rs/zerolog"
 )
 
 // ============== HELPER FUNCTIONS ==============
 
-var ErrNoMessagesFound = errors.New("no messages found")
+var (
+	ErrNoMessagesFound       = errors.New("no messages found")
+	ErrInvalidConsumerConfig = errors.New("invalid consumer config")
+)
 
-func getUserIDFromRequest(ctx *gin.Context) (string, error) {
+func getUserIDFromRequest(ctx *gin.Context) (int, error) {
 	userID := ctx.Param("userID")
 	if userID == "" {
-		return "", ErrNoMessagesFound
+		return 0, ErrNoMessagesFound
 	}
-	return userID, nil
+	return strconv.Atoi(userID)
 }
 
-// ====== NOTIFICATION STORAGE ======
+// ============== KAFKA RELATED FUNCTIONS ==============
 
-type UserNotifications map[string][]models.Notification
+// NotificationHandler xử lý một notification đã được giải mã từ Kafka,
+// trả về lỗi thì message không được mark và sẽ được xử lý lại.
+type NotificationHandler func(ctx context.Context, notification models.Notification) error
 
-//RWMutex cho phép nhiều goroutine có thể đọc dữ liệu cùng một lúc mà không cần khóa (lock) hoặc chặn lẫn nhau.
-//Tuy nhiên, chỉ một goroutine duy nhất được phép ghi (write) dữ liệu vào khi một RWMutex đang được khóa cho ghi.
+// NotificationDeleter xoá notification có ID id khi nhận tombstone từ Kafka,
+// trả về lỗi thì tombstone được xử lý lại giống NotificationHandler.
+type NotificationDeleter func(ctx context.Context, id string) error
 
-type NotificationStore struct {
-	data UserNotifications
-	mu   sync.RWMutex
+type Consumer struct {
+	handler NotificationHandler
+	// deleter xử lý tombstone, nil nghĩa là tombstone chỉ được ack
+	deleter NotificationDeleter
+	// DLQProducer nhận các message không giải mã được, nil nghĩa là chỉ log rồi bỏ qua
+	DLQProducer *dlq.Producer
+	// RetryProducer nhận message xử lý lỗi để retry consumer xử lý lại sau,
+	// nil nghĩa là trả lỗi và session sau sẽ đọc lại message
+	RetryProducer *retry.Producer
+	// SigningKey khác rỗng thì message thiếu hoặc sai X-Signature bị chuyển sang DLQ
+	SigningKey []byte
+	// Workers > 1 thì mỗi partition được xử lý song song trên Workers goroutine (CONSUMER_WORKERS)
+	Workers int
+	// Concurrency giới hạn số message được xử lý cùng lúc trên mọi partition (CONSUMER_MAX_GOROUTINES),
+	// nil là không giới hạn
+	Concurrency *worker.Semaphore
+	// PartitionRouter bỏ qua claim của partition không thuộc CONSUMER_PARTITION_FILTER, nil là xử lý mọi partition
+	PartitionRouter *consumer.PartitionRouter
+	// Flags bật tắt kiểm tra chữ ký (hmac_signing) và DLQ (dlq) lúc đang chạy, nil là bật hết
+	Flags flags.FlagStore
+	// CommitInterval và CommitBatch quyết định khi nào offset đã mark được commit
+	// (CONSUMER_COMMIT_INTERVAL, CONSUMER_COMMIT_BATCH), xem offsetCommitter
+	CommitInterval time.Duration
+	CommitBatch    int
+	// committers là offsetCommitter của từng session đang chạy. Consumer và RetryConsumer dùng chung
+	// struct nhưng thuộc hai group khác nhau nên mỗi session phải có committer riêng
+	committers sync.Map
+	// ErrorRate nhận kết quả xử lý từng message để alert khi tỉ lệ lỗi cao, nil là không theo dõi
+	ErrorRate *alerting.ErrorRateMonitor
 }
 
-func (ns *NotificationStore) Add(userID string, notification models.Notification) {
-	ns.mu.Lock()
-	defer ns.mu.Unlock()
-	ns.data[userID] = append(ns.data[userID], notification)
+// recordResult báo kết quả xử lý message cho ErrorRate, err khác nil là message thất bại.
+func (consumer *Consumer) recordResult(err error) {
+	if consumer.ErrorRate != nil {
+		consumer.ErrorRate.Record(err)
+	}
 }
 
-func (ns *NotificationStore) Get(userID string) []models.Notification {
-	ns.mu.RLock()
-	defer ns.mu.RUnlock()
-	return ns.data[userID]
+func (consumer *Consumer) enabled(flag string) bool {
+	return consumer.Flags == nil || consumer.Flags.IsEnabled(flag)
 }
 
-// ============== KAFKA RELATED FUNCTIONS ==============
-type Consumer struct {
-	store *NotificationStore
+// Setup bắt đầu commit định kỳ cho session mới.
+func (consumer *Consumer) Setup(session sarama.ConsumerGroupSession) error {
+	consumer.committers.Store(session, newOffsetCommitter(session, consumer.CommitInterval, consumer.CommitBatch))
+	return nil
+}
+
+// Cleanup chạy khi session kết thúc (rebalance hoặc shutdown),
+// commit các offset đã mark còn sót để không bị đọc lại message.
+func (consumer *Consumer) Cleanup(session sarama.ConsumerGroupSession) error {
+	if committer, ok := consumer.committers.LoadAndDelete(session); ok {
+		committer.(*offsetCommitter).stop()
+		return nil
+	}
+	session.Commit()
+	return nil
 }
 
-func (*Consumer) Setup(sarama.ConsumerGroupSession) error   { return nil }
-func (*Consumer) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+// ack trả về hàm mark offset của session, commit do offsetCommitter của session đảm nhiệm.
+func (consumer *Consumer) ack(session sarama.ConsumerGroupSession) func(*sarama.ConsumerMessage) {
+	committer, ok := consumer.committers.Load(session)
+	if !ok {
+		// Setup chưa chạy (không xảy ra với sarama), vẫn mark để Cleanup commit
+		return func(msg *sarama.ConsumerMessage) { session.MarkMessage(msg, "") }
+	}
+	return committer.(*offsetCommitter).mark
+}
 
+// ConsumeClaim xử lý tuần tự khi Workers <= 1, ngược lại dùng worker.WorkerPool riêng cho partition:
+// message được xử lý song song nhưng offset vẫn chỉ được mark theo thứ tự.
+//
+// sarama đã gọi ConsumeClaim trên một goroutine riêng cho mỗi partition được gán. Mỗi message
+// giữ một chỗ trong Concurrency khi đang xử lý và trả lại ngay khi xong, nên số claim lớn hơn
+// CONSUMER_MAX_GOROUTINES (nhiều topic priority, routing, tenant) vẫn được xử lý xen kẽ nhau.
 func (consumer *Consumer) ConsumeClaim(
 	session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	if consumer.skipClaim(claim) {
+		<-session.Context().Done()
+		return nil
+	}
+	ack := consumer.ack(session)
+	var err error
+	if consumer.Workers > 1 {
+		pool := worker.WorkerPool{Size: consumer.Workers}
+		err = pool.Run(session.Context(), claim.Messages(), func(msg *sarama.ConsumerMessage) error {
+			return consumer.withSlot(session.Context(), func() error {
+				return consumer.processMessage(session, msg, noAck)
+			})
+		}, ack)
+	} else {
+		for msg := range claim.Messages() {
+			err = consumer.withSlot(session.Context(), func() error {
+				return consumer.processMessage(session, msg, ack)
+			})
+			if err != nil {
+				break
+			}
+		}
+	}
+	if errors.Is(err, errSessionDone) {
+		// rebalance hoặc shutdown khi đang chờ chỗ: message chưa mark sẽ được đọc lại ở session sau
+		return nil
+	}
+	return err
+}
+
+// errSessionDone là lỗi của withSlot khi session kết thúc trước khi có chỗ trống
+var errSessionDone = errors.New("consumer session ended while waiting for a processing slot")
+
+// withSlot chạy process khi giữ một chỗ trong Concurrency, chỗ được trả bằng defer nên
+// không bị mất kể cả khi process panic.
+func (consumer *Consumer) withSlot(ctx context.Context, process func() error) error {
+	if consumer.Concurrency == nil {
+		return process()
+	}
+	if err := consumer.Concurrency.Acquire(ctx); err != nil {
+		return errSessionDone
+	}
+	defer consumer.Concurrency.Release()
+	return process()
+}
+
+// skipClaim trả về true nếu partition của claim không thuộc PartitionRouter. PartitionFilterStrategy không gán
+// partition như vậy, chỉ xảy ra khi group còn member bản cũ nên coordinator chọn range.
+// ConsumeClaim khi đó chờ session kết thúc mà không đọc claim: trả về sớm làm sarama huỷ cả session
+// của member và rebalance liên tục. Offset của partition không được mark.
+func (consumer *Consumer) skipClaim(claim sarama.ConsumerGroupClaim) bool {
+	if consumer.PartitionRouter.ShouldProcess(claim.Partition()) {
+		return false
+	}
+	log.Warn().Str("topic", claim.Topic()).Int32("partition", claim.Partition()).
+		Msg("partition is not in CONSUMER_PARTITION_FILTER, skipping claim")
+	return true
+}
+
+// noAck dùng khi WorkerPool tự mark offset sau khi processMessage trả về nil.
+func noAck(*sarama.ConsumerMessage) {}
+
+// processMessage giải mã và xử lý một message, gọi ack khi message đã xong (xử lý thành công,
+// bỏ qua hoặc đã chuyển sang retry/DLQ). Lỗi trả về sẽ kết thúc session hiện tại.
+func (consumer *Consumer) processMessage(session sarama.ConsumerGroupSession,
+	msg *sarama.ConsumerMessage, ack func(*sarama.ConsumerMessage)) error {
+	ctx, span := tracing.StartConsumerSpan(session.Context(), msg)
+	defer span.End()
+
+	msgLog := log.With().
+		Str("topic", msg.Topic).
+		Int32("partition", msg.Partition).
+		Int64("offset", msg.Offset).
+		Str("correlationID", kafka.HeaderValue(msg.Headers, kafka.HeaderCorrelationID)).
+		Str("tenantID", kafka.HeaderValue(msg.Headers, tenant.HeaderTenantID)).
+		Logger()
+
+	// message từ retry topic đã chờ backoff, không tính vào độ trễ end-to-end
+	if producedAt, ok := kafka.ProducerTimestamp(msg.Headers); ok && retry.Count(msg.Headers) == 0 {
+		metrics.ObserveE2ELatency(producedAt)
+	}
+
+	if kafka.IsExpired(msg.Headers, time.Now()) {
+		metrics.MessagesExpired.Inc()
+		msgLog.Info().Str("deadline", kafka.HeaderValue(msg.Headers, kafka.HeaderMessageTTL)).
+			Msg("notification expired, skipping")
+		ack(msg)
+		return nil
+	}
+
+	if len(consumer.SigningKey) > 0 && consumer.enabled(flags.HMACSigning) && !verifySignature(consumer.SigningKey, msg) {
+		err := errors.New(signing.ReasonSignatureMismatch)
+		tracing.RecordError(span, err)
+		msgLog.Error().Msg("notification signature mismatch")
+		consumer.recordResult(err)
+		return consumer.deadLetter(msg, signing.ReasonSignatureMismatch, ack)
+	}
+
+	notification, tombstone, err := unmarshalNotification(msg)
+	if err != nil {
+		tracing.RecordError(span, err)
+		msgLog.Error().Err(err).Msg("failed to unmarshal notification")
+		consumer.recordResult(err)
+		return consumer.deadLetter(msg, err.Error(), ack)
+	}
+	if tombstone {
+		return consumer.processTombstone(tenant.WithID(ctx, kafka.HeaderValue(msg.Headers, tenant.HeaderTenantID)),
+			msg, msgLog, ack)
+	}
+	// header quyết định topic DLQ/retry và store của tenant nào được dùng, nên phải khớp với payload
+	tenantID := kafka.HeaderValue(msg.Headers, tenant.HeaderTenantID)
+	if notification.To.TenantID != tenantID {
+		err := errors.New(tenant.ReasonTenantMismatch)
+		tracing.RecordError(span, err)
+		msgLog.Error().Str("payloadTenantID", notification.To.TenantID).Msg("notification tenant mismatch")
+		consumer.recordResult(err)
+		return consumer.deadLetter(msg, tenant.ReasonTenantMismatch, ack)
+	}
+	ctx = tenant.WithID(ctx, tenantID)
+	if err := consumer.handler(ctx, notification); err != nil {
+		tracing.RecordError(span, err)
+		msgLog.Error().Err(err).Int("retryCount", retry.Count(msg.Headers)).Msg("failed to handle notification")
+		consumer.recordResult(err)
+		if err := consumer.retryOrDeadLetter(msg, err, ack); err != nil {
+			tracing.RecordError(span, err)
+			return err
+		}
+		return nil
+	}
+	ack(msg)
+	consumer.recordResult(nil)
+	msgLog.Info().
+		Int("fromID", notification.From.ID).
+		Int("toID", notification.To.ID).
+		Msg("notification processed")
+	return nil
+}
+
+// processTombstone xoá notification có ID là key của message.
+// Notification không còn trong store (đã xoá trước đó) vẫn được coi là thành công.
+func (consumer *Consumer) processTombstone(ctx context.Context, msg *sarama.ConsumerMessage,
+	msgLog zerolog.Logger, ack func(*sarama.ConsumerMessage)) error {
+	id := string(msg.Key)
+	if consumer.deleter != nil && id != "" {
+		err := consumer.deleter(ctx, id)
+		if err != nil && !errors.Is(err, store.ErrNotificationNotFound) {
+			msgLog.Error().Err(err).Str("notificationID", id).Msg("failed to delete notification")
+			return consumer.retryOrDeadLetter(msg, err, ack)
+		}
+	}
+	ack(msg)
+	msgLog.Info().Str("notificationID", id).Msg("notification deleted")
+	return nil
+}
+
+// deadLetter chuyển message không thể xử lý (sai chữ ký, không giải mã được) sang DLQ.
+// Không có DLQ hoặc flag dlq đang tắt thì chỉ log rồi bỏ qua.
+func (consumer *Consumer) deadLetter(msg *sarama.ConsumerMessage, reason string,
+	ack func(*sarama.ConsumerMessage)) error {
+	if consumer.DLQProducer == nil || !consumer.enabled(flags.DLQ) {
+		return nil
+	}
+	// không mark offset khi chưa đẩy được sang DLQ, message sẽ được đọc lại ở session sau
+	if err := consumer.DLQProducer.Send(msg, reason); err != nil {
+		return err
+	}
+	ack(msg)
+	return nil
+}
+
+// verifySignature kiểm tra header X-Signature với HMAC của payload.
+func verifySignature(key []byte, msg *sarama.ConsumerMessage) bool {
+	sig, err := signing.DecodeHeader(kafka.HeaderValue(msg.Headers, signing.HeaderSignature))
+	if err != nil || len(sig) == 0 {
+		return false
+	}
+	return signing.Verify(key, msg.Value, sig)
+}
+
+// retryOrDeadLetter chuyển message lỗi sang retry topic kế tiếp, hết lượt retry thì sang DLQ.
+// Chỉ mark offset khi đã chuyển message đi thành công.
+func (consumer *Consumer) retryOrDeadLetter(msg *sarama.ConsumerMessage, cause error,
+	ack func(*sarama.ConsumerMessage)) error {
+	if consumer.RetryProducer == nil {
+		return cause
+	}
+	if consumer.RetryProducer.ShouldRetry(msg) {
+		if err := consumer.RetryProducer.Send(msg); err != nil {
+			return err
+		}
+		ack(msg)
+		return nil
+	}
+	if consumer.DLQProducer == nil || !consumer.enabled(flags.DLQ) {
+		return cause
+	}
+	if err := consumer.DLQProducer.Send(msg, cause.Error()); err != nil {
+		return err
+	}
+	ack(msg)
+	return nil
+}
+
+// RetryConsumer đọc các retry topic, chờ tới X-Retry-After rồi xử lý lại
+// message như Consumer. Mỗi retry topic có backoff cố định nên message
+// trong một partition luôn có X-Retry-After tăng dần.
+// RetryConsumer chỉ giữ chỗ trong Concurrency khi xử lý, không giữ trong lúc chờ X-Retry-After,
+// nếu không message đang chờ sẽ chặn partition của topic chính.
+type RetryConsumer struct {
+	*Consumer
+}
+
+func (consumer *RetryConsumer) ConsumeClaim(
+	session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	if consumer.skipClaim(claim) {
+		<-session.Context().Done()
+		return nil
+	}
+	ack := consumer.ack(session)
 	for msg := range claim.Messages() {
-		userID := string(msg.Key)
-		var notification models.Notification
-		err := json.Unmarshal(msg.Value, &notification)
+		if wait := time.Until(retry.ReadyAt(msg.Headers)); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-session.Context().Done():
+				// rebalance hoặc shutdown: message chưa mark sẽ được đọc lại ở session sau
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+		}
+		err := consumer.withSlot(session.Context(), func() error {
+			return consumer.processMessage(session, msg, ack)
+		})
+		if errors.Is(err, errSessionDone) {
+			return nil
+		}
 		if err != nil {
-			log.Printf("failed to unmarshal notification: %v", err)
-			continue
+			return err
 		}
-		consumer.store.Add(userID, notification)
-		session.MarkMessage(msg, "")
 	}
 	return nil
 }
 
-func initializeConsumerGroup() (sarama.ConsumerGroup, error) {
-	config := sarama.NewConfig()
-
-	consumerGroup, err := sarama.NewConsumerGroup(
-		[]string{KafkaServerAddress}, ConsumerGroup, config)
+// unmarshalNotification chọn codec theo header Content-Type mà producer gắn vào message
+// và gắn lại Metadata từ các header X-Meta-<key>, ExpiresAt từ X-Message-TTL. Message có Value nil là tombstone:
+// trả về models.TombstoneNotification và true.
+func unmarshalNotification(msg *sarama.ConsumerMessage) (models.Notification, bool, error) {
+	if msg.Value == nil {
+		return models.TombstoneNotification, true, nil
+	}
+	notificationCodec, err := codec.ForContentType(kafka.HeaderValue(msg.Headers, codec.HeaderContentType))
+	if err != nil {
+		return models.Notification{}, false, err
+	}
+	notification, err := notificationCodec.Unmarshal(msg.Value)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize consumer group: %w", err)
+		return models.Notification{}, false, &apperrors.ErrSerialisationFailed{Codec: notificationCodec.ContentType(), Cause: err}
+	}
+	notification.Metadata = kafka.Metadata(msg.Headers)
+	notification.ExpiresAt, _ = kafka.Deadline(msg.Headers)
+	return notification, false, nil
+}
+
+// newConsumerConfig tắt auto commit, offset chỉ được mark sau khi message xử lý thành công
+// và được offsetCommitter commit theo CONSUMER_COMMIT_INTERVAL/CONSUMER_COMMIT_BATCH.
+func newConsumerConfig(cfg *config.Config) (*sarama.Config, error) {
+	if err := validateGroupTimeouts(cfg.SessionTimeout, cfg.HeartbeatInterval); err != nil {
+		return nil, err
+	}
+	initialOffset := sarama.OffsetNewest
+	if cfg.ConsumerOffsetStrategy == config.OffsetStrategyOldest {
+		initialOffset = sarama.OffsetOldest
 	}
 
-	return consumerGroup, nil
+	config := sarama.NewConfig()
+	config.Consumer.Offsets.AutoCommit.Enable = false
+	config.Consumer.Offsets.Initial = initialOffset
+	// chỉ đọc message của transaction đã commit (xem KAFKA_TRANSACTIONAL_ID ở producer)
+	config.Consumer.IsolationLevel = sarama.ReadCommitted
+	config.Consumer.Group.Session.Timeout = cfg.SessionTimeout
+	config.Consumer.Group.Heartbeat.Interval = cfg.HeartbeatInterval
+	// partition-filter chia partition theo CONSUMER_PARTITION_FILTER của từng member,
+	// range để vẫn join được group còn member bản cũ chỉ hỗ trợ range
+	config.Consumer.Group.Member.UserData = consumer.NewPartitionRouter(cfg.ConsumerPartitions).UserData()
+	config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{
+		consumer.NewPartitionFilterStrategy(), sarama.NewBalanceStrategyRange(),
+	}
+	if err := kafka.ApplySecurity(config, cfg); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// validateGroupTimeouts theo khuyến nghị của Kafka: heartbeat phải nhỏ hơn một phần ba session timeout
+// để consumer còn ít nhất ba lần heartbeat trước khi bị coi là chết và group rebalance.
+func validateGroupTimeouts(session, heartbeat time.Duration) error {
+	if session <= 0 || heartbeat <= 0 {
+		return fmt.Errorf("%w: KAFKA_SESSION_TIMEOUT and KAFKA_HEARTBEAT_INTERVAL must be positive", ErrInvalidConsumerConfig)
+	}
+	if heartbeat*3 >= session {
+		return fmt.Errorf("%w: KAFKA_HEARTBEAT_INTERVAL (%s) must be less than one third of KAFKA_SESSION_TIMEOUT (%s)",
+			ErrInvalidConsumerConfig, heartbeat, session)
+	}
+	return nil
 }
 
-func setupConsumerGroup(ctx context.Context, store *NotificationStore) {
-	consumerGroup, err := initializeConsumerGroup()
+func setupConsumer(cfg *config.Config, groupID string) (sarama.ConsumerGroup, error) {
+	config, err := newConsumerConfig(cfg)
 	if err != nil {
-		log.Printf("initialization error: %v", err)
+		return nil, fmt.Errorf("failed to setup consumer group: %w", err)
 	}
-	defer consumerGroup.Close()
 
-	consumer := &Consumer{
-		store: store,
+	consumerGroup, err := kafka.SetupConsumerGroupWithRetry(cfg.KafkaBrokers, groupID, config,
+		cfg.KafkaConnectAttempts, cfg.KafkaConnectBackoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup consumer group: %w", err)
 	}
 
+	return consumerGroup, nil
+}
+
+// Consume trả về mỗi khi có rebalance, nên phải gọi lại trong vòng lặp
+// cho đến khi ctx bị cancel.
+func runConsumerGroup(ctx context.Context, consumerGroup sarama.ConsumerGroup,
+	topics []string, consumer sarama.ConsumerGroupHandler) {
 	for {
-		err = consumerGroup.Consume(ctx, []string{ConsumerTopic}, consumer)
+		err := consumerGroup.Consume(ctx, topics, consumer)
 		if err != nil {
-			log.Printf("error from consumer: %v", err)
+			log.Error().Err(err).Strs("topics", topics).Msg("error from consumer")
 		}
 		if ctx.Err() != nil {
 			return
@@ -115,14 +470,22 @@ func setupConsumerGroup(ctx context.Context, store *NotificationStore) {
 	}
 }
 
-func handleNotifications(ctx *gin.Context, store *NotificationStore) {
+func handleNotifications(ctx *gin.Context, notifications store.NotificationStore) {
 	userID, err := getUserIDFromRequest(ctx)
-	if err != nil {
+	if errors.Is(err, ErrNoMessagesFound) {
 		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
 		return
 	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
 
-	notes := store.Get(userID)
+	notes, err := notifications.FindByUserID(ctx.Request.Context(), userID, store.NotificationFilter{})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
 	if len(notes) == 0 {
 		ctx.JSON(http.StatusOK,
 			gin.H{
@@ -135,25 +498,73 @@ func handleNotifications(ctx *gin.Context, store *NotificationStore) {
 	ctx.JSON(http.StatusOK, gin.H{"notifications": notes})
 }
 
-func main() {
-	store := &NotificationStore{
-		data: make(UserNotifications),
+// threadNotificationsHandler xử lý GET /notifications/threads/:threadID, trả về mọi notification
+// của thread theo thời gian tạo. Notification của tenant khác bị loại vì thread ID do client đặt.
+func threadNotificationsHandler(notifications store.NotificationStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		threadID := ctx.Param("threadID")
+		thread, err := notifications.FindByThreadID(ctx.Request.Context(), threadID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		tenantID := middleware.TenantID(ctx)
+		visible := make([]models.Notification, 0, len(thread))
+		for _, n := range thread {
+			if n.To.TenantID == tenantID {
+				visible = append(visible, n)
+			}
+		}
+		ctx.JSON(http.StatusOK, gin.H{"threadID": threadID, "notifications": visible})
 	}
+}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	go setupConsumerGroup(ctx, store)
-	defer cancel()
+// listNotificationsHandler xử lý GET /notifications?userID=1&limit=20&offset=0&sortBy=priority&sortDir=desc,
+// query phân trang đã được middleware.ValidatePaginationParams kiểm tra. unread=true chỉ trả về
+// notification chưa được ack, mỗi notification kèm field acknowledged.
+func listNotificationsHandler(notifications store.NotificationStore, acks ack.AckStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, err := strconv.Atoi(ctx.Query("userID"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "userID query parameter must be a number"})
+			return
+		}
+		unread := false
+		if value := ctx.Query("unread"); value != "" {
+			if unread, err = strconv.ParseBool(value); err != nil {
+				ctx.JSON(http.StatusUnprocessableEntity, gin.H{"message": "unread must be true or false"})
+				return
+			}
+		}
+		filter := middleware.PaginationFilter(ctx)
 
-	gin.SetMode(gin.ReleaseMode)
-	router := gin.Default()
-	router.GET("/notifications/:userID", func(ctx *gin.Context) {
-		handleNotifications(ctx, store)
-	})
+		acked, err := acks.AckedIDs(ctx.Request.Context(), userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		if unread {
+			filter.ExcludeIDs = acked
+		}
 
-	fmt.Printf("Kafka CONSUMER (Group: %s) 👥📥 "+
-		"started at http://localhost%s\n", ConsumerGroup, ConsumerPort)
+		notes, err := notifications.FindByUserID(ctx.Request.Context(), userID, filter)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		total, err := notifications.CountByUserID(ctx.Request.Context(), userID, filter)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
 
-	if err := router.Run(ConsumerPort); err != nil {
-		log.Printf("failed to run the server: %v", err)
+		ctx.JSON(http.StatusOK, gin.H{
+			"notifications": withAcknowledged(notes, acked),
+			"total":         total,
+			"limit":         filter.Limit,
+			"offset":        filter.Offset,
+			"sortBy":        filter.SortBy,
+			"sortDir":       filter.SortDir,
+		})
 	}
 }
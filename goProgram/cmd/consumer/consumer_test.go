@@ -0,0 +1,460 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/dlq"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/metrics"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/worker"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+)
+
+const testTopic = "notifications.normal"
+
+// fakeSession là sarama.ConsumerGroupSession ghi lại offset đã mark và số lần commit
+type fakeSession struct {
+	ctx context.Context
+
+	mu        sync.Mutex
+	marked    map[int32]int64
+	committed map[int32]int64
+	commits   int
+}
+
+func newFakeSession(ctx context.Context) *fakeSession {
+	return &fakeSession{ctx: ctx, marked: make(map[int32]int64), committed: make(map[int32]int64)}
+}
+
+func (s *fakeSession) Claims() map[string][]int32 { return nil }
+
+func (s *fakeSession) MemberID() string { return "test-member" }
+
+func (s *fakeSession) GenerationID() int32 { return 1 }
+
+func (s *fakeSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if offset > s.marked[partition] {
+		s.marked[partition] = offset
+	}
+}
+
+func (s *fakeSession) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commits++
+	for partition, offset := range s.marked {
+		s.committed[partition] = offset
+	}
+}
+
+func (s *fakeSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+// committedOffset là offset đã commit của partition, 0 nếu chưa commit
+func (s *fakeSession) committedOffset(partition int32) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.committed[partition]
+}
+
+func (s *fakeSession) commitCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commits
+}
+
+// fakeClaim là claim của một partition, đóng messages để ConsumeClaim trả về
+type fakeClaim struct {
+	partition int32
+	messages  chan *sarama.ConsumerMessage
+}
+
+func newFakeClaim(partition int32, msgs ...*sarama.ConsumerMessage) *fakeClaim {
+	claim := &fakeClaim{partition: partition, messages: make(chan *sarama.ConsumerMessage, len(msgs))}
+	for _, msg := range msgs {
+		claim.messages <- msg
+	}
+	close(claim.messages)
+	return claim
+}
+
+func (c *fakeClaim) Topic() string { return testTopic }
+
+func (c *fakeClaim) Partition() int32 { return c.partition }
+
+func (c *fakeClaim) InitialOffset() int64 { return sarama.OffsetOldest }
+
+func (c *fakeClaim) HighWaterMarkOffset() int64 { return 0 }
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// newConsumerMessage encode notification như producer rồi đặt vào partition/offset cho trước
+func newConsumerMessage(t *testing.T, partition int32, offset int64, message string) *sarama.ConsumerMessage {
+	t.Helper()
+	notification := models.Notification{
+		ID:       "notification-" + strconv.Itoa(int(partition)) + "-" + strconv.FormatInt(offset, 10),
+		From:     models.User{ID: 1, Name: "Alice"},
+		To:       models.User{ID: 2, Name: "Bob"},
+		Message:  message,
+		Priority: models.PriorityNormal,
+	}
+	produced, err := sender.NewMessage(context.Background(), sender.Options{Codec: codec.JSONCodec{}}, notification)
+	if err != nil {
+		t.Fatalf("NewMessage() error = %v", err)
+	}
+	return consumedMessage(t, produced, partition, offset)
+}
+
+// consumedMessage chuyển message producer đã gửi thành message consumer nhận được ở partition/offset cho trước
+func consumedMessage(t *testing.T, produced *sarama.ProducerMessage, partition int32, offset int64) *sarama.ConsumerMessage {
+	t.Helper()
+	value, err := produced.Value.Encode()
+	if err != nil {
+		t.Fatalf("failed to encode value: %v", err)
+	}
+	headers := make([]*sarama.RecordHeader, len(produced.Headers))
+	for i := range produced.Headers {
+		headers[i] = &produced.Headers[i]
+	}
+	return &sarama.ConsumerMessage{
+		Topic: testTopic, Partition: partition, Offset: offset, Value: value, Headers: headers,
+		Timestamp: time.Now(),
+	}
+}
+
+// consumeClaims chạy ConsumeClaim của mỗi claim trên goroutine riêng như sarama rồi chạy Cleanup
+func consumeClaims(t *testing.T, consumer *Consumer, session *fakeSession, claims ...*fakeClaim) {
+	t.Helper()
+	if err := consumer.Setup(session); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	var wg sync.WaitGroup
+	for _, claim := range claims {
+		claim := claim
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := consumer.ConsumeClaim(session, claim); err != nil {
+				t.Errorf("ConsumeClaim(partition %d) error = %v", claim.partition, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := consumer.Cleanup(session); err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+}
+
+func TestConsumeClaimProcessesPartitionsConcurrently(t *testing.T) {
+	const partitions = 3
+	// handler của mỗi partition chờ cả 3 partition cùng đang xử lý, xử lý tuần tự thì hết hạn chờ
+	var started sync.WaitGroup
+	started.Add(partitions)
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+	var concurrent atomic.Bool
+	consumer := &Consumer{
+		handler: func(ctx context.Context, notification models.Notification) error {
+			started.Done()
+			select {
+			case <-allStarted:
+				concurrent.Store(true)
+			case <-time.After(time.Second):
+			}
+			return nil
+		},
+		Concurrency:    worker.NewSemaphore(partitions),
+		CommitInterval: time.Hour,
+		CommitBatch:    100,
+	}
+
+	session := newFakeSession(context.Background())
+	claims := make([]*fakeClaim, partitions)
+	for partition := range claims {
+		claims[partition] = newFakeClaim(int32(partition), newConsumerMessage(t, int32(partition), 0, "hello"))
+	}
+	consumeClaims(t, consumer, session, claims...)
+
+	if !concurrent.Load() {
+		t.Fatal("claims of 3 partitions were not processed concurrently")
+	}
+	for partition := int32(0); partition < partitions; partition++ {
+		if got := session.committedOffset(partition); got != 1 {
+			t.Errorf("partition %d committed offset = %d, want 1", partition, got)
+		}
+	}
+}
+
+// Concurrency nhỏ hơn số partition thì các partition được xử lý xen kẽ, không claim nào bị chặn cả session.
+func TestConsumeClaimSharesSlotsAcrossPartitions(t *testing.T) {
+	const partitions, perPartition = 3, 20
+	var inFlight, maxInFlight, processed atomic.Int32
+	consumer := &Consumer{
+		handler: func(ctx context.Context, notification models.Notification) error {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				highest := maxInFlight.Load()
+				if current <= highest || maxInFlight.CompareAndSwap(highest, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			processed.Add(1)
+			return nil
+		},
+		Concurrency:    worker.NewSemaphore(1),
+		CommitInterval: time.Hour,
+		CommitBatch:    100,
+	}
+
+	session := newFakeSession(context.Background())
+	claims := make([]*fakeClaim, partitions)
+	for partition := range claims {
+		msgs := make([]*sarama.ConsumerMessage, perPartition)
+		for offset := range msgs {
+			msgs[offset] = newConsumerMessage(t, int32(partition), int64(offset), "hello")
+		}
+		claims[partition] = newFakeClaim(int32(partition), msgs...)
+	}
+	consumeClaims(t, consumer, session, claims...)
+
+	if got := processed.Load(); got != partitions*perPartition {
+		t.Fatalf("processed %d messages, want %d", got, partitions*perPartition)
+	}
+	if got := maxInFlight.Load(); got != 1 {
+		t.Fatalf("max in-flight messages = %d, want 1 (CONSUMER_MAX_GOROUTINES)", got)
+	}
+}
+
+func TestConsumeClaimRecordsE2ELatency(t *testing.T) {
+	before := metrics.E2ELatencySummary().Count
+	consumer := &Consumer{
+		handler:        func(ctx context.Context, notification models.Notification) error { return nil },
+		Concurrency:    worker.NewSemaphore(1),
+		CommitInterval: time.Hour,
+		CommitBatch:    100,
+	}
+	// message được tạo như producer gửi, có header X-Producer-Timestamp
+	msg := newConsumerMessage(t, 0, 0, "hello")
+	if _, ok := kafka.ProducerTimestamp(msg.Headers); !ok {
+		t.Fatal("produced message has no X-Producer-Timestamp header")
+	}
+	consumeClaims(t, consumer, newFakeSession(context.Background()), newFakeClaim(0, msg))
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	metricsLatencyHandler(ctx)
+	var summary metrics.LatencySummary
+	if err := json.Unmarshal(recorder.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("GET /metrics/latency is not JSON: %v", err)
+	}
+	if summary.Count != before+1 {
+		t.Fatalf("latency samples = %d, want %d", summary.Count, before+1)
+	}
+	if summary.P50 <= 0 || summary.P99 <= 0 {
+		t.Fatalf("e2e latency = %+v, want > 0", summary)
+	}
+}
+
+func TestValidateGroupTimeouts(t *testing.T) {
+	tests := []struct {
+		name               string
+		session, heartbeat time.Duration
+		wantErr            bool
+	}{
+		{name: "sarama defaults", session: 10 * time.Second, heartbeat: 3 * time.Second},
+		{name: "just under one third", session: 9 * time.Second, heartbeat: 3*time.Second - time.Millisecond},
+		{name: "exactly one third", session: 9 * time.Second, heartbeat: 3 * time.Second, wantErr: true},
+		{name: "over one third", session: 9 * time.Second, heartbeat: 4 * time.Second, wantErr: true},
+		{name: "zero session", session: 0, heartbeat: time.Second, wantErr: true},
+		{name: "zero heartbeat", session: 10 * time.Second, heartbeat: 0, wantErr: true},
+		{name: "negative heartbeat", session: 10 * time.Second, heartbeat: -time.Second, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGroupTimeouts(tt.session, tt.heartbeat)
+			if tt.wantErr != errors.Is(err, ErrInvalidConsumerConfig) {
+				t.Fatalf("validateGroupTimeouts(%s, %s) error = %v, want error %v", tt.session, tt.heartbeat, err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("validateGroupTimeouts(%s, %s) error = %v", tt.session, tt.heartbeat, err)
+			}
+		})
+	}
+}
+
+func TestNewConsumerConfigGroupTimeouts(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	cfg.SessionTimeout, cfg.HeartbeatInterval = 30*time.Second, 5*time.Second
+	consumerConfig, err := newConsumerConfig(cfg)
+	if err != nil {
+		t.Fatalf("newConsumerConfig() error = %v", err)
+	}
+	if consumerConfig.Consumer.Group.Session.Timeout != 30*time.Second ||
+		consumerConfig.Consumer.Group.Heartbeat.Interval != 5*time.Second {
+		t.Fatalf("session/heartbeat = %s/%s, want 30s/5s",
+			consumerConfig.Consumer.Group.Session.Timeout, consumerConfig.Consumer.Group.Heartbeat.Interval)
+	}
+
+	cfg.HeartbeatInterval = 10 * time.Second
+	if _, err := newConsumerConfig(cfg); !errors.Is(err, ErrInvalidConsumerConfig) {
+		t.Fatalf("newConsumerConfig() error = %v, want %v", err, ErrInvalidConsumerConfig)
+	}
+}
+
+// message không giải mã được được chuyển sang DLQ topic rồi mới mark offset, message tốt vẫn được xử lý
+func TestConsumeClaimSendsBadMessageToDLQ(t *testing.T) {
+	dlqProducer := mock.NewSyncProducer()
+	var processed atomic.Int32
+	consumer := &Consumer{
+		handler: func(ctx context.Context, notification models.Notification) error {
+			processed.Add(1)
+			return nil
+		},
+		DLQProducer:    dlq.NewProducer(dlqProducer, "notifications.dlq"),
+		Concurrency:    worker.NewSemaphore(1),
+		CommitInterval: time.Hour,
+		CommitBatch:    100,
+	}
+	bad := newConsumerMessage(t, 0, 0, "hello")
+	bad.Value = []byte("{not json")
+	session := newFakeSession(context.Background())
+	consumeClaims(t, consumer, session, newFakeClaim(0, bad, newConsumerMessage(t, 0, 1, "hello")))
+
+	messages := dlqProducer.Messages()
+	if len(messages) != 1 || messages[0].Topic != "notifications.dlq" {
+		t.Fatalf("DLQ messages = %v, want one in notifications.dlq", messages)
+	}
+	if value, _ := messages[0].Value.Encode(); string(value) != "{not json" {
+		t.Fatalf("DLQ value = %q, want the raw message", value)
+	}
+	if processed.Load() != 1 {
+		t.Fatalf("processed %d messages, want 1", processed.Load())
+	}
+	if got := session.committedOffset(0); got != 2 {
+		t.Fatalf("committed offset = %d, want 2", got)
+	}
+}
+
+// correlation ID producer gắn vào header được ghi ở log của mọi message consumer xử lý
+func TestConsumeClaimLogsCorrelationID(t *testing.T) {
+	var output bytes.Buffer
+	previous := log
+	log = zerolog.New(&output)
+	t.Cleanup(func() { log = previous })
+
+	const correlationID = "3c6e0b8a-9c0b-4f5c-8c1d-2f6a7d9e0b1c"
+	notification := models.Notification{
+		ID:       "5f0c7c3e-8f9a-4a57-9d51-3b8a2f8d9e01",
+		From:     models.User{ID: 1, Name: "Alice"},
+		To:       models.User{ID: 2, Name: "Bob"},
+		Message:  "hello",
+		Priority: models.PriorityNormal,
+	}
+	produced, err := sender.NewMessage(context.Background(), sender.Options{Codec: codec.JSONCodec{}}, notification,
+		kafka.Header(kafka.HeaderCorrelationID, correlationID))
+	if err != nil {
+		t.Fatalf("NewMessage() error = %v", err)
+	}
+	consumer := &Consumer{
+		handler:        func(ctx context.Context, notification models.Notification) error { return nil },
+		Concurrency:    worker.NewSemaphore(1),
+		CommitInterval: time.Hour,
+		CommitBatch:    100,
+	}
+	consumeClaims(t, consumer, newFakeSession(context.Background()),
+		newFakeClaim(0, consumedMessage(t, produced, 0, 0)))
+
+	var entry map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(output.Bytes()), []byte("\n")) {
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("log line is not JSON: %s", line)
+		}
+		if entry["message"] == "notification processed" {
+			break
+		}
+		entry = nil
+	}
+	if entry == nil {
+		t.Fatalf("no notification processed log in:\n%s", output.String())
+	}
+	if entry["correlationID"] != correlationID {
+		t.Fatalf("logged correlationID = %v, want %s", entry["correlationID"], correlationID)
+	}
+}
+
+// message có TTL 1 giây được đọc sau 2 giây thì bị bỏ qua nhưng vẫn được mark offset
+func TestConsumeClaimSkipsExpiredMessage(t *testing.T) {
+	before := testutil.ToFloat64(metrics.MessagesExpired)
+	var processed []string
+	consumer := &Consumer{
+		handler: func(ctx context.Context, notification models.Notification) error {
+			processed = append(processed, notification.Message)
+			return nil
+		},
+		Concurrency:    worker.NewSemaphore(1),
+		CommitInterval: time.Hour,
+		CommitBatch:    100,
+	}
+	newMessage := func(offset int64, message string, headers ...sarama.RecordHeader) *sarama.ConsumerMessage {
+		produced, err := sender.NewMessage(context.Background(), sender.Options{Codec: codec.JSONCodec{}}, models.Notification{
+			ID:       "notification-" + strconv.FormatInt(offset, 10),
+			From:     models.User{ID: 1, Name: "Alice"},
+			To:       models.User{ID: 2, Name: "Bob"},
+			Message:  message,
+			Priority: models.PriorityNormal,
+		}, headers...)
+		if err != nil {
+			t.Fatalf("NewMessage() error = %v", err)
+		}
+		return consumedMessage(t, produced, 0, offset)
+	}
+	expiring := newMessage(0, "expired", kafka.TTLHeader(time.Now(), time.Second))
+	fresh := newMessage(1, "fresh", kafka.TTLHeader(time.Now(), time.Hour))
+	plain := newMessage(2, "no ttl")
+	time.Sleep(2 * time.Second)
+
+	session := newFakeSession(context.Background())
+	consumeClaims(t, consumer, session, newFakeClaim(0, expiring, fresh, plain))
+
+	if len(processed) != 2 || processed[0] != "fresh" || processed[1] != "no ttl" {
+		t.Fatalf("processed %q, want the fresh and no-TTL messages only", processed)
+	}
+	if got := testutil.ToFloat64(metrics.MessagesExpired) - before; got != 1 {
+		t.Fatalf("kafka_messages_expired_total increased by %v, want 1", got)
+	}
+	if got := session.committedOffset(0); got != 3 {
+		t.Fatalf("committed offset = %d, want 3", got)
+	}
+}
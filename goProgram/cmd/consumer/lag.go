@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/metrics"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+)
+
+// ============== CONSUMER LAG ==============
+
+// PartitionLag là số message còn chưa được commit trên một partition.
+type PartitionLag struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	// CommittedOffset = -1 nghĩa là group chưa commit offset nào trên partition này
+	CommittedOffset int64 `json:"committedOffset"`
+	HighWatermark   int64 `json:"highWatermark"`
+	Lag             int64 `json:"lag"`
+}
+
+// LagReporter so sánh offset đã commit của consumer group với high watermark của từng partition.
+type LagReporter struct {
+	client sarama.Client
+	admin  sarama.ClusterAdmin
+	group  string
+	topics []string
+}
+
+func setupLagReporter(cfg *config.Config) (*LagReporter, error) {
+	config, err := newConsumerConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup lag reporter: %w", err)
+	}
+	client, err := sarama.NewClient(cfg.KafkaBrokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup lag reporter: %w", err)
+	}
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to setup lag reporter: %w", err)
+	}
+	return &LagReporter{
+		client: client,
+		admin:  admin,
+		group:  cfg.ConsumerGroupID,
+		topics: cfg.ConsumerTopics(),
+	}, nil
+}
+
+// Lag trả về lag của mọi partition thuộc các topic mà consumer subscribe.
+// Topic chưa được tạo (chưa có message nào) được bỏ qua.
+func (r *LagReporter) Lag() ([]PartitionLag, error) {
+	partitions := make(map[string][]int32, len(r.topics))
+	for _, topic := range r.topics {
+		ids, err := r.client.Partitions(topic)
+		if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list partitions of %s: %w", topic, err)
+		}
+		partitions[topic] = ids
+	}
+
+	committed, err := r.admin.ListConsumerGroupOffsets(r.group, partitions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consumer group offsets: %w", err)
+	}
+
+	var lags []PartitionLag
+	for _, topic := range r.topics {
+		for _, partition := range partitions[topic] {
+			highWatermark, err := r.client.GetOffset(topic, partition, sarama.OffsetNewest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get high watermark of %s/%d: %w", topic, partition, err)
+			}
+			lag := PartitionLag{Topic: topic, Partition: partition, CommittedOffset: -1, HighWatermark: highWatermark}
+			if block := committed.GetBlock(topic, partition); block != nil && block.Offset >= 0 {
+				lag.CommittedOffset = block.Offset
+				lag.Lag = highWatermark - block.Offset
+			} else {
+				// chưa commit lần nào thì toàn bộ message còn giữ trên partition đều tính là lag
+				oldest, err := r.client.GetOffset(topic, partition, sarama.OffsetOldest)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get oldest offset of %s/%d: %w", topic, partition, err)
+				}
+				lag.Lag = highWatermark - oldest
+			}
+			lags = append(lags, lag)
+		}
+	}
+	return lags, nil
+}
+
+// Close đóng admin, admin tạo từ client nên sẽ đóng luôn client.
+func (r *LagReporter) Close() error {
+	return r.admin.Close()
+}
+
+// recordLagMetrics cập nhật gauge kafka_consumer_group_lag theo kết quả Lag.
+func recordLagMetrics(lags []PartitionLag) {
+	for _, lag := range lags {
+		metrics.ConsumerGroupLag.
+			WithLabelValues(lag.Topic, strconv.Itoa(int(lag.Partition))).
+			Set(float64(lag.Lag))
+	}
+}
+
+// runLagMetrics làm mới gauge lag mỗi interval cho tới khi ctx bị huỷ,
+// để Prometheus scrape /metrics vẫn thấy lag mà không cần gọi /metrics/lag.
+func runLagMetrics(ctx context.Context, reporter *LagReporter, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			lags, err := reporter.Lag()
+			if err != nil {
+				log.Warn().Err(err).Msg("failed to refresh consumer lag metrics")
+				continue
+			}
+			recordLagMetrics(lags)
+		}
+	}
+}
+
+// consumerLagHandler xử lý GET /consumer/lag.
+func consumerLagHandler(reporter *LagReporter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lags, err := reporter.Lag()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		var total int64
+		for _, lag := range lags {
+			total += lag.Lag
+		}
+		if lags == nil {
+			lags = []PartitionLag{}
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"group":      reporter.group,
+			"totalLag":   total,
+			"partitions": lags,
+		})
+	}
+}
+
+type partitionLagSummary struct {
+	Partition int32 `json:"partition"`
+	Lag       int64 `json:"lag"`
+}
+
+type topicLagSummary struct {
+	Topic      string                `json:"topic"`
+	Partitions []partitionLagSummary `json:"partitions"`
+	TotalLag   int64                 `json:"totalLag"`
+}
+
+// metricsLagHandler xử lý GET /metrics/lag: lag gom theo topic, ?topic= để lọc một topic.
+// Mỗi lần gọi cũng cập nhật gauge kafka_consumer_group_lag.
+func metricsLagHandler(reporter *LagReporter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lags, err := reporter.Lag()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		recordLagMetrics(lags)
+
+		filter := ctx.Query("topic")
+		summaries := []topicLagSummary{}
+		index := make(map[string]int)
+		var total int64
+		for _, lag := range lags {
+			if filter != "" && lag.Topic != filter {
+				continue
+			}
+			i, ok := index[lag.Topic]
+			if !ok {
+				i = len(summaries)
+				index[lag.Topic] = i
+				summaries = append(summaries, topicLagSummary{Topic: lag.Topic})
+			}
+			summaries[i].Partitions = append(summaries[i].Partitions,
+				partitionLagSummary{Partition: lag.Partition, Lag: lag.Lag})
+			summaries[i].TotalLag += lag.Lag
+			total += lag.Lag
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"group":    reporter.group,
+			"topics":   summaries,
+			"totalLag": total,
+		})
+	}
+}
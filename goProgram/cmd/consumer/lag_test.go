@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// getLag gọi GET /metrics/lag và trả về phần topic của testTopic
+func getLag(t *testing.T, reporter *LagReporter) topicLagSummary {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/metrics/lag", metricsLagHandler(reporter))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics/lag?topic="+testTopic, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET /metrics/lag status = %d, want 200: %s", recorder.Code, recorder.Body.String())
+	}
+	var body struct {
+		Group    string            `json:"group"`
+		Topics   []topicLagSummary `json:"topics"`
+		TotalLag int64             `json:"totalLag"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, recorder.Body.String())
+	}
+	if body.Group != testGroup || len(body.Topics) != 1 || body.Topics[0].Topic != testTopic {
+		t.Fatalf("GET /metrics/lag = %s, want group %s with topic %s", recorder.Body.String(), testGroup, testTopic)
+	}
+	if body.TotalLag != body.Topics[0].TotalLag {
+		t.Fatalf("totalLag = %d, want %d", body.TotalLag, body.Topics[0].TotalLag)
+	}
+	return body.Topics[0]
+}
+
+// consumer xử lý và commit 5 message rồi dừng, N message tới sau đó là lag
+func TestMetricsLagAfterConsumerStops(t *testing.T) {
+	const pending = 7
+	t.Setenv("KAFKA_CONSUMER_OFFSET_STRATEGY", config.OffsetStrategyOldest)
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	groupConfig, err := newConsumerConfig(cfg)
+	if err != nil {
+		t.Fatalf("newConsumerConfig() error = %v", err)
+	}
+	groupConfig.Consumer.Group.Rebalance.Retry.Backoff = 10 * time.Millisecond
+	broker := newRestartBroker(t, groupConfig.Consumer.Group.Rebalance.GroupStrategies[0].Name())
+
+	broker.produce(t, 0, 5)
+	consumeUntil(t, groupConfig, broker, 5)
+	// broker giả không lưu offset, OffsetFetch trả về offset consumer đã commit khi dừng
+	broker.setCommitted(t, broker.lastCommitted(t))
+	broker.produce(t, 5, 5+pending)
+
+	cfg.KafkaBrokers = []string{broker.Addr()}
+	cfg.ConsumerGroupID = testGroup
+	reporter, err := setupLagReporter(cfg)
+	if err != nil {
+		t.Fatalf("setupLagReporter() error = %v", err)
+	}
+	defer reporter.Close()
+	// broker giả chỉ có testTopic, bỏ các priority topic khác để không chờ refresh metadata
+	reporter.topics = []string{testTopic}
+
+	lag := getLag(t, reporter)
+	if len(lag.Partitions) != 1 || lag.Partitions[0] != (partitionLagSummary{Partition: 0, Lag: pending}) {
+		t.Fatalf("partitions = %+v, want partition 0 with lag %d", lag.Partitions, pending)
+	}
+	if lag.TotalLag != pending {
+		t.Fatalf("topic totalLag = %d, want %d", lag.TotalLag, pending)
+	}
+	if got := testutil.ToFloat64(metrics.ConsumerGroupLag.WithLabelValues(testTopic, "0")); got != pending {
+		t.Fatalf("kafka_consumer_group_lag = %v, want %d", got, pending)
+	}
+}
+
+// group chưa commit lần nào thì mọi message còn trên partition đều là lag
+func TestMetricsLagWithoutCommittedOffset(t *testing.T) {
+	broker := newRestartBroker(t, "range")
+	broker.produce(t, 0, 4)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	cfg.KafkaBrokers = []string{broker.Addr()}
+	cfg.ConsumerGroupID = testGroup
+	reporter, err := setupLagReporter(cfg)
+	if err != nil {
+		t.Fatalf("setupLagReporter() error = %v", err)
+	}
+	defer reporter.Close()
+	reporter.topics = []string{testTopic}
+
+	if lag := getLag(t, reporter); lag.TotalLag != 4 {
+		t.Fatalf("lag = %+v, want 4", lag)
+	}
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"kafka-notify/pkg/metrics"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricsLatencyHandler xử lý GET /metrics/latency: p50/p95/p99 (nano giây) độ trễ end-to-end
+// của các message gần nhất, từ lúc producer tạo message tới lúc consumer nhận.
+func metricsLatencyHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, metrics.E2ELatencySummary())
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/ack"
+	"kafka-notify/pkg/admin"
+	"kafka-notify/pkg/alerting"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/consumer"
+	"kafka-notify/pkg/delivery"
+	"kafka-notify/pkg/dlq"
+	"kafka-notify/pkg/expiry"
+	"kafka-notify/pkg/flags"
+	"kafka-notify/pkg/hub"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/logger"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/openapi"
+	"kafka-notify/pkg/receipt"
+	"kafka-notify/pkg/replay"
+	"kafka-notify/pkg/retry"
+	"kafka-notify/pkg/search"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/tenant"
+	"kafka-notify/pkg/tracing"
+	"kafka-notify/pkg/worker"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+)
+
+// log được khởi tạo trong main sau khi đọc LOG_LEVEL/LOG_FORMAT
+var log zerolog.Logger
+
+// thời gian tối đa chờ các request đang xử lý hoàn thành khi shutdown
+const shutdownTimeout = 10 * time.Second
+
+// chu kỳ làm mới gauge kafka_consumer_group_lag
+const lagMetricsInterval = 30 * time.Second
+
+// setupFlagStore đọc feature flag từ Redis hash FEATURE_FLAGS_REDIS_KEY nếu có, không thì từ biến môi trường
+func setupFlagStore(cfg *config.Config) (flags.FlagStore, func() error, error) {
+	if cfg.FeatureFlagsRedisKey == "" {
+		return flags.EnvFlagStore{}, func() error { return nil }, nil
+	}
+
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	flagStore := flags.NewRedisFlagStore(client, cfg.FeatureFlagsRedisKey, flags.DefaultRefreshInterval)
+	flagStore.OnError = func(err error) {
+		log.Warn().Err(err).Msg("failed to refresh feature flags, keeping previous values")
+	}
+	return flagStore, flagStore.Close, nil
+}
+
+// Nếu không có REDIS_URL thì lưu notification trong bộ nhớ
+func setupNotificationStore(cfg *config.Config) (store.NotificationStore, func() error, error) {
+	if cfg.RedisURL == "" {
+		return store.NewMemoryNotificationStore(), func() error { return nil }, nil
+	}
+
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.NewRedisNotificationStore(client), client.Close, nil
+}
+
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("failed to load config: %v", err))
+	}
+	if err := logger.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		panic(fmt.Sprintf("failed to setup logger: %v", err))
+	}
+	log = logger.Component("consumer")
+
+	tracerProvider, err := tracing.SetupTracer("kafka-notify-consumer")
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize tracer")
+	}
+
+	// tạo topic theo TOPICS_CONFIG_FILE và KAFKA_AUTO_CREATE_TOPICS trước khi producer/consumer group dùng tới
+	changedTopics, err := admin.EnsureConfiguredTopics(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create configured topics")
+	}
+	if len(changedTopics) > 0 {
+		log.Info().Strs("topics", changedTopics).Msg("topics created or extended")
+	}
+
+	notifications, closeNotifications, err := setupNotificationStore(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize notification store")
+	}
+	searchIndex, err := search.Open(cfg.BleveIndexPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize search index")
+	}
+	notifications = &indexedNotificationStore{NotificationStore: notifications, index: searchIndex}
+
+	consumerGroup, err := setupConsumer(cfg, cfg.ConsumerGroupID)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize consumer")
+	}
+	// group riêng cho retry topic để việc chờ backoff không chặn các topic chính
+	retryGroup, err := setupConsumer(cfg, cfg.ConsumerGroupID+".retry")
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize retry consumer")
+	}
+
+	dlqProducer, err := dlq.SetupDLQProducer(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize dlq producer")
+	}
+	webhooks, closeWebhooks, err := setupWebhookStore(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize webhook store")
+	}
+	defer closeWebhooks()
+
+	preferences, closePreferences, err := setupPreferencesStore(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize preferences store")
+	}
+	defer closePreferences()
+
+	acks, closeAcks, err := setupAckStore(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize ack store")
+	}
+	defer closeAcks()
+	ackGroup, err := setupAckConsumer(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize ack consumer")
+	}
+	ackPublisher := ack.NewPublisher(dlqProducer, cfg.AcksTopic)
+
+	flagStore, closeFlags, err := setupFlagStore(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize feature flags")
+	}
+	defer closeFlags()
+
+	realtime := hub.New()
+	pipeline := delivery.NewDeliveryPipeline(
+		delivery.NewSlackDeliverer(cfg.SlackWebhookURL),
+		delivery.NewWebhookDeliverer(webhooks),
+	)
+	consumer := &Consumer{
+		handler: filterByPreferences(preferences,
+			deliverNotification(notifications, realtime, pipeline, receipt.NewPublisher(dlqProducer, cfg.ReceiptsTopic))),
+		deleter:        notifications.Delete,
+		DLQProducer:    dlq.NewProducer(dlqProducer, cfg.DLQTopic),
+		Workers:        cfg.ConsumerWorkers,
+		Concurrency:    worker.NewSemaphore(cfg.ConsumerMaxGoroutines),
+		Flags:          flagStore,
+		CommitInterval: cfg.ConsumerCommitInterval,
+		CommitBatch:    cfg.ConsumerCommitBatch,
+		// blue-green: hai phiên bản chung group, mỗi phiên bản chỉ được gán partition của mình
+		PartitionRouter: consumer.NewPartitionRouter(cfg.ConsumerPartitions),
+	}
+	if cfg.MessageSigningKey != "" {
+		consumer.SigningKey = []byte(cfg.MessageSigningKey)
+	}
+	if cfg.PagerDutyAPIKey != "" {
+		consumer.ErrorRate = alerting.NewErrorRateMonitor(
+			alerting.NewPagerDutyAlerter(cfg.PagerDutyAPIKey, cfg.PagerDutyServiceID), cfg.AlertWindow, cfg.AlertErrorThreshold)
+		consumer.ErrorRate.OnError = func(err error) {
+			log.Warn().Err(err).Msg("failed to send pagerduty alert")
+		}
+	}
+	retryPolicy := retry.RetryPolicy{MaxRetries: cfg.MaxRetryCount, Backoffs: cfg.RetryBackoffs}
+	if retryPolicy.MaxRetries > 0 {
+		consumer.RetryProducer = retry.NewProducer(dlqProducer, cfg.KafkaTopicPrefix, retryPolicy)
+	}
+
+	users, closeUsers, err := store.OpenUserStore(context.Background(), cfg.DatabaseURL, cfg.Tenants...)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize user store")
+	}
+	defer closeUsers()
+
+	if cfg.SchemaRegistryURL != "" {
+		if err := codec.SetupAvro(cfg.SchemaRegistryURL, cfg.SchemaRegistrySubject, cfg.AvroCompatibility); err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize avro codec")
+		}
+	}
+	notificationCodec, err := codec.New(cfg.NotificationEncoding)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize codec")
+	}
+	// producer dùng cho notification client gửi lên qua WebSocket
+	producer, err := kafka.SetupProducer(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize producer")
+	}
+	wsSend := wsSender{
+		producer: producer,
+		opts:     sender.NewOptions(cfg, notificationCodec),
+		users:    users,
+	}
+
+	lagReporter, err := setupLagReporter(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize lag reporter")
+	}
+	defer lagReporter.Close()
+
+	var replayer *replay.MessageReplayer
+	if cfg.AdminAPIKey != "" {
+		replayClient, err := setupReplayClient(cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize message replayer")
+		}
+		defer replayClient.Close()
+		replayer = replay.NewMessageReplayer(replayClient)
+	} else {
+		log.Info().Msg("ADMIN_API_KEY is not set, /admin routes are disabled")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		runConsumerGroup(ctx, consumerGroup, cfg.ConsumerTopics(), consumer)
+	}()
+	go func() {
+		defer wg.Done()
+		if retryPolicy.MaxRetries == 0 {
+			return
+		}
+		runConsumerGroup(ctx, retryGroup, tenant.Topics(cfg.Tenants, retryPolicy.Topics(cfg.KafkaTopicPrefix)), &RetryConsumer{consumer})
+	}()
+	go func() {
+		defer wg.Done()
+		runLagMetrics(ctx, lagReporter, lagMetricsInterval)
+	}()
+	go func() {
+		defer wg.Done()
+		runAckConsumer(ctx, ackGroup, cfg.AcksTopic, acks)
+	}()
+	if cfg.ExpirySweepInterval > 0 {
+		// dùng store đã bọc search index để notification hết hạn cũng bị xoá khỏi index
+		sweeper := &expiry.Sweeper{Store: notifications, Interval: cfg.ExpirySweepInterval}
+		sweeper.OnSwept = func(deleted int) {
+			log.Info().Int("deleted", deleted).Msg("expired notifications removed")
+		}
+		sweeper.OnError = func(err error) {
+			log.Warn().Err(err).Msg("failed to remove expired notifications")
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sweeper.Run(ctx)
+		}()
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.Default()
+	router.Use(middleware.CORSMiddleware(cfg.CORS), middleware.BodyLimitMiddleware(int64(cfg.MaxRequestBodyBytes)),
+		middleware.TenantMiddleware(cfg.Tenants))
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	spec, err := openapi.Spec()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to build openapi spec")
+	}
+	router.GET(openapi.SpecPath, openapi.SpecHandler(spec))
+	router.GET(openapi.DocsPath, openapi.DocsHandler())
+	router.GET("/consumer/lag", consumerLagHandler(lagReporter))
+	router.GET("/metrics/lag", metricsLagHandler(lagReporter))
+	router.GET("/metrics/latency", metricsLatencyHandler)
+	if cfg.JWTSecret == "" {
+		log.Warn().Msg("JWT_SECRET is not set, /ws trusts the userID query parameter")
+	}
+	// route API nằm dưới /v1, đường dẫn cũ không version vẫn chạy nhưng trả header Deprecation/Sunset
+	apiGroups := []*gin.RouterGroup{
+		router.Group(middleware.APIV1),
+		router.Group("", middleware.DeprecationWarning(config.LegacyRoutesDeprecatedAt, config.LegacyRoutesSunsetAt)),
+	}
+	for _, api := range apiGroups {
+		api.GET("/stream", streamHandler(realtime, ctx.Done()))
+		if cfg.JWTSecret != "" {
+			api.GET("/ws", middleware.JWTAuthMiddleware(cfg.JWTSecret), websocketHandler(realtime, wsSend, ctx.Done()))
+		} else {
+			api.GET("/ws", websocketHandler(realtime, wsSend, ctx.Done()))
+		}
+		webhookRoutes := api.Group("/webhooks")
+		if cfg.JWTSecret != "" {
+			webhookRoutes.Use(middleware.JWTAuthMiddleware(cfg.JWTSecret))
+		}
+		webhookRoutes.POST("", createWebhookHandler(webhooks))
+		webhookRoutes.GET("", listWebhooksHandler(webhooks))
+		webhookRoutes.DELETE("/:id", deleteWebhookHandler(webhooks))
+		preferenceRoutes := api.Group("/preferences")
+		if cfg.JWTSecret != "" {
+			preferenceRoutes.Use(middleware.JWTAuthMiddleware(cfg.JWTSecret))
+		}
+		preferenceRoutes.PUT("", putPreferencesHandler(preferences))
+		preferenceRoutes.GET("", getPreferencesHandler(preferences))
+		api.GET("/notifications", middleware.ValidatePaginationParams(), listNotificationsHandler(notifications, acks))
+		api.GET("/notifications/search", middleware.ValidatePaginationParams(), searchNotificationsHandler(searchIndex))
+		api.GET("/notifications/threads/:threadID", threadNotificationsHandler(notifications))
+		if cfg.JWTSecret != "" {
+			api.POST("/notifications/:id/ack", middleware.JWTAuthMiddleware(cfg.JWTSecret), ackNotificationHandler(ackPublisher))
+		} else {
+			api.POST("/notifications/:id/ack", ackNotificationHandler(ackPublisher))
+		}
+		api.GET("/notifications/:userID", func(ctx *gin.Context) {
+			handleNotifications(ctx, notifications)
+		})
+		if replayer != nil {
+			adminGroup := api.Group("/admin")
+			adminGroup.Use(middleware.AdminAPIKeyMiddleware(cfg.AdminAPIKey))
+			if cfg.JWTSecret != "" {
+				// khi bật JWT, ngoài API key còn cần token có role admin
+				adminGroup.Use(middleware.JWTAuthMiddleware(cfg.JWTSecret), middleware.RequireRole(models.RoleAdmin))
+			}
+			adminGroup.POST("/replay", replayMessageHandler(replayer, consumer.handler))
+		}
+	}
+
+	httpServer := &http.Server{
+		Addr:    cfg.ConsumerPort,
+		Handler: router,
+	}
+
+	log.Info().
+		Str("group", cfg.ConsumerGroupID).
+		Strs("topics", cfg.ConsumerTopics()).
+		Msgf("Kafka CONSUMER 👥📥 started at http://localhost%s", cfg.ConsumerPort)
+
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("failed to run the server")
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info().Msg("shutdown: signal received, stopping HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to stop HTTP server gracefully")
+	}
+
+	log.Info().Msg("shutdown: waiting for consumer to finish in-flight messages")
+	wg.Wait()
+
+	// Close commit offset lần cuối và rời khỏi consumer group
+	log.Info().Msg("shutdown: closing consumer group")
+	if err := consumerGroup.Close(); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to close consumer group")
+	}
+	if err := retryGroup.Close(); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to close retry consumer group")
+	}
+	if err := ackGroup.Close(); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to close ack consumer group")
+	}
+
+	log.Info().Msg("shutdown: flushing and closing producer")
+	if err := producer.Close(); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to close producer")
+	}
+
+	log.Info().Msg("shutdown: flushing and closing dlq producer")
+	if err := consumer.DLQProducer.Close(); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to close dlq producer")
+	}
+	if err := closeNotifications(); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to close notification store")
+	}
+	if err := searchIndex.Close(); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to close search index")
+	}
+
+	log.Info().Msg("shutdown: flushing traces")
+	if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to flush traces")
+	}
+	log.Info().Msg("shutdown: complete")
+}
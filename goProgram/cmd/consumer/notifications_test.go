@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/ack"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type notificationsPage struct {
+	Notifications []notificationView `json:"notifications"`
+	Total         int                `json:"total"`
+	Limit         int                `json:"limit"`
+	Offset        int                `json:"offset"`
+}
+
+// getNotifications gọi GET /notifications qua ValidatePaginationParams như main.go
+func getNotifications(notifications store.NotificationStore, acks ack.AckStore, query string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/notifications", middleware.ValidatePaginationParams(), listNotificationsHandler(notifications, acks))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/notifications?"+query, nil))
+	return recorder
+}
+
+func decodePage(t *testing.T, recorder *httptest.ResponseRecorder) notificationsPage {
+	t.Helper()
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET /notifications status = %d, want 200: %s", recorder.Code, recorder.Body.String())
+	}
+	var page notificationsPage
+	if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, recorder.Body.String())
+	}
+	return page
+}
+
+// storeUserNotifications lưu count notification cho user 2 theo thứ tự notification-0, notification-1, ...
+func storeUserNotifications(t *testing.T, count int) *store.MemoryNotificationStore {
+	t.Helper()
+	notifications := store.NewMemoryNotificationStore()
+	for i := 0; i < count; i++ {
+		err := notifications.Store(context.Background(), models.Notification{
+			ID:       "notification-" + strconv.Itoa(i),
+			From:     models.User{ID: 1, Name: "Alice"},
+			To:       models.User{ID: 2, Name: "Bob"},
+			Message:  "hello",
+			Priority: models.PriorityNormal,
+		})
+		if err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+	}
+	return notifications
+}
+
+func TestListNotificationsHandlerPaging(t *testing.T) {
+	const total = 150
+	notifications := storeUserNotifications(t, total)
+	acks := ack.NewMemoryStore()
+
+	seen := make(map[string]bool)
+	offset := 0
+	for pages := 0; ; pages++ {
+		if pages > total {
+			t.Fatal("paging did not terminate")
+		}
+		page := decodePage(t, getNotifications(notifications, acks, "userID=2&limit=40&offset="+strconv.Itoa(offset)))
+		if page.Total != total || page.Limit != 40 || page.Offset != offset {
+			t.Fatalf("page at offset %d = total %d, limit %d, offset %d", offset, page.Total, page.Limit, page.Offset)
+		}
+		if len(page.Notifications) == 0 {
+			break
+		}
+		for _, n := range page.Notifications {
+			if seen[n.ID] {
+				t.Fatalf("%s returned on two pages", n.ID)
+			}
+			seen[n.ID] = true
+		}
+		offset += len(page.Notifications)
+	}
+	if len(seen) != total {
+		t.Fatalf("paged %d notifications, want %d", len(seen), total)
+	}
+
+	// không truyền limit thì dùng DefaultPageLimit
+	if page := decodePage(t, getNotifications(notifications, acks, "userID=2")); len(page.Notifications) != middleware.DefaultPageLimit {
+		t.Fatalf("default page has %d notifications, want %d", len(page.Notifications), middleware.DefaultPageLimit)
+	}
+}
+
+func TestListNotificationsHandlerInvalidParams(t *testing.T) {
+	notifications := storeUserNotifications(t, 1)
+	for _, query := range []string{
+		"limit=0", "limit=101", "limit=ten", "offset=-1",
+		"sortBy=message", "sortDir=up", "fromID=-1", "minPriority=9",
+	} {
+		recorder := getNotifications(notifications, ack.NewMemoryStore(), "userID=2&"+query)
+		if recorder.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("GET /notifications?%s status = %d, want 422", query, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// offsetCommitter gom các offset đã mark của một session và commit mỗi interval
+// hoặc khi đủ batch message, thay vì một round-trip tới broker cho mỗi message.
+// Message đã mark nhưng chưa commit sẽ được đọc lại nếu consumer chết, nên handler vẫn phải chịu được trùng lặp.
+type offsetCommitter struct {
+	session sarama.ConsumerGroupSession
+	batch   int64
+	pending atomic.Int64
+	done    chan struct{}
+	stopped sync.WaitGroup
+}
+
+func newOffsetCommitter(session sarama.ConsumerGroupSession, interval time.Duration, batch int) *offsetCommitter {
+	committer := &offsetCommitter{session: session, batch: int64(batch), done: make(chan struct{})}
+	committer.stopped.Add(1)
+	go func() {
+		defer committer.stopped.Done()
+		committer.run(interval)
+	}()
+	return committer
+}
+
+func (c *offsetCommitter) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if c.pending.Swap(0) > 0 {
+				c.session.Commit()
+			}
+		}
+	}
+}
+
+// mark đánh dấu msg đã xử lý xong, đủ batch thì commit ngay trên goroutine gọi.
+func (c *offsetCommitter) mark(msg *sarama.ConsumerMessage) {
+	c.session.MarkMessage(msg, "")
+	if c.pending.Add(1) >= c.batch {
+		c.pending.Store(0)
+		c.session.Commit()
+	}
+}
+
+// stop dừng commit định kỳ và commit các offset còn lại.
+func (c *offsetCommitter) stop() {
+	close(c.done)
+	c.stopped.Wait()
+	c.session.Commit()
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/worker"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// restartBroker là coordinator của testGroup với một partition, giữ handler map để
+// OffsetFetch của lần khởi động sau trả về offset lần trước đã commit.
+type restartBroker struct {
+	*groupBroker
+	handlers map[string]sarama.MockResponse
+	fetch    *sarama.MockFetchResponse
+}
+
+func newRestartBroker(t *testing.T, strategy string) *restartBroker {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	join := sarama.NewMockJoinGroupResponse(t).
+		SetGroupProtocol(strategy).SetGenerationId(1).SetMemberId("blue").SetLeaderId("blue").
+		SetMember("blue", &sarama.ConsumerGroupMemberMetadata{Topics: []string{testTopic}})
+	rb := &restartBroker{groupBroker: &groupBroker{MockBroker: broker}, fetch: sarama.NewMockFetchResponse(t, 10)}
+	rb.handlers = map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).SetLeader(testTopic, 0, broker.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, testGroup, broker),
+		"JoinGroupRequest": join,
+		"SyncGroupRequest": sarama.NewMockSyncGroupResponse(t).SetMemberAssignment(&sarama.ConsumerGroupMemberAssignment{
+			Topics: map[string][]int32{testTopic: {0}},
+		}),
+		"HeartbeatRequest":    sarama.NewMockHeartbeatResponse(t),
+		"LeaveGroupRequest":   sarama.NewMockLeaveGroupResponse(t),
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t),
+		"FetchRequest":        rb.fetch,
+	}
+	rb.setCommitted(t, -1)
+	rb.produce(t, 0, 0)
+	return rb
+}
+
+// setCommitted là offset OffsetFetch trả về, -1 là group chưa commit gì
+func (rb *restartBroker) setCommitted(t *testing.T, offset int64) {
+	rb.handlers["OffsetFetchRequest"] = sarama.NewMockOffsetFetchResponse(t).
+		SetOffset(testGroup, testTopic, 0, offset, "", sarama.ErrNoError)
+	rb.SetHandlerByMap(rb.handlers)
+}
+
+// produce ghi các notification có offset từ from tới to-1 vào partition 0, high watermark thành to
+func (rb *restartBroker) produce(t *testing.T, from, to int64) {
+	for offset := from; offset < to; offset++ {
+		value, _ := json.Marshal(models.Notification{
+			ID:      "notification-" + strconv.FormatInt(offset, 10),
+			From:    models.User{ID: 1, Name: "Alice"},
+			To:      models.User{ID: 2, Name: "Bob"},
+			Message: "hello", Priority: models.PriorityNormal,
+		})
+		rb.fetch.SetMessage(testTopic, 0, offset, sarama.ByteEncoder(value))
+	}
+	rb.fetch.SetHighWaterMark(testTopic, 0, to)
+	rb.handlers["OffsetRequest"] = sarama.NewMockOffsetResponse(t).
+		SetOffset(testTopic, 0, sarama.OffsetOldest, 0).SetOffset(testTopic, 0, sarama.OffsetNewest, to)
+	rb.SetHandlerByMap(rb.handlers)
+}
+
+// lastCommitted là offset của OffsetCommitRequest cuối cùng broker nhận được
+func (rb *restartBroker) lastCommitted(t *testing.T) int64 {
+	t.Helper()
+	commits := requests[*sarama.OffsetCommitRequest](rb.groupBroker)
+	if len(commits) == 0 {
+		t.Fatal("consumer committed no offsets")
+	}
+	offset, _, err := commits[len(commits)-1].Offset(testTopic, 0)
+	if err != nil {
+		t.Fatalf("last commit has no offset for partition 0: %v", err)
+	}
+	return offset
+}
+
+// consumeUntil chạy một vòng đời consumer (khởi động, xử lý want message, tắt) và
+// trả về notification ID đã xử lý theo thứ tự.
+func consumeUntil(t *testing.T, groupConfig *sarama.Config, broker *restartBroker, want int) []string {
+	t.Helper()
+	group, err := sarama.NewConsumerGroup([]string{broker.Addr()}, testGroup, groupConfig)
+	if err != nil {
+		t.Fatalf("NewConsumerGroup() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	var processed []string
+	enough := make(chan struct{})
+	handler := &Consumer{
+		handler: func(ctx context.Context, notification models.Notification) error {
+			mu.Lock()
+			defer mu.Unlock()
+			processed = append(processed, notification.ID)
+			if len(processed) == want {
+				close(enough)
+			}
+			return nil
+		},
+		Concurrency:    worker.NewSemaphore(1),
+		CommitInterval: time.Hour,
+		CommitBatch:    100,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			if err := group.Consume(ctx, []string{testTopic}, handler); err != nil && ctx.Err() == nil {
+				t.Errorf("Consume() error = %v", err)
+				return
+			}
+		}
+	}()
+	select {
+	case <-enough:
+	case <-time.After(5 * time.Second):
+		t.Errorf("processed %d messages, want %d", len(processed), want)
+	}
+	cancel()
+	<-done
+	if err := group.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]string(nil), processed...)
+}
+
+// offset đã commit khi tắt consumer là điểm bắt đầu của lần khởi động sau:
+// message đã xử lý không được đọc lại, message mới vẫn được đọc đủ.
+func TestConsumerRestartResumesFromCommittedOffset(t *testing.T) {
+	t.Setenv("KAFKA_CONSUMER_OFFSET_STRATEGY", config.OffsetStrategyOldest)
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	groupConfig, err := newConsumerConfig(cfg)
+	if err != nil {
+		t.Fatalf("newConsumerConfig() error = %v", err)
+	}
+	if groupConfig.Consumer.Offsets.AutoCommit.Enable {
+		t.Fatal("auto commit is enabled, want manual commits")
+	}
+	groupConfig.Consumer.Group.Rebalance.Retry.Backoff = 10 * time.Millisecond
+	broker := newRestartBroker(t, groupConfig.Consumer.Group.Rebalance.GroupStrategies[0].Name())
+
+	broker.produce(t, 0, 5)
+	first := consumeUntil(t, groupConfig, broker, 5)
+	if committed := broker.lastCommitted(t); committed != 5 {
+		t.Fatalf("committed offset after first run = %d, want 5", committed)
+	}
+
+	// khởi động lại: broker vẫn giữ message 0-4, thêm 5-9
+	broker.setCommitted(t, broker.lastCommitted(t))
+	broker.produce(t, 5, 10)
+	second := consumeUntil(t, groupConfig, broker, 5)
+
+	seen := make(map[string]int)
+	for _, id := range append(first, second...) {
+		seen[id]++
+	}
+	for id, count := range seen {
+		if count != 1 {
+			t.Errorf("%s processed %d times", id, count)
+		}
+	}
+	if len(seen) != 10 || second[0] != "notification-5" {
+		t.Fatalf("first run %v, second run %v, want 0-4 then 5-9", first, second)
+	}
+	if committed := broker.lastCommitted(t); committed != 10 {
+		t.Fatalf("committed offset after second run = %d, want 10", committed)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/consumer"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/worker"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+const testGroup = "test-group"
+
+// groupBroker là MockBroker làm coordinator của testGroup cho 6 partition của testTopic:
+// JoinGroup trả về member "blue" là leader (chính consumer trong test) cùng members,
+// SyncGroup gán assignment cho consumer bất kể leader gửi gì, giống coordinator đã chọn protocol.
+type groupBroker struct {
+	*sarama.MockBroker
+}
+
+func newGroupBroker(t *testing.T, protocol string, members map[string][]int32, assignment []int32) *groupBroker {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	metadata := sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID())
+	offsets := sarama.NewMockOffsetFetchResponse(t)
+	positions := sarama.NewMockOffsetResponse(t)
+	fetch := sarama.NewMockFetchResponse(t, 1)
+	for partition := int32(0); partition < 6; partition++ {
+		metadata.SetLeader(testTopic, partition, broker.BrokerID())
+		offsets.SetOffset(testGroup, testTopic, partition, 0, "", sarama.ErrNoError)
+		positions.SetOffset(testTopic, partition, sarama.OffsetOldest, 0).SetOffset(testTopic, partition, sarama.OffsetNewest, 1)
+		notification := models.Notification{
+			ID:      "notification-" + strconv.Itoa(int(partition)),
+			From:    models.User{ID: 1, Name: "Alice"},
+			To:      models.User{ID: 2, Name: "Bob"},
+			Message: "hello", Priority: models.PriorityNormal,
+		}
+		value, _ := json.Marshal(notification)
+		fetch.SetMessage(testTopic, partition, 0, sarama.ByteEncoder(value))
+	}
+	join := sarama.NewMockJoinGroupResponse(t).
+		SetGroupProtocol(protocol).SetGenerationId(1).SetMemberId("blue").SetLeaderId("blue")
+	for memberID, partitions := range members {
+		join.SetMember(memberID, &sarama.ConsumerGroupMemberMetadata{
+			Topics: []string{testTopic}, UserData: consumer.NewPartitionRouter(partitions).UserData(),
+		})
+	}
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": metadata,
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, testGroup, broker),
+		"JoinGroupRequest": join,
+		"SyncGroupRequest": sarama.NewMockSyncGroupResponse(t).SetMemberAssignment(&sarama.ConsumerGroupMemberAssignment{
+			Topics: map[string][]int32{testTopic: assignment},
+		}),
+		"HeartbeatRequest":    sarama.NewMockHeartbeatResponse(t),
+		"LeaveGroupRequest":   sarama.NewMockLeaveGroupResponse(t),
+		"OffsetFetchRequest":  offsets,
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t),
+		"OffsetRequest":       positions,
+		"FetchRequest":        fetch,
+	})
+	return &groupBroker{MockBroker: broker}
+}
+
+// requests trả về các request kiểu T broker đã nhận
+func requests[T any](broker *groupBroker) []T {
+	var found []T
+	for _, rr := range broker.History() {
+		if request, ok := rr.Request.(T); ok {
+			found = append(found, request)
+		}
+	}
+	return found
+}
+
+// runGroup chạy consumer với CONSUMER_PARTITION_FILTER=filter trong group thật của sarama,
+// lặp Consume như main tới khi test kết thúc, trả về các notification ID đã xử lý.
+func runGroup(t *testing.T, broker *groupBroker, filter string) *sync.Map {
+	t.Helper()
+	t.Setenv("CONSUMER_PARTITION_FILTER", filter)
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	groupConfig, err := newConsumerConfig(cfg)
+	if err != nil {
+		t.Fatalf("newConsumerConfig() error = %v", err)
+	}
+	groupConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	groupConfig.Consumer.Group.Rebalance.Retry.Backoff = 10 * time.Millisecond
+	group, err := sarama.NewConsumerGroup([]string{broker.Addr()}, testGroup, groupConfig)
+	if err != nil {
+		t.Fatalf("NewConsumerGroup() error = %v", err)
+	}
+
+	var processed sync.Map
+	handler := &Consumer{
+		handler: func(ctx context.Context, notification models.Notification) error {
+			processed.Store(notification.ID, true)
+			return nil
+		},
+		PartitionRouter: consumer.NewPartitionRouter(cfg.ConsumerPartitions),
+		Concurrency:     worker.NewSemaphore(6),
+		CommitInterval:  time.Hour,
+		CommitBatch:     100,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			if err := group.Consume(ctx, []string{testTopic}, handler); err != nil && ctx.Err() == nil {
+				t.Errorf("Consume() error = %v", err)
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		group.Close()
+	})
+	return &processed
+}
+
+func waitProcessed(t *testing.T, processed *sync.Map, id string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := processed.Load(id); ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%s was not processed", id)
+}
+
+// group còn member bản cũ nên coordinator chọn range và gán cả partition ngoài filter:
+// claim đó phải giữ nguyên session, nếu trả về sớm sarama huỷ session và group rebalance liên tục.
+func TestConsumerGroupFilteredClaimKeepsSession(t *testing.T) {
+	broker := newGroupBroker(t, sarama.RangeBalanceStrategyName, map[string][]int32{"blue": {0, 1, 2}}, []int32{2, 3})
+	processed := runGroup(t, broker, "0,1,2")
+
+	waitProcessed(t, processed, "notification-2")
+	time.Sleep(300 * time.Millisecond)
+	if _, ok := processed.Load("notification-3"); ok {
+		t.Fatal("partition 3 outside CONSUMER_PARTITION_FILTER was processed")
+	}
+	if joins := len(requests[*sarama.JoinGroupRequest](broker)); joins != 1 {
+		t.Fatalf("%d JoinGroup requests, want 1 (the filtered claim ended the session)", joins)
+	}
+}
+
+// consumer là leader thì chia partition bằng PartitionFilterStrategy theo filter trong UserData của từng member
+func TestConsumerGroupLeaderAssignsByPartitionFilter(t *testing.T) {
+	broker := newGroupBroker(t, consumer.PartitionFilterStrategyName,
+		map[string][]int32{"blue": {0, 1, 2}, "green": {3, 4, 5}}, []int32{0, 1, 2})
+	processed := runGroup(t, broker, "0,1,2")
+	waitProcessed(t, processed, "notification-0")
+
+	joins := requests[*sarama.JoinGroupRequest](broker)
+	if len(joins) == 0 {
+		t.Fatal("no JoinGroup request")
+	}
+	var protocols []string
+	for _, protocol := range joins[0].OrderedGroupProtocols {
+		protocols = append(protocols, protocol.Name)
+		members, err := (&sarama.JoinGroupResponse{Members: []sarama.GroupMember{
+			{MemberId: "blue", Metadata: protocol.Metadata},
+		}}).GetMembers()
+		if err != nil {
+			t.Fatalf("failed to decode member metadata: %v", err)
+		}
+		if string(members["blue"].UserData) != "[0,1,2]" {
+			t.Fatalf("%s UserData = %q, want [0,1,2]", protocol.Name, members["blue"].UserData)
+		}
+	}
+	if want := []string{consumer.PartitionFilterStrategyName, sarama.RangeBalanceStrategyName}; !reflect.DeepEqual(protocols, want) {
+		t.Fatalf("JoinGroup protocols = %v, want %v", protocols, want)
+	}
+
+	syncs := requests[*sarama.SyncGroupRequest](broker)
+	if len(syncs) == 0 {
+		t.Fatal("no SyncGroup request")
+	}
+	plan := map[string][]int32{}
+	for _, assignment := range syncs[0].GroupAssignments {
+		decoded, err := (&sarama.SyncGroupResponse{MemberAssignment: assignment.Assignment}).GetMemberAssignment()
+		if err != nil {
+			t.Fatalf("failed to decode assignment of %s: %v", assignment.MemberId, err)
+		}
+		plan[assignment.MemberId] = decoded.Topics[testTopic]
+	}
+	want := map[string][]int32{"blue": {0, 1, 2}, "green": {3, 4, 5}}
+	if !reflect.DeepEqual(plan, want) {
+		t.Fatalf("leader assignments = %v, want %v", plan, want)
+	}
+}
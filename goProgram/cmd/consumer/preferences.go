@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/metrics"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============== USER PREFERENCES ==============
+
+// Nếu không có REDIS_URL thì preferences chỉ lưu trong bộ nhớ
+func setupPreferencesStore(cfg *config.Config) (store.PreferencesStore, func() error, error) {
+	if cfg.RedisURL == "" {
+		return store.NewMemoryPreferencesStore(), func() error { return nil }, nil
+	}
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.NewRedisPreferencesStore(client), client.Close, nil
+}
+
+// filterByPreferences bỏ qua notification người nhận không muốn nhận. Handler trả về nil
+// nên offset vẫn được commit: đây chỉ là bộ lọc lúc giao, producer không biết gì về nó.
+func filterByPreferences(preferences store.PreferencesStore, next NotificationHandler) NotificationHandler {
+	return func(ctx context.Context, notification models.Notification) error {
+		prefs, err := preferences.Get(ctx, notification.To.ID)
+		if err != nil {
+			return err
+		}
+		if ok, reason := prefs.Allows(notification); !ok {
+			metrics.MessagesFiltered.WithLabelValues(reason).Inc()
+			log.Debug().
+				Str("notificationID", notification.ID).
+				Int("toID", notification.To.ID).
+				Str("reason", reason).
+				Msg("notification filtered by user preferences")
+			return nil
+		}
+		return next(ctx, notification)
+	}
+}
+
+// khi bật JWT, user chỉ xem và sửa được preferences của chính mình
+func canManagePreferences(ctx *gin.Context, userID int) bool {
+	authedID, ok := middleware.AuthedUserID(ctx)
+	return !ok || authedID == userID
+}
+
+// putPreferencesHandler xử lý PUT /preferences, body là models.UserPreferences đầy đủ.
+func putPreferencesHandler(preferences store.PreferencesStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var prefs models.UserPreferences
+		if err := ctx.ShouldBindJSON(&prefs); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if userID, ok := middleware.AuthedUserID(ctx); ok && prefs.UserID == 0 {
+			prefs.UserID = userID
+		}
+		if err := prefs.Validate(); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if !canManagePreferences(ctx, prefs.UserID) {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "cannot update preferences of another user"})
+			return
+		}
+		if err := preferences.Save(ctx.Request.Context(), prefs); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, prefs)
+	}
+}
+
+// getPreferencesHandler xử lý GET /preferences?userID=<id>.
+func getPreferencesHandler(preferences store.PreferencesStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, ok := middleware.AuthedUserID(ctx)
+		if value := ctx.Query("userID"); value != "" || !ok {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": "userID query parameter must be a number"})
+				return
+			}
+			userID = id
+		}
+		if !canManagePreferences(ctx, userID) {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "cannot read preferences of another user"})
+			return
+		}
+
+		prefs, err := preferences.Get(ctx.Request.Context(), userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, prefs)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/metrics"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/worker"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// notification bị lọc không được giao nhưng offset vẫn được commit như đã xử lý
+func TestFilterByPreferences(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefs      *models.UserPreferences
+		fromID     int
+		priority   int
+		wantReason string
+	}{
+		{name: "no preferences saved", fromID: 1, priority: models.PriorityNormal},
+		{name: "muted", prefs: &models.UserPreferences{UserID: 2, Muted: true}, fromID: 1, priority: models.PriorityNormal, wantReason: models.FilterReasonMuted},
+		{name: "allowed sender", prefs: &models.UserPreferences{UserID: 2, AllowedSenderIDs: []int{1}}, fromID: 1, priority: models.PriorityNormal},
+		{name: "sender not allowed", prefs: &models.UserPreferences{UserID: 2, AllowedSenderIDs: []int{1}}, fromID: 3, priority: models.PriorityNormal, wantReason: models.FilterReasonSender},
+		{name: "priority at minimum", prefs: &models.UserPreferences{UserID: 2, MinPriority: models.PriorityHigh}, fromID: 1, priority: models.PriorityHigh},
+		{name: "priority below minimum", prefs: &models.UserPreferences{UserID: 2, MinPriority: models.PriorityHigh}, fromID: 1, priority: models.PriorityLow, wantReason: models.FilterReasonPriority},
+		// preferences của người khác không ảnh hưởng người nhận
+		{name: "other user muted", prefs: &models.UserPreferences{UserID: 3, Muted: true}, fromID: 1, priority: models.PriorityNormal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preferences := store.NewMemoryPreferencesStore()
+			if tt.prefs != nil {
+				if err := preferences.Save(context.Background(), *tt.prefs); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+			var delivered atomic.Int32
+			consumer := &Consumer{
+				handler: filterByPreferences(preferences, func(ctx context.Context, notification models.Notification) error {
+					delivered.Add(1)
+					return nil
+				}),
+				Concurrency:    worker.NewSemaphore(1),
+				CommitInterval: time.Hour,
+				CommitBatch:    100,
+			}
+			var before float64
+			if tt.wantReason != "" {
+				before = testutil.ToFloat64(metrics.MessagesFiltered.WithLabelValues(tt.wantReason))
+			}
+
+			produced, err := sender.NewMessage(context.Background(), sender.Options{Codec: codec.JSONCodec{}}, models.Notification{
+				ID:       "notification-preferences",
+				From:     models.User{ID: tt.fromID, Name: "Sender"},
+				To:       models.User{ID: 2, Name: "Bob"},
+				Message:  "hello",
+				Priority: tt.priority,
+			})
+			if err != nil {
+				t.Fatalf("NewMessage() error = %v", err)
+			}
+			session := newFakeSession(context.Background())
+			consumeClaims(t, consumer, session, newFakeClaim(0, consumedMessage(t, produced, 0, 0)))
+
+			wantDelivered := int32(1)
+			if tt.wantReason != "" {
+				wantDelivered = 0
+			}
+			if got := delivered.Load(); got != wantDelivered {
+				t.Fatalf("delivered %d notifications, want %d", got, wantDelivered)
+			}
+			if got := session.committedOffset(0); got != 1 {
+				t.Fatalf("committed offset = %d, want 1", got)
+			}
+			if tt.wantReason != "" {
+				if got := testutil.ToFloat64(metrics.MessagesFiltered.WithLabelValues(tt.wantReason)); got != before+1 {
+					t.Fatalf("kafka_messages_filtered_total{reason=%q} = %v, want %v", tt.wantReason, got, before+1)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/replay"
+	"kafka-notify/pkg/tenant"
+	"net/http"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+)
+
+// ============== SINGLE MESSAGE REPLAY ==============
+
+type replayMessageRequest struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+// replayMessageHandler phục vụ POST /admin/replay: đọc lại một message theo offset và đưa
+// notification vào lại handler của consumer (preferences, store, realtime, delivery),
+// không đi qua retry/DLQ nên lỗi được trả thẳng cho người gọi.
+func replayMessageHandler(replayer *replay.MessageReplayer, handler NotificationHandler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req replayMessageRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if req.Topic == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "topic is required"})
+			return
+		}
+
+		notification, err := replayer.ReplayMessage(ctx.Request.Context(), req.Topic, req.Partition, req.Offset)
+		switch {
+		case errors.Is(err, replay.ErrMessageNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		case errors.Is(err, replay.ErrUndecodable):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+			return
+		case err != nil:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		// store và preferences theo tenant của người nhận, giống processMessage
+		handlerCtx := tenant.WithID(ctx.Request.Context(), notification.To.TenantID)
+		if err := handler(handlerCtx, *notification); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		log.Info().
+			Str("topic", req.Topic).
+			Int32("partition", req.Partition).
+			Int64("offset", req.Offset).
+			Str("notificationID", notification.ID).
+			Msg("notification replayed")
+		ctx.JSON(http.StatusOK, notification)
+	}
+}
+
+// setupReplayClient dùng cùng cấu hình với consumer group (ReadCommitted) để không replay
+// message của transaction bị abort.
+func setupReplayClient(cfg *config.Config) (sarama.Client, error) {
+	config, err := newConsumerConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup replay client: %w", err)
+	}
+	client, err := sarama.NewClient(cfg.KafkaBrokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup replay client: %w", err)
+	}
+	return client, nil
+}
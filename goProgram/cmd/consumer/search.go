@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/search"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============== FULL-TEXT SEARCH ==============
+
+// indexedNotificationStore index mỗi notification vào search index sau khi lưu vào store.
+// Lỗi index chỉ được log để không làm hỏng việc giao notification; dùng cmd/reindex để dựng lại index.
+type indexedNotificationStore struct {
+	store.NotificationStore
+	index *search.Index
+}
+
+func (s *indexedNotificationStore) Store(ctx context.Context, n models.Notification) error {
+	if err := s.NotificationStore.Store(ctx, n); err != nil {
+		return err
+	}
+	if err := s.index.Store(ctx, n); err != nil {
+		log.Warn().Err(err).Str("notificationID", n.ID).Msg("failed to index notification")
+	}
+	return nil
+}
+
+func (s *indexedNotificationStore) Delete(ctx context.Context, id string) error {
+	if err := s.NotificationStore.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := s.index.Delete(ctx, id); err != nil {
+		log.Warn().Err(err).Str("notificationID", id).Msg("failed to remove notification from index")
+	}
+	return nil
+}
+
+// searchNotificationsHandler xử lý GET /notifications/search?q=hello&userID=1&limit=20&offset=0,
+// kết quả sắp xếp theo độ liên quan giảm dần.
+func searchNotificationsHandler(index *search.Index) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		query := ctx.Query("q")
+		if query == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "q query parameter is required"})
+			return
+		}
+		userID, err := strconv.Atoi(ctx.Query("userID"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "userID query parameter must be a number"})
+			return
+		}
+		filter := middleware.PaginationFilter(ctx)
+
+		hits, total, err := index.Search(ctx.Request.Context(), userID, query, filter.Limit, filter.Offset)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"results": hits,
+			"total":   total,
+			"limit":   filter.Limit,
+			"offset":  filter.Offset,
+		})
+	}
+}
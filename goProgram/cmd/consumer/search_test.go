@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/search"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searchNotifications gọi GET /notifications/search như main.go
+func searchNotifications(index *search.Index, query url.Values) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/notifications/search", middleware.ValidatePaginationParams(), searchNotificationsHandler(index))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/notifications/search?"+query.Encode(), nil))
+	return recorder
+}
+
+func TestSearchNotificationsHandler(t *testing.T) {
+	index, err := search.Open("")
+	if err != nil {
+		t.Fatalf("search.Open() error = %v", err)
+	}
+	defer index.Close()
+
+	// notification đi qua indexedNotificationStore như consumer, không index trực tiếp
+	notifications := &indexedNotificationStore{NotificationStore: store.NewMemoryNotificationStore(), index: index}
+	messages := []string{
+		"server cpu alert", "lunch at noon", "disk alert on db-1", "welcome aboard", "alert resolved",
+		"invoice ready", "team offsite", "memory usage normal", "new follower", "release notes",
+	}
+	for i, message := range messages {
+		err := notifications.Store(context.Background(), models.Notification{
+			ID:       "notification-" + strconv.Itoa(i),
+			From:     models.User{ID: 1, Name: "Alice"},
+			To:       models.User{ID: 2, Name: "Bob"},
+			Message:  message,
+			Priority: models.PriorityNormal,
+		})
+		if err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+	}
+
+	recorder := searchNotifications(index, url.Values{"q": {"alert"}, "userID": {"2"}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET /notifications/search status = %d, want 200: %s", recorder.Code, recorder.Body.String())
+	}
+	var response struct {
+		Results []search.Hit `json:"results"`
+		Total   int          `json:"total"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	ids := make([]string, 0, len(response.Results))
+	for i, hit := range response.Results {
+		ids = append(ids, hit.Notification.ID)
+		if i > 0 && hit.Score > response.Results[i-1].Score {
+			t.Fatalf("results not sorted by score: %+v", response.Results)
+		}
+	}
+	sort.Strings(ids)
+	if want := []string{"notification-0", "notification-2", "notification-4"}; response.Total != 3 || len(ids) != 3 ||
+		ids[0] != want[0] || ids[1] != want[1] || ids[2] != want[2] {
+		t.Fatalf("search results = %v (total %d), want %v", ids, response.Total, want)
+	}
+
+	// kết quả giới hạn theo người nhận
+	var other struct {
+		Total int `json:"total"`
+	}
+	if err := json.Unmarshal(searchNotifications(index, url.Values{"q": {"alert"}, "userID": {"3"}}).Body.Bytes(), &other); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if other.Total != 0 {
+		t.Fatalf("search for user 3 total = %d, want 0", other.Total)
+	}
+}
+
+func TestSearchNotificationsHandlerInvalidParams(t *testing.T) {
+	index, err := search.Open("")
+	if err != nil {
+		t.Fatalf("search.Open() error = %v", err)
+	}
+	defer index.Close()
+
+	for _, query := range []url.Values{
+		{"userID": {"2"}},
+		{"q": {"alert"}},
+		{"q": {"alert"}, "userID": {"bob"}},
+	} {
+		if recorder := searchNotifications(index, query); recorder.Code != http.StatusBadRequest {
+			t.Fatalf("GET /notifications/search?%s status = %d, want 400", query.Encode(), recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"kafka-notify/pkg/delivery"
+	"kafka-notify/pkg/hub"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/receipt"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============== REALTIME DELIVERY ==============
+
+// gửi comment rỗng định kỳ để proxy/load balancer không cắt kết nối SSE đang rảnh
+const streamKeepAlive = 15 * time.Second
+
+// deliverNotification lưu notification rồi đẩy tới các kết nối realtime đang mở của người nhận
+// và các kênh ngoài trong pipeline (Slack, ...), sau đó gửi read receipt nếu notification có ID.
+// Lỗi của kênh ngoài và receipt chỉ được log: notification đã được lưu, retry sẽ tạo bản trùng.
+func deliverNotification(notifications store.NotificationStore, h *hub.Hub,
+	pipeline *delivery.DeliveryPipeline, receipts *receipt.Publisher) NotificationHandler {
+	return func(ctx context.Context, notification models.Notification) error {
+		if err := notifications.Store(ctx, notification); err != nil {
+			return err
+		}
+		h.Broadcast(notification)
+		if err := pipeline.Deliver(ctx, notification); err != nil {
+			log.Warn().Err(err).Str("notificationID", notification.ID).Msg("failed to deliver notification to external channels")
+		}
+
+		if receipts == nil || notification.ID == "" {
+			return nil
+		}
+		err := receipts.Publish(models.Receipt{
+			NotificationID: notification.ID,
+			ReaderID:       notification.To.ID,
+			ReadAt:         time.Now(),
+		})
+		if err != nil {
+			log.Warn().Err(err).Str("notificationID", notification.ID).Msg("failed to publish read receipt")
+		}
+		return nil
+	}
+}
+
+// streamHandler xử lý GET /stream?userID=1 bằng Server-Sent Events,
+// mỗi notification là một frame "data: <json>\n\n".
+// shutdown đóng khi service dừng để các stream đang mở kết thúc, không chặn httpServer.Shutdown.
+func streamHandler(h *hub.Hub, shutdown <-chan struct{}) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, err := strconv.Atoi(ctx.Query("userID"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "userID query parameter must be a number"})
+			return
+		}
+
+		flusher, ok := ctx.Writer.(http.Flusher)
+		if !ok {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "streaming is not supported"})
+			return
+		}
+
+		notes, unregister := h.Register(userID)
+		defer unregister()
+
+		ctx.Header("Content-Type", "text/event-stream")
+		ctx.Header("Cache-Control", "no-cache")
+		ctx.Header("Connection", "keep-alive")
+		ctx.Status(http.StatusOK)
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-ctx.Request.Context().Done():
+				return
+			case <-shutdown:
+				return
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(ctx.Writer, ": keepalive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case notification, ok := <-notes:
+				if !ok {
+					return
+				}
+				payload, err := json.Marshal(notification)
+				if err != nil {
+					log.Error().Err(err).Int("userID", userID).Msg("failed to encode notification for stream")
+					continue
+				}
+				if _, err := fmt.Fprintf(ctx.Writer, "data: %s\n\n", payload); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
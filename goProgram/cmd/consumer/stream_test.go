@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"kafka-notify/pkg/hub"
+	"kafka-notify/pkg/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// flushRecorder là httptest.ResponseRecorder báo qua flushed mỗi lần handler Flush,
+// để test biết stream đã đăng ký với hub (lần đầu) và đã ghi xong frame (các lần sau)
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed chan struct{}
+}
+
+func (r *flushRecorder) Flush() {
+	r.ResponseRecorder.Flush()
+	r.flushed <- struct{}{}
+}
+
+func waitFlush(t *testing.T, recorder *flushRecorder) {
+	t.Helper()
+	select {
+	case <-recorder.flushed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not flush")
+	}
+}
+
+func TestStreamHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := hub.New()
+	shutdown := make(chan struct{})
+	engine := gin.New()
+	engine.GET("/stream", streamHandler(h, shutdown))
+
+	recorder := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushed: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stream?userID=2", nil))
+	}()
+	waitFlush(t, recorder)
+
+	want := models.Notification{
+		ID:   "5f0c7c3e-8f9a-4a57-9d51-3b8a2f8d9e01",
+		From: models.User{ID: 1, Name: "Alice"}, To: models.User{ID: 2, Name: "Bob"},
+		Message: "hello", Priority: models.PriorityNormal,
+	}
+	go func() {
+		// notification của user khác không tới stream của user 2
+		h.Broadcast(models.Notification{ID: "other", To: models.User{ID: 3}, Message: "not for you"})
+		h.Broadcast(want)
+	}()
+	waitFlush(t, recorder)
+	close(shutdown)
+	<-done
+
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d, Content-Type = %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	frames := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n\n"), "\n\n")
+	if len(frames) != 1 || !strings.HasPrefix(frames[0], "data: ") {
+		t.Fatalf("body = %q, want one data frame", recorder.Body.String())
+	}
+	var got models.Notification
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(frames[0], "data: ")), &got); err != nil {
+		t.Fatalf("frame is not a notification: %v", err)
+	}
+	if got.ID != want.ID || got.Message != want.Message || got.To.ID != 2 {
+		t.Fatalf("streamed notification = %+v, want %+v", got, want)
+	}
+	// stream đã huỷ đăng ký, Broadcast sau đó không gửi vào channel đã đóng
+	h.Broadcast(want)
+}
+
+func TestStreamHandlerInvalidUserID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/stream", streamHandler(hub.New(), make(chan struct{})))
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stream?userID=abc", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", recorder.Code)
+	}
+}
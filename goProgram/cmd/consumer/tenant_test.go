@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/dlq"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/tenant"
+	"kafka-notify/pkg/worker"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// newTenantMessage encode notification gửi cho user 2 của toTenant như producer
+func newTenantMessage(t *testing.T, offset int64, toTenant string) *sarama.ConsumerMessage {
+	t.Helper()
+	notification := models.Notification{
+		ID:       "notification-" + toTenant,
+		From:     models.User{ID: 1, Name: "Alice", TenantID: toTenant},
+		To:       models.User{ID: 2, Name: "Bob", TenantID: toTenant},
+		Message:  "hello " + toTenant,
+		Priority: models.PriorityNormal,
+	}
+	produced, err := sender.NewMessage(context.Background(), sender.Options{Codec: codec.JSONCodec{}}, notification)
+	if err != nil {
+		t.Fatalf("NewMessage() error = %v", err)
+	}
+	return consumedMessage(t, produced, 0, offset)
+}
+
+// notification có tenant trong payload khác header X-Tenant-ID bị chuyển sang DLQ của tenant trong header,
+// không bao giờ được giao vào store của tenant khác
+func TestConsumeClaimTenantMismatchGoesToDLQ(t *testing.T) {
+	dlqProducer := mock.NewSyncProducer()
+	var mu sync.Mutex
+	delivered := map[string][]string{}
+	consumer := &Consumer{
+		handler: func(ctx context.Context, notification models.Notification) error {
+			mu.Lock()
+			defer mu.Unlock()
+			tenantID := tenant.FromContext(ctx)
+			delivered[tenantID] = append(delivered[tenantID], notification.To.TenantID)
+			return nil
+		},
+		DLQProducer:    dlq.NewProducer(dlqProducer, "notifications.dlq"),
+		Concurrency:    worker.NewSemaphore(1),
+		CommitInterval: time.Hour,
+		CommitBatch:    100,
+	}
+
+	acme := newTenantMessage(t, 0, "acme")
+	globex := newTenantMessage(t, 1, "globex")
+	// payload của globex nhưng header nói acme
+	forged := newTenantMessage(t, 2, "globex")
+	for _, header := range forged.Headers {
+		if string(header.Key) == tenant.HeaderTenantID {
+			header.Value = []byte("acme")
+		}
+	}
+	session := newFakeSession(context.Background())
+	consumeClaims(t, consumer, session, newFakeClaim(0, acme, globex, forged))
+
+	if got := delivered["acme"]; len(got) != 1 || got[0] != "acme" {
+		t.Fatalf("delivered to acme = %v, want only the acme notification", got)
+	}
+	if got := delivered["globex"]; len(got) != 1 || got[0] != "globex" {
+		t.Fatalf("delivered to globex = %v, want only the globex notification", got)
+	}
+	messages := dlqProducer.Messages()
+	if len(messages) != 1 || messages[0].Topic != "acme.notifications.dlq" {
+		t.Fatalf("DLQ messages = %v, want one in acme.notifications.dlq", messages)
+	}
+	var reason string
+	for _, header := range messages[0].Headers {
+		if string(header.Key) == dlq.HeaderErrorReason {
+			reason = string(header.Value)
+		}
+	}
+	if reason != tenant.ReasonTenantMismatch {
+		t.Fatalf("DLQ reason = %q, want %q", reason, tenant.ReasonTenantMismatch)
+	}
+	if got := session.committedOffset(0); got != 3 {
+		t.Fatalf("committed offset = %d, want 3", got)
+	}
+}
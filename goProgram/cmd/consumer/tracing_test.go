@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/worker"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// useInMemoryTracer đăng ký TracerProvider global ghi span vào exporter trong bộ nhớ cho tới hết test
+func useInMemoryTracer(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func spanOfKind(t *testing.T, spans tracetest.SpanStubs, kind trace.SpanKind) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.SpanKind == kind {
+			return span
+		}
+	}
+	t.Fatalf("no %s span in %d exported spans", kind, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func TestProducerSpanLinksToConsumerSpan(t *testing.T) {
+	exporter := useInMemoryTracer(t)
+
+	producer := mock.NewSyncProducer()
+	opts := sender.Options{TopicPrefix: "notifications", Codec: codec.JSONCodec{}}
+	notification := models.Notification{
+		ID:       "5f0c7c3e-8f9a-4a57-9d51-3b8a2f8d9e01",
+		From:     models.User{ID: 1, Name: "Alice"},
+		To:       models.User{ID: 2, Name: "Bob"},
+		Message:  "hello",
+		Priority: models.PriorityNormal,
+	}
+	if _, _, err := sender.Send(context.Background(), producer, opts, notification); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	messages := producer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("%d messages sent, want 1", len(messages))
+	}
+
+	consumer := &Consumer{
+		handler:        func(ctx context.Context, notification models.Notification) error { return nil },
+		Concurrency:    worker.NewSemaphore(1),
+		CommitInterval: time.Hour,
+		CommitBatch:    100,
+	}
+	consumeClaims(t, consumer, newFakeSession(context.Background()),
+		newFakeClaim(0, consumedMessage(t, messages[0], 0, 0)))
+
+	spans := exporter.GetSpans()
+	producerSpan := spanOfKind(t, spans, trace.SpanKindProducer)
+	consumerSpan := spanOfKind(t, spans, trace.SpanKindConsumer)
+	if producerSpan.Name != testTopic+" send" || consumerSpan.Name != testTopic+" process" {
+		t.Fatalf("span names = %q, %q", producerSpan.Name, consumerSpan.Name)
+	}
+	if consumerSpan.SpanContext.TraceID() != producerSpan.SpanContext.TraceID() {
+		t.Fatalf("consumer trace ID = %s, want producer trace ID %s",
+			consumerSpan.SpanContext.TraceID(), producerSpan.SpanContext.TraceID())
+	}
+	if consumerSpan.Parent.SpanID() != producerSpan.SpanContext.SpanID() || !consumerSpan.Parent.IsRemote() {
+		t.Fatalf("consumer parent = %s (remote %t), want remote producer span %s",
+			consumerSpan.Parent.SpanID(), consumerSpan.Parent.IsRemote(), producerSpan.SpanContext.SpanID())
+	}
+}
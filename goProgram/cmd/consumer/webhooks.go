@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ============== WEBHOOKS ==============
+
+// Nếu không có REDIS_URL thì webhook chỉ lưu trong bộ nhớ
+func setupWebhookStore(cfg *config.Config) (store.WebhookStore, func() error, error) {
+	if cfg.RedisURL == "" {
+		return store.NewMemoryWebhookStore(), func() error { return nil }, nil
+	}
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.NewRedisWebhookStore(client), client.Close, nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+func validateWebhookURL(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// khi bật JWT, user chỉ quản lý được webhook của chính mình
+func canManageWebhooks(ctx *gin.Context, userID int) bool {
+	authedID, ok := middleware.AuthedUserID(ctx)
+	return !ok || authedID == userID
+}
+
+// createWebhookHandler xử lý POST /webhooks. Secret bỏ trống thì được sinh ngẫu nhiên,
+// đây là lần duy nhất secret được trả về cho client.
+func createWebhookHandler(webhooks store.WebhookStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var webhook models.Webhook
+		if err := ctx.ShouldBindJSON(&webhook); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if userID, ok := middleware.AuthedUserID(ctx); ok && webhook.UserID == 0 {
+			webhook.UserID = userID
+		}
+		if webhook.UserID <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "userID is required"})
+			return
+		}
+		if !canManageWebhooks(ctx, webhook.UserID) {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "cannot register a webhook for another user"})
+			return
+		}
+		if err := validateWebhookURL(webhook.URL); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if webhook.Secret == "" {
+			secret, err := newWebhookSecret()
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+				return
+			}
+			webhook.Secret = secret
+		}
+		webhook.ID = uuid.NewString()
+
+		if err := webhooks.Save(ctx.Request.Context(), webhook); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusCreated, webhook)
+	}
+}
+
+// listWebhooksHandler xử lý GET /webhooks?userID=<id>, không trả về secret.
+func listWebhooksHandler(webhooks store.WebhookStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, ok := middleware.AuthedUserID(ctx)
+		if value := ctx.Query("userID"); value != "" || !ok {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": "userID query parameter must be a number"})
+				return
+			}
+			userID = id
+		}
+		if !canManageWebhooks(ctx, userID) {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "cannot list webhooks of another user"})
+			return
+		}
+
+		found, err := webhooks.FindByUserID(ctx.Request.Context(), userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		for i := range found {
+			found[i].Secret = ""
+		}
+		ctx.JSON(http.StatusOK, gin.H{"webhooks": found, "total": len(found)})
+	}
+}
+
+// deleteWebhookHandler xử lý DELETE /webhooks/:id, khi bật JWT chỉ xoá được webhook của chính mình.
+func deleteWebhookHandler(webhooks store.WebhookStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		if authedID, ok := middleware.AuthedUserID(ctx); ok {
+			owned, err := webhooks.FindByUserID(ctx.Request.Context(), authedID)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+				return
+			}
+			if !containsWebhook(owned, id) {
+				ctx.JSON(http.StatusNotFound, gin.H{"message": store.ErrWebhookNotFound.Error()})
+				return
+			}
+		}
+
+		err := webhooks.Delete(ctx.Request.Context(), id)
+		if errors.Is(err, store.ErrWebhookNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+func containsWebhook(webhooks []models.Webhook, id string) bool {
+	for _, webhook := range webhooks {
+		if webhook.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/hub"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// server gửi ping mỗi wsPingPeriod, client phải trả pong trong wsPongWait
+	wsPingPeriod = 30 * time.Second
+	wsPongWait   = wsPingPeriod + 10*time.Second
+	wsWriteWait  = 10 * time.Second
+	// kích thước tối đa của một message client gửi lên
+	wsMaxMessageBytes = 64 * 1024
+)
+
+var upgrader = websocket.Upgrader{}
+
+// wsEvent là message server gửi xuống client: "notification" khi có notification mới,
+// "sent" hoặc "error" là kết quả của notification client vừa gửi lên.
+type wsEvent struct {
+	Type         string               `json:"type"`
+	Notification *models.Notification `json:"notification,omitempty"`
+	Topic        string               `json:"topic,omitempty"`
+	Partition    int32                `json:"partition,omitempty"`
+	Offset       int64                `json:"offset,omitempty"`
+	Error        string               `json:"error,omitempty"`
+}
+
+// wsSender gom những gì cần để gửi notification client đẩy lên qua WebSocket.
+type wsSender struct {
+	producer sarama.SyncProducer
+	opts     sender.Options
+	users    store.UserStore
+}
+
+// send dùng ID của kết nối làm người gửi, chỉ lấy To.ID, Message và Priority từ client.
+func (s wsSender) send(ctx context.Context, fromID int, incoming models.Notification) wsEvent {
+	priority := incoming.Priority
+	if priority == 0 {
+		priority = models.PriorityNormal
+	}
+	notification, err := sender.BuildNotification(ctx, s.users, fromID, incoming.To.ID, incoming.Message, priority)
+	if err != nil {
+		return wsEvent{Type: "error", Error: err.Error()}
+	}
+	partition, offset, err := sender.Send(ctx, s.producer, s.opts, notification,
+		kafka.Header(kafka.HeaderCorrelationID, uuid.NewString()))
+	if err != nil {
+		log.Error().Err(err).Int("fromID", fromID).Int("toID", notification.To.ID).Msg("failed to send notification")
+		return wsEvent{Type: "error", Error: err.Error()}
+	}
+	return wsEvent{
+		Type:      "sent",
+		Topic:     s.opts.TopicFor(notification),
+		Partition: partition,
+		Offset:    offset,
+	}
+}
+
+// wsUserID lấy user của kết nối từ JWT nếu có, nếu không thì từ query userID.
+func wsUserID(ctx *gin.Context) (int, error) {
+	if userID, ok := middleware.AuthedUserID(ctx); ok {
+		return userID, nil
+	}
+	return strconv.Atoi(ctx.Query("userID"))
+}
+
+// websocketHandler xử lý GET /ws?userID=1: server đẩy notification mới của user xuống,
+// đồng thời client có thể gửi notification lên trên cùng kết nối.
+func websocketHandler(h *hub.Hub, s wsSender, shutdown <-chan struct{}) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, err := wsUserID(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "userID query parameter must be a number"})
+			return
+		}
+
+		conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+		if err != nil {
+			// Upgrade đã tự trả lỗi HTTP cho client
+			log.Warn().Err(err).Int("userID", userID).Msg("failed to upgrade websocket")
+			return
+		}
+		defer conn.Close()
+
+		notes, unregister := h.Register(userID)
+		defer unregister()
+
+		// gorilla/websocket chỉ cho một goroutine ghi, nên goroutine đọc
+		// chuyển kết quả gửi qua replies để vòng lặp bên dưới ghi thay.
+		replies := make(chan wsEvent, 1)
+		readDone := make(chan struct{})
+		go func() {
+			defer close(readDone)
+			conn.SetReadLimit(wsMaxMessageBytes)
+			conn.SetReadDeadline(time.Now().Add(wsPongWait))
+			conn.SetPongHandler(func(string) error {
+				return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+			})
+			for {
+				var incoming models.Notification
+				if err := conn.ReadJSON(&incoming); err != nil {
+					if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+						log.Warn().Err(err).Int("userID", userID).Msg("websocket read failed")
+					}
+					return
+				}
+				select {
+				case replies <- s.send(ctx.Request.Context(), userID, incoming):
+				case <-shutdown:
+					return
+				}
+			}
+		}()
+
+		ping := time.NewTicker(wsPingPeriod)
+		defer ping.Stop()
+		for {
+			var event wsEvent
+			select {
+			case <-readDone:
+				return
+			case <-shutdown:
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+					time.Now().Add(wsWriteWait))
+				return
+			case <-ping.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+					return
+				}
+				continue
+			case reply := <-replies:
+				event = reply
+			case notification, ok := <-notes:
+				if !ok {
+					return
+				}
+				event = wsEvent{Type: "notification", Notification: &notification}
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/hub"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+const testJWTSecret = "test-secret"
+
+// newWebsocketServer chạy GET /ws trên httptest server, secret khác rỗng thì bật JWT như main
+func newWebsocketServer(t *testing.T, h *hub.Hub, producer *mock.SyncProducer, secret string) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	s := wsSender{
+		producer: producer,
+		opts:     sender.Options{TopicPrefix: "notifications", Codec: codec.JSONCodec{}},
+		users:    store.NewMemoryUserStore(models.User{ID: 1, Name: "Alice"}, models.User{ID: 2, Name: "Bob"}),
+	}
+	shutdown := make(chan struct{})
+	engine := gin.New()
+	if secret != "" {
+		engine.GET("/ws", middleware.JWTAuthMiddleware(secret), websocketHandler(h, s, shutdown))
+	} else {
+		engine.GET("/ws", websocketHandler(h, s, shutdown))
+	}
+	server := httptest.NewServer(engine)
+	t.Cleanup(func() {
+		close(shutdown)
+		server.Close()
+	})
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+}
+
+func dialWebsocket(t *testing.T, url string, header http.Header) *websocket.Conn {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	resp.Body.Close()
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readEvent(t *testing.T, conn *websocket.Conn) wsEvent {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var event wsEvent
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+	return event
+}
+
+func TestWebsocketSendAndReceive(t *testing.T) {
+	h := hub.New()
+	producer := mock.NewSyncProducer()
+	conn := dialWebsocket(t, newWebsocketServer(t, h, producer, "")+"?userID=1", nil)
+
+	// From do client gửi bị bỏ qua, người gửi là user của kết nối
+	if err := conn.WriteJSON(models.Notification{From: models.User{ID: 2}, To: models.User{ID: 2}, Message: "hi"}); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	if event := readEvent(t, conn); event.Type != "sent" || event.Topic != "notifications.normal" {
+		t.Fatalf("event = %+v, want sent to notifications.normal", event)
+	}
+	messages := producer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("%d messages sent, want 1", len(messages))
+	}
+	var sent models.Notification
+	payload, _ := messages[0].Value.Encode()
+	if err := json.Unmarshal(payload, &sent); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if sent.From.ID != 1 || sent.To.ID != 2 || sent.Message != "hi" {
+		t.Fatalf("sent notification = %+v, want from 1 to 2", sent)
+	}
+
+	// kết nối đã nhận reply nên chắc chắn đã đăng ký với hub
+	h.Broadcast(models.Notification{ID: "n-1", From: models.User{ID: 2}, To: models.User{ID: 1}, Message: "hello"})
+	event := readEvent(t, conn)
+	if event.Type != "notification" || event.Notification == nil || event.Notification.ID != "n-1" {
+		t.Fatalf("event = %+v, want notification n-1", event)
+	}
+
+	if err := conn.WriteJSON(models.Notification{To: models.User{ID: 99}, Message: "hi"}); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	if event := readEvent(t, conn); event.Type != "error" || event.Error == "" {
+		t.Fatalf("event = %+v, want error for unknown recipient", event)
+	}
+}
+
+// khi bật JWT, người gửi lấy từ user_id của token, query userID không dùng được để giả danh
+func TestWebsocketSenderFromToken(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	url := newWebsocketServer(t, hub.New(), producer, testJWTSecret)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 2}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"?userID=1", nil); err == nil {
+		t.Fatal("Dial() without token succeeded")
+	} else if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Dial() without token = %v, want 401", err)
+	}
+
+	conn := dialWebsocket(t, url+"?userID=1", http.Header{"Authorization": {"Bearer " + token}})
+	if err := conn.WriteJSON(models.Notification{To: models.User{ID: 1}, Message: "hi"}); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	if event := readEvent(t, conn); event.Type != "sent" {
+		t.Fatalf("event = %+v, want sent", event)
+	}
+	var sent models.Notification
+	payload, _ := producer.Messages()[0].Value.Encode()
+	json.Unmarshal(payload, &sent)
+	if sent.From.ID != 2 {
+		t.Fatalf("sender = %d, want user 2 from the token", sent.From.ID)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/logger"
+	"kafka-notify/pkg/rpc/pb"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/tracing"
+	"net"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// log được khởi tạo trong main sau khi đọc LOG_LEVEL/LOG_FORMAT
+var log zerolog.Logger
+
+// thời gian tối đa chờ các RPC đang xử lý hoàn thành khi shutdown
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("failed to load config: %v", err))
+	}
+	if err := logger.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		panic(fmt.Sprintf("failed to setup logger: %v", err))
+	}
+	log = logger.Component("grpc-producer")
+
+	tracerProvider, err := tracing.SetupTracer("kafka-notify-grpc-producer")
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize tracer")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	users, closeUsers, err := store.OpenUserStore(context.Background(), cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize user store")
+	}
+	defer closeUsers()
+
+	if cfg.SchemaRegistryURL != "" {
+		if err := codec.SetupAvro(cfg.SchemaRegistryURL, cfg.SchemaRegistrySubject, cfg.AvroCompatibility); err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize avro codec")
+		}
+	}
+	notificationCodec, err := codec.New(cfg.NotificationEncoding)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize codec")
+	}
+	opts := sender.NewOptions(cfg, notificationCodec)
+
+	producer, err := kafka.SetupProducer(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize producer")
+	}
+
+	listener, err := net.Listen("tcp", cfg.GRPCPort)
+	if err != nil {
+		log.Fatal().Err(err).Str("addr", cfg.GRPCPort).Msg("failed to listen")
+	}
+	grpcServer := grpc.NewServer()
+	pb.RegisterNotificationServiceServer(grpcServer, newNotificationServer(producer, opts, users))
+
+	log.Info().
+		Str("topicPrefix", cfg.KafkaTopicPrefix).
+		Msgf("Kafka gRPC PRODUCER 📨 started at localhost%s", cfg.GRPCPort)
+
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Error().Err(err).Msg("failed to run the server")
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info().Msg("shutdown: signal received, stopping gRPC server")
+	// GracefulStop chờ các RPC đang chạy, quá shutdownTimeout thì dừng hẳn
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		log.Error().Msg("shutdown: failed to stop gRPC server gracefully")
+		grpcServer.Stop()
+	}
+
+	log.Info().Msg("shutdown: flushing and closing producer")
+	if err := producer.Close(); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to close producer")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	log.Info().Msg("shutdown: flushing traces")
+	if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to flush traces")
+	}
+	log.Info().Msg("shutdown: complete")
+}
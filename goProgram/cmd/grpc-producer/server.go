@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/middleware"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/metrics"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/rpc/pb"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/tracing"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// số notification tối đa trong một lời gọi SendBatch, giống POST /send/batch
+const maxBatchSize = 100
+
+// notificationServer cài đặt pb.NotificationServiceServer, dùng chung
+// phần build và gửi message với HTTP producer qua package sender.
+type notificationServer struct {
+	pb.UnimplementedNotificationServiceServer
+	producer sarama.SyncProducer
+	opts     sender.Options
+	users    store.UserStore
+}
+
+func newNotificationServer(producer sarama.SyncProducer, opts sender.Options, users store.UserStore) *notificationServer {
+	return &notificationServer{producer: producer, opts: opts, users: users}
+}
+
+func (s *notificationServer) SendNotification(ctx context.Context, req *pb.SendRequest) (*pb.SendResponse, error) {
+	start := time.Now()
+	corrID := correlationID(ctx)
+	ctx = middleware.WithCorrelationID(ctx, corrID)
+	notification, err := s.buildNotification(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	partition, offset, err := sender.Send(ctx, s.producer, s.opts, notification,
+		kafka.Header(kafka.HeaderCorrelationID, corrID))
+	metrics.ObserveSend(start, err)
+	if err != nil {
+		log.Error().Err(err).
+			Int("fromID", notification.From.ID).
+			Int("toID", notification.To.ID).
+			Msg("failed to send notification")
+		return nil, toStatus(err)
+	}
+	return &pb.SendResponse{
+		Topic:     s.opts.TopicFor(notification),
+		Partition: partition,
+		Offset:    offset,
+	}, nil
+}
+
+// SendBatch giống POST /send/batch: item lỗi được liệt kê theo index trong Failed,
+// các item còn lại vẫn được gửi trong một lần producer.SendMessages.
+func (s *notificationServer) SendBatch(ctx context.Context, req *pb.SendBatchRequest) (*pb.SendBatchResponse, error) {
+	if len(req.GetNotifications()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "notifications must not be empty")
+	}
+	if len(req.GetNotifications()) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"batch size %d exceeds the limit of %d", len(req.GetNotifications()), maxBatchSize)
+	}
+
+	spanCtx, span := tracing.StartProducerSpan(ctx, s.opts.TopicPrefix)
+	defer span.End()
+
+	corrID := correlationID(ctx)
+	ctx = middleware.WithCorrelationID(ctx, corrID)
+	var failed []*pb.BatchFailure
+	msgs := make([]*sarama.ProducerMessage, 0, len(req.GetNotifications()))
+	indexes := make(map[*sarama.ProducerMessage]int, len(req.GetNotifications()))
+	for i, item := range req.GetNotifications() {
+		notification, err := s.buildNotification(ctx, item)
+		if err != nil {
+			failed = append(failed, &pb.BatchFailure{Index: int32(i), Error: err.Error()})
+			continue
+		}
+		msg, err := sender.NewMessage(ctx, s.opts, notification, kafka.Header(kafka.HeaderCorrelationID, corrID))
+		if err != nil {
+			failed = append(failed, &pb.BatchFailure{Index: int32(i), Error: err.Error()})
+			continue
+		}
+		tracing.InjectProducerMessage(spanCtx, msg)
+		msgs = append(msgs, msg)
+		indexes[msg] = i
+	}
+
+	if len(msgs) > 0 {
+		err := s.producer.SendMessages(msgs)
+		var producerErrs sarama.ProducerErrors
+		if errors.As(err, &producerErrs) {
+			for _, producerErr := range producerErrs {
+				failed = append(failed, &pb.BatchFailure{
+					Index: int32(indexes[producerErr.Msg]),
+					Error: producerErr.Err.Error(),
+				})
+			}
+		} else if err != nil {
+			tracing.RecordError(span, err)
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		tracing.RecordError(span, fmt.Errorf("%d of %d notifications failed", len(failed), len(req.GetNotifications())))
+	}
+	return &pb.SendBatchResponse{
+		Sent:   int32(len(req.GetNotifications()) - len(failed)),
+		Failed: failed,
+	}, nil
+}
+
+func (s *notificationServer) buildNotification(ctx context.Context, req *pb.SendRequest) (models.Notification, error) {
+	priority := int(req.GetPriority())
+	if priority == 0 {
+		priority = models.PriorityNormal
+	}
+	return sender.BuildNotification(ctx, s.users,
+		int(req.GetFromId()), int(req.GetToId()), req.GetMessage(), priority)
+}
+
+// correlationID lấy từ metadata x-correlation-id của client, sinh mới nếu không có.
+func correlationID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(strings.ToLower(kafka.HeaderCorrelationID)); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return uuid.NewString()
+}
+
+// toStatus ánh xạ lỗi sang gRPC status code giống cách HTTP handler ánh xạ sang status code.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, &apperrors.ErrUserNotFound{}):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, models.ErrInvalidNotification):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, &apperrors.ErrMessageTooLarge{}):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, &apperrors.ErrBrokerUnreachable{}):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
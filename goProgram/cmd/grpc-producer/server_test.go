@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"kafka-notify/pkg/client"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/rpc/pb"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"net"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient chạy notificationServer trên bufconn và trả về pkg/client kết nối tới nó
+func newTestClient(t *testing.T, producer *mock.SyncProducer) *client.Client {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	users := store.NewMemoryUserStore(models.User{ID: 1, Name: "Alice"}, models.User{ID: 2, Name: "Bob"})
+	opts := sender.Options{TopicPrefix: "notifications", Codec: codec.JSONCodec{}}
+	pb.RegisterNotificationServiceServer(server, newNotificationServer(producer, opts, users))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	c, err := client.Dial("bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClientSend(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	c := newTestClient(t, producer)
+	const correlationID = "3c6e0b8a-9c0b-4f5c-8c1d-2f6a7d9e0b1c"
+
+	resp, err := c.Send(client.WithCorrelationID(context.Background(), correlationID), 1, 2, "hello", models.PriorityCritical)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if resp.GetTopic() != "notifications.critical" || resp.GetOffset() != 0 {
+		t.Fatalf("response = %v, want notifications.critical offset 0", resp)
+	}
+	messages := producer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("%d messages sent, want 1", len(messages))
+	}
+	var notification models.Notification
+	payload, _ := messages[0].Value.Encode()
+	if err := json.Unmarshal(payload, &notification); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if notification.From.ID != 1 || notification.To.ID != 2 || notification.Message != "hello" {
+		t.Fatalf("sent notification = %+v", notification)
+	}
+	var got string
+	for _, header := range messages[0].Headers {
+		if string(header.Key) == kafka.HeaderCorrelationID {
+			got = string(header.Value)
+		}
+	}
+	if got != correlationID {
+		t.Fatalf("correlation ID header = %q, want %q", got, correlationID)
+	}
+}
+
+func TestClientSendErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		toID     int
+		message  string
+		sendErr  error
+		wantCode codes.Code
+	}{
+		{name: "unknown recipient", toID: 99, message: "hello", wantCode: codes.NotFound},
+		{name: "empty message", toID: 2, wantCode: codes.InvalidArgument},
+		{name: "broker unreachable", toID: 2, message: "hello", sendErr: sarama.ErrOutOfBrokers, wantCode: codes.Unavailable},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			producer := mock.NewSyncProducer()
+			if tt.sendErr != nil {
+				producer.ErrorFor = func(string) error { return tt.sendErr }
+			}
+			_, err := newTestClient(t, producer).Send(context.Background(), 1, tt.toID, tt.message, 0)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Send() error = %v, want code %s", err, tt.wantCode)
+			}
+			if len(producer.Messages()) != 0 {
+				t.Fatalf("%d messages sent, want none", len(producer.Messages()))
+			}
+		})
+	}
+}
+
+func TestClientSendBatch(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	c := newTestClient(t, producer)
+
+	resp, err := c.SendBatch(context.Background(),
+		&pb.SendRequest{FromId: 1, ToId: 2, Message: "one"},
+		&pb.SendRequest{FromId: 1, ToId: 99, Message: "two"},
+		&pb.SendRequest{FromId: 1, ToId: 2, Message: "three"})
+	if err != nil {
+		t.Fatalf("SendBatch() error = %v", err)
+	}
+	if resp.GetSent() != 2 || len(resp.GetFailed()) != 1 || resp.GetFailed()[0].GetIndex() != 1 {
+		t.Fatalf("response = %v, want 2 sent and index 1 failed", resp)
+	}
+	if got := len(producer.Messages()); got != 2 {
+		t.Fatalf("%d messages sent, want 2", got)
+	}
+
+	tooMany := make([]*pb.SendRequest, maxBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = &pb.SendRequest{FromId: 1, ToId: 2, Message: "hi"}
+	}
+	if _, err := c.SendBatch(context.Background(), tooMany...); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("SendBatch(%d) error = %v, want InvalidArgument", len(tooMany), err)
+	}
+}
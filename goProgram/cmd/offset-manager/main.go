@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/logger"
+	"os"
+
+	"github.com/IBM/sarama"
+	"github.com/rs/zerolog"
+)
+
+// log được khởi tạo trong main sau khi đọc LOG_LEVEL/LOG_FORMAT
+var log zerolog.Logger
+
+// offset-manager đổi offset đã commit của một consumer group mà không cần Kafka CLI, ví dụ:
+//
+//	offset-manager --topic notifications.normal --to-oldest --dry-run
+//	offset-manager --group notifications-group --topic notifications.high --partition 0 --offset 42
+//
+// Kết nối Kafka (broker, TLS, SASL) đọc từ biến môi trường như các service khác, report in ra stdout.
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("failed to load config: %v", err))
+	}
+	if err := logger.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		panic(fmt.Sprintf("failed to setup logger: %v", err))
+	}
+	log = logger.Component("offset-manager")
+
+	group := flag.String("group", cfg.ConsumerGroupID, "consumer group cần đổi offset")
+	topic := flag.String("topic", "", "topic cần đổi offset (bắt buộc)")
+	partition := flag.Int("partition", -1, "partition cần đổi, -1 là mọi partition")
+	offset := flag.Int64("offset", -1, "offset mới")
+	toOldest := flag.Bool("to-oldest", false, "đặt về offset cũ nhất còn lưu")
+	toLatest := flag.Bool("to-latest", false, "đặt về high watermark (bỏ qua mọi message chưa đọc)")
+	dryRun := flag.Bool("dry-run", false, "chỉ in thay đổi, không commit")
+	flag.Parse()
+
+	if *topic == "" {
+		log.Fatal().Msg("--topic is required")
+	}
+	target, err := parseTarget(*offset, *toOldest, *toLatest)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid target")
+	}
+
+	client, err := setupClient(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize kafka client")
+	}
+	defer client.Close()
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize admin client")
+	}
+
+	report, err := NewOffsetResetter(client, admin).Reset(*group, *topic, int32(*partition), target, *dryRun)
+	if err != nil {
+		log.Fatal().Err(err).Str("group", *group).Str("topic", *topic).Msg("failed to reset offsets")
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatal().Err(err).Msg("failed to write report")
+	}
+}
+
+// parseTarget yêu cầu đúng một trong --offset, --to-oldest, --to-latest.
+func parseTarget(offset int64, toOldest, toLatest bool) (Target, error) {
+	count := 0
+	for _, set := range []bool{offset >= 0, toOldest, toLatest} {
+		if set {
+			count++
+		}
+	}
+	if count != 1 {
+		return Target{}, fmt.Errorf("%w: exactly one of --offset, --to-oldest, --to-latest is required", ErrInvalidTarget)
+	}
+	switch {
+	case toOldest:
+		return Target{Offset: sarama.OffsetOldest}, nil
+	case toLatest:
+		return Target{Offset: sarama.OffsetNewest}, nil
+	default:
+		return Target{Offset: offset}, nil
+	}
+}
+
+// setupClient bật Return.Errors để lỗi commit của OffsetManager được trả về thay vì chỉ log.
+func setupClient(cfg *config.Config) (sarama.Client, error) {
+	config := sarama.NewConfig()
+	// DescribeConsumerGroups cần protocol mới hơn mặc định của sarama, giống admin.NewClient
+	config.Version = sarama.V2_1_0_0
+	config.Consumer.Return.Errors = true
+	if err := kafka.ApplySecurity(config, cfg); err != nil {
+		return nil, err
+	}
+	return sarama.NewClient(cfg.KafkaBrokers, config)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+var (
+	ErrGroupActive   = errors.New("consumer group is active, stop its members before resetting offsets")
+	ErrInvalidTarget = errors.New("invalid target offset")
+)
+
+// các trạng thái group cho phép reset offset: không còn member nào đang giữ partition
+var inactiveGroupStates = map[string]bool{"Empty": true, "Dead": true, "": true}
+
+// Target là vị trí offset mới: Offset >= 0, hoặc sarama.OffsetOldest / sarama.OffsetNewest.
+type Target struct {
+	Offset int64
+}
+
+// PartitionChange là một dòng trong report, OldOffset = -1 nghĩa là group chưa commit partition này.
+type PartitionChange struct {
+	Partition int32 `json:"partition"`
+	OldOffset int64 `json:"oldOffset"`
+	NewOffset int64 `json:"newOffset"`
+}
+
+// Report được in ra stdout dạng JSON sau khi reset (hoặc dry run).
+type Report struct {
+	Group      string            `json:"group"`
+	Topic      string            `json:"topic"`
+	DryRun     bool              `json:"dryRun"`
+	Partitions []PartitionChange `json:"partitions"`
+}
+
+// OffsetResetter đổi offset đã commit của một consumer group trên các partition của topic.
+type OffsetResetter struct {
+	client sarama.Client
+	admin  sarama.ClusterAdmin
+}
+
+func NewOffsetResetter(client sarama.Client, admin sarama.ClusterAdmin) *OffsetResetter {
+	return &OffsetResetter{client: client, admin: admin}
+}
+
+// Reset đặt offset của group trên topic/partition (partition < 0 là mọi partition) về target.
+// Group đang có member thì trả về ErrGroupActive vì member sẽ commit đè offset mới.
+// dryRun chỉ tính report mà không commit.
+func (r *OffsetResetter) Reset(group, topic string, partition int32, target Target, dryRun bool) (Report, error) {
+	report := Report{Group: group, Topic: topic, DryRun: dryRun}
+	if err := r.checkInactive(group); err != nil {
+		return report, err
+	}
+
+	partitions := []int32{partition}
+	if partition < 0 {
+		all, err := r.client.Partitions(topic)
+		if err != nil {
+			return report, fmt.Errorf("failed to list partitions of %s: %w", topic, err)
+		}
+		partitions = all
+	}
+
+	committed, err := r.admin.ListConsumerGroupOffsets(group, map[string][]int32{topic: partitions})
+	if err != nil {
+		return report, fmt.Errorf("failed to list consumer group offsets: %w", err)
+	}
+	for _, p := range partitions {
+		newOffset, err := r.resolve(topic, p, target)
+		if err != nil {
+			return report, err
+		}
+		oldOffset := int64(-1)
+		if block := committed.GetBlock(topic, p); block != nil {
+			oldOffset = block.Offset
+		}
+		report.Partitions = append(report.Partitions, PartitionChange{Partition: p, OldOffset: oldOffset, NewOffset: newOffset})
+	}
+	if dryRun {
+		return report, nil
+	}
+	return report, r.commit(group, topic, report.Partitions)
+}
+
+func (r *OffsetResetter) checkInactive(group string) error {
+	groups, err := r.admin.DescribeConsumerGroups([]string{group})
+	if err != nil {
+		return fmt.Errorf("failed to describe consumer group %s: %w", group, err)
+	}
+	for _, description := range groups {
+		if !inactiveGroupStates[description.State] || len(description.Members) > 0 {
+			return fmt.Errorf("%w: %s is %s with %d members", ErrGroupActive, group, description.State, len(description.Members))
+		}
+	}
+	return nil
+}
+
+// resolve đổi target sang offset tuyệt đối và kiểm tra nó nằm trong [oldest, high watermark].
+func (r *OffsetResetter) resolve(topic string, partition int32, target Target) (int64, error) {
+	oldest, err := r.client.GetOffset(topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get oldest offset of %s/%d: %w", topic, partition, err)
+	}
+	newest, err := r.client.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get high watermark of %s/%d: %w", topic, partition, err)
+	}
+	switch target.Offset {
+	case sarama.OffsetOldest:
+		return oldest, nil
+	case sarama.OffsetNewest:
+		return newest, nil
+	}
+	if target.Offset < oldest || target.Offset > newest {
+		return 0, fmt.Errorf("%w: %d is outside [%d, %d] on %s/%d",
+			ErrInvalidTarget, target.Offset, oldest, newest, topic, partition)
+	}
+	return target.Offset, nil
+}
+
+// commit dùng OffsetManager của group: ResetOffset chỉ lùi và MarkOffset chỉ tiến,
+// nên chọn theo chiều thay đổi của từng partition.
+func (r *OffsetResetter) commit(group, topic string, changes []PartitionChange) error {
+	manager, err := sarama.NewOffsetManagerFromClient(group, r.client)
+	if err != nil {
+		return fmt.Errorf("failed to setup offset manager: %w", err)
+	}
+	managers := make([]sarama.PartitionOffsetManager, 0, len(changes))
+	for _, change := range changes {
+		pom, err := manager.ManagePartition(topic, change.Partition)
+		if err != nil {
+			manager.Close()
+			return fmt.Errorf("failed to manage %s/%d: %w", topic, change.Partition, err)
+		}
+		current, _ := pom.NextOffset()
+		if change.NewOffset < current {
+			pom.ResetOffset(change.NewOffset, "")
+		} else {
+			pom.MarkOffset(change.NewOffset, "")
+		}
+		managers = append(managers, pom)
+	}
+	manager.Commit()
+	// Close flush lần cuối rồi đóng channel Errors của từng partition
+	closeErr := manager.Close()
+
+	var errs []error
+	if closeErr != nil {
+		errs = append(errs, fmt.Errorf("failed to commit offsets: %w", closeErr))
+	}
+	for _, pom := range managers {
+		for err := range pom.Errors() {
+			errs = append(errs, fmt.Errorf("failed to commit offsets: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+const testGroup, testTopic = "notifications-group", "notifications.normal"
+
+// fakeClient chỉ cài Partitions và GetOffset: mỗi partition có offset [oldest, newest)
+type fakeClient struct {
+	sarama.Client
+
+	oldest, newest int64
+	partitions     []int32
+}
+
+func (c *fakeClient) Partitions(topic string) ([]int32, error) { return c.partitions, nil }
+
+func (c *fakeClient) GetOffset(topic string, partition int32, time int64) (int64, error) {
+	if time == sarama.OffsetOldest {
+		return c.oldest, nil
+	}
+	return c.newest, nil
+}
+
+// fakeAdmin trả về trạng thái group và offset đã commit cho trước
+type fakeAdmin struct {
+	sarama.ClusterAdmin
+
+	state     string
+	members   int
+	committed map[int32]int64
+}
+
+func (a *fakeAdmin) DescribeConsumerGroups(groups []string) ([]*sarama.GroupDescription, error) {
+	members := make(map[string]*sarama.GroupMemberDescription, a.members)
+	for i := 0; i < a.members; i++ {
+		members["member-"+strconv.Itoa(i)] = &sarama.GroupMemberDescription{}
+	}
+	return []*sarama.GroupDescription{{GroupId: groups[0], State: a.state, Members: members}}, nil
+}
+
+func (a *fakeAdmin) ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+	response := &sarama.OffsetFetchResponse{}
+	for partition, offset := range a.committed {
+		response.AddBlock(testTopic, partition, &sarama.OffsetFetchResponseBlock{Offset: offset})
+	}
+	return response, nil
+}
+
+func newTestResetter(state string, members int) *OffsetResetter {
+	client := &fakeClient{oldest: 10, newest: 100, partitions: []int32{0, 1}}
+	admin := &fakeAdmin{state: state, members: members, committed: map[int32]int64{0: 40}}
+	return NewOffsetResetter(client, admin)
+}
+
+func TestResetDryRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		partition int32
+		target    Target
+		want      []PartitionChange
+	}{
+		{
+			name:      "to oldest on all partitions",
+			partition: -1,
+			target:    Target{Offset: sarama.OffsetOldest},
+			want:      []PartitionChange{{Partition: 0, OldOffset: 40, NewOffset: 10}, {Partition: 1, OldOffset: -1, NewOffset: 10}},
+		},
+		{
+			name:      "to latest on one partition",
+			partition: 0,
+			target:    Target{Offset: sarama.OffsetNewest},
+			want:      []PartitionChange{{Partition: 0, OldOffset: 40, NewOffset: 100}},
+		},
+		{
+			name:      "absolute offset",
+			partition: 1,
+			target:    Target{Offset: 55},
+			want:      []PartitionChange{{Partition: 1, OldOffset: -1, NewOffset: 55}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// dry run không commit nên không cần OffsetManager của client thật
+			report, err := newTestResetter("Empty", 0).Reset(testGroup, testTopic, tt.partition, tt.target, true)
+			if err != nil {
+				t.Fatalf("Reset() error = %v", err)
+			}
+			if !report.DryRun || report.Group != testGroup || report.Topic != testTopic {
+				t.Fatalf("report = %+v, want dry run of %s on %s", report, testGroup, testTopic)
+			}
+			if !reflect.DeepEqual(report.Partitions, tt.want) {
+				t.Fatalf("partitions = %+v, want %+v", report.Partitions, tt.want)
+			}
+		})
+	}
+}
+
+func TestResetRejectsActiveGroup(t *testing.T) {
+	_, err := newTestResetter("Stable", 2).Reset(testGroup, testTopic, -1, Target{Offset: sarama.OffsetOldest}, true)
+	if !errors.Is(err, ErrGroupActive) {
+		t.Fatalf("Reset() error = %v, want %v", err, ErrGroupActive)
+	}
+}
+
+func TestResetRejectsOffsetOutOfRange(t *testing.T) {
+	for _, offset := range []int64{9, 101} {
+		_, err := newTestResetter("Empty", 0).Reset(testGroup, testTopic, 0, Target{Offset: offset}, true)
+		if !errors.Is(err, ErrInvalidTarget) {
+			t.Fatalf("Reset(offset %d) error = %v, want %v", offset, err, ErrInvalidTarget)
+		}
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		name               string
+		offset             int64
+		toOldest, toLatest bool
+		want               int64
+		wantErr            bool
+	}{
+		{name: "offset", offset: 42, want: 42},
+		{name: "to oldest", offset: -1, toOldest: true, want: sarama.OffsetOldest},
+		{name: "to latest", offset: -1, toLatest: true, want: sarama.OffsetNewest},
+		{name: "none", offset: -1, wantErr: true},
+		{name: "several", offset: 42, toLatest: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := parseTarget(tt.offset, tt.toOldest, tt.toLatest)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTarget) {
+					t.Fatalf("parseTarget() error = %v, want %v", err, ErrInvalidTarget)
+				}
+				return
+			}
+			if err != nil || target.Offset != tt.want {
+				t.Fatalf("parseTarget() = %d, %v, want %d", target.Offset, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"kafka-notify/pkg/admin"
+	apperrors "kafka-notify/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============== TOPIC ADMIN API ==============
+
+// registerAdminRoutes đăng ký /admin/topics, group đã được bảo vệ bởi AdminAPIKeyMiddleware.
+func registerAdminRoutes(group *gin.RouterGroup, client *admin.Client) {
+	group.POST("/topics", createTopicHandler(client))
+	group.GET("/topics", listTopicsHandler(client))
+	group.GET("/topics/:name", describeTopicHandler(client))
+	group.DELETE("/topics/:name", deleteTopicHandler(client))
+}
+
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, admin.ErrInvalidTopic):
+		return http.StatusBadRequest
+	case errors.Is(err, &apperrors.ErrTopicNotFound{}):
+		return http.StatusNotFound
+	case errors.Is(err, admin.ErrTopicExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func createTopicHandler(client *admin.Client) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var spec admin.TopicSpec
+		if err := ctx.ShouldBindJSON(&spec); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if err := client.CreateTopic(spec); err != nil {
+			ctx.JSON(adminErrorStatus(err), gin.H{"message": err.Error()})
+			return
+		}
+		log.Info().
+			Str("topic", spec.Name).
+			Int32("numPartitions", spec.NumPartitions).
+			Int16("replicationFactor", spec.ReplicationFactor).
+			Msg("topic created")
+		ctx.JSON(http.StatusCreated, spec)
+	}
+}
+
+func listTopicsHandler(client *admin.Client) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		topics, err := client.ListTopics()
+		if err != nil {
+			ctx.JSON(adminErrorStatus(err), gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"topics": topics})
+	}
+}
+
+func describeTopicHandler(client *admin.Client) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		info, err := client.DescribeTopic(ctx.Param("name"))
+		if err != nil {
+			ctx.JSON(adminErrorStatus(err), gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, info)
+	}
+}
+
+func deleteTopicHandler(client *admin.Client) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		name := ctx.Param("name")
+		if err := client.DeleteTopic(name); err != nil {
+			ctx.JSON(adminErrorStatus(err), gin.H{"message": err.Error()})
+			return
+		}
+		log.Info().Str("topic", name).Msg("topic deleted")
+		ctx.Status(http.StatusNoContent)
+	}
+}
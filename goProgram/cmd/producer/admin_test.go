@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/admin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+)
+
+const testAdminAPIKey = "admin-secret"
+
+// newAdminRouter dựng /admin giống main.go, admin.Client nói chuyện với sarama.MockBroker
+func newAdminRouter(t *testing.T, broker *sarama.MockBroker) *gin.Engine {
+	t.Helper()
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0
+	clusterAdmin, err := sarama.NewClusterAdmin([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatalf("NewClusterAdmin() error = %v", err)
+	}
+	client := admin.NewClientFromAdmin(clusterAdmin)
+	t.Cleanup(func() { client.Close() })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	adminGroup := router.Group("/admin")
+	adminGroup.Use(middleware.AdminAPIKeyMiddleware(testAdminAPIKey))
+	registerAdminRoutes(adminGroup, client)
+	return router
+}
+
+// setAdminBrokerTopics đặt metadata của broker chứa topic với partitions partition, 0 là topic chưa tồn tại
+func setAdminBrokerTopics(t *testing.T, broker *sarama.MockBroker, topic string, partitions int32) {
+	t.Helper()
+	metadata := sarama.NewMockMetadataResponse(t).
+		SetBroker(broker.Addr(), broker.BrokerID()).
+		SetController(broker.BrokerID())
+	for partition := int32(0); partition < partitions; partition++ {
+		metadata.SetLeader(topic, partition, broker.BrokerID())
+	}
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest":     metadata,
+		"CreateTopicsRequest": sarama.NewMockCreateTopicsResponse(t),
+	})
+}
+
+func adminRequest(router *gin.Engine, method, path, apiKey string, body any) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	request := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	request.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		request.Header.Set(middleware.HeaderAdminAPIKey, apiKey)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAdminTopicsCreateAndDescribe(t *testing.T) {
+	const topic = "notifications.campaigns"
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	setAdminBrokerTopics(t, broker, topic, 0)
+	router := newAdminRouter(t, broker)
+
+	spec := admin.TopicSpec{Name: topic, NumPartitions: 3, ReplicationFactor: 1}
+	for _, apiKey := range []string{"", "wrong-key"} {
+		if recorder := adminRequest(router, http.MethodPost, "/admin/topics", apiKey, spec); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("POST /admin/topics with key %q status = %d, want 401", apiKey, recorder.Code)
+		}
+	}
+	if recorder := adminRequest(router, http.MethodGet, "/admin/topics/"+topic, testAdminAPIKey, nil); recorder.Code != http.StatusNotFound {
+		t.Fatalf("describe before create status = %d, want 404: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder := adminRequest(router, http.MethodPost, "/admin/topics", testAdminAPIKey, spec)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("POST /admin/topics status = %d, want 201: %s", recorder.Code, recorder.Body.String())
+	}
+	var createRequests []*sarama.CreateTopicsRequest
+	for _, rr := range broker.History() {
+		if request, ok := rr.Request.(*sarama.CreateTopicsRequest); ok {
+			createRequests = append(createRequests, request)
+		}
+	}
+	if len(createRequests) != 1 {
+		t.Fatalf("broker got %d CreateTopics requests, want 1", len(createRequests))
+	}
+	if detail := createRequests[0].TopicDetails[topic]; detail == nil || detail.NumPartitions != 3 || detail.ReplicationFactor != 1 {
+		t.Fatalf("CreateTopics detail = %+v, want 3 partitions x 1 replica", detail)
+	}
+
+	// broker giả không tự lưu topic, metadata được cập nhật như cluster thật sau khi tạo
+	setAdminBrokerTopics(t, broker, topic, 3)
+	recorder = adminRequest(router, http.MethodGet, "/admin/topics/"+topic, testAdminAPIKey, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET /admin/topics/%s status = %d, want 200: %s", topic, recorder.Code, recorder.Body.String())
+	}
+	var info admin.TopicInfo
+	if err := json.Unmarshal(recorder.Body.Bytes(), &info); err != nil {
+		t.Fatalf("describe response is not JSON: %v", err)
+	}
+	if info.Name != topic || len(info.Partitions) != 3 {
+		t.Fatalf("describe = %+v, want %s with 3 partitions", info, topic)
+	}
+	for i, partition := range info.Partitions {
+		if partition.ID != int32(i) || partition.Leader != broker.BrokerID() {
+			t.Fatalf("partition %d = %+v, want leader %d", i, partition, broker.BrokerID())
+		}
+	}
+}
+
+func TestAdminTopicsCreateInvalid(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	setAdminBrokerTopics(t, broker, "notifications.normal", 1)
+	router := newAdminRouter(t, broker)
+
+	recorder := adminRequest(router, http.MethodPost, "/admin/topics", testAdminAPIKey,
+		admin.TopicSpec{Name: "notifications.invalid", ReplicationFactor: 1})
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", recorder.Code, recorder.Body.String())
+	}
+}
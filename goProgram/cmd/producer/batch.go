@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/tracing"
+	"net/http"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+)
+
+// ============== BATCH SEND ==============
+
+// số notification tối đa trong một request /send/batch
+const maxBatchSize = 100
+
+type batchItem struct {
+	FromID  int    `json:"fromID"`
+	ToID    int    `json:"toID"`
+	Message string `json:"message"`
+	// Priority mặc định là normal nếu bỏ trống
+	Priority int `json:"priority"`
+	// Type không bắt buộc, xem KAFKA_TOPIC_ROUTING
+	Type string `json:"type"`
+	// Metadata được gửi qua Kafka header X-Meta-<key>
+	Metadata map[string]string `json:"metadata"`
+}
+
+type batchSendRequest struct {
+	Notifications []batchItem `json:"notifications"`
+}
+
+type batchFailure struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ErrBatchRejected là lỗi của các item hợp lệ khi transactional producer không gửi batch
+// vì có item khác không hợp lệ: transaction là tất cả hoặc không nên cả batch bị từ chối.
+var ErrBatchRejected = errors.New("batch rejected because another item is invalid")
+
+// batchSender là phần của producer mà /send/batch và /broadcast cần,
+// có thể là sarama.SyncProducer hoặc kafka.TransactionalProducer khi bật KAFKA_TRANSACTIONAL_ID.
+type batchSender interface {
+	SendMessages(msgs []*sarama.ProducerMessage) error
+}
+
+// sendKafkaMessages gửi tất cả message trong một lần gọi producer.SendMessages,
+// sarama gom chúng theo broker nên chỉ tốn một round-trip cho mỗi broker.
+// Lỗi trả về (nếu có) là sarama.ProducerErrors chứa những message gửi thất bại,
+// riêng transactional producer thì mọi lỗi đều nghĩa là cả batch bị huỷ.
+func sendKafkaMessages(producer batchSender, msgs []*sarama.ProducerMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	return producer.SendMessages(msgs)
+}
+
+// isTransactional cho biết producer gửi cả batch trong một Kafka transaction (KAFKA_TRANSACTIONAL_ID),
+// khi đó batch có item không hợp lệ phải bị từ chối trước khi bắt đầu transaction.
+func isTransactional(producer batchSender) bool {
+	_, ok := producer.(*kafka.TransactionalProducer)
+	return ok
+}
+
+// sendBatchHandler xử lý POST /send/batch với body
+// {"notifications":[{"fromID":1,"toID":2,"message":"hi"}, ...]}.
+// Item không hợp lệ hoặc gửi thất bại được liệt kê theo index trong "failed".
+// Với transactional producer, một item không hợp lệ làm cả batch bị từ chối (422, không gửi gì)
+// và lỗi khi gửi nghĩa là transaction đã bị abort, không item nào được gửi.
+func sendBatchHandler(producer batchSender, opts sender.Options, users store.UserStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req batchSendRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if len(req.Notifications) == 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "notifications must not be empty"})
+			return
+		}
+		if len(req.Notifications) > maxBatchSize {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+				"message": fmt.Sprintf("batch size %d exceeds the limit of %d", len(req.Notifications), maxBatchSize),
+			})
+			return
+		}
+
+		// các item có thể thuộc nhiều topic priority khác nhau nên span dùng topic prefix
+		spanCtx, span := tracing.StartProducerSpan(ctx.Request.Context(), opts.TopicPrefix)
+		defer span.End()
+
+		correlationID := middleware.CorrelationID(ctx)
+		authedUserID, _ := middleware.AuthedUserID(ctx)
+
+		var failed []batchFailure
+		msgs := make([]*sarama.ProducerMessage, 0, len(req.Notifications))
+		indexes := make(map[*sarama.ProducerMessage]int, len(req.Notifications))
+		for i, item := range req.Notifications {
+			fromID := item.FromID
+			if fromID == 0 {
+				fromID = authedUserID
+			}
+			priority := item.Priority
+			if priority == 0 {
+				priority = models.PriorityNormal
+			}
+			notification, err := sender.BuildNotification(ctx.Request.Context(), users, fromID, item.ToID, item.Message, priority)
+			if err != nil {
+				failed = append(failed, batchFailure{Index: i, Error: err.Error()})
+				continue
+			}
+			notification.Type = item.Type
+			notification.Metadata = item.Metadata
+			msg, err := sender.NewMessage(ctx.Request.Context(), opts, notification,
+				kafka.Header(kafka.HeaderCorrelationID, correlationID))
+			if err != nil {
+				failed = append(failed, batchFailure{Index: i, Error: err.Error()})
+				continue
+			}
+			tracing.InjectProducerMessage(spanCtx, msg)
+			msgs = append(msgs, msg)
+			indexes[msg] = i
+		}
+
+		if len(failed) > 0 && isTransactional(producer) {
+			tracing.RecordError(span, ErrBatchRejected)
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"message": ErrBatchRejected.Error(), "sent": 0, "failed": failed})
+			return
+		}
+
+		err := sendKafkaMessages(producer, msgs)
+		var producerErrs sarama.ProducerErrors
+		if !isTransactional(producer) && errors.As(err, &producerErrs) {
+			for _, producerErr := range producerErrs {
+				failed = append(failed, batchFailure{Index: indexes[producerErr.Msg], Error: producerErr.Err.Error()})
+			}
+		} else if err != nil {
+			tracing.RecordError(span, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		sent := len(req.Notifications) - len(failed)
+		if len(failed) > 0 {
+			tracing.RecordError(span, fmt.Errorf("%d of %d notifications failed", len(failed), len(req.Notifications)))
+			ctx.JSON(http.StatusMultiStatus, gin.H{"sent": sent, "failed": failed})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"sent": sent, "failed": []batchFailure{}})
+	}
+}
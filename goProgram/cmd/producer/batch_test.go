@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"net/http"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func newBatchRequest(size int) batchSendRequest {
+	req := batchSendRequest{Notifications: make([]batchItem, size)}
+	for i := range req.Notifications {
+		req.Notifications[i] = batchItem{FromID: 1, ToID: 2, Message: "hi"}
+	}
+	return req
+}
+
+func TestSendBatchHandlerSizeLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		wantStatus int
+		wantSent   int
+	}{
+		{name: "empty", size: 0, wantStatus: http.StatusBadRequest},
+		{name: "one", size: 1, wantStatus: http.StatusOK, wantSent: 1},
+		{name: "at limit", size: maxBatchSize, wantStatus: http.StatusOK, wantSent: maxBatchSize},
+		{name: "over limit", size: maxBatchSize + 1, wantStatus: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			producer := mock.NewSyncProducer()
+			handler := sendBatchHandler(producer, newTestOptions(t), newTestUsers())
+
+			recorder := postJSON(handler, "/send/batch", "/send/batch", newBatchRequest(tt.size))
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			body := decodeBody(t, recorder)
+			if tt.wantStatus == http.StatusOK && body["sent"] != float64(tt.wantSent) {
+				t.Fatalf("sent = %v, want %d", body["sent"], tt.wantSent)
+			}
+			if got := len(producer.Messages()); got != tt.wantSent {
+				t.Fatalf("%d messages sent, want %d", got, tt.wantSent)
+			}
+		})
+	}
+}
+
+// item không hợp lệ và item gửi thất bại đều được liệt kê theo index, phần còn lại vẫn được gửi
+func TestSendBatchHandlerPartialSuccess(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	producer.ErrorFor = func(topic string) error {
+		if topic == "notifications.critical" {
+			return sarama.ErrNotLeaderForPartition
+		}
+		return nil
+	}
+	req := newBatchRequest(4)
+	req.Notifications[1].ToID = 99
+	req.Notifications[3].Priority = 4
+
+	recorder := postJSON(sendBatchHandler(producer, newTestOptions(t), newTestUsers()), "/send/batch", "/send/batch", req)
+	if recorder.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want 207: %s", recorder.Code, recorder.Body.String())
+	}
+	body := decodeBody(t, recorder)
+	failed, _ := body["failed"].([]any)
+	var indexes []float64
+	for _, failure := range failed {
+		indexes = append(indexes, failure.(map[string]any)["index"].(float64))
+	}
+	if body["sent"] != float64(2) || len(indexes) != 2 || indexes[0] != 1 || indexes[1] != 3 {
+		t.Fatalf("body = %v, want 2 sent and indexes 1, 3 failed", body)
+	}
+	if got := len(producer.Messages()); got != 2 {
+		t.Fatalf("%d messages sent, want 2", got)
+	}
+}
+
+// newFailingTxnProducer là producer transactional mà lần gửi thứ failAt (tính từ 1) bị lỗi,
+// Messages() chỉ chứa message đã commit, tức là những gì consumer read_committed thấy được
+func newFailingTxnProducer(failAt int) (*kafka.TransactionalProducer, *mock.SyncProducer) {
+	producer := mock.NewSyncProducer()
+	producer.Transactional = true
+	var sends int
+	producer.ErrorFor = func(string) error {
+		sends++
+		if sends == failAt {
+			return sarama.ErrNotEnoughReplicas
+		}
+		return nil
+	}
+	return kafka.NewTransactionalProducer(producer), producer
+}
+
+func TestSendBatchHandlerTransactionalNoPartialDelivery(t *testing.T) {
+	txn, producer := newFailingTxnProducer(3)
+
+	recorder := postJSON(sendBatchHandler(txn, newTestOptions(t), newTestUsers()), "/send/batch", "/send/batch",
+		newBatchRequest(5))
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", recorder.Code, recorder.Body.String())
+	}
+	if got := len(producer.Messages()); got != 0 {
+		t.Fatalf("%d messages committed after a mid-batch failure, want none", got)
+	}
+
+	// item không hợp lệ thì cả batch bị từ chối trước khi bắt đầu transaction
+	req := newBatchRequest(3)
+	req.Notifications[1].ToID = 99
+	recorder = postJSON(sendBatchHandler(txn, newTestOptions(t), newTestUsers()), "/send/batch", "/send/batch", req)
+	if recorder.Code != http.StatusUnprocessableEntity || decodeBody(t, recorder)["sent"] != float64(0) {
+		t.Fatalf("status = %d, want 422 with nothing sent: %s", recorder.Code, recorder.Body.String())
+	}
+	if got := len(producer.Messages()); got != 0 {
+		t.Fatalf("%d messages committed, want none", got)
+	}
+}
+
+func TestSendBroadcastNotificationsTransactionalNoPartialDelivery(t *testing.T) {
+	txn, producer := newFailingTxnProducer(2)
+	users := []models.User{testSender, testRecipient, {ID: 3, Name: "Carol"}, {ID: 4, Name: "Dave"}}
+
+	errs := sendBroadcastNotifications(context.Background(), txn, newTestOptions(t), testSender, "hello",
+		models.PriorityNormal, "", users, nil)
+	if errs[0] != nil {
+		t.Fatalf("sender has error %v, want it skipped", errs[0])
+	}
+	for i, err := range errs[1:] {
+		if !errors.Is(err, kafka.ErrTransactionAborted) {
+			t.Fatalf("recipient %d error = %v, want ErrTransactionAborted", users[i+1].ID, err)
+		}
+	}
+	if got := len(producer.Messages()); got != 0 {
+		t.Fatalf("%d messages committed after a mid-batch failure, want none", got)
+	}
+}
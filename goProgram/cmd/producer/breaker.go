@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/buffer"
+	"kafka-notify/pkg/dedup"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sony/gobreaker"
+)
+
+// ============== CIRCUIT BREAKER ==============
+
+// newSendBreaker mở mạch sau 5 lần gửi lỗi liên tiếp (mặc định của gobreaker),
+// sau Timeout thì cho tối đa MaxRequests request thử lại ở trạng thái half-open.
+func newSendBreaker() *gobreaker.CircuitBreaker {
+	return gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        "kafka-send",
+		MaxRequests: 5,
+		Interval:    60 * time.Second,
+		Timeout:     30 * time.Second,
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			log.Warn().Str("breaker", name).Str("from", from.String()).Str("to", to.String()).
+				Msg("circuit breaker state changed")
+		},
+	})
+}
+
+// isClientError là lỗi do request (user không tồn tại, notification không hợp lệ, trùng hoặc quá lớn),
+// không phải do Kafka nên không được tính vào breaker.
+func isClientError(err error) bool {
+	return errors.Is(err, &apperrors.ErrUserNotFound{}) || errors.Is(err, models.ErrInvalidNotification) ||
+		errors.Is(err, dedup.ErrDuplicateMessage) || errors.Is(err, &apperrors.ErrMessageTooLarge{})
+}
+
+// isBreakerOpen là lỗi breaker trả về khi từ chối request mà không gọi Kafka.
+func isBreakerOpen(err error) bool {
+	return errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests)
+}
+
+// guardedSendKafkaMessage chạy instrumentedSendKafkaMessage bên trong breaker. Khi breaker mở và buf khác nil,
+// notification được ghi vào buf và trả về ID cùng errBuffered.
+func guardedSendKafkaMessage(ctx context.Context, breaker *gobreaker.CircuitBreaker, producer sarama.SyncProducer,
+	buf *buffer.DiskBuffer, opts sender.Options, users store.UserStore, fromID, toID int, message string, priority int,
+	notificationType string, metadata map[string]string, thread threadRef, headers ...sarama.RecordHeader) (string, error) {
+	var clientErr error
+	notificationID, err := breaker.Execute(func() (interface{}, error) {
+		notificationID, err := instrumentedSendKafkaMessage(ctx, producer, opts, users, fromID, toID, message, priority, notificationType, metadata, thread, headers...)
+		if isClientError(err) {
+			clientErr = err
+			return nil, nil
+		}
+		return notificationID, err
+	})
+	if clientErr != nil {
+		return "", clientErr
+	}
+	if isBreakerOpen(err) && buf != nil {
+		notificationID, err := bufferKafkaMessage(ctx, buf, opts, users, fromID, toID, message, priority, notificationType,
+			metadata, thread, headers...)
+		if err != nil {
+			return "", err
+		}
+		return notificationID, errBuffered
+	}
+	if err != nil {
+		return "", err
+	}
+	return notificationID.(string), nil
+}
+
+// guardedSendKafkaMessageAsync từ chối ngay khi breaker mở. Kết quả gửi async chỉ có sau khi handler đã trả về
+// nên breaker không bọc lần gửi mà được cập nhật trong drainAsyncProducer (recordAsyncResult).
+func guardedSendKafkaMessageAsync(ctx context.Context, breaker *gobreaker.CircuitBreaker, producer sarama.AsyncProducer,
+	opts sender.Options, users store.UserStore, fromID, toID int, message string, priority int,
+	notificationType string, metadata map[string]string, thread threadRef, headers ...sarama.RecordHeader) (string, error) {
+	if breaker.State() == gobreaker.StateOpen {
+		return "", gobreaker.ErrOpenState
+	}
+	return sendKafkaMessageAsync(ctx, producer, opts, users, fromID, toID, message, priority, notificationType,
+		metadata, thread, headers...)
+}
+
+// recordAsyncResult ghi kết quả gửi của message async vào breaker
+func recordAsyncResult(breaker *gobreaker.CircuitBreaker, err error) {
+	breaker.Execute(func() (interface{}, error) {
+		return nil, err
+	})
+}
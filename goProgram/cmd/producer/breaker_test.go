@@ -0,0 +1,72 @@
+package main
+
+import (
+	"kafka-notify/pkg/idempotency"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sony/gobreaker"
+)
+
+// lỗi Kafka liên tiếp làm breaker mở, sau đó /send trả 503 ngay mà không gọi producer
+func TestSendMessageHandlerBreakerOpens(t *testing.T) {
+	breaker := newSendBreaker()
+	producer := mock.NewSyncProducer()
+	var attempts atomic.Int64
+	producer.ErrorFor = func(string) error {
+		attempts.Add(1)
+		return sarama.ErrOutOfBrokers
+	}
+	handler := sendMessageHandler(producer, newTestOptions(t), newTestUsers(),
+		idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), breaker, nil)
+	send := func(toID, message string) int {
+		form := url.Values{"fromID": {"1"}, "toID": {toID}, "message": {message}}
+		return postForm(handler, "/send", form).Code
+	}
+
+	// lỗi của client (người nhận không tồn tại) không làm breaker mở
+	for i := 0; i < 10; i++ {
+		if status := send("99", "unknown recipient "+strconv.Itoa(i)); status != http.StatusNotFound {
+			t.Fatalf("unknown recipient status = %d, want 404", status)
+		}
+	}
+	if breaker.State() != gobreaker.StateClosed {
+		t.Fatalf("breaker is %s after client errors, want closed", breaker.State())
+	}
+
+	for i := 0; breaker.State() != gobreaker.StateOpen; i++ {
+		if i == 10 {
+			t.Fatalf("breaker is %s after %d failed sends", breaker.State(), i)
+		}
+		if status := send("2", "hello "+strconv.Itoa(i)); status != http.StatusServiceUnavailable {
+			t.Fatalf("failed send status = %d, want 503", status)
+		}
+	}
+
+	before := attempts.Load()
+	for i := 0; i < 3; i++ {
+		recorder := postForm(handler, "/send", url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"after " + strconv.Itoa(i)}})
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status with open breaker = %d, want 503: %s", recorder.Code, recorder.Body.String())
+		}
+	}
+	if got := attempts.Load() - before; got != 0 {
+		t.Fatalf("producer called %d times with an open breaker, want 0", got)
+	}
+	if len(producer.Messages()) != 0 {
+		t.Fatalf("%d messages sent, want none", len(producer.Messages()))
+	}
+
+	pinger, _ := newMockPinger(t)
+	recorder := getPath(healthHandler(pinger, breaker, time.Now()), "/health")
+	if body := decodeBody(t, recorder); body["circuitBreakerState"] != gobreaker.StateOpen.String() {
+		t.Fatalf("/health = %v, want circuitBreakerState open", body)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"net/http"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ============== BROADCAST ==============
+
+var ErrFanoutTooLarge = errors.New("fanout exceeds the configured limit")
+
+type broadcastResult struct {
+	ToID   int    `json:"toID"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// sendBroadcastNotifications gửi message của from tới mọi user khác trong allUsers
+// bằng một lần producer.SendMessages. errs[i] là kết quả gửi tới allUsers[i],
+// nil nếu gửi thành công hoặc allUsers[i] chính là người gửi (bị bỏ qua).
+// key khác nil thì thay Kafka key mặc định của message gửi tới từng người nhận.
+// Với transactional producer, một message không tạo được thì không gửi message nào và các người nhận
+// còn lại có lỗi ErrBatchRejected; lỗi khi gửi (transaction bị abort) là lỗi của mọi người nhận.
+func sendBroadcastNotifications(ctx context.Context, producer batchSender, opts sender.Options,
+	from models.User, message string, priority int, notificationType string, allUsers []models.User,
+	key func(to models.User) string, headers ...sarama.RecordHeader) []error {
+	errs := make([]error, len(allUsers))
+	msgs := make([]*sarama.ProducerMessage, 0, len(allUsers))
+	indexes := make(map[*sarama.ProducerMessage]int, len(allUsers))
+	for i, to := range allUsers {
+		if to.ID == from.ID {
+			continue
+		}
+		msg, err := sender.NewMessage(ctx, opts, models.Notification{
+			ID:       uuid.NewString(),
+			From:     from,
+			To:       to,
+			Message:  message,
+			Priority: priority,
+			Type:     notificationType,
+		}, headers...)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if key != nil {
+			msg.Key = sarama.StringEncoder(key(to))
+			if err := sender.EnsureKey(opts, msg); err != nil {
+				errs[i] = err
+				continue
+			}
+		}
+		msgs = append(msgs, msg)
+		indexes[msg] = i
+	}
+
+	if isTransactional(producer) && hasErrors(errs) {
+		for _, i := range indexes {
+			errs[i] = ErrBatchRejected
+		}
+		return errs
+	}
+
+	err := sendKafkaMessages(producer, msgs)
+	var producerErrs sarama.ProducerErrors
+	if !isTransactional(producer) && errors.As(err, &producerErrs) {
+		for _, producerErr := range producerErrs {
+			errs[indexes[producerErr.Msg]] = producerErr.Err
+		}
+	} else if err != nil {
+		for _, i := range indexes {
+			errs[i] = err
+		}
+	}
+	return errs
+}
+
+func hasErrors(errs []error) bool {
+	for _, err := range errs {
+		if err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectedErrs là kết quả khi cả batch bị từ chối trước khi gửi
+func rejectedErrs(n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = ErrBatchRejected
+	}
+	return errs
+}
+
+// broadcastStatus là 422 nếu transactional producer đã từ chối cả batch, ngược lại 207 Multi-Status.
+func broadcastStatus(errs []error) int {
+	for _, err := range errs {
+		if errors.Is(err, ErrBatchRejected) {
+			return http.StatusUnprocessableEntity
+		}
+	}
+	return http.StatusMultiStatus
+}
+
+// broadcastHandler xử lý POST /broadcast (form fromID, message, priority, type) và trả về 207 Multi-Status
+// với kết quả của từng người nhận để client có thể gửi lại riêng những người bị lỗi.
+func broadcastHandler(producer batchSender, opts sender.Options,
+	users store.UserStore, maxFanout int) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		fromID, err := getFromIdFromRequest(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		priority, err := getPriorityFromRequest(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		from, err := users.FindByID(ctx.Request.Context(), fromID)
+		if writeAppError(ctx, err) {
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		allUsers, err := users.List(ctx.Request.Context())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		if recipients := len(allUsers) - 1; recipients > maxFanout {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+				"message": fmt.Sprintf("%v: %d recipients, limit is %d", ErrFanoutTooLarge, recipients, maxFanout),
+			})
+			return
+		}
+
+		errs := sendBroadcastNotifications(ctx.Request.Context(), producer, opts, from, ctx.PostForm("message"), priority,
+			ctx.PostForm("type"), allUsers, nil,
+			kafka.Header(kafka.HeaderCorrelationID, middleware.CorrelationID(ctx)))
+
+		results := make([]broadcastResult, 0, len(allUsers))
+		for i, to := range allUsers {
+			if to.ID == from.ID {
+				continue
+			}
+			if errs[i] != nil {
+				results = append(results, broadcastResult{ToID: to.ID, Status: "failed", Error: errs[i].Error()})
+				continue
+			}
+			results = append(results, broadcastResult{ToID: to.ID, Status: "sent"})
+		}
+		ctx.JSON(broadcastStatus(errs), gin.H{"results": results})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/buffer"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/tracing"
+	"net/http"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+	"github.com/sony/gobreaker"
+)
+
+// ============== DISK BUFFER ==============
+
+// errBuffered cho handler biết notification đã được ghi vào disk buffer thay vì gửi lên Kafka
+var errBuffered = errors.New("kafka is unavailable, notification buffered")
+
+// bufferKafkaMessage tạo message giống sendKafkaMessage nhưng ghi vào buf thay vì gửi,
+// dùng khi breaker đang mở. Dedup cache không được kiểm tra cho message trong buffer.
+func bufferKafkaMessage(ctx context.Context, buf *buffer.DiskBuffer, opts sender.Options, users store.UserStore,
+	fromID, toID int, message string, priority int, notificationType string, metadata map[string]string,
+	thread threadRef, headers ...sarama.RecordHeader) (string, error) {
+	notification, err := buildNotification(ctx, users, fromID, toID, message, priority, notificationType, metadata, thread)
+	if err != nil {
+		return "", err
+	}
+	msg, err := sender.NewMessage(ctx, opts, notification, headers...)
+	if err != nil {
+		return "", err
+	}
+	// trace context và baggage đi theo message vào buffer để consumer vẫn nhận được sau khi gửi lại
+	tracing.InjectProducerMessage(ctx, msg)
+	if err := buf.Append(msg); err != nil {
+		return "", err
+	}
+	return notification.ID, nil
+}
+
+// runBufferFlusher gửi lại message trong buf mỗi interval khi breaker không mở, cho tới khi ctx bị huỷ.
+// Mỗi message đi qua breaker nên Kafka lỗi lại thì breaker mở và việc gửi lại dừng.
+func runBufferFlusher(ctx context.Context, buf *buffer.DiskBuffer, producer sarama.SyncProducer,
+	breaker *gobreaker.CircuitBreaker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if breaker.State() == gobreaker.StateOpen {
+			continue
+		}
+		sent, err := buf.Flush(func(msg *sarama.ProducerMessage) error {
+			_, err := breaker.Execute(func() (interface{}, error) {
+				_, _, err := producer.SendMessage(msg)
+				return nil, err
+			})
+			return err
+		})
+		if sent > 0 {
+			log.Info().Int("count", sent).Msg("flushed buffered notifications")
+		}
+		if err != nil && !isBreakerOpen(err) {
+			log.Warn().Err(err).Msg("failed to flush buffered notifications")
+		}
+	}
+}
+
+// bufferSizeHandler phục vụ GET /producer/buffer-size, đăng ký cùng các route cần xác thực.
+func bufferSizeHandler(buf *buffer.DiskBuffer) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		size, err := buf.Len()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"size": size})
+	}
+}
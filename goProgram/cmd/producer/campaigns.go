@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/campaign"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/tenant"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ============== CAMPAIGNS ==============
+
+const (
+	// chu kỳ CampaignRunner kiểm tra các campaign đã tới hạn
+	campaignPollInterval = time.Second
+	// số campaign tối đa lấy ra mỗi lần kiểm tra
+	campaignBatchSize = 10
+)
+
+// Nếu không có REDIS_URL thì campaign chỉ lưu và chạy trong instance hiện tại
+func setupCampaignStore(cfg *config.Config) (campaign.CampaignStore, func(), error) {
+	if cfg.RedisURL == "" {
+		return campaign.NewMemoryStore(), func() {}, nil
+	}
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	campaigns := campaign.NewRedisStore(client)
+	return campaigns, func() { campaigns.Close() }, nil
+}
+
+// CampaignRunner định kỳ lấy các campaign đã tới ScheduledAt và gửi tới người nhận
+// bằng sendBroadcastNotifications.
+type CampaignRunner struct {
+	Campaigns campaign.CampaignStore
+	Producer  batchSender
+	Opts      sender.Options
+	Users     store.UserStore
+}
+
+// Run chạy cho tới khi ctx bị huỷ.
+func (r *CampaignRunner) Run(ctx context.Context) {
+	ticker := time.NewTicker(campaignPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			due, err := r.Campaigns.Due(ctx, now, campaignBatchSize)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to query due campaigns")
+			}
+			for _, c := range due {
+				r.run(ctx, c)
+			}
+		}
+	}
+}
+
+// run gửi một campaign trong tenant của nó và lưu lại Status cùng số người nhận thành công/thất bại.
+// Người nhận không tồn tại được tính là thất bại, campaign failed khi không gửi được cho ai.
+func (r *CampaignRunner) run(ctx context.Context, c models.Campaign) {
+	c.Status = models.CampaignStatusRunning
+	r.save(c)
+
+	ctx = middleware.WithCorrelationID(tenant.WithID(ctx, c.TenantID), c.ID)
+	from, err := r.Users.FindByID(ctx, c.FromID)
+	if err != nil {
+		r.fail(c, fmt.Errorf("sender %d: %w", c.FromID, err))
+		return
+	}
+	recipients := make([]models.User, 0, len(c.TargetUserIDs))
+	for _, id := range c.TargetUserIDs {
+		to, err := r.Users.FindByID(ctx, id)
+		if err != nil {
+			log.Warn().Err(err).Str("campaignID", c.ID).Int("toID", id).Msg("skipping campaign recipient")
+			c.Failed++
+			continue
+		}
+		recipients = append(recipients, to)
+	}
+
+	errs := sendBroadcastNotifications(ctx, r.Producer, r.Opts, from, c.Message, c.Priority, "", recipients, nil,
+		kafka.Header(kafka.HeaderCorrelationID, c.ID))
+	for i, to := range recipients {
+		if to.ID == from.ID {
+			continue
+		}
+		if errs[i] != nil {
+			c.Failed++
+			continue
+		}
+		c.Sent++
+	}
+	if c.Sent == 0 {
+		r.fail(c, errors.New("no recipient was delivered"))
+		return
+	}
+	c.Status = models.CampaignStatusCompleted
+	r.save(c)
+	log.Info().Str("campaignID", c.ID).Int("sent", c.Sent).Int("failed", c.Failed).Msg("campaign completed")
+}
+
+func (r *CampaignRunner) fail(c models.Campaign, err error) {
+	c.Status = models.CampaignStatusFailed
+	c.Error = err.Error()
+	r.save(c)
+	log.Error().Err(err).Str("campaignID", c.ID).Msg("campaign failed")
+}
+
+// save dùng context.Background để trạng thái vẫn được lưu khi đang shutdown
+func (r *CampaignRunner) save(c models.Campaign) {
+	if err := r.Campaigns.Update(context.Background(), c); err != nil {
+		log.Error().Err(err).Str("campaignID", c.ID).Str("status", c.Status).Msg("failed to update campaign")
+	}
+}
+
+type createCampaignRequest struct {
+	FromID        int    `json:"fromID"`
+	Message       string `json:"message"`
+	Priority      int    `json:"priority"`
+	TargetUserIDs []int  `json:"targetUserIDs"`
+	// ScheduledAt bỏ trống nghĩa là chạy ngay
+	ScheduledAt time.Time `json:"scheduledAt"`
+}
+
+// createCampaignHandler xử lý POST /campaigns (JSON), fromID mặc định là user trong JWT
+// và priority mặc định là normal. Campaign được chạy bởi CampaignRunner khi tới scheduledAt.
+func createCampaignHandler(campaigns campaign.CampaignStore, maxFanout int) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req createCampaignRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if userID, ok := middleware.AuthedUserID(ctx); ok && req.FromID == 0 {
+			req.FromID = userID
+		}
+		if req.Priority == 0 {
+			req.Priority = models.PriorityNormal
+		}
+		if req.ScheduledAt.IsZero() {
+			req.ScheduledAt = time.Now()
+		}
+
+		c := models.Campaign{
+			ID:            uuid.NewString(),
+			FromID:        req.FromID,
+			Message:       req.Message,
+			Priority:      req.Priority,
+			TargetUserIDs: uniqueIDs(req.TargetUserIDs),
+			ScheduledAt:   req.ScheduledAt.UTC(),
+			Status:        models.CampaignStatusScheduled,
+			TenantID:      middleware.TenantID(ctx),
+		}
+		if err := c.Validate(); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if len(c.TargetUserIDs) > maxFanout {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+				"message": fmt.Sprintf("%v: %d recipients, limit is %d", ErrFanoutTooLarge, len(c.TargetUserIDs), maxFanout),
+			})
+			return
+		}
+		if err := campaigns.Create(ctx.Request.Context(), c); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusCreated, c)
+	}
+}
+
+// getCampaignHandler xử lý GET /campaigns/:id, campaign của tenant khác trả về 404.
+func getCampaignHandler(campaigns campaign.CampaignStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		c, err := campaigns.Get(ctx.Request.Context(), ctx.Param("id"))
+		if err == nil && c.TenantID != middleware.TenantID(ctx) {
+			err = fmt.Errorf("%w: %s", campaign.ErrNotFound, c.ID)
+		}
+		if errors.Is(err, campaign.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, c)
+	}
+}
+
+// uniqueIDs bỏ các ID trùng, giữ nguyên thứ tự xuất hiện đầu tiên
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
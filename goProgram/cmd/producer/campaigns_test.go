@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"kafka-notify/pkg/campaign"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestCampaignRunnerSendsToEveryTarget(t *testing.T) {
+	users := store.NewMemoryUserStore(testSender, testRecipient,
+		models.User{ID: 3, Name: "Carol"}, models.User{ID: 4, Name: "Dave"},
+		models.User{ID: 5, Name: "Erin"}, models.User{ID: 6, Name: "Frank"})
+	campaigns := campaign.NewMemoryStore()
+	recorder := postJSON(createCampaignHandler(campaigns, 100), "/campaigns", "/campaigns",
+		createCampaignRequest{FromID: 1, Message: "sale", TargetUserIDs: []int{2, 3, 4, 5, 6}})
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	id, _ := decodeBody(t, recorder)["id"].(string)
+
+	producer := mock.NewSyncProducer()
+	runner := &CampaignRunner{Campaigns: campaigns, Producer: producer, Opts: newTestOptions(t), Users: users}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runner.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	var c models.Campaign
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		var err error
+		if c, err = campaigns.Get(context.Background(), id); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if c.Status == models.CampaignStatusCompleted || c.Status == models.CampaignStatusFailed {
+			break
+		}
+	}
+	if c.Status != models.CampaignStatusCompleted || c.Sent != 5 || c.Failed != 0 {
+		t.Fatalf("campaign = %s sent %d failed %d, want completed 5/0", c.Status, c.Sent, c.Failed)
+	}
+
+	messages := producer.Messages()
+	if len(messages) != 5 {
+		t.Fatalf("%d messages produced, want 5", len(messages))
+	}
+	var recipients []int
+	for _, msg := range messages {
+		value, _ := msg.Value.Encode()
+		var notification models.Notification
+		if err := json.Unmarshal(value, &notification); err != nil {
+			t.Fatalf("message is not a JSON notification: %v", err)
+		}
+		if notification.Message != "sale" || notification.From.ID != 1 {
+			t.Fatalf("notification = %+v, want sale from user 1", notification)
+		}
+		recipients = append(recipients, notification.To.ID)
+	}
+	sort.Ints(recipients)
+	if want := []int{2, 3, 4, 5, 6}; !reflect.DeepEqual(recipients, want) {
+		t.Fatalf("recipients = %v, want %v", recipients, want)
+	}
+}
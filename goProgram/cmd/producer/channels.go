@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============== CHANNELS ==============
+
+type createChannelRequest struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// writeChannelError trả về response cho lỗi của SubscriptionStore, false nếu err là nil
+func writeChannelError(ctx *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, store.ErrChannelNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, store.ErrChannelExists):
+		ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, store.ErrChannelFull):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+	case errors.Is(err, models.ErrInvalidChannel):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+	return true
+}
+
+// createChannelHandler xử lý POST /channels (JSON name, type).
+func createChannelHandler(channels store.SubscriptionStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req createChannelRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		channel, err := channels.CreateChannel(ctx.Request.Context(), models.Channel{Name: req.Name, Type: req.Type})
+		if writeChannelError(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusCreated, channel)
+	}
+}
+
+// subscribeChannelHandler xử lý POST /channels/:name/subscribe?userID=1, user phải tồn tại trong tenant.
+// Subscribe lại user đã subscribe vẫn trả về 200.
+func subscribeChannelHandler(channels store.SubscriptionStore, users store.UserStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, err := strconv.Atoi(ctx.Query("userID"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("userID must be a number, got %q", ctx.Query("userID"))})
+			return
+		}
+		_, err = users.FindByID(ctx.Request.Context(), userID)
+		if writeAppError(ctx, err) {
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		name := ctx.Param("name")
+		if writeChannelError(ctx, channels.Subscribe(ctx.Request.Context(), name, userID)) {
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"channel": name, "userID": userID})
+	}
+}
+
+// listSubscribersHandler xử lý GET /channels/:name/subscribers.
+func listSubscribersHandler(channels store.SubscriptionStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		name := ctx.Param("name")
+		subscribers, err := channels.Subscribers(ctx.Request.Context(), name)
+		if writeChannelError(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"channel": name, "subscribers": subscribers, "total": len(subscribers)})
+	}
+}
+
+// publishChannelHandler xử lý POST /channels/:name/publish (form fromID, message, priority): gửi tới mọi subscriber
+// trừ người gửi bằng sendBroadcastNotifications với Type của channel, trả về 207 Multi-Status giống /broadcast.
+func publishChannelHandler(producer batchSender, opts sender.Options, users store.UserStore,
+	channels store.SubscriptionStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		fromID, err := getFromIdFromRequest(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		priority, err := getPriorityFromRequest(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		channel, err := channels.Channel(ctx.Request.Context(), ctx.Param("name"))
+		if writeChannelError(ctx, err) {
+			return
+		}
+		subscribers, err := channels.Subscribers(ctx.Request.Context(), channel.Name)
+		if writeChannelError(ctx, err) {
+			return
+		}
+		from, err := users.FindByID(ctx.Request.Context(), fromID)
+		if writeAppError(ctx, err) {
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		recipients, results := resolveRecipients(ctx, users, from, subscribers)
+		if len(results) > 0 && isTransactional(producer) {
+			// có người nhận không hợp lệ, transaction không được gửi thiếu người nhận
+			results = appendSendResults(results, recipients, rejectedErrs(len(recipients)))
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"channel": channel.Name, "results": results})
+			return
+		}
+		errs := sendBroadcastNotifications(ctx.Request.Context(), producer, opts, from, ctx.PostForm("message"), priority,
+			channel.Type, recipients, nil,
+			kafka.Header(kafka.HeaderCorrelationID, middleware.CorrelationID(ctx)))
+		results = appendSendResults(results, recipients, errs)
+		ctx.JSON(broadcastStatus(errs), gin.H{"channel": channel.Name, "results": results})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newChannelRouter đăng ký các route /channels như main trên cùng một SubscriptionStore
+func newChannelRouter(t *testing.T, producer *mock.SyncProducer) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	users := newGroupUsers()
+	channels := store.NewMemorySubscriptionStore(10)
+	engine := gin.New()
+	engine.POST("/channels", createChannelHandler(channels))
+	engine.POST("/channels/:name/subscribe", subscribeChannelHandler(channels, users))
+	engine.GET("/channels/:name/subscribers", listSubscribersHandler(channels))
+	engine.POST("/channels/:name/publish", publishChannelHandler(producer, newTestOptions(t), users, channels))
+	return engine
+}
+
+func serve(engine *gin.Engine, request *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestChannelPublishEndToEnd(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	engine := newChannelRouter(t, producer)
+
+	payload, _ := json.Marshal(createChannelRequest{Name: "release_news", Type: "system_alert"})
+	request := httptest.NewRequest(http.MethodPost, "/channels", bytes.NewReader(payload))
+	request.Header.Set("Content-Type", "application/json")
+	if recorder := serve(engine, request); recorder.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := serve(engine, httptest.NewRequest(http.MethodPost, "/channels", bytes.NewReader(payload))); recorder.Code != http.StatusConflict {
+		t.Fatalf("create again status = %d, want 409", recorder.Code)
+	}
+
+	for _, userID := range []string{"2", "3", "3"} {
+		recorder := serve(engine, httptest.NewRequest(http.MethodPost, "/channels/release_news/subscribe?userID="+userID, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("subscribe %s status = %d: %s", userID, recorder.Code, recorder.Body.String())
+		}
+	}
+	if recorder := serve(engine, httptest.NewRequest(http.MethodPost, "/channels/release_news/subscribe?userID=99", nil)); recorder.Code != http.StatusNotFound {
+		t.Fatalf("subscribe unknown user status = %d, want 404", recorder.Code)
+	}
+
+	recorder := serve(engine, httptest.NewRequest(http.MethodGet, "/channels/release_news/subscribers", nil))
+	var list struct {
+		Subscribers []int `json:"subscribers"`
+		Total       int   `json:"total"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &list); err != nil {
+		t.Fatalf("subscribers response: %v", err)
+	}
+	sort.Ints(list.Subscribers)
+	if list.Total != 2 || len(list.Subscribers) != 2 || list.Subscribers[0] != 2 || list.Subscribers[1] != 3 {
+		t.Fatalf("subscribers = %+v, want [2 3]", list)
+	}
+
+	form := url.Values{"fromID": {"1"}, "message": {"v2 is out"}}
+	request = httptest.NewRequest(http.MethodPost, "/channels/release_news/publish", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if recorder := serve(engine, request); recorder.Code != http.StatusMultiStatus {
+		t.Fatalf("publish status = %d, want 207: %s", recorder.Code, recorder.Body.String())
+	}
+
+	// mỗi subscriber nhận đúng một notification mang Type của channel
+	received := map[int]int{}
+	for _, msg := range producer.Messages() {
+		value, _ := msg.Value.Encode()
+		var notification models.Notification
+		if err := json.Unmarshal(value, &notification); err != nil {
+			t.Fatalf("failed to decode payload: %v", err)
+		}
+		if notification.From.ID != 1 || notification.Message != "v2 is out" || notification.Type != "system_alert" {
+			t.Fatalf("sent notification = %+v", notification)
+		}
+		received[notification.To.ID]++
+	}
+	if len(received) != 2 || received[2] != 1 || received[3] != 1 {
+		t.Fatalf("received = %v, want users 2 and 3 once each", received)
+	}
+
+	request = httptest.NewRequest(http.MethodPost, "/channels/unknown/publish", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if recorder := serve(engine, request); recorder.Code != http.StatusNotFound {
+		t.Fatalf("publish to unknown channel status = %d, want 404", recorder.Code)
+	}
+}
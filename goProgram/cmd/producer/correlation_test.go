@@ -0,0 +1,71 @@
+package main
+
+import (
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/idempotency"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestSendMessageHandlerCorrelationIDHeader(t *testing.T) {
+	tests := []struct {
+		name          string
+		correlationID string
+	}{
+		{name: "from request", correlationID: "3c6e0b8a-9c0b-4f5c-8c1d-2f6a7d9e0b1c"},
+		{name: "generated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := mock.NewSyncProducer()
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			engine.Use(middleware.CorrelationMiddleware())
+			engine.POST("/send", sendMessageHandler(producer, newTestOptions(t), newTestUsers(),
+				idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil))
+
+			form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}}
+			request := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.correlationID != "" {
+				request.Header.Set(middleware.CorrelationIDHeader, tt.correlationID)
+			}
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, request)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", recorder.Code, recorder.Body.String())
+			}
+
+			want := recorder.Header().Get(middleware.CorrelationIDHeader)
+			if tt.correlationID != "" && want != tt.correlationID {
+				t.Fatalf("response correlation ID = %q, want %q", want, tt.correlationID)
+			}
+			if _, err := uuid.Parse(want); err != nil {
+				t.Fatalf("response correlation ID %q is not a UUID: %v", want, err)
+			}
+			messages := producer.Messages()
+			if len(messages) != 1 {
+				t.Fatalf("%d messages sent, want 1", len(messages))
+			}
+			var got []string
+			for _, header := range messages[0].Headers {
+				if string(header.Key) == kafka.HeaderCorrelationID {
+					got = append(got, string(header.Value))
+				}
+			}
+			if len(got) != 1 || got[0] != want {
+				t.Fatalf("Kafka %s headers = %q, want [%q]", kafka.HeaderCorrelationID, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/sender"
+	"net/http"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+)
+
+// deleteNotificationHandler xử lý DELETE /notifications/:id: gửi tombstone để consumer xoá
+// notification khỏi store và search index. Trả về 202 vì việc xoá diễn ra bất đồng bộ ở consumer.
+func deleteNotificationHandler(producer sarama.SyncProducer, opts sender.Options) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		sendCtx, cancel := context.WithTimeout(ctx.Request.Context(), opts.SendTimeout)
+		defer cancel()
+		if err := sender.SendTombstone(sendCtx, producer, opts, middleware.TenantID(ctx), id); err != nil {
+			log.Error().Err(err).Str("notificationID", id).Msg("failed to send tombstone")
+			if errors.Is(err, context.DeadlineExceeded) {
+				ctx.JSON(http.StatusGatewayTimeout, gin.H{"message": err.Error()})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusAccepted, gin.H{"notificationID": id})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============== DRY RUN ==============
+
+// dryRunHandler phục vụ POST /send/dry-run: nhận cùng form với /send và chạy đủ bước tra cứu user,
+// enrichment, Validate và marshal, nhưng message được gửi vào mock.SyncProducer nên không tới Kafka.
+// Không ghi idempotency key, không đánh dấu dedup và không lên lịch theo deliver_at.
+func dryRunHandler(opts sender.Options, users store.UserStore, templates template.Store, encoding string) gin.HandlerFunc {
+	// dedup cache đánh dấu notification đã gửi, dry run không được làm /send thật bị từ chối
+	opts.Dedup = nil
+	return func(ctx *gin.Context) {
+		fromID, err := getFromIdFromRequest(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"valid": false, "message": err.Error()})
+			return
+		}
+		toID, err := getIdFromRequest("toID", ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"valid": false, "message": err.Error()})
+			return
+		}
+		message, err := messageFromRequest(ctx, templates, users, fromID, toID)
+		if err != nil {
+			writeMessageError(ctx, err)
+			return
+		}
+		priority, err := getPriorityFromRequest(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"valid": false, "message": err.Error()})
+			return
+		}
+		metadata, err := getMetadataFromRequest(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"valid": false, "message": err.Error()})
+			return
+		}
+		ttl, err := getTTLFromRequest(ctx, opts.DefaultTTL)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"valid": false, "message": err.Error()})
+			return
+		}
+
+		// producer riêng cho mỗi request nên message không bị giữ lại sau khi trả kết quả
+		producer := mock.NewSyncProducer()
+		_, err = sendKafkaMessage(ctx.Request.Context(), producer, opts, users, fromID, toID, message, priority,
+			ctx.PostForm("type"), metadata, getThreadFromRequest(ctx), requestHeaders(ctx, "", ttl)...)
+		switch {
+		case errors.Is(err, models.ErrInvalidNotification):
+			ctx.JSON(http.StatusBadRequest, gin.H{"valid": false, "message": err.Error()})
+			return
+		case writeAppError(ctx, err):
+			return
+		case err != nil:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"valid": false, "message": err.Error()})
+			return
+		}
+
+		msg := producer.Messages()[0]
+		ctx.JSON(http.StatusOK, gin.H{
+			"valid":           true,
+			"serializedBytes": msg.Value.Length(),
+			"codec":           encoding,
+			"topic":           msg.Topic,
+		})
+	}
+}
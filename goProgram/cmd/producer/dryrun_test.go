@@ -0,0 +1,74 @@
+package main
+
+import (
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/template"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestDryRunHandler(t *testing.T) {
+	handler := dryRunHandler(newTestOptions(t), newTestUsers(), template.NewMemoryStore(), codec.EncodingJSON)
+
+	recorder := postForm(handler, "/send/dry-run", url.Values{
+		"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}, "metadata": {`{"source":"mobile"}`},
+	})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", recorder.Code, recorder.Body.String())
+	}
+	body := decodeBody(t, recorder)
+	if body["valid"] != true || body["codec"] != codec.EncodingJSON {
+		t.Fatalf("response = %v, want valid json", body)
+	}
+	if size, ok := body["serializedBytes"].(float64); !ok || size <= 0 {
+		t.Fatalf("serializedBytes = %v, want > 0", body["serializedBytes"])
+	}
+	if body["topic"] != "notifications.normal" {
+		t.Fatalf("topic = %v, want notifications.normal", body["topic"])
+	}
+}
+
+func TestDryRunHandlerRejectsInvalidRequests(t *testing.T) {
+	handler := dryRunHandler(newTestOptions(t), newTestUsers(), template.NewMemoryStore(), codec.EncodingJSON)
+
+	tests := []struct {
+		name       string
+		form       url.Values
+		wantStatus int
+		wantUserID float64
+	}{
+		{
+			name:       "unknown recipient",
+			form:       url.Values{"fromID": {"1"}, "toID": {"99"}, "message": {"hello"}},
+			wantStatus: http.StatusNotFound,
+			wantUserID: 99,
+		},
+		{
+			name:       "unknown sender",
+			form:       url.Values{"fromID": {"42"}, "toID": {"2"}, "message": {"hello"}},
+			wantStatus: http.StatusNotFound,
+			wantUserID: 42,
+		},
+		{
+			name:       "empty message",
+			form:       url.Values{"fromID": {"1"}, "toID": {"2"}},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := postForm(handler, "/send/dry-run", tt.form)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			body := decodeBody(t, recorder)
+			if body["valid"] == true {
+				t.Fatalf("response = %v, want not valid", body)
+			}
+			if tt.wantUserID != 0 && body["userID"] != tt.wantUserID {
+				t.Fatalf("userID = %v, want %v", body["userID"], tt.wantUserID)
+			}
+		})
+	}
+}
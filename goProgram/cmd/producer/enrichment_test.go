@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"kafka-notify/pkg/enrichment"
+	"kafka-notify/pkg/idempotency"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/template"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// /send chạy enricher theo thứ tự, message gửi lên Kafka mang thay đổi của mọi enricher
+func TestSendMessageHandlerEnrichersRunInOrder(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	opts := newTestOptions(t)
+	var order []string
+	appendTag := func(tag string) enrichment.Enricher {
+		return enrichment.EnricherFunc(func(ctx context.Context, n *models.Notification) error {
+			order = append(order, tag)
+			n.Message += " [" + tag + "]"
+			return nil
+		})
+	}
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	opts.Enrichment = enrichment.NewEnrichmentPipeline(
+		enrichment.TimestampEnricher{Now: func() time.Time { return createdAt }},
+		appendTag("geo"),
+		appendTag("ab-bucket"),
+	)
+	handler := sendMessageHandler(producer, opts, newTestUsers(),
+		idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil)
+
+	recorder := postForm(handler, "/send", url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if len(order) != 2 || order[0] != "geo" || order[1] != "ab-bucket" {
+		t.Fatalf("enrichers ran %v, want geo then ab-bucket", order)
+	}
+	messages := producer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("%d messages sent, want 1", len(messages))
+	}
+	value, _ := messages[0].Value.Encode()
+	var sent models.Notification
+	if err := json.Unmarshal(value, &sent); err != nil {
+		t.Fatalf("sent message is not JSON: %v", err)
+	}
+	if sent.Message != "hello [geo] [ab-bucket]" || !sent.CreatedAt.Equal(createdAt) {
+		t.Fatalf("sent notification message %q, createdAt %v, want enriched", sent.Message, sent.CreatedAt)
+	}
+}
+
+// enricher lỗi dừng pipeline: enricher sau không chạy và không có message nào được gửi
+func TestSendMessageHandlerFailingEnricher(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	opts := newTestOptions(t)
+	lastRan := false
+	opts.Enrichment = enrichment.NewEnrichmentPipeline(
+		enrichment.EnricherFunc(func(ctx context.Context, n *models.Notification) error {
+			return errors.New("geo lookup failed")
+		}),
+		enrichment.EnricherFunc(func(ctx context.Context, n *models.Notification) error {
+			lastRan = true
+			return nil
+		}),
+	)
+	handler := sendMessageHandler(producer, opts, newTestUsers(),
+		idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil)
+
+	recorder := postForm(handler, "/send", url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}})
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", recorder.Code, recorder.Body.String())
+	}
+	if lastRan {
+		t.Fatal("enricher after the failing one ran")
+	}
+	if got := len(producer.Messages()); got != 0 {
+		t.Fatalf("%d messages sent, want 0", got)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	apperrors "kafka-notify/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeAppError ghi response cho các kiểu lỗi của pkg/errors kèm chi tiết của lỗi,
+// trả về false nếu err không thuộc kiểu nào để caller tự xử lý.
+func writeAppError(ctx *gin.Context, err error) bool {
+	var (
+		userNotFound      *apperrors.ErrUserNotFound
+		topicNotFound     *apperrors.ErrTopicNotFound
+		tooLarge          *apperrors.ErrMessageTooLarge
+		brokerUnreachable *apperrors.ErrBrokerUnreachable
+		serialisation     *apperrors.ErrSerialisationFailed
+	)
+	switch {
+	case errors.As(err, &userNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error(), "userID": userNotFound.ID})
+	case errors.As(err, &topicNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error(), "topic": topicNotFound.Topic})
+	case errors.As(err, &tooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"message":      err.Error(),
+			"size":         tooLarge.Size,
+			"allowedBytes": tooLarge.MaxSize,
+		})
+	case errors.As(err, &brokerUnreachable):
+		// không trả danh sách broker cho client
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"message": "kafka is unavailable, try again later"})
+	case errors.As(err, &serialisation):
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error(), "codec": serialisation.Codec})
+	default:
+		return false
+	}
+	return true
+}
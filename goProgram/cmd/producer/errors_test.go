@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	apperrors "kafka-notify/pkg/errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWriteAppError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantField  string
+	}{
+		{name: "user not found", err: &apperrors.ErrUserNotFound{ID: 3}, wantStatus: http.StatusNotFound, wantField: "userID"},
+		{name: "topic not found", err: &apperrors.ErrTopicNotFound{Topic: "notifications.high"}, wantStatus: http.StatusNotFound, wantField: "topic"},
+		{name: "message too large", err: &apperrors.ErrMessageTooLarge{Size: 10, MaxSize: 5}, wantStatus: http.StatusRequestEntityTooLarge, wantField: "allowedBytes"},
+		{name: "broker unreachable", err: &apperrors.ErrBrokerUnreachable{Brokers: []string{"kafka:9092"}}, wantStatus: http.StatusServiceUnavailable},
+		{name: "serialisation failed", err: &apperrors.ErrSerialisationFailed{Codec: "application/json"}, wantStatus: http.StatusInternalServerError, wantField: "codec"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			if !writeAppError(ctx, fmt.Errorf("failed to send notification: %w", tt.err)) {
+				t.Fatal("writeAppError() = false, want true")
+			}
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			body := decodeBody(t, recorder)
+			if tt.wantField != "" && body[tt.wantField] == nil {
+				t.Fatalf("response = %v, want field %s", body, tt.wantField)
+			}
+		})
+	}
+
+	t.Run("unknown error", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		if writeAppError(ctx, errors.New("boom")) {
+			t.Fatal("writeAppError() = true for an unknown error")
+		}
+	})
+}
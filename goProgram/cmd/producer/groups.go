@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============== GROUPS ==============
+
+type createGroupRequest struct {
+	Name      string `json:"name"`
+	MemberIDs []int  `json:"memberIDs"`
+}
+
+type addGroupMembersRequest struct {
+	UserIDs []int `json:"userIDs"`
+}
+
+// writeGroupError trả về response cho lỗi của GroupStore, false nếu err là nil
+func writeGroupError(ctx *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, store.ErrGroupNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, store.ErrGroupTooLarge):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+	case errors.Is(err, models.ErrInvalidGroup):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+	return true
+}
+
+// checkMembersExist trả về lỗi của user đầu tiên không có trong tenant hiện tại
+func checkMembersExist(ctx *gin.Context, users store.UserStore, userIDs []int) error {
+	for _, id := range userIDs {
+		if _, err := users.FindByID(ctx.Request.Context(), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveRecipients tra cứu userIDs (trừ người gửi) trong users. User đã bị xoá khỏi user store
+// sau khi vào group/channel được báo failed trong results thay vì làm hỏng cả request.
+func resolveRecipients(ctx *gin.Context, users store.UserStore, from models.User,
+	userIDs []int) ([]models.User, []broadcastResult) {
+	results := make([]broadcastResult, 0, len(userIDs))
+	recipients := make([]models.User, 0, len(userIDs))
+	for _, id := range userIDs {
+		if id == from.ID {
+			continue
+		}
+		to, err := users.FindByID(ctx.Request.Context(), id)
+		if err != nil {
+			results = append(results, broadcastResult{ToID: id, Status: "failed", Error: err.Error()})
+			continue
+		}
+		recipients = append(recipients, to)
+	}
+	return recipients, results
+}
+
+// appendSendResults thêm kết quả gửi của sendBroadcastNotifications, errs[i] ứng với recipients[i]
+func appendSendResults(results []broadcastResult, recipients []models.User, errs []error) []broadcastResult {
+	for i, to := range recipients {
+		if errs[i] != nil {
+			results = append(results, broadcastResult{ToID: to.ID, Status: "failed", Error: errs[i].Error()})
+			continue
+		}
+		results = append(results, broadcastResult{ToID: to.ID, Status: "sent"})
+	}
+	return results
+}
+
+func groupIDParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("group id must be a number, got %q", ctx.Param("id"))})
+		return 0, false
+	}
+	return id, true
+}
+
+// createGroupHandler xử lý POST /groups (JSON name, memberIDs), member phải là user đã tồn tại.
+func createGroupHandler(groups store.GroupStore, users store.UserStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req createGroupRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		group := models.Group{Name: req.Name, MemberIDs: req.MemberIDs}
+		if err := group.Validate(); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		err := checkMembersExist(ctx, users, group.MemberIDs)
+		if writeAppError(ctx, err) || writeGroupError(ctx, err) {
+			return
+		}
+
+		group, err = groups.Create(ctx.Request.Context(), group)
+		if writeGroupError(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusCreated, group)
+	}
+}
+
+// addGroupMembersHandler xử lý POST /groups/:id/members (JSON userIDs).
+func addGroupMembersHandler(groups store.GroupStore, users store.UserStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := groupIDParam(ctx)
+		if !ok {
+			return
+		}
+		var req addGroupMembersRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if len(req.UserIDs) == 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "userIDs must not be empty"})
+			return
+		}
+		err := checkMembersExist(ctx, users, req.UserIDs)
+		if writeAppError(ctx, err) || writeGroupError(ctx, err) {
+			return
+		}
+
+		group, err := groups.AddMembers(ctx.Request.Context(), id, req.UserIDs...)
+		if writeGroupError(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusOK, group)
+	}
+}
+
+// removeGroupMemberHandler xử lý DELETE /groups/:id/members/:userID, xoá user không phải member vẫn trả về 200.
+func removeGroupMemberHandler(groups store.GroupStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := groupIDParam(ctx)
+		if !ok {
+			return
+		}
+		userID, err := strconv.Atoi(ctx.Param("userID"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("userID must be a number, got %q", ctx.Param("userID"))})
+			return
+		}
+
+		group, err := groups.RemoveMember(ctx.Request.Context(), id, userID)
+		if writeGroupError(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusOK, group)
+	}
+}
+
+// sendGroupHandler xử lý POST /send/group (form fromID, groupID, message, priority, type): gửi tới mọi member
+// trừ người gửi bằng sendBroadcastNotifications và trả về 207 Multi-Status giống /broadcast.
+// Key của message là group:{groupID}:{toUserID} để mỗi người nhận giữ thứ tự riêng trong group.
+func sendGroupHandler(producer batchSender, opts sender.Options, users store.UserStore,
+	groups store.GroupStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		fromID, err := getFromIdFromRequest(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		groupID, err := getIdFromRequest("groupID", ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		priority, err := getPriorityFromRequest(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+
+		group, err := groups.Get(ctx.Request.Context(), groupID)
+		if writeGroupError(ctx, err) {
+			return
+		}
+		from, err := users.FindByID(ctx.Request.Context(), fromID)
+		if writeAppError(ctx, err) {
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+
+		recipients, results := resolveRecipients(ctx, users, from, group.MemberIDs)
+		groupKey := func(to models.User) string {
+			return "group:" + strconv.Itoa(group.ID) + ":" + strconv.Itoa(to.ID)
+		}
+		if len(results) > 0 && isTransactional(producer) {
+			// có người nhận không hợp lệ, transaction không được gửi thiếu người nhận
+			results = appendSendResults(results, recipients, rejectedErrs(len(recipients)))
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"groupID": group.ID, "results": results})
+			return
+		}
+		errs := sendBroadcastNotifications(ctx.Request.Context(), producer, opts, from, ctx.PostForm("message"), priority,
+			ctx.PostForm("type"), recipients, groupKey,
+			kafka.Header(kafka.HeaderCorrelationID, middleware.CorrelationID(ctx)))
+		results = appendSendResults(results, recipients, errs)
+		ctx.JSON(broadcastStatus(errs), gin.H{"groupID": group.ID, "results": results})
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newGroupUsers có người gửi 1 và ba người nhận 2, 3, 4
+func newGroupUsers() *store.MemoryUserStore {
+	return store.NewMemoryUserStore(testSender, testRecipient,
+		models.User{ID: 3, Name: "Carol"}, models.User{ID: 4, Name: "Dave"})
+}
+
+// postJSON gửi body JSON tới handler như client gọi POST path
+func postJSON(handler gin.HandlerFunc, route, path string, body any) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST(route, handler)
+	payload, _ := json.Marshal(body)
+	request := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCreateGroupHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       createGroupRequest
+		wantStatus int
+	}{
+		{name: "created", body: createGroupRequest{Name: "team", MemberIDs: []int{1, 2, 3, 3}}, wantStatus: http.StatusCreated},
+		{name: "empty name", body: createGroupRequest{MemberIDs: []int{1}}, wantStatus: http.StatusBadRequest},
+		{name: "unknown member", body: createGroupRequest{Name: "team", MemberIDs: []int{1, 99}}, wantStatus: http.StatusNotFound},
+		{name: "too large", body: createGroupRequest{Name: "team", MemberIDs: []int{1, 2, 3, 4}}, wantStatus: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := createGroupHandler(store.NewMemoryGroupStore(3), newGroupUsers())
+			recorder := postJSON(handler, "/groups", "/groups", tt.body)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			var group models.Group
+			if err := json.Unmarshal(recorder.Body.Bytes(), &group); err != nil {
+				t.Fatalf("response is not a group: %v", err)
+			}
+			if group.ID == 0 || group.Name != "team" || len(group.MemberIDs) != 3 {
+				t.Fatalf("group = %+v, want team with members 1, 2, 3", group)
+			}
+		})
+	}
+}
+
+func TestGroupMembersHandlers(t *testing.T) {
+	groups := store.NewMemoryGroupStore(3)
+	users := newGroupUsers()
+	group, err := groups.Create(context.Background(), models.Group{Name: "team", MemberIDs: []int{1, 2}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	path := "/groups/" + strconv.Itoa(group.ID) + "/members"
+
+	recorder := postJSON(addGroupMembersHandler(groups, users), "/groups/:id/members", path, addGroupMembersRequest{UserIDs: []int{3}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("add status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	recorder = postJSON(addGroupMembersHandler(groups, users), "/groups/:id/members", path, addGroupMembersRequest{UserIDs: []int{4}})
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("add over MAX_GROUP_SIZE status = %d, want 422", recorder.Code)
+	}
+	recorder = postJSON(addGroupMembersHandler(groups, users), "/groups/:id/members", "/groups/99/members", addGroupMembersRequest{UserIDs: []int{3}})
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("add to unknown group status = %d, want 404", recorder.Code)
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.DELETE("/groups/:id/members/:userID", removeGroupMemberHandler(groups))
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, path+"/2", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("remove status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	group, _ = groups.Get(context.Background(), group.ID)
+	if len(group.MemberIDs) != 2 || group.MemberIDs[0] != 1 || group.MemberIDs[1] != 3 {
+		t.Fatalf("members = %v, want [1 3]", group.MemberIDs)
+	}
+}
+
+func TestSendGroupHandlerFanout(t *testing.T) {
+	groups := store.NewMemoryGroupStore(500)
+	group, err := groups.Create(context.Background(), models.Group{Name: "team", MemberIDs: []int{1, 2, 3, 4}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	producer := mock.NewSyncProducer()
+	handler := sendGroupHandler(producer, newTestOptions(t), newGroupUsers(), groups)
+
+	recorder := postForm(handler, "/send/group", url.Values{
+		"fromID": {"1"}, "groupID": {strconv.Itoa(group.ID)}, "message": {"standup in 5"},
+	})
+	if recorder.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want 207: %s", recorder.Code, recorder.Body.String())
+	}
+
+	// người gửi cũng là member nhưng không nhận notification của chính mình
+	messages := producer.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("%d messages sent, want 3", len(messages))
+	}
+	var keys []string
+	for _, msg := range messages {
+		key, _ := msg.Key.Encode()
+		keys = append(keys, string(key))
+		var notification models.Notification
+		payload, _ := msg.Value.Encode()
+		if err := json.Unmarshal(payload, &notification); err != nil {
+			t.Fatalf("failed to decode payload: %v", err)
+		}
+		if notification.From.ID != 1 || notification.Message != "standup in 5" {
+			t.Fatalf("sent notification = %+v", notification)
+		}
+	}
+	sort.Strings(keys)
+	prefix := "group:" + strconv.Itoa(group.ID) + ":"
+	for i, want := range []string{prefix + "2", prefix + "3", prefix + "4"} {
+		if keys[i] != want {
+			t.Fatalf("keys = %v, want %s{2,3,4}", keys, prefix)
+		}
+	}
+
+	recorder = postForm(handler, "/send/group", url.Values{"fromID": {"1"}, "groupID": {"99"}, "message": {"hi"}})
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("unknown group status = %d, want 404", recorder.Code)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/config"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/kafka"
+	"net/http"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+	"github.com/sony/gobreaker"
+)
+
+// ============== HEALTH CHECK ==============
+
+// KafkaPinger kiểm tra kết nối tới Kafka mà không cần gửi message thật.
+type KafkaPinger interface {
+	Ping() error
+}
+
+type clientPinger struct {
+	client  sarama.Client
+	brokers []string
+}
+
+// Ping lấy lại metadata từ broker, không broker nào trả lời thì trả về *apperrors.ErrBrokerUnreachable.
+func (p clientPinger) Ping() error {
+	return kafka.ClassifyError(p.brokers, "", p.client.RefreshMetadata())
+}
+
+func setupKafkaPinger(cfg *config.Config) (KafkaPinger, func() error, error) {
+	config, err := kafka.NewProducerConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to setup kafka client: %w", err)
+	}
+	client, err := sarama.NewClient(cfg.KafkaBrokers, config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to setup kafka client: %w", err)
+	}
+	return clientPinger{client: client, brokers: cfg.KafkaBrokers}, client.Close, nil
+}
+
+type healthResponse struct {
+	Status string `json:"status"`
+	Uptime string `json:"uptime,omitempty"`
+	Kafka  string `json:"kafka"`
+	// CircuitBreakerState là trạng thái breaker quanh việc gửi Kafka: closed, half-open hoặc open
+	CircuitBreakerState string `json:"circuitBreakerState"`
+}
+
+func healthHandler(pinger KafkaPinger, breaker *gobreaker.CircuitBreaker, startedAt time.Time) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := pinger.Ping(); err != nil {
+			ctx.JSON(http.StatusServiceUnavailable, healthResponse{
+				Status:              "degraded",
+				Kafka:               "unreachable",
+				CircuitBreakerState: breaker.State().String(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, healthResponse{
+			Status:              "ok",
+			Uptime:              time.Since(startedAt).Round(time.Second).String(),
+			Kafka:               "connected",
+			CircuitBreakerState: breaker.State().String(),
+		})
+	}
+}
+
+// readinessTimeout là thời gian tối đa /health/ready chờ broker trả metadata
+const readinessTimeout = 2 * time.Second
+
+var errPingTimeout = errors.New("kafka ping timed out")
+
+// liveHandler phục vụ GET /health/live: process còn phục vụ HTTP là đủ, không gọi Kafka
+// để broker chậm không làm Kubernetes restart pod.
+func liveHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// readyHandler phục vụ GET /health/ready: 200 khi Kafka trả metadata trong readinessTimeout,
+// 503 khi không broker nào trả lời (Kafka down) và 500 với lỗi khác (ví dụ client đã bị đóng).
+func readyHandler(pinger KafkaPinger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		err := pingWithTimeout(pinger, readinessTimeout)
+		switch {
+		case err == nil:
+			ctx.JSON(http.StatusOK, gin.H{"status": "ready", "kafka": "connected"})
+		case isKafkaUnavailable(err):
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "kafka": "unreachable", "message": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+		}
+	}
+}
+
+// pingWithTimeout không chờ Ping quá timeout vì RefreshMetadata không nhận context,
+// Ping bị bỏ lại vẫn chạy tới khi sarama tự hết hạn.
+func pingWithTimeout(pinger KafkaPinger, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- pinger.Ping()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return errPingTimeout
+	}
+}
+
+func isKafkaUnavailable(err error) bool {
+	var brokerUnreachable *apperrors.ErrBrokerUnreachable
+	return errors.Is(err, errPingTimeout) || errors.As(err, &brokerUnreachable)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+)
+
+// newMockPinger tạo clientPinger trên sarama.MockBroker, không retry metadata để broker tắt thì Ping lỗi ngay.
+// stopBroker tắt broker giữa test, gọi nhiều lần vẫn an toàn.
+func newMockPinger(t *testing.T) (pinger clientPinger, stopBroker func()) {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	var once sync.Once
+	stopBroker = func() { once.Do(broker.Close) }
+	t.Cleanup(stopBroker)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("notifications.normal", 0, broker.BrokerID()),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0
+	config.Metadata.Retry.Max = 0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return clientPinger{client: client, brokers: []string{broker.Addr()}}, stopBroker
+}
+
+func getPath(handler gin.HandlerFunc, path string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET(path, handler)
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder
+}
+
+func TestLiveHandler(t *testing.T) {
+	// live không gọi Kafka nên vẫn 200 khi không có broker nào
+	recorder := getPath(liveHandler(), "/health/live")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", recorder.Code)
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	t.Run("kafka reachable", func(t *testing.T) {
+		pinger, _ := newMockPinger(t)
+		recorder := getPath(readyHandler(pinger), "/health/ready")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", recorder.Code, recorder.Body.String())
+		}
+	})
+
+	t.Run("kafka down", func(t *testing.T) {
+		pinger, stopBroker := newMockPinger(t)
+		stopBroker()
+		recorder := getPath(readyHandler(pinger), "/health/ready")
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, want 503: %s", recorder.Code, recorder.Body.String())
+		}
+		if body := decodeBody(t, recorder); body["kafka"] != "unreachable" {
+			t.Fatalf("response = %v, want kafka unreachable", body)
+		}
+	})
+
+	t.Run("client closed", func(t *testing.T) {
+		pinger, _ := newMockPinger(t)
+		pinger.client.Close()
+		recorder := getPath(readyHandler(pinger), "/health/ready")
+		if recorder.Code != http.StatusInternalServerError {
+			t.Fatalf("status = %d, want 500: %s", recorder.Code, recorder.Body.String())
+		}
+	})
+}
+
+type blockingPinger struct {
+	release chan struct{}
+}
+
+func (p blockingPinger) Ping() error {
+	<-p.release
+	return nil
+}
+
+func TestPingWithTimeout(t *testing.T) {
+	pinger := blockingPinger{release: make(chan struct{})}
+	defer close(pinger.release)
+
+	err := pingWithTimeout(pinger, 10*time.Millisecond)
+	if !errors.Is(err, errPingTimeout) {
+		t.Fatalf("pingWithTimeout() error = %v, want %v", err, errPingTimeout)
+	}
+	if !isKafkaUnavailable(err) {
+		t.Fatal("ping timeout should be reported as kafka unavailable")
+	}
+}
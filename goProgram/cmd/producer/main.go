@@ -0,0 +1,488 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/admin"
+	"kafka-notify/pkg/buffer"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/dedup"
+	"kafka-notify/pkg/flags"
+	"kafka-notify/pkg/idempotency"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/logger"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/openapi"
+	"kafka-notify/pkg/partitioner"
+	"kafka-notify/pkg/ratelimit"
+	"kafka-notify/pkg/receipt"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/tracing"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+)
+
+// log được khởi tạo trong main sau khi đọc LOG_LEVEL/LOG_FORMAT
+var log zerolog.Logger
+
+// thời gian tối đa chờ các request đang xử lý hoàn thành khi shutdown
+const shutdownTimeout = 10 * time.Second
+
+// apiAuthMiddleware chọn cách xác thực các route API theo JWT_SECRET và OAUTH2_ISSUER_URL,
+// nil nghĩa là không xác thực
+func apiAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	client := middleware.OAuth2Client{ID: cfg.OAuth2ClientID, Secret: cfg.OAuth2ClientSecret}
+	switch {
+	case cfg.JWTSecret != "" && cfg.OAuth2IssuerURL != "":
+		return middleware.UserOrClientAuthMiddleware(cfg.JWTSecret, cfg.OAuth2IssuerURL, cfg.OAuth2Audience, client)
+	case cfg.OAuth2IssuerURL != "":
+		return middleware.OAuth2ClientCredentialsMiddleware(cfg.OAuth2IssuerURL, cfg.OAuth2Audience, client)
+	case cfg.JWTSecret != "":
+		return middleware.JWTAuthMiddleware(cfg.JWTSecret)
+	default:
+		return nil
+	}
+}
+
+// setupFlagStore đọc feature flag từ Redis hash FEATURE_FLAGS_REDIS_KEY nếu có, không thì từ biến môi trường
+func setupFlagStore(cfg *config.Config) (flags.FlagStore, func(), error) {
+	if cfg.FeatureFlagsRedisKey == "" {
+		return flags.EnvFlagStore{}, func() {}, nil
+	}
+
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	flagStore := flags.NewRedisFlagStore(client, cfg.FeatureFlagsRedisKey, flags.DefaultRefreshInterval)
+	flagStore.OnError = func(err error) {
+		log.Warn().Err(err).Msg("failed to refresh feature flags, keeping previous values")
+	}
+	return flagStore, func() { flagStore.Close() }, nil
+}
+
+// Nếu không có REDIS_URL thì chỉ lọc trùng trong bộ nhớ của instance hiện tại
+func setupIdempotencyStore(cfg *config.Config) (idempotency.Store, func(), error) {
+	if cfg.RedisURL == "" {
+		return idempotency.NewMemoryStore(cfg.IdempotencyWindow), func() {}, nil
+	}
+
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	idempotencyStore := idempotency.NewRedisStore(client, cfg.IdempotencyWindow)
+	return idempotencyStore, func() { idempotencyStore.Close() }, nil
+}
+
+// setupRateLimiter dùng token bucket trong bộ nhớ của pod, hoặc Redis khi RATELIMIT_BACKEND=redis
+// để mọi replica dùng chung quota.
+func setupRateLimiter(cfg *config.Config) (*middleware.RateLimiter, func(), error) {
+	if cfg.RateLimitBackend != config.RateLimitBackendRedis {
+		return middleware.NewRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst), func() {}, nil
+	}
+
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	redisLimiter := ratelimit.NewRedisLimiter(client)
+	limiter := middleware.NewRedisRateLimiter(redisLimiter, cfg.RateLimitRPS, cfg.RateLimitBurst)
+	limiter.OnError = func(err error) {
+		log.Warn().Err(err).Msg("redis rate limit failed, allowing request")
+	}
+	return limiter, func() { redisLimiter.Close() }, nil
+}
+
+func main() {
+	startedAt := time.Now()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("failed to load config: %v", err))
+	}
+	if err := logger.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		panic(fmt.Sprintf("failed to setup logger: %v", err))
+	}
+	log = logger.Component("producer")
+
+	tracerProvider, err := tracing.SetupTracer("kafka-notify-producer")
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize tracer")
+	}
+
+	// tạo topic theo TOPICS_CONFIG_FILE và KAFKA_AUTO_CREATE_TOPICS trước khi producer/consumer group dùng tới
+	changedTopics, err := admin.EnsureConfiguredTopics(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create configured topics")
+	}
+	if len(changedTopics) > 0 {
+		log.Info().Strs("topics", changedTopics).Msg("topics created or extended")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	flagStore, closeFlags, err := setupFlagStore(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize feature flags")
+	}
+	defer closeFlags()
+	// campaign runner và các goroutine nền khác đọc flag qua ctx, request HTTP qua FeatureFlagMiddleware
+	ctx = flags.WithStore(ctx, flagStore)
+
+	users, closeUsers, err := store.OpenUserStore(context.Background(), cfg.DatabaseURL, cfg.Tenants...)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize user store")
+	}
+	defer closeUsers()
+
+	idempotencyStore, closeIdempotencyStore, err := setupIdempotencyStore(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize idempotency store")
+	}
+	defer closeIdempotencyStore()
+
+	scheduled, closeScheduled, err := setupScheduledStore(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize scheduled notification store")
+	}
+	defer closeScheduled()
+
+	templates, closeTemplates, err := setupTemplateStore(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize template store")
+	}
+	defer closeTemplates()
+
+	campaigns, closeCampaigns, err := setupCampaignStore(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize campaign store")
+	}
+	defer closeCampaigns()
+	groups := store.NewMemoryGroupStore(cfg.MaxGroupSize)
+	// publish là một lần broadcast nên số subscriber dùng chung giới hạn MAX_FANOUT_SIZE
+	channels := store.NewMemorySubscriptionStore(cfg.MaxFanoutSize)
+
+	if cfg.SchemaRegistryURL != "" {
+		if err := codec.SetupAvro(cfg.SchemaRegistryURL, cfg.SchemaRegistrySubject, cfg.AvroCompatibility); err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize avro codec")
+		}
+	}
+	notificationCodec, err := codec.New(cfg.NotificationEncoding)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize codec")
+	}
+	opts := sender.NewOptions(cfg, notificationCodec)
+	if cfg.DedupTTL > 0 {
+		client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize dedup cache")
+		}
+		opts.Dedup = dedup.NewDeduplicationCache(client, cfg.DedupTTL)
+		defer opts.Dedup.Close()
+	}
+	if cfg.NotificationOrdering == config.OrderingFIFO {
+		client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize sticky partition cache")
+		}
+		defer client.Close()
+		partitioner.PinSenders(client, cfg.StickyPartitionTTL)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	requestLogLevel, err := zerolog.ParseLevel(cfg.RequestLogLevel)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid REQUEST_LOG_LEVEL")
+	}
+	router := gin.New()
+	router.Use(gin.Recovery(), middleware.CORSMiddleware(cfg.CORS),
+		middleware.BodyLimitMiddleware(int64(cfg.MaxRequestBodyBytes)), middleware.TraceContextMiddleware(),
+		middleware.CorrelationMiddleware(),
+		middleware.RequestLogger(logger.Component("http").Level(requestLogLevel),
+			middleware.APIV1+"/send", "/send"),
+		middleware.TenantMiddleware(cfg.Tenants), middleware.FeatureFlagMiddleware(flagStore))
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	spec, err := openapi.Spec()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to build openapi spec")
+	}
+	router.GET(openapi.SpecPath, openapi.SpecHandler(spec))
+	router.GET(openapi.DocsPath, openapi.DocsHandler())
+
+	pinger, closePinger, err := setupKafkaPinger(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize health check")
+	}
+	defer closePinger()
+	breaker := newSendBreaker()
+	router.GET("/health", healthHandler(pinger, breaker, startedAt))
+	router.GET("/health/live", liveHandler())
+	router.GET("/health/ready", readyHandler(pinger))
+
+	// route API nằm dưới /v1, đường dẫn cũ không version vẫn chạy nhưng trả header Deprecation/Sunset
+	apiGroups := []*gin.RouterGroup{
+		router.Group(middleware.APIV1),
+		router.Group("", middleware.DeprecationWarning(config.LegacyRoutesDeprecatedAt, config.LegacyRoutesSunsetAt)),
+	}
+
+	if cfg.AdminAPIKey != "" {
+		adminClient, err := admin.NewClient(cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize admin client")
+		}
+		defer adminClient.Close()
+		for _, api := range apiGroups {
+			adminGroup := api.Group("/admin")
+			adminGroup.Use(middleware.AdminAPIKeyMiddleware(cfg.AdminAPIKey))
+			if cfg.JWTSecret != "" {
+				// khi bật JWT, ngoài API key còn cần token có role admin
+				adminGroup.Use(middleware.JWTAuthMiddleware(cfg.JWTSecret), middleware.RequireRole(models.RoleAdmin))
+			}
+			registerAdminRoutes(adminGroup, adminClient)
+		}
+	} else {
+		log.Info().Msg("ADMIN_API_KEY is not set, /admin routes are disabled")
+	}
+
+	// dùng chung một middleware cho mọi apiGroups để cache introspection không bị tách đôi
+	apiAuth := apiAuthMiddleware(cfg)
+	if apiAuth == nil {
+		log.Warn().Msg("JWT_SECRET and OAUTH2_ISSUER_URL are not set, /send is unauthenticated")
+	}
+	if scheduled == nil {
+		log.Info().Msg("REDIS_URL is not set, scheduled delivery (deliver_at) is disabled")
+	}
+	// dùng chung một limiter để /v1 và alias cũ không nhân đôi quota
+	limiter, closeLimiter, err := setupRateLimiter(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize rate limiter")
+	}
+	defer closeLimiter()
+	receipts := receipt.NewMemoryStore()
+	receiptGroup, err := setupReceiptConsumer(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize receipt consumer")
+	}
+
+	// wg chờ các goroutine nền (ví dụ drainAsyncProducer) kết thúc khi shutdown.
+	// senders là các goroutine gửi bằng producer, phải dừng hẳn trước closeProducer
+	var wg, senders sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runReceiptConsumer(ctx, receiptGroup, cfg.ReceiptsTopic, receipts)
+	}()
+	if cfg.ConfigFile != "" {
+		// rate limit đọc config.Active() ở mỗi request, topic routing thì phải cập nhật vào router của opts.
+		// Consumer chỉ subscribe topic routing lúc khởi động nên topic mới cần restart consumer.
+		configWatcher, err := config.NewConfigWatcher(cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize config watcher")
+		}
+		configWatcher.OnReload = func(next *config.Config) {
+			opts.Router.SetRoutes(next.KafkaTopicRouting)
+			log.Info().Str("file", next.ConfigFile).Msg("config reloaded")
+		}
+		configWatcher.OnError = func(err error) {
+			log.Warn().Err(err).Msg("config reload failed, keeping the previous config")
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			configWatcher.Run(ctx)
+		}()
+	}
+	if cfg.PartitionWatchInterval > 0 {
+		watcherClient, err := admin.NewClient(cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize partition watcher")
+		}
+		defer watcherClient.Close()
+		watcher := watcherClient.WatchPartitions(cfg.ConsumerTopics(), cfg.PartitionWatchInterval)
+		watcher.OnError = func(err error) {
+			log.Warn().Err(err).Msg("failed to refresh partition counts")
+		}
+		changes := watcher.Subscribe()
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			watcher.Run(ctx)
+		}()
+		go func() {
+			defer wg.Done()
+			followPartitionChanges(changes)
+		}()
+	}
+	var (
+		closeProducer func() error
+		sendRoutes    func(authed *gin.RouterGroup)
+	)
+	switch cfg.ProducerMode {
+	case config.ProducerModeAsync:
+		producer, err := kafka.SetupAsyncProducer(cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize async producer")
+		}
+		closeProducer = producer.Close
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			drainAsyncProducer(producer, &AsyncProducerStats{}, breaker)
+		}()
+		sendRoutes = func(authed *gin.RouterGroup) {
+			authed.POST("/send", sendMessageAsyncHandler(producer, opts, users, idempotencyStore, scheduled, templates, breaker))
+		}
+	default:
+		producer, err := kafka.SetupProducer(cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize producer")
+		}
+		closeProducer = producer.Close
+
+		// transactional producer không gửi được message ngoài transaction,
+		// nên /send vẫn dùng producer thường còn batch và broadcast dùng producer riêng
+		var batchProducer batchSender = producer
+		if cfg.TransactionalID != "" {
+			txnProducer, err := kafka.SetupTransactionalProducer(cfg, cfg.TransactionalID)
+			if err != nil {
+				log.Fatal().Err(err).Msg("failed to initialize transactional producer")
+			}
+			batchProducer = txnProducer
+			closeProducer = func() error {
+				return errors.Join(producer.Close(), txnProducer.Close())
+			}
+		}
+
+		// disk buffer chỉ có ở mode sync vì breaker chỉ bọc SyncProducer
+		var buf *buffer.DiskBuffer
+		if cfg.BufferPath != "" {
+			buf, err = buffer.Open(cfg.BufferPath)
+			if err != nil {
+				log.Fatal().Err(err).Msg("failed to open producer buffer")
+			}
+			defer buf.Close()
+
+			senders.Add(1)
+			go func() {
+				defer senders.Done()
+				runBufferFlusher(ctx, buf, producer, breaker, cfg.BufferFlushInterval)
+			}()
+		}
+
+		runner := &CampaignRunner{Campaigns: campaigns, Producer: batchProducer, Opts: opts, Users: users}
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			runner.Run(ctx)
+		}()
+
+		sendRoutes = func(authed *gin.RouterGroup) {
+			authed.POST("/send", sendMessageHandler(producer, opts, users, idempotencyStore, scheduled, templates, breaker, buf))
+			authed.POST("/send/batch", sendBatchHandler(batchProducer, opts, users))
+			authed.POST("/broadcast", broadcastHandler(batchProducer, opts, users, cfg.MaxFanoutSize))
+			authed.POST("/send/group", sendGroupHandler(batchProducer, opts, users, groups))
+			authed.POST("/channels/:name/publish", publishChannelHandler(batchProducer, opts, users, channels))
+			authed.POST("/campaigns", createCampaignHandler(campaigns, cfg.MaxFanoutSize))
+			authed.GET("/campaigns/:id", getCampaignHandler(campaigns))
+			if apiAuth != nil {
+				// producer không biết notification thuộc về ai nên chỉ admin được xoá
+				authed.DELETE("/notifications/:id", middleware.RequireRole(models.RoleAdmin),
+					deleteNotificationHandler(producer, opts))
+			} else {
+				authed.DELETE("/notifications/:id", deleteNotificationHandler(producer, opts))
+			}
+			if buf != nil {
+				// số message đang chờ trong buffer là thông tin vận hành nên chỉ admin được xem
+				if apiAuth != nil {
+					authed.GET("/producer/buffer-size", middleware.RequireRole(models.RoleAdmin), bufferSizeHandler(buf))
+				} else {
+					authed.GET("/producer/buffer-size", bufferSizeHandler(buf))
+				}
+			}
+		}
+	}
+
+	for _, api := range apiGroups {
+		authed := api.Group("")
+		if apiAuth != nil {
+			authed.Use(apiAuth, middleware.RequireRole(models.RoleUser, models.RoleAdmin))
+		}
+		authed.Use(middleware.RateLimitMiddleware(limiter))
+
+		authed.GET("/receipts", receiptsHandler(receipts))
+		authed.POST("/templates", createTemplateHandler(templates))
+		authed.GET("/templates/:id", getTemplateHandler(templates))
+		authed.POST("/groups", createGroupHandler(groups, users))
+		authed.POST("/groups/:id/members", addGroupMembersHandler(groups, users))
+		authed.DELETE("/groups/:id/members/:userID", removeGroupMemberHandler(groups))
+		authed.POST("/channels", createChannelHandler(channels))
+		authed.POST("/channels/:name/subscribe", subscribeChannelHandler(channels, users))
+		authed.GET("/channels/:name/subscribers", listSubscribersHandler(channels))
+		authed.POST("/send/dry-run", dryRunHandler(opts, users, templates, cfg.NotificationEncoding))
+		if scheduled != nil {
+			authed.GET("/scheduled", listScheduledHandler(scheduled))
+			authed.DELETE("/scheduled/:id", cancelScheduledHandler(scheduled))
+		}
+		sendRoutes(authed)
+	}
+
+	httpServer := &http.Server{
+		Addr:    cfg.ProducerPort,
+		Handler: router,
+	}
+
+	log.Info().
+		Str("mode", cfg.ProducerMode).
+		Str("topicPrefix", cfg.KafkaTopicPrefix).
+		Msgf("Kafka PRODUCER 📨 started at http://localhost%s", cfg.ProducerPort)
+
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("failed to run the server")
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info().Msg("shutdown: signal received, stopping HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to stop HTTP server gracefully")
+	}
+
+	// ctx đã bị huỷ, chờ các goroutine đang gửi dừng để không gửi vào producer đã đóng
+	log.Info().Msg("shutdown: waiting for producer senders")
+	senders.Wait()
+
+	// Close flush các message còn trong buffer trước khi đóng kết nối
+	log.Info().Msg("shutdown: flushing and closing producer")
+	if err := closeProducer(); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to close producer")
+	}
+
+	log.Info().Msg("shutdown: waiting for background goroutines")
+	wg.Wait()
+	if err := receiptGroup.Close(); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to close receipt consumer")
+	}
+	log.Info().Msg("shutdown: flushing traces")
+	if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to flush traces")
+	}
+	log.Info().Msg("shutdown: complete")
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"kafka-notify/pkg/admin"
+	"kafka-notify/pkg/partitioner"
+)
+
+// followPartitionChanges cập nhật số partition cho UserPartitioner tới khi watcher dừng.
+func followPartitionChanges(changes <-chan admin.PartitionChange) {
+	for change := range changes {
+		partitioner.SetPartitionCount(change.Topic, change.New)
+		if change.Old == 0 {
+			continue
+		}
+		// key đang map sang partition khác nên thứ tự theo user chỉ được giữ từ sau thời điểm này
+		log.Warn().
+			Str("topic", change.Topic).
+			Int32("oldPartitions", change.Old).
+			Int32("newPartitions", change.New).
+			Msg("topic partition count changed")
+	}
+}
@@ -1,37 +1,36 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/IBM/sarama"
-	"github.com/gin-gonic/gin"
-	models "kafka-notify/pkg"
-	"log"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/buffer"
+	"kafka-notify/pkg/dedup"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/idempotency"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/metrics"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/schedule"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/template"
 	"net/http"
 	"strconv"
-)
+	"sync"
+	"sync/atomic"
+	"time"
 
-const (
-	// Broker is the Kafka broker address
-	ProducerPort       = ":8080"
-	KafkaServerAddress = "localhost:9092"
-	kafkaTopic         = "notifications"
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sony/gobreaker"
 )
 
 // =============HELPER FUNCTIONS==============
 
-var ErrUserNotFoundInProducer = errors.New("user not found in producer")
-
-func findUserById(id int, users []models.User) (models.User, error) {
-	for _, user := range users {
-		if user.ID == id {
-			return user, nil
-		}
-	}
-	return models.User{}, ErrUserNotFoundInProducer
-}
-
 func getIdFromRequest(formValue string, ctx *gin.Context) (int, error) {
 	id, err := strconv.Atoi(ctx.PostForm(formValue)) //Convert string to int ASCII to Int, kiểu string phải là số
 	if err != nil {
@@ -40,122 +39,419 @@ func getIdFromRequest(formValue string, ctx *gin.Context) (int, error) {
 	return id, nil
 }
 
+// priority không bắt buộc, mặc định là normal
+func getPriorityFromRequest(ctx *gin.Context) (int, error) {
+	value := ctx.PostForm("priority")
+	if value == "" {
+		return models.PriorityNormal, nil
+	}
+	priority, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("Fail to parse priority from value %s: %w", value, err)
+	}
+	return priority, nil
+}
+
+// metadata không bắt buộc, là JSON object string -> string, ví dụ {"source":"mobile","app_version":"2.1"}
+func getMetadataFromRequest(ctx *gin.Context) (map[string]string, error) {
+	value := ctx.PostForm("metadata")
+	if value == "" {
+		return nil, nil
+	}
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(value), &metadata); err != nil {
+		return nil, fmt.Errorf("metadata must be a JSON object of strings: %w", err)
+	}
+	return metadata, nil
+}
+
+// threadRef là thread của notification gửi qua /send.
+type threadRef struct {
+	ID        string
+	ReplyToID string
+	// New là true nếu ID được sinh mới vì request không có threadID
+	New bool
+}
+
+// threadID bỏ trống thì notification mở thread mới với ID (UUID) mới,
+// replyToID không bắt buộc, là ID của notification được trả lời
+func getThreadFromRequest(ctx *gin.Context) threadRef {
+	thread := threadRef{ID: ctx.PostForm("threadID"), ReplyToID: ctx.PostForm("replyToID")}
+	if thread.ID == "" {
+		thread.ID = uuid.NewString()
+		thread.New = true
+	}
+	return thread
+}
+
+// ttl_seconds không bắt buộc, bỏ trống thì dùng fallback (NOTIFICATION_DEFAULT_TTL), 0 là không hết hạn
+func getTTLFromRequest(ctx *gin.Context, fallback time.Duration) (time.Duration, error) {
+	value := ctx.PostForm("ttl_seconds")
+	if value == "" {
+		return fallback, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("ttl_seconds must be a non-negative number, got %q", value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// fromID có thể bỏ trống nếu request đã được xác thực bằng JWT,
+// khi đó lấy user_id từ token
+func getFromIdFromRequest(ctx *gin.Context) (int, error) {
+	if ctx.PostForm("fromID") == "" {
+		if userID, ok := middleware.AuthedUserID(ctx); ok {
+			return userID, nil
+		}
+	}
+	return getIdFromRequest("fromID", ctx)
+}
+
 // ============== KAFKA RELATED FUNCTIONS ==============
+
 // * sarama.SyncProducer là gửi message đồng bộ, phải chờ xác nhận từ Kafka server thì mới thực hiện tác vụ khác
 // đảm bảo dữ liệu đã được ghi thành công, tính nhất quán và an toàn dữ liệu
-func sendKafkaMessage(producer sarama.SyncProducer,
-	users []models.User, ctx *gin.Context, fromID, toID int) error {
-	message := ctx.PostForm("message")
-	fromUser, err := findUserById(fromID, users)
+// ctx hết hạn trước khi Kafka xác nhận thì trả về lỗi wrap context.DeadlineExceeded.
+// Giá trị trả về là ID của notification, dùng để tra cứu GET /receipts.
+func sendKafkaMessage(ctx context.Context, producer sarama.SyncProducer, opts sender.Options, users store.UserStore,
+	fromID, toID int, message string, priority int, notificationType string, metadata map[string]string,
+	thread threadRef, headers ...sarama.RecordHeader) (string, error) {
+	if err := checkMessageSize(opts, message); err != nil {
+		return "", err
+	}
+	notification, err := buildNotification(ctx, users, fromID, toID, message, priority, notificationType, metadata, thread)
 	if err != nil {
-		return err
+		return "", err
 	}
+	_, _, err = sender.Send(ctx, producer, opts, notification, headers...)
+	return notification.ID, err
+}
 
-	toUser, err := findUserById(toID, users)
-	if err != nil {
-		return err
+// BodyLimitMiddleware chỉ chặn request HTTP, kiểm tra lại ở đây cho caller gọi trực tiếp
+func checkMessageSize(opts sender.Options, message string) error {
+	if opts.MaxRequestBytes > 0 && len(message) > opts.MaxRequestBytes {
+		return &apperrors.ErrMessageTooLarge{
+			Size: len(message), MaxSize: opts.MaxRequestBytes, Limit: "MAX_REQUEST_BODY_BYTES",
+		}
 	}
+	return nil
+}
 
-	notification := models.Notification{
-		From:    fromUser,
-		To:      toUser,
-		Message: message,
+// buildNotification tạo notification từ các field của /send.
+func buildNotification(ctx context.Context, users store.UserStore, fromID, toID int, message string, priority int,
+	notificationType string, metadata map[string]string, thread threadRef) (models.Notification, error) {
+	notification, err := sender.BuildNotification(ctx, users, fromID, toID, message, priority)
+	if err != nil {
+		return models.Notification{}, err
 	}
+	notification.Type = notificationType
+	notification.Metadata = metadata
+	notification.ThreadID = thread.ID
+	notification.ReplyToID = thread.ReplyToID
+	notification.NewThread = thread.New
+	return notification, nil
+}
 
-	//parse to Json, ngược lại là unMarshal
-	notificationJSON, err := json.Marshal(notification)
+// instrumentedSendKafkaMessage gọi sendKafkaMessage và ghi lại metrics Prometheus.
+func instrumentedSendKafkaMessage(ctx context.Context, producer sarama.SyncProducer, opts sender.Options, users store.UserStore,
+	fromID, toID int, message string, priority int, notificationType string, metadata map[string]string,
+	thread threadRef, headers ...sarama.RecordHeader) (string, error) {
+	start := time.Now()
+	notificationID, err := sendKafkaMessage(ctx, producer, opts, users, fromID, toID, message, priority, notificationType,
+		metadata, thread, headers...)
+	metrics.ObserveSend(start, err)
+	return notificationID, err
+}
+
+// * sarama.AsyncProducer chỉ đẩy message vào channel Input() rồi trả về ngay,
+// kết quả gửi được trả về qua Successes() và Errors() (xem drainAsyncProducer).
+// Giống sendKafkaMessage, giá trị trả về là ID của notification.
+func sendKafkaMessageAsync(ctx context.Context, producer sarama.AsyncProducer, opts sender.Options, users store.UserStore,
+	fromID, toID int, message string, priority int, notificationType string, metadata map[string]string,
+	thread threadRef, headers ...sarama.RecordHeader) (string, error) {
+	if err := checkMessageSize(opts, message); err != nil {
+		return "", err
+	}
+	notification, err := buildNotification(ctx, users, fromID, toID, message, priority, notificationType, metadata, thread)
 	if err != nil {
-		return fmt.Errorf("Failed to marshal notification: %w", err) //wrapping error đễ dễ dàng đọc lỗi và kiểm soát
-	}
-
-	//Sử dụng &sarama.ProducerMessage là để tạo msg có kiểu là biến con trỏ
-	// mục đích sau khi tạo ra nó, thì có thể thao tác thay đổi giá trị trực tiếp của nó, nếu không dùng pointer thì ko thay đổi được
-	//EXAMPLE:
-	//msg.Topic = "NewTopic"
-	//msg.Key = sarama.StringEncoder("NewKey")
-	//msg.Value = sarama.StringEncoder("NewValue")
-	msg := &sarama.ProducerMessage{
-		Topic: kafkaTopic,
-		Key:   sarama.StringEncoder(strconv.Itoa(toUser.ID)), //Convert int to string  int to ASCII
-		Value: sarama.StringEncoder(notificationJSON),        //StringEncoder là để parse sang kiểu dữ liệu có thể gửi cho Kafka
-	}
-	// return 3 value: partition, offset, error
-	/*
-		partition: số partition của topic mà thông điệp đã được gửi đến. Mỗi topic có thể được chia thành nhiều partition để phân tán dữ liệu.
-		offset: vị trí của partition
-	*/
-	_, _, err = producer.SendMessage(msg)
-	return err
-}
-
-func sendMessageHandler(producer sarama.SyncProducer, users []models.User) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		fromID, err := getIdFromRequest("fromID", ctx)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
-			return
-		}
+		return "", err
+	}
+	return notification.ID, sender.SendAsync(ctx, producer, opts, notification, headers...)
+}
 
-		toID, err := getIdFromRequest("toID", ctx)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
-			return
-		}
+// AsyncProducerStats đếm số message async đã gửi thành công / thất bại.
+type AsyncProducerStats struct {
+	Successes atomic.Int64
+	Errors    atomic.Int64
+}
 
-		err = sendKafkaMessage(producer, users, ctx, fromID, toID)
-		if errors.Is(err, ErrUserNotFoundInProducer) {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"message": err.Error(),
-			})
-			return
+// drainAsyncProducer phải chạy suốt vòng đời của producer, nếu không đọc
+// Successes() và Errors() thì producer sẽ bị block (deadlock).
+// Kết quả gửi được ghi vào breaker (xem guardedSendKafkaMessageAsync), message lỗi được xoá khỏi dedup cache.
+func drainAsyncProducer(producer sarama.AsyncProducer, stats *AsyncProducerStats, breaker *gobreaker.CircuitBreaker) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range producer.Successes() {
+			stats.Successes.Add(1)
+			metrics.RecordResult(nil)
+			recordAsyncResult(breaker, nil)
 		}
-		ctx.JSON(http.StatusOK, gin.H{
-			"message": "Notification sent successfully!",
-		})
+	}()
+	go func() {
+		defer wg.Done()
+		for err := range producer.Errors() {
+			stats.Errors.Add(1)
+			metrics.RecordResult(err.Err)
+			recordAsyncResult(breaker, err.Err)
+			sender.ReleaseAsync(err.Msg)
+			log.Error().Err(err.Err).
+				Str("topic", err.Msg.Topic).
+				Int32("partition", err.Msg.Partition).
+				Msg("failed to send async message")
+		}
+	}()
+	wg.Wait()
+}
+
+// idempotencyKeyFromRequest lấy idempotency_key từ form, sinh mới nếu client không gửi.
+func idempotencyKeyFromRequest(ctx *gin.Context) (string, error) {
+	key := ctx.PostForm("idempotency_key")
+	if key == "" {
+		return idempotency.NewKey(), nil
+	}
+	if err := idempotency.ValidateKey(key); err != nil {
+		return "", err
 	}
+	return key, nil
 }
 
-/*
-Việc cấu hình Return.Successes là một phần quan trọng trong quá trình xác nhận và đảm bảo tính nhất quán khi gửi thông điệp đến Kafka.
-Nếu không bật tùy chọn này, bạn sẽ không biết được thông điệp đã gửi thành công hay không,
-và dữ liệu có thể bị mất hoặc không nhất quán trong trường hợp lỗi.
-*/
-//config.Producer.Flush nếu muốn cấu hình
-func setupProducer() (sarama.SyncProducer, error) {
-	config := sarama.NewConfig()
-	config.Producer.Return.Successes = true
-	producer, err := sarama.NewSyncProducer([]string{KafkaServerAddress},
-		config)
+// claimKey kiểm tra rồi ghi nhận idempotency key của caller trước khi gửi, trả về true (và đã ghi response)
+// nếu request phải dừng lại: lỗi store, hoặc key đã được gửi trong IDEMPOTENCY_WINDOW.
+// Key được gắn tenant và caller (user/client đã xác thực, không thì fromID) nên chỉ lọc trùng
+// các request của cùng một caller.
+func claimKey(ctx *gin.Context, idempotencyStore idempotency.Store, fromID int, key string) (string, bool) {
+	caller, ok := middleware.CallerID(ctx)
+	if !ok {
+		caller = "from:" + strconv.Itoa(fromID)
+	}
+	scopedKey := idempotency.ScopedKey(middleware.TenantID(ctx), caller, key)
+	seen, err := idempotencyStore.Check(ctx.Request.Context(), scopedKey)
+	if err == nil && !seen {
+		// request đồng thời cùng key có thể Record trước, khi đó coi như trùng
+		err = idempotencyStore.Record(ctx.Request.Context(), scopedKey)
+		seen = errors.Is(err, idempotency.ErrKeyRecorded)
+	}
+	if seen {
+		ctx.JSON(http.StatusOK, gin.H{"deduplicated": true, "idempotencyKey": key})
+		return "", true
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to setup producer: %w", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return "", true
 	}
-	return producer, nil
+	return scopedKey, false
 }
 
-func main() {
-	users := []models.User{
-		{ID: 1, Name: "Emma"},
-		{ID: 2, Name: "Bruno"},
-		{ID: 3, Name: "Rick"},
-		{ID: 4, Name: "Lena"},
+// releaseKeyOnFailure được defer sau claimKey: response lỗi (4xx/5xx) nghĩa là message chưa được
+// gửi, lên lịch hay buffer, nên xoá key để client retry được với cùng key. Riêng 504 và request
+// context đã hết hạn thì message vẫn có thể đã tới Kafka (giống sender.Send), key được giữ để chặn retry trùng.
+// Lỗi chỉ được log, hậu quả xấu nhất là retry bị coi là trùng tới hết IDEMPOTENCY_WINDOW.
+func releaseKeyOnFailure(ctx *gin.Context, idempotencyStore idempotency.Store, scopedKey string) {
+	status := ctx.Writer.Status()
+	if status < http.StatusBadRequest || status == http.StatusGatewayTimeout || ctx.Request.Context().Err() != nil {
+		return
 	}
+	releaseCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := idempotencyStore.Release(releaseCtx, scopedKey); err != nil {
+		log.Warn().Err(err).Str("idempotencyKey", scopedKey).Msg("failed to release idempotency key")
+	}
+}
 
-	producer, err := setupProducer()
-	if err != nil {
-		log.Fatalf("failed to initialize producer: %v", err)
+// requestHeaders là các Kafka header chung của /send: correlation ID, idempotency key
+// và deadline X-Message-TTL nếu ttl > 0.
+func requestHeaders(ctx *gin.Context, idempotencyKey string, ttl time.Duration) []sarama.RecordHeader {
+	headers := []sarama.RecordHeader{
+		kafka.Header(kafka.HeaderCorrelationID, middleware.CorrelationID(ctx)),
+		kafka.Header(idempotency.HeaderKey, idempotencyKey),
+	}
+	if ttl > 0 {
+		headers = append(headers, kafka.TTLHeader(time.Now(), ttl))
+	}
+	return headers
+}
+
+// sendRequest là các field form của /send, dùng chung cho handler sync và async
+type sendRequest struct {
+	fromID, toID     int
+	message          string
+	priority         int
+	notificationType string
+	metadata         map[string]string
+	thread           threadRef
+	ttl              time.Duration
+	deliverAt        time.Time
+	idempotencyKey   string
+}
+
+// parseSendRequest đọc form của /send, trả về false (và đã ghi response lỗi) nếu form không hợp lệ.
+// templateID khác rỗng thì message được render từ template thay cho field message.
+func parseSendRequest(ctx *gin.Context, opts sender.Options, users store.UserStore,
+	templates template.Store) (sendRequest, bool) {
+	var (
+		req sendRequest
+		err error
+	)
+	if req.fromID, err = getFromIdFromRequest(ctx); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return req, false
+	}
+	if req.toID, err = getIdFromRequest("toID", ctx); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return req, false
+	}
+	if req.message, err = messageFromRequest(ctx, templates, users, req.fromID, req.toID); err != nil {
+		writeMessageError(ctx, err)
+		return req, false
+	}
+	if req.priority, err = getPriorityFromRequest(ctx); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return req, false
+	}
+	req.notificationType = ctx.PostForm("type")
+	if req.metadata, err = getMetadataFromRequest(ctx); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return req, false
+	}
+	req.thread = getThreadFromRequest(ctx)
+	if req.ttl, err = getTTLFromRequest(ctx, opts.DefaultTTL); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return req, false
 	}
-	defer producer.Close()
-	//sử dụng để đảm bảo hàm Close được gọi khi scope này được thực thi xong,
-	//và sẽ đóng đúng cách
+	if req.deliverAt, err = getDeliverAtFromRequest(ctx); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return req, false
+	}
+	if req.idempotencyKey, err = idempotencyKeyFromRequest(ctx); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+// writeSendError ghi response cho lỗi gửi của /send, trả về false nếu err là nil.
+func writeSendError(ctx *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case writeAppError(ctx, err):
+	case errors.Is(err, models.ErrInvalidNotification):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.Is(err, dedup.ErrDuplicateMessage):
+		ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	case errors.Is(err, context.DeadlineExceeded):
+		ctx.JSON(http.StatusGatewayTimeout, gin.H{"message": err.Error()})
+	case isBreakerOpen(err):
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"message": "kafka is unavailable, try again later"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+	return true
+}
+
+// deliver_at ở tương lai thì notification được lưu vào scheduled, cmd/scheduler gửi khi tới hạn.
+func sendMessageHandler(producer sarama.SyncProducer, opts sender.Options, users store.UserStore, idempotencyStore idempotency.Store,
+	scheduled schedule.ScheduledNotificationStore, templates template.Store, breaker *gobreaker.CircuitBreaker,
+	buf *buffer.DiskBuffer) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req, ok := parseSendRequest(ctx, opts, users, templates)
+		if !ok {
+			return
+		}
+		scopedKey, stop := claimKey(ctx, idempotencyStore, req.fromID, req.idempotencyKey)
+		if stop {
+			return
+		}
+		defer releaseKeyOnFailure(ctx, idempotencyStore, scopedKey)
+		if req.deliverAt.After(time.Now()) {
+			scheduleNotification(ctx, scheduled, users, req.fromID, req.toID, req.message, req.priority, req.notificationType,
+				req.metadata, req.thread, req.ttl, req.idempotencyKey, req.deliverAt)
+			return
+		}
 
-	gin.SetMode(gin.ReleaseMode)
-	router := gin.Default()
-	router.POST("/send", sendMessageHandler(producer, users))
+		correlationID := middleware.CorrelationID(ctx)
+		sendCtx, cancel := context.WithTimeout(ctx.Request.Context(), opts.SendTimeout)
+		defer cancel()
+		notificationID, err := guardedSendKafkaMessage(sendCtx, breaker, producer, buf, opts, users, req.fromID, req.toID,
+			req.message, req.priority, req.notificationType, req.metadata, req.thread,
+			requestHeaders(ctx, req.idempotencyKey, req.ttl)...)
+		if errors.Is(err, errBuffered) {
+			ctx.JSON(http.StatusAccepted, gin.H{
+				"message":        err.Error(),
+				"idempotencyKey": req.idempotencyKey,
+				"notificationID": notificationID,
+				"threadID":       req.thread.ID,
+				"buffered":       true,
+			})
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).
+				Str("correlationID", correlationID).
+				Str("topic", opts.TopicFor(models.Notification{
+					To: models.User{TenantID: middleware.TenantID(ctx)}, Priority: req.priority, Type: req.notificationType})).
+				Int("fromID", req.fromID).
+				Int("toID", req.toID).
+				Msg("failed to send notification")
+		}
+		if writeSendError(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":        "Notification sent successfully!",
+			"idempotencyKey": req.idempotencyKey,
+			"notificationID": notificationID,
+			"threadID":       req.thread.ID,
+		})
+	}
+}
 
-	fmt.Printf("Kafka PRODUCER 📨 started at http://localhost%s\n",
-		ProducerPort)
+// sendMessageAsyncHandler giống sendMessageHandler nhưng trả về 202 ngay khi message đã vào AsyncProducer,
+// không có disk buffer nên breaker mở thì trả về 503.
+func sendMessageAsyncHandler(producer sarama.AsyncProducer, opts sender.Options, users store.UserStore, idempotencyStore idempotency.Store,
+	scheduled schedule.ScheduledNotificationStore, templates template.Store, breaker *gobreaker.CircuitBreaker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req, ok := parseSendRequest(ctx, opts, users, templates)
+		if !ok {
+			return
+		}
+		scopedKey, stop := claimKey(ctx, idempotencyStore, req.fromID, req.idempotencyKey)
+		if stop {
+			return
+		}
+		defer releaseKeyOnFailure(ctx, idempotencyStore, scopedKey)
+		if req.deliverAt.After(time.Now()) {
+			scheduleNotification(ctx, scheduled, users, req.fromID, req.toID, req.message, req.priority, req.notificationType,
+				req.metadata, req.thread, req.ttl, req.idempotencyKey, req.deliverAt)
+			return
+		}
 
-	if err := router.Run(ProducerPort); err != nil {
-		log.Printf("failed to run the server: %v", err)
+		sendCtx, cancel := context.WithTimeout(ctx.Request.Context(), opts.SendTimeout)
+		defer cancel()
+		notificationID, err := guardedSendKafkaMessageAsync(sendCtx, breaker, producer, opts, users, req.fromID, req.toID,
+			req.message, req.priority, req.notificationType, req.metadata, req.thread,
+			requestHeaders(ctx, req.idempotencyKey, req.ttl)...)
+		if writeSendError(ctx, err) {
+			return
+		}
+		ctx.JSON(http.StatusAccepted, gin.H{
+			"message":        "Notification queued successfully!",
+			"idempotencyKey": req.idempotencyKey,
+			"notificationID": notificationID,
+			"threadID":       req.thread.ID,
+		})
 	}
 }
@@ -0,0 +1,696 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/dedup"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/idempotency"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/router"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/template"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/sony/gobreaker"
+)
+
+var (
+	testSender    = models.User{ID: 1, Name: "Alice"}
+	testRecipient = models.User{ID: 2, Name: "Bob"}
+)
+
+func newTestUsers() *store.MemoryUserStore {
+	return store.NewMemoryUserStore(testSender, testRecipient)
+}
+
+func newTestOptions(t testing.TB) sender.Options {
+	t.Helper()
+	notificationCodec, err := codec.New(codec.EncodingJSON)
+	if err != nil {
+		t.Fatalf("codec.New() error = %v", err)
+	}
+	return sender.Options{
+		TopicPrefix: "notifications",
+		Codec:       notificationCodec,
+		Router:      router.NewTopicRouter(nil),
+		SendTimeout: time.Second,
+	}
+}
+
+// postForm gửi form tới handler như client gọi POST path
+func postForm(handler gin.HandlerFunc, path string, form url.Values) *httptest.ResponseRecorder {
+	return postFormRoute(handler, path, path, form)
+}
+
+// postFormRoute giống postForm với route có tham số, ví dụ /channels/:name/publish
+func postFormRoute(handler gin.HandlerFunc, route, path string, form url.Values) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST(route, handler)
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func decodeBody(t *testing.T, recorder *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var body map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, recorder.Body.String())
+	}
+	return body
+}
+
+func TestSendMessageHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		form       url.Values
+		sendErr    error
+		wantStatus int
+		wantSent   bool
+	}{
+		{
+			name:       "sent",
+			form:       url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}},
+			wantStatus: http.StatusOK,
+			wantSent:   true,
+		},
+		{
+			name:       "invalid toID",
+			form:       url.Values{"fromID": {"1"}, "toID": {"abc"}, "message": {"hello"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown recipient",
+			form:       url.Values{"fromID": {"1"}, "toID": {"99"}, "message": {"hello"}},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "empty message",
+			form:       url.Values{"fromID": {"1"}, "toID": {"2"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid metadata",
+			form:       url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}, "metadata": {"[1]"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "topic not found",
+			form:       url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}},
+			sendErr:    sarama.ErrUnknownTopicOrPartition,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "broker unreachable",
+			form:       url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}},
+			sendErr:    sarama.ErrOutOfBrokers,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := mock.NewSyncProducer()
+			if tt.sendErr != nil {
+				producer.ErrorFor = func(string) error { return tt.sendErr }
+			}
+			handler := sendMessageHandler(producer, newTestOptions(t), newTestUsers(),
+				idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil)
+
+			recorder := postForm(handler, "/send", tt.form)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			body := decodeBody(t, recorder)
+			if _, ok := body["message"].(string); !ok {
+				t.Fatalf("response has no message: %v", body)
+			}
+
+			messages := producer.Messages()
+			if !tt.wantSent {
+				if len(messages) != 0 {
+					t.Fatalf("%d messages sent, want none", len(messages))
+				}
+				return
+			}
+			if len(messages) != 1 {
+				t.Fatalf("%d messages sent, want 1", len(messages))
+			}
+			if body["notificationID"] == "" || body["idempotencyKey"] == "" {
+				t.Fatalf("response misses notificationID or idempotencyKey: %v", body)
+			}
+			var notification models.Notification
+			payload, _ := messages[0].Value.Encode()
+			if err := json.Unmarshal(payload, &notification); err != nil {
+				t.Fatalf("failed to decode payload: %v", err)
+			}
+			if notification.ID != body["notificationID"] || notification.From.ID != 1 || notification.To.ID != 2 ||
+				notification.Message != "hello" {
+				t.Fatalf("sent notification = %+v, response = %v", notification, body)
+			}
+		})
+	}
+}
+
+func TestSendMessageHandlerPriorityTopic(t *testing.T) {
+	tests := []struct {
+		priority  string
+		prefix    string
+		wantTopic string
+	}{
+		{priority: "", prefix: "notifications", wantTopic: "notifications.normal"},
+		{priority: "1", prefix: "notifications", wantTopic: "notifications.low"},
+		{priority: "2", prefix: "notifications", wantTopic: "notifications.normal"},
+		{priority: "3", prefix: "notifications", wantTopic: "notifications.high"},
+		{priority: "4", prefix: "notifications", wantTopic: "notifications.critical"},
+		{priority: "4", prefix: "acme", wantTopic: "acme.critical"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.wantTopic+"/"+tt.priority, func(t *testing.T) {
+			producer := mock.NewSyncProducer()
+			opts := newTestOptions(t)
+			opts.TopicPrefix = tt.prefix
+			handler := sendMessageHandler(producer, opts, newTestUsers(),
+				idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil)
+			form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}}
+			if tt.priority != "" {
+				form.Set("priority", tt.priority)
+			}
+
+			if recorder := postForm(handler, "/send", form); recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", recorder.Code, recorder.Body.String())
+			}
+			messages := producer.Messages()
+			if len(messages) != 1 || messages[0].Topic != tt.wantTopic {
+				t.Fatalf("messages = %v, want one in %s", messages, tt.wantTopic)
+			}
+		})
+	}
+}
+
+func TestSendMessageHandlerTTLHeader(t *testing.T) {
+	tests := []struct {
+		name       string
+		ttlSeconds string
+		defaultTTL time.Duration
+		want       time.Duration
+	}{
+		{name: "ttl_seconds", ttlSeconds: "1", want: time.Second},
+		{name: "default ttl", defaultTTL: time.Minute, want: time.Minute},
+		{name: "ttl_seconds overrides default", ttlSeconds: "1", defaultTTL: time.Minute, want: time.Second},
+		{name: "no expiry", ttlSeconds: "0", defaultTTL: time.Minute},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			producer := mock.NewSyncProducer()
+			opts := newTestOptions(t)
+			opts.DefaultTTL = tt.defaultTTL
+			handler := sendMessageHandler(producer, opts, newTestUsers(),
+				idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil)
+			form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}}
+			if tt.ttlSeconds != "" {
+				form.Set("ttl_seconds", tt.ttlSeconds)
+			}
+
+			now := time.Now()
+			if recorder := postForm(handler, "/send", form); recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", recorder.Code, recorder.Body.String())
+			}
+			var deadline string
+			for _, header := range producer.Messages()[0].Headers {
+				if string(header.Key) == kafka.HeaderMessageTTL {
+					deadline = string(header.Value)
+				}
+			}
+			if tt.want == 0 {
+				if deadline != "" {
+					t.Fatalf("%s = %s, want no header", kafka.HeaderMessageTTL, deadline)
+				}
+				return
+			}
+			unix, err := strconv.ParseInt(deadline, 10, 64)
+			if err != nil {
+				t.Fatalf("%s = %q is not a Unix timestamp", kafka.HeaderMessageTTL, deadline)
+			}
+			if want := now.Add(tt.want).Unix(); unix < want || unix > want+1 {
+				t.Fatalf("deadline = %d, want %d", unix, want)
+			}
+		})
+	}
+}
+
+func TestSendMessageHandlerIdempotencyKey(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	handler := sendMessageHandler(producer, newTestOptions(t), newTestUsers(),
+		idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil)
+	form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"},
+		"idempotency_key": {"0b6f6a52-4a8e-4d1c-9f3e-6a2d1c7b8e90"}}
+
+	// gửi lỗi thì key được nhả để client retry được với cùng key
+	producer.ErrorFor = func(string) error { return sarama.ErrOutOfBrokers }
+	if recorder := postForm(handler, "/send", form); recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("failed send status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	producer.ErrorFor = nil
+	if recorder := postForm(handler, "/send", form); recorder.Code != http.StatusOK {
+		t.Fatalf("retry status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder := postForm(handler, "/send", form)
+	if recorder.Code != http.StatusOK || decodeBody(t, recorder)["deduplicated"] != true {
+		t.Fatalf("duplicate status = %d, body %s, want 200 deduplicated", recorder.Code, recorder.Body.String())
+	}
+	if got := len(producer.Messages()); got != 1 {
+		t.Fatalf("%d messages sent, want 1", got)
+	}
+}
+
+// hết KAFKA_SEND_TIMEOUT thì message vẫn có thể đã tới Kafka: key được giữ, retry cùng key bị coi là trùng
+func TestSendMessageHandlerIdempotencyKeyKeptOnTimeout(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	producer.ErrorFor = func(string) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	}
+	opts := newTestOptions(t)
+	opts.SendTimeout = 20 * time.Millisecond
+	handler := sendMessageHandler(producer, opts, newTestUsers(),
+		idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil)
+	form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"},
+		"idempotency_key": {"0b6f6a52-4a8e-4d1c-9f3e-6a2d1c7b8e90"}}
+
+	if recorder := postForm(handler, "/send", form); recorder.Code != http.StatusGatewayTimeout {
+		t.Fatalf("timed out send status = %d, want 504: %s", recorder.Code, recorder.Body.String())
+	}
+	producer.ErrorFor = nil
+	recorder := postForm(handler, "/send", form)
+	if recorder.Code != http.StatusOK || decodeBody(t, recorder)["deduplicated"] != true {
+		t.Fatalf("retry status = %d, body %s, want 200 deduplicated", recorder.Code, recorder.Body.String())
+	}
+}
+
+// client không gửi threadID thì mỗi request có ThreadID ngẫu nhiên, dedup vẫn phải coi hai request cùng nội dung là trùng
+func TestSendMessageHandlerDedupWithoutThreadID(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	opts := newTestOptions(t)
+	opts.Dedup = dedup.NewDeduplicationCache(redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}), time.Minute)
+	handler := sendMessageHandler(producer, opts, newTestUsers(),
+		idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil)
+	form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}}
+
+	if recorder := postForm(handler, "/send", form); recorder.Code != http.StatusOK {
+		t.Fatalf("first status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := postForm(handler, "/send", form); recorder.Code != http.StatusConflict {
+		t.Fatalf("duplicate status = %d, want 409: %s", recorder.Code, recorder.Body.String())
+	}
+	if got := len(producer.Messages()); got != 1 {
+		t.Fatalf("%d messages sent, want 1", got)
+	}
+
+	// client gửi threadID thì thread là một phần nội dung, cùng message ở thread khác không trùng
+	form.Set("threadID", "thread-1")
+	if recorder := postForm(handler, "/send", form); recorder.Code != http.StatusOK {
+		t.Fatalf("other thread status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+// nhiều request trùng tới cùng lúc: đúng một request được gửi tới Kafka, còn lại trả 409
+func TestSendMessageHandlerConcurrentDuplicates(t *testing.T) {
+	const requests = 20
+	producer := mock.NewSyncProducer()
+	opts := newTestOptions(t)
+	opts.Dedup = dedup.NewDeduplicationCache(redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}), time.Minute)
+	handler := sendMessageHandler(producer, opts, newTestUsers(),
+		idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil)
+	form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}}
+
+	// dùng chung một engine vì gin.SetMode trong postForm không an toàn khi gọi song song
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/send", handler)
+	statuses := make(chan int, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, request)
+			statuses <- recorder.Code
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := make(map[int]int)
+	for status := range statuses {
+		counts[status]++
+	}
+	if counts[http.StatusOK] != 1 || counts[http.StatusConflict] != requests-1 {
+		t.Fatalf("statuses = %v, want one 200 and %d 409", counts, requests-1)
+	}
+	if got := len(producer.Messages()); got != 1 {
+		t.Fatalf("%d messages sent, want 1", got)
+	}
+}
+
+func TestSendMessageHandlerMessageTooLarge(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	opts := newTestOptions(t)
+	opts.MaxMessageBytes = 256
+	handler := sendMessageHandler(producer, opts, newTestUsers(),
+		idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil)
+
+	recorder := postForm(handler, "/send", url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {textMessage(512)}})
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413: %s", recorder.Code, recorder.Body.String())
+	}
+	body := decodeBody(t, recorder)
+	if size, ok := body["size"].(float64); !ok || size <= 256 || body["allowedBytes"] != float64(256) {
+		t.Fatalf("response = %v, want size > 256 and allowedBytes 256", body)
+	}
+	if got := len(producer.Messages()); got != 0 {
+		t.Fatalf("%d messages sent, want none", got)
+	}
+}
+
+// sendKafkaMessage giới hạn độ dài message cả khi không đi qua BodyLimitMiddleware
+func TestSendKafkaMessageRequestLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		wantSent bool
+	}{
+		{name: "exactly at the limit", size: 64, wantSent: true},
+		{name: "one byte over", size: 65},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := mock.NewSyncProducer()
+			opts := newTestOptions(t)
+			opts.MaxRequestBytes = 64
+			_, err := sendKafkaMessage(context.Background(), producer, opts, newTestUsers(), 1, 2,
+				strings.Repeat("a", tt.size), models.PriorityNormal, "", nil, threadRef{})
+			if tt.wantSent {
+				if err != nil || len(producer.Messages()) != 1 {
+					t.Fatalf("sendKafkaMessage() error = %v, %d messages sent, want 1", err, len(producer.Messages()))
+				}
+				return
+			}
+			var tooLarge *apperrors.ErrMessageTooLarge
+			if !errors.As(err, &tooLarge) || tooLarge.Size != 65 || tooLarge.MaxSize != 64 {
+				t.Fatalf("sendKafkaMessage() error = %v, want ErrMessageTooLarge 65 > 64", err)
+			}
+			if len(producer.Messages()) != 0 {
+				t.Fatalf("%d messages sent, want none", len(producer.Messages()))
+			}
+		})
+	}
+}
+
+// TestKafkaMessageKeyNeverEmpty gửi qua mọi handler tạo message Kafka với từng KAFKA_KEY_STRATEGY
+// và kiểm tra không message nào có key rỗng, điều kiện bắt buộc của topic compact.
+func TestKafkaMessageKeyNeverEmpty(t *testing.T) {
+	for _, strategy := range []string{
+		sender.KeyStrategyRecipient, sender.KeyStrategySender, sender.KeyStrategyThread, sender.KeyStrategyRandom,
+	} {
+		t.Run(strategy, func(t *testing.T) {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			cfg.KafkaKeyStrategy = strategy
+			cfg.KafkaCompactedTopic = true
+			opts := sender.NewOptions(cfg, codec.JSONCodec{})
+			ctx := context.Background()
+
+			producer := mock.NewSyncProducer()
+			users := newGroupUsers()
+			groups := store.NewMemoryGroupStore(10)
+			group, _ := groups.Create(ctx, models.Group{Name: "team", MemberIDs: []int{1, 2, 3}})
+			channels := store.NewMemorySubscriptionStore(10)
+			channels.CreateChannel(ctx, models.Channel{Name: "news"})
+			channels.Subscribe(ctx, "news", 2)
+			channels.Subscribe(ctx, "news", 3)
+
+			form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}}
+			if _, err := sendKafkaMessage(ctx, producer, opts, users, 1, 2, "hello", models.PriorityNormal, "", nil, threadRef{}); err != nil {
+				t.Fatalf("sendKafkaMessage() error = %v", err)
+			}
+			if _, err := sendKafkaMessage(ctx, producer, opts, users, 1, 2, "reply", models.PriorityHigh, "", nil,
+				threadRef{ID: "5f0c7c3e-8f9a-4a57-9d51-3b8a2f8d9e01"}); err != nil {
+				t.Fatalf("sendKafkaMessage() in a thread error = %v", err)
+			}
+			sendHandler := sendMessageHandler(producer, opts, users,
+				idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil)
+			responses := []*httptest.ResponseRecorder{
+				postForm(sendHandler, "/send", form),
+				postJSON(sendBatchHandler(producer, opts, users), "/send/batch", "/send/batch", batchSendRequest{
+					Notifications: []batchItem{{FromID: 1, ToID: 2, Message: "a"}, {FromID: 3, ToID: 4, Message: "b"}},
+				}),
+				postForm(broadcastHandler(producer, opts, users, 10), "/broadcast", url.Values{"fromID": {"1"}, "message": {"all"}}),
+				postForm(sendGroupHandler(producer, opts, users, groups), "/send/group",
+					url.Values{"fromID": {"1"}, "groupID": {strconv.Itoa(group.ID)}, "message": {"team"}}),
+				postFormRoute(publishChannelHandler(producer, opts, users, channels), "/channels/:name/publish",
+					"/channels/news/publish", url.Values{"fromID": {"1"}, "message": {"news"}}),
+			}
+			for i, recorder := range responses {
+				if recorder.Code >= http.StatusBadRequest {
+					t.Fatalf("request %d status = %d: %s", i, recorder.Code, recorder.Body.String())
+				}
+			}
+
+			messages := producer.Messages()
+			// 2 sendKafkaMessage + /send + 2 batch + 3 broadcast + 2 group + 2 channel
+			if len(messages) != 12 {
+				t.Fatalf("%d messages sent, want 12", len(messages))
+			}
+			for i, msg := range messages {
+				if msg.Key == nil || msg.Key.Length() == 0 {
+					t.Fatalf("message %d to %s has an empty key", i, msg.Topic)
+				}
+			}
+		})
+	}
+}
+
+// textMessage tạo nội dung dài size byte từ các từ ngẫu nhiên (seed cố định) để tỉ lệ nén
+// gần với tin nhắn thật hơn là lặp một chuỗi
+func textMessage(size int) string {
+	words := []string{"thông", "báo", "đơn", "hàng", "order", "shipped", "payment", "received",
+		"xin", "chào", "invoice", "#4821", "deadline", "tomorrow", "meeting", "at", "10:00"}
+	random := rand.New(rand.NewSource(1))
+	var builder strings.Builder
+	for builder.Len() < size {
+		builder.WriteString(words[random.Intn(len(words))])
+		builder.WriteByte(' ')
+	}
+	return builder.String()[:size]
+}
+
+// BenchmarkSendKafkaMessage gửi qua sarama.MockBroker với từng codec nén,
+// wire-bytes/op là số byte producer thật sự gửi lên broker cho mỗi message.
+func BenchmarkSendKafkaMessage(b *testing.B) {
+	codecs := []sarama.CompressionCodec{
+		sarama.CompressionNone, sarama.CompressionGZIP, sarama.CompressionSnappy,
+		sarama.CompressionLZ4, sarama.CompressionZSTD,
+	}
+	sizes := []struct {
+		name string
+		size int
+	}{{"1KB", 1 << 10}, {"100KB", 100 << 10}}
+
+	opts := newTestOptions(b)
+	users := newTestUsers()
+	topic := opts.TopicFor(models.Notification{To: testRecipient, Priority: models.PriorityNormal})
+	for _, size := range sizes {
+		message := textMessage(size.size)
+		for _, compression := range codecs {
+			b.Run(fmt.Sprintf("%s/%s", compression, size.name), func(b *testing.B) {
+				broker := sarama.NewMockBroker(b, 1)
+				defer broker.Close()
+				broker.SetHandlerByMap(map[string]sarama.MockResponse{
+					"MetadataRequest": sarama.NewMockMetadataResponse(b).
+						SetBroker(broker.Addr(), broker.BrokerID()).
+						SetLeader(topic, 0, broker.BrokerID()),
+					"ProduceRequest": sarama.NewMockProduceResponse(b),
+				})
+				var wireBytes atomic.Int64
+				broker.SetNotifier(func(bytesRead, bytesWritten int) {
+					wireBytes.Add(int64(bytesRead))
+				})
+
+				config := sarama.NewConfig()
+				config.Version = sarama.V2_1_0_0 // zstd cần Kafka 2.1
+				config.Producer.Return.Successes = true
+				config.Producer.Compression = compression
+				config.Producer.MaxMessageBytes = 1 << 20
+				producer, err := sarama.NewSyncProducer([]string{broker.Addr()}, config)
+				if err != nil {
+					b.Fatalf("NewSyncProducer() error = %v", err)
+				}
+				defer producer.Close()
+
+				// chỉ tính byte của các lần gửi, không tính metadata lúc kết nối
+				wireBytes.Store(0)
+				b.SetBytes(int64(size.size))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					_, err := sendKafkaMessage(context.Background(), producer, opts, users,
+						testSender.ID, testRecipient.ID, message, models.PriorityNormal, "", nil, threadRef{})
+					if err != nil {
+						b.Fatalf("sendKafkaMessage() error = %v", err)
+					}
+				}
+				b.StopTimer()
+				b.ReportMetric(float64(wireBytes.Load())/float64(b.N), "wire-bytes/op")
+			})
+		}
+	}
+}
+
+// asyncTestProducer là mocks.AsyncProducer đã được drainAsyncProducer đọc kết quả như ở main
+func asyncTestProducer(t *testing.T, breaker *gobreaker.CircuitBreaker) (*mocks.AsyncProducer, *AsyncProducerStats) {
+	t.Helper()
+	config := mocks.NewTestConfig()
+	config.Producer.Return.Successes = true
+	producer := mocks.NewAsyncProducer(t, config)
+	stats := &AsyncProducerStats{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		drainAsyncProducer(producer, stats, breaker)
+	}()
+	t.Cleanup(func() {
+		producer.Close()
+		<-done
+	})
+	return producer, stats
+}
+
+// waitAsyncResults chờ drainAsyncProducer đọc đủ n kết quả
+func waitAsyncResults(t *testing.T, stats *AsyncProducerStats, n int64) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if stats.Successes.Load()+stats.Errors.Load() >= n {
+			return
+		}
+	}
+	t.Fatalf("%d async results, want %d", stats.Successes.Load()+stats.Errors.Load(), n)
+}
+
+// /send ở mode async đi qua cùng đường gửi với mode sync: dedup chặn request trùng
+// và message lỗi được xoá khỏi dedup cache để client gửi lại được
+func TestSendMessageAsyncHandlerDedup(t *testing.T) {
+	breaker := newSendBreaker()
+	producer, stats := asyncTestProducer(t, breaker)
+	opts := newTestOptions(t)
+	opts.Dedup = dedup.NewDeduplicationCache(redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}), time.Minute)
+	handler := sendMessageAsyncHandler(producer, opts, newTestUsers(), idempotency.NewMemoryStore(time.Minute),
+		nil, template.NewMemoryStore(), breaker)
+	form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}}
+
+	producer.ExpectInputAndFail(sarama.ErrOutOfBrokers)
+	if recorder := postForm(handler, "/send", form); recorder.Code != http.StatusAccepted {
+		t.Fatalf("first status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	waitAsyncResults(t, stats, 1)
+
+	producer.ExpectInputAndSucceed()
+	recorder := postForm(handler, "/send", form)
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("retry after failure status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if decodeBody(t, recorder)["notificationID"] == "" {
+		t.Fatalf("response has no notificationID: %s", recorder.Body.String())
+	}
+	waitAsyncResults(t, stats, 2)
+
+	if recorder := postForm(handler, "/send", form); recorder.Code != http.StatusConflict {
+		t.Fatalf("duplicate status = %d, want 409: %s", recorder.Code, recorder.Body.String())
+	}
+	if stats.Successes.Load() != 1 || stats.Errors.Load() != 1 {
+		t.Fatalf("successes %d errors %d, want 1 and 1", stats.Successes.Load(), stats.Errors.Load())
+	}
+}
+
+// lỗi gửi async được tính vào breaker, breaker mở thì /send trả về 503 mà không đẩy message vào producer
+func TestSendMessageAsyncHandlerBreaker(t *testing.T) {
+	breaker := newSendBreaker()
+	producer, stats := asyncTestProducer(t, breaker)
+	handler := sendMessageAsyncHandler(producer, newTestOptions(t), newTestUsers(), idempotency.NewMemoryStore(time.Minute),
+		nil, template.NewMemoryStore(), breaker)
+
+	var sent int64
+	for breaker.State() != gobreaker.StateOpen {
+		if sent == 10 {
+			t.Fatalf("breaker is %s after %d failed sends", breaker.State(), sent)
+		}
+		producer.ExpectInputAndFail(sarama.ErrOutOfBrokers)
+		form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello " + strconv.FormatInt(sent, 10)}}
+		if recorder := postForm(handler, "/send", form); recorder.Code != http.StatusAccepted {
+			t.Fatalf("send status = %d: %s", recorder.Code, recorder.Body.String())
+		}
+		sent++
+		waitAsyncResults(t, stats, sent)
+	}
+
+	recorder := postForm(handler, "/send", url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"after"}})
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status with open breaker = %d, want 503: %s", recorder.Code, recorder.Body.String())
+	}
+	// mocks.AsyncProducer báo lỗi nếu nhận message không có expectation tương ứng
+}
+
+func TestSendMessageAsyncHandlerValidation(t *testing.T) {
+	producer, _ := asyncTestProducer(t, newSendBreaker())
+	handler := sendMessageAsyncHandler(producer, newTestOptions(t), newTestUsers(), idempotency.NewMemoryStore(time.Minute),
+		nil, template.NewMemoryStore(), newSendBreaker())
+
+	tests := []struct {
+		name       string
+		form       url.Values
+		wantStatus int
+	}{
+		{"invalid toID", url.Values{"fromID": {"1"}, "toID": {"abc"}, "message": {"hello"}}, http.StatusBadRequest},
+		{"unknown recipient", url.Values{"fromID": {"1"}, "toID": {"99"}, "message": {"hello"}}, http.StatusNotFound},
+		{"empty message", url.Values{"fromID": {"1"}, "toID": {"2"}}, http.StatusBadRequest},
+		{"invalid ttl", url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {"hello"}, "ttl_seconds": {"-1"}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if recorder := postForm(handler, "/send", tt.form); recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/receipt"
+	"net/http"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+)
+
+// ============== READ RECEIPTS ==============
+
+// setupReceiptConsumer tạo consumer group đọc receipts topic. Với receipt.MemoryStore,
+// mỗi instance chỉ thấy receipt của các partition được gán cho nó.
+func setupReceiptConsumer(cfg *config.Config) (sarama.ConsumerGroup, error) {
+	config := sarama.NewConfig()
+	if err := kafka.ApplySecurity(config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to setup receipt consumer: %w", err)
+	}
+	group, err := sarama.NewConsumerGroup(cfg.KafkaBrokers, cfg.ReceiptsGroupID, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup receipt consumer: %w", err)
+	}
+	return group, nil
+}
+
+// runReceiptConsumer lưu receipt vào receipts cho tới khi ctx bị huỷ.
+func runReceiptConsumer(ctx context.Context, group sarama.ConsumerGroup, topic string, receipts receipt.ReceiptStore) {
+	handler := &receipt.Handler{
+		Store: receipts,
+		OnError: func(msg *sarama.ConsumerMessage, err error) {
+			log.Warn().Err(err).
+				Int32("partition", msg.Partition).
+				Int64("offset", msg.Offset).
+				Msg("failed to store read receipt")
+		},
+	}
+	for {
+		if err := group.Consume(ctx, []string{topic}, handler); err != nil {
+			log.Error().Err(err).Str("topic", topic).Msg("error from receipt consumer")
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// receiptsHandler xử lý GET /receipts?notificationID=<uuid>.
+func receiptsHandler(receipts receipt.ReceiptStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		notificationID := ctx.Query("notificationID")
+		if notificationID == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "notificationID query parameter is required"})
+			return
+		}
+		found, err := receipts.FindByNotificationID(ctx.Request.Context(), notificationID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"notificationID": notificationID,
+			"read":           len(found) > 0,
+			"receipts":       found,
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/schedule"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============== SCHEDULED NOTIFICATIONS ==============
+
+var ErrSchedulingDisabled = errors.New("scheduled delivery requires REDIS_URL")
+
+// Lịch gửi được cmd/scheduler đọc từ Redis, nên không có REDIS_URL thì tắt tính năng này.
+func setupScheduledStore(cfg *config.Config) (schedule.ScheduledNotificationStore, func(), error) {
+	if cfg.RedisURL == "" {
+		return nil, func() {}, nil
+	}
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	scheduled := schedule.NewRedisStore(client)
+	return scheduled, func() { scheduled.Close() }, nil
+}
+
+// deliver_at không bắt buộc (RFC3339), bỏ trống trả về time.Time rỗng
+func getDeliverAtFromRequest(ctx *gin.Context) (time.Time, error) {
+	value := ctx.PostForm("deliver_at")
+	if value == "" {
+		return time.Time{}, nil
+	}
+	deliverAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("deliver_at must be an RFC3339 timestamp, got %q", value)
+	}
+	return deliverAt, nil
+}
+
+// scheduleNotification lưu notification vào scheduled thay vì gửi ngay và ghi response 202.
+func scheduleNotification(ctx *gin.Context, scheduled schedule.ScheduledNotificationStore, users store.UserStore,
+	fromID, toID int, message string, priority int, notificationType string,
+	metadata map[string]string, thread threadRef, ttl time.Duration, key string, deliverAt time.Time) {
+	if scheduled == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": ErrSchedulingDisabled.Error()})
+		return
+	}
+
+	notification, err := sender.BuildNotification(ctx.Request.Context(), users, fromID, toID, message, priority)
+	if writeAppError(ctx, err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	notification.Type = notificationType
+	notification.Metadata = metadata
+	notification.ThreadID = thread.ID
+	notification.ReplyToID = thread.ReplyToID
+	notification.NewThread = thread.New
+	// validate ngay để client biết lỗi, không đợi tới lúc scheduler gửi
+	if err := notification.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err = scheduled.Schedule(ctx.Request.Context(), schedule.ScheduledNotification{
+		Notification:  notification,
+		DeliverAt:     deliverAt,
+		TTL:           ttl,
+		CorrelationID: middleware.CorrelationID(ctx),
+		NewThread:     thread.New,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":        "Notification scheduled successfully!",
+		"idempotencyKey": key,
+		"notificationID": notification.ID,
+		"threadID":       notification.ThreadID,
+		"deliverAt":      deliverAt,
+	})
+}
+
+// listScheduledHandler xử lý GET /scheduled?userID=<fromID>, userID lấy từ JWT nếu bỏ trống.
+func listScheduledHandler(scheduled schedule.ScheduledNotificationStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, ok := middleware.AuthedUserID(ctx)
+		if value := ctx.Query("userID"); value != "" || !ok {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": "userID query parameter must be a number"})
+				return
+			}
+			userID = id
+		}
+		if !canManageScheduled(ctx, userID) {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "cannot access scheduled notifications of another user"})
+			return
+		}
+
+		pending, err := scheduled.ListBySender(ctx.Request.Context(), userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"scheduled": pending, "total": len(pending)})
+	}
+}
+
+// cancelScheduledHandler xử lý DELETE /scheduled/:id.
+func cancelScheduledHandler(scheduled schedule.ScheduledNotificationStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.Param("id")
+		item, err := scheduled.Get(ctx.Request.Context(), id)
+		if err == nil && !canManageScheduled(ctx, item.Notification.From.ID) {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "cannot cancel a notification scheduled by another user"})
+			return
+		}
+		if err == nil {
+			err = scheduled.Cancel(ctx.Request.Context(), id)
+		}
+		if errors.Is(err, schedule.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// khi bật JWT, user chỉ xem và huỷ được lịch gửi của chính mình
+func canManageScheduled(ctx *gin.Context, fromID int) bool {
+	authedID, ok := middleware.AuthedUserID(ctx)
+	return !ok || authedID == fromID
+}
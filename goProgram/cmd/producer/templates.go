@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ============== NOTIFICATION TEMPLATES ==============
+
+// Nếu không có REDIS_URL thì template chỉ lưu trong bộ nhớ của instance hiện tại
+func setupTemplateStore(cfg *config.Config) (template.Store, func(), error) {
+	if cfg.RedisURL == "" {
+		return template.NewMemoryStore(), func() {}, nil
+	}
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	templates := template.NewRedisStore(client)
+	return templates, func() { templates.Close() }, nil
+}
+
+// messageFromRequest trả về message của form, hoặc nội dung render từ templateID nếu có.
+func messageFromRequest(ctx *gin.Context, templates template.Store, users store.UserStore, fromID, toID int) (string, error) {
+	templateID := ctx.PostForm("templateID")
+	if templateID == "" {
+		return ctx.PostForm("message"), nil
+	}
+
+	tpl, err := templates.Get(ctx.Request.Context(), templateID)
+	if err != nil {
+		return "", err
+	}
+	fromUser, err := users.FindByID(ctx.Request.Context(), fromID)
+	if err != nil {
+		return "", fmt.Errorf("sender %d: %w", fromID, err)
+	}
+	toUser, err := users.FindByID(ctx.Request.Context(), toID)
+	if err != nil {
+		return "", fmt.Errorf("recipient %d: %w", toID, err)
+	}
+	return template.Render(tpl, map[string]any{
+		"From": fromUser.Name,
+		"To":   toUser.Name,
+	})
+}
+
+// writeMessageError ghi response cho lỗi của messageFromRequest.
+func writeMessageError(ctx *gin.Context, err error) {
+	if writeAppError(ctx, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, template.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, template.ErrInvalidTemplate):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+}
+
+// createTemplateHandler xử lý POST /templates, id bỏ trống thì sinh UUID mới.
+func createTemplateHandler(templates template.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var tpl models.Template
+		if err := ctx.ShouldBindJSON(&tpl); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if tpl.ID == "" {
+			tpl.ID = uuid.NewString()
+		}
+		if _, err := template.Parse(tpl); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if err := templates.Save(ctx.Request.Context(), tpl); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusCreated, tpl)
+	}
+}
+
+// getTemplateHandler xử lý GET /templates/:id.
+func getTemplateHandler(templates template.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tpl, err := templates.Get(ctx.Request.Context(), ctx.Param("id"))
+		if errors.Is(err, template.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, tpl)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/idempotency"
+	"kafka-notify/pkg/mock"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/template"
+	"kafka-notify/pkg/tenant"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+)
+
+var testTenants = []string{"acme", "globex"}
+
+// newTenantRouter tạo /send sau TenantMiddleware như main.go, mỗi tenant có user 1 và 2 riêng
+func newTenantRouter(t *testing.T, producer *mock.SyncProducer) *gin.Engine {
+	t.Helper()
+	var users []models.User
+	for _, tenantID := range append([]string{""}, testTenants...) {
+		users = append(users,
+			models.User{ID: 1, Name: "Alice " + tenantID, TenantID: tenantID},
+			models.User{ID: 2, Name: "Bob " + tenantID, TenantID: tenantID},
+		)
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/send", middleware.TenantMiddleware(testTenants),
+		sendMessageHandler(producer, newTestOptions(t), store.NewMemoryUserStore(users...),
+			idempotency.NewMemoryStore(time.Minute), nil, template.NewMemoryStore(), newSendBreaker(), nil))
+	return engine
+}
+
+// producedHeader trả về giá trị header key của message producer gửi, rỗng nếu không có
+func producedHeader(msg *sarama.ProducerMessage, key string) string {
+	for _, header := range msg.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func sendAsTenant(engine *gin.Engine, tenantID, message string) *httptest.ResponseRecorder {
+	form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {message}}
+	request := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if tenantID != "" {
+		request.Header.Set(tenant.HeaderTenantID, tenantID)
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestSendMessageHandlerIsolatesTenantTopics(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	engine := newTenantRouter(t, producer)
+
+	sent := map[string]int{}
+	for i := 0; i < 5; i++ {
+		for _, tenantID := range append([]string{""}, testTenants...) {
+			if recorder := sendAsTenant(engine, tenantID, "hello from "+tenantID); recorder.Code != http.StatusOK {
+				t.Fatalf("POST /send as %q status = %d, want 200: %s", tenantID, recorder.Code, recorder.Body.String())
+			}
+			sent[tenantID]++
+		}
+	}
+
+	received := map[string]int{}
+	for _, msg := range producer.Messages() {
+		var notification models.Notification
+		payload, _ := msg.Value.Encode()
+		if err := json.Unmarshal(payload, &notification); err != nil {
+			t.Fatalf("failed to decode payload: %v", err)
+		}
+		tenantID := notification.To.TenantID
+		received[tenantID]++
+
+		// topic, header và user đều phải thuộc tenant đã gửi
+		if want := tenant.Topic(tenantID, "notifications.normal"); msg.Topic != want {
+			t.Fatalf("message from tenant %q sent to %s, want %s", tenantID, msg.Topic, want)
+		}
+		if header := producedHeader(msg, tenant.HeaderTenantID); header != tenantID {
+			t.Fatalf("message in %s has %s header %q, want %q", msg.Topic, tenant.HeaderTenantID, header, tenantID)
+		}
+		if notification.From.TenantID != tenantID || notification.Message != "hello from "+tenantID ||
+			notification.To.Name != "Bob "+tenantID {
+			t.Fatalf("message in %s = %+v, want users and message of tenant %q", msg.Topic, notification, tenantID)
+		}
+		for _, other := range testTenants {
+			if other != tenantID && strings.HasPrefix(msg.Topic, other+".") {
+				t.Fatalf("message from tenant %q appeared in tenant %q topic %s", tenantID, other, msg.Topic)
+			}
+		}
+	}
+	for tenantID, count := range sent {
+		if received[tenantID] != count {
+			t.Fatalf("tenant %q has %d messages, want %d", tenantID, received[tenantID], count)
+		}
+	}
+}
+
+func TestSendMessageHandlerUnknownTenant(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	recorder := sendAsTenant(newTenantRouter(t, producer), "initech", "hello")
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("POST /send as unknown tenant status = %d, want 403", recorder.Code)
+	}
+	if messages := producer.Messages(); len(messages) != 0 {
+		t.Fatalf("%d messages sent for an unknown tenant, want none", len(messages))
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/logger"
+	"kafka-notify/pkg/replay"
+	"kafka-notify/pkg/search"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/rs/zerolog"
+)
+
+// log được khởi tạo trong main sau khi đọc LOG_LEVEL/LOG_FORMAT
+var log zerolog.Logger
+
+// reindex dựng lại search index tại BLEVE_INDEX_PATH bằng cách đọc lại các topic từ offset 0.
+// Index chỉ được mở bởi một process, vì vậy phải dừng consumer trước khi chạy.
+// Notification đã có trong index được ghi đè theo ID nên có thể chạy lại nhiều lần.
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("failed to load config: %v", err))
+	}
+	if err := logger.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		panic(fmt.Sprintf("failed to setup logger: %v", err))
+	}
+	log = logger.Component("reindex")
+
+	if cfg.BleveIndexPath == "" {
+		log.Fatal().Msg("BLEVE_INDEX_PATH is required to reindex")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.SchemaRegistryURL != "" {
+		if err := codec.SetupAvro(cfg.SchemaRegistryURL, cfg.SchemaRegistrySubject, cfg.AvroCompatibility); err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize avro codec")
+		}
+	}
+
+	index, err := search.Open(cfg.BleveIndexPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to open search index")
+	}
+	defer index.Close()
+
+	client, err := setupClient(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize kafka client")
+	}
+	defer client.Close()
+	replayer, err := replay.NewReplayConsumer(client, index, cfg.ConsumerTopics())
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize replay consumer")
+	}
+	defer replayer.Close()
+	replayer.OnSkip = func(msg *sarama.ConsumerMessage, err error) {
+		log.Warn().Err(err).
+			Str("topic", msg.Topic).Int32("partition", msg.Partition).Int64("offset", msg.Offset).
+			Msg("skipping undecodable message")
+	}
+
+	log.Info().
+		Strs("topics", cfg.ConsumerTopics()).
+		Str("index", cfg.BleveIndexPath).
+		Msg("Kafka REINDEX 🔎 started")
+	if err := replayer.Run(ctx); err != nil {
+		if errors.Is(err, context.Canceled) {
+			log.Warn().Msg("reindex interrupted, index is incomplete")
+			return
+		}
+		log.Error().Err(err).Msg("reindex failed")
+		return
+	}
+	status := replayer.Progress().Status(time.Now())
+	log.Info().
+		Int64("indexed", status.Replayed).
+		Int64("skipped", status.Skipped).
+		Str("elapsed", status.Elapsed).
+		Msg("reindex completed")
+}
+
+// setupClient dùng ReadCommitted giống consumer chính để không index message của transaction bị abort.
+func setupClient(cfg *config.Config) (sarama.Client, error) {
+	config := sarama.NewConfig()
+	config.Consumer.IsolationLevel = sarama.ReadCommitted
+	config.Consumer.Return.Errors = true
+	if err := kafka.ApplySecurity(config, cfg); err != nil {
+		return nil, err
+	}
+	return sarama.NewClient(cfg.KafkaBrokers, config)
+}
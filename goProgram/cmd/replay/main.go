@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/logger"
+	"kafka-notify/pkg/replay"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// log được khởi tạo trong main sau khi đọc LOG_LEVEL/LOG_FORMAT
+var log zerolog.Logger
+
+// thời gian tối đa chờ các request đang xử lý hoàn thành khi shutdown
+const shutdownTimeout = 10 * time.Second
+
+// replay dựng lại notification store của một query service mới từ toàn bộ dữ liệu trong Kafka.
+// Sau khi replay xong, process vẫn chạy để GET /replay/status trả về kết quả cho tới khi nhận SIGTERM.
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("failed to load config: %v", err))
+	}
+	if err := logger.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		panic(fmt.Sprintf("failed to setup logger: %v", err))
+	}
+	log = logger.Component("replay")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.SchemaRegistryURL != "" {
+		if err := codec.SetupAvro(cfg.SchemaRegistryURL, cfg.SchemaRegistrySubject, cfg.AvroCompatibility); err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize avro codec")
+		}
+	}
+
+	repository, closeRepository, err := setupRepository(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize notification repository")
+	}
+	defer closeRepository()
+
+	client, err := setupClient(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize kafka client")
+	}
+	defer client.Close()
+	replayer, err := replay.NewReplayConsumer(client, repository, cfg.ConsumerTopics())
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize replay consumer")
+	}
+	defer replayer.Close()
+	replayer.OnSkip = func(msg *sarama.ConsumerMessage, err error) {
+		log.Warn().Err(err).
+			Str("topic", msg.Topic).Int32("partition", msg.Partition).Int64("offset", msg.Offset).
+			Msg("skipping undecodable message")
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.Default()
+	router.GET("/replay/status", replay.StatusHandler(replayer.Progress()))
+	httpServer := &http.Server{
+		Addr:    cfg.ReplayPort,
+		Handler: router,
+	}
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("failed to run the server")
+			stop()
+		}
+	}()
+
+	log.Info().
+		Strs("topics", cfg.ConsumerTopics()).
+		Msgf("Kafka REPLAY ⏪ started, status at http://localhost%s/replay/status", cfg.ReplayPort)
+	go func() {
+		if err := replayer.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Error().Err(err).Msg("replay failed")
+			return
+		}
+		status := replayer.Progress().Status(time.Now())
+		log.Info().
+			Int64("replayed", status.Replayed).
+			Int64("skipped", status.Skipped).
+			Str("elapsed", status.Elapsed).
+			Msg("replay completed")
+	}()
+
+	<-ctx.Done()
+	log.Info().Msg("shutdown: signal received, stopping HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to stop HTTP server gracefully")
+	}
+	log.Info().Msg("shutdown: complete")
+}
+
+// setupRepository ghi vào Redis khi có REDIS_URL, nếu không thì chỉ giữ trong bộ nhớ (dùng để thử).
+func setupRepository(cfg *config.Config) (store.NotificationStore, func() error, error) {
+	if cfg.RedisURL == "" {
+		log.Warn().Msg("REDIS_URL is not set, replayed notifications are kept in memory only")
+		return store.NewMemoryNotificationStore(), func() error { return nil }, nil
+	}
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.NewRedisNotificationStore(client), client.Close, nil
+}
+
+// setupClient dùng ReadCommitted giống consumer chính để không replay message của transaction bị abort.
+func setupClient(cfg *config.Config) (sarama.Client, error) {
+	config := sarama.NewConfig()
+	config.Consumer.IsolationLevel = sarama.ReadCommitted
+	config.Consumer.Return.Errors = true
+	if err := kafka.ApplySecurity(config, cfg); err != nil {
+		return nil, err
+	}
+	return sarama.NewClient(cfg.KafkaBrokers, config)
+}
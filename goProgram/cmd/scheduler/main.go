@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/logger"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/schedule"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/tracing"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/rs/zerolog"
+)
+
+// log được khởi tạo trong main sau khi đọc LOG_LEVEL/LOG_FORMAT
+var log zerolog.Logger
+
+const (
+	// chu kỳ kiểm tra các notification đã tới hạn
+	tickInterval = time.Second
+	// số notification tối đa lấy ra mỗi lần tick
+	dueBatchSize = 100
+	// thời gian chờ trước khi thử gửi lại một notification gửi lỗi
+	resendDelay = 10 * time.Second
+)
+
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		panic(fmt.Sprintf("failed to load config: %v", err))
+	}
+	if err := logger.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		panic(fmt.Sprintf("failed to setup logger: %v", err))
+	}
+	log = logger.Component("scheduler")
+	if cfg.RedisURL == "" {
+		log.Fatal().Msg("REDIS_URL is required to read scheduled notifications")
+	}
+
+	tracerProvider, err := tracing.SetupTracer("kafka-notify-scheduler")
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize tracer")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client, err := store.OpenRedis(context.Background(), cfg.RedisURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize scheduled notification store")
+	}
+	scheduled := schedule.NewRedisStore(client)
+	defer scheduled.Close()
+
+	if cfg.SchemaRegistryURL != "" {
+		if err := codec.SetupAvro(cfg.SchemaRegistryURL, cfg.SchemaRegistrySubject, cfg.AvroCompatibility); err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize avro codec")
+		}
+	}
+	notificationCodec, err := codec.New(cfg.NotificationEncoding)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize codec")
+	}
+	opts := sender.NewOptions(cfg, notificationCodec)
+
+	producer, err := kafka.SetupProducer(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize producer")
+	}
+
+	log.Info().
+		Str("topicPrefix", cfg.KafkaTopicPrefix).
+		Dur("tick", tickInterval).
+		Msg("Kafka SCHEDULER ⏰ started")
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for running := true; running; {
+		select {
+		case <-ctx.Done():
+			running = false
+		case now := <-ticker.C:
+			dispatchDue(ctx, scheduled, producer, opts, now)
+		}
+	}
+
+	log.Info().Msg("shutdown: flushing and closing producer")
+	if err := producer.Close(); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to close producer")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	log.Info().Msg("shutdown: flushing traces")
+	if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("shutdown: failed to flush traces")
+	}
+	log.Info().Msg("shutdown: complete")
+}
+
+// dispatchDue gửi mọi notification đã tới hạn. Gửi lỗi thì đặt lịch lại sau resendDelay,
+// riêng notification không hợp lệ thì bỏ vì gửi lại cũng không thành công.
+func dispatchDue(ctx context.Context, scheduled schedule.ScheduledNotificationStore,
+	producer sarama.SyncProducer, opts sender.Options, now time.Time) {
+	due, err := scheduled.Due(ctx, now, dueBatchSize)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to query due notifications")
+	}
+	for _, item := range due {
+		headers := []sarama.RecordHeader{kafka.Header(kafka.HeaderCorrelationID, item.CorrelationID)}
+		if item.TTL > 0 {
+			headers = append(headers, kafka.TTLHeader(now, item.TTL))
+		}
+
+		sendCtx := middleware.WithCorrelationID(ctx, item.CorrelationID)
+		item.Notification.NewThread = item.NewThread
+		partition, offset, err := sender.Send(sendCtx, producer, opts, item.Notification, headers...)
+		if err == nil {
+			log.Info().
+				Str("notificationID", item.ID()).
+				Str("correlationID", item.CorrelationID).
+				Int32("partition", partition).
+				Int64("offset", offset).
+				Msg("dispatched scheduled notification")
+			continue
+		}
+		log.Error().Err(err).
+			Str("notificationID", item.ID()).
+			Str("correlationID", item.CorrelationID).
+			Msg("failed to dispatch scheduled notification")
+		if errors.Is(err, models.ErrInvalidNotification) {
+			continue
+		}
+
+		// dùng context.Background để notification đã lấy ra không bị mất khi đang shutdown
+		item.DeliverAt = now.Add(resendDelay)
+		if err := scheduled.Schedule(context.Background(), item); err != nil {
+			log.Error().Err(err).Str("notificationID", item.ID()).Msg("failed to reschedule notification")
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderAdminAPIKey là header chứa API key của các route /admin.
+const HeaderAdminAPIKey = "X-Admin-API-Key"
+
+// AdminAPIKeyMiddleware trả về 401 nếu header X-Admin-API-Key không khớp apiKey.
+// So sánh constant-time để không lộ key qua thời gian phản hồi.
+func AdminAPIKeyMiddleware(apiKey string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		provided := ctx.GetHeader(HeaderAdminAPIKey)
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey)) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid admin api key"})
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AuthedUserIDKey là key trong gin.Context chứa user_id lấy từ JWT,
+// AuthedRoleKey chứa role lấy từ claim role.
+const (
+	AuthedUserIDKey = "authedUserID"
+	AuthedRoleKey   = "authedRole"
+)
+
+var (
+	ErrMissingToken  = errors.New("missing bearer token")
+	ErrInvalidClaims = errors.New("invalid token claims")
+	// ErrTenantMismatch là lỗi khi X-Tenant-ID khác tenant của token
+	ErrTenantMismatch = errors.New("tenant does not match the token")
+)
+
+// JWTAuthMiddleware kiểm tra Bearer token trong header Authorization (HS256),
+// lấy claim user_id và lưu vào context dưới key AuthedUserIDKey.
+// Token thiếu, hết hạn hoặc sai định dạng đều trả về 401.
+// Claim role (nếu có) được lưu dưới key AuthedRoleKey cho RequireRole.
+// Claim tenant_id phải khớp tenant mà TenantMiddleware đã đọc từ X-Tenant-ID, khác thì trả về 403.
+func JWTAuthMiddleware(secretKey string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := authenticateUser(ctx, secretKey); err != nil {
+			abortAuth(ctx, err)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// abortAuth trả về 403 khi token hợp lệ nhưng thuộc tenant khác, còn lại là 401.
+func abortAuth(ctx *gin.Context, err error) {
+	status := http.StatusUnauthorized
+	if errors.Is(err, ErrTenantMismatch) {
+		status = http.StatusForbidden
+	}
+	ctx.AbortWithStatusJSON(status, gin.H{"message": err.Error()})
+}
+
+// checkTenant so tenant của token với tenant của request. TenantMiddleware chạy trước middleware
+// xác thực nên header đã được đọc. Token không có tenant thuộc tenant mặc định.
+func checkTenant(ctx *gin.Context, tokenTenantID string) error {
+	if tokenTenantID != TenantID(ctx) {
+		return fmt.Errorf("%w: token tenant %q, request tenant %q", ErrTenantMismatch, tokenTenantID, TenantID(ctx))
+	}
+	return nil
+}
+
+func authenticateUser(ctx *gin.Context, secretKey string) error {
+	claims, err := parseBearerToken(ctx.GetHeader("Authorization"), secretKey)
+	if err != nil {
+		return err
+	}
+	userID, err := userIDFromClaims(claims)
+	if err != nil {
+		return err
+	}
+	tenantID, _ := claims["tenant_id"].(string)
+	if err := checkTenant(ctx, tenantID); err != nil {
+		return err
+	}
+	ctx.Set(AuthedUserIDKey, userID)
+	ctx.Set(AuthedRoleKey, roleFromClaims(claims))
+	return nil
+}
+
+// AuthedUserID trả về user_id đã được JWTAuthMiddleware xác thực, nếu có.
+func AuthedUserID(ctx *gin.Context) (int, bool) {
+	value, ok := ctx.Get(AuthedUserIDKey)
+	if !ok {
+		return 0, false
+	}
+	userID, ok := value.(int)
+	return userID, ok
+}
+
+// CallerID định danh caller đã xác thực: "user:<user_id>" từ JWT hoặc "client:<client_id>"
+// từ token client credentials. false nếu route không bật xác thực.
+func CallerID(ctx *gin.Context) (string, bool) {
+	if userID, ok := AuthedUserID(ctx); ok {
+		return "user:" + strconv.Itoa(userID), true
+	}
+	if clientID, ok := AuthedClientID(ctx); ok {
+		return "client:" + clientID, true
+	}
+	return "", false
+}
+
+func parseBearerToken(header, secretKey string) (jwt.MapClaims, error) {
+	tokenString, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || tokenString == "" {
+		return nil, ErrMissingToken
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secretKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	return claims, nil
+}
+
+// user_id có thể là số (JSON number) hoặc chuỗi số.
+func userIDFromClaims(claims jwt.MapClaims) (int, error) {
+	switch value := claims["user_id"].(type) {
+	case float64:
+		return int(value), nil
+	case string:
+		userID, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("%w: user_id: %v", ErrInvalidClaims, err)
+		}
+		return userID, nil
+	default:
+		return 0, fmt.Errorf("%w: missing user_id", ErrInvalidClaims)
+	}
+}
+
+// token phát hành trước khi có RBAC không có claim role, coi như RoleUser
+func roleFromClaims(claims jwt.MapClaims) models.Role {
+	role, _ := claims["role"].(string)
+	if role == "" {
+		return models.RoleUser
+	}
+	return models.Role(role)
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimitMiddleware đọc trước body của request qua http.MaxBytesReader và trả về 413 nếu body
+// vượt maxBytes, nên handler không bao giờ giữ quá maxBytes trong bộ nhớ. Đọc trước thay vì để handler
+// tự đọc vì ctx.PostForm nuốt lỗi của MaxBytesReader và handler sẽ trả 400 thay cho 413.
+func BodyLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Body == nil || ctx.Request.Body == http.NoBody {
+			ctx.Next()
+			return
+		}
+		if ctx.Request.ContentLength > maxBytes {
+			abortBodyTooLarge(ctx, maxBytes)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBytes))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			abortBodyTooLarge(ctx, maxBytes)
+			return
+		}
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "failed to read request body: " + err.Error()})
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		ctx.Next()
+	}
+}
+
+func abortBodyTooLarge(ctx *gin.Context, maxBytes int64) {
+	ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"message":      fmt.Sprintf("request body exceeds MAX_REQUEST_BODY_BYTES (%d bytes)", maxBytes),
+		"allowedBytes": maxBytes,
+	})
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testBodyLimit = 64
+
+func doBodyLimitRequest(body io.Reader, contentLength int64) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimitMiddleware(testBodyLimit))
+	router.POST("/send", func(ctx *gin.Context) {
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		ctx.String(http.StatusOK, "%d", len(body))
+	})
+	request := httptest.NewRequest(http.MethodPost, "/send", body)
+	request.ContentLength = contentLength
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		wantStatus int
+	}{
+		{name: "exactly at the limit", size: testBodyLimit, wantStatus: http.StatusOK},
+		{name: "one byte over", size: testBodyLimit + 1, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		body := strings.Repeat("a", tt.size)
+		// -1 là body chunked không có Content-Length, chỉ MaxBytesReader phát hiện được body quá lớn
+		for _, contentLength := range []int64{int64(tt.size), -1} {
+			recorder := doBodyLimitRequest(strings.NewReader(body), contentLength)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("%s (Content-Length %d): status = %d, want %d", tt.name, contentLength, recorder.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && recorder.Body.String() != "64" {
+				t.Fatalf("%s: handler read %s bytes, want 64", tt.name, recorder.Body.String())
+			}
+			if tt.wantStatus == http.StatusRequestEntityTooLarge &&
+				!strings.Contains(recorder.Body.String(), `"allowedBytes":64`) {
+				t.Fatalf("%s: response = %s, want allowedBytes", tt.name, recorder.Body.String())
+			}
+		}
+	}
+}
+
+func TestBodyLimitMiddlewareEmptyBody(t *testing.T) {
+	if recorder := doBodyLimitRequest(nil, 0); recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", recorder.Code)
+	}
+}
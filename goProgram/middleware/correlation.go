@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// CorrelationIDHeader là HTTP header chứa correlation ID của request,
+	// trùng tên với Kafka header để dễ tìm log ở cả hai phía.
+	CorrelationIDHeader = "X-Correlation-ID"
+	// CorrelationIDKey là key trong gin.Context chứa correlation ID.
+	CorrelationIDKey = "correlationID"
+)
+
+// CorrelationMiddleware lấy X-Correlation-ID từ request, nếu không có thì sinh UUID v4 mới,
+// lưu vào gin.Context lẫn context của request (cho code không phụ thuộc gin) và trả lại trong response header.
+func CorrelationMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		correlationID := ctx.GetHeader(CorrelationIDHeader)
+		if correlationID == "" {
+			correlationID = uuid.NewString()
+		}
+		ctx.Set(CorrelationIDKey, correlationID)
+		ctx.Request = ctx.Request.WithContext(WithCorrelationID(ctx.Request.Context(), correlationID))
+		ctx.Header(CorrelationIDHeader, correlationID)
+		ctx.Next()
+	}
+}
+
+// CorrelationID trả về correlation ID mà CorrelationMiddleware đã gắn vào context.
+func CorrelationID(ctx *gin.Context) string {
+	return ctx.GetString(CorrelationIDKey)
+}
+
+type correlationIDContextKey struct{}
+
+// WithCorrelationID gắn correlation ID vào context.Context.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDContextKey{}, correlationID)
+}
+
+// CorrelationIDFromContext trả về correlation ID đã gắn bằng WithCorrelationID, rỗng nếu không có.
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDContextKey{}).(string)
+	return correlationID
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/tenant"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsAllowedHeaders là các header client trình duyệt được gửi kèm request
+var corsAllowedHeaders = strings.Join([]string{
+	"Authorization", "Content-Type", CorrelationIDHeader, tenant.HeaderTenantID, "traceparent", "baggage",
+}, ", ")
+
+// CORSMiddleware gắn header CORS cho request có Origin nằm trong cfg.AllowedOrigins và trả lời
+// preflight (OPTIONS có Access-Control-Request-Method) bằng 204 mà không chạy handler.
+// Origin không được phép thì không có header CORS nên trình duyệt chặn response, preflight bị trả 403.
+func CORSMiddleware(cfg config.CORSConfig) gin.HandlerFunc {
+	allowAll := false
+	allowed := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[origin] = true
+	}
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(ctx *gin.Context) {
+		origin := ctx.GetHeader("Origin")
+		if origin == "" {
+			ctx.Next()
+			return
+		}
+		// response khác nhau theo Origin, cache phía trước không được dùng lại cho origin khác
+		ctx.Writer.Header().Add("Vary", "Origin")
+		preflight := ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+		if !allowAll && !allowed[origin] {
+			if preflight {
+				ctx.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("Access-Control-Allow-Origin", origin)
+		if !preflight {
+			ctx.Next()
+			return
+		}
+		ctx.Header("Access-Control-Allow-Methods", methods)
+		ctx.Header("Access-Control-Allow-Headers", corsAllowedHeaders)
+		ctx.Header("Access-Control-Max-Age", maxAge)
+		ctx.AbortWithStatus(http.StatusNoContent)
+	}
+}
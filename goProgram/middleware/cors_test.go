@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"kafka-notify/pkg/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const allowedOrigin = "https://app.example.com"
+
+func newCORSRouter(origins ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORSMiddleware(config.CORSConfig{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{"GET", "POST"},
+		MaxAge:         10 * time.Minute,
+	}))
+	router.POST("/send", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "sent")
+	})
+	return router
+}
+
+func doCORSRequest(router *gin.Engine, method, origin string, preflight bool) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, "/send", nil)
+	if origin != "" {
+		request.Header.Set("Origin", origin)
+	}
+	if preflight {
+		request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCORSPreflight(t *testing.T) {
+	recorder := doCORSRequest(newCORSRouter(allowedOrigin), http.MethodOptions, allowedOrigin, true)
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", recorder.Code)
+	}
+	header := recorder.Header()
+	if got := header.Get("Access-Control-Allow-Origin"); got != allowedOrigin {
+		t.Fatalf("Access-Control-Allow-Origin = %q, want %q", got, allowedOrigin)
+	}
+	if got := header.Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Fatalf("Access-Control-Allow-Methods = %q, want GET, POST", got)
+	}
+	for _, name := range []string{"Authorization", "Content-Type", CorrelationIDHeader} {
+		if !strings.Contains(header.Get("Access-Control-Allow-Headers"), name) {
+			t.Fatalf("Access-Control-Allow-Headers = %q, want %s", header.Get("Access-Control-Allow-Headers"), name)
+		}
+	}
+	if got := header.Get("Access-Control-Max-Age"); got != "600" {
+		t.Fatalf("Access-Control-Max-Age = %q, want 600", got)
+	}
+	if recorder.Body.Len() != 0 {
+		t.Fatalf("preflight ran the handler: %q", recorder.Body.String())
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	recorder := doCORSRequest(newCORSRouter(allowedOrigin), http.MethodPost, allowedOrigin, false)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "sent" {
+		t.Fatalf("response = %d %q, want 200 sent", recorder.Code, recorder.Body.String())
+	}
+	header := recorder.Header()
+	if got := header.Get("Access-Control-Allow-Origin"); got != allowedOrigin {
+		t.Fatalf("Access-Control-Allow-Origin = %q, want %q", got, allowedOrigin)
+	}
+	if got := header.Get("Vary"); got != "Origin" {
+		t.Fatalf("Vary = %q, want Origin", got)
+	}
+	// header chỉ dành cho preflight không có trên request thật
+	if got := header.Get("Access-Control-Allow-Methods"); got != "" {
+		t.Fatalf("Access-Control-Allow-Methods = %q on an actual request", got)
+	}
+}
+
+func TestCORSDisallowedOrigin(t *testing.T) {
+	// mặc định không cho origin nào
+	for _, router := range []*gin.Engine{newCORSRouter(), newCORSRouter(allowedOrigin)} {
+		preflight := doCORSRequest(router, http.MethodOptions, "https://evil.example.com", true)
+		if preflight.Code != http.StatusForbidden {
+			t.Fatalf("preflight status = %d, want 403", preflight.Code)
+		}
+		actual := doCORSRequest(router, http.MethodPost, "https://evil.example.com", false)
+		if got := actual.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Fatalf("Access-Control-Allow-Origin = %q, want none", got)
+		}
+	}
+}
+
+func TestCORSWildcardAndSameOrigin(t *testing.T) {
+	recorder := doCORSRequest(newCORSRouter("*"), http.MethodPost, "https://any.example.com", false)
+	if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != "https://any.example.com" {
+		t.Fatalf("Access-Control-Allow-Origin = %q, want the request origin", got)
+	}
+
+	// request không có Origin (cùng origin hoặc không phải trình duyệt) đi thẳng tới handler
+	recorder = doCORSRequest(newCORSRouter(), http.MethodPost, "", false)
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Vary") != "" {
+		t.Fatalf("response = %d, Vary %q, want 200 without CORS headers", recorder.Code, recorder.Header().Get("Vary"))
+	}
+}
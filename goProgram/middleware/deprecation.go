@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIV1 là tiền tố của các route phiên bản 1.
+const APIV1 = "/v1"
+
+// DeprecationWarning gắn header Deprecation (ngày deprecate) và Sunset (RFC 8594, ngày dự kiến gỡ)
+// cho route đã có phiên bản mới, kèm Link rel="successor-version" trỏ tới route /v1 tương ứng.
+func DeprecationWarning(deprecatedAt time.Time, sunsetAt time.Time) gin.HandlerFunc {
+	deprecation := deprecatedAt.UTC().Format(http.TimeFormat)
+	sunset := sunsetAt.UTC().Format(http.TimeFormat)
+	return func(ctx *gin.Context) {
+		ctx.Header("Deprecation", deprecation)
+		ctx.Header("Sunset", sunset)
+		if !strings.HasPrefix(ctx.Request.URL.Path, APIV1+"/") {
+			ctx.Header("Link", "<"+APIV1+ctx.Request.URL.Path+`>; rel="successor-version"`)
+		}
+		ctx.Next()
+	}
+}
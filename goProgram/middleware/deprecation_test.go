@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newVersionedRouter đăng ký cùng handler dưới /v1 và dưới đường dẫn cũ có DeprecationWarning như main.go
+func newVersionedRouter(deprecatedAt, sunsetAt time.Time) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	for _, api := range []*gin.RouterGroup{
+		router.Group(APIV1),
+		router.Group("", DeprecationWarning(deprecatedAt, sunsetAt)),
+	} {
+		api.POST("/send", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+		api.GET("/templates/:id", func(ctx *gin.Context) { ctx.String(http.StatusOK, ctx.Param("id")) })
+	}
+	return router
+}
+
+func TestDeprecationWarning(t *testing.T) {
+	deprecatedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// giờ địa phương được chuyển sang GMT trong header
+	sunsetAt := time.Date(2024, 7, 1, 7, 0, 0, 0, time.FixedZone("ICT", 7*60*60))
+	router := newVersionedRouter(deprecatedAt, sunsetAt)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		deprecated bool
+		wantLink   string
+	}{
+		{name: "legacy send", method: http.MethodPost, path: "/send", deprecated: true, wantLink: `</v1/send>; rel="successor-version"`},
+		{name: "legacy route with param", method: http.MethodGet, path: "/templates/welcome?lang=vi", deprecated: true, wantLink: `</v1/templates/welcome>; rel="successor-version"`},
+		{name: "v1 send", method: http.MethodPost, path: "/v1/send"},
+		{name: "v1 route with param", method: http.MethodGet, path: "/v1/templates/welcome"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("%s %s status = %d, want 200", tt.method, tt.path, recorder.Code)
+			}
+			header := recorder.Header()
+			if !tt.deprecated {
+				for _, name := range []string{"Deprecation", "Sunset", "Link"} {
+					if value := header.Get(name); value != "" {
+						t.Fatalf("%s %s has %s header %q, want none", tt.method, tt.path, name, value)
+					}
+				}
+				return
+			}
+			if got := header.Get("Deprecation"); got != "Mon, 01 Jan 2024 00:00:00 GMT" {
+				t.Fatalf("Deprecation = %q, want Mon, 01 Jan 2024 00:00:00 GMT", got)
+			}
+			if got := header.Get("Sunset"); got != "Mon, 01 Jul 2024 00:00:00 GMT" {
+				t.Fatalf("Sunset = %q, want Mon, 01 Jul 2024 00:00:00 GMT", got)
+			}
+			if got := header.Get("Link"); got != tt.wantLink {
+				t.Fatalf("Link = %q, want %q", got, tt.wantLink)
+			}
+		})
+	}
+}
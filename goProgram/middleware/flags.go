@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"kafka-notify/pkg/flags"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureFlagsKey là key trong gin.Context chứa flags.FlagStore.
+const FeatureFlagsKey = "featureFlags"
+
+// FeatureFlagMiddleware gắn store vào gin.Context lẫn context của request, sender đọc flag
+// qua flags.FromContext nên mỗi request thấy giá trị flag mới nhất của store.
+func FeatureFlagMiddleware(store flags.FlagStore) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(FeatureFlagsKey, store)
+		ctx.Request = ctx.Request.WithContext(flags.WithStore(ctx.Request.Context(), store))
+		ctx.Next()
+	}
+}
+
+// FeatureFlags trả về store FeatureFlagMiddleware đã gắn, flags.AllEnabled nếu không có.
+func FeatureFlags(ctx *gin.Context) flags.FlagStore {
+	if store, ok := ctx.Value(FeatureFlagsKey).(flags.FlagStore); ok {
+		return store
+	}
+	return flags.AllEnabled{}
+}
@@ -0,0 +1,287 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthedClientIDKey là key trong gin.Context chứa client_id của token client credentials.
+const AuthedClientIDKey = "authedClientID"
+
+const oauth2RequestTimeout = 5 * time.Second
+
+var (
+	ErrInactiveToken = errors.New("token is not active")
+	// ErrInvalidToken là lỗi khi access token không qua được kiểm tra của issuer
+	ErrInvalidToken = errors.New("invalid access token")
+)
+
+// introspection là response của token introspection endpoint (RFC 7662), aud có thể là chuỗi hoặc mảng.
+type introspection struct {
+	Active   bool            `json:"active"`
+	ClientID string          `json:"client_id"`
+	TenantID string          `json:"tenant_id"`
+	Exp      int64           `json:"exp"`
+	Aud      json.RawMessage `json:"aud"`
+}
+
+// accessTokenClaims là claim của access token dạng JWT (RFC 9068) mà middleware dùng,
+// một số issuer chỉ gửi azp thay cho client_id.
+type accessTokenClaims struct {
+	ClientID string `json:"client_id"`
+	Azp      string `json:"azp"`
+	TenantID string `json:"tenant_id"`
+}
+
+type introspectedToken struct {
+	clientID  string
+	tenantID  string
+	expiresAt time.Time
+}
+
+// OAuth2Client là thông tin xác thực của service với issuer, gửi bằng HTTP Basic khi gọi introspection.
+type OAuth2Client struct {
+	ID     string
+	Secret string
+}
+
+// oauth2Introspector đọc OIDC discovery của issuer (qua go-oidc) ở lần dùng đầu tiên, kiểm tra access token
+// dạng JWT bằng JWKS của issuer, token opaque thì gọi introspection endpoint. Token hợp lệ được cache tới exp.
+type oauth2Introspector struct {
+	issuerURL string
+	audience  string
+	client    OAuth2Client
+	http      *http.Client
+
+	mu                    sync.Mutex
+	verifier              *oidc.IDTokenVerifier
+	introspectionEndpoint string
+
+	tokens sync.Map // token -> introspectedToken
+}
+
+// OAuth2ClientCredentialsMiddleware xác thực Bearer token của client máy (OAuth2 client credentials) của issuerURL:
+// access token dạng JWT được kiểm tra chữ ký, exp và audience bằng go-oidc, token opaque được kiểm tra qua
+// introspection endpoint với client làm thông tin xác thực. Token phải active, chưa hết hạn và có audience.
+// client_id được lưu dưới key AuthedClientIDKey, role là RoleUser để đi qua RequireRole như user thường.
+// Kết quả hợp lệ được cache tới exp nên token bị thu hồi vẫn được chấp nhận tới khi hết hạn.
+func OAuth2ClientCredentialsMiddleware(issuerURL, audience string, client OAuth2Client) gin.HandlerFunc {
+	introspector := &oauth2Introspector{
+		issuerURL: issuerURL,
+		audience:  audience,
+		client:    client,
+		http:      &http.Client{Timeout: oauth2RequestTimeout},
+	}
+	return func(ctx *gin.Context) {
+		if err := introspector.authenticate(ctx); err != nil {
+			abortAuth(ctx, err)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// UserOrClientAuthMiddleware nhận cả JWT của user (HS256 ký bằng secretKey) lẫn token client credentials
+// của issuerURL: token không phải JWT user hợp lệ thì mới gọi introspection.
+func UserOrClientAuthMiddleware(secretKey, issuerURL, audience string, client OAuth2Client) gin.HandlerFunc {
+	clientAuth := OAuth2ClientCredentialsMiddleware(issuerURL, audience, client)
+	return func(ctx *gin.Context) {
+		err := authenticateUser(ctx, secretKey)
+		if err == nil {
+			ctx.Next()
+			return
+		}
+		if errors.Is(err, ErrTenantMismatch) {
+			abortAuth(ctx, err)
+			return
+		}
+		clientAuth(ctx)
+	}
+}
+
+// AuthedClientID trả về client_id đã được OAuth2ClientCredentialsMiddleware xác thực, nếu có.
+func AuthedClientID(ctx *gin.Context) (string, bool) {
+	value, ok := ctx.Get(AuthedClientIDKey)
+	if !ok {
+		return "", false
+	}
+	clientID, ok := value.(string)
+	return clientID, ok
+}
+
+func (i *oauth2Introspector) authenticate(ctx *gin.Context) error {
+	token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return ErrMissingToken
+	}
+
+	now := time.Now()
+	cached, ok := i.tokens.Load(token)
+	if ok && now.Before(cached.(introspectedToken).expiresAt) {
+		return i.setClient(ctx, cached.(introspectedToken))
+	}
+	if ok {
+		i.tokens.Delete(token)
+	}
+
+	introspected, err := i.check(ctx.Request.Context(), token, now)
+	if err != nil {
+		return err
+	}
+
+	i.prune(now)
+	i.tokens.Store(token, introspected)
+	return i.setClient(ctx, introspected)
+}
+
+// setClient kiểm tra tenant_id của token (giống claim tenant_id của JWT user) rồi gắn client vào context.
+func (i *oauth2Introspector) setClient(ctx *gin.Context, token introspectedToken) error {
+	if err := checkTenant(ctx, token.tenantID); err != nil {
+		return err
+	}
+	ctx.Set(AuthedClientIDKey, token.clientID)
+	ctx.Set(AuthedRoleKey, models.RoleUser)
+	return nil
+}
+
+// prune xoá token đã hết hạn, chỉ chạy khi có token mới nên số client ít thì rẻ
+func (i *oauth2Introspector) prune(now time.Time) {
+	i.tokens.Range(func(key, value any) bool {
+		if !now.Before(value.(introspectedToken).expiresAt) {
+			i.tokens.Delete(key)
+		}
+		return true
+	})
+}
+
+// check kiểm tra token với issuer: JWT thì verify bằng go-oidc, còn lại thì introspection
+func (i *oauth2Introspector) check(ctx context.Context, token string, now time.Time) (introspectedToken, error) {
+	verifier, endpoint, err := i.discover(ctx)
+	if err != nil {
+		return introspectedToken{}, err
+	}
+	if strings.Count(token, ".") == 2 {
+		return i.verify(ctx, verifier, token)
+	}
+
+	result, err := i.introspect(ctx, endpoint, token)
+	if err != nil {
+		return introspectedToken{}, err
+	}
+	expiresAt := time.Unix(result.Exp, 0)
+	if !result.Active || result.Exp == 0 || !now.Before(expiresAt) {
+		return introspectedToken{}, ErrInactiveToken
+	}
+	if !hasAudience(result.Aud, i.audience) {
+		return introspectedToken{}, fmt.Errorf("%w: token audience does not include %s", ErrInvalidClaims, i.audience)
+	}
+	return introspectedToken{clientID: result.ClientID, tenantID: result.TenantID, expiresAt: expiresAt}, nil
+}
+
+// verify kiểm tra chữ ký (JWKS của issuer), iss, exp và aud của access token dạng JWT
+func (i *oauth2Introspector) verify(ctx context.Context, verifier *oidc.IDTokenVerifier,
+	token string) (introspectedToken, error) {
+	verified, err := verifier.Verify(oidc.ClientContext(ctx, i.http), token)
+	if err != nil {
+		return introspectedToken{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	var claims accessTokenClaims
+	if err := verified.Claims(&claims); err != nil {
+		return introspectedToken{}, fmt.Errorf("%w: %w", ErrInvalidClaims, err)
+	}
+	clientID := claims.ClientID
+	if clientID == "" {
+		clientID = claims.Azp
+	}
+	if clientID == "" {
+		return introspectedToken{}, fmt.Errorf("%w: token has no client_id", ErrInvalidClaims)
+	}
+	return introspectedToken{clientID: clientID, tenantID: claims.TenantID, expiresAt: verified.Expiry}, nil
+}
+
+func (i *oauth2Introspector) introspect(ctx context.Context, endpoint, token string) (introspection, error) {
+	if endpoint == "" {
+		return introspection{}, fmt.Errorf("%w: oauth2 issuer %s has no introspection_endpoint for opaque tokens",
+			ErrInvalidToken, i.issuerURL)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint,
+		strings.NewReader(url.Values{"token": {token}, "token_type_hint": {"access_token"}}.Encode()))
+	if err != nil {
+		return introspection{}, fmt.Errorf("failed to introspect token: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if i.client.ID != "" {
+		// RFC 6749 mục 2.3.1: client_id và secret được form-encode trước khi ghép vào Basic auth
+		request.SetBasicAuth(url.QueryEscape(i.client.ID), url.QueryEscape(i.client.Secret))
+	}
+
+	var result introspection
+	if err := i.getJSON(request, &result); err != nil {
+		return introspection{}, fmt.Errorf("failed to introspect token: %w", err)
+	}
+	return result, nil
+}
+
+// discover đọc OIDC discovery của issuer bằng go-oidc (go-oidc kiểm tra issuer khớp), lỗi thì lần sau đọc lại
+func (i *oauth2Introspector) discover(ctx context.Context) (*oidc.IDTokenVerifier, string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.verifier != nil {
+		return i.verifier, i.introspectionEndpoint, nil
+	}
+
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, i.http), i.issuerURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to discover oauth2 issuer: %w", err)
+	}
+	var discovery struct {
+		IntrospectionEndpoint string `json:"introspection_endpoint"`
+	}
+	if err := provider.Claims(&discovery); err != nil {
+		return nil, "", fmt.Errorf("failed to discover oauth2 issuer: %w", err)
+	}
+	i.verifier = provider.Verifier(&oidc.Config{ClientID: i.audience})
+	i.introspectionEndpoint = discovery.IntrospectionEndpoint
+	return i.verifier, i.introspectionEndpoint, nil
+}
+
+func (i *oauth2Introspector) getJSON(request *http.Request, target any) error {
+	response, err := i.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
+
+func hasAudience(raw json.RawMessage, audience string) bool {
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return single == audience
+	}
+	var many []string
+	if json.Unmarshal(raw, &many) != nil {
+		return false
+	}
+	for _, aud := range many {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testAudience     = "notify-api"
+	testClientID     = "notify-producer"
+	testClientSecret = "s3cret"
+	testKeyID        = "test-key"
+	opaqueToken      = "opaque-token"
+)
+
+// fakeIssuer là OIDC issuer giả với discovery, JWKS và introspection endpoint
+type fakeIssuer struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	introspection introspection
+	introspected  atomic.Int32
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	issuer := &fakeIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                issuer.server.URL,
+			"jwks_uri":                              issuer.server.URL + "/jwks",
+			"introspection_endpoint":                issuer.server.URL + "/introspect",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testKeyID,
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/introspect", func(w http.ResponseWriter, r *http.Request) {
+		issuer.introspected.Add(1)
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != testClientID || secret != testClientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.PostFormValue("token") != opaqueToken {
+			json.NewEncoder(w).Encode(introspection{Active: false})
+			return
+		}
+		json.NewEncoder(w).Encode(issuer.introspection)
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (f *fakeIssuer) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(f.key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func newOAuth2Router(issuer *fakeIssuer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TenantMiddleware([]string{"acme"}))
+	router.Use(OAuth2ClientCredentialsMiddleware(issuer.server.URL, testAudience,
+		OAuth2Client{ID: testClientID, Secret: testClientSecret}))
+	router.GET("/", func(ctx *gin.Context) {
+		clientID, _ := AuthedClientID(ctx)
+		ctx.String(http.StatusOK, clientID)
+	})
+	return router
+}
+
+func doAuthRequest(router *gin.Engine, token, tenantID string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	if tenantID != "" {
+		request.Header.Set("X-Tenant-ID", tenantID)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestOAuth2ClientCredentialsIntrospection(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	issuer.introspection = introspection{
+		Active:   true,
+		ClientID: "billing",
+		Exp:      time.Now().Add(time.Hour).Unix(),
+		Aud:      json.RawMessage(`["other", "` + testAudience + `"]`),
+	}
+	router := newOAuth2Router(issuer)
+
+	for i := 0; i < 3; i++ {
+		recorder := doAuthRequest(router, opaqueToken, "")
+		if recorder.Code != http.StatusOK || recorder.Body.String() != "billing" {
+			t.Fatalf("request %d: got %d %q, want 200 billing", i, recorder.Code, recorder.Body.String())
+		}
+	}
+	// kết quả hợp lệ được cache nên chỉ gọi introspection một lần
+	if got := issuer.introspected.Load(); got != 1 {
+		t.Fatalf("introspection called %d times, want 1", got)
+	}
+}
+
+func TestOAuth2ClientCredentialsRejectsInvalidTokens(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	issuer.introspection = introspection{
+		Active:   true,
+		ClientID: "billing",
+		Exp:      time.Now().Add(time.Hour).Unix(),
+		Aud:      json.RawMessage(`"other"`),
+	}
+	router := newOAuth2Router(issuer)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "missing token"},
+		{name: "inactive token", token: "revoked"},
+		{name: "wrong audience", token: opaqueToken},
+		{name: "expired jwt", token: issuer.sign(t, jwt.MapClaims{
+			"iss": issuer.server.URL, "aud": testAudience, "client_id": "billing",
+			"exp": time.Now().Add(-time.Minute).Unix(),
+		})},
+		{name: "jwt for another audience", token: issuer.sign(t, jwt.MapClaims{
+			"iss": issuer.server.URL, "aud": "other", "client_id": "billing",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if recorder := doAuthRequest(router, tt.token, ""); recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("got %d, want 401", recorder.Code)
+			}
+		})
+	}
+}
+
+func TestOAuth2ClientCredentialsVerifiesJWT(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	router := newOAuth2Router(issuer)
+	token := issuer.sign(t, jwt.MapClaims{
+		"iss":       issuer.server.URL,
+		"aud":       testAudience,
+		"azp":       "billing",
+		"tenant_id": "acme",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	})
+
+	if recorder := doAuthRequest(router, token, "acme"); recorder.Code != http.StatusOK || recorder.Body.String() != "billing" {
+		t.Fatalf("got %d %q, want 200 billing", recorder.Code, recorder.Body.String())
+	}
+	// token của tenant acme không dùng được cho tenant mặc định
+	if recorder := doAuthRequest(router, token, ""); recorder.Code != http.StatusForbidden {
+		t.Fatalf("tenant mismatch: got %d, want 403", recorder.Code)
+	}
+	// JWT được kiểm tra bằng JWKS, không gọi introspection
+	if got := issuer.introspected.Load(); got != 0 {
+		t.Fatalf("introspection called %d times, want 0", got)
+	}
+}
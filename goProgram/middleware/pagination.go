@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"fmt"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationFilterKey là key trong gin.Context chứa store.NotificationFilter đã validate.
+const NotificationFilterKey = "notificationFilter"
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ValidatePaginationParams đọc limit, offset, sortBy, sortDir, fromID, minPriority từ query,
+// trả về 422 nếu giá trị không hợp lệ, ngược lại lưu filter vào context (xem PaginationFilter).
+func ValidatePaginationParams() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		filter, err := parseNotificationFilter(ctx)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+			return
+		}
+		ctx.Set(NotificationFilterKey, filter)
+		ctx.Next()
+	}
+}
+
+// PaginationFilter trả về filter do ValidatePaginationParams lưu, hoặc filter mặc định.
+func PaginationFilter(ctx *gin.Context) store.NotificationFilter {
+	if value, ok := ctx.Get(NotificationFilterKey); ok {
+		if filter, ok := value.(store.NotificationFilter); ok {
+			return filter
+		}
+	}
+	return store.NotificationFilter{Limit: DefaultPageLimit, SortBy: store.SortByCreatedAt, SortDir: store.SortAsc}
+}
+
+func parseNotificationFilter(ctx *gin.Context) (store.NotificationFilter, error) {
+	filter := store.NotificationFilter{
+		SortBy:  ctx.DefaultQuery("sortBy", store.SortByCreatedAt),
+		SortDir: ctx.DefaultQuery("sortDir", store.SortAsc),
+	}
+
+	var err error
+	if filter.Limit, err = intQuery(ctx, "limit", DefaultPageLimit); err != nil {
+		return filter, err
+	}
+	if filter.Limit <= 0 || filter.Limit > MaxPageLimit {
+		return filter, fmt.Errorf("limit must be between 1 and %d, got %d", MaxPageLimit, filter.Limit)
+	}
+	if filter.Offset, err = intQuery(ctx, "offset", 0); err != nil {
+		return filter, err
+	}
+	if filter.Offset < 0 {
+		return filter, fmt.Errorf("offset must be non-negative, got %d", filter.Offset)
+	}
+	if filter.SortBy != store.SortByCreatedAt && filter.SortBy != store.SortByPriority {
+		return filter, fmt.Errorf("sortBy must be %s or %s, got %q", store.SortByCreatedAt, store.SortByPriority, filter.SortBy)
+	}
+	if filter.SortDir != store.SortAsc && filter.SortDir != store.SortDesc {
+		return filter, fmt.Errorf("sortDir must be %s or %s, got %q", store.SortAsc, store.SortDesc, filter.SortDir)
+	}
+	if filter.FromID, err = intQuery(ctx, "fromID", 0); err != nil {
+		return filter, err
+	}
+	if filter.FromID < 0 {
+		return filter, fmt.Errorf("fromID must be positive, got %d", filter.FromID)
+	}
+	if filter.MinPriority, err = intQuery(ctx, "minPriority", 0); err != nil {
+		return filter, err
+	}
+	if filter.MinPriority != 0 && (filter.MinPriority < models.PriorityLow || filter.MinPriority > models.PriorityCritical) {
+		return filter, fmt.Errorf("minPriority must be between %d and %d, got %d",
+			models.PriorityLow, models.PriorityCritical, filter.MinPriority)
+	}
+	return filter, nil
+}
+
+func intQuery(ctx *gin.Context, key string, fallback int) (int, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, got %q", key, value)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/ratelimit"
+	"kafka-notify/pkg/tenant"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter giới hạn số request của mỗi caller đã xác thực (user hoặc OAuth2 client) trong từng tenant.
+// Backend local: mỗi user có một token bucket riêng lưu trong sync.Map của pod.
+// Backend redis (NewRedisRateLimiter): quota dùng chung giữa các replica qua ratelimit.RedisLimiter.
+// rps và burst chỉ dùng khi chưa có config.Active().
+type RateLimiter struct {
+	limiters sync.Map
+	rps      rate.Limit
+	burst    int
+	redis    *ratelimit.RedisLimiter
+	// OnError được gọi khi Redis lỗi, request khi đó vẫn được cho qua; nil là bỏ qua
+	OnError func(err error)
+}
+
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	return &RateLimiter{rps: rate.Limit(rps), burst: burst}
+}
+
+// NewRedisRateLimiter giới hạn burst request trong mỗi cửa sổ burst/rps giây (làm tròn lên, tối thiểu 1 giây),
+// giữ đúng tốc độ trung bình rps của token bucket nhưng đếm chung trên Redis.
+func NewRedisRateLimiter(limiter *ratelimit.RedisLimiter, rps float64, burst int) *RateLimiter {
+	return &RateLimiter{rps: rate.Limit(rps), burst: burst, redis: limiter}
+}
+
+func (rl *RateLimiter) limits() (rate.Limit, int) {
+	if cfg := config.Active(); cfg != nil {
+		return rate.Limit(cfg.RateLimitRPS), cfg.RateLimitBurst
+	}
+	return rl.rps, rl.burst
+}
+
+// limiterFor trả về token bucket của key, bucket tạo trước khi RATE_LIMIT_* được reload
+// được chỉnh theo giới hạn mới ở lần dùng kế tiếp.
+func (rl *RateLimiter) limiterFor(key string) *rate.Limiter {
+	rps, burst := rl.limits()
+	value, ok := rl.limiters.Load(key)
+	if !ok {
+		value, _ = rl.limiters.LoadOrStore(key, rate.NewLimiter(rps, burst))
+	}
+	limiter := value.(*rate.Limiter)
+	if limiter.Limit() != rps {
+		limiter.SetLimit(rps)
+	}
+	if limiter.Burst() != burst {
+		limiter.SetBurst(burst)
+	}
+	return limiter
+}
+
+// reserve trả về thời gian key phải chờ trước khi được gửi tiếp, 0 là được phép ngay.
+func (rl *RateLimiter) reserve(ctx *gin.Context, key string) time.Duration {
+	if rl.redis == nil {
+		reservation := rl.limiterFor(key).Reserve()
+		delay := reservation.Delay()
+		if delay > 0 {
+			reservation.Cancel()
+		}
+		return delay
+	}
+
+	rps, burst := rl.limits()
+	window := int(math.Ceil(float64(burst) / float64(rps)))
+	if window < 1 {
+		window = 1
+	}
+	allowed, err := rl.redis.Allow(ctx.Request.Context(), key, window, burst)
+	if err != nil {
+		// Redis lỗi thì không chặn /send, chỉ mất giới hạn chung trong lúc đó
+		if rl.OnError != nil {
+			rl.OnError(err)
+		}
+		return 0
+	}
+	if allowed {
+		return 0
+	}
+	// chờ tới hết cửa sổ hiện tại
+	windowDuration := time.Duration(window) * time.Second
+	return windowDuration - time.Duration(time.Now().UnixNano()%int64(windowDuration))
+}
+
+// RateLimitMiddleware đếm quota theo caller đã xác thực và trả về 429 kèm header Retry-After
+// khi caller đã dùng hết quota. Key không bao giờ lấy từ field của request (ví dụ fromID),
+// nếu không client chỉ cần đổi field là có quota mới.
+func RateLimitMiddleware(rl *RateLimiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// user ID chỉ duy nhất trong một tenant, user 1 của hai tenant có quota riêng
+		key := tenant.Key(TenantID(ctx), rateLimitKey(ctx))
+
+		if delay := rl.reserve(ctx, key); delay > 0 {
+			retryAfter := int(math.Ceil(delay.Seconds()))
+			if delay == rate.InfDuration {
+				retryAfter = int(time.Minute.Seconds())
+			}
+			ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"message": "rate limit exceeded",
+			})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// rateLimitKey là CallerID, hoặc địa chỉ kết nối khi route không bật xác thực.
+// Không dùng ClientIP vì router tin mọi proxy nên X-Forwarded-For do client tự đặt được.
+func rateLimitKey(ctx *gin.Context) string {
+	if caller, ok := CallerID(ctx); ok {
+		return caller
+	}
+	return "ip:" + ctx.RemoteIP()
+}
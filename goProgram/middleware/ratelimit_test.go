@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"kafka-notify/pkg/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRateLimitedRouter(rl *RateLimiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/send", RateLimitMiddleware(rl), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRedisRateLimiterSharedAcrossReplicas(t *testing.T) {
+	server := miniredis.RunT(t)
+	var replicas []*gin.Engine
+	for i := 0; i < 2; i++ {
+		limiter := ratelimit.NewRedisLimiter(redis.NewClient(&redis.Options{Addr: server.Addr()}))
+		t.Cleanup(func() { limiter.Close() })
+		// burst 4 với rps 0.01 là 4 request trong cửa sổ 400 giây
+		replicas = append(replicas, newRateLimitedRouter(NewRedisRateLimiter(limiter, 0.01, 4)))
+	}
+
+	for i := 0; i < 6; i++ {
+		recorder := httptest.NewRecorder()
+		replicas[i%2].ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/send", nil))
+		want := http.StatusOK
+		if i >= 4 {
+			want = http.StatusTooManyRequests
+		}
+		if recorder.Code != want {
+			t.Fatalf("request %d status = %d, want %d", i, recorder.Code, want)
+		}
+		if want == http.StatusTooManyRequests && recorder.Header().Get("Retry-After") == "" {
+			t.Fatalf("request %d has no Retry-After header", i)
+		}
+	}
+}
+
+func TestRedisRateLimiterFailsOpen(t *testing.T) {
+	server := miniredis.RunT(t)
+	limiter := ratelimit.NewRedisLimiter(redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1}))
+	defer limiter.Close()
+	rl := NewRedisRateLimiter(limiter, 1, 1)
+	var errs int
+	rl.OnError = func(err error) { errs++ }
+	router := newRateLimitedRouter(rl)
+	server.Close()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/send", nil))
+	if recorder.Code != http.StatusOK || errs != 1 {
+		t.Fatalf("status = %d, OnError calls = %d, want 200 and 1", recorder.Code, errs)
+	}
+}
+
+// hammerSend gửi requests request /send đồng thời trên từng goroutine của user userID,
+// mỗi request một fromID khác nhau, trả về số request được qua và bị 429
+func hammerSend(tb testing.TB, router *gin.Engine, userID, requests int) (allowed, limited int64) {
+	tb.Helper()
+	var ok, tooMany atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			form := url.Values{"fromID": {strconv.Itoa(i)}, "toID": {"2"}, "message": {"hello"}}
+			request := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			request.Header.Set("X-Test-User", strconv.Itoa(userID))
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			switch recorder.Code {
+			case http.StatusOK:
+				ok.Add(1)
+			case http.StatusTooManyRequests:
+				if recorder.Header().Get("Retry-After") == "" {
+					tb.Errorf("429 without Retry-After header")
+				}
+				tooMany.Add(1)
+			default:
+				tb.Errorf("status = %d", recorder.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	return ok.Load(), tooMany.Load()
+}
+
+// newAuthedRateLimitedRouter giả lập JWTAuthMiddleware bằng header X-Test-User rồi mới giới hạn
+func newAuthedRateLimitedRouter(rl *RateLimiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/send", func(ctx *gin.Context) {
+		userID, _ := strconv.Atoi(ctx.GetHeader("X-Test-User"))
+		ctx.Set(AuthedUserIDKey, userID)
+	}, RateLimitMiddleware(rl), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	return router
+}
+
+const (
+	hammerGoroutines = 100
+	hammerBurst      = 10
+)
+
+func TestRateLimitMiddlewareConcurrentBurst(t *testing.T) {
+	// rps rất nhỏ để không có token mới trong lúc test
+	router := newAuthedRateLimitedRouter(NewRateLimiter(0.001, hammerBurst))
+
+	allowed, limited := hammerSend(t, router, 1, hammerGoroutines)
+	if allowed != hammerBurst || limited != hammerGoroutines-hammerBurst {
+		t.Fatalf("allowed %d, limited %d, want %d and %d", allowed, limited, hammerBurst, hammerGoroutines-hammerBurst)
+	}
+	// user khác có quota riêng
+	if allowed, _ := hammerSend(t, router, 2, hammerGoroutines); allowed != hammerBurst {
+		t.Fatalf("second user allowed %d, want %d", allowed, hammerBurst)
+	}
+}
+
+func BenchmarkRateLimitMiddleware(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		router := newAuthedRateLimitedRouter(NewRateLimiter(0.001, hammerBurst))
+		allowed, limited := hammerSend(b, router, 1, hammerGoroutines)
+		if allowed != hammerBurst || limited != hammerGoroutines-hammerBurst {
+			b.Fatalf("allowed %d, limited %d, want %d and %d", allowed, limited, hammerBurst, hammerGoroutines-hammerBurst)
+		}
+	}
+}
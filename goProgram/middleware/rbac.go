@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"kafka-notify/pkg/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthedRole trả về role đã được JWTAuthMiddleware lấy từ token, nếu có.
+func AuthedRole(ctx *gin.Context) (models.Role, bool) {
+	value, ok := ctx.Get(AuthedRoleKey)
+	if !ok {
+		return "", false
+	}
+	role, ok := value.(models.Role)
+	return role, ok
+}
+
+// RequireRole phải đứng sau JWTAuthMiddleware, trả về 403 nếu role của token
+// không thuộc roles.
+func RequireRole(roles ...models.Role) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role, ok := AuthedRole(ctx)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "missing role"})
+			return
+		}
+		for _, allowed := range roles {
+			if role == allowed {
+				ctx.Next()
+				return
+			}
+		}
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "role " + string(role) + " is not allowed"})
+	}
+}
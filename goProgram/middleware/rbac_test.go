@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"kafka-notify/pkg/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testJWTSecret = "rbac-secret"
+
+// newRBACRouter bảo vệ route giống producer: /admin chỉ cho admin, /send cho user và admin
+func newRBACRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	authed := router.Group("", JWTAuthMiddleware(testJWTSecret))
+	authed.GET("/admin/topics", RequireRole(models.RoleAdmin), ok)
+	authed.POST("/send", RequireRole(models.RoleUser, models.RoleAdmin), ok)
+	return router
+}
+
+// roleToken ký JWT HS256 cho user 1, role rỗng thì không có claim role
+func roleToken(t *testing.T, role string) string {
+	t.Helper()
+	claims := jwt.MapClaims{"user_id": 1}
+	if role != "" {
+		claims["role"] = role
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{name: "admin on admin route", role: "admin", method: http.MethodGet, path: "/admin/topics", wantStatus: http.StatusOK},
+		{name: "admin on send", role: "admin", method: http.MethodPost, path: "/send", wantStatus: http.StatusOK},
+		{name: "user on admin route", role: "user", method: http.MethodGet, path: "/admin/topics", wantStatus: http.StatusForbidden},
+		{name: "user on send", role: "user", method: http.MethodPost, path: "/send", wantStatus: http.StatusOK},
+		// token phát hành trước RBAC không có claim role, được coi là user
+		{name: "no role claim on admin route", method: http.MethodGet, path: "/admin/topics", wantStatus: http.StatusForbidden},
+		{name: "no role claim on send", method: http.MethodPost, path: "/send", wantStatus: http.StatusOK},
+		{name: "unknown role on admin route", role: "guest", method: http.MethodGet, path: "/admin/topics", wantStatus: http.StatusForbidden},
+		{name: "unknown role on send", role: "guest", method: http.MethodPost, path: "/send", wantStatus: http.StatusForbidden},
+		// so sánh role phân biệt hoa thường
+		{name: "uppercase admin on admin route", role: "ADMIN", method: http.MethodGet, path: "/admin/topics", wantStatus: http.StatusForbidden},
+	}
+	router := newRBACRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, tt.path, nil)
+			request.Header.Set("Authorization", "Bearer "+roleToken(t, tt.role))
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("%s %s with role %q status = %d, want %d: %s",
+					tt.method, tt.path, tt.role, recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+		})
+	}
+}
+
+// không có token thì JWTAuthMiddleware trả 401 trước khi RequireRole chạy
+func TestRequireRoleWithoutToken(t *testing.T) {
+	router := newRBACRouter()
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/admin/topics"},
+		{http.MethodPost, "/send"},
+	} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(route.method, route.path, nil))
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("%s %s without token status = %d, want 401", route.method, route.path, recorder.Code)
+		}
+	}
+}
+
+// RequireRole đứng một mình (không có JWTAuthMiddleware phía trước) thì từ chối thay vì cho qua
+func TestRequireRoleWithoutAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin/topics", RequireRole(models.RoleAdmin), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/topics", nil))
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", recorder.Code)
+	}
+}
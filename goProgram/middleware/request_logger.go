@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// Redacted thay cho giá trị các field nhạy cảm trong log.
+const Redacted = "[REDACTED]"
+
+// body lớn hơn giới hạn này không được log để tránh tốn bộ nhớ
+const maxLoggedBody = 64 << 10
+
+// redactedFields là các field chứa nội dung người dùng (PII), chỉ log Redacted.
+var redactedFields = []string{"message"}
+
+// RequestLogger log method, path, status, latency và client IP của mỗi request ở mức info.
+// Ở mức debug, body của request có route nằm trong bodyRoutes (ví dụ POST /send) được log
+// sau khi che các field nhạy cảm, và body của response không phải 2xx cũng được log.
+// Level của log quyết định độ chi tiết (REQUEST_LOG_LEVEL), production nên để info.
+func RequestLogger(log zerolog.Logger, bodyRoutes ...string) gin.HandlerFunc {
+	logBodies := log.GetLevel() <= zerolog.DebugLevel
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		var requestBody []byte
+		if logBodies && contains(bodyRoutes, ctx.FullPath()) {
+			requestBody = peekBody(ctx.Request)
+		}
+		var recorder *responseRecorder
+		if logBodies {
+			recorder = &responseRecorder{ResponseWriter: ctx.Writer}
+			ctx.Writer = recorder
+		}
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		event := log.Info()
+		if status >= http.StatusInternalServerError {
+			event = log.Error()
+		} else if status >= http.StatusBadRequest {
+			event = log.Warn()
+		}
+		event = event.
+			Str("method", ctx.Request.Method).
+			Str("path", ctx.Request.URL.Path).
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Str("clientIP", ctx.ClientIP()).
+			Str("correlationID", CorrelationID(ctx))
+		if requestBody != nil {
+			event = event.Str("requestBody", RedactBody(ctx.ContentType(), requestBody))
+		}
+		if recorder != nil && (status < 200 || status >= 300) && recorder.body.Len() > 0 {
+			event = event.Str("responseBody", recorder.body.String())
+		}
+		event.Msg("http request")
+	}
+}
+
+// RedactBody trả về body (form hoặc JSON) với giá trị các field nhạy cảm được thay bằng Redacted,
+// body không đọc được theo contentType thì chỉ trả về Redacted.
+func RedactBody(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return Redacted
+		}
+		for _, field := range redactedFields {
+			if values.Has(field) {
+				values.Set(field, Redacted)
+			}
+		}
+		// giữ Redacted dễ đọc thay vì %5BREDACTED%5D
+		return strings.ReplaceAll(values.Encode(), url.QueryEscape(Redacted), Redacted)
+	case "application/json":
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return Redacted
+		}
+		for _, field := range redactedFields {
+			if _, ok := fields[field]; ok {
+				fields[field] = json.RawMessage(`"` + Redacted + `"`)
+			}
+		}
+		redacted, err := json.Marshal(fields)
+		if err != nil {
+			return Redacted
+		}
+		return string(redacted)
+	default:
+		return Redacted
+	}
+}
+
+// peekBody đọc body để log rồi trả lại nguyên vẹn cho handler, nil nếu body quá lớn.
+func peekBody(req *http.Request) []byte {
+	if req.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxLoggedBody+1))
+	req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), req.Body), Closer: req.Body}
+	if err != nil || len(body) > maxLoggedBody {
+		return nil
+	}
+	return body
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// responseRecorder giữ bản sao body response (tối đa maxLoggedBody) để log khi lỗi.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	if remaining := maxLoggedBody - w.body.Len(); remaining > 0 {
+		if len(data) > remaining {
+			w.body.Write(data[:remaining])
+		} else {
+			w.body.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
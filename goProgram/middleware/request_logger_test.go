@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+const secretMessage = "my card number is 4111 1111 1111 1111"
+
+// serveLogged gửi request qua RequestLogger (bodyRoutes là POST /send), handler trả status kèm message
+// trong response. Trả về dòng log đã decode cùng message handler nhận được.
+func serveLogged(t *testing.T, level zerolog.Level, contentType, body string, status int) (map[string]any, string) {
+	t.Helper()
+	var logs bytes.Buffer
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestLogger(zerolog.New(&logs).Level(level), "/send"))
+	var received string
+	router.POST("/send", func(ctx *gin.Context) {
+		var payload struct {
+			Message string `form:"message" json:"message"`
+		}
+		if err := ctx.ShouldBind(&payload); err != nil {
+			t.Errorf("handler could not read the body: %v", err)
+		}
+		received = payload.Message
+		ctx.JSON(status, gin.H{"message": "failed: " + payload.Message})
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/send", strings.NewReader(body))
+	request.Header.Set("Content-Type", contentType)
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	var entry map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("log is not a single JSON line: %v: %s", err, logs.String())
+	}
+	return entry, received
+}
+
+func TestRequestLoggerRedactsMessage(t *testing.T) {
+	form := url.Values{"fromID": {"1"}, "toID": {"2"}, "message": {secretMessage}}.Encode()
+	jsonBody := `{"fromID":1,"toID":2,"message":"` + secretMessage + `"}`
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        []string
+	}{
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: form,
+			want: []string{"message=" + Redacted, "fromID=1", "toID=2"}},
+		{name: "json", contentType: "application/json; charset=utf-8", body: jsonBody,
+			want: []string{`"message":"` + Redacted + `"`, `"fromID":1`, `"toID":2`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, received := serveLogged(t, zerolog.DebugLevel, tt.contentType, tt.body, http.StatusOK)
+			// handler vẫn nhận body nguyên vẹn
+			if received != secretMessage {
+				t.Fatalf("handler got message %q, want %q", received, secretMessage)
+			}
+			requestBody, _ := entry["requestBody"].(string)
+			if strings.Contains(requestBody, "4111") {
+				t.Fatalf("requestBody = %q leaks the message", requestBody)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(requestBody, want) {
+					t.Fatalf("requestBody = %q, want it to contain %q", requestBody, want)
+				}
+			}
+			if entry["method"] != http.MethodPost || entry["path"] != "/send" || entry["status"] != float64(http.StatusOK) {
+				t.Fatalf("log entry = %v, want method, path and status", entry)
+			}
+			if _, ok := entry["responseBody"]; ok {
+				t.Fatalf("2xx response body was logged: %v", entry["responseBody"])
+			}
+		})
+	}
+}
+
+// ở mức info (production) không log body, kể cả khi response lỗi
+func TestRequestLoggerInfoLevelSkipsBodies(t *testing.T) {
+	form := url.Values{"message": {secretMessage}}.Encode()
+	entry, _ := serveLogged(t, zerolog.InfoLevel, "application/x-www-form-urlencoded", form, http.StatusBadRequest)
+	for _, key := range []string{"requestBody", "responseBody"} {
+		if value, ok := entry[key]; ok {
+			t.Fatalf("%s = %v logged at info level", key, value)
+		}
+	}
+	if entry["level"] != "warn" {
+		t.Fatalf("level = %v, want warn for 400", entry["level"])
+	}
+}
+
+func TestRequestLoggerLogsErrorResponseBody(t *testing.T) {
+	form := url.Values{"message": {"hello"}}.Encode()
+	entry, _ := serveLogged(t, zerolog.DebugLevel, "application/x-www-form-urlencoded", form, http.StatusInternalServerError)
+	if entry["level"] != "error" {
+		t.Fatalf("level = %v, want error for 500", entry["level"])
+	}
+	if body, _ := entry["responseBody"].(string); !strings.Contains(body, "failed: hello") {
+		t.Fatalf("responseBody = %q, want the error response", body)
+	}
+}
+
+func TestRedactBodyUnreadable(t *testing.T) {
+	for _, contentType := range []string{"text/plain", "application/json", ""} {
+		if got := RedactBody(contentType, []byte("message: "+secretMessage)); got != Redacted {
+			t.Fatalf("RedactBody(%q) = %q, want %s", contentType, got, Redacted)
+		}
+	}
+}
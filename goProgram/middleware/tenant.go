@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"kafka-notify/pkg/tenant"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantIDKey là key trong gin.Context chứa tenant của request.
+const TenantIDKey = "tenantID"
+
+// TenantMiddleware đọc header X-Tenant-ID và trả về 403 nếu tenant không có trong tenants.
+// Request không gửi header thuộc tenant mặc định. Header do client tự đặt nên các middleware xác thực
+// (JWTAuthMiddleware, OAuth2ClientCredentialsMiddleware) còn kiểm tra nó khớp claim tenant_id của token. Tenant được lưu vào gin.Context lẫn context
+// của request để user store, preferences store và sender dùng.
+func TenantMiddleware(tenants []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(tenants))
+	for _, tenantID := range tenants {
+		allowed[tenantID] = true
+	}
+	return func(ctx *gin.Context) {
+		tenantID := ctx.GetHeader(tenant.HeaderTenantID)
+		if tenantID != "" && !allowed[tenantID] {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "unknown tenant"})
+			return
+		}
+		ctx.Set(TenantIDKey, tenantID)
+		ctx.Request = ctx.Request.WithContext(tenant.WithID(ctx.Request.Context(), tenantID))
+		ctx.Next()
+	}
+}
+
+// TenantID trả về tenant mà TenantMiddleware đã gắn vào context, rỗng là tenant mặc định.
+func TenantID(ctx *gin.Context) string {
+	return ctx.GetString(TenantIDKey)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"kafka-notify/pkg/tenant"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TenantMiddleware([]string{"acme", "globex"}))
+	router.GET("/", func(ctx *gin.Context) {
+		// gin.Context và context của request phải cùng một tenant
+		if TenantID(ctx) != tenant.FromContext(ctx.Request.Context()) {
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		ctx.String(http.StatusOK, TenantID(ctx))
+	})
+
+	tests := []struct {
+		header     string
+		wantStatus int
+		wantTenant string
+	}{
+		{header: "", wantStatus: http.StatusOK, wantTenant: ""},
+		{header: "acme", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{header: "globex", wantStatus: http.StatusOK, wantTenant: "globex"},
+		{header: "initech", wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				request.Header.Set(tenant.HeaderTenantID, tt.header)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && recorder.Body.String() != tt.wantTenant {
+				t.Fatalf("tenant = %q, want %q", recorder.Body.String(), tt.wantTenant)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"kafka-notify/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// TraceContextMiddleware đọc header traceparent và baggage của request HTTP vào context của request,
+// nên span và baggage của client được sender chuyển tiếp qua Kafka header tới consumer.
+func TraceContextMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestCtx := tracing.Propagator.Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+		ctx.Next()
+	}
+}
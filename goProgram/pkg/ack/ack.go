@@ -0,0 +1,66 @@
+package ack
+
+import (
+	"encoding/json"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"strconv"
+
+	"github.com/IBM/sarama"
+)
+
+// Publisher gửi ack sang acks topic, key là user ID để các ack của cùng
+// một người nhận nằm trên cùng partition.
+type Publisher struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+func NewPublisher(producer sarama.SyncProducer, topic string) *Publisher {
+	return &Publisher{producer: producer, topic: topic}
+}
+
+func (p *Publisher) Publish(a models.Ack) error {
+	payload, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ack: %w", err)
+	}
+	_, _, err = p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: p.topic,
+		Key:   sarama.StringEncoder(strconv.Itoa(a.UserID)),
+		Value: sarama.ByteEncoder(payload),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send ack to %s: %w", p.topic, err)
+	}
+	return nil
+}
+
+// Handler là sarama.ConsumerGroupHandler đọc acks topic và lưu vào Store.
+// Ack không decode được bị bỏ qua, ack không lưu được thì dừng session để đọc lại sau.
+type Handler struct {
+	Store AckStore
+	// OnError được gọi khi một ack không decode được, có thể nil
+	OnError func(msg *sarama.ConsumerMessage, err error)
+}
+
+func (h *Handler) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (h *Handler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (h *Handler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		var a models.Ack
+		if err := json.Unmarshal(msg.Value, &a); err != nil {
+			if h.OnError != nil {
+				h.OnError(msg, fmt.Errorf("failed to unmarshal ack: %w", err))
+			}
+			sess.MarkMessage(msg, "")
+			continue
+		}
+		if err := h.Store.Save(sess.Context(), a); err != nil {
+			return err
+		}
+		sess.MarkMessage(msg, "")
+	}
+	return nil
+}
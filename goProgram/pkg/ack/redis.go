@@ -0,0 +1,45 @@
+package ack
+
+import (
+	"context"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore lưu ack trong hash acks:user:<userID> (notification ID -> AckedAt RFC3339),
+// các instance consumer dùng chung nên filter unread đúng dù ack được đọc ở instance khác.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func acksKey(userID int) string {
+	return "acks:user:" + strconv.Itoa(userID)
+}
+
+func (s *RedisStore) Save(ctx context.Context, a models.Ack) error {
+	err := s.client.HSetNX(ctx, acksKey(a.UserID), a.NotificationID, a.AckedAt.UTC().Format(time.RFC3339Nano)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to save ack: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) AckedIDs(ctx context.Context, userID int) (map[string]bool, error) {
+	keys, err := s.client.HKeys(ctx, acksKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list acks: %w", err)
+	}
+	ids := make(map[string]bool, len(keys))
+	for _, id := range keys {
+		ids[id] = true
+	}
+	return ids, nil
+}
@@ -0,0 +1,49 @@
+package ack
+
+import (
+	"context"
+	"kafka-notify/pkg/models"
+	"sync"
+)
+
+// AckStore lưu các notification mà mỗi user đã đánh dấu đã đọc.
+type AckStore interface {
+	// Save ghi nhận ack, ack lặp lại của cùng notification giữ nguyên AckedAt đầu tiên
+	Save(ctx context.Context, a models.Ack) error
+	// AckedIDs trả về tập notification ID mà userID đã ack
+	AckedIDs(ctx context.Context, userID int) (map[string]bool, error)
+}
+
+// MemoryStore giữ ack trong bộ nhớ của instance hiện tại, mất khi restart.
+type MemoryStore struct {
+	data map[int]map[string]models.Ack
+	mu   sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[int]map[string]models.Ack)}
+}
+
+func (s *MemoryStore) Save(_ context.Context, a models.Ack) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acks, ok := s.data[a.UserID]
+	if !ok {
+		acks = make(map[string]models.Ack)
+		s.data[a.UserID] = acks
+	}
+	if _, ok := acks[a.NotificationID]; !ok {
+		acks[a.NotificationID] = a
+	}
+	return nil
+}
+
+func (s *MemoryStore) AckedIDs(_ context.Context, userID int) (map[string]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make(map[string]bool, len(s.data[userID]))
+	for id := range s.data[userID] {
+		ids[id] = true
+	}
+	return ids, nil
+}
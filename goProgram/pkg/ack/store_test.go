@@ -0,0 +1,61 @@
+package ack
+
+import (
+	"context"
+	"kafka-notify/pkg/models"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestAckStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) (AckStore, *miniredis.Miniredis){
+		"memory": func(t *testing.T) (AckStore, *miniredis.Miniredis) { return NewMemoryStore(), nil },
+		"redis": func(t *testing.T) (AckStore, *miniredis.Miniredis) {
+			server := miniredis.RunT(t)
+			return NewRedisStore(redis.NewClient(&redis.Options{Addr: server.Addr()})), server
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			acks, server := newStore(t)
+			ctx := context.Background()
+			first := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+			for _, a := range []models.Ack{
+				{NotificationID: "n-1", UserID: 2, AckedAt: first},
+				{NotificationID: "n-2", UserID: 2, AckedAt: first},
+				{NotificationID: "n-1", UserID: 3, AckedAt: first},
+				{NotificationID: "n-1", UserID: 2, AckedAt: first.Add(time.Hour)},
+			} {
+				if err := acks.Save(ctx, a); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+
+			ids, err := acks.AckedIDs(ctx, 2)
+			if err != nil {
+				t.Fatalf("AckedIDs() error = %v", err)
+			}
+			if want := map[string]bool{"n-1": true, "n-2": true}; !reflect.DeepEqual(ids, want) {
+				t.Fatalf("AckedIDs(2) = %v, want %v", ids, want)
+			}
+			if ids, _ := acks.AckedIDs(ctx, 4); len(ids) != 0 {
+				t.Fatalf("AckedIDs(4) = %v, want none", ids)
+			}
+
+			// ack lặp lại giữ AckedAt đầu tiên
+			var ackedAt string
+			if server != nil {
+				ackedAt = server.HGet(acksKey(2), "n-1")
+			} else {
+				ackedAt = acks.(*MemoryStore).data[2]["n-1"].AckedAt.Format(time.RFC3339Nano)
+			}
+			if ackedAt != first.Format(time.RFC3339Nano) {
+				t.Fatalf("AckedAt = %s, want the first ack %s", ackedAt, first.Format(time.RFC3339Nano))
+			}
+		})
+	}
+}
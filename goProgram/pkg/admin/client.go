@@ -0,0 +1,265 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/config"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/kafka"
+	"sort"
+	"strconv"
+
+	"github.com/IBM/sarama"
+)
+
+var (
+	ErrTopicExists  = errors.New("topic already exists")
+	ErrInvalidTopic = errors.New("invalid topic request")
+)
+
+// TopicSpec là thông tin dùng để tạo topic mới.
+type TopicSpec struct {
+	Name              string `json:"name"`
+	NumPartitions     int32  `json:"numPartitions"`
+	ReplicationFactor int16  `json:"replicationFactor"`
+}
+
+// PartitionInfo mô tả leader và các replica của một partition.
+type PartitionInfo struct {
+	ID       int32   `json:"id"`
+	Leader   int32   `json:"leader"`
+	Replicas []int32 `json:"replicas"`
+	ISR      []int32 `json:"isr"`
+}
+
+// TopicInfo là kết quả describe một topic.
+type TopicInfo struct {
+	Name       string          `json:"name"`
+	Internal   bool            `json:"internal"`
+	Partitions []PartitionInfo `json:"partitions"`
+}
+
+// Client bọc sarama.ClusterAdmin cho các thao tác quản lý topic.
+type Client struct {
+	admin sarama.ClusterAdmin
+}
+
+// NewClient kết nối tới cluster với cùng cấu hình TLS/SASL như producer.
+func NewClient(cfg *config.Config) (*Client, error) {
+	config := sarama.NewConfig()
+	// CreateTopics/DeleteTopics cần Kafka >= 0.10.1
+	config.Version = sarama.V2_1_0_0
+	if err := kafka.ApplySecurity(config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to setup admin client: %w", err)
+	}
+	admin, err := sarama.NewClusterAdmin(cfg.KafkaBrokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup admin client: %w", err)
+	}
+	return NewClientFromAdmin(admin), nil
+}
+
+// NewClientFromAdmin dùng một ClusterAdmin đã có sẵn.
+func NewClientFromAdmin(admin sarama.ClusterAdmin) *Client {
+	return &Client{admin: admin}
+}
+
+// CreateTopic tạo topic, trả về ErrTopicExists nếu topic đã tồn tại.
+func (c *Client) CreateTopic(spec TopicSpec) error {
+	if spec.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTopic)
+	}
+	if spec.NumPartitions <= 0 {
+		return fmt.Errorf("%w: numPartitions must be positive", ErrInvalidTopic)
+	}
+	if spec.ReplicationFactor <= 0 {
+		return fmt.Errorf("%w: replicationFactor must be positive", ErrInvalidTopic)
+	}
+
+	err := c.admin.CreateTopic(spec.Name, &sarama.TopicDetail{
+		NumPartitions:     spec.NumPartitions,
+		ReplicationFactor: spec.ReplicationFactor,
+	}, false)
+	if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		return fmt.Errorf("%w: %s", ErrTopicExists, spec.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create topic %s: %w", spec.Name, err)
+	}
+	return nil
+}
+
+// DeleteTopic xoá topic, trả về *apperrors.ErrTopicNotFound nếu topic không tồn tại.
+func (c *Client) DeleteTopic(name string) error {
+	err := c.admin.DeleteTopic(name)
+	if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+		return &apperrors.ErrTopicNotFound{Topic: name}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete topic %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListTopics trả về tên các topic đã sắp xếp theo thứ tự chữ cái.
+func (c *Client) ListTopics() ([]string, error) {
+	topics, err := c.admin.ListTopics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+	names := make([]string, 0, len(topics))
+	for name := range topics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DescribeTopic trả về partition, leader và replica của topic.
+func (c *Client) DescribeTopic(name string) (TopicInfo, error) {
+	metadata, err := c.admin.DescribeTopics([]string{name})
+	if err != nil {
+		return TopicInfo{}, fmt.Errorf("failed to describe topic %s: %w", name, err)
+	}
+	if len(metadata) == 0 || errors.Is(metadata[0].Err, sarama.ErrUnknownTopicOrPartition) {
+		return TopicInfo{}, &apperrors.ErrTopicNotFound{Topic: name}
+	}
+	if metadata[0].Err != sarama.ErrNoError {
+		return TopicInfo{}, fmt.Errorf("failed to describe topic %s: %w", name, metadata[0].Err)
+	}
+
+	topic := metadata[0]
+	info := TopicInfo{Name: topic.Name, Internal: topic.IsInternal}
+	for _, partition := range topic.Partitions {
+		info.Partitions = append(info.Partitions, PartitionInfo{
+			ID:       partition.ID,
+			Leader:   partition.Leader,
+			Replicas: partition.Replicas,
+			ISR:      partition.Isr,
+		})
+	}
+	sort.Slice(info.Partitions, func(i, j int) bool { return info.Partitions[i].ID < info.Partitions[j].ID })
+	return info, nil
+}
+
+// EnsureTopics tạo các topic trong topics chưa tồn tại và trả về tên các topic vừa tạo.
+// Topic đã tồn tại được giữ nguyên cấu hình (không đổi partition hay retention).
+// Producer và consumer cùng khởi động có thể tạo trùng, ErrTopicAlreadyExists được bỏ qua.
+func (c *Client) EnsureTopics(topics []config.TopicConfig) ([]string, error) {
+	existing, err := c.admin.ListTopics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+	var created []string
+	for _, topic := range topics {
+		if _, ok := existing[topic.Name]; ok {
+			continue
+		}
+		detail := &sarama.TopicDetail{
+			NumPartitions:     topic.Partitions,
+			ReplicationFactor: topic.ReplicationFactor,
+		}
+		if topic.Retention > 0 {
+			retention := strconv.FormatInt(topic.Retention.Milliseconds(), 10)
+			detail.ConfigEntries = map[string]*string{"retention.ms": &retention}
+		}
+		err := c.admin.CreateTopic(topic.Name, detail, false)
+		if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+			continue
+		}
+		if err != nil {
+			return created, fmt.Errorf("failed to create topic %s: %w", topic.Name, err)
+		}
+		created = append(created, topic.Name)
+	}
+	return created, nil
+}
+
+func (c *Client) Close() error {
+	return c.admin.Close()
+}
+
+// EnsureTopicExists tạo topic với partitions partition nếu chưa tồn tại, hoặc thêm partition nếu topic
+// đang có ít hơn partitions; topic có nhiều partition hơn được giữ nguyên vì Kafka không giảm được partition.
+// Trả về true nếu topic vừa được tạo hoặc thêm partition.
+// Producer và consumer cùng khởi động có thể làm trùng, lỗi do instance kia đã làm trước được bỏ qua.
+func EnsureTopicExists(admin sarama.ClusterAdmin, topic string, partitions int32, replicationFactor int16) (bool, error) {
+	current, err := partitionCount(admin, topic)
+	if err != nil {
+		return false, err
+	}
+	if current == 0 {
+		err := admin.CreateTopic(topic, &sarama.TopicDetail{
+			NumPartitions:     partitions,
+			ReplicationFactor: replicationFactor,
+		}, false)
+		if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to create topic %s: %w", topic, err)
+		}
+		return true, nil
+	}
+	if current >= partitions {
+		return false, nil
+	}
+	if err := admin.CreatePartitions(topic, partitions, nil, false); err != nil {
+		if current, describeErr := partitionCount(admin, topic); describeErr == nil && current >= partitions {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to add partitions to topic %s: %w", topic, err)
+	}
+	return true, nil
+}
+
+// partitionCount trả về số partition của topic, 0 nếu topic chưa tồn tại.
+func partitionCount(admin sarama.ClusterAdmin, topic string) (int32, error) {
+	metadata, err := admin.DescribeTopics([]string{topic})
+	if err != nil {
+		return 0, fmt.Errorf("failed to describe topic %s: %w", topic, err)
+	}
+	if len(metadata) == 0 || errors.Is(metadata[0].Err, sarama.ErrUnknownTopicOrPartition) {
+		return 0, nil
+	}
+	if metadata[0].Err != sarama.ErrNoError {
+		return 0, fmt.Errorf("failed to describe topic %s: %w", topic, metadata[0].Err)
+	}
+	return int32(len(metadata[0].Partitions)), nil
+}
+
+// EnsureConfiguredTopics tạo các topic của TOPICS_CONFIG_FILE và, khi KAFKA_AUTO_CREATE_TOPICS bật,
+// đảm bảo các topic consumer đọc có đủ KAFKA_TOPIC_PARTITIONS partition.
+// Trả về tên các topic vừa tạo hoặc thêm partition, không làm gì nếu cả hai đều không được cấu hình.
+func EnsureConfiguredTopics(cfg *config.Config) ([]string, error) {
+	if len(cfg.Topics) == 0 && !cfg.AutoCreateTopics {
+		return nil, nil
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	changed, err := client.EnsureTopics(cfg.Topics)
+	if err != nil || !cfg.AutoCreateTopics {
+		return changed, err
+	}
+
+	declared := make(map[string]bool, len(cfg.Topics))
+	for _, topic := range cfg.Topics {
+		declared[topic.Name] = true
+	}
+	for _, topic := range cfg.ConsumerTopics() {
+		if declared[topic] {
+			continue
+		}
+		ok, err := EnsureTopicExists(client.admin, topic, int32(cfg.AutoTopicPartitions), int16(cfg.AutoTopicReplication))
+		if err != nil {
+			return changed, err
+		}
+		if ok {
+			changed = append(changed, topic)
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,283 @@
+package admin
+
+import (
+	"errors"
+	"kafka-notify/pkg/config"
+	apperrors "kafka-notify/pkg/errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// topicAdmin là sarama.ClusterAdmin trong bộ nhớ, chỉ cài các method tạo và liệt kê topic
+type topicAdmin struct {
+	sarama.ClusterAdmin
+
+	mu     sync.Mutex
+	topics map[string]sarama.TopicDetail
+}
+
+func newTopicAdmin(existing ...string) *topicAdmin {
+	admin := &topicAdmin{topics: make(map[string]sarama.TopicDetail)}
+	for _, topic := range existing {
+		admin.topics[topic] = sarama.TopicDetail{NumPartitions: 1, ReplicationFactor: 1}
+	}
+	return admin
+}
+
+func (a *topicAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	topics := make(map[string]sarama.TopicDetail, len(a.topics))
+	for name, detail := range a.topics {
+		topics[name] = detail
+	}
+	return topics, nil
+}
+
+func (a *topicAdmin) CreateTopic(topic string, detail *sarama.TopicDetail, validateOnly bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.topics[topic]; ok {
+		return sarama.ErrTopicAlreadyExists
+	}
+	a.topics[topic] = *detail
+	return nil
+}
+
+func TestEnsureTopicsFromYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topics.yaml")
+	yaml := `
+- name: notifications.normal
+  partitions: 3
+  replicationFactor: 1
+  retention: 168h
+- name: notifications.high
+  partitions: 6
+  replicationFactor: 2
+- name: notifications.dlq
+  partitions: 1
+  replicationFactor: 1
+  retention: 336h
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatalf("failed to write topics file: %v", err)
+	}
+	topics, err := config.LoadTopics(path)
+	if err != nil {
+		t.Fatalf("LoadTopics() error = %v", err)
+	}
+
+	clusterAdmin := newTopicAdmin()
+	created, err := NewClientFromAdmin(clusterAdmin).EnsureTopics(topics)
+	if err != nil {
+		t.Fatalf("EnsureTopics() error = %v", err)
+	}
+	sort.Strings(created)
+	if want := []string{"notifications.dlq", "notifications.high", "notifications.normal"}; !reflect.DeepEqual(created, want) {
+		t.Fatalf("created = %v, want %v", created, want)
+	}
+
+	for _, topic := range topics {
+		detail, ok := clusterAdmin.topics[topic.Name]
+		if !ok {
+			t.Fatalf("topic %s was not created", topic.Name)
+		}
+		if detail.NumPartitions != topic.Partitions || detail.ReplicationFactor != topic.ReplicationFactor {
+			t.Fatalf("topic %s = %d partitions x %d replicas, want %d x %d", topic.Name,
+				detail.NumPartitions, detail.ReplicationFactor, topic.Partitions, topic.ReplicationFactor)
+		}
+	}
+	if retention := clusterAdmin.topics["notifications.normal"].ConfigEntries["retention.ms"]; retention == nil || *retention != "604800000" {
+		t.Fatalf("notifications.normal retention.ms = %v, want 604800000", retention)
+	}
+	if entries := clusterAdmin.topics["notifications.high"].ConfigEntries; entries != nil {
+		t.Fatalf("notifications.high config entries = %v, want broker defaults", entries)
+	}
+}
+
+func TestEnsureTopicsKeepsExistingTopics(t *testing.T) {
+	clusterAdmin := newTopicAdmin("notifications.normal")
+	created, err := NewClientFromAdmin(clusterAdmin).EnsureTopics([]config.TopicConfig{
+		{Name: "notifications.normal", Partitions: 3, ReplicationFactor: 1},
+		{Name: "notifications.high", Partitions: 3, ReplicationFactor: 1},
+	})
+	if err != nil {
+		t.Fatalf("EnsureTopics() error = %v", err)
+	}
+	if !reflect.DeepEqual(created, []string{"notifications.high"}) {
+		t.Fatalf("created = %v, want [notifications.high]", created)
+	}
+	if got := clusterAdmin.topics["notifications.normal"].NumPartitions; got != 1 {
+		t.Fatalf("existing topic partitions = %d, want 1 (unchanged)", got)
+	}
+}
+
+// newMockClusterAdmin tạo ClusterAdmin trên sarama.MockBroker, topic trong partitions đã tồn tại
+// với số partition cho trước
+func newMockClusterAdmin(t *testing.T, partitions map[string]int32) (sarama.ClusterAdmin, *sarama.MockBroker) {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	setMockTopics(t, broker, partitions)
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0
+	clusterAdmin, err := sarama.NewClusterAdmin([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatalf("NewClusterAdmin() error = %v", err)
+	}
+	t.Cleanup(func() { clusterAdmin.Close() })
+	return clusterAdmin, broker
+}
+
+// setMockTopics đặt lại handler của broker để metadata chứa các topic trong partitions
+func setMockTopics(t *testing.T, broker *sarama.MockBroker, partitions map[string]int32) {
+	t.Helper()
+	metadata := sarama.NewMockMetadataResponse(t).
+		SetBroker(broker.Addr(), broker.BrokerID()).
+		SetController(broker.BrokerID())
+	for topic, count := range partitions {
+		for partition := int32(0); partition < count; partition++ {
+			metadata.SetLeader(topic, partition, broker.BrokerID())
+		}
+	}
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest":         metadata,
+		"CreateTopicsRequest":     sarama.NewMockCreateTopicsResponse(t),
+		"CreatePartitionsRequest": sarama.NewMockCreatePartitionsResponse(t),
+	})
+}
+
+// adminRequests trả về các request CreateTopics và CreatePartitions broker đã nhận
+func adminRequests(broker *sarama.MockBroker) (created []*sarama.CreateTopicsRequest, added []*sarama.CreatePartitionsRequest) {
+	for _, rr := range broker.History() {
+		switch request := rr.Request.(type) {
+		case *sarama.CreateTopicsRequest:
+			created = append(created, request)
+		case *sarama.CreatePartitionsRequest:
+			added = append(added, request)
+		}
+	}
+	return created, added
+}
+
+func TestEnsureTopicExists(t *testing.T) {
+	const topic = "notifications.normal"
+	tests := []struct {
+		name         string
+		existing     map[string]int32
+		wantChanged  bool
+		wantCreated  bool
+		wantAddition bool
+	}{
+		{name: "topic missing", existing: nil, wantChanged: true, wantCreated: true},
+		{name: "fewer partitions", existing: map[string]int32{topic: 1}, wantChanged: true, wantAddition: true},
+		{name: "enough partitions", existing: map[string]int32{topic: 3}},
+		// Kafka không giảm được partition nên topic nhiều partition hơn được giữ nguyên
+		{name: "more partitions", existing: map[string]int32{topic: 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterAdmin, broker := newMockClusterAdmin(t, tt.existing)
+			changed, err := EnsureTopicExists(clusterAdmin, topic, 3, 1)
+			if err != nil {
+				t.Fatalf("EnsureTopicExists() error = %v", err)
+			}
+			if changed != tt.wantChanged {
+				t.Fatalf("EnsureTopicExists() = %v, want %v", changed, tt.wantChanged)
+			}
+
+			created, added := adminRequests(broker)
+			if tt.wantCreated != (len(created) == 1) || tt.wantAddition != (len(added) == 1) {
+				t.Fatalf("broker got %d CreateTopics and %d CreatePartitions requests", len(created), len(added))
+			}
+			if tt.wantCreated {
+				detail := created[0].TopicDetails[topic]
+				if detail == nil || detail.NumPartitions != 3 || detail.ReplicationFactor != 1 {
+					t.Fatalf("CreateTopics detail = %+v, want 3 partitions x 1 replica", detail)
+				}
+			}
+			if tt.wantAddition {
+				partitions := added[0].TopicPartitions[topic]
+				if partitions == nil || partitions.Count != 3 {
+					t.Fatalf("CreatePartitions = %+v, want count 3", partitions)
+				}
+			}
+		})
+	}
+}
+
+func TestEnsureConfiguredTopicsDisabled(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	// không bật KAFKA_AUTO_CREATE_TOPICS và không có TOPICS_CONFIG_FILE thì không kết nối broker
+	cfg.AutoCreateTopics, cfg.Topics = false, nil
+	cfg.KafkaBrokers = []string{"127.0.0.1:1"}
+	changed, err := EnsureConfiguredTopics(cfg)
+	if err != nil || changed != nil {
+		t.Fatalf("EnsureConfiguredTopics() = %v, %v, want nothing", changed, err)
+	}
+}
+
+func TestClientCreateAndDescribeTopic(t *testing.T) {
+	const topic = "notifications.campaigns"
+	clusterAdmin, broker := newMockClusterAdmin(t, nil)
+	client := NewClientFromAdmin(clusterAdmin)
+
+	if _, err := client.DescribeTopic(topic); !errors.Is(err, &apperrors.ErrTopicNotFound{}) {
+		t.Fatalf("DescribeTopic() before create error = %v, want ErrTopicNotFound", err)
+	}
+
+	if err := client.CreateTopic(TopicSpec{Name: topic, NumPartitions: 3, ReplicationFactor: 1}); err != nil {
+		t.Fatalf("CreateTopic() error = %v", err)
+	}
+	created, _ := adminRequests(broker)
+	if len(created) != 1 {
+		t.Fatalf("broker got %d CreateTopics requests, want 1", len(created))
+	}
+	detail := created[0].TopicDetails[topic]
+	if detail == nil || detail.NumPartitions != 3 || detail.ReplicationFactor != 1 {
+		t.Fatalf("CreateTopics detail = %+v, want 3 partitions x 1 replica", detail)
+	}
+
+	// broker giả không tự lưu topic, metadata được cập nhật như cluster thật sau khi tạo
+	setMockTopics(t, broker, map[string]int32{topic: 3})
+	info, err := client.DescribeTopic(topic)
+	if err != nil {
+		t.Fatalf("DescribeTopic() error = %v", err)
+	}
+	if info.Name != topic || len(info.Partitions) != 3 {
+		t.Fatalf("DescribeTopic() = %+v, want %s with 3 partitions", info, topic)
+	}
+	for i, partition := range info.Partitions {
+		if partition.ID != int32(i) || partition.Leader != broker.BrokerID() ||
+			!reflect.DeepEqual(partition.Replicas, []int32{broker.BrokerID()}) {
+			t.Fatalf("partition %d = %+v, want leader and replica %d", i, partition, broker.BrokerID())
+		}
+	}
+}
+
+func TestClientCreateTopicInvalid(t *testing.T) {
+	clusterAdmin, broker := newMockClusterAdmin(t, nil)
+	client := NewClientFromAdmin(clusterAdmin)
+	for _, spec := range []TopicSpec{
+		{NumPartitions: 1, ReplicationFactor: 1},
+		{Name: "notifications.invalid", ReplicationFactor: 1},
+		{Name: "notifications.invalid", NumPartitions: 1},
+	} {
+		if err := client.CreateTopic(spec); !errors.Is(err, ErrInvalidTopic) {
+			t.Fatalf("CreateTopic(%+v) error = %v, want ErrInvalidTopic", spec, err)
+		}
+	}
+	if created, _ := adminRequests(broker); len(created) != 0 {
+		t.Fatalf("broker got %d CreateTopics requests for invalid specs, want 0", len(created))
+	}
+}
@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// PartitionChange báo số partition của Topic đổi từ Old sang New, Old là 0 ở lần đầu quan sát.
+type PartitionChange struct {
+	Topic string
+	Old   int32
+	New   int32
+}
+
+// độ lớn buffer của channel mỗi subscriber
+const subscriberBuffer = 16
+
+// PartitionWatcher định kỳ gọi DescribeTopics và báo cho các subscriber khi số partition
+// của một topic thay đổi (ví dụ sau khi tăng partition bằng kafka-topics --alter).
+type PartitionWatcher struct {
+	// OnError khác nil thì được gọi khi DescribeTopics lỗi, watcher vẫn chạy tiếp
+	OnError func(error)
+
+	admin    sarama.ClusterAdmin
+	topics   []string
+	interval time.Duration
+
+	mu          sync.Mutex
+	counts      map[string]int32
+	subscribers []chan PartitionChange
+}
+
+func NewPartitionWatcher(admin sarama.ClusterAdmin, topics []string, interval time.Duration) *PartitionWatcher {
+	return &PartitionWatcher{admin: admin, topics: topics, interval: interval, counts: make(map[string]int32)}
+}
+
+// WatchPartitions tạo PartitionWatcher dùng ClusterAdmin của client.
+func (c *Client) WatchPartitions(topics []string, interval time.Duration) *PartitionWatcher {
+	return NewPartitionWatcher(c.admin, topics, interval)
+}
+
+// Subscribe trả về channel nhận PartitionChange, phải gọi trước Run để nhận cả lần quan sát đầu.
+// Subscriber đọc chậm để đầy buffer thì thay đổi mới bị bỏ qua thay vì chặn watcher.
+// Channel được đóng khi Run kết thúc.
+func (w *PartitionWatcher) Subscribe() <-chan PartitionChange {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ch := make(chan PartitionChange, subscriberBuffer)
+	w.subscribers = append(w.subscribers, ch)
+	return ch
+}
+
+// Run kiểm tra ngay rồi lặp lại sau mỗi interval cho tới khi ctx bị huỷ.
+func (w *PartitionWatcher) Run(ctx context.Context) {
+	defer w.closeSubscribers()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if err := w.poll(); err != nil && w.OnError != nil {
+			w.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Count trả về số partition quan sát được gần nhất của topic.
+func (w *PartitionWatcher) Count(topic string) (int32, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	count, ok := w.counts[topic]
+	return count, ok
+}
+
+func (w *PartitionWatcher) poll() error {
+	metadata, err := w.admin.DescribeTopics(w.topics)
+	if err != nil {
+		return fmt.Errorf("failed to describe topics: %w", err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// topic chưa tồn tại hoặc lỗi riêng thì bỏ qua, giữ số partition cũ
+	for _, topic := range metadata {
+		if topic.Err != sarama.ErrNoError {
+			continue
+		}
+		count := int32(len(topic.Partitions))
+		old := w.counts[topic.Name]
+		if count == old {
+			continue
+		}
+		w.counts[topic.Name] = count
+		change := PartitionChange{Topic: topic.Name, Old: old, New: count}
+		for _, ch := range w.subscribers {
+			select {
+			case ch <- change:
+			default:
+			}
+		}
+	}
+	return nil
+}
+
+func (w *PartitionWatcher) closeSubscribers() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, ch := range w.subscribers {
+		close(ch)
+	}
+	w.subscribers = nil
+}
@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"context"
+	"kafka-notify/pkg/partitioner"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// fakeClusterAdmin chỉ cài DescribeTopics, method khác của sarama.ClusterAdmin không được gọi
+type fakeClusterAdmin struct {
+	sarama.ClusterAdmin
+
+	mu         sync.Mutex
+	partitions int
+}
+
+func (a *fakeClusterAdmin) setPartitions(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.partitions = n
+}
+
+func (a *fakeClusterAdmin) DescribeTopics(topics []string) ([]*sarama.TopicMetadata, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	metadata := make([]*sarama.TopicMetadata, 0, len(topics))
+	for _, topic := range topics {
+		partitions := make([]*sarama.PartitionMetadata, a.partitions)
+		for i := range partitions {
+			partitions[i] = &sarama.PartitionMetadata{ID: int32(i)}
+		}
+		metadata = append(metadata, &sarama.TopicMetadata{Name: topic, Partitions: partitions})
+	}
+	return metadata, nil
+}
+
+// maxPartition là partition lớn nhất UserPartitioner chọn cho 1000 key khi sarama biết numPartitions
+func maxPartition(t *testing.T, topic string, numPartitions int32) int32 {
+	t.Helper()
+	userPartitioner := partitioner.NewUserPartitioner(topic)
+	var highest int32
+	for i := 0; i < 1000; i++ {
+		partition, err := userPartitioner.Partition(&sarama.ProducerMessage{
+			Topic: topic, Key: sarama.StringEncoder(strconv.Itoa(i)),
+		}, numPartitions)
+		if err != nil {
+			t.Fatalf("Partition() error = %v", err)
+		}
+		if partition > highest {
+			highest = partition
+		}
+	}
+	return highest
+}
+
+func waitChange(t *testing.T, changes <-chan PartitionChange) PartitionChange {
+	t.Helper()
+	select {
+	case change := <-changes:
+		return change
+	case <-time.After(time.Second):
+		t.Fatal("no partition change reported")
+	}
+	return PartitionChange{}
+}
+
+func TestPartitionWatcherPartitionerAdapts(t *testing.T) {
+	const topic = "test.partition-watcher"
+	clusterAdmin := &fakeClusterAdmin{partitions: 3}
+	watcher := NewPartitionWatcher(clusterAdmin, []string{topic}, 10*time.Millisecond)
+	changes := watcher.Subscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watcher.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	change := waitChange(t, changes)
+	if change != (PartitionChange{Topic: topic, Old: 0, New: 3}) {
+		t.Fatalf("first change = %+v, want 0 -> 3", change)
+	}
+	partitioner.SetPartitionCount(change.Topic, change.New)
+	// sarama đã thấy 6 partition nhưng watcher mới thấy 3, partitioner chỉ dùng 3
+	if got := maxPartition(t, topic, 6); got != 2 {
+		t.Fatalf("max partition with 3 watched partitions = %d, want 2", got)
+	}
+
+	clusterAdmin.setPartitions(6)
+	change = waitChange(t, changes)
+	if change != (PartitionChange{Topic: topic, Old: 3, New: 6}) {
+		t.Fatalf("second change = %+v, want 3 -> 6", change)
+	}
+	partitioner.SetPartitionCount(change.Topic, change.New)
+	if got := maxPartition(t, topic, 6); got != 5 {
+		t.Fatalf("max partition with 6 watched partitions = %d, want 5", got)
+	}
+	if count, ok := watcher.Count(topic); !ok || count != 6 {
+		t.Fatalf("Count() = %d, %v, want 6", count, ok)
+	}
+}
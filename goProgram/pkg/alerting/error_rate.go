@@ -0,0 +1,100 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// minAlertMessages là số message tối thiểu trong Window trước khi tính tỉ lệ lỗi,
+	// để vài message lỗi lúc ít traffic không gọi người trực dậy
+	minAlertMessages = 10
+	alertTimeout     = 10 * time.Second
+)
+
+// Alerter gửi cảnh báo ra hệ thống bên ngoài, ví dụ PagerDutyAlerter.
+type Alerter interface {
+	Alert(ctx context.Context, title, details string) error
+}
+
+type rateBucket struct {
+	second int64
+	total  int
+	failed int
+}
+
+// ErrorRateMonitor đếm message xử lý thành công/thất bại trong Window gần nhất (chia theo từng giây)
+// và gọi Alerter khi tỉ lệ lỗi vượt Threshold. Sau mỗi lần alert, monitor chờ hết một Window
+// mới alert lại để không tạo hàng loạt event khi lỗi kéo dài.
+type ErrorRateMonitor struct {
+	Alerter Alerter
+	Window  time.Duration
+	// Threshold là tỉ lệ lỗi (0..1), ví dụ 0.1 là 10% message trong Window thất bại
+	Threshold float64
+	// OnError được gọi khi gửi alert thất bại, nil là bỏ qua
+	OnError func(err error)
+
+	mu        sync.Mutex
+	buckets   []rateBucket
+	alertedAt time.Time
+}
+
+func NewErrorRateMonitor(alerter Alerter, window time.Duration, threshold float64) *ErrorRateMonitor {
+	return &ErrorRateMonitor{Alerter: alerter, Window: window, Threshold: threshold}
+}
+
+// Record ghi nhận kết quả xử lý một message, err khác nil là thất bại.
+// Alert được gửi trên goroutine riêng nên Record không chặn consumer.
+func (m *ErrorRateMonitor) Record(err error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.buckets == nil {
+		size := int(m.Window / time.Second)
+		if size < 1 {
+			size = 1
+		}
+		m.buckets = make([]rateBucket, size)
+	}
+	second := now.Unix()
+	bucket := &m.buckets[second%int64(len(m.buckets))]
+	if bucket.second != second {
+		*bucket = rateBucket{second: second}
+	}
+	bucket.total++
+	if err == nil {
+		return
+	}
+	bucket.failed++
+
+	total, failed := m.counts(second)
+	rate := float64(failed) / float64(total)
+	if total < minAlertMessages || rate <= m.Threshold || now.Sub(m.alertedAt) < m.Window {
+		return
+	}
+	m.alertedAt = now
+	title := fmt.Sprintf("consumer error rate %.1f%% exceeds %.1f%%", rate*100, m.Threshold*100)
+	details := fmt.Sprintf("%d of %d messages failed in the last %s, last error: %v", failed, total, m.Window, err)
+	go m.alert(title, details)
+}
+
+// counts phải được gọi khi đã giữ mu, bucket cũ hơn Window bị bỏ qua
+func (m *ErrorRateMonitor) counts(now int64) (total, failed int) {
+	for _, bucket := range m.buckets {
+		if now-bucket.second < int64(len(m.buckets)) {
+			total += bucket.total
+			failed += bucket.failed
+		}
+	}
+	return total, failed
+}
+
+func (m *ErrorRateMonitor) alert(title, details string) {
+	ctx, cancel := context.WithTimeout(context.Background(), alertTimeout)
+	defer cancel()
+	if err := m.Alerter.Alert(ctx, title, details); err != nil && m.OnError != nil {
+		m.OnError(err)
+	}
+}
@@ -0,0 +1,85 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// PagerDutyEventsURL là endpoint của PagerDuty Events API v2
+	PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	pagerDutyTimeout   = 5 * time.Second
+)
+
+// PagerDutyAlerter tạo incident trên PagerDuty qua Events API v2. APIKey là integration (routing) key
+// của service trên PagerDuty, ServiceID là tên nguồn gửi alert và cũng là dedup key, nên các alert
+// gửi khi incident còn mở được gom vào cùng một incident.
+type PagerDutyAlerter struct {
+	APIKey    string
+	ServiceID string
+	// URL mặc định là PagerDutyEventsURL
+	URL    string
+	Client *http.Client
+}
+
+func NewPagerDutyAlerter(apiKey, serviceID string) *PagerDutyAlerter {
+	return &PagerDutyAlerter{
+		APIKey:    apiKey,
+		ServiceID: serviceID,
+		URL:       PagerDutyEventsURL,
+		Client:    &http.Client{Timeout: pagerDutyTimeout},
+	}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// Alert gửi event trigger với severity critical, PagerDuty trả 202 khi đã nhận event.
+func (a *PagerDutyAlerter) Alert(ctx context.Context, title, details string) error {
+	payload, err := json.Marshal(pagerDutyEvent{
+		RoutingKey:  a.APIKey,
+		EventAction: "trigger",
+		DedupKey:    a.ServiceID,
+		Payload: pagerDutyPayload{
+			Summary:       title,
+			Source:        a.ServiceID,
+			Severity:      "critical",
+			CustomDetails: map[string]string{"details": details},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal pagerduty event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create pagerduty request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send pagerduty event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to send pagerduty event: status %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newPagerDutyServer giả lập Events API v2: trả về status cho mỗi event và gửi event nhận được vào channel
+func newPagerDutyServer(t *testing.T, status int) (*httptest.Server, <-chan pagerDutyEvent) {
+	t.Helper()
+	events := make(chan pagerDutyEvent, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %s %s, want POST application/json", r.Method, r.Header.Get("Content-Type"))
+		}
+		var event pagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("failed to decode event: %v", err)
+		}
+		events <- event
+		w.WriteHeader(status)
+		if status == http.StatusAccepted {
+			w.Write([]byte(`{"status":"success","dedup_key":"kafka-consumer"}`))
+		} else {
+			w.Write([]byte(`{"status":"invalid event","errors":["routing_key is invalid"]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, events
+}
+
+func newTestAlerter(server *httptest.Server) *PagerDutyAlerter {
+	alerter := NewPagerDutyAlerter("routing-key", "kafka-consumer")
+	alerter.URL = server.URL
+	return alerter
+}
+
+func TestPagerDutyAlerterAlert(t *testing.T) {
+	server, events := newPagerDutyServer(t, http.StatusAccepted)
+
+	if err := newTestAlerter(server).Alert(context.Background(), "error rate high", "3 of 20 failed"); err != nil {
+		t.Fatalf("Alert() error = %v", err)
+	}
+	event := <-events
+	if event.RoutingKey != "routing-key" || event.EventAction != "trigger" || event.DedupKey != "kafka-consumer" {
+		t.Fatalf("event = %+v, want trigger with routing key and dedup key", event)
+	}
+	if event.Payload.Summary != "error rate high" || event.Payload.Source != "kafka-consumer" ||
+		event.Payload.Severity != "critical" || event.Payload.CustomDetails["details"] != "3 of 20 failed" {
+		t.Fatalf("payload = %+v", event.Payload)
+	}
+}
+
+func TestPagerDutyAlerterErrors(t *testing.T) {
+	server, _ := newPagerDutyServer(t, http.StatusBadRequest)
+	err := newTestAlerter(server).Alert(context.Background(), "title", "details")
+	if err == nil || !strings.Contains(err.Error(), "status 400") || !strings.Contains(err.Error(), "routing_key is invalid") {
+		t.Fatalf("Alert() error = %v, want status 400 with body", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := newTestAlerter(server).Alert(ctx, "title", "details"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Alert() with cancelled ctx error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// waitEvent trả về event PagerDuty nhận được trong timeout, false nếu không có
+func waitEvent(events <-chan pagerDutyEvent, timeout time.Duration) (pagerDutyEvent, bool) {
+	select {
+	case event := <-events:
+		return event, true
+	case <-time.After(timeout):
+		return pagerDutyEvent{}, false
+	}
+}
+
+func TestErrorRateMonitorAlertsPagerDuty(t *testing.T) {
+	server, events := newPagerDutyServer(t, http.StatusAccepted)
+	monitor := NewErrorRateMonitor(newTestAlerter(server), time.Minute, 0.1)
+	monitor.OnError = func(err error) { t.Errorf("alert error = %v", err) }
+
+	// 2 / 20 là đúng ngưỡng 10%, chưa alert
+	for i := 0; i < 18; i++ {
+		monitor.Record(nil)
+	}
+	monitor.Record(errors.New("decode failed"))
+	monitor.Record(errors.New("decode failed"))
+	if event, ok := waitEvent(events, 50*time.Millisecond); ok {
+		t.Fatalf("alert at 10%% error rate: %+v", event)
+	}
+
+	monitor.Record(errors.New("store unavailable"))
+	event, ok := waitEvent(events, time.Second)
+	if !ok {
+		t.Fatal("no alert after error rate exceeded the threshold")
+	}
+	if !strings.Contains(event.Payload.Summary, "14.3%") ||
+		!strings.Contains(event.Payload.CustomDetails["details"], "3 of 21 messages failed") ||
+		!strings.Contains(event.Payload.CustomDetails["details"], "store unavailable") {
+		t.Fatalf("payload = %+v", event.Payload)
+	}
+
+	// lỗi tiếp tục trong cùng Window không tạo thêm event
+	for i := 0; i < 10; i++ {
+		monitor.Record(errors.New("store unavailable"))
+	}
+	if event, ok := waitEvent(events, 50*time.Millisecond); ok {
+		t.Fatalf("second alert in the same window: %+v", event)
+	}
+}
+
+func TestErrorRateMonitorNeedsMinimumMessages(t *testing.T) {
+	server, events := newPagerDutyServer(t, http.StatusAccepted)
+	monitor := NewErrorRateMonitor(newTestAlerter(server), time.Minute, 0.1)
+
+	for i := 0; i < minAlertMessages-1; i++ {
+		monitor.Record(errors.New("decode failed"))
+	}
+	if event, ok := waitEvent(events, 50*time.Millisecond); ok {
+		t.Fatalf("alert with %d messages: %+v", minAlertMessages-1, event)
+	}
+}
+
+func TestErrorRateMonitorReportsAlertFailure(t *testing.T) {
+	server, _ := newPagerDutyServer(t, http.StatusBadRequest)
+	monitor := NewErrorRateMonitor(newTestAlerter(server), time.Minute, 0.1)
+	alertErrs := make(chan error, 1)
+	monitor.OnError = func(err error) { alertErrs <- err }
+
+	for i := 0; i < minAlertMessages; i++ {
+		monitor.Record(errors.New("decode failed"))
+	}
+	select {
+	case err := <-alertErrs:
+		if !strings.Contains(err.Error(), "status 400") {
+			t.Fatalf("OnError() error = %v, want status 400", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnError was not called")
+	}
+}
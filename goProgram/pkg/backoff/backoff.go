@@ -0,0 +1,87 @@
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Tên các strategy của KAFKA_PRODUCER_RETRY_BACKOFF_STRATEGY
+const (
+	StrategyFixed       = "fixed"
+	StrategyExponential = "exponential"
+	StrategyJitter      = "jitter"
+)
+
+// jitterFraction là biên độ ngẫu nhiên của JitteredBackoff, ±20% quanh backoff gốc
+const jitterFraction = 0.2
+
+// Backoff cho biết phải chờ bao lâu trước lần retry thứ attempt (bắt đầu từ 1).
+type Backoff interface {
+	Duration(attempt int) time.Duration
+}
+
+// FixedBackoff chờ Wait giữa mọi lần retry, giống Producer.Retry.Backoff mặc định của sarama.
+type FixedBackoff struct {
+	Wait time.Duration
+}
+
+func (b FixedBackoff) Duration(int) time.Duration {
+	return b.Wait
+}
+
+// ExponentialBackoff chờ Initial ở lần retry đầu, mỗi lần sau nhân thêm Multiplier và không vượt Max.
+// Max bằng 0 là không giới hạn, Multiplier nhỏ hơn 1 coi như 1.
+type ExponentialBackoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+func (b ExponentialBackoff) Duration(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	multiplier := math.Max(b.Multiplier, 1)
+	wait := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	// so sánh trên float để số lần retry lớn không làm tràn time.Duration
+	if b.Max > 0 && wait >= float64(b.Max) {
+		return b.Max
+	}
+	if wait >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(wait)
+}
+
+// JitteredBackoff cộng trừ ngẫu nhiên đều tới 20% vào backoff của Base, để các producer
+// cùng gặp lỗi (ví dụ khi leader đổi) không retry vào broker đồng loạt.
+type JitteredBackoff struct {
+	Base ExponentialBackoff
+}
+
+func (b JitteredBackoff) Duration(attempt int) time.Duration {
+	wait := float64(b.Base.Duration(attempt))
+	return time.Duration(wait * (1 + jitterFraction*(2*rand.Float64()-1)))
+}
+
+// New tạo Backoff theo tên strategy, tên không hợp lệ (config đã validate) dùng FixedBackoff với initial.
+func New(strategy string, initial, max time.Duration, multiplier float64) Backoff {
+	exponential := ExponentialBackoff{Initial: initial, Max: max, Multiplier: multiplier}
+	switch strategy {
+	case StrategyExponential:
+		return exponential
+	case StrategyJitter:
+		return JitteredBackoff{Base: exponential}
+	default:
+		return FixedBackoff{Wait: initial}
+	}
+}
+
+// SaramaFunc chuyển Backoff sang Producer.Retry.BackoffFunc của sarama,
+// retries là số lần đã retry của message kể cả lần sắp tới.
+func SaramaFunc(b Backoff) func(retries, maxRetries int) time.Duration {
+	return func(retries, _ int) time.Duration {
+		return b.Duration(retries)
+	}
+}
@@ -0,0 +1,166 @@
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: 100 * time.Millisecond},
+		{attempt: 1, want: 100 * time.Millisecond},
+		{attempt: 2, want: 200 * time.Millisecond},
+		{attempt: 4, want: 800 * time.Millisecond},
+		{attempt: 5, want: time.Second},
+		{attempt: 1000, want: time.Second},
+	}
+	for _, tt := range tests {
+		if got := b.Duration(tt.attempt); got != tt.want {
+			t.Fatalf("Duration(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+
+	unbounded := ExponentialBackoff{Initial: time.Second, Multiplier: 10}
+	if got := unbounded.Duration(1000); got != time.Duration(math.MaxInt64) {
+		t.Fatalf("Duration(1000) without Max = %v, want MaxInt64", got)
+	}
+	flat := ExponentialBackoff{Initial: time.Second, Multiplier: 0.5}
+	if got := flat.Duration(3); got != time.Second {
+		t.Fatalf("Duration(3) with Multiplier 0.5 = %v, want %v", got, time.Second)
+	}
+}
+
+func TestJitteredBackoffBounds(t *testing.T) {
+	b := JitteredBackoff{Base: ExponentialBackoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}}
+	for attempt := 1; attempt <= 6; attempt++ {
+		base := b.Base.Duration(attempt)
+		low, high := time.Duration(float64(base)*0.8), time.Duration(float64(base)*1.2)
+		distinct := map[time.Duration]bool{}
+		for i := 0; i < 200; i++ {
+			got := b.Duration(attempt)
+			if got < low || got > high {
+				t.Fatalf("Duration(%d) = %v, want within [%v, %v]", attempt, got, low, high)
+			}
+			distinct[got] = true
+		}
+		if len(distinct) < 2 {
+			t.Fatalf("Duration(%d) returned the same value 200 times, want jitter", attempt)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     Backoff
+	}{
+		{strategy: StrategyFixed, want: FixedBackoff{Wait: 100 * time.Millisecond}},
+		{strategy: "", want: FixedBackoff{Wait: 100 * time.Millisecond}},
+		{strategy: StrategyExponential, want: ExponentialBackoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 3}},
+		{strategy: StrategyJitter, want: JitteredBackoff{Base: ExponentialBackoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 3}}},
+	}
+	for _, tt := range tests {
+		if got := New(tt.strategy, 100*time.Millisecond, time.Second, 3); got != tt.want {
+			t.Fatalf("New(%q) = %#v, want %#v", tt.strategy, got, tt.want)
+		}
+	}
+
+	saramaFunc := SaramaFunc(New(StrategyExponential, 100*time.Millisecond, time.Second, 2))
+	if got := saramaFunc(3, 5); got != 400*time.Millisecond {
+		t.Fatalf("SaramaFunc()(3, 5) = %v, want 400ms", got)
+	}
+}
+
+// simulateRetries giả lập một message gặp lỗi tạm thời (ví dụ leader đang bầu lại) kéo dài outage:
+// lần gửi đầu thất bại, mỗi lần retry chờ theo b, message tới được broker khi tổng thời gian chờ đã qua outage.
+// Trả về số lần retry, thời gian tới khi gửi được, và false nếu hết maxRetries lần retry.
+func simulateRetries(b Backoff, outage time.Duration, maxRetries int) (int, time.Duration, bool) {
+	var elapsed time.Duration
+	for retry := 1; retry <= maxRetries; retry++ {
+		elapsed += b.Duration(retry)
+		if elapsed >= outage {
+			return retry, elapsed, true
+		}
+	}
+	return maxRetries, elapsed, false
+}
+
+// BenchmarkRetryConvergence so sánh các strategy trên cùng dãy outage ngẫu nhiên 50ms-3s với Retry.Max 5
+// như NewProducerConfig, thời gian là giả lập nên không phải sleep:
+// delivered là tỉ lệ message gửi được, retries/op là số lần retry, wait-ms/op là thời gian tới khi gửi được
+// và late-ms/op là thời gian chờ thừa sau khi broker đã hồi phục.
+func BenchmarkRetryConvergence(b *testing.B) {
+	const (
+		maxRetries = 5
+		initial    = 100 * time.Millisecond
+		maxBackoff = 5 * time.Second
+	)
+	strategies := []string{StrategyFixed, StrategyExponential, StrategyJitter}
+	for _, strategy := range strategies {
+		b.Run(strategy, func(b *testing.B) {
+			backoff := New(strategy, initial, maxBackoff, 2)
+			outages := rand.New(rand.NewSource(1))
+			var delivered, retries int
+			var wait, late time.Duration
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				outage := 50*time.Millisecond + time.Duration(outages.Int63n(int64(3*time.Second)))
+				n, elapsed, ok := simulateRetries(backoff, outage, maxRetries)
+				retries += n
+				if ok {
+					delivered++
+					wait += elapsed
+					late += elapsed - outage
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(delivered)/float64(b.N), "delivered")
+			b.ReportMetric(float64(retries)/float64(b.N), "retries/op")
+			if delivered > 0 {
+				b.ReportMetric(float64(wait.Milliseconds())/float64(delivered), "wait-ms/op")
+				b.ReportMetric(float64(late.Milliseconds())/float64(delivered), "late-ms/op")
+			}
+		})
+	}
+}
+
+// BenchmarkRetryBurst đo số producer retry vào broker trong cùng một cửa sổ 10ms khi 100 producer
+// cùng gặp lỗi một lúc, peak-retries càng thấp thì broker vừa hồi phục càng ít bị dồn request.
+func BenchmarkRetryBurst(b *testing.B) {
+	const (
+		producers  = 100
+		maxRetries = 5
+		slot       = 10 * time.Millisecond
+	)
+	strategies := []string{StrategyFixed, StrategyExponential, StrategyJitter}
+	for _, strategy := range strategies {
+		b.Run(strategy, func(b *testing.B) {
+			backoff := New(strategy, 100*time.Millisecond, 5*time.Second, 2)
+			var peak int
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				slots := map[time.Duration]int{}
+				for p := 0; p < producers; p++ {
+					var elapsed time.Duration
+					for retry := 1; retry <= maxRetries; retry++ {
+						elapsed += backoff.Duration(retry)
+						slots[elapsed/slot]++
+					}
+				}
+				for _, count := range slots {
+					if count > peak {
+						peak = count
+					}
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(peak), "peak-retries")
+		})
+	}
+}
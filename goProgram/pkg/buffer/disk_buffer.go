@@ -0,0 +1,148 @@
+package buffer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucketMessages = []byte("messages")
+
+// record là dạng lưu trên đĩa của sarama.ProducerMessage, partition không được lưu
+// vì partitioner chọn lại khi gửi.
+type record struct {
+	Topic   string                `json:"topic"`
+	Key     []byte                `json:"key,omitempty"`
+	Value   []byte                `json:"value,omitempty"`
+	Headers []sarama.RecordHeader `json:"headers,omitempty"`
+}
+
+// DiskBuffer là WAL trên BoltDB giữ các message chưa gửi được khi Kafka không khả dụng.
+// Mỗi message có key là sequence tăng dần (big-endian), nên Flush gửi lại đúng thứ tự đã ghi
+// và message vẫn còn sau khi process bị kill.
+type DiskBuffer struct {
+	db *bolt.DB
+}
+
+// Open mở (hoặc tạo) file BoltDB ở path. BoltDB khoá file nên chỉ một process mở được cùng lúc.
+func Open(path string) (*DiskBuffer, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open buffer %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketMessages)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open buffer %s: %w", path, err)
+	}
+	return &DiskBuffer{db: db}, nil
+}
+
+// Append ghi msg vào cuối buffer, trả về khi đã fsync xuống đĩa.
+func (b *DiskBuffer) Append(msg *sarama.ProducerMessage) error {
+	rec := record{Topic: msg.Topic, Headers: msg.Headers}
+	var err error
+	if msg.Key != nil {
+		if rec.Key, err = msg.Key.Encode(); err != nil {
+			return fmt.Errorf("failed to encode message key: %w", err)
+		}
+	}
+	if msg.Value != nil {
+		if rec.Value, err = msg.Value.Encode(); err != nil {
+			return fmt.Errorf("failed to encode message value: %w", err)
+		}
+	}
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal buffered message: %w", err)
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketMessages)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return fmt.Errorf("failed to buffer message: %w", err)
+		}
+		return bucket.Put(sequenceKey(seq), payload)
+	})
+}
+
+// Len trả về số message đang nằm trong buffer.
+func (b *DiskBuffer) Len() (int, error) {
+	var n int
+	err := b.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(bucketMessages).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+// Flush gửi lần lượt các message theo thứ tự ghi bằng send và xoá message đã gửi thành công,
+// dừng ở lỗi đầu tiên để giữ thứ tự. Message bị xoá sau khi send trả về nên process chết giữa hai bước
+// sẽ gửi lại message đó lần nữa (at-least-once). Trả về số message đã gửi.
+func (b *DiskBuffer) Flush(send func(msg *sarama.ProducerMessage) error) (int, error) {
+	sent := 0
+	for {
+		key, msg, err := b.first()
+		if err != nil || msg == nil {
+			return sent, err
+		}
+		// không giữ transaction trong lúc gửi để Append vẫn ghi được
+		if err := send(msg); err != nil {
+			return sent, err
+		}
+		err = b.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(bucketMessages).Delete(key)
+		})
+		if err != nil {
+			return sent, fmt.Errorf("failed to remove flushed message: %w", err)
+		}
+		sent++
+	}
+}
+
+// first trả về message cũ nhất, msg nil nghĩa là buffer rỗng.
+func (b *DiskBuffer) first() ([]byte, *sarama.ProducerMessage, error) {
+	var (
+		key []byte
+		rec record
+	)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		k, v := tx.Bucket(bucketMessages).Cursor().First()
+		if k == nil {
+			return nil
+		}
+		key = append([]byte(nil), k...)
+		return json.Unmarshal(v, &rec)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read buffered message: %w", err)
+	}
+	if key == nil {
+		return nil, nil, nil
+	}
+	msg := &sarama.ProducerMessage{Topic: rec.Topic, Headers: rec.Headers}
+	if rec.Key != nil {
+		msg.Key = sarama.ByteEncoder(rec.Key)
+	}
+	if rec.Value != nil {
+		msg.Value = sarama.ByteEncoder(rec.Value)
+	}
+	return key, msg, nil
+}
+
+func (b *DiskBuffer) Close() error {
+	return b.db.Close()
+}
+
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
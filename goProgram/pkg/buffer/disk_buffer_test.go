@@ -0,0 +1,143 @@
+package buffer
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// crashBufferEnv là đường dẫn buffer mà process con ghi vào trước khi bị kill
+	crashBufferEnv  = "DISK_BUFFER_CRASH_PATH"
+	crashedMessages = 5
+)
+
+func bufferedMessage(i int) *sarama.ProducerMessage {
+	return &sarama.ProducerMessage{
+		Topic:   "notifications.normal",
+		Key:     sarama.StringEncoder("user-" + strconv.Itoa(i)),
+		Value:   sarama.StringEncoder("message-" + strconv.Itoa(i)),
+		Headers: []sarama.RecordHeader{{Key: []byte("X-Seq"), Value: []byte(strconv.Itoa(i))}},
+	}
+}
+
+// TestCrashingProducer không phải test thật: TestDiskBufferCrashRecovery chạy lại binary test với
+// crashBufferEnv để process con ghi message vào buffer, báo "ready" rồi chờ bị kill.
+func TestCrashingProducer(t *testing.T) {
+	path := os.Getenv(crashBufferEnv)
+	if path == "" {
+		t.Skip("only runs as the child process of TestDiskBufferCrashRecovery")
+	}
+	buffer, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for i := 0; i < crashedMessages; i++ {
+		if err := buffer.Append(bufferedMessage(i)); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	// không Close: process bị kill trong lúc vẫn giữ file BoltDB
+	os.Stdout.WriteString("ready\n")
+	time.Sleep(time.Minute)
+}
+
+func TestDiskBufferCrashRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffer.db")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCrashingProducer$")
+	cmd.Env = append(os.Environ(), crashBufferEnv+"="+path)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("StdoutPipe() error = %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start child process: %v", err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || line != "ready\n" {
+		cmd.Process.Kill()
+		cmd.Wait()
+		t.Fatalf("child process output = %q, %v, want ready", line, err)
+	}
+	if err := cmd.Process.Signal(os.Kill); err != nil {
+		t.Fatalf("failed to kill child process: %v", err)
+	}
+	cmd.Wait()
+
+	// file vẫn là BoltDB hợp lệ sau khi process bị kill
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if err != nil {
+		t.Fatalf("bolt.Open() after crash error = %v", err)
+	}
+	if err := db.View(func(tx *bolt.Tx) error {
+		if n := tx.Bucket(bucketMessages).Stats().KeyN; n != crashedMessages {
+			t.Errorf("bucket has %d messages, want %d", n, crashedMessages)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+	db.Close()
+
+	buffer, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() after crash error = %v", err)
+	}
+	defer buffer.Close()
+	var flushed []*sarama.ProducerMessage
+	sent, err := buffer.Flush(func(msg *sarama.ProducerMessage) error {
+		flushed = append(flushed, msg)
+		return nil
+	})
+	if err != nil || sent != crashedMessages {
+		t.Fatalf("Flush() = %d, %v, want %d", sent, err, crashedMessages)
+	}
+	for i, msg := range flushed {
+		want := bufferedMessage(i)
+		key, _ := msg.Key.Encode()
+		value, _ := msg.Value.Encode()
+		if msg.Topic != want.Topic || string(key) != "user-"+strconv.Itoa(i) ||
+			string(value) != "message-"+strconv.Itoa(i) || string(msg.Headers[0].Value) != strconv.Itoa(i) {
+			t.Fatalf("flushed message %d = %s/%s/%s, want message %d in write order", i, msg.Topic, key, value, i)
+		}
+	}
+	if n, err := buffer.Len(); err != nil || n != 0 {
+		t.Fatalf("Len() after flush = %d, %v, want 0", n, err)
+	}
+}
+
+func TestDiskBufferFlushStopsAtFirstError(t *testing.T) {
+	buffer, err := Open(filepath.Join(t.TempDir(), "buffer.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer buffer.Close()
+	for i := 0; i < 3; i++ {
+		if err := buffer.Append(bufferedMessage(i)); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	errBroker := errors.New("broker down")
+	sent, err := buffer.Flush(func(msg *sarama.ProducerMessage) error {
+		if string(msg.Headers[0].Value) == "1" {
+			return errBroker
+		}
+		return nil
+	})
+	if !errors.Is(err, errBroker) || sent != 1 {
+		t.Fatalf("Flush() = %d, %v, want 1, %v", sent, err, errBroker)
+	}
+	// message lỗi và message sau nó vẫn còn để lần flush sau gửi đúng thứ tự
+	if n, err := buffer.Len(); err != nil || n != 2 {
+		t.Fatalf("Len() = %d, %v, want 2", n, err)
+	}
+}
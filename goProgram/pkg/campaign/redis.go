@@ -0,0 +1,105 @@
+package campaign
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore dùng sorted set campaigns:due (score là unix timestamp của ScheduledAt)
+// và hash campaigns:data (ID -> JSON). Campaign vẫn nằm trong campaigns:data sau khi chạy
+// để GET /campaigns/:id xem được kết quả.
+type RedisStore struct {
+	client *redis.Client
+}
+
+const (
+	dueKey  = "campaigns:due"
+	dataKey = "campaigns:data"
+)
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Create(ctx context.Context, c models.Campaign) error {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal campaign: %w", err)
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, dataKey, c.ID, payload)
+		pipe.ZAdd(ctx, dueKey, redis.Z{Score: float64(c.ScheduledAt.Unix()), Member: c.ID})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create campaign: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) (models.Campaign, error) {
+	payload, err := s.client.HGet(ctx, dataKey, id).Result()
+	if errors.Is(err, redis.Nil) {
+		return models.Campaign{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return models.Campaign{}, fmt.Errorf("failed to get campaign: %w", err)
+	}
+	var c models.Campaign
+	if err := json.Unmarshal([]byte(payload), &c); err != nil {
+		return models.Campaign{}, fmt.Errorf("failed to unmarshal campaign: %w", err)
+	}
+	return c, nil
+}
+
+// Due chỉ trả về các campaign mà ZREM của runner này xoá được,
+// runner khác đã lấy trước thì bỏ qua.
+func (s *RedisStore) Due(ctx context.Context, now time.Time, limit int) ([]models.Campaign, error) {
+	ids, err := s.client.ZRangeByScore(ctx, dueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due campaigns: %w", err)
+	}
+
+	var due []models.Campaign
+	for _, id := range ids {
+		removed, err := s.client.ZRem(ctx, dueKey, id).Result()
+		if err != nil {
+			return due, fmt.Errorf("failed to claim campaign %s: %w", id, err)
+		}
+		if removed == 0 {
+			continue
+		}
+		c, err := s.Get(ctx, id)
+		if err != nil {
+			return due, err
+		}
+		due = append(due, c)
+	}
+	return due, nil
+}
+
+func (s *RedisStore) Update(ctx context.Context, c models.Campaign) error {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal campaign: %w", err)
+	}
+	if err := s.client.HSet(ctx, dataKey, c.ID, payload).Err(); err != nil {
+		return fmt.Errorf("failed to update campaign %s: %w", c.ID, err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
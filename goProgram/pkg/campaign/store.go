@@ -0,0 +1,84 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("campaign not found")
+
+// CampaignStore lưu campaign và trạng thái của nó.
+// Due trả về campaign scheduled đã tới hạn và đánh dấu đã lấy, nên nhiều runner
+// chạy cùng lúc không gửi trùng một campaign.
+type CampaignStore interface {
+	Create(ctx context.Context, c models.Campaign) error
+	Get(ctx context.Context, id string) (models.Campaign, error)
+	Due(ctx context.Context, now time.Time, limit int) ([]models.Campaign, error)
+	// Update ghi đè campaign đã có, dùng để cập nhật Status và kết quả gửi
+	Update(ctx context.Context, c models.Campaign) error
+}
+
+// MemoryStore giữ campaign trong bộ nhớ của một instance producer.
+type MemoryStore struct {
+	campaigns map[string]models.Campaign
+	// due là ID các campaign chưa được runner lấy
+	due map[string]bool
+	mu  sync.Mutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{campaigns: make(map[string]models.Campaign), due: make(map[string]bool)}
+}
+
+func (s *MemoryStore) Create(_ context.Context, c models.Campaign) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.campaigns[c.ID] = c
+	s.due[c.ID] = true
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (models.Campaign, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.campaigns[id]
+	if !ok {
+		return models.Campaign{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return c, nil
+}
+
+// Due trả về campaign sớm nhất trước.
+func (s *MemoryStore) Due(_ context.Context, now time.Time, limit int) ([]models.Campaign, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []models.Campaign
+	for id := range s.due {
+		if c := s.campaigns[id]; !c.ScheduledAt.After(now) {
+			due = append(due, c)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ScheduledAt.Before(due[j].ScheduledAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for _, c := range due {
+		delete(s.due, c.ID)
+	}
+	return due, nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, c models.Campaign) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.campaigns[c.ID]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, c.ID)
+	}
+	s.campaigns[c.ID] = c
+	return nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"kafka-notify/pkg/rpc/pb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// correlationIDKey là metadata key mà grpc-producer đọc để nối log và trace giữa các service.
+const correlationIDKey = "x-correlation-id"
+
+// Client là helper gọi NotificationService qua gRPC.
+type Client struct {
+	conn *grpc.ClientConn
+	rpc  pb.NotificationServiceClient
+}
+
+// Dial kết nối tới grpc-producer, mặc định không dùng TLS;
+// truyền thêm grpc.DialOption (ví dụ credentials) để ghi đè.
+func Dial(addr string, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", addr, err)
+	}
+	return &Client{conn: conn, rpc: pb.NewNotificationServiceClient(conn)}, nil
+}
+
+// WithCorrelationID gắn correlation ID vào các lời gọi dùng ctx trả về.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, correlationIDKey, correlationID)
+}
+
+// Send gửi một notification, priority = 0 nghĩa là normal.
+func (c *Client) Send(ctx context.Context, fromID, toID int, message string, priority int) (*pb.SendResponse, error) {
+	return c.rpc.SendNotification(ctx, &pb.SendRequest{
+		FromId:   int64(fromID),
+		ToId:     int64(toID),
+		Message:  message,
+		Priority: int32(priority),
+	})
+}
+
+// SendBatch gửi nhiều notification trong một lời gọi, lỗi từng item nằm trong SendBatchResponse.Failed.
+func (c *Client) SendBatch(ctx context.Context, notifications ...*pb.SendRequest) (*pb.SendBatchResponse, error) {
+	return c.rpc.SendBatch(ctx, &pb.SendBatchRequest{Notifications: notifications})
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
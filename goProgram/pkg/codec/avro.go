@@ -0,0 +1,210 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// notificationSchema là Avro schema của models.Notification, các field thêm sau priority có default
+// để dữ liệu ghi bằng schema cũ (chưa có các field này) vẫn đọc được.
+const notificationSchema = `{
+  "type": "record",
+  "name": "Notification",
+  "namespace": "kafka_notify",
+  "fields": [
+    {"name": "from", "type": {"type": "record", "name": "User", "fields": [
+      {"name": "id", "type": "long"},
+      {"name": "name", "type": "string"},
+      {"name": "tenantID", "type": "string", "default": ""}
+    ]}},
+    {"name": "to", "type": "User"},
+    {"name": "message", "type": "string"},
+    {"name": "priority", "type": "int", "default": 2},
+    {"name": "id", "type": "string", "default": ""},
+    {"name": "createdAt", "type": "long", "default": 0},
+    {"name": "correlationID", "type": "string", "default": ""},
+    {"name": "type", "type": "string", "default": ""},
+    {"name": "threadID", "type": "string", "default": ""},
+    {"name": "replyToID", "type": "string", "default": ""}
+  ]
+}`
+
+// Confluent wire format: 1 byte magic (0) + 4 byte schema ID (big endian) + Avro binary.
+const (
+	wireMagicByte  = 0
+	wireHeaderSize = 5
+)
+
+var ErrInvalidAvroPayload = errors.New("invalid avro payload")
+
+// Các mức AVRO_COMPATIBILITY_MODE, CompatibilityNone bỏ qua kiểm tra
+const (
+	CompatibilityBackward = "BACKWARD"
+	CompatibilityForward  = "FORWARD"
+	CompatibilityFull     = "FULL"
+	CompatibilityNone     = "NONE"
+)
+
+// ErrSchemaIncompatible là lỗi khi schema mới không tương thích với version mới nhất của Subject
+// theo AVRO_COMPATIBILITY_MODE, ví dụ thêm field không có default làm consumer cũ không đọc được.
+type ErrSchemaIncompatible struct {
+	Subject       string
+	NewSchemaJSON string
+}
+
+func (e *ErrSchemaIncompatible) Error() string {
+	return "avro schema is not compatible with the latest version of subject " + e.Subject
+}
+
+func (e *ErrSchemaIncompatible) Is(target error) bool {
+	t, ok := target.(*ErrSchemaIncompatible)
+	return ok && (t.Subject == "" || t.Subject == e.Subject)
+}
+
+// AvroCodec encode notification theo Confluent wire format, schema được đăng ký
+// trên Schema Registry nên consumer chỉ cần schema ID trong payload để decode.
+type AvroCodec struct {
+	registry *schemaRegistryClient
+	schemaID int
+	codec    *goavro.Codec
+
+	mu sync.RWMutex
+	// byID cache schema theo ID để không phải gọi registry cho mỗi message
+	byID map[int]*goavro.Codec
+}
+
+// NewAvroCodec đăng ký schema của notification vào subject (thường là "<topic>-value").
+// Trước khi đăng ký, subject được đặt mức compatibility và schema được kiểm tra với version mới nhất,
+// schema không tương thích trả về *ErrSchemaIncompatible để service dừng ngay lúc khởi động.
+// compatibility là CompatibilityNone hoặc rỗng thì không kiểm tra và giữ cấu hình của registry.
+func NewAvroCodec(registryURL, subject, compatibility string) (*AvroCodec, error) {
+	avroCodec, err := goavro.NewCodec(notificationSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse avro schema: %w", err)
+	}
+	registry := newSchemaRegistryClient(registryURL)
+	if compatibility != "" && compatibility != CompatibilityNone {
+		if err := registry.setCompatibility(subject, compatibility); err != nil {
+			return nil, err
+		}
+		compatible, err := registry.checkCompatibility(subject, notificationSchema)
+		if err != nil {
+			return nil, err
+		}
+		if !compatible {
+			return nil, &ErrSchemaIncompatible{Subject: subject, NewSchemaJSON: notificationSchema}
+		}
+	}
+	schemaID, err := registry.register(subject, notificationSchema)
+	if err != nil {
+		return nil, err
+	}
+	return &AvroCodec{
+		registry: registry,
+		schemaID: schemaID,
+		codec:    avroCodec,
+		byID:     map[int]*goavro.Codec{schemaID: avroCodec},
+	}, nil
+}
+
+func (c *AvroCodec) Marshal(n models.Notification) ([]byte, error) {
+	header := make([]byte, wireHeaderSize)
+	header[0] = wireMagicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(c.schemaID))
+	return c.codec.BinaryFromNative(header, map[string]interface{}{
+		"from":          avroUserRecord(n.From),
+		"to":            avroUserRecord(n.To),
+		"message":       n.Message,
+		"priority":      int32(n.Priority),
+		"id":            n.ID,
+		"createdAt":     unixMilli(n.CreatedAt),
+		"correlationID": n.CorrelationID,
+		"type":          n.Type,
+		"threadID":      n.ThreadID,
+		"replyToID":     n.ReplyToID,
+	})
+}
+
+func (c *AvroCodec) Unmarshal(data []byte) (models.Notification, error) {
+	if len(data) < wireHeaderSize || data[0] != wireMagicByte {
+		return models.Notification{}, fmt.Errorf("%w: missing confluent wire header", ErrInvalidAvroPayload)
+	}
+	writerCodec, err := c.codecForID(int(binary.BigEndian.Uint32(data[1:wireHeaderSize])))
+	if err != nil {
+		return models.Notification{}, err
+	}
+	native, _, err := writerCodec.NativeFromBinary(data[wireHeaderSize:])
+	if err != nil {
+		return models.Notification{}, fmt.Errorf("%w: %v", ErrInvalidAvroPayload, err)
+	}
+	record, ok := native.(map[string]interface{})
+	if !ok {
+		return models.Notification{}, fmt.Errorf("%w: expected record", ErrInvalidAvroPayload)
+	}
+	return models.Notification{
+		ID:            stringField(record, "id"),
+		From:          avroUser(record["from"]),
+		To:            avroUser(record["to"]),
+		Message:       stringField(record, "message"),
+		Priority:      int(int32Field(record, "priority")),
+		CreatedAt:     fromUnixMilli(int64Field(record, "createdAt")),
+		CorrelationID: stringField(record, "correlationID"),
+		Type:          stringField(record, "type"),
+		ThreadID:      stringField(record, "threadID"),
+		ReplyToID:     stringField(record, "replyToID"),
+	}, nil
+}
+
+func (c *AvroCodec) ContentType() string { return ContentTypeAvro }
+
+func (c *AvroCodec) codecForID(id int) (*goavro.Codec, error) {
+	c.mu.RLock()
+	cached, ok := c.byID[id]
+	c.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	schema, err := c.registry.schemaByID(id)
+	if err != nil {
+		return nil, err
+	}
+	fetched, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse avro schema %d: %w", id, err)
+	}
+	c.mu.Lock()
+	c.byID[id] = fetched
+	c.mu.Unlock()
+	return fetched, nil
+}
+
+func avroUserRecord(user models.User) map[string]interface{} {
+	return map[string]interface{}{"id": int64(user.ID), "name": user.Name, "tenantID": user.TenantID}
+}
+
+func avroUser(value interface{}) models.User {
+	record, _ := value.(map[string]interface{})
+	id, _ := record["id"].(int64)
+	return models.User{ID: int(id), Name: stringField(record, "name"), TenantID: stringField(record, "tenantID")}
+}
+
+func stringField(record map[string]interface{}, name string) string {
+	value, _ := record[name].(string)
+	return value
+}
+
+func int32Field(record map[string]interface{}, name string) int32 {
+	value, _ := record[name].(int32)
+	return value
+}
+
+func int64Field(record map[string]interface{}, name string) int64 {
+	value, _ := record[name].(int64)
+	return value
+}
@@ -0,0 +1,189 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"kafka-notify/pkg/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testSubject = "notifications.normal-value"
+
+// mockRegistry là Schema Registry giả: compatible quyết định kết quả của /compatibility,
+// latestMissing giả lập subject chưa có version nào (404).
+type mockRegistry struct {
+	compatible    bool
+	latestMissing bool
+
+	mu            sync.Mutex
+	compatibility string
+	schemas       map[int]string
+	calls         []string
+}
+
+func newMockRegistry(t *testing.T, compatible bool) (*mockRegistry, *httptest.Server) {
+	t.Helper()
+	registry := &mockRegistry{compatible: compatible, schemas: map[int]string{}}
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+	return registry, server
+}
+
+func (r *mockRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, req.Method+" "+req.URL.Path)
+	w.Header().Set("Content-Type", schemaRegistryContentType)
+
+	if req.Method != http.MethodGet && req.Header.Get("Content-Type") != schemaRegistryContentType {
+		http.Error(w, `{"error_code":415}`, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	switch {
+	case req.Method == http.MethodPut && req.URL.Path == "/config/"+testSubject:
+		var payload configPayload
+		json.NewDecoder(req.Body).Decode(&payload)
+		r.compatibility = payload.Compatibility
+		json.NewEncoder(w).Encode(payload)
+	case req.Method == http.MethodPost && req.URL.Path == "/compatibility/subjects/"+testSubject+"/versions/latest":
+		if r.latestMissing {
+			http.Error(w, `{"error_code":40401}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(compatibilityPayload{IsCompatible: r.compatible})
+	case req.Method == http.MethodPost && req.URL.Path == "/subjects/"+testSubject+"/versions":
+		var payload schemaPayload
+		json.NewDecoder(req.Body).Decode(&payload)
+		// schema đã có thì registry trả lại ID cũ
+		for id, schema := range r.schemas {
+			if schema == payload.Schema {
+				json.NewEncoder(w).Encode(schemaIDPayload{ID: id})
+				return
+			}
+		}
+		id := len(r.schemas) + 1
+		r.schemas[id] = payload.Schema
+		json.NewEncoder(w).Encode(schemaIDPayload{ID: id})
+	case req.Method == http.MethodGet && strings.HasPrefix(req.URL.Path, "/schemas/ids/"):
+		id, _ := strconv.Atoi(strings.TrimPrefix(req.URL.Path, "/schemas/ids/"))
+		schema, ok := r.schemas[id]
+		if !ok {
+			http.Error(w, `{"error_code":40403}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(schemaPayload{Schema: schema})
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (r *mockRegistry) requests() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func (r *mockRegistry) registered() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.schemas)
+}
+
+func (r *mockRegistry) subjectCompatibility() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.compatibility
+}
+
+func TestNewAvroCodecCompatibilityCheck(t *testing.T) {
+	for _, mode := range []string{CompatibilityBackward, CompatibilityForward, CompatibilityFull} {
+		t.Run(mode, func(t *testing.T) {
+			registry, server := newMockRegistry(t, true)
+			avroCodec, err := NewAvroCodec(server.URL, testSubject, mode)
+			if err != nil {
+				t.Fatalf("NewAvroCodec() error = %v", err)
+			}
+			if got := registry.subjectCompatibility(); got != mode {
+				t.Fatalf("subject compatibility = %q, want %q", got, mode)
+			}
+			want := []string{
+				"PUT /config/" + testSubject,
+				"POST /compatibility/subjects/" + testSubject + "/versions/latest",
+				"POST /subjects/" + testSubject + "/versions",
+			}
+			if got := registry.requests(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+				t.Fatalf("registry calls = %v, want %v", got, want)
+			}
+
+			notification := models.Notification{
+				ID:       "5f0c7c3e-8f9a-4a57-9d51-3b8a2f8d9e01",
+				From:     models.User{ID: 1, Name: "Alice"},
+				To:       models.User{ID: 2, Name: "Bob"},
+				Message:  "hello",
+				Priority: models.PriorityNormal,
+			}
+			payload, err := avroCodec.Marshal(notification)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			got, err := avroCodec.Unmarshal(payload)
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got.ID != notification.ID || got.From != notification.From || got.To != notification.To || got.Message != notification.Message {
+				t.Fatalf("Unmarshal() = %+v, want %+v", got, notification)
+			}
+		})
+	}
+}
+
+func TestNewAvroCodecIncompatibleSchema(t *testing.T) {
+	registry, server := newMockRegistry(t, false)
+
+	_, err := NewAvroCodec(server.URL, testSubject, CompatibilityBackward)
+	if !errors.Is(err, &ErrSchemaIncompatible{Subject: testSubject}) {
+		t.Fatalf("NewAvroCodec() error = %v, want ErrSchemaIncompatible", err)
+	}
+	var incompatible *ErrSchemaIncompatible
+	if !errors.As(err, &incompatible) || incompatible.NewSchemaJSON != notificationSchema {
+		t.Fatalf("error = %#v, want NewSchemaJSON of the notification schema", err)
+	}
+	if errors.Is(err, &ErrSchemaIncompatible{Subject: "other-value"}) {
+		t.Fatal("error matched another subject")
+	}
+	// schema không tương thích thì không được đăng ký
+	if n := registry.registered(); n != 0 {
+		t.Fatalf("%d schemas registered, want 0", n)
+	}
+}
+
+func TestNewAvroCodecFirstVersion(t *testing.T) {
+	registry, server := newMockRegistry(t, false)
+	registry.latestMissing = true
+
+	if _, err := NewAvroCodec(server.URL, testSubject, CompatibilityFull); err != nil {
+		t.Fatalf("NewAvroCodec() error = %v, want subject without versions to be compatible", err)
+	}
+	if n := registry.registered(); n != 1 {
+		t.Fatalf("%d schemas registered, want 1", n)
+	}
+}
+
+func TestNewAvroCodecCompatibilityNone(t *testing.T) {
+	for _, mode := range []string{CompatibilityNone, ""} {
+		registry, server := newMockRegistry(t, false)
+		if _, err := NewAvroCodec(server.URL, testSubject, mode); err != nil {
+			t.Fatalf("NewAvroCodec(%q) error = %v", mode, err)
+		}
+		// không kiểm tra nên registry chỉ nhận request đăng ký
+		if got := registry.requests(); len(got) != 1 || got[0] != "POST /subjects/"+testSubject+"/versions" {
+			t.Fatalf("NewAvroCodec(%q) registry calls = %v, want only register", mode, got)
+		}
+	}
+}
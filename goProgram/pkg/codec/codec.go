@@ -0,0 +1,101 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"sync"
+)
+
+// HeaderContentType là Kafka header cho consumer biết message được encode bằng codec nào.
+const HeaderContentType = "Content-Type"
+
+const (
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+	EncodingAvro     = "avro"
+	EncodingMsgpack  = "msgpack"
+
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/protobuf"
+	ContentTypeAvro     = "application/vnd.confluent.avro"
+	ContentTypeMsgpack  = "application/msgpack"
+)
+
+var ErrUnknownCodec = errors.New("unknown codec")
+
+// registered chứa các codec cần khởi tạo lúc chạy (ví dụ Avro cần Schema Registry),
+// được thêm vào qua Register và tra cứu theo content type.
+var (
+	registeredMu sync.RWMutex
+	registered   = map[string]Codec{}
+)
+
+// Register cho phép New và ForContentType trả về codec c.
+func Register(c Codec) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	registered[c.ContentType()] = c
+}
+
+func lookup(contentType string) (Codec, bool) {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	c, ok := registered[contentType]
+	return c, ok
+}
+
+// SetupAvro tạo AvroCodec và đăng ký nó, gọi khi SCHEMA_REGISTRY_URL được set.
+// compatibility là AVRO_COMPATIBILITY_MODE, xem NewAvroCodec.
+func SetupAvro(registryURL, subject, compatibility string) error {
+	avroCodec, err := NewAvroCodec(registryURL, subject, compatibility)
+	if err != nil {
+		return err
+	}
+	Register(avroCodec)
+	return nil
+}
+
+// Codec encode/decode models.Notification thành payload của Kafka message.
+type Codec interface {
+	Marshal(n models.Notification) ([]byte, error)
+	Unmarshal(data []byte) (models.Notification, error)
+	ContentType() string
+}
+
+// New trả về codec theo giá trị của NOTIFICATION_ENCODING.
+func New(encoding string) (Codec, error) {
+	switch encoding {
+	case EncodingJSON:
+		return JSONCodec{}, nil
+	case EncodingProtobuf:
+		return ProtobufCodec{}, nil
+	case EncodingMsgpack:
+		return MsgpackCodec{}, nil
+	case EncodingAvro:
+		if c, ok := lookup(ContentTypeAvro); ok {
+			return c, nil
+		}
+		return nil, fmt.Errorf("%w: encoding %q requires SCHEMA_REGISTRY_URL", ErrUnknownCodec, encoding)
+	default:
+		return nil, fmt.Errorf("%w: encoding %q", ErrUnknownCodec, encoding)
+	}
+}
+
+// ForContentType chọn codec theo header Content-Type của message.
+// Message cũ không có header được coi là JSON.
+func ForContentType(contentType string) (Codec, error) {
+	switch contentType {
+	case "", ContentTypeJSON:
+		return JSONCodec{}, nil
+	case ContentTypeProtobuf:
+		return ProtobufCodec{}, nil
+	case ContentTypeMsgpack:
+		return MsgpackCodec{}, nil
+	default:
+		if c, ok := lookup(contentType); ok {
+			return c, nil
+		}
+		return nil, fmt.Errorf("%w: content type %q", ErrUnknownCodec, contentType)
+	}
+}
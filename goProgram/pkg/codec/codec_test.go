@@ -0,0 +1,108 @@
+package codec
+
+import (
+	"errors"
+	"kafka-notify/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchNotification có message 256 byte như một notification điển hình
+func benchNotification() models.Notification {
+	return models.Notification{
+		ID:            "6f1c2a9e-3b1d-4c55-9d0e-2a7b8c9d0e1f",
+		From:          models.User{ID: 1, Name: "Alice"},
+		To:            models.User{ID: 2, Name: "Bob"},
+		Message:       strings.Repeat("a", 256),
+		Priority:      models.PriorityNormal,
+		CreatedAt:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		CorrelationID: "c0ffee00-1234-4567-89ab-cdef01234567",
+		Type:          "chat",
+		ThreadID:      "thread-1",
+	}
+}
+
+func builtinCodecs() []Codec {
+	return []Codec{JSONCodec{}, ProtobufCodec{}, MsgpackCodec{}}
+}
+
+// mỗi codec giải mã được đúng payload của chính nó, chọn qua header Content-Type như consumer
+func TestCodecsRoundTrip(t *testing.T) {
+	want := benchNotification()
+	for _, c := range builtinCodecs() {
+		t.Run(c.ContentType(), func(t *testing.T) {
+			payload, err := c.Marshal(want)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			decoder, err := ForContentType(c.ContentType())
+			if err != nil {
+				t.Fatalf("ForContentType(%q) error = %v", c.ContentType(), err)
+			}
+			got, err := decoder.Unmarshal(payload)
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got.ID != want.ID || got.From != want.From || got.To != want.To || got.Message != want.Message ||
+				got.Priority != want.Priority || !got.CreatedAt.Equal(want.CreatedAt) ||
+				got.CorrelationID != want.CorrelationID || got.Type != want.Type || got.ThreadID != want.ThreadID {
+				t.Fatalf("round trip = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestNewAndForContentType(t *testing.T) {
+	for encoding, contentType := range map[string]string{
+		EncodingJSON:     ContentTypeJSON,
+		EncodingProtobuf: ContentTypeProtobuf,
+		EncodingMsgpack:  ContentTypeMsgpack,
+	} {
+		c, err := New(encoding)
+		if err != nil || c.ContentType() != contentType {
+			t.Fatalf("New(%q) = %v, %v, want %s", encoding, c, err, contentType)
+		}
+	}
+	// message cũ không có header Content-Type là JSON
+	if c, err := ForContentType(""); err != nil || c.ContentType() != ContentTypeJSON {
+		t.Fatalf("ForContentType(\"\") = %v, %v, want JSON", c, err)
+	}
+	if _, err := New("xml"); !errors.Is(err, ErrUnknownCodec) {
+		t.Fatalf("New(\"xml\") error = %v, want ErrUnknownCodec", err)
+	}
+	if _, err := ForContentType("application/xml"); !errors.Is(err, ErrUnknownCodec) {
+		t.Fatalf("ForContentType(\"application/xml\") error = %v, want ErrUnknownCodec", err)
+	}
+}
+
+// BenchmarkCodecs so sánh encode/decode notification có message 256 byte, metric bytes là kích thước payload.
+func BenchmarkCodecs(b *testing.B) {
+	n := benchNotification()
+	for _, c := range builtinCodecs() {
+		payload, err := c.Marshal(n)
+		if err != nil {
+			b.Fatalf("%s Marshal() error = %v", c.ContentType(), err)
+		}
+		name := strings.TrimPrefix(c.ContentType(), "application/")
+		b.Run(name+"/marshal", func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(payload)))
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Marshal(n); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(payload)), "bytes")
+		})
+		b.Run(name+"/unmarshal", func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(payload)))
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Unmarshal(payload); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package codec
+
+import (
+	"encoding/json"
+	"kafka-notify/pkg/models"
+)
+
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(n models.Notification) ([]byte, error) {
+	return json.Marshal(n)
+}
+
+func (JSONCodec) Unmarshal(data []byte) (models.Notification, error) {
+	var n models.Notification
+	err := json.Unmarshal(data, &n)
+	return n, err
+}
+
+func (JSONCodec) ContentType() string { return ContentTypeJSON }
@@ -0,0 +1,33 @@
+package codec
+
+import (
+	"bytes"
+	"kafka-notify/pkg/models"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgpackCodec encode notification bằng MessagePack, gọn và nhanh hơn JSON
+// nhưng vẫn không cần schema như protobuf/avro.
+// Dùng lại json tag của models nên tên field giống hệt payload JSON.
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Marshal(n models.Notification) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (MsgpackCodec) Unmarshal(data []byte) (models.Notification, error) {
+	var n models.Notification
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	err := dec.Decode(&n)
+	return n, err
+}
+
+func (MsgpackCodec) ContentType() string { return ContentTypeMsgpack }
@@ -0,0 +1,78 @@
+package codec
+
+//go:generate protoc --go_out=pb --go_opt=paths=source_relative -I pb notification.proto
+
+import (
+	"kafka-notify/pkg/codec/pb"
+	"kafka-notify/pkg/models"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtobufCodec encode notification theo pb/notification.proto,
+// payload nhỏ hơn JSON đáng kể vì không lặp lại tên field.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) Marshal(n models.Notification) ([]byte, error) {
+	return proto.Marshal(toProto(n))
+}
+
+func (ProtobufCodec) Unmarshal(data []byte) (models.Notification, error) {
+	var msg pb.Notification
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return models.Notification{}, err
+	}
+	return fromProto(&msg), nil
+}
+
+func (ProtobufCodec) ContentType() string { return ContentTypeProtobuf }
+
+func toProto(n models.Notification) *pb.Notification {
+	return &pb.Notification{
+		Id:            n.ID,
+		CreatedAt:     unixMilli(n.CreatedAt),
+		CorrelationId: n.CorrelationID,
+		From:          &pb.User{Id: int64(n.From.ID), Name: n.From.Name, TenantId: n.From.TenantID},
+		To:            &pb.User{Id: int64(n.To.ID), Name: n.To.Name, TenantId: n.To.TenantID},
+		Message:       n.Message,
+		Priority:      int32(n.Priority),
+		Type:          n.Type,
+		ThreadId:      n.ThreadID,
+		ReplyToId:     n.ReplyToID,
+	}
+}
+
+func fromProto(msg *pb.Notification) models.Notification {
+	return models.Notification{
+		ID:            msg.GetId(),
+		From:          userFromProto(msg.GetFrom()),
+		To:            userFromProto(msg.GetTo()),
+		Message:       msg.GetMessage(),
+		Priority:      int(msg.GetPriority()),
+		CreatedAt:     fromUnixMilli(msg.GetCreatedAt()),
+		CorrelationID: msg.GetCorrelationId(),
+		Type:          msg.GetType(),
+		ThreadID:      msg.GetThreadId(),
+		ReplyToID:     msg.GetReplyToId(),
+	}
+}
+
+func userFromProto(user *pb.User) models.User {
+	return models.User{ID: int(user.GetId()), Name: user.GetName(), TenantID: user.GetTenantId()}
+}
+
+// CreatedAt rỗng được encode thành 0 thay vì unix milli của năm 1
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func fromUnixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms).UTC()
+}
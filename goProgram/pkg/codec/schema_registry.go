@@ -0,0 +1,132 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+
+// schemaRegistryClient gọi REST API của Confluent Schema Registry.
+type schemaRegistryClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func newSchemaRegistryClient(registryURL string) *schemaRegistryClient {
+	return &schemaRegistryClient{
+		baseURL: strings.TrimSuffix(registryURL, "/"),
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type schemaPayload struct {
+	Schema string `json:"schema"`
+}
+
+type schemaIDPayload struct {
+	ID int `json:"id"`
+}
+
+type compatibilityPayload struct {
+	IsCompatible bool `json:"is_compatible"`
+}
+
+type configPayload struct {
+	Compatibility string `json:"compatibility"`
+}
+
+// registryStatusError là response khác 200 của registry
+type registryStatusError struct {
+	status string
+	code   int
+}
+
+func (e *registryStatusError) Error() string {
+	return "schema registry returned " + e.status
+}
+
+// setCompatibility đặt mức compatibility (BACKWARD, FORWARD, FULL...) của subject,
+// registry dùng mức này cho cả checkCompatibility lẫn register.
+func (c *schemaRegistryClient) setCompatibility(subject, mode string) error {
+	body, err := json.Marshal(configPayload{Compatibility: mode})
+	if err != nil {
+		return err
+	}
+	var result configPayload
+	endpoint := fmt.Sprintf("%s/config/%s", c.baseURL, url.PathEscape(subject))
+	if err := c.do(http.MethodPut, endpoint, body, &result); err != nil {
+		return fmt.Errorf("failed to set compatibility of subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+// checkCompatibility kiểm tra schema với version mới nhất của subject.
+// Subject chưa có version nào (registry trả 404) coi là tương thích.
+func (c *schemaRegistryClient) checkCompatibility(subject, schema string) (bool, error) {
+	body, err := json.Marshal(schemaPayload{Schema: schema})
+	if err != nil {
+		return false, err
+	}
+	var result compatibilityPayload
+	endpoint := fmt.Sprintf("%s/compatibility/subjects/%s/versions/latest", c.baseURL, url.PathEscape(subject))
+	err = c.do(http.MethodPost, endpoint, body, &result)
+	var statusErr *registryStatusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check schema compatibility for subject %s: %w", subject, err)
+	}
+	return result.IsCompatible, nil
+}
+
+// register đăng ký schema cho subject (trả về ID cũ nếu schema đã tồn tại).
+func (c *schemaRegistryClient) register(subject, schema string) (int, error) {
+	body, err := json.Marshal(schemaPayload{Schema: schema})
+	if err != nil {
+		return 0, err
+	}
+	var result schemaIDPayload
+	endpoint := fmt.Sprintf("%s/subjects/%s/versions", c.baseURL, url.PathEscape(subject))
+	if err := c.do(http.MethodPost, endpoint, body, &result); err != nil {
+		return 0, fmt.Errorf("failed to register schema for subject %s: %w", subject, err)
+	}
+	return result.ID, nil
+}
+
+// schemaByID lấy schema theo ID, dùng khi consumer gặp schema ID chưa có trong cache.
+func (c *schemaRegistryClient) schemaByID(id int) (string, error) {
+	var result schemaPayload
+	endpoint := fmt.Sprintf("%s/schemas/ids/%d", c.baseURL, id)
+	if err := c.do(http.MethodGet, endpoint, nil, &result); err != nil {
+		return "", fmt.Errorf("failed to fetch schema %d: %w", id, err)
+	}
+	return result.Schema, nil
+}
+
+func (c *schemaRegistryClient) do(method, endpoint string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", schemaRegistryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &registryStatusError{status: resp.Status, code: resp.StatusCode}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,137 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"kafka-notify/pkg/models"
+	"net/http"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// schemaV1 là version đầu của notification schema, chưa có các field thêm sau priority
+const schemaV1 = `{
+  "type": "record",
+  "name": "Notification",
+  "namespace": "kafka_notify",
+  "fields": [
+    {"name": "from", "type": {"type": "record", "name": "User", "fields": [
+      {"name": "id", "type": "long"},
+      {"name": "name", "type": "string"}
+    ]}},
+    {"name": "to", "type": "User"},
+    {"name": "message", "type": "string"},
+    {"name": "priority", "type": "int"}
+  ]
+}`
+
+func TestSchemaRegistryClientRegisterAndFetch(t *testing.T) {
+	registry, server := newMockRegistry(t, true)
+	client := newSchemaRegistryClient(server.URL + "/")
+
+	id, err := client.register(testSubject, schemaV1)
+	if err != nil {
+		t.Fatalf("register() error = %v", err)
+	}
+	again, err := client.register(testSubject, schemaV1)
+	if err != nil || again != id {
+		t.Fatalf("register() again = %d, %v, want the existing ID %d", again, err, id)
+	}
+	schema, err := client.schemaByID(id)
+	if err != nil || schema != schemaV1 {
+		t.Fatalf("schemaByID(%d) = %q, %v, want the registered schema", id, schema, err)
+	}
+
+	want := []string{
+		"POST /subjects/" + testSubject + "/versions",
+		"POST /subjects/" + testSubject + "/versions",
+		"GET /schemas/ids/1",
+	}
+	got := registry.requests()
+	if len(got) != len(want) {
+		t.Fatalf("registry calls = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("registry calls = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSchemaRegistryClientUnknownSchema(t *testing.T) {
+	_, server := newMockRegistry(t, true)
+
+	_, err := newSchemaRegistryClient(server.URL).schemaByID(42)
+	var statusErr *registryStatusError
+	if !errors.As(err, &statusErr) || statusErr.code != http.StatusNotFound {
+		t.Fatalf("schemaByID(42) error = %v, want registry 404", err)
+	}
+}
+
+// consumer đọc schema ID từ 5 byte đầu, schema chưa có trong cache được lấy từ /schemas/ids và giữ lại
+func TestAvroCodecDecodesOlderSchemaByID(t *testing.T) {
+	registry, server := newMockRegistry(t, true)
+	oldID, err := newSchemaRegistryClient(server.URL).register(testSubject, schemaV1)
+	if err != nil {
+		t.Fatalf("register() error = %v", err)
+	}
+	writer, err := goavro.NewCodec(schemaV1)
+	if err != nil {
+		t.Fatalf("failed to parse schema v1: %v", err)
+	}
+	header := make([]byte, wireHeaderSize)
+	binary.BigEndian.PutUint32(header[1:], uint32(oldID))
+	payload, err := writer.BinaryFromNative(header, map[string]interface{}{
+		"from":     map[string]interface{}{"id": int64(1), "name": "Alice"},
+		"to":       map[string]interface{}{"id": int64(2), "name": "Bob"},
+		"message":  "hello",
+		"priority": int32(models.PriorityHigh),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode with schema v1: %v", err)
+	}
+
+	avroCodec, err := NewAvroCodec(server.URL, testSubject, CompatibilityNone)
+	if err != nil {
+		t.Fatalf("NewAvroCodec() error = %v", err)
+	}
+	if avroCodec.schemaID == oldID {
+		t.Fatalf("current schema registered with the v1 ID %d", oldID)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := avroCodec.Unmarshal(payload)
+		if err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if got.From.Name != "Alice" || got.To.ID != 2 || got.Message != "hello" || got.Priority != models.PriorityHigh {
+			t.Fatalf("Unmarshal() = %+v", got)
+		}
+	}
+	var fetches int
+	for _, call := range registry.requests() {
+		if call == "GET /schemas/ids/1" {
+			fetches++
+		}
+	}
+	if fetches != 1 {
+		t.Fatalf("schema %d fetched %d times, want once", oldID, fetches)
+	}
+}
+
+func TestAvroCodecInvalidWireFormat(t *testing.T) {
+	_, server := newMockRegistry(t, true)
+	avroCodec, err := NewAvroCodec(server.URL, testSubject, CompatibilityNone)
+	if err != nil {
+		t.Fatalf("NewAvroCodec() error = %v", err)
+	}
+	for _, payload := range [][]byte{nil, {0, 0, 0}, {1, 0, 0, 0, 1, 2}} {
+		if _, err := avroCodec.Unmarshal(payload); !errors.Is(err, ErrInvalidAvroPayload) {
+			t.Fatalf("Unmarshal(%v) error = %v, want ErrInvalidAvroPayload", payload, err)
+		}
+	}
+	// schema ID không có trên registry
+	if _, err := avroCodec.Unmarshal([]byte{0, 0, 0, 0, 9, 2}); err == nil {
+		t.Fatal("Unmarshal() with an unknown schema ID succeeded")
+	}
+}
@@ -0,0 +1,659 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/tenant"
+	"math"
+	"net"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+)
+
+const (
+	defaultKafkaBroker     = "localhost:9092"
+	defaultKafkaTopic      = "notifications"
+	defaultProducerPort    = ":8080"
+	defaultConsumerPort    = ":8081"
+	defaultGRPCPort        = ":50051"
+	defaultReplayPort      = ":8082"
+	defaultConsumerGroupID = "notifications-group"
+	defaultLogLevel        = "info"
+)
+
+const (
+	ProducerModeSync  = "sync"
+	ProducerModeAsync = "async"
+
+	OffsetStrategyNewest = "newest"
+	OffsetStrategyOldest = "oldest"
+
+	RequiredAcksNoResponse   = "no_response"
+	RequiredAcksWaitForLocal = "wait_for_local"
+	RequiredAcksWaitForAll   = "wait_for_all"
+
+	OrderingBestEffort = "best-effort"
+	OrderingFIFO       = "fifo"
+
+	RateLimitBackendLocal = "local"
+	RateLimitBackendRedis = "redis"
+)
+
+// các giá trị hợp lệ của KAFKA_COMPRESSION
+var compressionCodecs = []string{"none", "gzip", "snappy", "lz4", "zstd"}
+
+// các giá trị hợp lệ của KAFKA_PRODUCER_REQUIRED_ACKS
+var requiredAcks = []string{RequiredAcksNoResponse, RequiredAcksWaitForLocal, RequiredAcksWaitForAll}
+
+// các giá trị hợp lệ của NOTIFICATION_ENCODING
+var notificationEncodings = []string{"json", "protobuf", "avro", "msgpack"}
+
+var keyStrategies = []string{"recipient", "sender", "thread", "random"}
+
+var avroCompatibilityModes = []string{"BACKWARD", "FORWARD", "FULL", "NONE"}
+
+var retryBackoffStrategies = []string{"fixed", "exponential", "jitter"}
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Các route không version được giữ làm alias của /v1 tới LegacyRoutesSunsetAt.
+var (
+	LegacyRoutesDeprecatedAt = time.Date(2026, time.October, 14, 0, 0, 0, 0, time.UTC)
+	LegacyRoutesSunsetAt     = time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Config chứa toàn bộ cấu hình đọc từ biến môi trường,
+// để chạy được trong container mà không cần build lại.
+type Config struct {
+	KafkaBrokers []string
+	KafkaTopic   string
+	// KafkaTopicPrefix là tiền tố chung của các topic theo priority (<prefix>.low, <prefix>.high, ...)
+	KafkaTopicPrefix string
+	ProducerPort     string
+	ConsumerPort     string
+	GRPCPort         string
+	ReplayPort       string
+	ConsumerGroupID  string
+	// ConsumerOffsetStrategy quyết định group mới bắt đầu đọc từ đâu: newest hoặc oldest
+	ConsumerOffsetStrategy string
+	LogLevel               string
+	LogFormat              string
+	// RequestLogLevel là level của HTTP request log: info chỉ log request line, debug log thêm body
+	// (đã che field nhạy cảm), disabled tắt hẳn
+	RequestLogLevel string
+	ProducerMode    string
+	// JWTSecret rỗng nghĩa là tắt xác thực JWT cho /send
+	JWTSecret string
+	// OAuth2IssuerURL khác rỗng thì /send nhận thêm access token client credentials của issuer này
+	// (JWT kiểm tra bằng JWKS, token opaque qua token introspection), OAuth2Audience là audience token phải có
+	OAuth2IssuerURL string
+	OAuth2Audience  string
+	// OAuth2ClientID và OAuth2ClientSecret là client của service, gửi bằng Basic auth khi gọi introspection
+	OAuth2ClientID     string
+	OAuth2ClientSecret string
+	// AdminAPIKey rỗng nghĩa là tắt các route /admin
+	AdminAPIKey string
+	// DatabaseURL rỗng nghĩa là dùng user store trong bộ nhớ
+	DatabaseURL string
+	// RedisURL rỗng nghĩa là consumer lưu notification trong bộ nhớ
+	RedisURL    string
+	Compression string
+	// IdempotentProducer bật producer idempotent của Kafka, chỉ dùng được với PRODUCER_MODE=sync
+	IdempotentProducer bool
+	// ProducerRequiredAcks là số broker phải xác nhận mỗi lần ghi (no_response|wait_for_local|wait_for_all),
+	// idempotent/transactional producer luôn dùng wait_for_all
+	ProducerRequiredAcks string
+	// MinISR > 0 cùng với wait_for_all thì producer không khởi động nếu topic có ít replica hơn MinISR
+	MinISR int
+	// KafkaMaxMessageBytes là kích thước payload tối đa producer gửi, nên bằng max.message.bytes của broker
+	KafkaMaxMessageBytes int
+	// KafkaCompactedTopic báo topic notification dùng cleanup.policy=compact: message không có key
+	// bị từ chối thay vì được gán key ngẫu nhiên
+	KafkaCompactedTopic bool
+	// KafkaKeyStrategy chọn key của message (recipient|sender|thread|random), mặc định thread:
+	// ThreadID nếu có, còn lại là ID người nhận
+	KafkaKeyStrategy string
+	// MaxRequestBodyBytes là kích thước body tối đa của một HTTP request, cũng là độ dài message tối đa của /send
+	MaxRequestBodyBytes int
+	// KafkaSendTimeout là thời gian tối đa một request /send chờ Kafka xác nhận
+	KafkaSendTimeout time.Duration
+	// KafkaConnectAttempts là số lần thử kết nối Kafka lúc khởi động trước khi bỏ cuộc,
+	// mỗi lần sau chờ lâu gấp đôi KafkaConnectBackoff (có jitter)
+	KafkaConnectAttempts int
+	KafkaConnectBackoff  time.Duration
+	// ProducerRetryBackoff* chọn thời gian producer chờ giữa các lần gửi lại message lỗi
+	// (KAFKA_PRODUCER_RETRY_BACKOFF_STRATEGY fixed|exponential|jitter, mặc định fixed 100ms như trước).
+	// exponential bắt đầu từ ProducerRetryBackoff, nhân ProducerRetryMultiplier mỗi lần, tối đa ProducerRetryMaxBackoff
+	ProducerRetryStrategy   string
+	ProducerRetryBackoff    time.Duration
+	ProducerRetryMaxBackoff time.Duration
+	ProducerRetryMultiplier float64
+	// ProducerFlush* là ngưỡng gom batch của producer (bytes, số message, chu kỳ),
+	// batch được gửi khi chạm ngưỡng bất kỳ. 0 là dùng mặc định của sarama (gửi ngay)
+	ProducerFlushBytes     int
+	ProducerFlushMessages  int
+	ProducerFlushFrequency time.Duration
+	// PartitionWatchInterval là chu kỳ producer kiểm tra số partition của các topic để
+	// UserPartitioner nhận partition mới khi topic được tăng partition, 0 là tắt
+	PartitionWatchInterval time.Duration
+	// TransactionalID khác rỗng thì /send/batch và /broadcast gửi trong một Kafka transaction
+	TransactionalID string
+	// NotificationEncoding là codec producer dùng để encode notification (json|protobuf|avro|msgpack)
+	NotificationEncoding string
+	// SchemaRegistryURL khác rỗng thì bật Avro codec, schema được đăng ký vào SchemaRegistrySubject
+	SchemaRegistryURL     string
+	SchemaRegistrySubject string
+	// AvroCompatibility là mức compatibility (BACKWARD|FORWARD|FULL|NONE) đặt cho subject và dùng để
+	// kiểm tra schema trước khi đăng ký, NONE là không kiểm tra
+	AvroCompatibility string
+	// DLQTopic nhận các message consumer không giải mã được
+	DLQTopic string
+	// ReceiptsTopic nhận read receipt consumer gửi sau khi giao notification,
+	// producer đọc topic này bằng group ReceiptsGroupID để phục vụ GET /receipts
+	ReceiptsTopic   string
+	ReceiptsGroupID string
+	// AcksTopic nhận ack (mark-as-read) từ POST /notifications/:id/ack,
+	// consumer đọc lại bằng group AcksGroupID để phục vụ GET /notifications?unread=true
+	AcksTopic   string
+	AcksGroupID string
+	// Tenants là ID các tenant được phép gửi qua header X-Tenant-ID, topic của tenant có dạng
+	// <tenantID>.<topic>. Rỗng nghĩa là chỉ có tenant mặc định (không gửi header)
+	Tenants []string
+	// BleveIndexPath là thư mục full-text index của GET /notifications/search,
+	// rỗng nghĩa là index chỉ nằm trong bộ nhớ và mất khi restart
+	BleveIndexPath string
+	// SlackWebhookURL khác rỗng thì consumer chuyển tiếp notification tới Slack incoming webhook
+	SlackWebhookURL string
+	// MessageSigningKey khác rỗng thì producer ký và consumer kiểm tra HMAC của mỗi message
+	MessageSigningKey string
+	// MaxRetryCount là số lần xử lý lại qua retry topic trước khi chuyển sang DLQ,
+	// RetryBackoffs là thời gian chờ trước mỗi lần retry
+	MaxRetryCount int
+	RetryBackoffs []time.Duration
+	// ConsumerWorkers là số goroutine xử lý song song message của mỗi partition, 1 là xử lý tuần tự
+	ConsumerWorkers int
+	// ConsumerPartitions khác rỗng thì consumer chỉ xử lý các partition này (CONSUMER_PARTITION_FILTER)
+	ConsumerPartitions []int32
+	// ConsumerMaxGoroutines là số message tối đa được xử lý cùng lúc trên một consumer (cộng mọi partition),
+	// mặc định gấp đôi số CPU
+	ConsumerMaxGoroutines int
+	// ConsumerCommitInterval/ConsumerCommitBatch: offset đã mark được commit mỗi interval
+	// hoặc khi đủ batch message, tuỳ điều kiện nào tới trước
+	ConsumerCommitInterval time.Duration
+	ConsumerCommitBatch    int
+	// SessionTimeout và HeartbeatInterval là session.timeout.ms và heartbeat.interval.ms của consumer group,
+	// mặc định giống sarama (10s và 3s)
+	SessionTimeout    time.Duration
+	HeartbeatInterval time.Duration
+
+	TLS  TLSConfig
+	SASL SASLConfig
+	CORS CORSConfig
+
+	// RateLimitRPS là số request /send mỗi giây cho một user, RateLimitBurst là số request dồn tối đa
+	RateLimitRPS   float64
+	RateLimitBurst int
+	// RateLimitBackend là local (token bucket trong từng pod) hoặc redis (quota chung mọi replica, cần REDIS_URL)
+	RateLimitBackend string
+	// MaxFanoutSize là số người nhận tối đa của một lần /broadcast
+	MaxFanoutSize int
+	// MaxGroupSize là số member tối đa của một group của /send/group
+	MaxGroupSize int
+	// IdempotencyWindow là thời gian giữ idempotency key để lọc request gửi lại
+	IdempotencyWindow time.Duration
+	// DedupTTL > 0 thì producer từ chối notification giống hệt đã gửi trong khoảng này (cần REDIS_URL)
+	DedupTTL time.Duration
+	// FeatureFlagsRedisKey khác rỗng thì feature flag đọc từ Redis hash này (cần REDIS_URL),
+	// rỗng thì đọc từ biến môi trường FEATURE_<FLAG>
+	FeatureFlagsRedisKey string
+	// NotificationOrdering là fifo thì producer ghim mỗi cặp (người gửi, người nhận) vào một partition
+	// qua Redis (cần REDIS_URL), best-effort chỉ hash key nên thứ tự có thể đổi khi tăng partition
+	NotificationOrdering string
+	// StickyPartitionTTL là thời gian giữ partition đã ghim của một cặp kể từ lần ghim
+	StickyPartitionTTL time.Duration
+	// BufferPath khác rỗng thì /send ghi notification vào file BoltDB này khi circuit breaker mở
+	// và gửi lại mỗi BufferFlushInterval khi Kafka hoạt động trở lại
+	BufferPath          string
+	BufferFlushInterval time.Duration
+	// KafkaTopicRouting map loại notification sang topic riêng (KAFKA_TOPIC_ROUTING, JSON),
+	// ví dụ {"system_alert":"alerts"}; loại không có trong map dùng topic theo priority
+	KafkaTopicRouting map[string]string
+	// TopicsConfigFile khác rỗng thì producer và consumer tạo các topic trong Topics lúc khởi động
+	TopicsConfigFile string
+	Topics           []TopicConfig
+	// AutoCreateTopics bật thì lúc khởi động tạo topic của service chưa có trên cluster (KAFKA_AUTO_CREATE_TOPICS)
+	// với AutoTopicPartitions partition, hoặc thêm partition nếu topic đang có ít hơn.
+	// Topic đã khai báo trong TOPICS_CONFIG_FILE không bị đụng tới.
+	AutoCreateTopics     bool
+	AutoTopicPartitions  int
+	AutoTopicReplication int
+	// ConfigFile khác rỗng thì rate limit và topic routing được đọc thêm từ file YAML (CONFIG_FILE),
+	// producer đọc lại file ngay khi file đổi, xem ConfigWatcher
+	ConfigFile string
+	// ExpirySweepInterval là chu kỳ consumer xoá notification đã hết hạn khỏi store (EXPIRY_SWEEP_INTERVAL), 0 là tắt
+	ExpirySweepInterval time.Duration
+	// NotificationDefaultTTL là TTL mặc định của notification, 0 nghĩa là không hết hạn
+	NotificationDefaultTTL time.Duration
+	// PagerDutyAPIKey khác rỗng thì consumer tạo incident trên PagerDuty khi tỉ lệ message xử lý lỗi
+	// trong AlertWindow vượt AlertErrorThreshold (PAGERDUTY_API_KEY là integration key của Events API v2)
+	PagerDutyAPIKey     string
+	PagerDutyServiceID  string
+	AlertWindow         time.Duration
+	AlertErrorThreshold float64
+}
+
+// TLSConfig cấu hình mã hoá kết nối tới Kafka.
+// CertFile/KeyFile là client certificate (mTLS), CAFile dùng để xác thực broker.
+type TLSConfig struct {
+	Enabled  bool
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+// SASLConfig cấu hình xác thực SASL, Mechanism là SCRAM-SHA-256, SCRAM-SHA-512 hoặc PLAIN.
+type SASLConfig struct {
+	Enabled   bool
+	Mechanism string
+	Username  string
+	Password  string
+}
+
+// CORSConfig cấu hình CORS cho trình duyệt gọi REST API và /stream.
+// AllowedOrigins rỗng (mặc định) là không cho origin nào, "*" phải được đặt rõ ràng.
+type CORSConfig struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	// MaxAge là thời gian trình duyệt được cache kết quả preflight
+	MaxAge time.Duration
+}
+
+// LoadConfig đọc biến môi trường, dùng giá trị mặc định khi không có và validate kết quả.
+func LoadConfig() (*Config, error) {
+	env := &envReader{}
+	cfg := &Config{
+		KafkaBrokers:           splitList(getEnv("KAFKA_BROKERS", getEnv("KAFKA_BROKER", defaultKafkaBroker))),
+		KafkaTopic:             getEnv("KAFKA_TOPIC", defaultKafkaTopic),
+		ProducerPort:           getEnv("PRODUCER_PORT", defaultProducerPort),
+		ConsumerPort:           getEnv("CONSUMER_PORT", defaultConsumerPort),
+		GRPCPort:               getEnv("GRPC_PORT", defaultGRPCPort),
+		ReplayPort:             getEnv("REPLAY_PORT", defaultReplayPort),
+		ConsumerGroupID:        getEnv("CONSUMER_GROUP_ID", defaultConsumerGroupID),
+		ConsumerOffsetStrategy: getEnv("KAFKA_CONSUMER_OFFSET_STRATEGY", OffsetStrategyNewest),
+		LogLevel:               getEnv("LOG_LEVEL", defaultLogLevel),
+		LogFormat:              getEnv("LOG_FORMAT", "json"),
+		RequestLogLevel:        getEnv("REQUEST_LOG_LEVEL", defaultLogLevel),
+		ProducerMode:           getEnv("PRODUCER_MODE", ProducerModeSync),
+		NotificationOrdering:   getEnv("NOTIFICATION_ORDERING", OrderingBestEffort),
+		JWTSecret:              os.Getenv("JWT_SECRET"),
+		OAuth2IssuerURL:        os.Getenv("OAUTH2_ISSUER_URL"),
+		OAuth2Audience:         os.Getenv("OAUTH2_AUDIENCE"),
+		OAuth2ClientID:         os.Getenv("OAUTH2_CLIENT_ID"),
+		OAuth2ClientSecret:     os.Getenv("OAUTH2_CLIENT_SECRET"),
+		AdminAPIKey:            os.Getenv("ADMIN_API_KEY"),
+		DatabaseURL:            os.Getenv("DATABASE_URL"),
+		BufferPath:             os.Getenv("BUFFER_PATH"),
+		RedisURL:               os.Getenv("REDIS_URL"),
+		FeatureFlagsRedisKey:   os.Getenv("FEATURE_FLAGS_REDIS_KEY"),
+		Compression:            getEnv("KAFKA_COMPRESSION", "none"),
+		KafkaKeyStrategy:       getEnv("KAFKA_KEY_STRATEGY", "thread"),
+		IdempotentProducer:     env.bool("KAFKA_IDEMPOTENT_PRODUCER", false),
+		ProducerRequiredAcks:   getEnv("KAFKA_PRODUCER_REQUIRED_ACKS", RequiredAcksWaitForLocal),
+		MinISR:                 env.int("KAFKA_MIN_ISR", 0),
+		TransactionalID:        os.Getenv("KAFKA_TRANSACTIONAL_ID"),
+		BleveIndexPath:         os.Getenv("BLEVE_INDEX_PATH"),
+		PagerDutyAPIKey:        os.Getenv("PAGERDUTY_API_KEY"),
+		PagerDutyServiceID:     getEnv("PAGERDUTY_SERVICE_ID", "kafka-notify-consumer"),
+		Tenants:                splitList(os.Getenv("TENANTS")),
+
+		NotificationEncoding:   getEnv("NOTIFICATION_ENCODING", "json"),
+		SchemaRegistryURL:      os.Getenv("SCHEMA_REGISTRY_URL"),
+		AvroCompatibility:      getEnv("AVRO_COMPATIBILITY_MODE", "BACKWARD"),
+		MessageSigningKey:      os.Getenv("KAFKA_MESSAGE_SIGNING_KEY"),
+		SlackWebhookURL:        os.Getenv("SLACK_WEBHOOK_URL"),
+		MaxRetryCount:          env.int("MAX_RETRY_COUNT", 3),
+		RetryBackoffs:          env.durations("RETRY_BACKOFFS", []time.Duration{time.Second, 10 * time.Second, time.Minute}),
+		ConsumerWorkers:        env.int("CONSUMER_WORKERS", 1),
+		ConsumerPartitions:     env.partitions("CONSUMER_PARTITION_FILTER"),
+		ConsumerMaxGoroutines:  env.int("CONSUMER_MAX_GOROUTINES", runtime.NumCPU()*2),
+		ConsumerCommitInterval: env.duration("CONSUMER_COMMIT_INTERVAL", time.Second),
+		ConsumerCommitBatch:    env.int("CONSUMER_COMMIT_BATCH", 100),
+		SessionTimeout:         env.duration("KAFKA_SESSION_TIMEOUT", 10*time.Second),
+		HeartbeatInterval:      env.duration("KAFKA_HEARTBEAT_INTERVAL", 3*time.Second),
+
+		TLS: TLSConfig{
+			Enabled:  env.bool("KAFKA_TLS_ENABLED", false),
+			CertFile: os.Getenv("KAFKA_TLS_CERT_FILE"),
+			KeyFile:  os.Getenv("KAFKA_TLS_KEY_FILE"),
+			CAFile:   os.Getenv("KAFKA_TLS_CA_FILE"),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: splitList(os.Getenv("CORS_ALLOWED_ORIGINS")),
+			AllowedMethods: splitList(getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE")),
+			MaxAge:         env.duration("CORS_MAX_AGE", 10*time.Minute),
+		},
+		SASL: SASLConfig{
+			Enabled:   env.bool("KAFKA_SASL_ENABLED", false),
+			Mechanism: getEnv("KAFKA_SASL_MECHANISM", "SCRAM-SHA-256"),
+			Username:  os.Getenv("KAFKA_SASL_USERNAME"),
+			Password:  os.Getenv("KAFKA_SASL_PASSWORD"),
+		},
+		RateLimitRPS:            env.float("RATE_LIMIT_RPS", 5),
+		RateLimitBurst:          env.int("RATE_LIMIT_BURST", 10),
+		RateLimitBackend:        getEnv("RATELIMIT_BACKEND", RateLimitBackendLocal),
+		MaxFanoutSize:           env.int("MAX_FANOUT_SIZE", 1000),
+		MaxGroupSize:            env.int("MAX_GROUP_SIZE", 500),
+		IdempotencyWindow:       env.duration("IDEMPOTENCY_WINDOW", 5*time.Minute),
+		KafkaSendTimeout:        env.duration("KAFKA_SEND_TIMEOUT", 5*time.Second),
+		KafkaConnectAttempts:    env.int("KAFKA_CONNECT_MAX_ATTEMPTS", 5),
+		KafkaConnectBackoff:     env.duration("KAFKA_CONNECT_BACKOFF", time.Second),
+		ProducerRetryStrategy:   getEnv("KAFKA_PRODUCER_RETRY_BACKOFF_STRATEGY", "fixed"),
+		ProducerRetryBackoff:    env.duration("KAFKA_PRODUCER_RETRY_BACKOFF", 100*time.Millisecond),
+		ProducerRetryMaxBackoff: env.duration("KAFKA_PRODUCER_RETRY_MAX_BACKOFF", 5*time.Second),
+		ProducerRetryMultiplier: env.float("KAFKA_PRODUCER_RETRY_BACKOFF_MULTIPLIER", 2),
+		KafkaMaxMessageBytes:    env.int("KAFKA_MAX_MESSAGE_BYTES", 1048576),
+		MaxRequestBodyBytes:     env.int("MAX_REQUEST_BODY_BYTES", 65536),
+		KafkaCompactedTopic:     env.bool("KAFKA_COMPACTED_TOPIC", false),
+		ProducerFlushBytes:      env.int("KAFKA_PRODUCER_FLUSH_BYTES", 0),
+		ProducerFlushMessages:   env.int("KAFKA_PRODUCER_FLUSH_MESSAGES", 0),
+		ProducerFlushFrequency:  env.duration("KAFKA_PRODUCER_FLUSH_FREQUENCY", 0),
+		PartitionWatchInterval:  env.duration("PARTITION_WATCH_INTERVAL", 30*time.Second),
+		DedupTTL:                env.duration("DEDUP_TTL", 0),
+		BufferFlushInterval:     env.duration("BUFFER_FLUSH_INTERVAL", 5*time.Second),
+		StickyPartitionTTL:      env.duration("STICKY_PARTITION_TTL", 24*time.Hour),
+		NotificationDefaultTTL:  env.duration("NOTIFICATION_DEFAULT_TTL", 0),
+		KafkaTopicRouting:       env.stringMap("KAFKA_TOPIC_ROUTING"),
+		AutoCreateTopics:        env.bool("KAFKA_AUTO_CREATE_TOPICS", false),
+		ExpirySweepInterval:     env.duration("EXPIRY_SWEEP_INTERVAL", time.Minute),
+		AutoTopicPartitions:     env.int("KAFKA_TOPIC_PARTITIONS", 3),
+		AutoTopicReplication:    env.int("KAFKA_TOPIC_REPLICATION_FACTOR", 1),
+		AlertWindow:             env.duration("ALERT_WINDOW", time.Minute),
+		AlertErrorThreshold:     env.float("ALERT_ERROR_THRESHOLD", 0.1),
+	}
+	if env.err != nil {
+		return nil, env.err
+	}
+
+	cfg.KafkaTopicPrefix = getEnv("KAFKA_TOPIC_PREFIX", cfg.KafkaTopic)
+	cfg.SchemaRegistrySubject = getEnv("SCHEMA_REGISTRY_SUBJECT", cfg.KafkaTopic+"-value")
+	cfg.DLQTopic = getEnv("DLQ_TOPIC", cfg.KafkaTopic+".dlq")
+	cfg.ReceiptsTopic = getEnv("RECEIPTS_TOPIC", cfg.KafkaTopic+".receipts")
+	cfg.ReceiptsGroupID = getEnv("RECEIPTS_GROUP_ID", cfg.ConsumerGroupID+".receipts")
+	cfg.AcksTopic = getEnv("ACKS_TOPIC", cfg.KafkaTopic+".acks")
+	cfg.AcksGroupID = getEnv("ACKS_GROUP_ID", cfg.ConsumerGroupID+".acks")
+
+	cfg.TopicsConfigFile = os.Getenv("TOPICS_CONFIG_FILE")
+	if cfg.TopicsConfigFile != "" {
+		topics, err := LoadTopics(cfg.TopicsConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Topics = topics
+	}
+	cfg.ConfigFile = os.Getenv("CONFIG_FILE")
+	if cfg.ConfigFile != "" {
+		if err := cfg.applyConfigFile(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	active.Store(cfg)
+	return cfg, nil
+}
+
+func (cfg *Config) validate() error {
+	if len(cfg.KafkaBrokers) == 0 {
+		return fmt.Errorf("%w: KAFKA_BROKERS must contain at least one broker", ErrInvalidConfig)
+	}
+	for _, broker := range cfg.KafkaBrokers {
+		if err := validateHostPort(broker); err != nil {
+			return fmt.Errorf("%w: KAFKA_BROKERS: %v", ErrInvalidConfig, err)
+		}
+	}
+	if cfg.KafkaTopic == "" {
+		return fmt.Errorf("%w: KAFKA_TOPIC must not be empty", ErrInvalidConfig)
+	}
+	if cfg.ConsumerGroupID == "" {
+		return fmt.Errorf("%w: CONSUMER_GROUP_ID must not be empty", ErrInvalidConfig)
+	}
+	if cfg.ProducerMode != ProducerModeSync && cfg.ProducerMode != ProducerModeAsync {
+		return fmt.Errorf("%w: PRODUCER_MODE must be %q or %q, got %q",
+			ErrInvalidConfig, ProducerModeSync, ProducerModeAsync, cfg.ProducerMode)
+	}
+	if cfg.ConsumerOffsetStrategy != OffsetStrategyNewest && cfg.ConsumerOffsetStrategy != OffsetStrategyOldest {
+		return fmt.Errorf("%w: KAFKA_CONSUMER_OFFSET_STRATEGY must be %q or %q, got %q",
+			ErrInvalidConfig, OffsetStrategyNewest, OffsetStrategyOldest, cfg.ConsumerOffsetStrategy)
+	}
+	if !contains(compressionCodecs, cfg.Compression) {
+		return fmt.Errorf("%w: KAFKA_COMPRESSION must be one of %v, got %q",
+			ErrInvalidConfig, compressionCodecs, cfg.Compression)
+	}
+	if !contains(requiredAcks, cfg.ProducerRequiredAcks) {
+		return fmt.Errorf("%w: KAFKA_PRODUCER_REQUIRED_ACKS must be one of %v, got %q",
+			ErrInvalidConfig, requiredAcks, cfg.ProducerRequiredAcks)
+	}
+	if cfg.MinISR < 0 {
+		return fmt.Errorf("%w: KAFKA_MIN_ISR must not be negative", ErrInvalidConfig)
+	}
+	if !contains(keyStrategies, cfg.KafkaKeyStrategy) {
+		return fmt.Errorf("%w: KAFKA_KEY_STRATEGY must be one of %v, got %q",
+			ErrInvalidConfig, keyStrategies, cfg.KafkaKeyStrategy)
+	}
+	if !contains(notificationEncodings, cfg.NotificationEncoding) {
+		return fmt.Errorf("%w: NOTIFICATION_ENCODING must be one of %v, got %q",
+			ErrInvalidConfig, notificationEncodings, cfg.NotificationEncoding)
+	}
+	if !contains(avroCompatibilityModes, cfg.AvroCompatibility) {
+		return fmt.Errorf("%w: AVRO_COMPATIBILITY_MODE must be one of %v, got %q",
+			ErrInvalidConfig, avroCompatibilityModes, cfg.AvroCompatibility)
+	}
+	if cfg.NotificationEncoding == "avro" && cfg.SchemaRegistryURL == "" {
+		return fmt.Errorf("%w: NOTIFICATION_ENCODING=avro requires SCHEMA_REGISTRY_URL", ErrInvalidConfig)
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		return fmt.Errorf("%w: KAFKA_TLS_CERT_FILE and KAFKA_TLS_KEY_FILE must be set together", ErrInvalidConfig)
+	}
+	if cfg.SASL.Enabled && (cfg.SASL.Username == "" || cfg.SASL.Password == "") {
+		return fmt.Errorf("%w: KAFKA_SASL_USERNAME and KAFKA_SASL_PASSWORD are required when SASL is enabled", ErrInvalidConfig)
+	}
+	if cfg.OAuth2IssuerURL != "" && cfg.OAuth2Audience == "" {
+		return fmt.Errorf("%w: OAUTH2_AUDIENCE is required when OAUTH2_ISSUER_URL is set", ErrInvalidConfig)
+	}
+	if (cfg.OAuth2ClientID == "") != (cfg.OAuth2ClientSecret == "") {
+		return fmt.Errorf("%w: OAUTH2_CLIENT_ID and OAUTH2_CLIENT_SECRET must be set together", ErrInvalidConfig)
+	}
+	if cfg.RateLimitRPS <= 0 || cfg.RateLimitBurst <= 0 {
+		return fmt.Errorf("%w: RATE_LIMIT_RPS and RATE_LIMIT_BURST must be positive", ErrInvalidConfig)
+	}
+	if cfg.MaxRetryCount < 0 {
+		return fmt.Errorf("%w: MAX_RETRY_COUNT must not be negative", ErrInvalidConfig)
+	}
+	if cfg.ConsumerWorkers <= 0 {
+		return fmt.Errorf("%w: CONSUMER_WORKERS must be positive", ErrInvalidConfig)
+	}
+	for _, partition := range cfg.ConsumerPartitions {
+		if partition < 0 {
+			return fmt.Errorf("%w: CONSUMER_PARTITION_FILTER must not contain negative partitions", ErrInvalidConfig)
+		}
+	}
+	if cfg.ConsumerMaxGoroutines <= 0 {
+		return fmt.Errorf("%w: CONSUMER_MAX_GOROUTINES must be positive", ErrInvalidConfig)
+	}
+	if cfg.ConsumerCommitInterval <= 0 || cfg.ConsumerCommitBatch <= 0 {
+		return fmt.Errorf("%w: CONSUMER_COMMIT_INTERVAL and CONSUMER_COMMIT_BATCH must be positive", ErrInvalidConfig)
+	}
+	if cfg.ExpirySweepInterval < 0 {
+		return fmt.Errorf("%w: EXPIRY_SWEEP_INTERVAL must not be negative", ErrInvalidConfig)
+	}
+	if cfg.PagerDutyAPIKey != "" {
+		if cfg.AlertWindow < time.Second {
+			return fmt.Errorf("%w: ALERT_WINDOW must be at least 1s", ErrInvalidConfig)
+		}
+		if cfg.AlertErrorThreshold <= 0 || cfg.AlertErrorThreshold >= 1 {
+			return fmt.Errorf("%w: ALERT_ERROR_THRESHOLD must be between 0 and 1", ErrInvalidConfig)
+		}
+	}
+	if cfg.NotificationDefaultTTL < 0 {
+		return fmt.Errorf("%w: NOTIFICATION_DEFAULT_TTL must not be negative", ErrInvalidConfig)
+	}
+	if cfg.AutoCreateTopics {
+		if cfg.AutoTopicPartitions <= 0 || cfg.AutoTopicPartitions > math.MaxInt32 {
+			return fmt.Errorf("%w: KAFKA_TOPIC_PARTITIONS must be between 1 and %d", ErrInvalidConfig, math.MaxInt32)
+		}
+		if cfg.AutoTopicReplication <= 0 || cfg.AutoTopicReplication > math.MaxInt16 {
+			return fmt.Errorf("%w: KAFKA_TOPIC_REPLICATION_FACTOR must be between 1 and %d", ErrInvalidConfig, math.MaxInt16)
+		}
+	}
+	if cfg.MaxRequestBodyBytes <= 0 {
+		return fmt.Errorf("%w: MAX_REQUEST_BODY_BYTES must be positive", ErrInvalidConfig)
+	}
+	if cfg.KafkaMaxMessageBytes <= 0 {
+		return fmt.Errorf("%w: KAFKA_MAX_MESSAGE_BYTES must be positive", ErrInvalidConfig)
+	}
+	if cfg.KafkaSendTimeout <= 0 {
+		return fmt.Errorf("%w: KAFKA_SEND_TIMEOUT must be positive", ErrInvalidConfig)
+	}
+	if cfg.KafkaConnectAttempts <= 0 {
+		return fmt.Errorf("%w: KAFKA_CONNECT_MAX_ATTEMPTS must be positive", ErrInvalidConfig)
+	}
+	if cfg.KafkaConnectBackoff <= 0 {
+		return fmt.Errorf("%w: KAFKA_CONNECT_BACKOFF must be positive", ErrInvalidConfig)
+	}
+	if !contains(retryBackoffStrategies, cfg.ProducerRetryStrategy) {
+		return fmt.Errorf("%w: KAFKA_PRODUCER_RETRY_BACKOFF_STRATEGY must be one of %v, got %q",
+			ErrInvalidConfig, retryBackoffStrategies, cfg.ProducerRetryStrategy)
+	}
+	if cfg.ProducerRetryBackoff <= 0 || cfg.ProducerRetryMaxBackoff < cfg.ProducerRetryBackoff {
+		return fmt.Errorf("%w: KAFKA_PRODUCER_RETRY_BACKOFF must be positive and at most KAFKA_PRODUCER_RETRY_MAX_BACKOFF", ErrInvalidConfig)
+	}
+	if cfg.ProducerRetryMultiplier < 1 {
+		return fmt.Errorf("%w: KAFKA_PRODUCER_RETRY_BACKOFF_MULTIPLIER must be at least 1", ErrInvalidConfig)
+	}
+	if cfg.ProducerFlushBytes < 0 || cfg.ProducerFlushMessages < 0 {
+		return fmt.Errorf("%w: KAFKA_PRODUCER_FLUSH_BYTES and KAFKA_PRODUCER_FLUSH_MESSAGES must not be negative", ErrInvalidConfig)
+	}
+	if cfg.ProducerFlushFrequency < 0 {
+		return fmt.Errorf("%w: KAFKA_PRODUCER_FLUSH_FREQUENCY must not be negative", ErrInvalidConfig)
+	}
+	if cfg.PartitionWatchInterval < 0 {
+		return fmt.Errorf("%w: PARTITION_WATCH_INTERVAL must not be negative", ErrInvalidConfig)
+	}
+	if cfg.DedupTTL < 0 {
+		return fmt.Errorf("%w: DEDUP_TTL must not be negative", ErrInvalidConfig)
+	}
+	if cfg.DedupTTL > 0 && cfg.RedisURL == "" {
+		return fmt.Errorf("%w: DEDUP_TTL requires REDIS_URL", ErrInvalidConfig)
+	}
+	if cfg.RateLimitBackend != RateLimitBackendLocal && cfg.RateLimitBackend != RateLimitBackendRedis {
+		return fmt.Errorf("%w: RATELIMIT_BACKEND must be %q or %q, got %q",
+			ErrInvalidConfig, RateLimitBackendLocal, RateLimitBackendRedis, cfg.RateLimitBackend)
+	}
+	if cfg.RateLimitBackend == RateLimitBackendRedis && cfg.RedisURL == "" {
+		return fmt.Errorf("%w: RATELIMIT_BACKEND=redis requires REDIS_URL", ErrInvalidConfig)
+	}
+	if cfg.FeatureFlagsRedisKey != "" && cfg.RedisURL == "" {
+		return fmt.Errorf("%w: FEATURE_FLAGS_REDIS_KEY requires REDIS_URL", ErrInvalidConfig)
+	}
+	if cfg.NotificationOrdering != OrderingBestEffort && cfg.NotificationOrdering != OrderingFIFO {
+		return fmt.Errorf("%w: NOTIFICATION_ORDERING must be %q or %q, got %q",
+			ErrInvalidConfig, OrderingBestEffort, OrderingFIFO, cfg.NotificationOrdering)
+	}
+	if cfg.NotificationOrdering == OrderingFIFO && cfg.RedisURL == "" {
+		return fmt.Errorf("%w: NOTIFICATION_ORDERING=%s requires REDIS_URL", ErrInvalidConfig, OrderingFIFO)
+	}
+	if cfg.StickyPartitionTTL <= 0 {
+		return fmt.Errorf("%w: STICKY_PARTITION_TTL must be positive", ErrInvalidConfig)
+	}
+	if len(cfg.CORS.AllowedOrigins) > 0 && len(cfg.CORS.AllowedMethods) == 0 {
+		return fmt.Errorf("%w: CORS_ALLOWED_METHODS must not be empty when CORS_ALLOWED_ORIGINS is set", ErrInvalidConfig)
+	}
+	if cfg.CORS.MaxAge < 0 {
+		return fmt.Errorf("%w: CORS_MAX_AGE must not be negative", ErrInvalidConfig)
+	}
+	if cfg.BufferFlushInterval <= 0 {
+		return fmt.Errorf("%w: BUFFER_FLUSH_INTERVAL must be positive", ErrInvalidConfig)
+	}
+	if cfg.IdempotencyWindow <= 0 {
+		return fmt.Errorf("%w: IDEMPOTENCY_WINDOW must be positive", ErrInvalidConfig)
+	}
+	if cfg.MaxFanoutSize <= 0 {
+		return fmt.Errorf("%w: MAX_FANOUT_SIZE must be positive", ErrInvalidConfig)
+	}
+	if cfg.MaxGroupSize <= 0 {
+		return fmt.Errorf("%w: MAX_GROUP_SIZE must be positive", ErrInvalidConfig)
+	}
+	for notificationType, topic := range cfg.KafkaTopicRouting {
+		if notificationType == "" || topic == "" {
+			return fmt.Errorf("%w: KAFKA_TOPIC_ROUTING must not contain empty types or topics", ErrInvalidConfig)
+		}
+	}
+	for _, tenantID := range cfg.Tenants {
+		if err := models.ValidateTenantID(tenantID); err != nil {
+			return fmt.Errorf("%w: TENANTS: %v", ErrInvalidConfig, err)
+		}
+	}
+	if cfg.TopicsConfigFile != "" {
+		return cfg.validateTopics()
+	}
+	return nil
+}
+
+// PriorityTopic trả về topic dành cho priority, ví dụ "notifications.critical".
+func PriorityTopic(prefix string, priority int) string {
+	return prefix + "." + models.PriorityName(priority)
+}
+
+// PriorityTopics trả về tất cả topic theo priority mà consumer cần subscribe.
+func (cfg *Config) PriorityTopics() []string {
+	priorities := models.Priorities()
+	topics := make([]string, 0, len(priorities))
+	for _, priority := range priorities {
+		topics = append(topics, PriorityTopic(cfg.KafkaTopicPrefix, priority))
+	}
+	return topics
+}
+
+// ConsumerTopics là PriorityTopics cộng thêm các topic trong KAFKA_TOPIC_ROUTING,
+// của tenant mặc định và của từng tenant trong TENANTS.
+func (cfg *Config) ConsumerTopics() []string {
+	topics := cfg.PriorityTopics()
+	var routed []string
+	for _, topic := range cfg.KafkaTopicRouting {
+		if !contains(topics, topic) && !contains(routed, topic) {
+			routed = append(routed, topic)
+		}
+	}
+	sort.Strings(routed)
+	return tenant.Topics(cfg.Tenants, append(topics, routed...))
+}
+
+// IsTenant trả về true nếu tenantID là tenant mặc định (rỗng) hoặc có trong TENANTS.
+func (cfg *Config) IsTenant(tenantID string) bool {
+	return tenantID == "" || contains(cfg.Tenants, tenantID)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func validateHostPort(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if host == "" || port == "" {
+		return fmt.Errorf("%q must be in host:port form", address)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfigPartitionFilter(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []int32
+		wantErr bool
+	}{
+		{value: "", want: nil},
+		{value: "0,1,2", want: []int32{0, 1, 2}},
+		{value: " 3, 4 ,5 ", want: []int32{3, 4, 5}},
+		{value: "7", want: []int32{7}},
+		{value: "0,x", wantErr: true},
+		{value: "-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("CONSUMER_PARTITION_FILTER", tt.value)
+			cfg, err := LoadConfig()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidConfig) {
+					t.Fatalf("LoadConfig() error = %v, want %v", err, ErrInvalidConfig)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg.ConsumerPartitions, tt.want) {
+				t.Fatalf("ConsumerPartitions = %v, want %v", cfg.ConsumerPartitions, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigAvroCompatibility(t *testing.T) {
+	for _, mode := range []string{"BACKWARD", "FORWARD", "FULL", "NONE"} {
+		t.Setenv("AVRO_COMPATIBILITY_MODE", mode)
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig() with %s error = %v", mode, err)
+		}
+		if cfg.AvroCompatibility != mode {
+			t.Fatalf("AvroCompatibility = %q, want %q", cfg.AvroCompatibility, mode)
+		}
+	}
+
+	t.Setenv("AVRO_COMPATIBILITY_MODE", "backward_transitive")
+	if _, err := LoadConfig(); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("LoadConfig() error = %v, want %v", err, ErrInvalidConfig)
+	}
+}
+
+func TestLoadConfigTopicRouting(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "unset", value: "", want: nil},
+		{name: "routes", value: `{"system_alert":"notifications.alerts","chat_message":"notifications.chat"}`,
+			want: map[string]string{"system_alert": "notifications.alerts", "chat_message": "notifications.chat"}},
+		{name: "invalid json", value: `system_alert=notifications.alerts`, wantErr: true},
+		{name: "empty topic", value: `{"system_alert":""}`, wantErr: true},
+		{name: "empty type", value: `{"":"notifications.alerts"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KAFKA_TOPIC_ROUTING", tt.value)
+			cfg, err := LoadConfig()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidConfig) {
+					t.Fatalf("LoadConfig() error = %v, want %v", err, ErrInvalidConfig)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg.KafkaTopicRouting, tt.want) {
+				t.Fatalf("KafkaTopicRouting = %v, want %v", cfg.KafkaTopicRouting, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getEnv trả về fallback khi biến môi trường không được set hoặc rỗng.
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// splitList tách chuỗi dạng "a,b,c" thành slice, bỏ qua phần tử rỗng.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envReader đọc biến môi trường có kiểu và giữ lại lỗi parse đầu tiên,
+// để LoadConfig chỉ cần kiểm tra err một lần sau khi đọc hết.
+type envReader struct {
+	err error
+}
+
+func (r *envReader) fail(key, value string, err error) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %s=%q: %v", ErrInvalidConfig, key, value, err)
+	}
+}
+
+func (r *envReader) bool(key string, fallback bool) bool {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		r.fail(key, value, err)
+		return fallback
+	}
+	return parsed
+}
+
+func (r *envReader) int(key string, fallback int) int {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		r.fail(key, value, err)
+		return fallback
+	}
+	return parsed
+}
+
+func (r *envReader) float(key string, fallback float64) float64 {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		r.fail(key, value, err)
+		return fallback
+	}
+	return parsed
+}
+
+func (r *envReader) duration(key string, fallback time.Duration) time.Duration {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		r.fail(key, value, err)
+		return fallback
+	}
+	return parsed
+}
+
+// stringMap đọc object JSON dạng {"key":"value"}.
+func (r *envReader) stringMap(key string) map[string]string {
+	value := getEnv(key, "")
+	if value == "" {
+		return nil
+	}
+	var parsed map[string]string
+	if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+		r.fail(key, value, err)
+		return nil
+	}
+	return parsed
+}
+
+// partitions đọc danh sách partition ID dạng "0,1,2".
+func (r *envReader) partitions(key string) []int32 {
+	value := getEnv(key, "")
+	var parsed []int32
+	for _, item := range splitList(value) {
+		partition, err := strconv.ParseInt(item, 10, 32)
+		if err != nil {
+			r.fail(key, value, err)
+			return nil
+		}
+		parsed = append(parsed, int32(partition))
+	}
+	return parsed
+}
+
+// durations đọc danh sách duration dạng "1s,10s,1m".
+func (r *envReader) durations(key string, fallback []time.Duration) []time.Duration {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	var parsed []time.Duration
+	for _, item := range splitList(value) {
+		d, err := time.ParseDuration(item)
+		if err != nil {
+			r.fail(key, value, err)
+			return fallback
+		}
+		parsed = append(parsed, d)
+	}
+	return parsed
+}
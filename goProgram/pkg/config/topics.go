@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"kafka-notify/pkg/models"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TopicConfig mô tả một topic trong TOPICS_CONFIG_FILE, được tạo lúc khởi động nếu chưa tồn tại.
+type TopicConfig struct {
+	Name              string `yaml:"name"`
+	Partitions        int32  `yaml:"partitions"`
+	ReplicationFactor int16  `yaml:"replicationFactor"`
+	// Retention là retention.ms của topic (ví dụ 168h), 0 là dùng mặc định của broker
+	Retention time.Duration `yaml:"retention"`
+}
+
+// LoadTopics đọc danh sách topic từ file YAML (xem config/topics.yaml).
+func LoadTopics(path string) ([]TopicConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read topics config: %w", err)
+	}
+	var topics []TopicConfig
+	if err := yaml.Unmarshal(data, &topics); err != nil {
+		return nil, fmt.Errorf("failed to parse topics config %s: %w", path, err)
+	}
+	return topics, nil
+}
+
+// validateTopics yêu cầu topic mặc định (priority normal, nơi /send gửi khi không chỉ định priority)
+// luôn có trong file để service không chạy với topic được broker tự tạo theo cấu hình mặc định.
+func (cfg *Config) validateTopics() error {
+	seen := make(map[string]bool, len(cfg.Topics))
+	for _, topic := range cfg.Topics {
+		if topic.Name == "" {
+			return fmt.Errorf("%w: TOPICS_CONFIG_FILE: topic name is required", ErrInvalidConfig)
+		}
+		if seen[topic.Name] {
+			return fmt.Errorf("%w: TOPICS_CONFIG_FILE: duplicate topic %q", ErrInvalidConfig, topic.Name)
+		}
+		seen[topic.Name] = true
+		if topic.Partitions <= 0 || topic.ReplicationFactor <= 0 {
+			return fmt.Errorf("%w: TOPICS_CONFIG_FILE: %s: partitions and replicationFactor must be positive",
+				ErrInvalidConfig, topic.Name)
+		}
+		if topic.Retention < 0 {
+			return fmt.Errorf("%w: TOPICS_CONFIG_FILE: %s: retention must not be negative", ErrInvalidConfig, topic.Name)
+		}
+	}
+	if defaultTopic := PriorityTopic(cfg.KafkaTopicPrefix, models.PriorityNormal); !seen[defaultTopic] {
+		return fmt.Errorf("%w: TOPICS_CONFIG_FILE must contain the default topic %q", ErrInvalidConfig, defaultTopic)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigRequiresDefaultTopic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topics.yaml")
+	content := "- name: notifications.high\n  partitions: 3\n  replicationFactor: 1\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write topics file: %v", err)
+	}
+	t.Setenv("TOPICS_CONFIG_FILE", path)
+
+	if _, err := LoadConfig(); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("LoadConfig() error = %v, want %v", err, ErrInvalidConfig)
+	}
+}
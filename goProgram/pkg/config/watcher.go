@@ -0,0 +1,142 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// ReloadableConfig là các giá trị trong CONFIG_FILE (YAML) đổi được khi service đang chạy.
+// Field không có trong file giữ giá trị từ biến môi trường.
+type ReloadableConfig struct {
+	RateLimitRPS   *float64          `yaml:"rateLimitRPS"`
+	RateLimitBurst *int              `yaml:"rateLimitBurst"`
+	TopicRouting   map[string]string `yaml:"topicRouting"`
+}
+
+// active là config đã validate gần nhất, LoadConfig thành công thì cập nhật
+var active atomic.Pointer[Config]
+
+// Active trả về config đang dùng: lần LoadConfig thành công gần nhất, kể cả khi được ConfigWatcher gọi lại.
+// Code cần giá trị reload được phải gọi Active() ở mỗi request thay vì giữ config lúc khởi động.
+// Trả về nil nếu LoadConfig chưa chạy thành công lần nào.
+func Active() *Config {
+	return active.Load()
+}
+
+// applyConfigFile ghi đè các giá trị của CONFIG_FILE lên cfg.
+func (cfg *Config) applyConfigFile() error {
+	data, err := os.ReadFile(cfg.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var reloadable ReloadableConfig
+	if err := yaml.Unmarshal(data, &reloadable); err != nil {
+		return fmt.Errorf("%w: CONFIG_FILE %s: %v", ErrInvalidConfig, cfg.ConfigFile, err)
+	}
+	if reloadable.RateLimitRPS != nil {
+		cfg.RateLimitRPS = *reloadable.RateLimitRPS
+	}
+	if reloadable.RateLimitBurst != nil {
+		cfg.RateLimitBurst = *reloadable.RateLimitBurst
+	}
+	if reloadable.TopicRouting != nil {
+		cfg.KafkaTopicRouting = reloadable.TopicRouting
+	}
+	return nil
+}
+
+// reloadDebounce là thời gian chờ sau event cuối cùng trước khi đọc lại file, một lần ghi file
+// thường sinh nhiều event (truncate, write, chmod...) nên gom lại thành một lần reload.
+const reloadDebounce = 50 * time.Millisecond
+
+// ConfigWatcher theo dõi thư mục chứa CONFIG_FILE bằng fsnotify, khi nội dung file đổi thì chạy lại LoadConfig
+// và cập nhật Active(). Theo dõi thư mục thay vì file để vẫn thấy file được thay bằng rename
+// (ghi file mới rồi rename đè lên) hay symlink của ConfigMap Kubernetes được đổi.
+// File sai hoặc config không hợp lệ thì giữ config cũ và báo qua OnError.
+type ConfigWatcher struct {
+	path    string
+	last    []byte
+	watcher *fsnotify.Watcher
+	// OnReload được gọi với config mới sau mỗi lần reload thành công, nil là bỏ qua
+	OnReload func(cfg *Config)
+	// OnError được gọi khi fsnotify báo lỗi, đọc file hoặc validate thất bại, nil là bỏ qua
+	OnError func(err error)
+}
+
+// NewConfigWatcher bắt đầu theo dõi cfg.ConfigFile ngay, nội dung hiện tại coi như đã được áp dụng.
+// Event tới trước khi Run chạy vẫn được xử lý khi Run bắt đầu.
+func NewConfigWatcher(cfg *Config) (*ConfigWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch config file: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(cfg.ConfigFile)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch config file: %w", err)
+	}
+	last, _ := os.ReadFile(cfg.ConfigFile)
+	return &ConfigWatcher{path: cfg.ConfigFile, last: last, watcher: watcher}, nil
+}
+
+// Run xử lý event của file tới khi ctx bị huỷ rồi dừng theo dõi.
+func (w *ConfigWatcher) Run(ctx context.Context) {
+	defer w.watcher.Close()
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// mọi event trong thư mục đều có thể là file bị thay, reload chỉ chạy khi nội dung thật sự đổi
+			debounce.Reset(reloadDebounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.report(fmt.Errorf("failed to watch config file: %w", err))
+		case <-debounce.C:
+			w.report(w.reload())
+		}
+	}
+}
+
+func (w *ConfigWatcher) report(err error) {
+	if err != nil && w.OnError != nil {
+		w.OnError(err)
+	}
+}
+
+func (w *ConfigWatcher) reload() error {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	// file rỗng thường là lúc đang bị ghi đè (đã truncate, chưa ghi xong), chờ event tiếp theo
+	if len(data) == 0 || bytes.Equal(data, w.last) {
+		return nil
+	}
+	// ghi nhận nội dung trước khi load để file lỗi chỉ báo một lần tới khi được sửa
+	w.last = data
+	cfg, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+	if w.OnReload != nil {
+		w.OnReload(cfg)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// reloadDeadline là thời gian tối đa từ lúc ghi file tới khi Active() có config mới
+const reloadDeadline = 100 * time.Millisecond
+
+func startWatcher(t *testing.T, content string) (string, <-chan *Config, <-chan error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	if _, err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	watcher, err := NewConfigWatcher(Active())
+	if err != nil {
+		t.Fatalf("NewConfigWatcher() error = %v", err)
+	}
+	reloaded := make(chan *Config, 1)
+	failed := make(chan error, 1)
+	watcher.OnReload = func(cfg *Config) { reloaded <- cfg }
+	watcher.OnError = func(err error) { failed <- err }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watcher.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return path, reloaded, failed
+}
+
+func waitReload(t *testing.T, reloaded <-chan *Config, failed <-chan error, written time.Time) *Config {
+	t.Helper()
+	select {
+	case cfg := <-reloaded:
+		if elapsed := time.Since(written); elapsed > reloadDeadline {
+			t.Fatalf("config reloaded after %v, want within %v", elapsed, reloadDeadline)
+		}
+		return cfg
+	case err := <-failed:
+		t.Fatalf("reload failed: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("config was not reloaded")
+	}
+	return nil
+}
+
+func TestConfigWatcherReloadsRewrittenFile(t *testing.T) {
+	path, reloaded, failed := startWatcher(t, "rateLimitRPS: 10\n")
+	if got := Active().RateLimitRPS; got != 10 {
+		t.Fatalf("RateLimitRPS = %v, want 10", got)
+	}
+
+	written := time.Now()
+	if err := os.WriteFile(path, []byte("rateLimitRPS: 20\nrateLimitBurst: 40\n"), 0o644); err != nil {
+		t.Fatalf("failed to rewrite config file: %v", err)
+	}
+	cfg := waitReload(t, reloaded, failed, written)
+	if cfg.RateLimitRPS != 20 || cfg.RateLimitBurst != 40 {
+		t.Fatalf("reloaded config = %v/%v, want 20/40", cfg.RateLimitRPS, cfg.RateLimitBurst)
+	}
+	if Active() != cfg {
+		t.Fatal("Active() does not return the reloaded config")
+	}
+}
+
+func TestConfigWatcherReloadsRenamedFile(t *testing.T) {
+	path, reloaded, failed := startWatcher(t, "topicRouting:\n  email: notifications.email\n")
+
+	// ghi file mới rồi rename đè lên như cách deploy nên làm
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte("topicRouting:\n  email: notifications.email.v2\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	written := time.Now()
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("failed to replace config file: %v", err)
+	}
+	cfg := waitReload(t, reloaded, failed, written)
+	if got := cfg.KafkaTopicRouting["email"]; got != "notifications.email.v2" {
+		t.Fatalf("topic routing for email = %q, want notifications.email.v2", got)
+	}
+}
+
+func TestConfigWatcherKeepsConfigOnInvalidFile(t *testing.T) {
+	path, reloaded, failed := startWatcher(t, "rateLimitRPS: 10\n")
+	previous := Active()
+
+	if err := os.WriteFile(path, []byte("rateLimitRPS: -1\n"), 0o644); err != nil {
+		t.Fatalf("failed to rewrite config file: %v", err)
+	}
+	select {
+	case <-failed:
+	case cfg := <-reloaded:
+		t.Fatalf("invalid config was applied: %+v", cfg.RateLimitRPS)
+	case <-time.After(time.Second):
+		t.Fatal("invalid config was not reported")
+	}
+	if Active() != previous {
+		t.Fatal("Active() changed after an invalid reload")
+	}
+}
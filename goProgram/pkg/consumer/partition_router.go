@@ -0,0 +1,46 @@
+package consumer
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// PartitionRouter quyết định consumer có xử lý một partition được gán hay không (CONSUMER_PARTITION_FILTER),
+// dùng khi chạy blue-green: v1 xử lý partition 0-2, v2 xử lý 3-5 mà không chồng lấn.
+// Hai phiên bản dùng chung CONSUMER_GROUP_ID, PartitionFilterStrategy chỉ gán cho mỗi member
+// các partition thuộc filter của nó, nên partition của phiên bản này không bị gán cho phiên bản kia.
+type PartitionRouter struct {
+	partitions map[int32]bool
+}
+
+// NewPartitionRouter tạo router chỉ xử lý partitions, danh sách rỗng nghĩa là xử lý mọi partition.
+func NewPartitionRouter(partitions []int32) *PartitionRouter {
+	router := &PartitionRouter{partitions: make(map[int32]bool, len(partitions))}
+	for _, partition := range partitions {
+		router.partitions[partition] = true
+	}
+	return router
+}
+
+// ShouldProcess trả về true nếu claim của partition phải được xử lý, router nil xử lý mọi partition.
+func (r *PartitionRouter) ShouldProcess(partition int32) bool {
+	if r == nil || len(r.partitions) == 0 {
+		return true
+	}
+	return r.partitions[partition]
+}
+
+// UserData là filter gửi trong JoinGroup (sarama Consumer.Group.Member.UserData) để leader của group
+// chia partition bằng PartitionFilterStrategy, nil khi router xử lý mọi partition.
+func (r *PartitionRouter) UserData() []byte {
+	if r == nil || len(r.partitions) == 0 {
+		return nil
+	}
+	partitions := make([]int32, 0, len(r.partitions))
+	for partition := range r.partitions {
+		partitions = append(partitions, partition)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+	data, _ := json.Marshal(partitions)
+	return data
+}
@@ -0,0 +1,46 @@
+package consumer
+
+import "testing"
+
+func TestPartitionRouterShouldProcess(t *testing.T) {
+	const partitions = 6
+	tests := []struct {
+		name   string
+		router *PartitionRouter
+		want   []int32
+	}{
+		{name: "nil router", router: nil, want: []int32{0, 1, 2, 3, 4, 5}},
+		{name: "empty filter", router: NewPartitionRouter(nil), want: []int32{0, 1, 2, 3, 4, 5}},
+		{name: "blue 0-2", router: NewPartitionRouter([]int32{0, 1, 2}), want: []int32{0, 1, 2}},
+		{name: "green 3-5", router: NewPartitionRouter([]int32{3, 4, 5}), want: []int32{3, 4, 5}},
+		{name: "single partition", router: NewPartitionRouter([]int32{4}), want: []int32{4}},
+		{name: "non-contiguous", router: NewPartitionRouter([]int32{5, 0, 3}), want: []int32{0, 3, 5}},
+		{name: "duplicates", router: NewPartitionRouter([]int32{1, 1, 2}), want: []int32{1, 2}},
+		// partition chưa tồn tại (topic chưa tăng partition) không làm router xử lý partition khác
+		{name: "partition outside the topic", router: NewPartitionRouter([]int32{9}), want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := make(map[int32]bool, len(tt.want))
+			for _, partition := range tt.want {
+				want[partition] = true
+			}
+			for partition := int32(0); partition < partitions; partition++ {
+				if got := tt.router.ShouldProcess(partition); got != want[partition] {
+					t.Errorf("ShouldProcess(%d) = %v, want %v", partition, got, want[partition])
+				}
+			}
+		})
+	}
+}
+
+// v1 và v2 chạy cùng lúc phải chia đủ mọi partition mà không partition nào được xử lý hai lần
+func TestPartitionRouterBlueGreenNoOverlap(t *testing.T) {
+	blue, green := NewPartitionRouter([]int32{0, 1, 2}), NewPartitionRouter([]int32{3, 4, 5})
+	for partition := int32(0); partition < 6; partition++ {
+		if blue.ShouldProcess(partition) == green.ShouldProcess(partition) {
+			t.Errorf("partition %d: blue = %v, green = %v, want exactly one", partition,
+				blue.ShouldProcess(partition), green.ShouldProcess(partition))
+		}
+	}
+}
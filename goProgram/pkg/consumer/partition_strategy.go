@@ -0,0 +1,98 @@
+package consumer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/IBM/sarama"
+)
+
+// PartitionFilterStrategyName là tên protocol của PartitionFilterStrategy khi join consumer group.
+const PartitionFilterStrategyName = "partition-filter"
+
+// PartitionFilterStrategy là sarama.BalanceStrategy chia partition theo CONSUMER_PARTITION_FILTER của từng member,
+// nên blue và green chạy chung một CONSUMER_GROUP_ID (chung offset) mà không chồng lấn: partition chỉ được gán
+// cho member có filter chứa nó hoặc member không đặt filter, member nhận ít partition nhất được ưu tiên.
+// Filter của member đi trong UserData của JoinGroup (PartitionRouter.UserData), leader của group dùng nó để chia.
+type PartitionFilterStrategy struct{}
+
+func NewPartitionFilterStrategy() sarama.BalanceStrategy {
+	return PartitionFilterStrategy{}
+}
+
+func (PartitionFilterStrategy) Name() string { return PartitionFilterStrategyName }
+
+// Plan gán mỗi partition cho một member được xử lý nó, partition không member nào xử lý được bỏ trống
+// tới khi có member mới join (ví dụ phiên bản còn lại của blue-green).
+func (PartitionFilterStrategy) Plan(members map[string]sarama.ConsumerGroupMemberMetadata,
+	topics map[string][]int32) (sarama.BalanceStrategyPlan, error) {
+	memberIDs := make([]string, 0, len(members))
+	routers := make(map[string]*PartitionRouter, len(members))
+	for memberID, meta := range members {
+		router, err := decodePartitionRouter(meta.UserData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid partition filter of member %s: %w", memberID, err)
+		}
+		routers[memberID] = router
+		memberIDs = append(memberIDs, memberID)
+	}
+	// thứ tự cố định để mọi leader cho cùng một kết quả
+	sort.Strings(memberIDs)
+	topicNames := make([]string, 0, len(topics))
+	for topic := range topics {
+		topicNames = append(topicNames, topic)
+	}
+	sort.Strings(topicNames)
+
+	plan := make(sarama.BalanceStrategyPlan, len(members))
+	assigned := make(map[string]int, len(members))
+	for _, topic := range topicNames {
+		partitions := append([]int32(nil), topics[topic]...)
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+		for _, partition := range partitions {
+			owner := ""
+			for _, memberID := range memberIDs {
+				if !subscribed(members[memberID], topic) || !routers[memberID].ShouldProcess(partition) {
+					continue
+				}
+				if owner == "" || assigned[memberID] < assigned[owner] {
+					owner = memberID
+				}
+			}
+			if owner == "" {
+				continue
+			}
+			plan.Add(owner, topic, partition)
+			assigned[owner]++
+		}
+	}
+	return plan, nil
+}
+
+// AssignmentData không cần dữ liệu thêm trong assignment.
+func (PartitionFilterStrategy) AssignmentData(string, map[string][]int32, int32) ([]byte, error) {
+	return nil, nil
+}
+
+func subscribed(meta sarama.ConsumerGroupMemberMetadata, topic string) bool {
+	for _, t := range meta.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// decodePartitionRouter đọc UserData do PartitionRouter.UserData tạo, rỗng là member xử lý mọi partition
+// (ví dụ member không đặt CONSUMER_PARTITION_FILTER).
+func decodePartitionRouter(userData []byte) (*PartitionRouter, error) {
+	if len(userData) == 0 {
+		return NewPartitionRouter(nil), nil
+	}
+	var partitions []int32
+	if err := json.Unmarshal(userData, &partitions); err != nil {
+		return nil, err
+	}
+	return NewPartitionRouter(partitions), nil
+}
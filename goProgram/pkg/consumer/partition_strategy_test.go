@@ -0,0 +1,109 @@
+package consumer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func member(topics []string, partitions ...int32) sarama.ConsumerGroupMemberMetadata {
+	return sarama.ConsumerGroupMemberMetadata{Topics: topics, UserData: NewPartitionRouter(partitions).UserData()}
+}
+
+func TestPartitionFilterStrategyPlan(t *testing.T) {
+	topics := []string{"notifications.normal"}
+	sixPartitions := map[string][]int32{"notifications.normal": {5, 4, 3, 2, 1, 0}}
+	tests := []struct {
+		name    string
+		members map[string]sarama.ConsumerGroupMemberMetadata
+		want    sarama.BalanceStrategyPlan
+	}{
+		{
+			name: "blue-green",
+			members: map[string]sarama.ConsumerGroupMemberMetadata{
+				"blue": member(topics, 0, 1, 2), "green": member(topics, 3, 4, 5),
+			},
+			want: sarama.BalanceStrategyPlan{
+				"blue":  {"notifications.normal": {0, 1, 2}},
+				"green": {"notifications.normal": {3, 4, 5}},
+			},
+		},
+		{
+			name: "two replicas of each version",
+			members: map[string]sarama.ConsumerGroupMemberMetadata{
+				"blue-1": member(topics, 0, 1, 2), "blue-2": member(topics, 0, 1, 2),
+				"green-1": member(topics, 3, 4, 5), "green-2": member(topics, 3, 4, 5),
+			},
+			want: sarama.BalanceStrategyPlan{
+				"blue-1":  {"notifications.normal": {0, 2}},
+				"blue-2":  {"notifications.normal": {1}},
+				"green-1": {"notifications.normal": {3, 5}},
+				"green-2": {"notifications.normal": {4}},
+			},
+		},
+		{
+			name: "member without filter takes the rest",
+			members: map[string]sarama.ConsumerGroupMemberMetadata{
+				"blue": member(topics, 0, 1), "all": member(topics),
+			},
+			want: sarama.BalanceStrategyPlan{
+				"all":  {"notifications.normal": {0, 2, 3, 4, 5}},
+				"blue": {"notifications.normal": {1}},
+			},
+		},
+		{
+			// chỉ còn blue (green chưa lên) thì partition 3-5 chờ green, không gán cho blue
+			name:    "partitions outside every filter stay unassigned",
+			members: map[string]sarama.ConsumerGroupMemberMetadata{"blue": member(topics, 0, 1, 2)},
+			want:    sarama.BalanceStrategyPlan{"blue": {"notifications.normal": {0, 1, 2}}},
+		},
+		{
+			name: "member not subscribed to the topic",
+			members: map[string]sarama.ConsumerGroupMemberMetadata{
+				"blue": member(topics, 0, 1, 2), "retry": member([]string{"notifications.retry.1"}),
+			},
+			want: sarama.BalanceStrategyPlan{"blue": {"notifications.normal": {0, 1, 2}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := NewPartitionFilterStrategy().Plan(tt.members, sixPartitions)
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			if !reflect.DeepEqual(plan, tt.want) {
+				t.Fatalf("Plan() = %v, want %v", plan, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartitionFilterStrategyInvalidUserData(t *testing.T) {
+	members := map[string]sarama.ConsumerGroupMemberMetadata{
+		"broken": {Topics: []string{"notifications.normal"}, UserData: []byte("0,1")},
+	}
+	if _, err := NewPartitionFilterStrategy().Plan(members, map[string][]int32{"notifications.normal": {0}}); err == nil {
+		t.Fatal("Plan() error = nil, want invalid partition filter")
+	}
+}
+
+func TestPartitionRouterUserData(t *testing.T) {
+	if data := NewPartitionRouter(nil).UserData(); data != nil {
+		t.Fatalf("UserData() without filter = %q, want nil", data)
+	}
+	data := NewPartitionRouter([]int32{5, 0, 3, 3}).UserData()
+	if string(data) != "[0,3,5]" {
+		t.Fatalf("UserData() = %q, want [0,3,5]", data)
+	}
+	router, err := decodePartitionRouter(data)
+	if err != nil {
+		t.Fatalf("decodePartitionRouter() error = %v", err)
+	}
+	for partition := int32(0); partition < 6; partition++ {
+		want := partition == 0 || partition == 3 || partition == 5
+		if router.ShouldProcess(partition) != want {
+			t.Errorf("decoded ShouldProcess(%d) = %v, want %v", partition, !want, want)
+		}
+	}
+}
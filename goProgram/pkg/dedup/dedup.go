@@ -0,0 +1,76 @@
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrDuplicateMessage là lỗi khi notification giống hệt đã được gửi trong cửa sổ TTL.
+var ErrDuplicateMessage = errors.New("duplicate message")
+
+const keyPrefix = "dedup:"
+
+// DeduplicationCache nhớ (topic, key, hash của notification) -> thời điểm gửi trong Redis,
+// dùng SET NX nên nhiều request đồng thời chỉ có đúng một request được gửi.
+type DeduplicationCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewDeduplicationCache(client *redis.Client, ttl time.Duration) *DeduplicationCache {
+	return &DeduplicationCache{client: client, ttl: ttl}
+}
+
+// Hash là SHA-256 của notification đã serialise. Các field khác nhau ở mỗi request
+// (ID, CreatedAt, CorrelationID, ThreadID do producer sinh khi NewThread) bị bỏ qua,
+// nếu không hai request cùng nội dung sẽ không bao giờ trùng hash.
+func Hash(n models.Notification) (string, error) {
+	n.ID = ""
+	n.CreatedAt = time.Time{}
+	n.CorrelationID = ""
+	if n.NewThread {
+		n.ThreadID = ""
+	}
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func cacheKey(topic, key, hash string) string {
+	return keyPrefix + topic + ":" + key + ":" + hash
+}
+
+// Claim ghi nhận message, trả về ErrDuplicateMessage nếu đã có trong TTL.
+func (c *DeduplicationCache) Claim(ctx context.Context, topic, key, hash string) error {
+	ok, err := c.client.SetNX(ctx, cacheKey(topic, key, hash), time.Now().UnixMilli(), c.ttl).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check dedup cache: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: same notification was sent to %s within %s", ErrDuplicateMessage, topic, c.ttl)
+	}
+	return nil
+}
+
+// Release xoá bản ghi của Claim khi gửi thất bại để client có thể gửi lại ngay.
+func (c *DeduplicationCache) Release(ctx context.Context, topic, key, hash string) error {
+	if err := c.client.Del(ctx, cacheKey(topic, key, hash)).Err(); err != nil {
+		return fmt.Errorf("failed to release dedup cache entry: %w", err)
+	}
+	return nil
+}
+
+func (c *DeduplicationCache) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,148 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/models"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestCache(t *testing.T, ttl time.Duration) (*DeduplicationCache, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	cache := NewDeduplicationCache(redis.NewClient(&redis.Options{Addr: server.Addr()}), ttl)
+	t.Cleanup(func() { cache.Close() })
+	return cache, server
+}
+
+// claimConcurrently gọi Claim từ n goroutine cùng lúc, trả về số lần được chấp nhận và số lần trùng
+func claimConcurrently(t *testing.T, cache *DeduplicationCache, n int, topic, key, hash string) (accepted, duplicates int32) {
+	t.Helper()
+	var acceptedCount, duplicateCount atomic.Int32
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			err := cache.Claim(context.Background(), topic, key, hash)
+			switch {
+			case err == nil:
+				acceptedCount.Add(1)
+			case errors.Is(err, ErrDuplicateMessage):
+				duplicateCount.Add(1)
+			default:
+				t.Errorf("Claim() error = %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return acceptedCount.Load(), duplicateCount.Load()
+}
+
+func TestDeduplicationCacheConcurrentClaims(t *testing.T) {
+	const goroutines = 50
+	cache, _ := newTestCache(t, time.Minute)
+	accepted, duplicates := claimConcurrently(t, cache, goroutines, "notifications.normal", "2", "hash")
+	if accepted != 1 || duplicates != goroutines-1 {
+		t.Fatalf("accepted %d and rejected %d of %d claims, want exactly one accepted", accepted, duplicates, goroutines)
+	}
+}
+
+// mỗi (topic, key, hash) được dedup riêng, request song song cho message khác nhau đều được gửi
+func TestDeduplicationCacheConcurrentDistinctMessages(t *testing.T) {
+	const messages = 20
+	cache, _ := newTestCache(t, time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < messages; i++ {
+		hash := "hash-" + strconv.Itoa(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if accepted, _ := claimConcurrently(t, cache, 5, "notifications.normal", "2", hash); accepted != 1 {
+				t.Errorf("%s accepted %d times, want 1", hash, accepted)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, other := range []struct{ topic, key string }{{"notifications.high", "2"}, {"notifications.normal", "3"}} {
+		if err := cache.Claim(context.Background(), other.topic, other.key, "hash-0"); err != nil {
+			t.Fatalf("Claim(%s, %s) error = %v, want accepted", other.topic, other.key, err)
+		}
+	}
+}
+
+func TestDeduplicationCacheTTLAndRelease(t *testing.T) {
+	ctx := context.Background()
+	cache, server := newTestCache(t, time.Minute)
+	if err := cache.Claim(ctx, "notifications.normal", "2", "hash"); err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if err := cache.Claim(ctx, "notifications.normal", "2", "hash"); !errors.Is(err, ErrDuplicateMessage) {
+		t.Fatalf("Claim() within TTL error = %v, want ErrDuplicateMessage", err)
+	}
+	server.FastForward(time.Minute + time.Second)
+	if err := cache.Claim(ctx, "notifications.normal", "2", "hash"); err != nil {
+		t.Fatalf("Claim() after TTL error = %v, want accepted", err)
+	}
+
+	// gửi thất bại thì Release để client gửi lại được ngay
+	if err := cache.Release(ctx, "notifications.normal", "2", "hash"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if err := cache.Claim(ctx, "notifications.normal", "2", "hash"); err != nil {
+		t.Fatalf("Claim() after Release error = %v, want accepted", err)
+	}
+}
+
+func TestHash(t *testing.T) {
+	base := models.Notification{
+		ID:            "notification-1",
+		From:          models.User{ID: 1, Name: "Alice"},
+		To:            models.User{ID: 2, Name: "Bob"},
+		Message:       "hello",
+		Priority:      models.PriorityNormal,
+		CreatedAt:     time.Now(),
+		CorrelationID: "correlation-1",
+		ThreadID:      "thread-1",
+		NewThread:     true,
+	}
+	hash := func(n models.Notification) string {
+		t.Helper()
+		value, err := Hash(n)
+		if err != nil {
+			t.Fatalf("Hash() error = %v", err)
+		}
+		return value
+	}
+
+	// field producer sinh riêng cho mỗi request không ảnh hưởng hash
+	retry := base
+	retry.ID, retry.CreatedAt, retry.CorrelationID, retry.ThreadID = "notification-2", base.CreatedAt.Add(time.Second), "correlation-2", "thread-2"
+	if hash(retry) != hash(base) {
+		t.Fatal("retry of the same notification has a different hash")
+	}
+
+	changed := base
+	changed.Message = "hello again"
+	if hash(changed) == hash(base) {
+		t.Fatal("different message has the same hash")
+	}
+	// thread do client chọn là một phần nội dung
+	existingThread, otherThread := base, base
+	existingThread.NewThread, otherThread.NewThread = false, false
+	otherThread.ThreadID = "thread-2"
+	if hash(existingThread) == hash(otherThread) {
+		t.Fatal("same message in different client threads has the same hash")
+	}
+}
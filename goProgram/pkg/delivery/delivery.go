@@ -0,0 +1,38 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/models"
+	"sync"
+)
+
+// Deliverer là một kênh giao notification ra ngoài hệ thống (Slack, webhook, ...).
+type Deliverer interface {
+	Deliver(ctx context.Context, n models.Notification) error
+}
+
+// DeliveryPipeline gửi notification song song tới mọi Deliverer đã đăng ký,
+// một kênh lỗi không chặn các kênh còn lại.
+type DeliveryPipeline struct {
+	deliverers []Deliverer
+}
+
+func NewDeliveryPipeline(deliverers ...Deliverer) *DeliveryPipeline {
+	return &DeliveryPipeline{deliverers: deliverers}
+}
+
+// Deliver chờ mọi kênh hoàn thành và trả về lỗi gộp (errors.Join) của các kênh thất bại.
+func (p *DeliveryPipeline) Deliver(ctx context.Context, n models.Notification) error {
+	errs := make([]error, len(p.deliverers))
+	var wg sync.WaitGroup
+	for i, deliverer := range p.deliverers {
+		wg.Add(1)
+		go func(i int, deliverer Deliverer) {
+			defer wg.Done()
+			errs[i] = deliverer.Deliver(ctx, n)
+		}(i, deliverer)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
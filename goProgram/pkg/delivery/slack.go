@@ -0,0 +1,62 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kafka-notify/pkg/models"
+	"net/http"
+	"time"
+)
+
+// thời gian tối đa chờ Slack phản hồi một request
+const slackTimeout = 5 * time.Second
+
+// SlackDeliverer gửi notification tới Slack qua incoming webhook.
+// WebhookURL rỗng (SLACK_WEBHOOK_URL chưa đặt) thì Deliver không làm gì.
+type SlackDeliverer struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+func NewSlackDeliverer(webhookURL string) *SlackDeliverer {
+	return &SlackDeliverer{
+		WebhookURL: webhookURL,
+		Client:     &http.Client{Timeout: slackTimeout},
+	}
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+func (d *SlackDeliverer) Deliver(ctx context.Context, n models.Notification) error {
+	if d.WebhookURL == "" {
+		return nil
+	}
+
+	payload, err := json.Marshal(slackMessage{
+		Text: fmt.Sprintf("*%s* → *%s* [%s]: %s", n.From.Name, n.To.Name, models.PriorityName(n.Priority), n.Message),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver to slack: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to deliver to slack: status %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"kafka-notify/pkg/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func testNotification() models.Notification {
+	return models.Notification{
+		ID:       "notification-1",
+		From:     models.User{ID: 1, Name: "Alice"},
+		To:       models.User{ID: 2, Name: "Bob"},
+		Message:  "Deploy finished",
+		Priority: models.PriorityHigh,
+	}
+}
+
+// newSlackServer giả lập incoming webhook của Slack: nhận JSON {"text": ...} và trả "ok"
+func newSlackServer(t *testing.T, status int, received chan<- slackMessage) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("slack got %s with Content-Type %q, want POST application/json", r.Method, r.Header.Get("Content-Type"))
+		}
+		var message slackMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("slack payload is not JSON: %v", err)
+		}
+		received <- message
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte("ok"))
+		} else {
+			w.Write([]byte("invalid_payload"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSlackDelivererDeliver(t *testing.T) {
+	received := make(chan slackMessage, 1)
+	server := newSlackServer(t, http.StatusOK, received)
+
+	if err := NewSlackDeliverer(server.URL).Deliver(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	message := <-received
+	for _, part := range []string{"Alice", "Bob", models.PriorityName(models.PriorityHigh), "Deploy finished"} {
+		if !strings.Contains(message.Text, part) {
+			t.Fatalf("slack text = %q, want it to contain %q", message.Text, part)
+		}
+	}
+}
+
+func TestSlackDelivererErrorStatus(t *testing.T) {
+	received := make(chan slackMessage, 1)
+	server := newSlackServer(t, http.StatusBadRequest, received)
+
+	err := NewSlackDeliverer(server.URL).Deliver(context.Background(), testNotification())
+	if err == nil || !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "invalid_payload") {
+		t.Fatalf("Deliver() error = %v, want status 400 with slack's body", err)
+	}
+}
+
+func TestSlackDelivererWithoutWebhookURL(t *testing.T) {
+	if err := NewSlackDeliverer("").Deliver(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Deliver() without SLACK_WEBHOOK_URL error = %v, want no-op", err)
+	}
+}
+
+type delivererFunc func(ctx context.Context, n models.Notification) error
+
+func (f delivererFunc) Deliver(ctx context.Context, n models.Notification) error { return f(ctx, n) }
+
+// một kênh lỗi không chặn Slack, lỗi được gộp vào kết quả của pipeline
+func TestDeliveryPipelineFansOut(t *testing.T) {
+	received := make(chan slackMessage, 1)
+	server := newSlackServer(t, http.StatusOK, received)
+	errBroken := errors.New("broken channel")
+	var calls atomic.Int32
+	pipeline := NewDeliveryPipeline(
+		NewSlackDeliverer(server.URL),
+		delivererFunc(func(context.Context, models.Notification) error {
+			calls.Add(1)
+			return errBroken
+		}),
+	)
+
+	err := pipeline.Deliver(context.Background(), testNotification())
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("Deliver() error = %v, want errBroken", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("failing deliverer called %d times, want 1", calls.Load())
+	}
+	select {
+	case <-received:
+	default:
+		t.Fatal("slack got no message")
+	}
+}
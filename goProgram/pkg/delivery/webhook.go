@@ -0,0 +1,100 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/signing"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"time"
+)
+
+const (
+	// số lần gửi lại tối đa khi webhook trả về non-2xx hoặc lỗi mạng
+	webhookMaxRetries = 3
+	// backoff của lần retry đầu tiên, các lần sau nhân đôi
+	webhookInitialBackoff = 500 * time.Millisecond
+	webhookTimeout        = 5 * time.Second
+)
+
+// WebhookDeliverer POST notification (JSON) tới mọi webhook người nhận đã đăng ký,
+// body được ký HMAC-SHA256 bằng secret của từng webhook trong header X-Signature.
+type WebhookDeliverer struct {
+	Store          store.WebhookStore
+	Client         *http.Client
+	MaxRetries     int
+	InitialBackoff time.Duration
+}
+
+func NewWebhookDeliverer(webhooks store.WebhookStore) *WebhookDeliverer {
+	return &WebhookDeliverer{
+		Store:          webhooks,
+		Client:         &http.Client{Timeout: webhookTimeout},
+		MaxRetries:     webhookMaxRetries,
+		InitialBackoff: webhookInitialBackoff,
+	}
+}
+
+func (d *WebhookDeliverer) Deliver(ctx context.Context, n models.Notification) error {
+	webhooks, err := d.Store.FindByUserID(ctx, n.To.ID)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	var errs []error
+	for _, webhook := range webhooks {
+		if err := d.deliverWithRetry(ctx, webhook, payload); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", webhook.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *WebhookDeliverer) deliverWithRetry(ctx context.Context, webhook models.Webhook, payload []byte) error {
+	backoff := d.InitialBackoff
+	var err error
+	for attempt := 0; attempt <= d.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		if err = d.post(ctx, webhook, payload); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("giving up after %d retries: %w", d.MaxRetries, err)
+}
+
+func (d *WebhookDeliverer) post(ctx context.Context, webhook models.Webhook, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(signing.HeaderSignature, signing.EncodeHeader(signing.Sign([]byte(webhook.Secret), payload)))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/signing"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookServer trả về lần lượt các status trong statuses, hết thì trả 200, và ghi lại mọi request
+type webhookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	sigs     []string
+	times    []time.Time
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	t.Helper()
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, body)
+		s.sigs = append(s.sigs, r.Header.Get(signing.HeaderSignature))
+		s.times = append(s.times, time.Now())
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		// mở khoá trước khi trả lời để test đọc bodies sau khi Deliver xong không bị race
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func newTestWebhookDeliverer(t *testing.T, webhooks ...models.Webhook) *WebhookDeliverer {
+	t.Helper()
+	webhookStore := store.NewMemoryWebhookStore()
+	for _, webhook := range webhooks {
+		if err := webhookStore.Save(context.Background(), webhook); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	deliverer := NewWebhookDeliverer(webhookStore)
+	deliverer.InitialBackoff = 10 * time.Millisecond
+	return deliverer
+}
+
+// mỗi webhook nhận body JSON của notification, ký bằng secret của chính nó
+func TestWebhookDelivererSignsPayload(t *testing.T) {
+	first, second, other := newWebhookServer(t), newWebhookServer(t), newWebhookServer(t)
+	secrets := map[*webhookServer]string{first: "secret-one", second: "secret-two"}
+	deliverer := newTestWebhookDeliverer(t,
+		models.Webhook{ID: "first", URL: first.URL, Secret: secrets[first], UserID: 2},
+		models.Webhook{ID: "second", URL: second.URL, Secret: secrets[second], UserID: 2},
+		models.Webhook{ID: "other", URL: other.URL, Secret: "secret-other", UserID: 3},
+	)
+
+	notification := testNotification()
+	if err := deliverer.Deliver(context.Background(), notification); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	for server, secret := range secrets {
+		if server.attempts() != 1 {
+			t.Fatalf("webhook got %d requests, want 1", server.attempts())
+		}
+		sig, err := signing.DecodeHeader(server.sigs[0])
+		if err != nil {
+			t.Fatalf("%s header %q: %v", signing.HeaderSignature, server.sigs[0], err)
+		}
+		if !signing.Verify([]byte(secret), server.bodies[0], sig) {
+			t.Fatalf("signature %q does not verify with the webhook secret", server.sigs[0])
+		}
+		if signing.Verify([]byte("secret-other"), server.bodies[0], sig) {
+			t.Fatal("signature verifies with another webhook's secret")
+		}
+		var got models.Notification
+		if err := json.Unmarshal(server.bodies[0], &got); err != nil || got.ID != notification.ID || got.Message != notification.Message {
+			t.Fatalf("webhook body = %s, %v, want notification %s", server.bodies[0], err, notification.ID)
+		}
+	}
+	if other.attempts() != 0 {
+		t.Fatalf("webhook of another user got %d requests, want 0", other.attempts())
+	}
+}
+
+// non-2xx được gửi lại với backoff nhân đôi, cùng body và chữ ký
+func TestWebhookDelivererRetriesNon2xx(t *testing.T) {
+	server := newWebhookServer(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusTooManyRequests)
+	deliverer := newTestWebhookDeliverer(t, models.Webhook{ID: "flaky", URL: server.URL, Secret: "secret", UserID: 2})
+
+	if err := deliverer.Deliver(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if got := server.attempts(); got != 4 {
+		t.Fatalf("webhook got %d requests, want 4 (3 failures then success)", got)
+	}
+	for i := 1; i < len(server.bodies); i++ {
+		if string(server.bodies[i]) != string(server.bodies[0]) || server.sigs[i] != server.sigs[0] {
+			t.Fatalf("retry %d sent a different body or signature", i)
+		}
+		want := deliverer.InitialBackoff << (i - 1)
+		if gap := server.times[i].Sub(server.times[i-1]); gap < want {
+			t.Fatalf("retry %d came after %v, want at least %v", i, gap, want)
+		}
+	}
+}
+
+func TestWebhookDelivererGivesUpAfterMaxRetries(t *testing.T) {
+	failing := newWebhookServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	healthy := newWebhookServer(t)
+	deliverer := newTestWebhookDeliverer(t,
+		models.Webhook{ID: "failing", URL: failing.URL, Secret: "secret", UserID: 2},
+		models.Webhook{ID: "healthy", URL: healthy.URL, Secret: "secret", UserID: 2},
+	)
+
+	if err := deliverer.Deliver(context.Background(), testNotification()); err == nil {
+		t.Fatal("Deliver() error = nil, want the failing webhook's error")
+	}
+	if got := failing.attempts(); got != webhookMaxRetries+1 {
+		t.Fatalf("failing webhook got %d requests, want %d", got, webhookMaxRetries+1)
+	}
+	// webhook lỗi không làm mất notification của webhook còn lại
+	if got := healthy.attempts(); got != 1 {
+		t.Fatalf("healthy webhook got %d requests, want 1", got)
+	}
+}
+
+func TestWebhookDelivererStopsRetryingOnCancel(t *testing.T) {
+	server := newWebhookServer(t, http.StatusInternalServerError, http.StatusInternalServerError)
+	deliverer := newTestWebhookDeliverer(t, models.Webhook{ID: "flaky", URL: server.URL, Secret: "secret", UserID: 2})
+	deliverer.InitialBackoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := deliverer.Deliver(ctx, testNotification()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Deliver() error = %v, want context.DeadlineExceeded", err)
+	}
+	if got := server.attempts(); got != 1 {
+		t.Fatalf("webhook got %d requests, want 1 before the context expired", got)
+	}
+}
@@ -0,0 +1,75 @@
+package dlq
+
+import (
+	"fmt"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/tenant"
+	"strconv"
+
+	"github.com/IBM/sarama"
+)
+
+// Header gắn vào message trong DLQ để biết vì sao và từ đâu message bị chuyển sang.
+const (
+	HeaderErrorReason       = "X-Error-Reason"
+	HeaderOriginalTopic     = "X-Original-Topic"
+	HeaderOriginalPartition = "X-Original-Partition"
+	HeaderOriginalOffset    = "X-Original-Offset"
+)
+
+// SetupDLQProducer tạo SyncProducer dùng riêng để đẩy message lỗi sang DLQ topic,
+// đồng bộ để chắc chắn message đã vào DLQ rồi mới commit offset gốc.
+func SetupDLQProducer(cfg *config.Config) (sarama.SyncProducer, error) {
+	config := sarama.NewConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	if err := kafka.ApplySecurity(config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to setup dlq producer: %w", err)
+	}
+	producer, err := sarama.NewSyncProducer(cfg.KafkaBrokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup dlq producer: %w", err)
+	}
+	return producer, nil
+}
+
+// Producer chuyển message không xử lý được sang DLQ topic, giữ nguyên key, value và header gốc.
+// Message có header X-Tenant-ID được chuyển sang DLQ topic của tenant đó (<tenantID>.<topic>).
+type Producer struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+func NewProducer(producer sarama.SyncProducer, topic string) *Producer {
+	return &Producer{producer: producer, topic: topic}
+}
+
+func (p *Producer) Send(msg *sarama.ConsumerMessage, reason string) error {
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+4)
+	for _, header := range msg.Headers {
+		headers = append(headers, *header)
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(HeaderErrorReason), Value: []byte(reason)},
+		sarama.RecordHeader{Key: []byte(HeaderOriginalTopic), Value: []byte(msg.Topic)},
+		sarama.RecordHeader{Key: []byte(HeaderOriginalPartition), Value: []byte(strconv.FormatInt(int64(msg.Partition), 10))},
+		sarama.RecordHeader{Key: []byte(HeaderOriginalOffset), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+
+	topic := tenant.Topic(kafka.HeaderValue(msg.Headers, tenant.HeaderTenantID), p.topic)
+	_, _, err := p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(msg.Key),
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send message to dlq topic %s: %w", topic, err)
+	}
+	return nil
+}
+
+func (p *Producer) Close() error {
+	return p.producer.Close()
+}
@@ -0,0 +1,143 @@
+package dlq
+
+import (
+	"errors"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/tenant"
+	"sync"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// recordingProducer ghi lại các message SyncProducer thật đã gửi thành công tới broker
+type recordingProducer struct {
+	sarama.SyncProducer
+
+	mu   sync.Mutex
+	sent []*sarama.ProducerMessage
+}
+
+func (p *recordingProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	partition, offset, err := p.SyncProducer.SendMessage(msg)
+	if err == nil {
+		p.mu.Lock()
+		p.sent = append(p.sent, msg)
+		p.mu.Unlock()
+	}
+	return partition, offset, err
+}
+
+func (p *recordingProducer) messages() []*sarama.ProducerMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*sarama.ProducerMessage(nil), p.sent...)
+}
+
+// setupDLQProducer tạo Producer bằng SetupDLQProducer trên MockBroker làm leader của DLQ topic
+// (cả DLQ topic của tenant acme), produceErr khác ErrNoError thì broker từ chối mọi message.
+func setupDLQProducer(t *testing.T, produceErr sarama.KError) (*Producer, *recordingProducer, *config.Config) {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	t.Setenv("KAFKA_BROKERS", broker.Addr())
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	tenantTopic := tenant.Topic("acme", cfg.DLQTopic)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(cfg.DLQTopic, 0, broker.BrokerID()).
+			SetLeader(tenantTopic, 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).
+			SetError(cfg.DLQTopic, 0, produceErr).
+			SetError(tenantTopic, 0, produceErr),
+	})
+
+	producer, err := SetupDLQProducer(cfg)
+	if err != nil {
+		t.Fatalf("SetupDLQProducer() error = %v", err)
+	}
+	recorder := &recordingProducer{SyncProducer: producer}
+	dlqProducer := NewProducer(recorder, cfg.DLQTopic)
+	t.Cleanup(func() { dlqProducer.Close() })
+	return dlqProducer, recorder, cfg
+}
+
+// badMessage là message consumer không giải mã được
+func badMessage(headers ...*sarama.RecordHeader) *sarama.ConsumerMessage {
+	return &sarama.ConsumerMessage{
+		Topic: "notifications.normal", Partition: 2, Offset: 7,
+		Key: []byte("2"), Value: []byte("{not json"),
+		Headers: append([]*sarama.RecordHeader{
+			{Key: []byte("Content-Type"), Value: []byte("application/json")},
+		}, headers...),
+	}
+}
+
+func TestBadMessageLandsInDLQ(t *testing.T) {
+	producer, recorder, cfg := setupDLQProducer(t, sarama.ErrNoError)
+
+	if err := producer.Send(badMessage(), "invalid character 'n'"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	sent := recorder.messages()
+	if len(sent) != 1 {
+		t.Fatalf("%d messages in the DLQ, want 1", len(sent))
+	}
+	msg := sent[0]
+	if msg.Topic != cfg.DLQTopic || cfg.DLQTopic != "notifications.dlq" {
+		t.Fatalf("topic = %s, DLQ_TOPIC = %s, want notifications.dlq", msg.Topic, cfg.DLQTopic)
+	}
+	key, _ := msg.Key.Encode()
+	value, _ := msg.Value.Encode()
+	if string(key) != "2" || string(value) != "{not json" {
+		t.Fatalf("key %q value %q, want the original key and raw bytes", key, value)
+	}
+
+	headers := make([]*sarama.RecordHeader, len(msg.Headers))
+	for i := range msg.Headers {
+		headers[i] = &msg.Headers[i]
+	}
+	want := map[string]string{
+		"Content-Type":          "application/json",
+		HeaderErrorReason:       "invalid character 'n'",
+		HeaderOriginalTopic:     "notifications.normal",
+		HeaderOriginalPartition: "2",
+		HeaderOriginalOffset:    "7",
+	}
+	for name, value := range want {
+		if got := kafka.HeaderValue(headers, name); got != value {
+			t.Errorf("header %s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestBadTenantMessageLandsInTenantDLQ(t *testing.T) {
+	producer, recorder, cfg := setupDLQProducer(t, sarama.ErrNoError)
+
+	header := &sarama.RecordHeader{Key: []byte(tenant.HeaderTenantID), Value: []byte("acme")}
+	if err := producer.Send(badMessage(header), "invalid character 'n'"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	sent := recorder.messages()
+	if len(sent) != 1 || sent[0].Topic != "acme."+cfg.DLQTopic {
+		t.Fatalf("DLQ messages = %v, want one in acme.%s", sent, cfg.DLQTopic)
+	}
+}
+
+func TestSendReturnsBrokerError(t *testing.T) {
+	producer, recorder, _ := setupDLQProducer(t, sarama.ErrInvalidMessage)
+
+	err := producer.Send(badMessage(), "invalid character 'n'")
+	if !errors.Is(err, sarama.ErrInvalidMessage) {
+		t.Fatalf("Send() error = %v, want ErrInvalidMessage", err)
+	}
+	if len(recorder.messages()) != 0 {
+		t.Fatal("rejected message was recorded as sent")
+	}
+}
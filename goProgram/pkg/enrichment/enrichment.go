@@ -0,0 +1,70 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/models"
+	"time"
+)
+
+// Enricher bổ sung thông tin vào notification trước khi gửi lên Kafka.
+type Enricher interface {
+	Enrich(ctx context.Context, n *models.Notification) error
+}
+
+// EnricherFunc cho phép dùng một hàm thường làm Enricher.
+type EnricherFunc func(ctx context.Context, n *models.Notification) error
+
+func (f EnricherFunc) Enrich(ctx context.Context, n *models.Notification) error {
+	return f(ctx, n)
+}
+
+// EnrichmentPipeline chạy các Enricher theo thứ tự, dừng ở enricher lỗi đầu tiên.
+type EnrichmentPipeline struct {
+	enrichers []Enricher
+}
+
+func NewEnrichmentPipeline(enrichers ...Enricher) *EnrichmentPipeline {
+	return &EnrichmentPipeline{enrichers: enrichers}
+}
+
+func (p *EnrichmentPipeline) Enrich(ctx context.Context, n *models.Notification) error {
+	if p == nil {
+		return nil
+	}
+	for i, enricher := range p.enrichers {
+		if err := enricher.Enrich(ctx, n); err != nil {
+			return fmt.Errorf("enricher %d (%T): %w", i, enricher, err)
+		}
+	}
+	return nil
+}
+
+// TimestampEnricher gán CreatedAt (UTC) nếu notification chưa có.
+type TimestampEnricher struct {
+	// Now mặc định là time.Now
+	Now func() time.Time
+}
+
+func (e TimestampEnricher) Enrich(_ context.Context, n *models.Notification) error {
+	if !n.CreatedAt.IsZero() {
+		return nil
+	}
+	now := time.Now
+	if e.Now != nil {
+		now = e.Now
+	}
+	n.CreatedAt = now().UTC()
+	return nil
+}
+
+// RequestIDEnricher copy correlation ID của request (xem middleware.WithCorrelationID) vào notification.
+type RequestIDEnricher struct{}
+
+func (RequestIDEnricher) Enrich(ctx context.Context, n *models.Notification) error {
+	if n.CorrelationID == "" {
+		n.CorrelationID = middleware.CorrelationIDFromContext(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/models"
+	"testing"
+	"time"
+)
+
+// recordingEnricher ghi tên vào order và thêm tên vào Message để enricher sau thấy thay đổi của enricher trước
+func recordingEnricher(name string, order *[]string, err error) Enricher {
+	return EnricherFunc(func(ctx context.Context, n *models.Notification) error {
+		*order = append(*order, name)
+		if err != nil {
+			return err
+		}
+		n.Message += "+" + name
+		return nil
+	})
+}
+
+func TestEnrichmentPipelineRunsInOrder(t *testing.T) {
+	var order []string
+	pipeline := NewEnrichmentPipeline(
+		recordingEnricher("first", &order, nil),
+		recordingEnricher("second", &order, nil),
+		recordingEnricher("third", &order, nil),
+	)
+	n := models.Notification{Message: "hello"}
+	if err := pipeline.Enrich(context.Background(), &n); err != nil {
+		t.Fatalf("Enrich() error = %v", err)
+	}
+	if len(order) != 3 || order[0] != "first" || order[1] != "second" || order[2] != "third" {
+		t.Fatalf("enrichers ran in order %v, want first, second, third", order)
+	}
+	if want := "hello+first+second+third"; n.Message != want {
+		t.Fatalf("Message = %q, want %q", n.Message, want)
+	}
+}
+
+func TestEnrichmentPipelineShortCircuits(t *testing.T) {
+	errLookup := errors.New("geo lookup failed")
+	var order []string
+	pipeline := NewEnrichmentPipeline(
+		recordingEnricher("first", &order, nil),
+		recordingEnricher("failing", &order, errLookup),
+		recordingEnricher("third", &order, nil),
+	)
+	n := models.Notification{Message: "hello"}
+	err := pipeline.Enrich(context.Background(), &n)
+	if !errors.Is(err, errLookup) {
+		t.Fatalf("Enrich() error = %v, want errLookup", err)
+	}
+	if len(order) != 2 || order[1] != "failing" {
+		t.Fatalf("enrichers ran %v, want the pipeline to stop at the failing enricher", order)
+	}
+}
+
+func TestNilEnrichmentPipeline(t *testing.T) {
+	var pipeline *EnrichmentPipeline
+	n := models.Notification{Message: "hello"}
+	if err := pipeline.Enrich(context.Background(), &n); err != nil || n.Message != "hello" {
+		t.Fatalf("nil pipeline Enrich() = %v, message %q, want no-op", err, n.Message)
+	}
+}
+
+func TestTimestampEnricher(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("ICT", 7*60*60))
+	enricher := TimestampEnricher{Now: func() time.Time { return now }}
+
+	var n models.Notification
+	if err := enricher.Enrich(context.Background(), &n); err != nil {
+		t.Fatalf("Enrich() error = %v", err)
+	}
+	if !n.CreatedAt.Equal(now) || n.CreatedAt.Location() != time.UTC {
+		t.Fatalf("CreatedAt = %v, want %v in UTC", n.CreatedAt, now)
+	}
+
+	// CreatedAt đã có thì giữ nguyên
+	existing := now.Add(-time.Hour).UTC()
+	n = models.Notification{CreatedAt: existing}
+	if err := enricher.Enrich(context.Background(), &n); err != nil || !n.CreatedAt.Equal(existing) {
+		t.Fatalf("Enrich() = %v, CreatedAt %v, want %v kept", err, n.CreatedAt, existing)
+	}
+}
+
+func TestRequestIDEnricher(t *testing.T) {
+	ctx := middleware.WithCorrelationID(context.Background(), "correlation-1")
+
+	var n models.Notification
+	if err := (RequestIDEnricher{}).Enrich(ctx, &n); err != nil || n.CorrelationID != "correlation-1" {
+		t.Fatalf("Enrich() = %v, CorrelationID %q, want correlation-1", err, n.CorrelationID)
+	}
+	n = models.Notification{CorrelationID: "from-client"}
+	if err := (RequestIDEnricher{}).Enrich(ctx, &n); err != nil || n.CorrelationID != "from-client" {
+		t.Fatalf("Enrich() = %v, CorrelationID %q, want from-client kept", err, n.CorrelationID)
+	}
+}
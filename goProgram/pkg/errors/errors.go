@@ -0,0 +1,106 @@
+// Package errors chứa các kiểu lỗi có cấu trúc của service, handler dùng errors.As để lấy
+// chi tiết (ID user, topic, kích thước...) và chọn HTTP status thay vì so chuỗi lỗi.
+//
+// Mỗi kiểu có method Is: target cùng kiểu với các field để trống khớp mọi lỗi của kiểu đó,
+// ví dụ errors.Is(err, &ErrUserNotFound{}) đúng với mọi user ID,
+// còn errors.Is(err, &ErrUserNotFound{ID: 3}) chỉ đúng với user 3.
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrBrokerUnreachable là lỗi khi không broker nào trong Brokers trả lời.
+type ErrBrokerUnreachable struct {
+	Brokers []string
+	Cause   error
+}
+
+func (e *ErrBrokerUnreachable) Error() string {
+	message := "kafka brokers unreachable"
+	if len(e.Brokers) > 0 {
+		message += " (" + strings.Join(e.Brokers, ",") + ")"
+	}
+	if e.Cause != nil {
+		message += ": " + e.Cause.Error()
+	}
+	return message
+}
+
+func (e *ErrBrokerUnreachable) Unwrap() error {
+	return e.Cause
+}
+
+func (e *ErrBrokerUnreachable) Is(target error) bool {
+	_, ok := target.(*ErrBrokerUnreachable)
+	return ok
+}
+
+// ErrSerialisationFailed là lỗi khi Codec không encode/decode được notification.
+type ErrSerialisationFailed struct {
+	Codec string
+	Cause error
+}
+
+func (e *ErrSerialisationFailed) Error() string {
+	return fmt.Sprintf("failed to serialise notification with %s: %v", e.Codec, e.Cause)
+}
+
+func (e *ErrSerialisationFailed) Unwrap() error {
+	return e.Cause
+}
+
+func (e *ErrSerialisationFailed) Is(target error) bool {
+	t, ok := target.(*ErrSerialisationFailed)
+	return ok && (t.Codec == "" || t.Codec == e.Codec)
+}
+
+// ErrUserNotFound là lỗi khi UserStore không có user ID trong tenant hiện tại.
+type ErrUserNotFound struct {
+	ID int
+}
+
+func (e *ErrUserNotFound) Error() string {
+	return fmt.Sprintf("user not found: %d", e.ID)
+}
+
+func (e *ErrUserNotFound) Is(target error) bool {
+	t, ok := target.(*ErrUserNotFound)
+	return ok && (t.ID == 0 || t.ID == e.ID)
+}
+
+// ErrTopicNotFound là lỗi khi topic không tồn tại trên cluster.
+type ErrTopicNotFound struct {
+	Topic string
+}
+
+func (e *ErrTopicNotFound) Error() string {
+	return "topic not found: " + e.Topic
+}
+
+func (e *ErrTopicNotFound) Is(target error) bool {
+	t, ok := target.(*ErrTopicNotFound)
+	return ok && (t.Topic == "" || t.Topic == e.Topic)
+}
+
+// ErrMessageTooLarge là lỗi khi message vượt giới hạn Limit, mặc định là payload đã encode vượt KAFKA_MAX_MESSAGE_BYTES.
+type ErrMessageTooLarge struct {
+	Size    int
+	MaxSize int
+	// Limit là tên cấu hình giới hạn, rỗng là KAFKA_MAX_MESSAGE_BYTES
+	Limit string
+}
+
+func (e *ErrMessageTooLarge) Error() string {
+	limit := e.Limit
+	if limit == "" {
+		limit = "KAFKA_MAX_MESSAGE_BYTES"
+	}
+	return fmt.Sprintf("message exceeds %s: %d bytes, limit is %d", limit, e.Size, e.MaxSize)
+}
+
+func (e *ErrMessageTooLarge) Is(target error) bool {
+	_, ok := target.(*ErrMessageTooLarge)
+	return ok
+}
@@ -0,0 +1,125 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestErrorTypes(t *testing.T) {
+	cause := io.ErrUnexpectedEOF
+	tests := []struct {
+		name      string
+		err       error
+		message   string
+		matches   []error
+		unmatched []error
+		cause     error
+	}{
+		{
+			name:      "broker unreachable",
+			err:       &ErrBrokerUnreachable{Brokers: []string{"kafka-1:9092", "kafka-2:9092"}, Cause: cause},
+			message:   "kafka brokers unreachable (kafka-1:9092,kafka-2:9092): unexpected EOF",
+			matches:   []error{&ErrBrokerUnreachable{}},
+			unmatched: []error{&ErrTopicNotFound{}},
+			cause:     cause,
+		},
+		{
+			name:      "broker unreachable without details",
+			err:       &ErrBrokerUnreachable{},
+			message:   "kafka brokers unreachable",
+			matches:   []error{&ErrBrokerUnreachable{Brokers: []string{"other:9092"}}},
+			unmatched: []error{cause},
+		},
+		{
+			name:      "serialisation failed",
+			err:       &ErrSerialisationFailed{Codec: "application/json", Cause: cause},
+			message:   "failed to serialise notification with application/json: unexpected EOF",
+			matches:   []error{&ErrSerialisationFailed{}, &ErrSerialisationFailed{Codec: "application/json"}},
+			unmatched: []error{&ErrSerialisationFailed{Codec: "application/x-protobuf"}, &ErrMessageTooLarge{}},
+			cause:     cause,
+		},
+		{
+			name:      "user not found",
+			err:       &ErrUserNotFound{ID: 3},
+			message:   "user not found: 3",
+			matches:   []error{&ErrUserNotFound{}, &ErrUserNotFound{ID: 3}},
+			unmatched: []error{&ErrUserNotFound{ID: 4}, &ErrTopicNotFound{}},
+		},
+		{
+			name:      "topic not found",
+			err:       &ErrTopicNotFound{Topic: "notifications.high"},
+			message:   "topic not found: notifications.high",
+			matches:   []error{&ErrTopicNotFound{}, &ErrTopicNotFound{Topic: "notifications.high"}},
+			unmatched: []error{&ErrTopicNotFound{Topic: "notifications.low"}, &ErrUserNotFound{}},
+		},
+		{
+			name:      "message too large",
+			err:       &ErrMessageTooLarge{Size: 2048, MaxSize: 1024},
+			message:   "message exceeds KAFKA_MAX_MESSAGE_BYTES: 2048 bytes, limit is 1024",
+			matches:   []error{&ErrMessageTooLarge{}, &ErrMessageTooLarge{Size: 1}},
+			unmatched: []error{&ErrSerialisationFailed{}},
+		},
+		{
+			name:    "message too large with custom limit",
+			err:     &ErrMessageTooLarge{Size: 70000, MaxSize: 65536, Limit: "MAX_REQUEST_BODY_BYTES"},
+			message: "message exceeds MAX_REQUEST_BODY_BYTES: 70000 bytes, limit is 65536",
+			matches: []error{&ErrMessageTooLarge{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.message {
+				t.Fatalf("Error() = %q, want %q", got, tt.message)
+			}
+			// handler nhận lỗi đã bị bọc qua nhiều tầng fmt.Errorf
+			wrapped := fmt.Errorf("failed to send notification: %w", fmt.Errorf("send: %w", tt.err))
+			for _, target := range tt.matches {
+				if !errors.Is(wrapped, target) {
+					t.Errorf("errors.Is(%v, %#v) = false, want true", wrapped, target)
+				}
+			}
+			for _, target := range tt.unmatched {
+				if errors.Is(wrapped, target) {
+					t.Errorf("errors.Is(%v, %#v) = true, want false", wrapped, target)
+				}
+			}
+			if tt.cause != nil && !errors.Is(wrapped, tt.cause) {
+				t.Errorf("errors.Is(%v, cause) = false, want true", wrapped)
+			}
+		})
+	}
+}
+
+func TestErrorTypesAs(t *testing.T) {
+	wrap := func(err error) error { return fmt.Errorf("failed to send notification: %w", err) }
+
+	var brokerUnreachable *ErrBrokerUnreachable
+	if !errors.As(wrap(&ErrBrokerUnreachable{Brokers: []string{"kafka:9092"}}), &brokerUnreachable) ||
+		len(brokerUnreachable.Brokers) != 1 || brokerUnreachable.Brokers[0] != "kafka:9092" {
+		t.Errorf("errors.As(ErrBrokerUnreachable) = %+v", brokerUnreachable)
+	}
+	var serialisation *ErrSerialisationFailed
+	if !errors.As(wrap(&ErrSerialisationFailed{Codec: "application/avro"}), &serialisation) ||
+		serialisation.Codec != "application/avro" {
+		t.Errorf("errors.As(ErrSerialisationFailed) = %+v", serialisation)
+	}
+	var userNotFound *ErrUserNotFound
+	if !errors.As(wrap(&ErrUserNotFound{ID: 7}), &userNotFound) || userNotFound.ID != 7 {
+		t.Errorf("errors.As(ErrUserNotFound) = %+v", userNotFound)
+	}
+	var topicNotFound *ErrTopicNotFound
+	if !errors.As(wrap(&ErrTopicNotFound{Topic: "notifications.dlq"}), &topicNotFound) ||
+		topicNotFound.Topic != "notifications.dlq" {
+		t.Errorf("errors.As(ErrTopicNotFound) = %+v", topicNotFound)
+	}
+	var tooLarge *ErrMessageTooLarge
+	if !errors.As(wrap(&ErrMessageTooLarge{Size: 10, MaxSize: 5}), &tooLarge) || tooLarge.Size != 10 || tooLarge.MaxSize != 5 {
+		t.Errorf("errors.As(ErrMessageTooLarge) = %+v", tooLarge)
+	}
+
+	if errors.As(wrap(io.EOF), &userNotFound) {
+		t.Error("errors.As matched an unrelated error")
+	}
+}
@@ -0,0 +1,61 @@
+package expiry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/store"
+	"time"
+)
+
+// Sweeper xoá khỏi Store các notification đã quá ExpiresAt mỗi Interval,
+// để notification hết hạn không còn được trả về ở GET /notifications.
+type Sweeper struct {
+	Store    store.NotificationStore
+	Interval time.Duration
+	// OnSwept được gọi sau mỗi lần quét xoá được ít nhất một notification, nil là bỏ qua
+	OnSwept func(deleted int)
+	// OnError được gọi khi một lần quét thất bại, nil là bỏ qua
+	OnError func(err error)
+}
+
+// Run quét ngay khi bắt đầu rồi lặp lại mỗi Interval tới khi ctx bị huỷ.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		deleted, err := s.DeleteExpiredBefore(ctx, time.Now())
+		if err != nil && s.OnError != nil {
+			s.OnError(err)
+		}
+		if deleted > 0 && s.OnSwept != nil {
+			s.OnSwept(deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeleteExpiredBefore xoá các notification có ExpiresAt trước t và trả về số notification đã xoá.
+// Notification đã bị xoá bởi tombstone trong lúc quét được bỏ qua.
+func (s *Sweeper) DeleteExpiredBefore(ctx context.Context, t time.Time) (int, error) {
+	ids, err := s.Store.ExpiredBefore(ctx, t)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, id := range ids {
+		err := s.Store.Delete(ctx, id)
+		if errors.Is(err, store.ErrNotificationNotFound) {
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete expired notification %s: %w", id, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
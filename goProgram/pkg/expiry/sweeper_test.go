@@ -0,0 +1,98 @@
+package expiry
+
+import (
+	"context"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"testing"
+	"time"
+)
+
+func newExpiryStore(t *testing.T, now time.Time) store.NotificationStore {
+	t.Helper()
+	s := store.NewMemoryNotificationStore()
+	notifications := []models.Notification{
+		{ID: "expired-1", Message: "a", ExpiresAt: now.Add(-time.Hour)},
+		{ID: "expired-2", Message: "b", ExpiresAt: now.Add(-time.Minute)},
+		{ID: "expired-3", Message: "c", ExpiresAt: now.Add(-time.Second)},
+		{ID: "future", Message: "d", ExpiresAt: now.Add(time.Hour)},
+		{ID: "no-ttl", Message: "e"},
+	}
+	for _, n := range notifications {
+		n.To = models.User{ID: 2, Name: "Bob"}
+		if err := s.Store(context.Background(), n); err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+	}
+	return s
+}
+
+func remainingIDs(t *testing.T, s store.NotificationStore) map[string]bool {
+	t.Helper()
+	notifications, err := s.FindByUserID(context.Background(), 2, store.NotificationFilter{})
+	if err != nil {
+		t.Fatalf("FindByUserID() error = %v", err)
+	}
+	ids := make(map[string]bool, len(notifications))
+	for _, n := range notifications {
+		ids[n.ID] = true
+	}
+	return ids
+}
+
+func TestSweeperDeleteExpiredBefore(t *testing.T) {
+	now := time.Now()
+	s := newExpiryStore(t, now)
+	sweeper := &Sweeper{Store: s, Interval: time.Hour}
+
+	deleted, err := sweeper.DeleteExpiredBefore(context.Background(), now)
+	if err != nil {
+		t.Fatalf("DeleteExpiredBefore() error = %v", err)
+	}
+	if deleted != 3 {
+		t.Fatalf("deleted = %d, want 3", deleted)
+	}
+	ids := remainingIDs(t, s)
+	if len(ids) != 2 || !ids["future"] || !ids["no-ttl"] {
+		t.Fatalf("remaining = %v, want future and no-ttl", ids)
+	}
+
+	// lần quét sau không còn gì để xoá
+	if deleted, err := sweeper.DeleteExpiredBefore(context.Background(), now); err != nil || deleted != 0 {
+		t.Fatalf("second sweep = %d, %v, want 0", deleted, err)
+	}
+}
+
+func TestSweeperRunSweepsImmediately(t *testing.T) {
+	s := newExpiryStore(t, time.Now())
+	swept := make(chan int, 1)
+	sweeper := &Sweeper{
+		Store:    s,
+		Interval: time.Hour,
+		OnSwept:  func(deleted int) { swept <- deleted },
+		OnError:  func(err error) { t.Errorf("sweep error = %v", err) },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sweeper.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case deleted := <-swept:
+		if deleted != 3 {
+			t.Fatalf("deleted = %d, want 3", deleted)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not sweep before the first tick")
+	}
+	if ids := remainingIDs(t, s); len(ids) != 2 {
+		t.Fatalf("remaining = %v, want 2 notifications", ids)
+	}
+}
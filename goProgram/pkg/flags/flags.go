@@ -0,0 +1,117 @@
+package flags
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Tên các feature flag. Flag chưa được đặt coi là bật nên hành vi mặc định giữ nguyên như trước,
+// flag chỉ tắt được tính năng đã cấu hình (ví dụ hmac_signing không có tác dụng khi thiếu SIGNING_KEY).
+const (
+	HMACSigning = "hmac_signing"
+	Enrichment  = "enrichment"
+	DLQ         = "dlq"
+)
+
+// DefaultRefreshInterval là khoảng thời gian RedisFlagStore đọc lại hash từ Redis
+const DefaultRefreshInterval = 5 * time.Second
+
+const redisTimeout = time.Second
+
+// FlagStore cho biết một feature có đang bật hay không, được gọi trên đường gửi/nhận message
+// nên phải trả lời nhanh và không trả lỗi.
+type FlagStore interface {
+	IsEnabled(flag string) bool
+}
+
+// AllEnabled là FlagStore bật mọi flag, dùng khi không có store nào được cấu hình.
+type AllEnabled struct{}
+
+func (AllEnabled) IsEnabled(string) bool { return true }
+
+// EnvFlagStore đọc flag từ biến môi trường FEATURE_<FLAG>, ví dụ FEATURE_HMAC_SIGNING=false.
+type EnvFlagStore struct{}
+
+func (EnvFlagStore) IsEnabled(flag string) bool {
+	return parse(os.Getenv("FEATURE_" + strings.ToUpper(flag)))
+}
+
+// RedisFlagStore đọc flag từ Redis hash key (field là tên flag, value true/false) để bật tắt
+// lúc đang chạy bằng HSET mà không phải deploy lại. Hash được cache và đọc lại sau mỗi refresh,
+// Redis lỗi thì giữ giá trị đã đọc lần trước.
+type RedisFlagStore struct {
+	client  *redis.Client
+	key     string
+	refresh time.Duration
+	// OnError được gọi khi đọc Redis thất bại, nil là bỏ qua
+	OnError func(err error)
+
+	mu       sync.Mutex
+	flags    map[string]string
+	loadedAt time.Time
+}
+
+func NewRedisFlagStore(client *redis.Client, key string, refresh time.Duration) *RedisFlagStore {
+	return &RedisFlagStore{client: client, key: key, refresh: refresh}
+}
+
+func (s *RedisFlagStore) IsEnabled(flag string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.loadedAt) >= s.refresh {
+		s.load()
+	}
+	return parse(s.flags[flag])
+}
+
+// load phải được gọi khi đã giữ mu
+func (s *RedisFlagStore) load() {
+	// đặt loadedAt trước để Redis lỗi không làm mọi lần gọi sau đều chờ timeout
+	s.loadedAt = time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	flags, err := s.client.HGetAll(ctx, s.key).Result()
+	if err != nil {
+		if s.OnError != nil {
+			s.OnError(err)
+		}
+		return
+	}
+	s.flags = flags
+}
+
+func (s *RedisFlagStore) Close() error {
+	return s.client.Close()
+}
+
+// giá trị rỗng hoặc không hợp lệ coi là bật
+func parse(value string) bool {
+	enabled, err := strconv.ParseBool(value)
+	return err != nil || enabled
+}
+
+type contextKey struct{}
+
+// WithStore gắn store vào ctx để sender và các runner dùng chung.
+func WithStore(ctx context.Context, store FlagStore) context.Context {
+	return context.WithValue(ctx, contextKey{}, store)
+}
+
+// FromContext trả về store gắn bởi WithStore, AllEnabled nếu không có.
+func FromContext(ctx context.Context) FlagStore {
+	if store, ok := ctx.Value(contextKey{}).(FlagStore); ok {
+		return store
+	}
+	return AllEnabled{}
+}
+
+// IsEnabled là viết tắt của FromContext(ctx).IsEnabled(flag).
+func IsEnabled(ctx context.Context, flag string) bool {
+	return FromContext(ctx).IsEnabled(flag)
+}
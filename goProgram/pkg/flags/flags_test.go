@@ -0,0 +1,84 @@
+package flags
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const testFlagsKey = "feature-flags"
+
+func TestRedisFlagStoreLiveUpdate(t *testing.T) {
+	server := miniredis.RunT(t)
+	store := NewRedisFlagStore(redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1}), testFlagsKey, 10*time.Millisecond)
+	defer store.Close()
+	var errs int
+	store.OnError = func(error) { errs++ }
+
+	// flag chưa có trong hash coi là bật
+	if !store.IsEnabled(HMACSigning) {
+		t.Fatal("unset flag is disabled, want enabled")
+	}
+
+	server.HSet(testFlagsKey, HMACSigning, "false")
+	// trong refresh vẫn dùng giá trị đã cache
+	if !store.IsEnabled(HMACSigning) {
+		t.Fatal("flag changed before the refresh interval")
+	}
+	waitFlag(t, store, HMACSigning, false)
+	if !store.IsEnabled(Enrichment) {
+		t.Fatal("other flags should stay enabled")
+	}
+
+	server.HSet(testFlagsKey, HMACSigning, "true")
+	waitFlag(t, store, HMACSigning, true)
+
+	// Redis lỗi thì giữ giá trị đã đọc lần trước
+	server.HSet(testFlagsKey, DLQ, "false")
+	waitFlag(t, store, DLQ, false)
+	server.Close()
+	time.Sleep(20 * time.Millisecond)
+	if store.IsEnabled(DLQ) || !store.IsEnabled(HMACSigning) {
+		t.Fatal("flags were not kept while Redis is down")
+	}
+	if errs == 0 {
+		t.Fatal("OnError was not called while Redis is down")
+	}
+}
+
+// waitFlag chờ tối đa 1s tới khi store thấy flag có giá trị want
+func waitFlag(t *testing.T, store FlagStore, flag string, want bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for store.IsEnabled(flag) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("IsEnabled(%s) did not become %v", flag, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEnvFlagStore(t *testing.T) {
+	t.Setenv("FEATURE_HMAC_SIGNING", "false")
+	t.Setenv("FEATURE_DLQ", "not-a-bool")
+	store := EnvFlagStore{}
+	if store.IsEnabled(HMACSigning) {
+		t.Fatal("FEATURE_HMAC_SIGNING=false is enabled")
+	}
+	if !store.IsEnabled(DLQ) || !store.IsEnabled(Enrichment) {
+		t.Fatal("invalid or unset flags should be enabled")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if !IsEnabled(context.Background(), HMACSigning) {
+		t.Fatal("context without a store should enable every flag")
+	}
+	t.Setenv("FEATURE_ENRICHMENT", "false")
+	if IsEnabled(WithStore(context.Background(), EnvFlagStore{}), Enrichment) {
+		t.Fatal("IsEnabled() ignored the store in the context")
+	}
+}
@@ -0,0 +1,58 @@
+package hub
+
+import (
+	"kafka-notify/pkg/models"
+	"sync"
+)
+
+// số notification tối đa được giữ cho một subscriber chưa kịp đọc
+const subscriberBuffer = 16
+
+// Hub phát notification từ consumer tới các kết nối realtime (SSE, WebSocket)
+// đang mở của người nhận. Một user có thể có nhiều kết nối cùng lúc.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[int]map[chan models.Notification]struct{}
+}
+
+func New() *Hub {
+	return &Hub{subscribers: make(map[int]map[chan models.Notification]struct{})}
+}
+
+// Register đăng ký một kết nối cho userID, hàm trả về phải được gọi khi
+// kết nối đóng để huỷ đăng ký và đóng channel.
+func (h *Hub) Register(userID int) (<-chan models.Notification, func()) {
+	ch := make(chan models.Notification, subscriberBuffer)
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan models.Notification]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Broadcast gửi n tới mọi kết nối của n.To. Không block consumer:
+// subscriber nào đầy buffer (client đọc chậm) sẽ bị bỏ qua notification này.
+func (h *Hub) Broadcast(n models.Notification) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subscribers[n.To.ID] {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// HeaderKey là Kafka header mang idempotency key, consumer có thể dùng để lọc trùng.
+const HeaderKey = "X-Idempotency-Key"
+
+var (
+	ErrInvalidKey = errors.New("idempotency key must be a UUID v4")
+	// ErrKeyRecorded là lỗi Record trả về khi key đã được ghi nhận trong window
+	ErrKeyRecorded = errors.New("idempotency key already recorded")
+)
+
+// Store ghi nhớ các idempotency key đã gặp trong một khoảng thời gian (window).
+// Key được Record trước khi gửi nên hai request đồng thời cùng key chỉ có một request được gửi.
+type Store interface {
+	// Check trả về true nếu key đã được ghi nhận trong window.
+	Check(ctx context.Context, key string) (bool, error)
+	// Record ghi nhận key trong window, trả về ErrKeyRecorded nếu request khác đã ghi nhận key
+	// giữa Check và Record.
+	Record(ctx context.Context, key string) error
+	// Release xoá key khi gửi thất bại để client retry được ngay với cùng key.
+	Release(ctx context.Context, key string) error
+}
+
+// ScopedKey gắn tenant và caller vào key client gửi, để key trùng nhau của hai caller khác nhau
+// không chặn message của nhau.
+func ScopedKey(tenantID, caller, key string) string {
+	return tenantID + ":" + caller + ":" + key
+}
+
+// NewKey sinh key mới khi client không gửi idempotency_key.
+func NewKey() string {
+	return uuid.NewString()
+}
+
+// ValidateKey kiểm tra key do client gửi có đúng định dạng UUID v4 không.
+func ValidateKey(key string) error {
+	parsed, err := uuid.Parse(key)
+	if err != nil || parsed.Version() != 4 {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemoryStore(time.Minute) },
+		"redis": func(t *testing.T) Store {
+			return NewRedisStore(redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}), time.Minute)
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			if seen, err := store.Check(ctx, "key"); err != nil || seen {
+				t.Fatalf("Check() before Record = %v, %v, want false", seen, err)
+			}
+			if err := store.Record(ctx, "key"); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+			if seen, err := store.Check(ctx, "key"); err != nil || !seen {
+				t.Fatalf("Check() after Record = %v, %v, want true", seen, err)
+			}
+			if err := store.Record(ctx, "key"); !errors.Is(err, ErrKeyRecorded) {
+				t.Fatalf("second Record() error = %v, want ErrKeyRecorded", err)
+			}
+
+			if err := store.Release(ctx, "key"); err != nil {
+				t.Fatalf("Release() error = %v", err)
+			}
+			if seen, err := store.Check(ctx, "key"); err != nil || seen {
+				t.Fatalf("Check() after Release = %v, %v, want false", seen, err)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreWindow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(10 * time.Millisecond)
+	if err := store.Record(ctx, "key"); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if seen, _ := store.Check(ctx, "key"); seen {
+		t.Fatal("key is still seen after the window")
+	}
+	if err := store.Record(ctx, "key"); err != nil {
+		t.Fatalf("Record() after the window error = %v", err)
+	}
+}
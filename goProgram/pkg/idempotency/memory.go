@@ -0,0 +1,49 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore dùng khi không có Redis, chỉ lọc trùng trong phạm vi một instance.
+type MemoryStore struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+}
+
+func NewMemoryStore(window time.Duration) *MemoryStore {
+	return &MemoryStore{window: window, seen: make(map[string]time.Time)}
+}
+
+func (s *MemoryStore) Check(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt, ok := s.seen[key]
+	return ok && !time.Now().After(expiresAt), nil
+}
+
+func (s *MemoryStore) Record(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if expiresAt, ok := s.seen[key]; ok && !now.After(expiresAt) {
+		return ErrKeyRecorded
+	}
+	// dọn các key đã hết hạn để map không tăng mãi
+	for k, expiresAt := range s.seen {
+		if now.After(expiresAt) {
+			delete(s.seen, k)
+		}
+	}
+	s.seen[key] = now.Add(s.window)
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.seen, key)
+	return nil
+}
@@ -0,0 +1,53 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "idempotency:"
+
+// RedisStore lưu mỗi key dưới dạng idempotency:<key> với TTL bằng window,
+// nên Redis tự xoá key khi hết hạn và nhiều instance producer dùng chung được.
+// Record dùng SET NX nên chỉ một request đồng thời ghi nhận được key.
+type RedisStore struct {
+	client *redis.Client
+	window time.Duration
+}
+
+func NewRedisStore(client *redis.Client, window time.Duration) *RedisStore {
+	return &RedisStore{client: client, window: window}
+}
+
+func (s *RedisStore) Check(ctx context.Context, key string) (bool, error) {
+	n, err := s.client.Exists(ctx, keyPrefix+key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check idempotency key: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *RedisStore) Record(ctx context.Context, key string) error {
+	ok, err := s.client.SetNX(ctx, keyPrefix+key, 1, s.window).Result()
+	if err != nil {
+		return fmt.Errorf("failed to record idempotency key: %w", err)
+	}
+	if !ok {
+		return ErrKeyRecorded
+	}
+	return nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, keyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
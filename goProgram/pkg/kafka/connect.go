@@ -0,0 +1,106 @@
+package kafka
+
+import (
+	"fmt"
+	"kafka-notify/pkg/logger"
+	"math/rand"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// maxConnectBackoff giới hạn thời gian chờ giữa hai lần thử kết nối
+const maxConnectBackoff = 30 * time.Second
+
+// ConnectRetry là cách thử lại khi kết nối Kafka lúc khởi động thất bại,
+// để pod khởi động trước Kafka tự kết nối được thay vì crash liên tục.
+type ConnectRetry struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	// OnRetry được gọi trước khi chờ backoff của lần thử attempt+1, nil là không báo
+	OnRetry func(attempt int, backoff time.Duration, err error)
+}
+
+// Backoff là thời gian chờ sau lần thử attempt (bắt đầu từ 1): BaseBackoff nhân đôi sau mỗi lần,
+// lấy ngẫu nhiên trong nửa trên để nhiều pod khởi động cùng lúc không kết nối lại đồng loạt.
+func (r ConnectRetry) Backoff(attempt int) time.Duration {
+	backoff := r.BaseBackoff
+	for i := 1; i < attempt && backoff < maxConnectBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxConnectBackoff {
+		backoff = maxConnectBackoff
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// Connect gọi connect tối đa MaxAttempts lần, trả về lỗi của lần cuối nếu đều thất bại.
+func Connect[T any](retry ConnectRetry, connect func() (T, error)) (T, error) {
+	var (
+		result T
+		err    error
+	)
+	for attempt := 1; ; attempt++ {
+		result, err = connect()
+		if err == nil || attempt >= retry.MaxAttempts {
+			break
+		}
+		backoff := retry.Backoff(attempt)
+		if retry.OnRetry != nil {
+			retry.OnRetry(attempt, backoff, err)
+		}
+		time.Sleep(backoff)
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to connect to kafka after %d attempts: %w", retry.MaxAttempts, err)
+	}
+	return result, nil
+}
+
+// newConnectRetry ghi log mỗi lần kết nối thất bại (số lần thử và thời gian chờ) trước khi thử lại
+func newConnectRetry(maxAttempts int, baseBackoff time.Duration) ConnectRetry {
+	log := logger.Component("kafka")
+	return ConnectRetry{
+		MaxAttempts: maxAttempts,
+		BaseBackoff: baseBackoff,
+		OnRetry: func(attempt int, backoff time.Duration, err error) {
+			log.Warn().Err(err).
+				Int("attempt", attempt).
+				Int("maxAttempts", maxAttempts).
+				Dur("backoff", backoff).
+				Msg("failed to connect to kafka, retrying")
+		},
+	}
+}
+
+// SetupProducerWithRetry tạo SyncProducer, thử lại tối đa maxAttempts lần với backoff
+// bắt đầu từ baseBackoff khi chưa kết nối được broker.
+func SetupProducerWithRetry(brokers []string, config *sarama.Config, maxAttempts int,
+	baseBackoff time.Duration) (sarama.SyncProducer, error) {
+	return connectProducer(brokers, config, newConnectRetry(maxAttempts, baseBackoff))
+}
+
+func connectProducer(brokers []string, config *sarama.Config, retry ConnectRetry) (sarama.SyncProducer, error) {
+	return Connect(retry, func() (sarama.SyncProducer, error) {
+		producer, err := sarama.NewSyncProducer(brokers, config)
+		return producer, ClassifyError(brokers, "", err)
+	})
+}
+
+// SetupAsyncProducerWithRetry giống SetupProducerWithRetry cho AsyncProducer.
+func SetupAsyncProducerWithRetry(brokers []string, config *sarama.Config, maxAttempts int,
+	baseBackoff time.Duration) (sarama.AsyncProducer, error) {
+	return Connect(newConnectRetry(maxAttempts, baseBackoff), func() (sarama.AsyncProducer, error) {
+		producer, err := sarama.NewAsyncProducer(brokers, config)
+		return producer, ClassifyError(brokers, "", err)
+	})
+}
+
+// SetupConsumerGroupWithRetry giống SetupProducerWithRetry cho ConsumerGroup.
+func SetupConsumerGroupWithRetry(brokers []string, groupID string, config *sarama.Config, maxAttempts int,
+	baseBackoff time.Duration) (sarama.ConsumerGroup, error) {
+	return Connect(newConnectRetry(maxAttempts, baseBackoff), func() (sarama.ConsumerGroup, error) {
+		group, err := sarama.NewConsumerGroup(brokers, groupID, config)
+		return group, ClassifyError(brokers, "", err)
+	})
+}
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// freeAddr trả về địa chỉ localhost chưa có ai listen
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve port: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func TestSetupProducerBrokerAvailableOnThirdAttempt(t *testing.T) {
+	addr := freeAddr(t)
+
+	var retries []int
+	retry := ConnectRetry{
+		MaxAttempts: 5,
+		BaseBackoff: 10 * time.Millisecond,
+		OnRetry: func(attempt int, backoff time.Duration, err error) {
+			retries = append(retries, attempt)
+			if backoff <= 0 {
+				t.Errorf("attempt %d: backoff = %v, want > 0", attempt, backoff)
+			}
+			// broker lên sau lần thử thứ 2 nên lần thử thứ 3 kết nối được
+			if attempt == 2 {
+				broker := sarama.NewMockBrokerAddr(t, 1, addr)
+				broker.SetHandlerByMap(map[string]sarama.MockResponse{
+					"MetadataRequest": sarama.NewMockMetadataResponse(t).SetBroker(addr, 1),
+				})
+				t.Cleanup(broker.Close)
+			}
+		},
+	}
+
+	config := sarama.NewConfig()
+	config.Producer.Return.Successes = true
+	config.Metadata.Retry.Max = 0
+	producer, err := connectProducer([]string{addr}, config, retry)
+	if err != nil {
+		t.Fatalf("connectProducer() error = %v", err)
+	}
+	defer producer.Close()
+
+	if len(retries) != 2 || retries[0] != 1 || retries[1] != 2 {
+		t.Fatalf("OnRetry attempts = %v, want [1 2]", retries)
+	}
+}
+
+func TestConnectGivesUpAfterMaxAttempts(t *testing.T) {
+	errDown := errors.New("broker down")
+	calls := 0
+	_, err := Connect(ConnectRetry{MaxAttempts: 3, BaseBackoff: time.Millisecond}, func() (int, error) {
+		calls++
+		return 0, errDown
+	})
+	if !errors.Is(err, errDown) {
+		t.Fatalf("Connect() error = %v, want %v", err, errDown)
+	}
+	if calls != 3 {
+		t.Fatalf("connect called %d times, want 3", calls)
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	retry := ConnectRetry{BaseBackoff: time.Second}
+	for attempt := 1; attempt <= 10; attempt++ {
+		if backoff := retry.Backoff(attempt); backoff > maxConnectBackoff {
+			t.Fatalf("Backoff(%d) = %v, want <= %v", attempt, backoff, maxConnectBackoff)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package kafka
+
+import (
+	"errors"
+	apperrors "kafka-notify/pkg/errors"
+	"net"
+
+	"github.com/IBM/sarama"
+)
+
+// ClassifyError chuyển lỗi của sarama sang kiểu lỗi của pkg/errors: không broker nào trả lời
+// thành ErrBrokerUnreachable, topic không tồn tại thành ErrTopicNotFound; lỗi khác giữ nguyên.
+func ClassifyError(brokers []string, topic string, err error) error {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sarama.ErrOutOfBrokers), errors.Is(err, sarama.ErrNotConnected), errors.As(err, &netErr):
+		return &apperrors.ErrBrokerUnreachable{Brokers: brokers, Cause: err}
+	case topic != "" && errors.Is(err, sarama.ErrUnknownTopicOrPartition):
+		return &apperrors.ErrTopicNotFound{Topic: topic}
+	default:
+		return err
+	}
+}
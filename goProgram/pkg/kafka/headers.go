@@ -0,0 +1,61 @@
+package kafka
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// Các Kafka header do producer gắn vào message.
+const (
+	HeaderCorrelationID = "X-Correlation-ID"
+	// HeaderMetadataPrefix là tiền tố của header mang Notification.Metadata,
+	// ví dụ metadata source=mobile thành header X-Meta-source: mobile
+	HeaderMetadataPrefix = "X-Meta-"
+)
+
+// HeaderValue trả về giá trị header đầu tiên có key tương ứng, không có thì trả về chuỗi rỗng.
+func HeaderValue(headers []*sarama.RecordHeader, key string) string {
+	for _, header := range headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Header tạo RecordHeader từ cặp key/value dạng string.
+func Header(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
+
+// MetadataHeaders chuyển metadata thành các header X-Meta-<key>, sắp xếp theo key.
+func MetadataHeaders(metadata map[string]string) []sarama.RecordHeader {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	headers := make([]sarama.RecordHeader, 0, len(keys))
+	for _, key := range keys {
+		headers = append(headers, Header(HeaderMetadataPrefix+key, metadata[key]))
+	}
+	return headers
+}
+
+// Metadata đọc lại các header X-Meta-<key> thành map, nil nếu message không có metadata.
+func Metadata(headers []*sarama.RecordHeader) map[string]string {
+	var metadata map[string]string
+	for _, header := range headers {
+		key, ok := strings.CutPrefix(string(header.Key), HeaderMetadataPrefix)
+		if !ok {
+			continue
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[key] = string(header.Value)
+	}
+	return metadata
+}
@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// consumed chuyển header phía producer sang dạng consumer nhận được
+func consumed(headers []sarama.RecordHeader) []*sarama.RecordHeader {
+	result := make([]*sarama.RecordHeader, len(headers))
+	for i := range headers {
+		result[i] = &headers[i]
+	}
+	return result
+}
+
+func TestMetadataHeadersRoundTripUnicode(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+	}{
+		{name: "vietnamese", metadata: map[string]string{"source": "điện thoại", "note": "Xin chào, thế giới"}},
+		{name: "emoji", metadata: map[string]string{"reaction": "👍🏽🎉", "flag": "🇻🇳"}},
+		{name: "cjk and rtl", metadata: map[string]string{"zh": "通知", "ja": "お知らせ", "ar": "إشعار"}},
+		{name: "unicode keys", metadata: map[string]string{"nguồn": "mobile", "app_version": "2.1"}},
+		{name: "combining marks and empty value", metadata: map[string]string{"nfd": "Tiếng Việt", "empty": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := MetadataHeaders(tt.metadata)
+			if len(headers) != len(tt.metadata) {
+				t.Fatalf("MetadataHeaders() returned %d headers, want %d", len(headers), len(tt.metadata))
+			}
+			if got := Metadata(consumed(headers)); !reflect.DeepEqual(got, tt.metadata) {
+				t.Fatalf("Metadata() = %q, want %q", got, tt.metadata)
+			}
+		})
+	}
+}
+
+func TestMetadataIgnoresOtherHeaders(t *testing.T) {
+	headers := append(MetadataHeaders(map[string]string{"source": "mobile"}),
+		Header(HeaderCorrelationID, "abc"), Header("X-Other", "ignored"))
+	if got := Metadata(consumed(headers)); !reflect.DeepEqual(got, map[string]string{"source": "mobile"}) {
+		t.Fatalf("Metadata() = %v, want only source", got)
+	}
+	if got := Metadata(consumed([]sarama.RecordHeader{Header(HeaderCorrelationID, "abc")})); got != nil {
+		t.Fatalf("Metadata() without metadata headers = %v, want nil", got)
+	}
+}
@@ -0,0 +1,129 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/backoff"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/metrics"
+	"kafka-notify/pkg/partitioner"
+
+	"github.com/IBM/sarama"
+)
+
+var ErrIncompatibleProducerConfig = errors.New("incompatible producer config")
+
+// NewProducerConfig chứa cấu hình dùng chung cho sync và async producer.
+// Khi một broker chết, sarama sẽ retry và lấy lại metadata để gửi sang leader mới.
+func NewProducerConfig(cfg *config.Config) (*sarama.Config, error) {
+	// retry của async producer có thể đổi thứ tự các batch đang bay,
+	// nên chỉ cho phép idempotent producer ở chế độ sync
+	if cfg.IdempotentProducer && cfg.ProducerMode == config.ProducerModeAsync {
+		return nil, fmt.Errorf("%w: KAFKA_IDEMPOTENT_PRODUCER requires PRODUCER_MODE=%s",
+			ErrIncompatibleProducerConfig, config.ProducerModeSync)
+	}
+
+	var newPartitioner sarama.PartitionerConstructor = partitioner.NewUserPartitioner
+	if cfg.NotificationOrdering == config.OrderingFIFO {
+		newPartitioner = partitioner.NewStickySenderPartitioner
+	}
+
+	config := sarama.NewConfig()
+	config.Producer.Retry.Max = 5
+	// sarama dùng BackoffFunc cho các lần gửi lại, Retry.Backoff chỉ còn dùng khi bỏ broker leader cũ
+	config.Producer.Retry.Backoff = cfg.ProducerRetryBackoff
+	config.Producer.Retry.BackoffFunc = backoff.SaramaFunc(backoff.New(cfg.ProducerRetryStrategy,
+		cfg.ProducerRetryBackoff, cfg.ProducerRetryMaxBackoff, cfg.ProducerRetryMultiplier))
+	config.Producer.Partitioner = newPartitioner
+	acks, err := requiredAcks(cfg.ProducerRequiredAcks)
+	if err != nil {
+		return nil, err
+	}
+	config.Producer.RequiredAcks = acks
+	// gom nhiều message vào một request khi tải lớn, đánh đổi thêm độ trễ tối đa là FlushFrequency
+	config.Producer.Flush.Bytes = cfg.ProducerFlushBytes
+	config.Producer.Flush.Messages = cfg.ProducerFlushMessages
+	config.Producer.Flush.Frequency = cfg.ProducerFlushFrequency
+	// sarama tính cả key và header nên giới hạn này rộng hơn một chút so với kiểm tra payload của sender
+	config.Producer.MaxMessageBytes = cfg.KafkaMaxMessageBytes
+
+	codec, err := compressionCodec(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+	config.Producer.Compression = codec
+	if codec == sarama.CompressionZSTD {
+		// zstd chỉ được hỗ trợ từ Kafka 2.1
+		config.Version = sarama.V2_1_0_0
+	}
+
+	if cfg.IdempotentProducer {
+		// broker ghi mỗi message đúng một lần dù producer retry,
+		// sarama yêu cầu WaitForAll (ghi đè KAFKA_PRODUCER_REQUIRED_ACKS), một request đang bay và Kafka >= 0.11
+		config.Producer.Idempotent = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Net.MaxOpenRequests = 1
+		if !config.Version.IsAtLeast(sarama.V0_11_0_0) {
+			config.Version = sarama.V0_11_0_0
+		}
+	}
+
+	if err := ApplySecurity(config, cfg); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// compressionCodec chuyển tên codec (none, gzip, snappy, lz4, zstd) sang sarama.CompressionCodec.
+func compressionCodec(name string) (sarama.CompressionCodec, error) {
+	var codec sarama.CompressionCodec
+	if err := codec.UnmarshalText([]byte(name)); err != nil {
+		return sarama.CompressionNone, fmt.Errorf("unknown compression codec %q: %w", name, err)
+	}
+	return codec, nil
+}
+
+/*
+Việc cấu hình Return.Successes là một phần quan trọng trong quá trình xác nhận và đảm bảo tính nhất quán khi gửi thông điệp đến Kafka.
+Nếu không bật tùy chọn này, bạn sẽ không biết được thông điệp đã gửi thành công hay không,
+và dữ liệu có thể bị mất hoặc không nhất quán trong trường hợp lỗi.
+*/
+//
+// Kafka chưa sẵn sàng thì thử lại theo KAFKA_CONNECT_MAX_ATTEMPTS và KAFKA_CONNECT_BACKOFF, kiểm tra replication chạy sau khi đã kết nối được.
+func SetupProducer(cfg *config.Config) (sarama.SyncProducer, error) {
+	config, err := NewProducerConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup producer: %w", err)
+	}
+	config.Producer.Return.Successes = true
+	producer, err := SetupProducerWithRetry(cfg.KafkaBrokers, config, cfg.KafkaConnectAttempts, cfg.KafkaConnectBackoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup producer: %w", err)
+	}
+	if err := checkReplication(cfg, config); err != nil {
+		producer.Close()
+		return nil, fmt.Errorf("failed to setup producer: %w", err)
+	}
+	metrics.RegisterSaramaMetrics(config.MetricRegistry)
+	return producer, nil
+}
+
+// Return.Successes bật để caller (ví dụ drainAsyncProducer) đếm được số message gửi thành công,
+// Return.Errors mặc định đã là true.
+func SetupAsyncProducer(cfg *config.Config) (sarama.AsyncProducer, error) {
+	config, err := NewProducerConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup async producer: %w", err)
+	}
+	config.Producer.Return.Successes = true
+	producer, err := SetupAsyncProducerWithRetry(cfg.KafkaBrokers, config, cfg.KafkaConnectAttempts, cfg.KafkaConnectBackoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup async producer: %w", err)
+	}
+	if err := checkReplication(cfg, config); err != nil {
+		producer.Close()
+		return nil, fmt.Errorf("failed to setup async producer: %w", err)
+	}
+	metrics.RegisterSaramaMetrics(config.MetricRegistry)
+	return producer, nil
+}
@@ -0,0 +1,127 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/config"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// BenchmarkProducerFlush so sánh flush từng message với gom 1000 message một request trên sarama.MockBroker,
+// requests/op là số request gửi tới broker cho mỗi message.
+func BenchmarkProducerFlush(b *testing.B) {
+	const topic = "notifications.normal"
+	value := sarama.ByteEncoder(make([]byte, 512))
+
+	for _, flushMessages := range []int{1, 1000} {
+		b.Run(fmt.Sprintf("messages=%d", flushMessages), func(b *testing.B) {
+			broker := sarama.NewMockBroker(b, 1)
+			defer broker.Close()
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(b).
+					SetBroker(broker.Addr(), broker.BrokerID()).
+					SetLeader(topic, 0, broker.BrokerID()),
+				"ProduceRequest": sarama.NewMockProduceResponse(b),
+			})
+			// sau khi kết nối, request tới broker gần như chỉ còn ProduceRequest
+			var requests atomic.Int64
+			broker.SetNotifier(func(bytesRead, bytesWritten int) {
+				requests.Add(1)
+			})
+
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				b.Fatalf("LoadConfig() error = %v", err)
+			}
+			cfg.ProducerFlushMessages = flushMessages
+			// phần lẻ cuối cùng (b.N không chia hết cho 1000) được gửi sau FlushFrequency
+			cfg.ProducerFlushFrequency = 10 * time.Millisecond
+			producerConfig, err := NewProducerConfig(cfg)
+			if err != nil {
+				b.Fatalf("NewProducerConfig() error = %v", err)
+			}
+			producerConfig.Producer.Return.Successes = true
+			if flushMessages == 1 {
+				// không có MaxMessages thì sarama vẫn gom các message tới trong lúc chờ request trước
+				producerConfig.Producer.Flush.MaxMessages = 1
+			}
+			producer, err := sarama.NewAsyncProducer([]string{broker.Addr()}, producerConfig)
+			if err != nil {
+				b.Fatalf("NewAsyncProducer() error = %v", err)
+			}
+			defer producer.Close()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < b.N; i++ {
+					select {
+					case <-producer.Successes():
+					case err := <-producer.Errors():
+						b.Errorf("send failed: %v", err)
+					}
+				}
+			}()
+
+			requests.Store(0)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				producer.Input() <- &sarama.ProducerMessage{
+					Topic: topic,
+					Key:   sarama.StringEncoder(strconv.Itoa(i % 100)),
+					Value: value,
+				}
+			}
+			<-done
+			b.StopTimer()
+			b.ReportMetric(float64(requests.Load())/float64(b.N), "requests/op")
+		})
+	}
+}
+
+// sarama không hỗ trợ idempotent cho async producer, setup phải lỗi trước khi kết nối tới broker
+func TestSetupProducerIdempotentAsyncIncompatible(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	cfg.IdempotentProducer = true
+	cfg.ProducerMode = config.ProducerModeAsync
+	cfg.KafkaBrokers = []string{freeAddr(t)}
+
+	if _, err := SetupAsyncProducer(cfg); !errors.Is(err, ErrIncompatibleProducerConfig) {
+		t.Fatalf("SetupAsyncProducer() error = %v, want ErrIncompatibleProducerConfig", err)
+	}
+	if _, err := SetupProducer(cfg); !errors.Is(err, ErrIncompatibleProducerConfig) {
+		t.Fatalf("SetupProducer() error = %v, want ErrIncompatibleProducerConfig", err)
+	}
+}
+
+func TestNewProducerConfigIdempotent(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	cfg.IdempotentProducer = true
+	cfg.ProducerMode = config.ProducerModeSync
+	cfg.ProducerRequiredAcks = config.RequiredAcksWaitForLocal
+
+	producerConfig, err := NewProducerConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewProducerConfig() error = %v", err)
+	}
+	if !producerConfig.Producer.Idempotent || producerConfig.Producer.RequiredAcks != sarama.WaitForAll ||
+		producerConfig.Net.MaxOpenRequests != 1 {
+		t.Fatalf("idempotent = %t, acks = %d, max open requests = %d, want true, WaitForAll, 1",
+			producerConfig.Producer.Idempotent, producerConfig.Producer.RequiredAcks, producerConfig.Net.MaxOpenRequests)
+	}
+	producerConfig.Producer.Return.Successes = true
+	if err := producerConfig.Validate(); err != nil {
+		t.Fatalf("sarama rejects the idempotent config: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/config"
+
+	"github.com/IBM/sarama"
+)
+
+var ErrUnderReplicated = errors.New("topic has fewer replicas than KAFKA_MIN_ISR")
+
+// requiredAcks chuyển giá trị KAFKA_PRODUCER_REQUIRED_ACKS sang sarama.RequiredAcks.
+func requiredAcks(name string) (sarama.RequiredAcks, error) {
+	switch name {
+	case config.RequiredAcksNoResponse:
+		return sarama.NoResponse, nil
+	case config.RequiredAcksWaitForLocal:
+		return sarama.WaitForLocal, nil
+	case config.RequiredAcksWaitForAll:
+		return sarama.WaitForAll, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown required acks %q", ErrIncompatibleProducerConfig, name)
+	}
+}
+
+// checkReplication chỉ chạy khi producer dùng WaitForAll và KAFKA_MIN_ISR > 0:
+// topic có ít replica hơn min ISR thì broker (với min.insync.replicas tương ứng) sẽ từ chối mọi lần ghi,
+// còn nếu broker đặt min.insync.replicas thấp hơn thì WaitForAll chỉ chờ số replica ít ỏi đó
+// và message vẫn có thể mất. Báo lỗi lúc khởi động thay vì để lỗi lộ ra khi đang chạy.
+// Topic chưa tồn tại được bỏ qua vì sẽ được tạo với cấu hình mặc định của cluster.
+func checkReplication(cfg *config.Config, saramaConfig *sarama.Config) error {
+	if saramaConfig.Producer.RequiredAcks != sarama.WaitForAll || cfg.MinISR <= 0 {
+		return nil
+	}
+	admin, err := sarama.NewClusterAdmin(cfg.KafkaBrokers, saramaConfig)
+	if err != nil {
+		return fmt.Errorf("failed to check topic replication: %w", err)
+	}
+	defer admin.Close()
+
+	metadata, err := admin.DescribeTopics(cfg.ConsumerTopics())
+	if err != nil {
+		return fmt.Errorf("failed to check topic replication: %w", err)
+	}
+	for _, topic := range metadata {
+		if topic.Err != sarama.ErrNoError {
+			continue
+		}
+		for _, partition := range topic.Partitions {
+			if len(partition.Replicas) < cfg.MinISR {
+				return fmt.Errorf("%w: %s/%d has %d replicas, KAFKA_MIN_ISR is %d",
+					ErrUnderReplicated, topic.Name, partition.ID, len(partition.Replicas), cfg.MinISR)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package kafka
+
+import (
+	"errors"
+	"kafka-notify/pkg/config"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// newReplicationConfig trỏ producer tới sarama.MockBroker một broker,
+// mỗi partition của các topic consumer chỉ có một replica
+func newReplicationConfig(t *testing.T, minISR int) *config.Config {
+	t.Helper()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	metadata := sarama.NewMockMetadataResponse(t).
+		SetBroker(broker.Addr(), broker.BrokerID()).
+		SetController(broker.BrokerID())
+	for _, topic := range cfg.ConsumerTopics() {
+		metadata.SetLeader(topic, 0, broker.BrokerID())
+	}
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest":    metadata,
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+	})
+
+	cfg.KafkaBrokers = []string{broker.Addr()}
+	cfg.ProducerRequiredAcks = config.RequiredAcksWaitForAll
+	cfg.MinISR = minISR
+	cfg.KafkaConnectAttempts = 1
+	cfg.KafkaConnectBackoff = time.Millisecond
+	return cfg
+}
+
+func TestSetupProducerUnderReplicated(t *testing.T) {
+	_, err := SetupProducer(newReplicationConfig(t, 2))
+	if !errors.Is(err, ErrUnderReplicated) {
+		t.Fatalf("SetupProducer() error = %v, want %v", err, ErrUnderReplicated)
+	}
+}
+
+func TestSetupProducerReplicated(t *testing.T) {
+	producer, err := SetupProducer(newReplicationConfig(t, 1))
+	if err != nil {
+		t.Fatalf("SetupProducer() error = %v", err)
+	}
+	producer.Close()
+}
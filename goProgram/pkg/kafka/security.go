@@ -0,0 +1,57 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/config"
+	kafkatls "kafka-notify/pkg/tls"
+
+	"github.com/IBM/sarama"
+)
+
+var ErrUnknownSASLMechanism = errors.New("unknown SASL mechanism")
+
+// ApplySecurity bật TLS và SASL trên sarama config theo cấu hình, dùng chung cho
+// producer, consumer và các client phụ (DLQ, health check).
+func ApplySecurity(saramaConfig *sarama.Config, cfg *config.Config) error {
+	if cfg.TLS.Enabled {
+		tlsConfig, err := kafkatls.BuildTLSConfig(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.CAFile)
+		if err != nil {
+			return err
+		}
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+
+	if cfg.SASL.Enabled {
+		if err := applySASL(saramaConfig, cfg.SASL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applySASL(saramaConfig *sarama.Config, sasl config.SASLConfig) error {
+	saramaConfig.Net.SASL.Enable = true
+	saramaConfig.Net.SASL.User = sasl.Username
+	saramaConfig.Net.SASL.Password = sasl.Password
+
+	switch sasl.Mechanism {
+	case sarama.SASLTypePlaintext:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	case sarama.SASLTypeSCRAMSHA256:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &XDGSCRAMClient{HashGeneratorFcn: SHA256}
+		}
+	case sarama.SASLTypeSCRAMSHA512:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &XDGSCRAMClient{HashGeneratorFcn: SHA512}
+		}
+	default:
+		return fmt.Errorf("%w: %q (supported: %s, %s, %s)", ErrUnknownSASLMechanism, sasl.Mechanism,
+			sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512, sarama.SASLTypePlaintext)
+	}
+	return nil
+}
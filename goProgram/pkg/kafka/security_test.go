@@ -0,0 +1,180 @@
+package kafka
+
+import (
+	"errors"
+	"kafka-notify/pkg/config"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+func saslConfig(mechanism string) *config.Config {
+	return &config.Config{SASL: config.SASLConfig{
+		Enabled:   true,
+		Mechanism: mechanism,
+		Username:  "notify",
+		Password:  "s3cret",
+	}}
+}
+
+// newSASLBroker mở MockBroker chỉ cho phép các mechanism đã cho và chấp nhận SaslAuthenticate
+func newSASLBroker(t *testing.T, mechanisms ...string) *sarama.MockBroker {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest":      sarama.NewMockApiVersionsResponse(t),
+		"SaslHandshakeRequest":    sarama.NewMockSaslHandshakeResponse(t).SetEnabledMechanisms(mechanisms),
+		"SaslAuthenticateRequest": sarama.NewMockSaslAuthenticateResponse(t),
+		"MetadataRequest":         sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID()),
+	})
+	return broker
+}
+
+func newSASLClientConfig(t *testing.T, mechanism string) *sarama.Config {
+	t.Helper()
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Metadata.Retry.Max = 0
+	if err := ApplySecurity(saramaConfig, saslConfig(mechanism)); err != nil {
+		t.Fatalf("ApplySecurity() error = %v", err)
+	}
+	return saramaConfig
+}
+
+// saslRequests trả về các request SASL broker đã nhận theo thứ tự
+func saslRequests(broker *sarama.MockBroker) ([]*sarama.SaslHandshakeRequest, []*sarama.SaslAuthenticateRequest) {
+	var handshakes []*sarama.SaslHandshakeRequest
+	var authenticates []*sarama.SaslAuthenticateRequest
+	for _, rr := range broker.History() {
+		switch req := rr.Request.(type) {
+		case *sarama.SaslHandshakeRequest:
+			handshakes = append(handshakes, req)
+		case *sarama.SaslAuthenticateRequest:
+			authenticates = append(authenticates, req)
+		}
+	}
+	return handshakes, authenticates
+}
+
+func TestApplySecurityPlainHandshake(t *testing.T) {
+	broker := newSASLBroker(t, sarama.SASLTypePlaintext)
+
+	client, err := sarama.NewClient([]string{broker.Addr()}, newSASLClientConfig(t, sarama.SASLTypePlaintext))
+	if err != nil {
+		t.Fatalf("client with SASL/PLAIN failed: %v", err)
+	}
+	client.Close()
+
+	handshakes, authenticates := saslRequests(broker)
+	if len(handshakes) == 0 || handshakes[0].Mechanism != sarama.SASLTypePlaintext {
+		t.Fatalf("handshakes = %+v, want mechanism %s", handshakes, sarama.SASLTypePlaintext)
+	}
+	if len(authenticates) == 0 || string(authenticates[0].SaslAuthBytes) != "\x00notify\x00s3cret" {
+		t.Fatalf("authenticate requests = %+v, want PLAIN credentials", authenticates)
+	}
+}
+
+// MockBroker chỉ trả lời cố định nên không tính được server-first của SCRAM,
+// ở đây chỉ kiểm tra client chọn đúng mechanism và gửi client-first message
+func TestApplySecuritySCRAMHandshakeStarts(t *testing.T) {
+	for _, mechanism := range []sarama.SASLMechanism{sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512} {
+		mechanism := mechanism
+		t.Run(string(mechanism), func(t *testing.T) {
+			broker := newSASLBroker(t, string(mechanism))
+
+			client, err := sarama.NewClient([]string{broker.Addr()}, newSASLClientConfig(t, string(mechanism)))
+			if err == nil {
+				client.Close()
+				t.Fatal("SCRAM against a broker without a SCRAM server succeeded")
+			}
+
+			handshakes, authenticates := saslRequests(broker)
+			if len(handshakes) == 0 || handshakes[0].Mechanism != string(mechanism) {
+				t.Fatalf("handshakes = %+v, want mechanism %s", handshakes, mechanism)
+			}
+			if len(authenticates) == 0 || !strings.HasPrefix(string(authenticates[0].SaslAuthBytes), "n,,n=notify,r=") {
+				t.Fatalf("authenticate requests = %+v, want SCRAM client-first message", authenticates)
+			}
+		})
+	}
+}
+
+// scramServer tạo server SCRAM của xdg-go/scram biết credential của user notify
+func scramServer(t *testing.T, hash scram.HashGeneratorFcn, password string) *scram.ServerConversation {
+	t.Helper()
+	client, err := hash.NewClient("notify", password, "")
+	if err != nil {
+		t.Fatalf("failed to create SCRAM client: %v", err)
+	}
+	credentials := client.GetStoredCredentials(scram.KeyFactors{Salt: "kafka-notify-salt", Iters: 4096})
+	server, err := hash.NewServer(func(user string) (scram.StoredCredentials, error) {
+		if user != "notify" {
+			return scram.StoredCredentials{}, errors.New("unknown user")
+		}
+		return credentials, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to create SCRAM server: %v", err)
+	}
+	return server.NewConversation()
+}
+
+// runSCRAM chạy trọn cuộc hội thoại SCRAM giữa client sarama lấy từ ApplySecurity và server
+func runSCRAM(saramaConfig *sarama.Config, server *scram.ServerConversation) error {
+	client := saramaConfig.Net.SASL.SCRAMClientGeneratorFunc()
+	if err := client.Begin(saramaConfig.Net.SASL.User, saramaConfig.Net.SASL.Password, ""); err != nil {
+		return err
+	}
+	challenge := ""
+	for !client.Done() {
+		response, err := client.Step(challenge)
+		if err != nil {
+			return err
+		}
+		if client.Done() {
+			break
+		}
+		if challenge, err = server.Step(response); err != nil {
+			return err
+		}
+	}
+	if !server.Valid() {
+		return errors.New("server did not validate the client")
+	}
+	return nil
+}
+
+func TestApplySecuritySCRAMConversation(t *testing.T) {
+	tests := []struct {
+		mechanism sarama.SASLMechanism
+		hash      scram.HashGeneratorFcn
+	}{
+		{sarama.SASLTypeSCRAMSHA256, scram.SHA256},
+		{sarama.SASLTypeSCRAMSHA512, scram.SHA512},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.mechanism), func(t *testing.T) {
+			saramaConfig := newSASLClientConfig(t, string(tt.mechanism))
+			if !saramaConfig.Net.SASL.Enable || saramaConfig.Net.SASL.Mechanism != tt.mechanism {
+				t.Fatalf("SASL = %+v, want enabled with %s", saramaConfig.Net.SASL, tt.mechanism)
+			}
+
+			if err := runSCRAM(saramaConfig, scramServer(t, tt.hash, "s3cret")); err != nil {
+				t.Fatalf("SCRAM conversation failed: %v", err)
+			}
+			if err := runSCRAM(saramaConfig, scramServer(t, tt.hash, "other")); err == nil {
+				t.Fatal("SCRAM with a wrong password succeeded")
+			}
+		})
+	}
+}
+
+func TestApplySecurityUnknownMechanism(t *testing.T) {
+	err := ApplySecurity(sarama.NewConfig(), saslConfig("GSSAPI-ish"))
+	if !errors.Is(err, ErrUnknownSASLMechanism) {
+		t.Fatalf("error = %v, want ErrUnknownSASLMechanism", err)
+	}
+}
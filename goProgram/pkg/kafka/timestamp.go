@@ -0,0 +1,31 @@
+package kafka
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// HeaderProducerTimestamp chứa thời điểm producer tạo message (Unix timestamp, nano giây),
+// consumer dùng để đo độ trễ end-to-end. Producer và consumer chạy trên máy khác nhau
+// nên kết quả phụ thuộc vào độ lệch đồng hồ giữa hai máy.
+const HeaderProducerTimestamp = "X-Producer-Timestamp"
+
+// ProducerTimestampHeader tạo header X-Producer-Timestamp với thời điểm now.
+func ProducerTimestampHeader(now time.Time) sarama.RecordHeader {
+	return Header(HeaderProducerTimestamp, strconv.FormatInt(now.UnixNano(), 10))
+}
+
+// ProducerTimestamp đọc header X-Producer-Timestamp, ok false nếu header không có hoặc không parse được.
+func ProducerTimestamp(headers []*sarama.RecordHeader) (time.Time, bool) {
+	value := HeaderValue(headers, HeaderProducerTimestamp)
+	if value == "" {
+		return time.Time{}, false
+	}
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, nanos), true
+}
@@ -0,0 +1,80 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/config"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+var ErrTransactionAborted = errors.New("kafka transaction aborted")
+
+// SetupTransactionalProducer tạo producer có Producer.Transaction.ID,
+// transaction yêu cầu idempotent producer nên các điều kiện của idempotent cũng được bật.
+func SetupTransactionalProducer(cfg *config.Config, transactionID string) (*TransactionalProducer, error) {
+	config, err := NewProducerConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup transactional producer: %w", err)
+	}
+	config.Producer.Return.Successes = true
+	config.Producer.Transaction.ID = transactionID
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Net.MaxOpenRequests = 1
+	if !config.Version.IsAtLeast(sarama.V0_11_0_0) {
+		config.Version = sarama.V0_11_0_0
+	}
+	if err := checkReplication(cfg, config); err != nil {
+		return nil, fmt.Errorf("failed to setup transactional producer: %w", err)
+	}
+
+	producer, err := sarama.NewSyncProducer(cfg.KafkaBrokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup transactional producer: %w", err)
+	}
+	return NewTransactionalProducer(producer), nil
+}
+
+// NewTransactionalProducer bọc producer đã có Producer.Transaction.ID (hoặc mock transactional trong test).
+func NewTransactionalProducer(producer sarama.SyncProducer) *TransactionalProducer {
+	return &TransactionalProducer{producer: producer}
+}
+
+// TransactionalProducer gửi cả nhóm message trong một Kafka transaction:
+// consumer (isolation read_committed) thấy tất cả hoặc không thấy message nào.
+type TransactionalProducer struct {
+	// một producer chỉ có một transaction tại một thời điểm, mu tuần tự hoá các lần gửi
+	mu       sync.Mutex
+	producer sarama.SyncProducer
+}
+
+// SendMessages gửi msgs trong một transaction, lỗi bất kỳ sẽ abort cả transaction
+// và được wrap bằng ErrTransactionAborted.
+func (p *TransactionalProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.producer.BeginTxn(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := p.producer.SendMessages(msgs); err != nil {
+		return p.abort(err)
+	}
+	if err := p.producer.CommitTxn(); err != nil {
+		return p.abort(err)
+	}
+	return nil
+}
+
+func (p *TransactionalProducer) abort(cause error) error {
+	if err := p.producer.AbortTxn(); err != nil {
+		return fmt.Errorf("%w: %w (abort failed: %w)", ErrTransactionAborted, cause, err)
+	}
+	return fmt.Errorf("%w: %w", ErrTransactionAborted, cause)
+}
+
+func (p *TransactionalProducer) Close() error {
+	return p.producer.Close()
+}
@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"errors"
+	"kafka-notify/pkg/mock"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func newTxnMessages(topics ...string) []*sarama.ProducerMessage {
+	msgs := make([]*sarama.ProducerMessage, len(topics))
+	for i, topic := range topics {
+		msgs[i] = &sarama.ProducerMessage{Topic: topic, Value: sarama.StringEncoder("hello")}
+	}
+	return msgs
+}
+
+// message thứ ba lỗi thì transaction bị abort, hai message trước đó cũng không được commit
+func TestTransactionalProducerAbortsMidBatch(t *testing.T) {
+	producer := mock.NewSyncProducer()
+	producer.Transactional = true
+	producer.ErrorFor = func(topic string) error {
+		if topic == "notifications.critical" {
+			return sarama.ErrNotEnoughReplicas
+		}
+		return nil
+	}
+	txn := NewTransactionalProducer(producer)
+
+	err := txn.SendMessages(newTxnMessages("notifications.normal", "notifications.normal",
+		"notifications.critical", "notifications.normal"))
+	var producerErrs sarama.ProducerErrors
+	if !errors.Is(err, ErrTransactionAborted) || !errors.As(err, &producerErrs) ||
+		len(producerErrs) != 1 || producerErrs[0].Msg.Topic != "notifications.critical" {
+		t.Fatalf("SendMessages() error = %v, want ErrTransactionAborted wrapping the failed message", err)
+	}
+	if got := len(producer.Messages()); got != 0 {
+		t.Fatalf("%d messages committed, want none", got)
+	}
+	if producer.TxnStatus() != sarama.ProducerTxnFlagReady {
+		t.Fatalf("transaction status = %v, want ready for the next batch", producer.TxnStatus())
+	}
+
+	producer.ErrorFor = nil
+	if err := txn.SendMessages(newTxnMessages("notifications.normal", "notifications.critical")); err != nil {
+		t.Fatalf("SendMessages() after abort error = %v", err)
+	}
+	if got := len(producer.Messages()); got != 2 {
+		t.Fatalf("%d messages committed, want 2", got)
+	}
+}
@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// HeaderMessageTTL chứa thời điểm hết hạn (Unix timestamp, giây) của message,
+// consumer bỏ qua message đã quá hạn thay vì giao notification cũ cho user.
+const HeaderMessageTTL = "X-Message-TTL"
+
+// TTLHeader tạo header hết hạn sau ttl kể từ now.
+func TTLHeader(now time.Time, ttl time.Duration) sarama.RecordHeader {
+	return Header(HeaderMessageTTL, strconv.FormatInt(now.Add(ttl).Unix(), 10))
+}
+
+// IsExpired trả về true nếu message có header X-Message-TTL và now đã qua deadline.
+// Header không có hoặc không parse được thì coi như message không hết hạn.
+func IsExpired(headers []*sarama.RecordHeader, now time.Time) bool {
+	deadline, ok := Deadline(headers)
+	return ok && now.Unix() > deadline.Unix()
+}
+
+// Deadline đọc thời điểm hết hạn trong header X-Message-TTL, ok false nếu header không có hoặc không parse được.
+func Deadline(headers []*sarama.RecordHeader) (time.Time, bool) {
+	value := HeaderValue(headers, HeaderMessageTTL)
+	if value == "" {
+		return time.Time{}, false
+	}
+	deadline, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(deadline, 0), true
+}
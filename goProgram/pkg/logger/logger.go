@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Logger là logger dùng chung cho toàn bộ service, cấu hình lại bằng Setup.
+var Logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
+
+// Setup cấu hình Logger theo LOG_LEVEL (debug, info, warn, error...) và
+// LOG_FORMAT (json cho Datadog/ELK, console cho chạy local).
+func Setup(level, format string) error {
+	parsedLevel, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	switch format {
+	case FormatJSON:
+		Logger = zerolog.New(os.Stdout)
+	case FormatConsole:
+		Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
+	default:
+		return fmt.Errorf("invalid log format %q", format)
+	}
+	Logger = Logger.Level(parsedLevel).With().Timestamp().Logger()
+	return nil
+}
+
+// Component trả về logger gắn sẵn field component, gọi sau Setup.
+func Component(name string) zerolog.Logger {
+	return Logger.With().Str("component", name).Logger()
+}
+
+// WithContext trả về logger gắn trong ctx (qua zerolog.Logger.WithContext),
+// nếu ctx chưa có logger thì dùng Logger.
+func WithContext(ctx context.Context) zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return *l
+	}
+	return Logger
+}
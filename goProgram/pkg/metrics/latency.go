@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// latencyWindowSize là số mẫu gần nhất dùng để tính percentile cho GET /metrics/latency
+const latencyWindowSize = 1024
+
+// E2ELatency là độ trễ từ lúc producer tạo message (X-Producer-Timestamp) tới lúc consumer nhận,
+// bucket từ 1ms tới 10s.
+var E2ELatency = promauto.NewHistogram(prometheus.HistogramOpts{
+	Name:    "kafka_e2e_latency_nanoseconds",
+	Help:    "Time from the producer building a notification to the consumer receiving it.",
+	Buckets: prometheus.ExponentialBucketsRange(float64(time.Millisecond), float64(10*time.Second), 14),
+})
+
+var e2eWindow = latencyWindow{samples: make([]time.Duration, latencyWindowSize)}
+
+// LatencySummary là percentile của các mẫu độ trễ gần nhất, JSON là số nano giây.
+type LatencySummary struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+	P99   time.Duration `json:"p99"`
+}
+
+// ObserveE2ELatency ghi lại độ trễ của message được tạo lúc producedAt.
+// Độ trễ không dương (đồng hồ hai máy lệch nhau) bị bỏ qua.
+func ObserveE2ELatency(producedAt time.Time) {
+	latency := time.Since(producedAt)
+	if latency <= 0 {
+		return
+	}
+	E2ELatency.Observe(float64(latency.Nanoseconds()))
+	e2eWindow.add(latency)
+}
+
+// E2ELatencySummary trả về p50/p95/p99 của tối đa latencyWindowSize mẫu gần nhất,
+// histogram Prometheus chỉ ước lượng được percentile theo bucket.
+func E2ELatencySummary() LatencySummary {
+	return e2eWindow.summary()
+}
+
+// latencyWindow là ring buffer giữ các mẫu gần nhất
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	count   int
+}
+
+func (w *latencyWindow) add(latency time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.samples[w.next] = latency
+	w.next = (w.next + 1) % len(w.samples)
+	if w.count < len(w.samples) {
+		w.count++
+	}
+}
+
+func (w *latencyWindow) summary() LatencySummary {
+	w.mu.Lock()
+	sorted := append([]time.Duration(nil), w.samples[:w.count]...)
+	w.mu.Unlock()
+	if len(sorted) == 0 {
+		return LatencySummary{}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return LatencySummary{
+		Count: len(sorted),
+		P50:   percentile(sorted, 0.50),
+		P95:   percentile(sorted, 0.95),
+		P99:   percentile(sorted, 0.99),
+	}
+}
+
+// percentile theo nearest-rank trên sorted đã sắp xếp tăng dần
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
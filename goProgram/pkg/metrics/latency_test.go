@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyWindowSummary(t *testing.T) {
+	window := latencyWindow{samples: make([]time.Duration, 100)}
+	if got := window.summary(); got != (LatencySummary{}) {
+		t.Fatalf("empty summary = %+v, want zero", got)
+	}
+	for i := 100; i >= 1; i-- {
+		window.add(time.Duration(i) * time.Millisecond)
+	}
+	want := LatencySummary{Count: 100, P50: 50 * time.Millisecond, P95: 95 * time.Millisecond, P99: 99 * time.Millisecond}
+	if got := window.summary(); got != want {
+		t.Fatalf("summary = %+v, want %+v", got, want)
+	}
+
+	// ring buffer đầy thì mẫu cũ nhất bị thay, chỉ còn 100 mẫu 1s
+	for i := 0; i < 100; i++ {
+		window.add(time.Second)
+	}
+	if got := window.summary(); got.Count != 100 || got.P50 != time.Second {
+		t.Fatalf("summary after wrap = %+v, want 100 samples of 1s", got)
+	}
+}
+
+func TestObserveE2ELatencySkipsClockSkew(t *testing.T) {
+	before := E2ELatencySummary().Count
+	// producer có đồng hồ chạy nhanh hơn consumer
+	ObserveE2ELatency(time.Now().Add(time.Minute))
+	if got := E2ELatencySummary().Count; got != before {
+		t.Fatalf("samples = %d, want %d (negative latency skipped)", got, before)
+	}
+	ObserveE2ELatency(time.Now().Add(-time.Millisecond))
+	if got := E2ELatencySummary(); got.Count != before+1 || got.P50 <= 0 {
+		t.Fatalf("summary = %+v, want one more sample > 0", got)
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+// Label cardinality: kafka_messages_sent_total chỉ có label status với đúng hai
+// giá trị "success" và "error". KHÔNG thêm label có cardinality cao như topic,
+// user ID hay message key, mỗi giá trị label mới tạo ra một time series riêng
+// trong Prometheus và sẽ làm phình bộ nhớ rất nhanh.
+var (
+	MessagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_sent_total",
+		Help: "Total number of notifications sent to Kafka, by status.",
+	}, []string{"status"})
+
+	SendDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kafka_send_duration_seconds",
+		Help:    "Time taken to send a notification to Kafka.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// ProducerErrorsInFlight là số lần gửi lỗi liên tiếp kể từ lần gửi thành công gần nhất,
+	// giá trị > 0 kéo dài nghĩa là broker đang có vấn đề.
+	ProducerErrorsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "kafka_producer_errors_in_flight",
+		Help: "Consecutive failed sends since the last successful send.",
+	})
+
+	// MessagesExpired đếm số message consumer bỏ qua vì đã quá X-Message-TTL.
+	MessagesExpired = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kafka_messages_expired_total",
+		Help: "Total number of consumed messages skipped because their TTL had passed.",
+	})
+
+	// MessagesFiltered đếm số notification consumer không giao vì UserPreferences của người nhận,
+	// label reason chỉ có muted, sender hoặc priority.
+	MessagesFiltered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_filtered_total",
+		Help: "Total number of consumed notifications skipped by recipient preferences, by reason.",
+	}, []string{"reason"})
+
+	// ConsumerGroupLag là lag của consumer group trên từng partition. topic chỉ gồm
+	// các priority topic cố định và partition bị giới hạn bởi số partition của topic,
+	// nên cardinality vẫn nhỏ.
+	ConsumerGroupLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_group_lag",
+		Help: "Messages not yet committed by the consumer group, by topic and partition.",
+	}, []string{"topic", "partition"})
+)
+
+// ObserveSend ghi lại kết quả của một lần gửi bắt đầu từ start.
+func ObserveSend(start time.Time, err error) {
+	SendDuration.Observe(time.Since(start).Seconds())
+	RecordResult(err)
+}
+
+// RecordResult cập nhật counter và gauge theo kết quả gửi, dùng khi không đo được thời gian
+// (ví dụ kết quả của async producer).
+func RecordResult(err error) {
+	if err != nil {
+		MessagesSent.WithLabelValues(StatusError).Inc()
+		ProducerErrorsInFlight.Inc()
+		return
+	}
+	MessagesSent.WithLabelValues(StatusSuccess).Inc()
+	ProducerErrorsInFlight.Set(0)
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+const saramaNamespace = "kafka_producer"
+
+// quantile của histogram sarama được xuất ra summary, go-metrics chỉ giữ một mẫu
+// các giá trị nên không dựng lại được bucket của Prometheus histogram
+var saramaQuantiles = []float64{0.5, 0.75, 0.95, 0.99}
+
+var (
+	saramaRegistry atomic.Pointer[gometrics.Registry]
+	registerBridge sync.Once
+)
+
+// RegisterSaramaMetrics xuất các metric của sarama.Config.MetricRegistry ra /metrics với tiền tố
+// kafka_producer_, ví dụ request-latency-in-ms thành kafka_producer_request_latency_in_ms.
+// Gọi lại với registry khác thì registry mới thay registry cũ, mỗi process chỉ xuất một producer.
+//
+// Chỉ xuất metric tổng, bỏ qua các bản theo broker hoặc topic (…-for-broker-N, …-for-topic-X)
+// vì topic được đặt theo tenant, xem ghi chú về cardinality ở metrics.go.
+func RegisterSaramaMetrics(registry gometrics.Registry) {
+	saramaRegistry.Store(&registry)
+	registerBridge.Do(func() {
+		prometheus.MustRegister(saramaCollector{})
+	})
+}
+
+// saramaCollector đọc registry mỗi lần Prometheus scrape, metric mới sarama tạo sau khi
+// kết nối broker vẫn được xuất mà không cần đăng ký lại.
+type saramaCollector struct{}
+
+// Describe không gửi descriptor nào, tên metric chỉ biết được lúc Collect
+// nên collector được đăng ký dạng unchecked.
+func (saramaCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (saramaCollector) Collect(ch chan<- prometheus.Metric) {
+	registry := saramaRegistry.Load()
+	if registry == nil {
+		return
+	}
+	(*registry).Each(func(name string, metric interface{}) {
+		if strings.Contains(name, "-for-broker-") || strings.Contains(name, "-for-topic-") {
+			return
+		}
+		fqName := saramaNamespace + "_" + strings.ReplaceAll(name, "-", "_")
+		switch m := metric.(type) {
+		case gometrics.Meter:
+			// tên của meter trong sarama đã là ...-rate, xuất tốc độ trung bình một phút
+			desc := prometheus.NewDesc(fqName, "Sarama meter "+name+" (one-minute rate per second).", nil, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, m.Snapshot().Rate1())
+		case gometrics.Histogram:
+			snapshot := m.Snapshot()
+			values := snapshot.Percentiles(saramaQuantiles)
+			quantiles := make(map[float64]float64, len(saramaQuantiles))
+			for i, q := range saramaQuantiles {
+				quantiles[q] = values[i]
+			}
+			desc := prometheus.NewDesc(fqName, "Sarama histogram "+name+".", nil, nil)
+			ch <- prometheus.MustNewConstSummary(desc, uint64(snapshot.Count()), float64(snapshot.Sum()), quantiles)
+		case gometrics.Counter:
+			// sarama dùng counter cho giá trị tăng giảm như requests-in-flight
+			desc := prometheus.NewDesc(fqName, "Sarama counter "+name+".", nil, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(m.Count()))
+		case gometrics.Gauge:
+			desc := prometheus.NewDesc(fqName, "Sarama gauge "+name+".", nil, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(m.Value()))
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestRegisterSaramaMetrics(t *testing.T) {
+	const topic = "notifications.normal"
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(topic, 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t),
+	})
+
+	config := sarama.NewConfig()
+	config.Producer.Return.Successes = true
+	producer, err := sarama.NewSyncProducer([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatalf("NewSyncProducer() error = %v", err)
+	}
+	defer producer.Close()
+	// metric request/byte của sarama chỉ có sau khi producer gửi request tới broker
+	if _, _, err := producer.SendMessage(&sarama.ProducerMessage{Topic: topic, Value: sarama.StringEncoder("hello")}); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	RegisterSaramaMetrics(config.MetricRegistry)
+
+	recorder := httptest.NewRecorder()
+	promhttp.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(recorder.Body)
+	for _, name := range []string{
+		"kafka_producer_request_latency_in_ms",
+		"kafka_producer_outgoing_byte_rate",
+		"kafka_producer_request_rate",
+		"kafka_producer_record_send_rate",
+	} {
+		if !strings.Contains(string(body), "\n"+name) {
+			t.Errorf("/metrics is missing %s", name)
+		}
+	}
+	// metric theo broker hoặc topic bị bỏ qua
+	if strings.Contains(string(body), "_for_broker_") || strings.Contains(string(body), "_for_topic_") {
+		t.Error("/metrics exports per-broker or per-topic sarama metrics")
+	}
+}
@@ -0,0 +1,197 @@
+package mock
+
+import (
+	"context"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// độ lớn mặc định của hàng đợi Feed
+const defaultFeedBuffer = 1024
+
+// ConsumerGroup là sarama.ConsumerGroup nhận message từ Feed thay vì từ broker.
+// Mỗi topic được subscribe là một claim (partition 0) chạy trong goroutine riêng như consumer thật,
+// message của topic không được subscribe bị bỏ qua. Offset được mark lưu lại để kiểm tra bằng MarkedOffset.
+type ConsumerGroup struct {
+	feed      chan *sarama.ConsumerMessage
+	errors    chan error
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu     sync.Mutex
+	marked map[string]map[int32]int64
+}
+
+func NewConsumerGroup() *ConsumerGroup {
+	return &ConsumerGroup{
+		feed:   make(chan *sarama.ConsumerMessage, defaultFeedBuffer),
+		errors: make(chan error),
+		closed: make(chan struct{}),
+		marked: make(map[string]map[int32]int64),
+	}
+}
+
+// Feed đưa message vào hàng đợi, message được giao cho session Consume hiện tại hoặc kế tiếp.
+// Feed chặn khi hàng đợi đã đầy.
+func (g *ConsumerGroup) Feed(msgs ...*sarama.ConsumerMessage) {
+	for _, msg := range msgs {
+		g.feed <- msg
+	}
+}
+
+// MarkedOffset trả về offset đã được mark gần nhất (offset của message kế tiếp cần đọc).
+func (g *ConsumerGroup) MarkedOffset(topic string, partition int32) (int64, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	offset, ok := g.marked[topic][partition]
+	return offset, ok
+}
+
+// Consume chạy một session cho tới khi ctx bị huỷ hoặc Close được gọi.
+func (g *ConsumerGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	select {
+	case <-g.closed:
+		return sarama.ErrClosedConsumerGroup
+	default:
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	session := &consumerGroupSession{group: g, ctx: ctx, topics: topics}
+	if err := handler.Setup(session); err != nil {
+		return err
+	}
+
+	claims := make(map[string]*consumerGroupClaim, len(topics))
+	errs := make(chan error, len(topics))
+	var wg sync.WaitGroup
+	for _, topic := range topics {
+		claim := &consumerGroupClaim{topic: topic, messages: make(chan *sarama.ConsumerMessage)}
+		claims[topic] = claim
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// ConsumeClaim trả về lỗi thì kết thúc cả session, giống consumer thật
+			if err := handler.ConsumeClaim(session, claim); err != nil {
+				errs <- err
+				cancel()
+			}
+		}()
+	}
+
+	g.dispatch(ctx, claims)
+	for _, claim := range claims {
+		close(claim.messages)
+	}
+	wg.Wait()
+	if err := handler.Cleanup(session); err != nil {
+		return err
+	}
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// dispatch chuyển message từ Feed sang claim của topic cho tới khi session kết thúc.
+func (g *ConsumerGroup) dispatch(ctx context.Context, claims map[string]*consumerGroupClaim) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-g.closed:
+			return
+		case msg := <-g.feed:
+			claim, ok := claims[msg.Topic]
+			if !ok {
+				continue
+			}
+			select {
+			case claim.messages <- msg:
+			case <-ctx.Done():
+				return
+			case <-g.closed:
+				return
+			}
+		}
+	}
+}
+
+func (g *ConsumerGroup) mark(topic string, partition int32, offset int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.marked[topic] == nil {
+		g.marked[topic] = make(map[int32]int64)
+	}
+	if offset > g.marked[topic][partition] {
+		g.marked[topic][partition] = offset
+	}
+}
+
+func (g *ConsumerGroup) Errors() <-chan error {
+	return g.errors
+}
+
+func (g *ConsumerGroup) Close() error {
+	g.closeOnce.Do(func() { close(g.closed) })
+	return nil
+}
+
+func (g *ConsumerGroup) Pause(map[string][]int32) {}
+
+func (g *ConsumerGroup) Resume(map[string][]int32) {}
+
+func (g *ConsumerGroup) PauseAll() {}
+
+func (g *ConsumerGroup) ResumeAll() {}
+
+type consumerGroupSession struct {
+	group  *ConsumerGroup
+	ctx    context.Context
+	topics []string
+}
+
+func (s *consumerGroupSession) Claims() map[string][]int32 {
+	claims := make(map[string][]int32, len(s.topics))
+	for _, topic := range s.topics {
+		claims[topic] = []int32{0}
+	}
+	return claims
+}
+
+func (s *consumerGroupSession) MemberID() string { return "mock-member" }
+
+func (s *consumerGroupSession) GenerationID() int32 { return 1 }
+
+func (s *consumerGroupSession) MarkOffset(topic string, partition int32, offset int64, _ string) {
+	s.group.mark(topic, partition, offset)
+}
+
+func (s *consumerGroupSession) Commit() {}
+
+func (s *consumerGroupSession) ResetOffset(string, int32, int64, string) {}
+
+// MarkMessage mark offset của message kế tiếp, giống sarama.
+func (s *consumerGroupSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+func (s *consumerGroupSession) Context() context.Context { return s.ctx }
+
+type consumerGroupClaim struct {
+	topic    string
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *consumerGroupClaim) Topic() string { return c.topic }
+
+func (c *consumerGroupClaim) Partition() int32 { return 0 }
+
+func (c *consumerGroupClaim) InitialOffset() int64 { return sarama.OffsetOldest }
+
+func (c *consumerGroupClaim) HighWaterMarkOffset() int64 { return 0 }
+
+func (c *consumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
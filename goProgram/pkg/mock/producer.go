@@ -0,0 +1,153 @@
+package mock
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+var ErrClosed = errors.New("mock producer is closed")
+
+// SyncProducer là sarama.SyncProducer giữ message trong bộ nhớ thay vì gửi lên broker,
+// dùng để chạy handler của producer mà không cần Kafka. Mỗi topic chỉ có partition 0,
+// offset tăng dần từ 0. An toàn khi gọi đồng thời.
+type SyncProducer struct {
+	// ErrorFor khác nil thì được gọi trước mỗi lần gửi, lỗi trả về được trả cho caller
+	// và message không được ghi nhận. Dùng để giả lập topic không tồn tại, broker lỗi...
+	ErrorFor func(topic string) error
+	// Transactional bật các method transaction, message trong transaction chỉ
+	// xuất hiện trong Messages sau CommitTxn
+	Transactional bool
+
+	mu       sync.Mutex
+	messages []*sarama.ProducerMessage
+	offsets  map[string]int64
+	pending  []*sarama.ProducerMessage
+	inTxn    bool
+	closed   bool
+}
+
+func NewSyncProducer() *SyncProducer {
+	return &SyncProducer{offsets: make(map[string]int64)}
+}
+
+// Messages trả về bản sao các message đã gửi thành công theo thứ tự gửi.
+func (p *SyncProducer) Messages() []*sarama.ProducerMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*sarama.ProducerMessage(nil), p.messages...)
+}
+
+func (p *SyncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.send(msg); err != nil {
+		return 0, 0, err
+	}
+	return msg.Partition, msg.Offset, nil
+}
+
+// SendMessages trả về sarama.ProducerErrors chứa các message gửi lỗi, giống producer thật.
+func (p *SyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs sarama.ProducerErrors
+	for _, msg := range msgs {
+		if err := p.send(msg); err != nil {
+			errs = append(errs, &sarama.ProducerError{Msg: msg, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// send gán partition/offset cho msg và ghi nhận nó, p.mu phải đang được giữ.
+func (p *SyncProducer) send(msg *sarama.ProducerMessage) error {
+	if p.closed {
+		return ErrClosed
+	}
+	if p.Transactional && !p.inTxn {
+		return sarama.ErrTransactionNotReady
+	}
+	if p.ErrorFor != nil {
+		if err := p.ErrorFor(msg.Topic); err != nil {
+			return err
+		}
+	}
+	msg.Partition = 0
+	msg.Offset = p.offsets[msg.Topic]
+	p.offsets[msg.Topic]++
+	if p.inTxn {
+		p.pending = append(p.pending, msg)
+		return nil
+	}
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func (p *SyncProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func (p *SyncProducer) TxnStatus() sarama.ProducerTxnStatusFlag {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inTxn {
+		return sarama.ProducerTxnFlagInTransaction
+	}
+	return sarama.ProducerTxnFlagReady
+}
+
+func (p *SyncProducer) IsTransactional() bool {
+	return p.Transactional
+}
+
+func (p *SyncProducer) BeginTxn() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.Transactional {
+		return sarama.ErrNonTransactedProducer
+	}
+	if p.inTxn {
+		return sarama.ErrTransactionNotReady
+	}
+	p.inTxn = true
+	return nil
+}
+
+func (p *SyncProducer) CommitTxn() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.inTxn {
+		return sarama.ErrTransactionNotReady
+	}
+	p.messages = append(p.messages, p.pending...)
+	p.pending = nil
+	p.inTxn = false
+	return nil
+}
+
+func (p *SyncProducer) AbortTxn() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.inTxn {
+		return sarama.ErrTransactionNotReady
+	}
+	p.pending = nil
+	p.inTxn = false
+	return nil
+}
+
+func (p *SyncProducer) AddOffsetsToTxn(map[string][]*sarama.PartitionOffsetMetadata, string) error {
+	return nil
+}
+
+func (p *SyncProducer) AddMessageToTxn(*sarama.ConsumerMessage, string, *string) error {
+	return nil
+}
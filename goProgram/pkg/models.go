@@ -1,12 +0,0 @@
-package pkg
-
-type User struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
-}
-
-type Notification struct {
-	From    User   `json:"from"`
-	To      User   `json:"to"`
-	Message string `json:"message"`
-}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Ack là xác nhận người nhận UserID đã đọc notification NotificationID (mark-as-read).
+// Khác Receipt (consumer tự gửi khi giao), Ack do chính người nhận gửi qua POST /notifications/:id/ack.
+type Ack struct {
+	NotificationID string    `json:"notificationID"`
+	UserID         int       `json:"userID"`
+	AckedAt        time.Time `json:"ackedAt"`
+}
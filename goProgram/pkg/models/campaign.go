@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidCampaign = errors.New("invalid campaign")
+
+// Trạng thái của Campaign: scheduled -> running -> completed hoặc failed.
+const (
+	CampaignStatusScheduled = "scheduled"
+	CampaignStatusRunning   = "running"
+	CampaignStatusCompleted = "completed"
+	CampaignStatusFailed    = "failed"
+)
+
+// Campaign gửi cùng một Message từ FromID tới danh sách TargetUserIDs khi tới ScheduledAt.
+// Sent/Failed là số người nhận gửi thành công/thất bại, Error là lý do khi Status là failed.
+type Campaign struct {
+	ID            string    `json:"id"`
+	FromID        int       `json:"fromID"`
+	Message       string    `json:"message"`
+	Priority      int       `json:"priority"`
+	TargetUserIDs []int     `json:"targetUserIDs"`
+	ScheduledAt   time.Time `json:"scheduledAt"`
+	Status        string    `json:"status"`
+	// TenantID là tenant tạo campaign, runner gửi trong tenant này
+	TenantID string `json:"tenantID,omitempty"`
+	Sent     int    `json:"sent"`
+	Failed   int    `json:"failed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Validate kiểm tra người gửi, nội dung, priority và danh sách người nhận.
+func (c Campaign) Validate() error {
+	if c.FromID <= 0 {
+		return fmt.Errorf("%w: fromID must be positive, got %d", ErrInvalidCampaign, c.FromID)
+	}
+	if c.Message == "" {
+		return fmt.Errorf("%w: message must not be empty", ErrInvalidCampaign)
+	}
+	if err := validatePriority(c.Priority); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCampaign, err)
+	}
+	if len(c.TargetUserIDs) == 0 {
+		return fmt.Errorf("%w: targetUserIDs must not be empty", ErrInvalidCampaign)
+	}
+	for _, id := range c.TargetUserIDs {
+		if id <= 0 {
+			return fmt.Errorf("%w: target user id must be positive, got %d", ErrInvalidCampaign, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidChannel = errors.New("invalid channel")
+
+// Channel là kênh publish-subscribe: POST /channels/:name/publish gửi notification tới mọi subscriber.
+// Type là loại notification khi publish, nên KAFKA_TOPIC_ROUTING quyết định topic của channel;
+// Type rỗng thì dùng topic theo priority.
+type Channel struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+	// TenantID do subscription store gán theo tenant của request
+	TenantID string `json:"tenantID,omitempty"`
+}
+
+// Validate kiểm tra Name không rỗng, Name và Type đều dạng snake_case như system_alert.
+func (c Channel) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: name must not be empty", ErrInvalidChannel)
+	}
+	if !validType(c.Name) {
+		return fmt.Errorf("%w: name must be at most %d lowercase letters, digits or underscores",
+			ErrInvalidChannel, maxTypeLength)
+	}
+	if !validType(c.Type) {
+		return fmt.Errorf("%w: type must be at most %d lowercase letters, digits or underscores",
+			ErrInvalidChannel, maxTypeLength)
+	}
+	return nil
+}
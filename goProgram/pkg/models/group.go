@@ -0,0 +1,30 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidGroup = errors.New("invalid group")
+
+// Group là nhóm người nhận của POST /send/group, MemberIDs là user ID trong cùng tenant.
+type Group struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	MemberIDs []int  `json:"memberIDs"`
+	// TenantID do group store gán theo tenant của request
+	TenantID string `json:"tenantID,omitempty"`
+}
+
+// Validate kiểm tra Name không rỗng và mọi member ID là số dương.
+func (g Group) Validate() error {
+	if g.Name == "" {
+		return fmt.Errorf("%w: name must not be empty", ErrInvalidGroup)
+	}
+	for _, id := range g.MemberIDs {
+		if id <= 0 {
+			return fmt.Errorf("%w: member id must be positive, got %d", ErrInvalidGroup, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrInvalidNotification = errors.New("invalid notification")
+
+// TombstoneNotification được trả về khi message là tombstone (Value nil, key là ID notification
+// cần xoá), caller so sánh cờ tombstone thay vì dùng nội dung của nó.
+var TombstoneNotification = Notification{}
+
+type Notification struct {
+	// ID là UUID sinh khi gửi, dùng để tra cứu read receipt. Message cũ có thể không có ID.
+	ID      string `json:"id,omitempty"`
+	From    User   `json:"from"`
+	To      User   `json:"to"`
+	Message string `json:"message"`
+	// Priority: 1=low, 2=normal, 3=high, 4=critical
+	Priority int `json:"priority"`
+	// Type là loại notification (ví dụ system_alert, chat_message), dùng để route sang topic riêng
+	Type string `json:"type,omitempty"`
+	// CreatedAt và CorrelationID do enrichment pipeline của producer gán
+	CreatedAt     time.Time `json:"createdAt,omitempty"`
+	CorrelationID string    `json:"correlationID,omitempty"`
+	// Metadata là thông tin tuỳ ý của client (ví dụ source=mobile), được gửi qua Kafka header
+	// X-Meta-<key> thay vì trong payload để consumer phía sau route được mà không cần decode
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// ThreadID gom các notification của cùng một cuộc hội thoại, khác rỗng thì được dùng làm
+	// Kafka key nên mọi message của thread nằm trên một partition và giữ đúng thứ tự
+	ThreadID string `json:"threadID,omitempty"`
+	// ReplyToID là ID của notification mà notification này trả lời
+	ReplyToID string `json:"replyToID,omitempty"`
+	// NewThread là true khi ThreadID vừa được producer sinh vì client không gửi threadID,
+	// ThreadKeyStrategy khi đó vẫn dùng key người nhận như trước khi có thread. Không nằm trong payload
+	NewThread bool `json:"-"`
+	// ExpiresAt do consumer gán từ header X-Message-TTL, zero là không hết hạn;
+	// expiry.Sweeper xoá notification đã quá hạn khỏi store
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+}
+
+// Validate kiểm tra người gửi, người nhận hợp lệ, không tự gửi cho chính mình
+// Message không được rỗng và Priority nằm trong khoảng hợp lệ.
+func (n Notification) Validate() error {
+	if err := n.From.Validate(); err != nil {
+		return fmt.Errorf("%w: from: %v", ErrInvalidNotification, err)
+	}
+	if err := n.To.Validate(); err != nil {
+		return fmt.Errorf("%w: to: %v", ErrInvalidNotification, err)
+	}
+	if n.From.ID == n.To.ID {
+		return fmt.Errorf("%w: sender and recipient must differ", ErrInvalidNotification)
+	}
+	if n.From.TenantID != n.To.TenantID {
+		return fmt.Errorf("%w: sender and recipient must belong to the same tenant", ErrInvalidNotification)
+	}
+	if n.Message == "" {
+		return fmt.Errorf("%w: message must not be empty", ErrInvalidNotification)
+	}
+	if err := validatePriority(n.Priority); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+	if !validType(n.Type) {
+		return fmt.Errorf("%w: type must be at most %d lowercase letters, digits or underscores",
+			ErrInvalidNotification, maxTypeLength)
+	}
+	if err := validateMetadata(n.Metadata); err != nil {
+		return fmt.Errorf("%w: metadata: %v", ErrInvalidNotification, err)
+	}
+	if len(n.ThreadID) > maxThreadIDLength || len(n.ReplyToID) > maxThreadIDLength {
+		return fmt.Errorf("%w: threadID and replyToID must be at most %d characters", ErrInvalidNotification, maxThreadIDLength)
+	}
+	return nil
+}
+
+// độ dài tối đa của ThreadID và ReplyToID (UUID là 36 ký tự)
+const maxThreadIDLength = 64
+
+// giới hạn của Notification.Metadata, mỗi entry là một Kafka header
+const (
+	maxMetadataEntries     = 20
+	maxMetadataKeyLength   = 64
+	maxMetadataValueLength = 1024
+)
+
+// validateMetadata chỉ nhận key gồm chữ, số, '_', '-', '.' để dùng được trong tên header,
+// value là chuỗi UTF-8 bất kỳ.
+func validateMetadata(metadata map[string]string) error {
+	if len(metadata) > maxMetadataEntries {
+		return fmt.Errorf("at most %d entries allowed, got %d", maxMetadataEntries, len(metadata))
+	}
+	for key, value := range metadata {
+		if key == "" || len(key) > maxMetadataKeyLength {
+			return fmt.Errorf("key must be 1 to %d characters, got %q", maxMetadataKeyLength, key)
+		}
+		for _, r := range key {
+			if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' && r != '-' && r != '.' {
+				return fmt.Errorf("key must contain only letters, digits, '_', '-' or '.', got %q", key)
+			}
+		}
+		if len(value) > maxMetadataValueLength {
+			return fmt.Errorf("value of %q must be at most %d bytes", key, maxMetadataValueLength)
+		}
+		if !utf8.ValidString(value) {
+			return fmt.Errorf("value of %q must be valid UTF-8", key)
+		}
+	}
+	return nil
+}
+
+// độ dài tối đa của Notification.Type
+const maxTypeLength = 64
+
+// validType chấp nhận type rỗng hoặc dạng snake_case như system_alert.
+func validType(notificationType string) bool {
+	if len(notificationType) > maxTypeLength {
+		return false
+	}
+	for _, r := range notificationType {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
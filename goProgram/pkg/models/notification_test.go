@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func validNotification() Notification {
+	return Notification{
+		From:     User{ID: 1, Name: "Alice"},
+		To:       User{ID: 2, Name: "Bob"},
+		Message:  "hello",
+		Priority: PriorityNormal,
+	}
+}
+
+func metadataEntries(n int) map[string]string {
+	metadata := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		metadata["key"+strconv.Itoa(i)] = "value"
+	}
+	return metadata
+}
+
+func TestNotificationValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(n *Notification)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(n *Notification) {}},
+		{name: "invalid sender", modify: func(n *Notification) { n.From.ID = 0 }, wantErr: true},
+		{name: "invalid recipient", modify: func(n *Notification) { n.To.Name = "" }, wantErr: true},
+		{name: "send to self", modify: func(n *Notification) { n.To = n.From }, wantErr: true},
+		{name: "different tenants", modify: func(n *Notification) { n.To.TenantID = "acme" }, wantErr: true},
+		{name: "same tenant", modify: func(n *Notification) { n.From.TenantID, n.To.TenantID = "acme", "acme" }},
+		{name: "empty message", modify: func(n *Notification) { n.Message = "" }, wantErr: true},
+		{name: "single character message", modify: func(n *Notification) { n.Message = "x" }},
+		{name: "priority below low", modify: func(n *Notification) { n.Priority = PriorityLow - 1 }, wantErr: true},
+		{name: "priority low", modify: func(n *Notification) { n.Priority = PriorityLow }},
+		{name: "priority critical", modify: func(n *Notification) { n.Priority = PriorityCritical }},
+		{name: "priority above critical", modify: func(n *Notification) { n.Priority = PriorityCritical + 1 }, wantErr: true},
+		{name: "snake case type", modify: func(n *Notification) { n.Type = "system_alert_2" }},
+		{name: "uppercase type", modify: func(n *Notification) { n.Type = "System" }, wantErr: true},
+		{name: "type at max length", modify: func(n *Notification) { n.Type = strings.Repeat("a", maxTypeLength) }},
+		{name: "type over max length", modify: func(n *Notification) { n.Type = strings.Repeat("a", maxTypeLength+1) }, wantErr: true},
+		{name: "metadata at max entries", modify: func(n *Notification) { n.Metadata = metadataEntries(maxMetadataEntries) }},
+		{name: "metadata over max entries", modify: func(n *Notification) { n.Metadata = metadataEntries(maxMetadataEntries + 1) }, wantErr: true},
+		{name: "empty metadata key", modify: func(n *Notification) { n.Metadata = map[string]string{"": "v"} }, wantErr: true},
+		{name: "metadata key at max length", modify: func(n *Notification) {
+			n.Metadata = map[string]string{strings.Repeat("k", maxMetadataKeyLength): "v"}
+		}},
+		{name: "metadata key over max length", modify: func(n *Notification) {
+			n.Metadata = map[string]string{strings.Repeat("k", maxMetadataKeyLength+1): "v"}
+		}, wantErr: true},
+		{name: "metadata key with space", modify: func(n *Notification) { n.Metadata = map[string]string{"order id": "v"} }, wantErr: true},
+		{name: "metadata value at max length", modify: func(n *Notification) {
+			n.Metadata = map[string]string{"k": strings.Repeat("v", maxMetadataValueLength)}
+		}},
+		{name: "metadata value over max length", modify: func(n *Notification) {
+			n.Metadata = map[string]string{"k": strings.Repeat("v", maxMetadataValueLength+1)}
+		}, wantErr: true},
+		{name: "metadata value invalid utf-8", modify: func(n *Notification) { n.Metadata = map[string]string{"k": "\xff"} }, wantErr: true},
+		{name: "thread id at max length", modify: func(n *Notification) { n.ThreadID = strings.Repeat("t", maxThreadIDLength) }},
+		{name: "thread id over max length", modify: func(n *Notification) { n.ThreadID = strings.Repeat("t", maxThreadIDLength+1) }, wantErr: true},
+		{name: "reply to id over max length", modify: func(n *Notification) { n.ReplyToID = strings.Repeat("r", maxThreadIDLength+1) }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := validNotification()
+			tt.modify(&n)
+			err := n.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidNotification) {
+				t.Fatalf("Validate() error = %v, want ErrInvalidNotification", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidPreferences = errors.New("invalid preferences")
+
+// Lý do notification bị bỏ qua bởi UserPreferences.
+const (
+	FilterReasonMuted    = "muted"
+	FilterReasonSender   = "sender"
+	FilterReasonPriority = "priority"
+)
+
+// UserPreferences là bộ lọc phía consumer của người nhận UserID.
+// AllowedSenderIDs rỗng nghĩa là nhận từ mọi người gửi, MinPriority 0 nghĩa là mọi priority.
+type UserPreferences struct {
+	UserID           int   `json:"userID"`
+	Muted            bool  `json:"muted"`
+	AllowedSenderIDs []int `json:"allowedSenderIDs"`
+	MinPriority      int   `json:"minPriority"`
+}
+
+// Allows trả về false kèm lý do nếu n không được giao tới người nhận.
+func (p UserPreferences) Allows(n Notification) (bool, string) {
+	if p.Muted {
+		return false, FilterReasonMuted
+	}
+	if len(p.AllowedSenderIDs) > 0 && !containsID(p.AllowedSenderIDs, n.From.ID) {
+		return false, FilterReasonSender
+	}
+	if n.Priority < p.MinPriority {
+		return false, FilterReasonPriority
+	}
+	return true, ""
+}
+
+// Validate kiểm tra UserID và MinPriority, MinPriority 0 được chấp nhận (không lọc theo priority).
+func (p UserPreferences) Validate() error {
+	if p.UserID <= 0 {
+		return fmt.Errorf("%w: userID must be positive, got %d", ErrInvalidPreferences, p.UserID)
+	}
+	if p.MinPriority == 0 {
+		return nil
+	}
+	if err := validatePriority(p.MinPriority); err != nil {
+		return fmt.Errorf("%w: minPriority: %v", ErrInvalidPreferences, err)
+	}
+	return nil
+}
+
+func containsID(ids []int, id int) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
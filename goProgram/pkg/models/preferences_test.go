@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUserPreferencesAllows(t *testing.T) {
+	notification := Notification{From: User{ID: 1, Name: "Alice"}, To: User{ID: 2, Name: "Bob"}, Priority: PriorityNormal}
+	tests := []struct {
+		name       string
+		prefs      UserPreferences
+		wantAllow  bool
+		wantReason string
+	}{
+		{name: "no preferences", prefs: UserPreferences{UserID: 2}, wantAllow: true},
+		{name: "muted", prefs: UserPreferences{UserID: 2, Muted: true}, wantReason: FilterReasonMuted},
+		{name: "muted wins over allowed sender", prefs: UserPreferences{UserID: 2, Muted: true, AllowedSenderIDs: []int{1}}, wantReason: FilterReasonMuted},
+		{name: "sender allowed", prefs: UserPreferences{UserID: 2, AllowedSenderIDs: []int{3, 1}}, wantAllow: true},
+		{name: "sender not allowed", prefs: UserPreferences{UserID: 2, AllowedSenderIDs: []int{3}}, wantReason: FilterReasonSender},
+		{name: "priority equal to minimum", prefs: UserPreferences{UserID: 2, MinPriority: PriorityNormal}, wantAllow: true},
+		{name: "priority below minimum", prefs: UserPreferences{UserID: 2, MinPriority: PriorityHigh}, wantReason: FilterReasonPriority},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allow, reason := tt.prefs.Allows(notification)
+			if allow != tt.wantAllow || reason != tt.wantReason {
+				t.Fatalf("Allows() = %v, %q, want %v, %q", allow, reason, tt.wantAllow, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestUserPreferencesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefs   UserPreferences
+		wantErr bool
+	}{
+		{name: "valid", prefs: UserPreferences{UserID: 2, MinPriority: PriorityHigh}},
+		{name: "no minimum priority", prefs: UserPreferences{UserID: 2}},
+		{name: "zero user id", prefs: UserPreferences{}, wantErr: true},
+		{name: "unknown minimum priority", prefs: UserPreferences{UserID: 2, MinPriority: 9}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.prefs.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPreferences) {
+				t.Fatalf("Validate() error = %v, want ErrInvalidPreferences", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Mức độ ưu tiên của notification, mỗi mức được gửi vào một topic riêng
+// để consumer có thể xử lý mức cao trước.
+const (
+	PriorityLow      = 1
+	PriorityNormal   = 2
+	PriorityHigh     = 3
+	PriorityCritical = 4
+)
+
+var priorityNames = map[int]string{
+	PriorityLow:      "low",
+	PriorityNormal:   "normal",
+	PriorityHigh:     "high",
+	PriorityCritical: "critical",
+}
+
+// Priorities trả về các mức ưu tiên theo thứ tự tăng dần.
+func Priorities() []int {
+	return []int{PriorityLow, PriorityNormal, PriorityHigh, PriorityCritical}
+}
+
+// PriorityName trả về tên của mức ưu tiên (low, normal, high, critical).
+func PriorityName(priority int) string {
+	if name, ok := priorityNames[priority]; ok {
+		return name
+	}
+	return strconv.Itoa(priority)
+}
+
+func validatePriority(priority int) error {
+	if _, ok := priorityNames[priority]; !ok {
+		return fmt.Errorf("priority must be between %d and %d, got %d", PriorityLow, PriorityCritical, priority)
+	}
+	return nil
+}
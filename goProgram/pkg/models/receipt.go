@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Receipt xác nhận notification NotificationID đã được consumer giao tới người nhận ReaderID.
+type Receipt struct {
+	NotificationID string    `json:"notificationID"`
+	ReaderID       int       `json:"readerID"`
+	ReadAt         time.Time `json:"readAt"`
+}
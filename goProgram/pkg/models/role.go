@@ -0,0 +1,9 @@
+package models
+
+// Role là quyền của user, lấy từ claim role của JWT.
+type Role string
+
+const (
+	RoleAdmin Role = "admin"
+	RoleUser  Role = "user"
+)
@@ -0,0 +1,8 @@
+package models
+
+// Template là nội dung notification dùng lại được, Body là cú pháp text/template
+// với các placeholder {{.From}} và {{.To}} (tên người gửi, người nhận).
+type Template struct {
+	ID   string `json:"id"`
+	Body string `json:"body"`
+}
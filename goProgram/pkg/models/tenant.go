@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidTenant = errors.New("invalid tenant")
+
+// Tenant là một khách hàng dùng chung service nhưng tách biệt topic, user và preferences.
+// ID rỗng là tenant mặc định, tức các topic và dữ liệu có từ trước khi có multi-tenancy.
+type Tenant struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// độ dài tối đa của Tenant.ID, ID được dùng làm tiền tố tên topic
+const maxTenantIDLength = 64
+
+// ValidateTenantID chấp nhận ID gồm chữ thường, số, '-' và '_' để ghép được vào tên topic và key Redis.
+func ValidateTenantID(id string) error {
+	if id == "" || len(id) > maxTenantIDLength {
+		return fmt.Errorf("%w: id must be 1 to %d characters, got %q", ErrInvalidTenant, maxTenantIDLength, id)
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return fmt.Errorf("%w: id must contain only lowercase letters, digits, '-' or '_', got %q", ErrInvalidTenant, id)
+		}
+	}
+	return nil
+}
+
+func (t Tenant) Validate() error {
+	if err := ValidateTenantID(t.ID); err != nil {
+		return err
+	}
+	if t.Name == "" {
+		return fmt.Errorf("%w: name must not be empty", ErrInvalidTenant)
+	}
+	return nil
+}
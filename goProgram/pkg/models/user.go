@@ -0,0 +1,28 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidUser = errors.New("invalid user")
+
+type User struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Role chỉ dùng cho phân quyền, không được gửi kèm notification qua protobuf/avro
+	Role Role `json:"role,omitempty"`
+	// TenantID rỗng là tenant mặc định, user store gán field này theo tenant của request
+	TenantID string `json:"tenantID,omitempty"`
+}
+
+// Validate kiểm tra ID phải là số dương và Name không được rỗng.
+func (u User) Validate() error {
+	if u.ID <= 0 {
+		return fmt.Errorf("%w: id must be positive, got %d", ErrInvalidUser, u.ID)
+	}
+	if u.Name == "" {
+		return fmt.Errorf("%w: name must not be empty", ErrInvalidUser)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUserValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    User
+		wantErr bool
+	}{
+		{name: "valid", user: User{ID: 1, Name: "Alice"}},
+		{name: "zero id", user: User{ID: 0, Name: "Alice"}, wantErr: true},
+		{name: "negative id", user: User{ID: -1, Name: "Alice"}, wantErr: true},
+		{name: "empty name", user: User{ID: 1}, wantErr: true},
+		{name: "single character name", user: User{ID: 1, Name: "A"}},
+		{name: "large id", user: User{ID: 1<<31 - 1, Name: "Alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.user.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidUser) {
+				t.Fatalf("Validate() error = %v, want ErrInvalidUser", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package models
+
+// Webhook là URL nhận notification của UserID qua HTTP POST, body được ký
+// HMAC-SHA256 bằng Secret trong header X-Signature.
+type Webhook struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"`
+	UserID int    `json:"userID"`
+}
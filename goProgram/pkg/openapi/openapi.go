@@ -0,0 +1,196 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"kafka-notify/middleware"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/tenant"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+	"github.com/gin-gonic/gin"
+)
+
+// đường dẫn phục vụ spec và Swagger UI
+const (
+	SpecPath = "/openapi.json"
+	DocsPath = "/docs"
+)
+
+// Spec dựng tài liệu OpenAPI 3.0 cho các route chính,
+// schema Notification và User được sinh từ struct trong models nên luôn khớp với JSON thật.
+func Spec() (*openapi3.T, error) {
+	schemas := openapi3.Schemas{}
+	for name, value := range map[string]any{
+		"Notification": models.Notification{},
+		"User":         models.User{},
+	} {
+		ref, err := openapi3gen.NewSchemaRefForValue(value, schemas)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %s schema: %w", name, err)
+		}
+		schemas[name] = ref
+	}
+	schemas["Error"] = openapi3.NewSchemaRef("", openapi3.NewObjectSchema().
+		WithProperty("message", openapi3.NewStringSchema()))
+
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:   "Kafka notification service",
+			Version: "1.0.0",
+		},
+		Paths:      openapi3.Paths{},
+		Components: &openapi3.Components{Schemas: schemas},
+	}
+	doc.AddOperation(middleware.APIV1+"/send", http.MethodPost, sendOperation(schemas))
+	doc.AddOperation(middleware.APIV1+"/notifications", http.MethodGet, listNotificationsOperation(schemas))
+	doc.AddOperation("/health", http.MethodGet, healthOperation())
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+	return doc, nil
+}
+
+func sendOperation(schemas openapi3.Schemas) *openapi3.Operation {
+	form := openapi3.NewObjectSchema().
+		WithProperty("fromID", openapi3.NewIntegerSchema()).
+		WithProperty("toID", openapi3.NewIntegerSchema()).
+		WithProperty("message", openapi3.NewStringSchema()).
+		WithProperty("templateID", openapi3.NewStringSchema()).
+		WithProperty("priority", openapi3.NewIntegerSchema().WithMin(1).WithMax(4)).
+		WithProperty("type", openapi3.NewStringSchema().WithPattern("^[a-z0-9_]{0,64}$")).
+		WithProperty("ttl_seconds", openapi3.NewIntegerSchema().WithMin(0)).
+		WithProperty("deliver_at", openapi3.NewDateTimeSchema()).
+		WithProperty("idempotency_key", openapi3.NewStringSchema()).
+		WithProperty("metadata", openapi3.NewStringSchema().WithFormat("json")).
+		WithProperty("threadID", openapi3.NewStringSchema().WithMaxLength(64)).
+		WithProperty("replyToID", openapi3.NewStringSchema().WithMaxLength(64))
+	form.Required = []string{"toID"}
+
+	operation := openapi3.NewOperation()
+	operation.Summary = "Gửi notification tới một user"
+	operation.Description = "fromID mặc định là user trong JWT khi bật xác thực; " +
+		"deliver_at trong tương lai thì notification được lên lịch và trả về 202."
+	operation.Parameters = openapi3.Parameters{tenantParameter()}
+	operation.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+		WithRequired(true).
+		WithFormDataSchema(form)}
+	operation.Responses = openapi3.Responses{
+		"200": jsonResponse("Notification đã được Kafka ghi nhận", openapi3.NewObjectSchema().
+			WithProperty("message", openapi3.NewStringSchema()).
+			WithProperty("idempotencyKey", openapi3.NewStringSchema()).
+			WithProperty("notificationID", openapi3.NewUUIDSchema())),
+		"202": jsonResponse("Notification đã được lên lịch", openapi3.NewObjectSchema()),
+		"400": errorResponse(schemas, "Tham số không hợp lệ"),
+		"403": errorResponse(schemas, "Tenant không có trong TENANTS"),
+		"404": errorResponse(schemas, "Không tìm thấy người gửi hoặc người nhận"),
+		"409": errorResponse(schemas, "Notification trùng lặp"),
+		"503": errorResponse(schemas, "Kafka không khả dụng"),
+		"504": errorResponse(schemas, "Hết KAFKA_SEND_TIMEOUT khi chờ Kafka"),
+	}
+	return operation
+}
+
+func listNotificationsOperation(schemas openapi3.Schemas) *openapi3.Operation {
+	operation := openapi3.NewOperation()
+	operation.Summary = "Danh sách notification của một user (consumer service)"
+	operation.Parameters = openapi3.Parameters{
+		queryParameter("userID", openapi3.NewIntegerSchema(), true),
+		queryParameter("limit", openapi3.NewIntegerSchema().WithMin(1).WithMax(middleware.MaxPageLimit), false),
+		queryParameter("offset", openapi3.NewIntegerSchema().WithMin(0), false),
+		queryParameter("sortBy", openapi3.NewStringSchema().WithEnum(store.SortByCreatedAt, store.SortByPriority), false),
+		queryParameter("sortDir", openapi3.NewStringSchema().WithEnum(store.SortAsc, store.SortDesc), false),
+		queryParameter("fromID", openapi3.NewIntegerSchema(), false),
+		queryParameter("minPriority", openapi3.NewIntegerSchema().WithMin(1).WithMax(4), false),
+		queryParameter("unread", openapi3.NewBoolSchema(), false),
+	}
+	notes := openapi3.NewArraySchema()
+	notes.Items = componentRef(schemas, "Notification")
+	list := openapi3.NewObjectSchema().
+		WithProperty("notifications", notes).
+		WithProperty("total", openapi3.NewIntegerSchema()).
+		WithProperty("limit", openapi3.NewIntegerSchema()).
+		WithProperty("offset", openapi3.NewIntegerSchema()).
+		WithProperty("sortBy", openapi3.NewStringSchema()).
+		WithProperty("sortDir", openapi3.NewStringSchema())
+	operation.Responses = openapi3.Responses{
+		"200": jsonResponse("Danh sách notification", list),
+		"400": errorResponse(schemas, "userID không hợp lệ"),
+		"422": errorResponse(schemas, "Tham số phân trang không hợp lệ"),
+	}
+	return operation
+}
+
+func healthOperation() *openapi3.Operation {
+	operation := openapi3.NewOperation()
+	operation.Summary = "Trạng thái producer và kết nối Kafka"
+	health := openapi3.NewObjectSchema().
+		WithProperty("status", openapi3.NewStringSchema().WithEnum("ok", "degraded")).
+		WithProperty("uptime", openapi3.NewStringSchema()).
+		WithProperty("kafka", openapi3.NewStringSchema().WithEnum("connected", "unreachable")).
+		WithProperty("circuitBreakerState", openapi3.NewStringSchema())
+	operation.Responses = openapi3.Responses{
+		"200": jsonResponse("Kafka kết nối được", health),
+		"503": jsonResponse("Kafka không kết nối được", health),
+	}
+	return operation
+}
+
+func queryParameter(name string, schema *openapi3.Schema, required bool) *openapi3.ParameterRef {
+	return &openapi3.ParameterRef{Value: openapi3.NewQueryParameter(name).WithSchema(schema).WithRequired(required)}
+}
+
+// tenantParameter là header X-Tenant-ID, bỏ trống nghĩa là tenant mặc định.
+func tenantParameter() *openapi3.ParameterRef {
+	return &openapi3.ParameterRef{Value: openapi3.NewHeaderParameter(tenant.HeaderTenantID).
+		WithSchema(openapi3.NewStringSchema().WithPattern("^[a-z0-9_-]{1,64}$"))}
+}
+
+func jsonResponse(description string, schema *openapi3.Schema) *openapi3.ResponseRef {
+	return &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription(description).WithJSONSchema(schema)}
+}
+
+func errorResponse(schemas openapi3.Schemas, description string) *openapi3.ResponseRef {
+	return &openapi3.ResponseRef{Value: openapi3.NewResponse().
+		WithDescription(description).
+		WithJSONSchemaRef(componentRef(schemas, "Error"))}
+}
+
+// componentRef trỏ tới #/components/schemas/<name>, kèm Value để Validate không cần resolve lại.
+func componentRef(schemas openapi3.Schemas, name string) *openapi3.SchemaRef {
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, schemas[name].Value)
+}
+
+// SpecHandler trả về spec dạng JSON, spec được dựng một lần khi khởi tạo.
+func SpecHandler(doc *openapi3.T) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, doc)
+	}
+}
+
+// Swagger UI lấy từ CDN để không phải nhúng asset vào binary
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Kafka notification service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>window.ui = SwaggerUIBundle({url: "` + SpecPath + `", dom_id: "#swagger-ui"});</script>
+</body>
+</html>`
+
+// DocsHandler phục vụ Swagger UI đọc spec từ SpecPath.
+func DocsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+	}
+}
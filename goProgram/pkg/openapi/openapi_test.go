@@ -0,0 +1,42 @@
+package openapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+// TestOpenAPISpecIsValid đọc lại spec đã phục vụ ở SpecPath như client bên ngoài và validate bằng kin-openapi.
+func TestOpenAPISpecIsValid(t *testing.T) {
+	spec, err := Spec()
+	if err != nil {
+		t.Fatalf("Spec() error = %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(SpecPath, SpecHandler(spec))
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, SpecPath, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d, want 200", SpecPath, recorder.Code)
+	}
+
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(recorder.Body.Bytes())
+	if err != nil {
+		t.Fatalf("failed to parse served spec: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("served spec is invalid: %v", err)
+	}
+	for _, path := range []string{"/v1/send", "/v1/notifications", "/health"} {
+		if doc.Paths.Find(path) == nil {
+			t.Errorf("served spec has no path %s", path)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package partitioner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// HeaderFromID và HeaderToID là ID người gửi và người nhận, sender gắn vào mọi notification
+	// để StickySenderPartitioner biết cặp cần ghim
+	HeaderFromID = "X-From-ID"
+	HeaderToID   = "X-To-ID"
+
+	stickyKeyPrefix = "partition:sticky:"
+	// stickyTimeout giới hạn thời gian chờ Redis, Partition chạy trên goroutine chia partition của sarama
+	stickyTimeout = 500 * time.Millisecond
+)
+
+var ErrStickyUnavailable = errors.New("sticky partition cache is unavailable")
+
+type stickyCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// sticky là Redis dùng chung cho mọi StickySenderPartitioner trong process,
+// nil thì StickySenderPartitioner hash key giống UserPartitioner.
+var sticky atomic.Pointer[stickyCache]
+
+// PinSenders bật ghim partition cho StickySenderPartitioner, mỗi cặp được giữ ttl kể từ lần ghim đầu.
+func PinSenders(client *redis.Client, ttl time.Duration) {
+	sticky.Store(&stickyCache{client: client, ttl: ttl})
+}
+
+// StickySenderPartitioner ghim mỗi cặp (fromID, toID) vào partition được chọn ở message đầu tiên,
+// lưu trong Redis bằng SET NX, nên thứ tự giữa hai user được giữ cả khi topic tăng partition
+// và khi nhiều producer cùng gửi.
+type StickySenderPartitioner struct {
+	topic string
+}
+
+// NewStickySenderPartitioner có dạng sarama.PartitionerConstructor giống NewUserPartitioner.
+func NewStickySenderPartitioner(topic string) sarama.Partitioner {
+	return &StickySenderPartitioner{topic: topic}
+}
+
+// Partition chọn partition đã ghim của cặp, chưa ghim thì ghim partition theo hash key.
+// Message không có HeaderFromID/HeaderToID (ví dụ tombstone) được chia như UserPartitioner.
+// Lỗi Redis được trả về thay vì hash key, nếu không message có thể vào partition khác với các message trước.
+func (p *StickySenderPartitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if message.Key == nil {
+		return -1, ErrMissingKey
+	}
+	key, err := message.Key.Encode()
+	if err != nil {
+		return -1, fmt.Errorf("failed to encode message key: %w", err)
+	}
+	partition := hashPartition(p.topic, key, numPartitions)
+
+	cache := sticky.Load()
+	fromID, toID := header(message, HeaderFromID), header(message, HeaderToID)
+	if cache == nil || fromID == "" || toID == "" {
+		return partition, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stickyTimeout)
+	defer cancel()
+	stickyKey := stickyKeyPrefix + p.topic + ":" + fromID + ":" + toID
+	ok, err := cache.client.SetNX(ctx, stickyKey, partition, cache.ttl).Result()
+	if err != nil {
+		return -1, fmt.Errorf("%w: %v", ErrStickyUnavailable, err)
+	}
+	if ok {
+		return partition, nil
+	}
+
+	value, err := cache.client.Get(ctx, stickyKey).Result()
+	if errors.Is(err, redis.Nil) {
+		// key vừa hết hạn giữa SETNX và GET, message kế tiếp sẽ ghim lại
+		return partition, nil
+	}
+	if err != nil {
+		return -1, fmt.Errorf("%w: %v", ErrStickyUnavailable, err)
+	}
+	pinned, err := strconv.ParseInt(value, 10, 32)
+	if err == nil && pinned >= 0 && int32(pinned) < numPartitions {
+		return int32(pinned), nil
+	}
+	// topic bị tạo lại với ít partition hơn, ghim lại theo hash
+	if err := cache.client.Set(ctx, stickyKey, partition, cache.ttl).Err(); err != nil {
+		return -1, fmt.Errorf("%w: %v", ErrStickyUnavailable, err)
+	}
+	return partition, nil
+}
+
+// RequiresConsistency giống UserPartitioner, partition đã ghim không được đổi khi leader tạm thời không sẵn sàng.
+func (p *StickySenderPartitioner) RequiresConsistency() bool {
+	return true
+}
+
+func header(message *sarama.ProducerMessage, key string) string {
+	for _, h := range message.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package partitioner
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// pinWithMiniredis bật PinSenders trên miniredis và tắt lại khi test kết thúc
+func pinWithMiniredis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		sticky.Store(nil)
+		client.Close()
+	})
+	PinSenders(client, time.Hour)
+	return server
+}
+
+func senderMessage(topic, key string, fromID, toID int) *sarama.ProducerMessage {
+	return &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(key),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte(HeaderFromID), Value: []byte(strconv.Itoa(fromID))},
+			{Key: []byte(HeaderToID), Value: []byte(strconv.Itoa(toID))},
+		},
+	}
+}
+
+func TestStickySenderPartitionerFIFO(t *testing.T) {
+	const topic = "test.sticky-fifo"
+	pinWithMiniredis(t)
+	stickyPartitioner := NewStickySenderPartitioner(topic)
+
+	pinned := int32(-1)
+	for i := 0; i < 50; i++ {
+		// key đổi theo từng notification và topic tăng từ 3 lên 6 partition ở giữa chừng,
+		// hash key sẽ rải message ra nhiều partition nhưng cặp người gửi/người nhận vẫn giữ partition đầu tiên
+		numPartitions := int32(3)
+		if i >= 25 {
+			numPartitions = 6
+		}
+		partition, err := stickyPartitioner.Partition(senderMessage(topic, "notification-"+strconv.Itoa(i), 1, 2), numPartitions)
+		if err != nil {
+			t.Fatalf("Partition() error = %v", err)
+		}
+		if pinned < 0 {
+			pinned = partition
+		}
+		if partition != pinned {
+			t.Fatalf("notification %d went to partition %d, want pinned partition %d", i, partition, pinned)
+		}
+	}
+}
+
+func TestStickySenderPartitionerRepinsWhenPartitionsShrink(t *testing.T) {
+	const topic = "test.sticky-shrink"
+	server := pinWithMiniredis(t)
+	server.Set(stickyKeyPrefix+topic+":1:2", "5")
+
+	partition, err := NewStickySenderPartitioner(topic).Partition(senderMessage(topic, "2", 1, 2), 3)
+	if err != nil {
+		t.Fatalf("Partition() error = %v", err)
+	}
+	if partition < 0 || partition >= 3 {
+		t.Fatalf("Partition() = %d, want a partition below 3", partition)
+	}
+	if got, _ := server.Get(stickyKeyPrefix + topic + ":1:2"); got != strconv.Itoa(int(partition)) {
+		t.Fatalf("pinned partition = %s, want %d", got, partition)
+	}
+}
+
+func TestStickySenderPartitionerRedisDown(t *testing.T) {
+	const topic = "test.sticky-down"
+	server := pinWithMiniredis(t)
+	server.Close()
+
+	_, err := NewStickySenderPartitioner(topic).Partition(senderMessage(topic, "2", 1, 2), 3)
+	if !errors.Is(err, ErrStickyUnavailable) {
+		t.Fatalf("Partition() error = %v, want %v", err, ErrStickyUnavailable)
+	}
+}
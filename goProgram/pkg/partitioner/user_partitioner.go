@@ -0,0 +1,87 @@
+package partitioner
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/IBM/sarama"
+)
+
+var ErrMissingKey = errors.New("message key is required to pick a user partition")
+
+// watched giữ số partition (topic -> *atomic.Int32) do admin.PartitionWatcher quan sát được,
+// dùng chung cho mọi producer trong process.
+var watched sync.Map
+
+// SetPartitionCount cập nhật số partition của topic, UserPartitioner dùng giá trị mới
+// ngay ở message kế tiếp.
+func SetPartitionCount(topic string, count int32) {
+	value, _ := watched.LoadOrStore(topic, new(atomic.Int32))
+	value.(*atomic.Int32).Store(count)
+}
+
+func partitionCount(topic string) int32 {
+	value, ok := watched.Load(topic)
+	if !ok {
+		return 0
+	}
+	return value.(*atomic.Int32).Load()
+}
+
+// UserPartitioner chọn partition theo key của message (mặc định ID người nhận hoặc ThreadID,
+// xem KAFKA_KEY_STRATEGY), nên mọi notification cùng key luôn nằm trên cùng một partition
+// và giữ đúng thứ tự.
+type UserPartitioner struct {
+	topic string
+}
+
+// NewUserPartitioner có dạng sarama.PartitionerConstructor,
+// dùng với sarama.NewCustomPartitioner hoặc gán thẳng vào config.Producer.Partitioner.
+func NewUserPartitioner(topic string) sarama.Partitioner {
+	return &UserPartitioner{topic: topic}
+}
+
+// Partition hash key bằng FNV-64a rồi chọn partition bằng jump consistent hash trên số partition hiện tại của topic.
+// Số partition là giá trị nhỏ hơn giữa metadata của sarama (numPartitions) và số PartitionWatcher
+// quan sát được: khi topic vừa được tăng partition, partitioner chỉ chuyển sang số mới khi
+// cả hai đã thấy, nên không trả về partition mà sarama chưa biết.
+func (p *UserPartitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if message.Key == nil {
+		return -1, ErrMissingKey
+	}
+	key, err := message.Key.Encode()
+	if err != nil {
+		return -1, fmt.Errorf("failed to encode message key: %w", err)
+	}
+	return hashPartition(p.topic, key, numPartitions), nil
+}
+
+func hashPartition(topic string, key []byte, numPartitions int32) int32 {
+	if count := partitionCount(topic); count > 0 && count < numPartitions {
+		numPartitions = count
+	}
+	hasher := fnv.New64a()
+	hasher.Write(key)
+	return jumpHash(hasher.Sum64(), numPartitions)
+}
+
+// jumpHash là jump consistent hash (Lamping & Veach): khi tăng từ n lên n+1 partition
+// chỉ khoảng 1/(n+1) số key đổi partition, thay vì gần như tất cả như khi lấy dư.
+func jumpHash(key uint64, buckets int32) int32 {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int32(b)
+}
+
+// RequiresConsistency báo cho sarama biết cùng key phải luôn vào cùng partition,
+// nên không được chuyển sang partition khác khi leader tạm thời không sẵn sàng.
+func (p *UserPartitioner) RequiresConsistency() bool {
+	return true
+}
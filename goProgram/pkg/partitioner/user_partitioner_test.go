@@ -0,0 +1,84 @@
+package partitioner
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+)
+
+const userTopic = "notifications.normal"
+
+// producer mock của sarama chạy partitioner trong config.Producer.Partitioner như producer thật,
+// sarama không có MockPartitioner riêng
+func newPartitionedProducer(t *testing.T, partitions int32) *mocks.SyncProducer {
+	t.Helper()
+	config := mocks.NewTestConfig()
+	config.Producer.Partitioner = NewUserPartitioner
+	producer := mocks.NewSyncProducer(t, config)
+	producer.SetDefaultPartitions(partitions)
+	t.Cleanup(func() { producer.Close() })
+	return producer
+}
+
+// sendKeyed gửi message có key là userID và trả về partition partitioner đã chọn
+func sendKeyed(t *testing.T, producer *mocks.SyncProducer, userID int) int32 {
+	t.Helper()
+	partition := int32(-1)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		partition = msg.Partition
+		return nil
+	})
+	msg := &sarama.ProducerMessage{Topic: userTopic, Key: sarama.StringEncoder(strconv.Itoa(userID))}
+	if _, _, err := producer.SendMessage(msg); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	return partition
+}
+
+func TestUserPartitionerSameUserSamePartition(t *testing.T) {
+	producer := newPartitionedProducer(t, 32)
+	partitions := make(map[int32]bool)
+	for userID := 1; userID <= 50; userID++ {
+		first := sendKeyed(t, producer, userID)
+		if first < 0 || first >= 32 {
+			t.Fatalf("user %d: partition %d out of range", userID, first)
+		}
+		for i := 0; i < 5; i++ {
+			if got := sendKeyed(t, producer, userID); got != first {
+				t.Fatalf("user %d: call %d went to partition %d, first call went to %d", userID, i+2, got, first)
+			}
+		}
+		partitions[first] = true
+	}
+	// hash phải rải user ra nhiều partition, không dồn vào một
+	if len(partitions) < 10 {
+		t.Fatalf("50 users landed on %d partitions, want at least 10", len(partitions))
+	}
+}
+
+func TestUserPartitionerNewInstanceSamePartition(t *testing.T) {
+	msg := &sarama.ProducerMessage{Topic: userTopic, Key: sarama.StringEncoder("42")}
+	want, err := NewUserPartitioner(userTopic).Partition(msg, 12)
+	if err != nil {
+		t.Fatalf("Partition() error = %v", err)
+	}
+	// producer khác (instance khác) phải chọn cùng partition cho cùng user
+	for i := 0; i < 3; i++ {
+		if got, _ := NewUserPartitioner(userTopic).Partition(msg, 12); got != want {
+			t.Fatalf("partitioner %d chose %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestUserPartitionerMissingKey(t *testing.T) {
+	_, err := NewUserPartitioner(userTopic).Partition(&sarama.ProducerMessage{Topic: userTopic}, 12)
+	if !errors.Is(err, ErrMissingKey) {
+		t.Fatalf("Partition() error = %v, want ErrMissingKey", err)
+	}
+	if !NewUserPartitioner(userTopic).RequiresConsistency() {
+		t.Fatal("RequiresConsistency() = false, want true")
+	}
+}
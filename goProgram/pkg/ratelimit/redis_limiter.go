@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "ratelimit:"
+
+// RedisLimiter đếm request trong Redis nên mọi replica dùng chung một quota cho mỗi key.
+// Dùng sliding window counter: mỗi cửa sổ cố định có một counter (INCR + EXPIRE), số request
+// ước lượng là counter hiện tại cộng phần còn nằm trong cửa sổ trượt của counter trước,
+// nên không cho gấp đôi quota ở ranh giới hai cửa sổ như fixed window.
+type RedisLimiter struct {
+	client *redis.Client
+}
+
+func NewRedisLimiter(client *redis.Client) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+// Allow ghi nhận một request của key và trả về false nếu key đã vượt maxRequests trong windowSeconds giây gần nhất.
+// Request bị từ chối không được tính vào quota.
+func (l *RedisLimiter) Allow(ctx context.Context, key string, windowSeconds int, maxRequests int) (bool, error) {
+	if windowSeconds <= 0 || maxRequests <= 0 {
+		return false, fmt.Errorf("invalid rate limit: window %ds, max %d requests", windowSeconds, maxRequests)
+	}
+	window := int64(windowSeconds)
+	now := time.Now()
+	index := now.Unix() / window
+	elapsed := float64(now.UnixNano()-index*window*int64(time.Second)) / float64(window*int64(time.Second))
+	current := windowKey(key, index)
+
+	pipe := l.client.TxPipeline()
+	incr := pipe.Incr(ctx, current)
+	// giữ counter thêm một cửa sổ để cửa sổ sau còn đọc được làm counter trước
+	pipe.Expire(ctx, current, 2*time.Duration(windowSeconds)*time.Second)
+	previous := pipe.Get(ctx, windowKey(key, index-1))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return false, fmt.Errorf("failed to count request: %w", err)
+	}
+	previousCount, err := previous.Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, fmt.Errorf("failed to read previous window: %w", err)
+	}
+
+	estimated := float64(previousCount)*(1-elapsed) + float64(incr.Val())
+	if estimated <= float64(maxRequests) {
+		return true, nil
+	}
+	// không trừ lại được thì request bị từ chối vẫn bị tính, quota hồi lại ở cửa sổ sau
+	l.client.Decr(ctx, current)
+	return false, nil
+}
+
+func (l *RedisLimiter) Close() error {
+	return l.client.Close()
+}
+
+func windowKey(key string, index int64) string {
+	return keyPrefix + key + ":" + strconv.FormatInt(index, 10)
+}
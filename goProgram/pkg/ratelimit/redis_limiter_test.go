@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newReplicaLimiters tạo n RedisLimiter với client riêng trên cùng miniredis, như n replica của producer
+func newReplicaLimiters(t *testing.T, server *miniredis.Miniredis, n int) []*RedisLimiter {
+	t.Helper()
+	limiters := make([]*RedisLimiter, n)
+	for i := range limiters {
+		limiter := NewRedisLimiter(redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1}))
+		t.Cleanup(func() { limiter.Close() })
+		limiters[i] = limiter
+	}
+	return limiters
+}
+
+func TestRedisLimiterSharedBucket(t *testing.T) {
+	const (
+		maxRequests = 20
+		requests    = 100
+		// cửa sổ dài để cả loạt request nằm trong một cửa sổ
+		window = 3600
+	)
+	server := miniredis.RunT(t)
+	limiters := newReplicaLimiters(t, server, 2)
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(limiter *RedisLimiter) {
+			defer wg.Done()
+			ok, err := limiter.Allow(context.Background(), "tenant:user:1", window, maxRequests)
+			if err != nil {
+				t.Errorf("Allow() error = %v", err)
+				return
+			}
+			if ok {
+				allowed.Add(1)
+			}
+		}(limiters[i%len(limiters)])
+	}
+	wg.Wait()
+
+	// hai replica dùng chung quota nên tổng cộng chỉ maxRequests request được qua
+	if got := allowed.Load(); got != maxRequests {
+		t.Fatalf("%d requests allowed across replicas, want %d", got, maxRequests)
+	}
+
+	// key khác có quota riêng
+	ok, err := limiters[0].Allow(context.Background(), "tenant:user:2", window, maxRequests)
+	if err != nil || !ok {
+		t.Fatalf("Allow() for another key = %v, %v, want allowed", ok, err)
+	}
+}
+
+func TestRedisLimiterRejectedRequestsAreNotCounted(t *testing.T) {
+	server := miniredis.RunT(t)
+	limiter := newReplicaLimiters(t, server, 1)[0]
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		limiter.Allow(ctx, "user:1", 3600, 3)
+	}
+	keys := server.Keys()
+	if len(keys) != 1 {
+		t.Fatalf("keys = %v, want one window counter", keys)
+	}
+	if count, _ := server.Get(keys[0]); count != "3" {
+		t.Fatalf("counter = %s, want 3", count)
+	}
+	if ttl := server.TTL(keys[0]); ttl <= 0 {
+		t.Fatalf("counter TTL = %v, want EXPIRE set", ttl)
+	}
+}
+
+func TestRedisLimiterErrors(t *testing.T) {
+	server := miniredis.RunT(t)
+	limiter := newReplicaLimiters(t, server, 1)[0]
+	ctx := context.Background()
+
+	if _, err := limiter.Allow(ctx, "user:1", 0, 10); err == nil {
+		t.Fatal("Allow() with window 0 error = nil")
+	}
+	if _, err := limiter.Allow(ctx, "user:1", 60, 0); err == nil {
+		t.Fatal("Allow() with max 0 error = nil")
+	}
+
+	server.Close()
+	if ok, err := limiter.Allow(ctx, "user:1", 60, 10); err == nil || ok {
+		t.Fatalf("Allow() with Redis down = %v, %v, want error", ok, err)
+	}
+}
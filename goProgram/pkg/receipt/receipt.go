@@ -0,0 +1,66 @@
+package receipt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"kafka-notify/pkg/models"
+
+	"github.com/IBM/sarama"
+)
+
+// Publisher gửi receipt sang receipts topic, key là notification ID để
+// các receipt của cùng một notification nằm trên cùng partition.
+type Publisher struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+func NewPublisher(producer sarama.SyncProducer, topic string) *Publisher {
+	return &Publisher{producer: producer, topic: topic}
+}
+
+func (p *Publisher) Publish(r models.Receipt) error {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal receipt: %w", err)
+	}
+	_, _, err = p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: p.topic,
+		Key:   sarama.StringEncoder(r.NotificationID),
+		Value: sarama.ByteEncoder(payload),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send receipt to %s: %w", p.topic, err)
+	}
+	return nil
+}
+
+// Handler là sarama.ConsumerGroupHandler đọc receipts topic và lưu vào Store.
+// Receipt không decode được chỉ bị bỏ qua vì không ảnh hưởng việc giao notification.
+type Handler struct {
+	Store ReceiptStore
+	// OnError được gọi khi một receipt không decode hoặc không lưu được, có thể nil
+	OnError func(msg *sarama.ConsumerMessage, err error)
+}
+
+func (h *Handler) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (h *Handler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (h *Handler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		if err := h.save(sess.Context(), msg); err != nil && h.OnError != nil {
+			h.OnError(msg, err)
+		}
+		sess.MarkMessage(msg, "")
+	}
+	return nil
+}
+
+func (h *Handler) save(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	var r models.Receipt
+	if err := json.Unmarshal(msg.Value, &r); err != nil {
+		return fmt.Errorf("failed to unmarshal receipt: %w", err)
+	}
+	return h.Store.Save(ctx, r)
+}
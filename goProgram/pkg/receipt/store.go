@@ -0,0 +1,39 @@
+package receipt
+
+import (
+	"context"
+	"kafka-notify/pkg/models"
+	"sync"
+)
+
+// ReceiptStore lưu read receipt theo notification ID.
+type ReceiptStore interface {
+	Save(ctx context.Context, r models.Receipt) error
+	FindByNotificationID(ctx context.Context, notificationID string) ([]models.Receipt, error)
+}
+
+// MemoryStore giữ receipt trong bộ nhớ của instance hiện tại, mất khi restart.
+type MemoryStore struct {
+	data map[string][]models.Receipt
+	mu   sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: make(map[string][]models.Receipt)}
+}
+
+func (s *MemoryStore) Save(_ context.Context, r models.Receipt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[r.NotificationID] = append(s.data[r.NotificationID], r)
+	return nil
+}
+
+// FindByNotificationID trả về bản copy để caller không giữ tham chiếu tới dữ liệu đang được khoá.
+func (s *MemoryStore) FindByNotificationID(_ context.Context, notificationID string) ([]models.Receipt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	receipts := make([]models.Receipt, len(s.data[notificationID]))
+	copy(receipts, s.data[notificationID])
+	return receipts, nil
+}
@@ -0,0 +1,98 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+var (
+	// ErrMessageNotFound: offset nằm ngoài khoảng còn lưu trên partition, hoặc là transaction marker /
+	// message của transaction bị abort / bản ghi đã bị compact
+	ErrMessageNotFound = errors.New("message not found at offset")
+	// ErrUndecodable: message tồn tại nhưng không giải mã được thành notification
+	ErrUndecodable = errors.New("message is not a valid notification")
+)
+
+// MessageReplayer đọc lại đúng một message theo topic/partition/offset,
+// dùng khi cần xử lý lại một notification mà không replay cả topic.
+type MessageReplayer struct {
+	client sarama.Client
+	// timeout chờ message sau khi seek, hết hạn thì coi như không có message ở offset đó
+	timeout time.Duration
+}
+
+func NewMessageReplayer(client sarama.Client) *MessageReplayer {
+	return &MessageReplayer{client: client, timeout: replayIdleTimeout}
+}
+
+// ReplayMessage tạo một sarama.Consumer, seek tới offset, đọc đúng một message rồi giải mã
+// bằng codec theo header Content-Type (giống consumer chính).
+func (r *MessageReplayer) ReplayMessage(ctx context.Context, topic string,
+	partition int32, offset int64) (*models.Notification, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", ErrMessageNotFound)
+	}
+	// không có message ở offset >= high watermark, kiểm tra trước để không phải chờ timeout
+	newest, err := r.client.GetOffset(topic, partition, sarama.OffsetNewest)
+	if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+		return nil, fmt.Errorf("%w: %s/%d does not exist", ErrMessageNotFound, topic, partition)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get high watermark of %s/%d: %w", topic, partition, err)
+	}
+	if offset >= newest {
+		return nil, fmt.Errorf("%w: %s/%d/%d is beyond high watermark %d", ErrMessageNotFound, topic, partition, offset, newest)
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(r.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup message replayer: %w", err)
+	}
+	defer consumer.Close()
+
+	pc, err := consumer.ConsumePartition(topic, partition, offset)
+	if errors.Is(err, sarama.ErrOffsetOutOfRange) {
+		return nil, fmt.Errorf("%w: %s/%d/%d has been deleted by retention", ErrMessageNotFound, topic, partition, offset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume %s/%d: %w", topic, partition, err)
+	}
+	defer pc.AsyncClose()
+
+	timeout := time.NewTimer(r.timeout)
+	defer timeout.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout.C:
+		return nil, fmt.Errorf("%w: %s/%d/%d", ErrMessageNotFound, topic, partition, offset)
+	case consumerErr := <-pc.Errors():
+		return nil, fmt.Errorf("failed to read %s/%d/%d: %w", topic, partition, offset, consumerErr.Err)
+	case msg := <-pc.Messages():
+		// sarama bỏ qua offset không đọc được và trả message kế tiếp
+		if msg.Offset != offset {
+			return nil, fmt.Errorf("%w: %s/%d/%d", ErrMessageNotFound, topic, partition, offset)
+		}
+		return decode(msg)
+	}
+}
+
+func decode(msg *sarama.ConsumerMessage) (*models.Notification, error) {
+	notificationCodec, err := codec.ForContentType(kafka.HeaderValue(msg.Headers, codec.HeaderContentType))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUndecodable, err)
+	}
+	notification, err := notificationCodec.Unmarshal(msg.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUndecodable, err)
+	}
+	notification.Metadata = kafka.Metadata(msg.Headers)
+	return &notification, nil
+}
@@ -0,0 +1,94 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"kafka-notify/pkg/models"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+const replayTopic = "notifications.normal"
+
+// newMockReplayer tạo MessageReplayer trên sarama.MockBroker có partition 0 với offset [0, 50)
+// và message ở các offset trong messages.
+func newMockReplayer(t *testing.T, messages map[int64]sarama.Encoder) *MessageReplayer {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	fetch := sarama.NewMockFetchResponse(t, 1).SetHighWaterMark(replayTopic, 0, 50)
+	for offset, value := range messages {
+		fetch.SetMessage(replayTopic, 0, offset, value)
+	}
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(replayTopic, 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset(replayTopic, 0, sarama.OffsetOldest, 0).
+			SetOffset(replayTopic, 0, sarama.OffsetNewest, 50),
+		"FetchRequest": fetch,
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	replayer := NewMessageReplayer(client)
+	replayer.timeout = time.Second
+	return replayer
+}
+
+func TestReplayMessage(t *testing.T) {
+	want := models.Notification{
+		ID:       "5f0c7c3e-8f9a-4a57-9d51-3b8a2f8d9e01",
+		From:     models.User{ID: 1, Name: "Alice"},
+		To:       models.User{ID: 2, Name: "Bob"},
+		Message:  "replay me",
+		Priority: models.PriorityNormal,
+	}
+	payload, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("failed to marshal notification: %v", err)
+	}
+	replayer := newMockReplayer(t, map[int64]sarama.Encoder{
+		42: sarama.ByteEncoder(payload),
+		43: sarama.StringEncoder("not json"),
+	})
+
+	got, err := replayer.ReplayMessage(context.Background(), replayTopic, 0, 42)
+	if err != nil {
+		t.Fatalf("ReplayMessage() error = %v", err)
+	}
+	if got.ID != want.ID || got.From != want.From || got.To != want.To || got.Message != want.Message {
+		t.Fatalf("ReplayMessage() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReplayMessageErrors(t *testing.T) {
+	replayer := newMockReplayer(t, map[int64]sarama.Encoder{43: sarama.StringEncoder("not json")})
+
+	tests := []struct {
+		name    string
+		offset  int64
+		wantErr error
+	}{
+		{name: "negative offset", offset: -1, wantErr: ErrMessageNotFound},
+		{name: "beyond high watermark", offset: 50, wantErr: ErrMessageNotFound},
+		{name: "undecodable message", offset: 43, wantErr: ErrUndecodable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := replayer.ReplayMessage(context.Background(), replayTopic, 0, tt.offset)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReplayMessage() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/store"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/gin-gonic/gin"
+)
+
+// replayIdleTimeout: partition không có message mới trong khoảng này thì coi như đã đọc hết.
+// Cần thiết vì với ReadCommitted, các offset cuối có thể là transaction marker hoặc message
+// của transaction bị abort nên consumer không bao giờ nhận được message ở high watermark - 1.
+const replayIdleTimeout = 5 * time.Second
+
+// Repository là nơi nhận notification được replay, ví dụ store.NotificationStore hoặc search index.
+type Repository interface {
+	Store(ctx context.Context, n models.Notification) error
+}
+
+// deleter là Repository xoá được notification, dùng để áp dụng tombstone khi replay.
+type deleter interface {
+	Delete(ctx context.Context, id string) error
+}
+
+// ReplayConsumer đọc lại các topic notification từ offset đầu tiên tới high watermark
+// (lấy lúc bắt đầu) và ghi từng notification vào repository, Kafka là nguồn dữ liệu gốc.
+// Repository nên là store mới (chưa có dữ liệu) nếu nó không bỏ qua được bản ghi trùng.
+type ReplayConsumer struct {
+	// OnSkip được gọi khi một message không decode được và bị bỏ qua, có thể nil
+	OnSkip func(msg *sarama.ConsumerMessage, err error)
+
+	client     sarama.Client
+	consumer   sarama.Consumer
+	repository Repository
+	topics     []string
+	progress   *ReplayProgress
+}
+
+func NewReplayConsumer(client sarama.Client, repository Repository, topics []string) (*ReplayConsumer, error) {
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup replay consumer: %w", err)
+	}
+	return &ReplayConsumer{
+		client:     client,
+		consumer:   consumer,
+		repository: repository,
+		topics:     topics,
+		progress:   &ReplayProgress{},
+	}, nil
+}
+
+// Progress trả về tiến độ replay, an toàn khi đọc trong lúc Run đang chạy.
+func (r *ReplayConsumer) Progress() *ReplayProgress {
+	return r.progress
+}
+
+type partitionRange struct {
+	topic     string
+	partition int32
+	oldest    int64
+	// target là high watermark lúc bắt đầu, message mới hơn không được replay
+	target int64
+}
+
+// Run replay mọi partition song song và trả về khi tất cả đã tới target hoặc ctx bị huỷ.
+func (r *ReplayConsumer) Run(ctx context.Context) error {
+	ranges, err := r.partitionRanges()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, pr := range ranges {
+		total += pr.target - pr.oldest
+	}
+	r.progress.start(time.Now(), total)
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(ranges))
+	for i, pr := range ranges {
+		wg.Add(1)
+		go func(i int, pr partitionRange) {
+			defer wg.Done()
+			errs[i] = r.replayPartition(ctx, pr)
+		}(i, pr)
+	}
+	wg.Wait()
+	err = errors.Join(errs...)
+	r.progress.finish(time.Now(), err)
+	return err
+}
+
+func (r *ReplayConsumer) partitionRanges() ([]partitionRange, error) {
+	var ranges []partitionRange
+	for _, topic := range r.topics {
+		partitions, err := r.client.Partitions(topic)
+		if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+			// topic chưa được tạo nghĩa là chưa có gì để replay
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list partitions of %s: %w", topic, err)
+		}
+		for _, partition := range partitions {
+			oldest, err := r.client.GetOffset(topic, partition, sarama.OffsetOldest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get oldest offset of %s/%d: %w", topic, partition, err)
+			}
+			target, err := r.client.GetOffset(topic, partition, sarama.OffsetNewest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get high watermark of %s/%d: %w", topic, partition, err)
+			}
+			if target > oldest {
+				ranges = append(ranges, partitionRange{topic: topic, partition: partition, oldest: oldest, target: target})
+			}
+		}
+	}
+	return ranges, nil
+}
+
+func (r *ReplayConsumer) replayPartition(ctx context.Context, pr partitionRange) error {
+	pc, err := r.consumer.ConsumePartition(pr.topic, pr.partition, sarama.OffsetOldest)
+	if err != nil {
+		return fmt.Errorf("failed to consume %s/%d: %w", pr.topic, pr.partition, err)
+	}
+	defer pc.AsyncClose()
+
+	idle := time.NewTimer(replayIdleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-idle.C:
+			// các offset còn lại chỉ là transaction marker hoặc message bị abort
+			return nil
+		case consumerErr, ok := <-pc.Errors():
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("failed to replay %s/%d: %w", pr.topic, pr.partition, consumerErr.Err)
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				return nil
+			}
+			if msg.Offset >= pr.target {
+				return nil
+			}
+			if err := r.apply(ctx, msg); err != nil {
+				return err
+			}
+			if msg.Offset+1 >= pr.target {
+				return nil
+			}
+			if !idle.Stop() {
+				<-idle.C
+			}
+			idle.Reset(replayIdleTimeout)
+		}
+	}
+}
+
+// apply ghi một message vào repository, message không decode được bị bỏ qua và đếm vào Skipped
+// (giống consumer chính chuyển chúng sang DLQ). Lỗi của repository dừng replay.
+func (r *ReplayConsumer) apply(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	if msg.Value == nil {
+		return r.applyTombstone(ctx, msg)
+	}
+	notificationCodec, err := codec.ForContentType(kafka.HeaderValue(msg.Headers, codec.HeaderContentType))
+	if err == nil {
+		notification, decodeErr := notificationCodec.Unmarshal(msg.Value)
+		if decodeErr == nil {
+			notification.Metadata = kafka.Metadata(msg.Headers)
+			if err := r.repository.Store(ctx, notification); err != nil {
+				return fmt.Errorf("failed to store notification at %s/%d/%d: %w", msg.Topic, msg.Partition, msg.Offset, err)
+			}
+			r.progress.Replayed.Add(1)
+			return nil
+		}
+		err = decodeErr
+	}
+	if r.OnSkip != nil {
+		r.OnSkip(msg, err)
+	}
+	r.progress.Skipped.Add(1)
+	return nil
+}
+
+// applyTombstone xoá notification đã replay trước đó, Repository không hỗ trợ Delete thì bỏ qua.
+func (r *ReplayConsumer) applyTombstone(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	repository, ok := r.repository.(deleter)
+	if !ok || len(msg.Key) == 0 {
+		return nil
+	}
+	if err := repository.Delete(ctx, string(msg.Key)); err != nil && !errors.Is(err, store.ErrNotificationNotFound) {
+		return fmt.Errorf("failed to delete notification at %s/%d/%d: %w", msg.Topic, msg.Partition, msg.Offset, err)
+	}
+	r.progress.Replayed.Add(1)
+	return nil
+}
+
+func (r *ReplayConsumer) Close() error {
+	return r.consumer.Close()
+}
+
+// ReplayProgress đếm số message đã replay, dùng cho GET /replay/status.
+type ReplayProgress struct {
+	Replayed atomic.Int64
+	Skipped  atomic.Int64
+
+	mu         sync.RWMutex
+	total      int64
+	startedAt  time.Time
+	finishedAt time.Time
+	err        error
+}
+
+func (p *ReplayProgress) start(now time.Time, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.startedAt = now
+	p.total = total
+}
+
+func (p *ReplayProgress) finish(now time.Time, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finishedAt = now
+	p.err = err
+}
+
+// Status là tiến độ replay trả về bởi GET /replay/status.
+type Status struct {
+	// State là pending (chưa lấy được offset), running, completed hoặc failed
+	State    string `json:"state"`
+	Replayed int64  `json:"replayed"`
+	Skipped  int64  `json:"skipped"`
+	// Total là số offset cần đọc, có thể lớn hơn Replayed+Skipped do transaction marker
+	Total   int64  `json:"total"`
+	Elapsed string `json:"elapsed"`
+	// EstimatedRemaining ước lượng theo tốc độ trung bình từ lúc bắt đầu
+	EstimatedRemaining string `json:"estimatedRemaining,omitempty"`
+	Error              string `json:"error,omitempty"`
+}
+
+// Status chụp lại tiến độ tại thời điểm now.
+func (p *ReplayProgress) Status(now time.Time) Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	status := Status{
+		State:    "pending",
+		Replayed: p.Replayed.Load(),
+		Skipped:  p.Skipped.Load(),
+		Total:    p.total,
+	}
+	if p.startedAt.IsZero() {
+		return status
+	}
+
+	end := now
+	switch {
+	case p.finishedAt.IsZero():
+		status.State = "running"
+	case p.err != nil:
+		status.State = "failed"
+		status.Error = p.err.Error()
+		end = p.finishedAt
+	default:
+		status.State = "completed"
+		end = p.finishedAt
+	}
+	elapsed := end.Sub(p.startedAt)
+	status.Elapsed = elapsed.Round(time.Millisecond).String()
+
+	processed := status.Replayed + status.Skipped
+	if status.State == "running" && processed > 0 && processed < status.Total {
+		remaining := time.Duration(float64(elapsed) / float64(processed) * float64(status.Total-processed))
+		status.EstimatedRemaining = remaining.Round(time.Second).String()
+	}
+	return status
+}
+
+// StatusHandler phục vụ GET /replay/status.
+func StatusHandler(progress *ReplayProgress) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, progress.Status(time.Now()))
+	}
+}
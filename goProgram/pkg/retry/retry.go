@@ -0,0 +1,118 @@
+package retry
+
+import (
+	"fmt"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/tenant"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Header gắn vào message khi chuyển sang retry topic.
+const (
+	HeaderRetryCount = "X-Retry-Count"
+	// HeaderRetryAfter là thời điểm (Unix millisecond) sớm nhất được xử lý lại message
+	HeaderRetryAfter = "X-Retry-After"
+)
+
+// RetryPolicy quy định số lần xử lý lại và thời gian chờ trước mỗi lần.
+// Backoffs[i] là thời gian chờ trước lần retry thứ i+1, thiếu thì dùng phần tử cuối.
+type RetryPolicy struct {
+	MaxRetries int
+	Backoffs   []time.Duration
+}
+
+// ShouldRetry trả về true nếu message chưa dùng hết số lần retry.
+func (p RetryPolicy) ShouldRetry(msg *sarama.ConsumerMessage) bool {
+	return Count(msg.Headers) < p.MaxRetries
+}
+
+// Backoff trả về thời gian chờ trước lần retry attempt (bắt đầu từ 1).
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if len(p.Backoffs) == 0 || attempt < 1 {
+		return 0
+	}
+	if attempt > len(p.Backoffs) {
+		return p.Backoffs[len(p.Backoffs)-1]
+	}
+	return p.Backoffs[attempt-1]
+}
+
+// Count trả về số lần message đã được retry, message gốc là 0.
+func Count(headers []*sarama.RecordHeader) int {
+	count, err := strconv.Atoi(kafka.HeaderValue(headers, HeaderRetryCount))
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// ReadyAt trả về thời điểm message được phép xử lý lại, zero time nếu không có header.
+func ReadyAt(headers []*sarama.RecordHeader) time.Time {
+	millis, err := strconv.ParseInt(kafka.HeaderValue(headers, HeaderRetryAfter), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(millis)
+}
+
+// Topic trả về retry topic của lần retry attempt, ví dụ "notifications.retry.1".
+func Topic(prefix string, attempt int) string {
+	return prefix + ".retry." + strconv.Itoa(attempt)
+}
+
+// Topics trả về tất cả retry topic mà retry consumer cần subscribe.
+func (p RetryPolicy) Topics(prefix string) []string {
+	topics := make([]string, 0, p.MaxRetries)
+	for attempt := 1; attempt <= p.MaxRetries; attempt++ {
+		topics = append(topics, Topic(prefix, attempt))
+	}
+	return topics
+}
+
+// Producer đẩy message xử lý lỗi sang retry topic của lần retry tiếp theo.
+type Producer struct {
+	producer sarama.SyncProducer
+	prefix   string
+	policy   RetryPolicy
+}
+
+func NewProducer(producer sarama.SyncProducer, prefix string, policy RetryPolicy) *Producer {
+	return &Producer{producer: producer, prefix: prefix, policy: policy}
+}
+
+func (p *Producer) ShouldRetry(msg *sarama.ConsumerMessage) bool {
+	return p.policy.ShouldRetry(msg)
+}
+
+// Send giữ nguyên key, value và header gốc, chỉ thay X-Retry-Count và X-Retry-After.
+// Message có header X-Tenant-ID được chuyển sang retry topic của tenant đó.
+func (p *Producer) Send(msg *sarama.ConsumerMessage) error {
+	attempt := Count(msg.Headers) + 1
+	topic := tenant.Topic(kafka.HeaderValue(msg.Headers, tenant.HeaderTenantID), Topic(p.prefix, attempt))
+
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+2)
+	for _, header := range msg.Headers {
+		if key := string(header.Key); key == HeaderRetryCount || key == HeaderRetryAfter {
+			continue
+		}
+		headers = append(headers, *header)
+	}
+	headers = append(headers,
+		kafka.Header(HeaderRetryCount, strconv.Itoa(attempt)),
+		kafka.Header(HeaderRetryAfter, strconv.FormatInt(time.Now().Add(p.policy.Backoff(attempt)).UnixMilli(), 10)),
+	)
+
+	_, _, err := p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(msg.Key),
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send message to retry topic %s: %w", topic, err)
+	}
+	return nil
+}
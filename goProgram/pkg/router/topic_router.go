@@ -0,0 +1,57 @@
+package router
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// TopicRouter chọn topic theo loại notification (ví dụ system_alert, chat_message),
+// để từng loại có consumer và SLA riêng. Loại không có trong bảng dùng topic mặc định của caller.
+type TopicRouter struct {
+	routes atomic.Pointer[map[string]string]
+}
+
+// NewTopicRouter tạo router từ bảng type -> topic (KAFKA_TOPIC_ROUTING), routes được copy
+// nên caller sửa map sau đó không ảnh hưởng router.
+func NewTopicRouter(routes map[string]string) *TopicRouter {
+	r := &TopicRouter{}
+	r.SetRoutes(routes)
+	return r
+}
+
+// SetRoutes thay toàn bộ bảng route, an toàn khi đang có goroutine khác gọi Route.
+func (r *TopicRouter) SetRoutes(routes map[string]string) {
+	copied := make(map[string]string, len(routes))
+	for notificationType, topic := range routes {
+		copied[notificationType] = topic
+	}
+	r.routes.Store(&copied)
+}
+
+// Route trả về topic của notificationType, ok=false nếu type rỗng hoặc chưa được map.
+// Router nil coi như không có route nào.
+func (r *TopicRouter) Route(notificationType string) (topic string, ok bool) {
+	if r == nil || notificationType == "" {
+		return "", false
+	}
+	topic, ok = (*r.routes.Load())[notificationType]
+	return topic, ok
+}
+
+// Topics trả về các topic được route tới (không trùng lặp), consumer cần subscribe thêm các topic này.
+func (r *TopicRouter) Topics() []string {
+	if r == nil {
+		return nil
+	}
+	routes := *r.routes.Load()
+	seen := make(map[string]bool, len(routes))
+	var topics []string
+	for _, topic := range routes {
+		if !seen[topic] {
+			seen[topic] = true
+			topics = append(topics, topic)
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
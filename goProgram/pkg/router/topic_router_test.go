@@ -0,0 +1,87 @@
+package router
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testRoutes = map[string]string{
+	"system_alert":     "notifications.alerts",
+	"chat_message":     "notifications.chat",
+	"payment_received": "notifications.alerts",
+}
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		name             string
+		router           *TopicRouter
+		notificationType string
+		wantTopic        string
+		wantOK           bool
+	}{
+		{name: "mapped type", router: NewTopicRouter(testRoutes), notificationType: "chat_message", wantTopic: "notifications.chat", wantOK: true},
+		{name: "types share a topic", router: NewTopicRouter(testRoutes), notificationType: "payment_received", wantTopic: "notifications.alerts", wantOK: true},
+		{name: "unmapped type", router: NewTopicRouter(testRoutes), notificationType: "newsletter"},
+		{name: "empty type", router: NewTopicRouter(testRoutes), notificationType: ""},
+		{name: "type is case sensitive", router: NewTopicRouter(testRoutes), notificationType: "System_Alert"},
+		{name: "nil routes", router: NewTopicRouter(nil), notificationType: "chat_message"},
+		{name: "nil router", router: nil, notificationType: "chat_message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topic, ok := tt.router.Route(tt.notificationType)
+			if topic != tt.wantTopic || ok != tt.wantOK {
+				t.Fatalf("Route(%q) = (%q, %v), want (%q, %v)", tt.notificationType, topic, ok, tt.wantTopic, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewTopicRouterCopiesRoutes(t *testing.T) {
+	routes := map[string]string{"chat_message": "notifications.chat"}
+	r := NewTopicRouter(routes)
+
+	routes["chat_message"] = "notifications.other"
+	routes["system_alert"] = "notifications.alerts"
+
+	if topic, _ := r.Route("chat_message"); topic != "notifications.chat" {
+		t.Fatalf("Route(chat_message) = %q, want notifications.chat", topic)
+	}
+	if _, ok := r.Route("system_alert"); ok {
+		t.Fatal("Route(system_alert) ok = true, want a route added after NewTopicRouter to be ignored")
+	}
+}
+
+func TestSetRoutes(t *testing.T) {
+	r := NewTopicRouter(testRoutes)
+	r.SetRoutes(map[string]string{"newsletter": "notifications.marketing"})
+
+	if topic, ok := r.Route("newsletter"); !ok || topic != "notifications.marketing" {
+		t.Fatalf("Route(newsletter) = (%q, %v), want (notifications.marketing, true)", topic, ok)
+	}
+	if _, ok := r.Route("chat_message"); ok {
+		t.Fatal("Route(chat_message) ok = true, want the old table replaced")
+	}
+	if got, want := r.Topics(), []string{"notifications.marketing"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Topics() = %v, want %v", got, want)
+	}
+}
+
+func TestTopics(t *testing.T) {
+	tests := []struct {
+		name   string
+		router *TopicRouter
+		want   []string
+	}{
+		{name: "deduplicated and sorted", router: NewTopicRouter(testRoutes), want: []string{"notifications.alerts", "notifications.chat"}},
+		{name: "nil routes", router: NewTopicRouter(nil), want: nil},
+		{name: "nil router", router: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.router.Topics(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Topics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package pb chứa code sinh từ notification_service.proto cho gRPC transport.
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative notification_service.proto
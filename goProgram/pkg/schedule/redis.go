@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore dùng sorted set scheduled:due (score là unix timestamp của DeliverAt),
+// hash scheduled:data (ID -> JSON) và set scheduled:sender:<fromID> để liệt kê theo người gửi.
+type RedisStore struct {
+	client *redis.Client
+}
+
+const (
+	dueKey  = "scheduled:due"
+	dataKey = "scheduled:data"
+)
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func senderKey(fromID int) string {
+	return "scheduled:sender:" + strconv.Itoa(fromID)
+}
+
+func (s *RedisStore) Schedule(ctx context.Context, scheduled ScheduledNotification) error {
+	payload, err := json.Marshal(scheduled)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scheduled notification: %w", err)
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, dataKey, scheduled.ID(), payload)
+		pipe.SAdd(ctx, senderKey(scheduled.Notification.From.ID), scheduled.ID())
+		pipe.ZAdd(ctx, dueKey, redis.Z{Score: float64(scheduled.DeliverAt.Unix()), Member: scheduled.ID()})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule notification: %w", err)
+	}
+	return nil
+}
+
+// Due chỉ trả về các notification mà ZREM của scheduler này xoá được,
+// scheduler khác đã lấy trước thì bỏ qua.
+func (s *RedisStore) Due(ctx context.Context, now time.Time, limit int) ([]ScheduledNotification, error) {
+	ids, err := s.client.ZRangeByScore(ctx, dueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due notifications: %w", err)
+	}
+
+	var due []ScheduledNotification
+	for _, id := range ids {
+		removed, err := s.client.ZRem(ctx, dueKey, id).Result()
+		if err != nil {
+			return due, fmt.Errorf("failed to claim scheduled notification %s: %w", id, err)
+		}
+		if removed == 0 {
+			continue
+		}
+		scheduled, err := s.remove(ctx, id)
+		if err != nil {
+			return due, err
+		}
+		due = append(due, scheduled)
+	}
+	return due, nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) (ScheduledNotification, error) {
+	payload, err := s.client.HGet(ctx, dataKey, id).Result()
+	if errors.Is(err, redis.Nil) {
+		return ScheduledNotification{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return ScheduledNotification{}, fmt.Errorf("failed to get scheduled notification: %w", err)
+	}
+	return decode(payload)
+}
+
+func (s *RedisStore) ListBySender(ctx context.Context, fromID int) ([]ScheduledNotification, error) {
+	ids, err := s.client.SMembers(ctx, senderKey(fromID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled notifications: %w", err)
+	}
+	scheduled := make([]ScheduledNotification, 0, len(ids))
+	if len(ids) == 0 {
+		return scheduled, nil
+	}
+	values, err := s.client.HMGet(ctx, dataKey, ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled notifications: %w", err)
+	}
+	for _, value := range values {
+		// ID còn trong set nhưng đã bị scheduler lấy đi thì HMGET trả về nil
+		payload, ok := value.(string)
+		if !ok {
+			continue
+		}
+		item, err := decode(payload)
+		if err != nil {
+			return nil, err
+		}
+		scheduled = append(scheduled, item)
+	}
+	sort.Slice(scheduled, func(i, j int) bool { return scheduled[i].DeliverAt.Before(scheduled[j].DeliverAt) })
+	return scheduled, nil
+}
+
+func (s *RedisStore) Cancel(ctx context.Context, id string) error {
+	removed, err := s.client.ZRem(ctx, dueKey, id).Result()
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled notification: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	_, err = s.remove(ctx, id)
+	return err
+}
+
+// remove xoá dữ liệu của id sau khi đã ZREM thành công.
+func (s *RedisStore) remove(ctx context.Context, id string) (ScheduledNotification, error) {
+	scheduled, err := s.Get(ctx, id)
+	if err != nil {
+		return ScheduledNotification{}, err
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, dataKey, id)
+		pipe.SRem(ctx, senderKey(scheduled.Notification.From.ID), id)
+		return nil
+	})
+	if err != nil {
+		return ScheduledNotification{}, fmt.Errorf("failed to remove scheduled notification %s: %w", id, err)
+	}
+	return scheduled, nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+func decode(payload string) (ScheduledNotification, error) {
+	var scheduled ScheduledNotification
+	if err := json.Unmarshal([]byte(payload), &scheduled); err != nil {
+		return ScheduledNotification{}, fmt.Errorf("failed to unmarshal scheduled notification: %w", err)
+	}
+	return scheduled, nil
+}
@@ -0,0 +1,39 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/models"
+	"time"
+)
+
+var ErrNotFound = errors.New("scheduled notification not found")
+
+// ScheduledNotification là notification đã được tạo (có ID, đã tra cứu user)
+// nhưng chỉ được gửi lên Kafka khi tới DeliverAt.
+type ScheduledNotification struct {
+	Notification models.Notification `json:"notification"`
+	DeliverAt    time.Time           `json:"deliverAt"`
+	// TTL tính từ lúc gửi thật sự, 0 nghĩa là không hết hạn
+	TTL           time.Duration `json:"ttl,omitempty"`
+	CorrelationID string        `json:"correlationID,omitempty"`
+	// NewThread giữ Notification.NewThread (không có trong JSON của Notification) tới lúc gửi
+	NewThread bool `json:"newThread,omitempty"`
+}
+
+// ID của lịch gửi chính là ID của notification.
+func (s ScheduledNotification) ID() string {
+	return s.Notification.ID
+}
+
+// ScheduledNotificationStore giữ các notification chờ tới hạn gửi.
+// Due lấy và xoá khỏi store các notification đã tới hạn, nên nhiều scheduler
+// chạy cùng lúc không gửi trùng một notification.
+type ScheduledNotificationStore interface {
+	Schedule(ctx context.Context, s ScheduledNotification) error
+	Due(ctx context.Context, now time.Time, limit int) ([]ScheduledNotification, error)
+	Get(ctx context.Context, id string) (ScheduledNotification, error)
+	// ListBySender trả về các notification đang chờ của fromID, sắp xếp theo DeliverAt
+	ListBySender(ctx context.Context, fromID int) ([]ScheduledNotification, error)
+	Cancel(ctx context.Context, id string) error
+}
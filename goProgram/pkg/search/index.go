@@ -0,0 +1,142 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"strconv"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+)
+
+// ErrEmptyQuery trả về khi từ khoá tìm kiếm rỗng.
+var ErrEmptyQuery = errors.New("search query must not be empty")
+
+// Hit là một notification khớp từ khoá cùng điểm liên quan của nó.
+type Hit struct {
+	Notification models.Notification `json:"notification"`
+	Score        float64             `json:"score"`
+}
+
+// document là dạng notification được đưa vào bleve: chỉ message được phân tích full-text,
+// toID là keyword để giới hạn kết quả theo người nhận, payload giữ nguyên notification để trả về.
+type document struct {
+	ToID    string `json:"toID"`
+	Message string `json:"message"`
+	Payload string `json:"payload"`
+}
+
+// Index là full-text index của notification theo Message, document ID là Notification.ID
+// nên index lại cùng một notification (ví dụ khi reindex) chỉ ghi đè chứ không tạo bản trùng.
+type Index struct {
+	index bleve.Index
+}
+
+// Open mở index tại path (BLEVE_INDEX_PATH), tạo mới nếu chưa có.
+// path rỗng nghĩa là index chỉ nằm trong bộ nhớ và mất khi restart.
+func Open(path string) (*Index, error) {
+	if path == "" {
+		index, err := bleve.NewMemOnly(newMapping())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create search index: %w", err)
+		}
+		return &Index{index: index}, nil
+	}
+
+	index, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		index, err = bleve.New(path, newMapping())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open search index %s: %w", path, err)
+	}
+	return &Index{index: index}, nil
+}
+
+func newMapping() *mapping.IndexMappingImpl {
+	message := bleve.NewTextFieldMapping()
+	message.Store = false
+
+	toID := bleve.NewKeywordFieldMapping()
+	toID.Store = false
+
+	payload := bleve.NewTextFieldMapping()
+	payload.Index = false
+	payload.IncludeInAll = false
+
+	doc := bleve.NewDocumentStaticMapping()
+	doc.AddFieldMappingsAt("message", message)
+	doc.AddFieldMappingsAt("toID", toID)
+	doc.AddFieldMappingsAt("payload", payload)
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = doc
+	return indexMapping
+}
+
+// Store index notification, notification chưa có ID (message cũ) bị bỏ qua.
+// Tên Store để Index dùng được như replay.Repository khi reindex.
+func (i *Index) Store(_ context.Context, n models.Notification) error {
+	if n.ID == "" {
+		return nil
+	}
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	err = i.index.Index(n.ID, document{
+		ToID:    strconv.Itoa(n.To.ID),
+		Message: n.Message,
+		Payload: string(payload),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index notification %s: %w", n.ID, err)
+	}
+	return nil
+}
+
+// Delete xoá notification khỏi index, ID không có trong index thì không làm gì.
+func (i *Index) Delete(_ context.Context, id string) error {
+	if err := i.index.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete notification %s from index: %w", id, err)
+	}
+	return nil
+}
+
+// Search tìm notification của userID có Message khớp query, sắp xếp theo điểm giảm dần.
+// Giá trị trả về thứ hai là tổng số notification khớp, bỏ qua limit/offset.
+func (i *Index) Search(ctx context.Context, userID int, query string, limit, offset int) ([]Hit, int, error) {
+	if query == "" {
+		return nil, 0, ErrEmptyQuery
+	}
+	match := bleve.NewMatchQuery(query)
+	match.SetField("message")
+	recipient := bleve.NewTermQuery(strconv.Itoa(userID))
+	recipient.SetField("toID")
+
+	request := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(match, recipient), limit, offset, false)
+	request.SortBy([]string{"-_score"})
+	request.Fields = []string{"payload"}
+	result, err := i.index.SearchInContext(ctx, request)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search notifications: %w", err)
+	}
+
+	hits := make([]Hit, 0, len(result.Hits))
+	for _, match := range result.Hits {
+		payload, _ := match.Fields["payload"].(string)
+		var n models.Notification
+		if err := json.Unmarshal([]byte(payload), &n); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal indexed notification %s: %w", match.ID, err)
+		}
+		hits = append(hits, Hit{Notification: n, Score: match.Score})
+	}
+	return hits, int(result.Total), nil
+}
+
+func (i *Index) Close() error {
+	return i.index.Close()
+}
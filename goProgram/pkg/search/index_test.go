@@ -0,0 +1,173 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/models"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// testMessages là message của 10 notification, notification-<i> gửi cho user 2 trừ notification-9
+var testMessages = []string{
+	"your invoice is ready",
+	"meeting moved to friday",
+	"invoice overdue: invoice 42 is unpaid",
+	"new comment on your post",
+	"payment received for invoice 17",
+	"password changed",
+	"weekly digest",
+	"friday deploy freeze",
+	"your order has shipped",
+	"invoice for another user",
+}
+
+func openTestIndex(t *testing.T, path string) *Index {
+	t.Helper()
+	index, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { _ = index.Close() })
+	return index
+}
+
+func indexTestNotifications(t *testing.T, index *Index) {
+	t.Helper()
+	for i, message := range testMessages {
+		to := models.User{ID: 2, Name: "Bob"}
+		if i == 9 {
+			to = models.User{ID: 3, Name: "Carol"}
+		}
+		n := models.Notification{
+			ID:       "notification-" + strconv.Itoa(i),
+			From:     models.User{ID: 1, Name: "Alice"},
+			To:       to,
+			Message:  message,
+			Priority: models.PriorityNormal,
+		}
+		if err := index.Store(context.Background(), n); err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+	}
+}
+
+func hitIDs(hits []Hit) []string {
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.Notification.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSearchReturnsOnlyMatches(t *testing.T) {
+	index := openTestIndex(t, "")
+	indexTestNotifications(t, index)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "invoice", want: []string{"notification-0", "notification-2", "notification-4"}},
+		{query: "friday", want: []string{"notification-1", "notification-7"}},
+		{query: "Shipped", want: []string{"notification-8"}},
+		{query: "refund", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			hits, total, err := index.Search(context.Background(), 2, tt.query, 20, 0)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if got := hitIDs(hits); total != len(tt.want) || !equalIDs(got, tt.want) {
+				t.Fatalf("Search(%q) = %v (total %d), want %v", tt.query, got, total, tt.want)
+			}
+			for _, hit := range hits {
+				if hit.Notification.To.ID != 2 || hit.Notification.Message != testMessages[indexOf(hit.Notification.ID)] {
+					t.Fatalf("hit %+v does not match the indexed notification", hit.Notification)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchSortedByScore(t *testing.T) {
+	index := openTestIndex(t, "")
+	indexTestNotifications(t, index)
+
+	hits, _, err := index.Search(context.Background(), 2, "invoice overdue", 20, 0)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	for i := 1; i < len(hits); i++ {
+		if hits[i].Score > hits[i-1].Score {
+			t.Fatalf("hits not sorted by score: %v before %v", hits[i-1].Score, hits[i].Score)
+		}
+	}
+	// chỉ notification-2 khớp cả hai từ nên có điểm cao nhất
+	if len(hits) == 0 || hits[0].Notification.ID != "notification-2" {
+		t.Fatalf("top hit = %v, want notification-2", hitIDs(hits))
+	}
+
+	page, total, err := index.Search(context.Background(), 2, "invoice overdue", 1, 1)
+	if err != nil || len(page) != 1 || total != 3 || page[0].Notification.ID != hits[1].Notification.ID {
+		t.Fatalf("Search(limit=1, offset=1) = %v, total %d, %v, want %s", hitIDs(page), total, err, hits[1].Notification.ID)
+	}
+}
+
+func TestSearchReindexAndDelete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.bleve")
+	index, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	indexTestNotifications(t, index)
+	// reindex cùng notification chỉ ghi đè
+	indexTestNotifications(t, index)
+	if err := index.Delete(context.Background(), "notification-0"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := index.Store(context.Background(), models.Notification{Message: "invoice without an ID", To: models.User{ID: 2}}); err != nil {
+		t.Fatalf("Store() without an ID error = %v", err)
+	}
+	if err := index.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// mở lại index trên đĩa vẫn còn dữ liệu
+	reopened := openTestIndex(t, path)
+	hits, total, err := reopened.Search(context.Background(), 2, "invoice", 20, 0)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if want := []string{"notification-2", "notification-4"}; total != 2 || !equalIDs(hitIDs(hits), want) {
+		t.Fatalf("Search(invoice) after reopen = %v (total %d), want %v", hitIDs(hits), total, want)
+	}
+}
+
+func TestSearchEmptyQuery(t *testing.T) {
+	index := openTestIndex(t, "")
+	if _, _, err := index.Search(context.Background(), 2, "", 20, 0); !errors.Is(err, ErrEmptyQuery) {
+		t.Fatalf("Search(\"\") error = %v, want %v", err, ErrEmptyQuery)
+	}
+}
+
+func equalIDs(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func indexOf(id string) int {
+	i, _ := strconv.Atoi(id[len("notification-"):])
+	return i
+}
@@ -0,0 +1,73 @@
+package sender
+
+import (
+	"kafka-notify/pkg/models"
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+)
+
+// Tên các strategy của KAFKA_KEY_STRATEGY
+const (
+	KeyStrategyRecipient = "recipient"
+	KeyStrategySender    = "sender"
+	KeyStrategyThread    = "thread"
+	KeyStrategyRandom    = "random"
+)
+
+// KeyStrategy chọn key của Kafka message cho notification. Key quyết định partition,
+// nên cũng quyết định nhóm notification nào được giữ đúng thứ tự với nhau.
+type KeyStrategy interface {
+	ComputeKey(n models.Notification) (sarama.Encoder, error)
+}
+
+// RecipientKeyStrategy dùng ID người nhận, giữ thứ tự notification theo từng user nhận.
+type RecipientKeyStrategy struct{}
+
+func (RecipientKeyStrategy) ComputeKey(n models.Notification) (sarama.Encoder, error) {
+	return sarama.StringEncoder(strconv.Itoa(n.To.ID)), nil
+}
+
+// SenderKeyStrategy dùng ID người gửi, giữ thứ tự các notification một user đã gửi đi.
+type SenderKeyStrategy struct{}
+
+func (SenderKeyStrategy) ComputeKey(n models.Notification) (sarama.Encoder, error) {
+	return sarama.StringEncoder(strconv.Itoa(n.From.ID)), nil
+}
+
+// ThreadKeyStrategy dùng ThreadID để cả thread nằm trên một partition. Notification không thuộc
+// thread nào, hoặc có thread vừa được sinh vì client không gửi threadID (NewThread), dùng ID người nhận
+// như RecipientKeyStrategy để giữ thứ tự theo người nhận như trước khi có thread.
+type ThreadKeyStrategy struct{}
+
+func (ThreadKeyStrategy) ComputeKey(n models.Notification) (sarama.Encoder, error) {
+	if n.ThreadID == "" || n.NewThread {
+		return RecipientKeyStrategy{}.ComputeKey(n)
+	}
+	return sarama.StringEncoder(n.ThreadID), nil
+}
+
+// RandomKeyStrategy dùng UUID mới cho mỗi message để chia đều tải trên các partition,
+// đổi lại không còn đảm bảo thứ tự giữa các notification.
+type RandomKeyStrategy struct{}
+
+func (RandomKeyStrategy) ComputeKey(models.Notification) (sarama.Encoder, error) {
+	return sarama.StringEncoder(uuid.NewString()), nil
+}
+
+// keyStrategies map giá trị của KAFKA_KEY_STRATEGY sang strategy, config đã validate tên
+var keyStrategies = map[string]KeyStrategy{
+	KeyStrategyRecipient: RecipientKeyStrategy{},
+	KeyStrategySender:    SenderKeyStrategy{},
+	KeyStrategyThread:    ThreadKeyStrategy{},
+	KeyStrategyRandom:    RandomKeyStrategy{},
+}
+
+// keyStrategy trả về opts.KeyStrategy, ThreadKeyStrategy (hành vi trước khi có KAFKA_KEY_STRATEGY) nếu chưa đặt.
+func (opts Options) keyStrategy() KeyStrategy {
+	if opts.KeyStrategy == nil {
+		return ThreadKeyStrategy{}
+	}
+	return opts.KeyStrategy
+}
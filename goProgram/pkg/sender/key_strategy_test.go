@@ -0,0 +1,91 @@
+package sender
+
+import (
+	"kafka-notify/pkg/models"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+)
+
+const testThreadID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+func TestKeyStrategies(t *testing.T) {
+	inThread := newTestNotification()
+	inThread.ThreadID = testThreadID
+	newThread := inThread
+	newThread.NewThread = true
+
+	tests := []struct {
+		name         string
+		strategy     KeyStrategy
+		notification models.Notification
+		// want rỗng là key phải là UUID ngẫu nhiên
+		want string
+	}{
+		{name: "recipient", strategy: RecipientKeyStrategy{}, notification: newTestNotification(), want: "2"},
+		{name: "recipient in a thread", strategy: RecipientKeyStrategy{}, notification: inThread, want: "2"},
+		{name: "sender", strategy: SenderKeyStrategy{}, notification: newTestNotification(), want: "1"},
+		{name: "thread", strategy: ThreadKeyStrategy{}, notification: inThread, want: testThreadID},
+		{name: "thread without thread ID", strategy: ThreadKeyStrategy{}, notification: newTestNotification(), want: "2"},
+		{name: "newly generated thread", strategy: ThreadKeyStrategy{}, notification: newThread, want: "2"},
+		{name: "random", strategy: RandomKeyStrategy{}, notification: newTestNotification()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.strategy.ComputeKey(tt.notification)
+			if err != nil {
+				t.Fatalf("ComputeKey() error = %v", err)
+			}
+			if _, ok := key.(sarama.StringEncoder); !ok {
+				t.Fatalf("ComputeKey() = %T, want sarama.StringEncoder", key)
+			}
+			encoded, _ := key.Encode()
+			if tt.want != "" {
+				if string(encoded) != tt.want {
+					t.Fatalf("ComputeKey() = %q, want %q", encoded, tt.want)
+				}
+				return
+			}
+			if _, err := uuid.Parse(string(encoded)); err != nil {
+				t.Fatalf("ComputeKey() = %q, want a UUID", encoded)
+			}
+			other, _ := tt.strategy.ComputeKey(tt.notification)
+			if otherEncoded, _ := other.Encode(); string(otherEncoded) == string(encoded) {
+				t.Fatal("random strategy returned the same key twice")
+			}
+		})
+	}
+}
+
+func TestOptionsKeyStrategy(t *testing.T) {
+	if _, ok := (Options{}).keyStrategy().(ThreadKeyStrategy); !ok {
+		t.Fatal("default key strategy is not ThreadKeyStrategy")
+	}
+	for name, strategy := range keyStrategies {
+		if got := (Options{KeyStrategy: strategy}).keyStrategy(); got != strategy {
+			t.Fatalf("keyStrategy() for %s = %T", name, got)
+		}
+	}
+}
+
+func BenchmarkKeyStrategies(b *testing.B) {
+	notification := newTestNotification()
+	notification.ThreadID = testThreadID
+	for _, name := range []string{KeyStrategyRecipient, KeyStrategySender, KeyStrategyThread, KeyStrategyRandom} {
+		strategy := keyStrategies[name]
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				key, err := strategy.ComputeKey(notification)
+				if err != nil {
+					b.Fatal(err)
+				}
+				// partitioner encode key cho mỗi message nên tính cả Encode
+				if _, err := key.Encode(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
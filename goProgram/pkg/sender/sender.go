@@ -0,0 +1,359 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	"kafka-notify/pkg/dedup"
+	"kafka-notify/pkg/enrichment"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/flags"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/partitioner"
+	"kafka-notify/pkg/router"
+	"kafka-notify/pkg/signing"
+	"kafka-notify/pkg/store"
+	"kafka-notify/pkg/tenant"
+	"kafka-notify/pkg/tracing"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+)
+
+// Options gom các thiết lập dùng khi tạo Kafka message,
+// dùng chung cho mọi transport (HTTP, gRPC) gửi notification.
+type Options struct {
+	// Brokers chỉ dùng để báo lỗi ErrBrokerUnreachable
+	Brokers     []string
+	TopicPrefix string
+	Codec       codec.Codec
+	// DefaultTTL dùng khi request không chỉ định TTL, 0 nghĩa là notification không hết hạn
+	DefaultTTL time.Duration
+	// SigningKey khác rỗng thì mỗi message được ký HMAC-SHA256 vào header X-Signature
+	SigningKey []byte
+	// SendTimeout là thời gian tối đa chờ Kafka xác nhận một lần gửi (KAFKA_SEND_TIMEOUT)
+	SendTimeout time.Duration
+	// Dedup khác nil thì Send từ chối notification giống hệt đã gửi trong DEDUP_TTL
+	Dedup *dedup.DeduplicationCache
+	// Enrichment chạy trên notification ngay trước khi encode, nil là không bổ sung gì
+	Enrichment *enrichment.EnrichmentPipeline
+	// Router chọn topic theo Notification.Type, type chưa map thì dùng topic theo priority
+	Router *router.TopicRouter
+	// MaxMessageBytes là kích thước payload tối đa sau khi encode (KAFKA_MAX_MESSAGE_BYTES), 0 là không giới hạn
+	MaxMessageBytes int
+	// MaxRequestBytes là độ dài tối đa của nội dung message (MAX_REQUEST_BODY_BYTES), 0 là không giới hạn
+	MaxRequestBytes int
+	// CompactedTopic là KAFKA_COMPACTED_TOPIC, xem EnsureKey
+	CompactedTopic bool
+	// KeyStrategy chọn key của message (KAFKA_KEY_STRATEGY), nil là ThreadKeyStrategy
+	KeyStrategy KeyStrategy
+}
+
+// ErrNilMessageKey là lỗi khi message không có key trong khi topic là compacted (KAFKA_COMPACTED_TOPIC=true),
+// broker từ chối message không key trên topic compact.
+var ErrNilMessageKey = errors.New("message key must not be empty on a compacted topic")
+
+// NewOptions tạo Options từ cấu hình chung của service.
+func NewOptions(cfg *config.Config, notificationCodec codec.Codec) Options {
+	opts := Options{
+		Brokers:         cfg.KafkaBrokers,
+		TopicPrefix:     cfg.KafkaTopicPrefix,
+		Codec:           notificationCodec,
+		DefaultTTL:      cfg.NotificationDefaultTTL,
+		SendTimeout:     cfg.KafkaSendTimeout,
+		MaxMessageBytes: cfg.KafkaMaxMessageBytes,
+		MaxRequestBytes: cfg.MaxRequestBodyBytes,
+		CompactedTopic:  cfg.KafkaCompactedTopic,
+		KeyStrategy:     keyStrategies[cfg.KafkaKeyStrategy],
+		Router:          router.NewTopicRouter(cfg.KafkaTopicRouting),
+		Enrichment: enrichment.NewEnrichmentPipeline(
+			enrichment.TimestampEnricher{},
+			enrichment.RequestIDEnricher{},
+		),
+	}
+	if cfg.MessageSigningKey != "" {
+		opts.SigningKey = []byte(cfg.MessageSigningKey)
+	}
+	return opts
+}
+
+// TopicFor chọn topic theo Type của notification qua Router,
+// nếu type chưa được map thì chọn theo priority. Topic được đặt trong namespace
+// tenant của người nhận (<tenantID>.<topic>) nên một producer dùng được cho mọi tenant.
+func (opts Options) TopicFor(notification models.Notification) string {
+	topic, ok := opts.Router.Route(notification.Type)
+	if !ok {
+		topic = config.PriorityTopic(opts.TopicPrefix, notification.Priority)
+	}
+	return tenant.Topic(notification.To.TenantID, topic)
+}
+
+// BuildNotification tra cứu người gửi và người nhận trong users để tạo notification,
+// mỗi notification được gán một ID (UUID) mới.
+func BuildNotification(ctx context.Context, users store.UserStore,
+	fromID, toID int, message string, priority int) (models.Notification, error) {
+	fromUser, err := users.FindByID(ctx, fromID)
+	if err != nil {
+		return models.Notification{}, fmt.Errorf("sender: %w", err)
+	}
+
+	toUser, err := users.FindByID(ctx, toID)
+	if err != nil {
+		return models.Notification{}, fmt.Errorf("recipient: %w", err)
+	}
+
+	return models.Notification{
+		ID:       uuid.NewString(),
+		From:     fromUser,
+		To:       toUser,
+		Message:  message,
+		Priority: priority,
+	}, nil
+}
+
+// NewMessage chạy enrichment pipeline, validate và encode notification thành Kafka message,
+// headers là các Kafka header bổ sung (ví dụ correlation ID) gắn thêm vào message.
+// Enrichment và chữ ký HMAC bị bỏ qua khi flag enrichment/hmac_signing trong ctx đang tắt.
+func NewMessage(ctx context.Context, opts Options, notification models.Notification,
+	headers ...sarama.RecordHeader) (*sarama.ProducerMessage, error) {
+	if flags.IsEnabled(ctx, flags.Enrichment) {
+		if err := opts.Enrichment.Enrich(ctx, &notification); err != nil {
+			return nil, fmt.Errorf("failed to enrich notification: %w", err)
+		}
+	}
+	if err := notification.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to validate notification: %w", err)
+	}
+
+	// metadata chỉ đi qua header, không encode vào payload
+	headers = append(headers, kafka.MetadataHeaders(notification.Metadata)...)
+	notification.Metadata = nil
+
+	//parse to Json (hoặc protobuf tuỳ codec), ngược lại là unMarshal
+	payload, err := opts.Codec.Marshal(notification)
+	if err != nil {
+		return nil, &apperrors.ErrSerialisationFailed{Codec: opts.Codec.ContentType(), Cause: err}
+	}
+	// broker từ chối message vượt max.message.bytes, kiểm tra trước để trả lỗi rõ ràng cho client
+	if opts.MaxMessageBytes > 0 && len(payload) > opts.MaxMessageBytes {
+		return nil, &apperrors.ErrMessageTooLarge{Size: len(payload), MaxSize: opts.MaxMessageBytes}
+	}
+
+	headers = append(headers,
+		kafka.Header(partitioner.HeaderFromID, strconv.Itoa(notification.From.ID)),
+		kafka.Header(partitioner.HeaderToID, strconv.Itoa(notification.To.ID)),
+		kafka.ProducerTimestampHeader(time.Now()))
+	if notification.To.TenantID != "" {
+		headers = append(headers, kafka.Header(tenant.HeaderTenantID, notification.To.TenantID))
+	}
+	if len(opts.SigningKey) > 0 && flags.IsEnabled(ctx, flags.HMACSigning) {
+		signature := signing.Sign(opts.SigningKey, payload)
+		headers = append(headers, kafka.Header(signing.HeaderSignature, signing.EncodeHeader(signature)))
+	}
+
+	key, err := opts.keyStrategy().ComputeKey(notification)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute message key: %w", err)
+	}
+
+	//Sử dụng &sarama.ProducerMessage là để tạo msg có kiểu là biến con trỏ
+	// mục đích sau khi tạo ra nó, thì có thể thao tác thay đổi giá trị trực tiếp của nó, nếu không dùng pointer thì ko thay đổi được
+	//EXAMPLE:
+	//msg.Topic = "NewTopic"
+	//msg.Key = sarama.StringEncoder("NewKey")
+	//msg.Value = sarama.StringEncoder("NewValue")
+	msg := &sarama.ProducerMessage{
+		Topic: opts.TopicFor(notification),
+		Key:   key,
+		Value: sarama.ByteEncoder(payload), //ByteEncoder là để parse sang kiểu dữ liệu có thể gửi cho Kafka
+		Headers: append([]sarama.RecordHeader{
+			kafka.Header(codec.HeaderContentType, opts.Codec.ContentType()),
+		}, headers...),
+	}
+	if err := EnsureKey(opts, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// EnsureKey gán UUID làm key cho msg chưa có key (nil hoặc rỗng), trừ khi topic là compacted:
+// key ngẫu nhiên không bao giờ bị compact nên trả về ErrNilMessageKey để lỗi lộ ra ngay.
+// NewMessage đã gọi EnsureKey, caller tự đổi msg.Key sau đó thì phải gọi lại.
+func EnsureKey(opts Options, msg *sarama.ProducerMessage) error {
+	if msg.Key != nil && msg.Key.Length() > 0 {
+		return nil
+	}
+	if opts.CompactedTopic {
+		return fmt.Errorf("%w: topic %s", ErrNilMessageKey, msg.Topic)
+	}
+	msg.Key = sarama.StringEncoder(uuid.NewString())
+	return nil
+}
+
+// NewTombstone tạo tombstone (Value nil, key là ID notification) để consumer xoá notification khỏi store.
+// Producer không biết notification nằm ở topic nào nên tombstone luôn đi vào topic priority normal
+// của tenant; consumer subscribe mọi topic nên vẫn nhận được.
+//
+// Message notification có key là ID người nhận, không phải ID notification, nên tombstone này không
+// làm log compaction xoá bản ghi gốc; không bật cleanup.policy=compact cho các topic notification
+// vì compact theo ID người nhận sẽ chỉ giữ lại notification mới nhất của mỗi user.
+// Với KAFKA_COMPACTED_TOPIC=true, tombstone chỉ xoá (sau delete.retention.ms) các bản ghi có key
+// trùng ID notification, notification trong store của consumer vẫn được xoá như bình thường.
+func NewTombstone(opts Options, tenantID, id string) *sarama.ProducerMessage {
+	var headers []sarama.RecordHeader
+	if tenantID != "" {
+		headers = append(headers, kafka.Header(tenant.HeaderTenantID, tenantID))
+	}
+	if len(opts.SigningKey) > 0 {
+		// ký payload rỗng để consumer bật MESSAGE_SIGNING_KEY không chuyển tombstone sang DLQ
+		signature := signing.Sign(opts.SigningKey, nil)
+		headers = append(headers, kafka.Header(signing.HeaderSignature, signing.EncodeHeader(signature)))
+	}
+	return &sarama.ProducerMessage{
+		Topic:   tenant.Topic(tenantID, config.PriorityTopic(opts.TopicPrefix, models.PriorityNormal)),
+		Key:     sarama.StringEncoder(id),
+		Headers: headers,
+	}
+}
+
+// SendTombstone gửi tombstone của notification id, chờ tối đa theo ctx.
+func SendTombstone(ctx context.Context, producer sarama.SyncProducer, opts Options, tenantID, id string) error {
+	// tombstone không key không xoá được gì, kể cả khi topic không compact
+	if id == "" {
+		return ErrNilMessageKey
+	}
+	_, _, err := sendMessage(ctx, producer, opts, NewTombstone(opts, tenantID, id))
+	return err
+}
+
+// Send gửi notification qua SyncProducer trong một tracing span,
+// trace context được inject vào header để consumer nối tiếp span.
+func Send(ctx context.Context, producer sarama.SyncProducer, opts Options,
+	notification models.Notification, headers ...sarama.RecordHeader) (int32, int64, error) {
+	spanCtx, span := tracing.StartProducerSpan(ctx, opts.TopicFor(notification))
+	defer span.End()
+
+	msg, err := NewMessage(ctx, opts, notification, headers...)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, 0, err
+	}
+	tracing.InjectProducerMessage(spanCtx, msg)
+
+	release, err := claim(ctx, opts, msg, notification)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return 0, 0, err
+	}
+	// return 3 value: partition, offset, error
+	/*
+		partition: số partition của topic mà thông điệp đã được gửi đến. Mỗi topic có thể được chia thành nhiều partition để phân tán dữ liệu.
+		offset: vị trí của partition
+	*/
+	partition, offset, err := sendMessage(ctx, producer, opts, msg)
+	if err != nil {
+		// hết KAFKA_SEND_TIMEOUT thì message vẫn có thể đã tới Kafka, giữ đánh dấu để chặn retry trùng
+		if ctx.Err() == nil {
+			release()
+		}
+		tracing.RecordError(span, err)
+		return 0, 0, err
+	}
+	tracing.SetDelivered(span, partition, offset)
+	return partition, offset, nil
+}
+
+// SendAsync giống Send nhưng chỉ đẩy message vào producer.Input() rồi trả về, chờ tối đa theo ctx.
+// Kết quả gửi tới sau qua Successes() và Errors(), caller đọc Errors() phải gọi ReleaseAsync
+// để xoá đánh dấu dedup của message gửi thất bại.
+func SendAsync(ctx context.Context, producer sarama.AsyncProducer, opts Options,
+	notification models.Notification, headers ...sarama.RecordHeader) error {
+	spanCtx, span := tracing.StartProducerSpan(ctx, opts.TopicFor(notification))
+	defer span.End()
+
+	msg, err := NewMessage(ctx, opts, notification, headers...)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	tracing.InjectProducerMessage(spanCtx, msg)
+
+	release, err := claim(ctx, opts, msg, notification)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	msg.Metadata = asyncRelease(release)
+	select {
+	case producer.Input() <- msg:
+		return nil
+	case <-ctx.Done():
+		// message chưa vào producer nên chắc chắn chưa tới Kafka
+		release()
+		err := fmt.Errorf("failed to enqueue message to %s: %w", msg.Topic, ctx.Err())
+		tracing.RecordError(span, err)
+		return err
+	}
+}
+
+// asyncRelease là msg.Metadata do SendAsync gắn vào message
+type asyncRelease func()
+
+// ReleaseAsync xoá đánh dấu dedup của message SendAsync đã gửi nhưng Kafka báo lỗi.
+func ReleaseAsync(msg *sarama.ProducerMessage) {
+	if release, ok := msg.Metadata.(asyncRelease); ok {
+		release()
+	}
+}
+
+// claim đánh dấu notification trong dedup cache trước khi gửi,
+// hàm trả về dùng để xoá đánh dấu nếu gửi thất bại.
+func claim(ctx context.Context, opts Options, msg *sarama.ProducerMessage,
+	notification models.Notification) (func(), error) {
+	if opts.Dedup == nil {
+		return func() {}, nil
+	}
+	hash, err := dedup.Hash(notification)
+	if err != nil {
+		return nil, err
+	}
+	key := strconv.Itoa(notification.To.ID)
+	if err := opts.Dedup.Claim(ctx, msg.Topic, key, hash); err != nil {
+		return nil, err
+	}
+	return func() {
+		// message async có thể lỗi sau khi request đã kết thúc, không dùng ctx của request
+		releaseCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		opts.Dedup.Release(releaseCtx, msg.Topic, key, hash)
+	}, nil
+}
+
+type sendResult struct {
+	partition int32
+	offset    int64
+	err       error
+}
+
+// sendMessage cho phép huỷ SendMessage theo ctx vì SyncProducer không nhận context.
+// Khi ctx hết hạn, message vẫn có thể được Kafka ghi nhận sau đó; caller chỉ
+// biết là không chờ được kết quả. Lỗi broker và topic được chuyển sang kiểu lỗi của pkg/errors.
+func sendMessage(ctx context.Context, producer sarama.SyncProducer, opts Options,
+	msg *sarama.ProducerMessage) (int32, int64, error) {
+	done := make(chan sendResult, 1)
+	go func() {
+		partition, offset, err := producer.SendMessage(msg)
+		done <- sendResult{partition: partition, offset: offset, err: err}
+	}()
+	select {
+	case result := <-done:
+		return result.partition, result.offset, kafka.ClassifyError(opts.Brokers, msg.Topic, result.err)
+	case <-ctx.Done():
+		return 0, 0, fmt.Errorf("failed to send message to %s: %w", msg.Topic, ctx.Err())
+	}
+}
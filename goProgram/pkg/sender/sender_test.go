@@ -0,0 +1,184 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"kafka-notify/pkg/codec"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/router"
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+var (
+	testFrom = models.User{ID: 1, Name: "Alice"}
+	testTo   = models.User{ID: 2, Name: "Bob"}
+)
+
+func newTestOptions() Options {
+	return Options{TopicPrefix: "notifications", Codec: codec.JSONCodec{}}
+}
+
+func newTestNotification() models.Notification {
+	return models.Notification{
+		ID:       "5f0c7c3e-8f9a-4a57-9d51-3b8a2f8d9e01",
+		From:     testFrom,
+		To:       testTo,
+		Message:  "hello",
+		Priority: models.PriorityNormal,
+	}
+}
+
+// consumedHeaders chuyển header của ProducerMessage sang dạng ConsumerMessage nhận được
+func consumedHeaders(msg *sarama.ProducerMessage) []*sarama.RecordHeader {
+	headers := make([]*sarama.RecordHeader, len(msg.Headers))
+	for i := range msg.Headers {
+		headers[i] = &msg.Headers[i]
+	}
+	return headers
+}
+
+func TestNewMessageMetadataRoundTripUnicode(t *testing.T) {
+	metadata := map[string]string{
+		"source":      "điện thoại",
+		"app_version": "2.1",
+		"reaction":    "👍🏽",
+		"note":        "通知 · إشعار",
+	}
+	notification := newTestNotification()
+	notification.Metadata = metadata
+
+	msg, err := NewMessage(context.Background(), newTestOptions(), notification)
+	if err != nil {
+		t.Fatalf("NewMessage() error = %v", err)
+	}
+	if got := kafka.Metadata(consumedHeaders(msg)); !reflect.DeepEqual(got, metadata) {
+		t.Fatalf("metadata from headers = %q, want %q", got, metadata)
+	}
+
+	// metadata chỉ đi qua header, payload không mang metadata
+	payload, err := msg.Value.Encode()
+	if err != nil {
+		t.Fatalf("failed to encode value: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if _, ok := decoded["metadata"]; ok {
+		t.Fatalf("payload contains metadata: %s", payload)
+	}
+}
+
+func TestNewMessageMaxMessageBytesBoundary(t *testing.T) {
+	unlimited, err := NewMessage(context.Background(), newTestOptions(), newTestNotification())
+	if err != nil {
+		t.Fatalf("NewMessage() error = %v", err)
+	}
+	size := unlimited.Value.Length()
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		wantErr  bool
+	}{
+		{name: "exactly at the limit", maxBytes: size},
+		{name: "one byte over", maxBytes: size - 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := newTestOptions()
+			opts.MaxMessageBytes = tt.maxBytes
+			_, err := NewMessage(context.Background(), opts, newTestNotification())
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("NewMessage() error = %v", err)
+				}
+				return
+			}
+			var tooLarge *apperrors.ErrMessageTooLarge
+			if !errors.As(err, &tooLarge) {
+				t.Fatalf("NewMessage() error = %v, want ErrMessageTooLarge", err)
+			}
+			if tooLarge.Size != size || tooLarge.MaxSize != tt.maxBytes {
+				t.Fatalf("ErrMessageTooLarge = %+v, want size %d, max %d", tooLarge, size, tt.maxBytes)
+			}
+		})
+	}
+}
+
+func TestEnsureKey(t *testing.T) {
+	opts := newTestOptions()
+	for _, key := range []sarama.Encoder{nil, sarama.StringEncoder("")} {
+		msg := &sarama.ProducerMessage{Topic: "notifications.normal", Key: key}
+		if err := EnsureKey(opts, msg); err != nil {
+			t.Fatalf("EnsureKey() error = %v", err)
+		}
+		// topic không compact thì key rỗng được thay bằng UUID
+		if msg.Key == nil || msg.Key.Length() != 36 {
+			t.Fatalf("key = %v, want a UUID", msg.Key)
+		}
+	}
+
+	opts.CompactedTopic = true
+	msg := &sarama.ProducerMessage{Topic: "notifications.normal"}
+	if err := EnsureKey(opts, msg); !errors.Is(err, ErrNilMessageKey) {
+		t.Fatalf("EnsureKey() on a compacted topic error = %v, want %v", err, ErrNilMessageKey)
+	}
+	msg.Key = sarama.StringEncoder("2")
+	if err := EnsureKey(opts, msg); err != nil {
+		t.Fatalf("EnsureKey() with a key error = %v", err)
+	}
+}
+
+func TestNewTombstoneKeyIsNotificationID(t *testing.T) {
+	msg := NewTombstone(newTestOptions(), "acme", "5f0c7c3e-8f9a-4a57-9d51-3b8a2f8d9e01")
+	key, err := msg.Key.Encode()
+	if err != nil || string(key) != "5f0c7c3e-8f9a-4a57-9d51-3b8a2f8d9e01" {
+		t.Fatalf("tombstone key = %q, %v, want the notification ID", key, err)
+	}
+	if msg.Value != nil {
+		t.Fatal("tombstone has a value")
+	}
+}
+
+func TestTopicFor(t *testing.T) {
+	opts := newTestOptions()
+	opts.Router = router.NewTopicRouter(map[string]string{"system_alert": "notifications.alerts"})
+
+	tests := []struct {
+		name             string
+		notificationType string
+		priority         int
+		tenantID         string
+		want             string
+	}{
+		{name: "mapped type", notificationType: "system_alert", priority: models.PriorityNormal, want: "notifications.alerts"},
+		{name: "mapped type ignores priority", notificationType: "system_alert", priority: models.PriorityHigh, want: "notifications.alerts"},
+		{name: "unmapped type falls back to priority", notificationType: "newsletter", priority: models.PriorityHigh, want: "notifications.high"},
+		{name: "no type falls back to priority", priority: models.PriorityNormal, want: "notifications.normal"},
+		{name: "mapped type in tenant namespace", notificationType: "system_alert", tenantID: "acme", want: "acme.notifications.alerts"},
+		{name: "fallback in tenant namespace", priority: models.PriorityNormal, tenantID: "acme", want: "acme.notifications.normal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := newTestNotification()
+			notification.Type = tt.notificationType
+			notification.Priority = tt.priority
+			notification.To.TenantID = tt.tenantID
+			if got := opts.TopicFor(notification); got != tt.want {
+				t.Fatalf("TopicFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// không cấu hình Router thì luôn chọn theo priority
+	if got := newTestOptions().TopicFor(newTestNotification()); got != "notifications.normal" {
+		t.Fatalf("TopicFor() without a router = %q, want notifications.normal", got)
+	}
+}
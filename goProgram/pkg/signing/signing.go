@@ -0,0 +1,35 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HeaderSignature chứa HMAC-SHA256 (hex) của payload, consumer dùng để phát hiện
+// message bị sửa trên đường truyền hoặc không phải do producer của mình gửi.
+const HeaderSignature = "X-Signature"
+
+// ReasonSignatureMismatch là X-Error-Reason khi message bị chuyển sang DLQ vì sai chữ ký.
+const ReasonSignatureMismatch = "signature-mismatch"
+
+// Sign trả về HMAC-SHA256 của payload với key.
+func Sign(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Verify so sánh chữ ký bằng hmac.Equal để không lộ thông tin qua thời gian so sánh.
+func Verify(key, payload, sig []byte) bool {
+	return hmac.Equal(Sign(key, payload), sig)
+}
+
+// EncodeHeader và DecodeHeader chuyển chữ ký sang dạng hex để đặt trong Kafka header.
+func EncodeHeader(sig []byte) string {
+	return hex.EncodeToString(sig)
+}
+
+func DecodeHeader(value string) ([]byte, error) {
+	return hex.DecodeString(value)
+}
@@ -0,0 +1,77 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"testing"
+)
+
+// chữ ký đúng bằng RFC 4231 test case 2
+func TestSignKnownVector(t *testing.T) {
+	got := EncodeHeader(Sign([]byte("Jefe"), []byte("what do ya want for nothing?")))
+	if want := "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"; got != want {
+		t.Fatalf("Sign() = %s, want %s", got, want)
+	}
+}
+
+func FuzzSign(f *testing.F) {
+	f.Add([]byte("secret"), []byte(`{"message":"hello"}`))
+	f.Add([]byte{}, []byte{})
+	f.Add(bytes.Repeat([]byte{0xff}, 100), []byte("payload"))
+	f.Fuzz(func(t *testing.T, key, payload []byte) {
+		sig := Sign(key, payload)
+		if len(sig) != sha256.Size {
+			t.Fatalf("signature has %d bytes, want %d", len(sig), sha256.Size)
+		}
+		if !bytes.Equal(sig, Sign(key, payload)) {
+			t.Fatal("Sign() is not deterministic")
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write(payload)
+		if !bytes.Equal(sig, mac.Sum(nil)) {
+			t.Fatal("Sign() differs from HMAC-SHA256")
+		}
+		decoded, err := DecodeHeader(EncodeHeader(sig))
+		if err != nil || !bytes.Equal(decoded, sig) {
+			t.Fatalf("header round-trip = %x, %v, want %x", decoded, err, sig)
+		}
+	})
+}
+
+// equivalentKeys cho biết hai key cho cùng HMAC: key ngắn hơn block size được thêm byte 0 cho đủ block
+func equivalentKeys(a, b []byte) bool {
+	blockSize := sha256.New().BlockSize()
+	if len(a) > blockSize || len(b) > blockSize {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(bytes.TrimRight(a, "\x00"), bytes.TrimRight(b, "\x00"))
+}
+
+func FuzzVerify(f *testing.F) {
+	f.Add([]byte("secret"), []byte(`{"message":"hello"}`), []byte("other"))
+	f.Add([]byte{}, []byte{}, []byte{0})
+	f.Fuzz(func(t *testing.T, key, payload, other []byte) {
+		sig := Sign(key, payload)
+		if !Verify(key, payload, sig) {
+			t.Fatal("Verify() rejects its own signature")
+		}
+		if !bytes.Equal(other, payload) && Verify(key, other, sig) {
+			t.Fatalf("Verify() accepts the signature of %q for %q", payload, other)
+		}
+		if !equivalentKeys(key, other) && Verify(other, payload, sig) {
+			t.Fatal("Verify() accepts a signature made with another key")
+		}
+		// mọi chữ ký bị sửa một bit đều bị từ chối
+		for i := range sig {
+			tampered := append([]byte(nil), sig...)
+			tampered[i] ^= 1
+			if Verify(key, payload, tampered) {
+				t.Fatalf("Verify() accepts a signature with byte %d flipped", i)
+			}
+		}
+		if Verify(key, payload, sig[:len(sig)-1]) || Verify(key, payload, nil) {
+			t.Fatal("Verify() accepts a truncated signature")
+		}
+	})
+}
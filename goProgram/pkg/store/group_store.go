@@ -0,0 +1,23 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/models"
+)
+
+var (
+	ErrGroupNotFound = errors.New("group not found")
+	ErrGroupTooLarge = errors.New("group exceeds MAX_GROUP_SIZE")
+)
+
+// GroupStore lưu các group người nhận, mỗi method chỉ thấy group thuộc tenant trong ctx.
+// Create và AddMembers trả về ErrGroupTooLarge khi số member vượt giới hạn của store.
+type GroupStore interface {
+	// Create gán ID cho g và trả về group đã lưu
+	Create(ctx context.Context, g models.Group) (models.Group, error)
+	Get(ctx context.Context, id int) (models.Group, error)
+	// AddMembers bỏ qua user đã là member
+	AddMembers(ctx context.Context, id int, userIDs ...int) (models.Group, error)
+	RemoveMember(ctx context.Context, id, userID int) (models.Group, error)
+}
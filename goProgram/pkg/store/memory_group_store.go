@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/tenant"
+	"sync"
+)
+
+// MemoryGroupStore giữ group trong bộ nhớ của một instance producer, ID tăng dần dùng chung mọi tenant.
+type MemoryGroupStore struct {
+	groups  map[int]models.Group
+	nextID  int
+	maxSize int
+	mu      sync.RWMutex
+}
+
+// NewMemoryGroupStore tạo store giới hạn mỗi group tối đa maxSize member.
+func NewMemoryGroupStore(maxSize int) *MemoryGroupStore {
+	return &MemoryGroupStore{groups: make(map[int]models.Group), maxSize: maxSize}
+}
+
+func (s *MemoryGroupStore) Create(ctx context.Context, g models.Group) (models.Group, error) {
+	if err := g.Validate(); err != nil {
+		return models.Group{}, err
+	}
+	g.MemberIDs = appendMembers(nil, g.MemberIDs...)
+	if len(g.MemberIDs) > s.maxSize {
+		return models.Group{}, fmt.Errorf("%w: %d members, limit is %d", ErrGroupTooLarge, len(g.MemberIDs), s.maxSize)
+	}
+	g.TenantID = tenant.FromContext(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	g.ID = s.nextID
+	s.groups[g.ID] = g
+	return cloneGroup(g), nil
+}
+
+func (s *MemoryGroupStore) Get(ctx context.Context, id int) (models.Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	g, err := s.get(ctx, id)
+	if err != nil {
+		return models.Group{}, err
+	}
+	return cloneGroup(g), nil
+}
+
+func (s *MemoryGroupStore) AddMembers(ctx context.Context, id int, userIDs ...int) (models.Group, error) {
+	for _, userID := range userIDs {
+		if userID <= 0 {
+			return models.Group{}, fmt.Errorf("%w: member id must be positive, got %d", models.ErrInvalidGroup, userID)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, err := s.get(ctx, id)
+	if err != nil {
+		return models.Group{}, err
+	}
+	members := appendMembers(append([]int(nil), g.MemberIDs...), userIDs...)
+	if len(members) > s.maxSize {
+		return models.Group{}, fmt.Errorf("%w: %d members, limit is %d", ErrGroupTooLarge, len(members), s.maxSize)
+	}
+	g.MemberIDs = members
+	s.groups[id] = g
+	return cloneGroup(g), nil
+}
+
+func (s *MemoryGroupStore) RemoveMember(ctx context.Context, id, userID int) (models.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, err := s.get(ctx, id)
+	if err != nil {
+		return models.Group{}, err
+	}
+	members := make([]int, 0, len(g.MemberIDs))
+	for _, member := range g.MemberIDs {
+		if member != userID {
+			members = append(members, member)
+		}
+	}
+	g.MemberIDs = members
+	s.groups[id] = g
+	return cloneGroup(g), nil
+}
+
+// get phải được gọi khi đã giữ mu, group của tenant khác coi như không tồn tại
+func (s *MemoryGroupStore) get(ctx context.Context, id int) (models.Group, error) {
+	g, ok := s.groups[id]
+	if !ok || g.TenantID != tenant.FromContext(ctx) {
+		return models.Group{}, fmt.Errorf("%w: %d", ErrGroupNotFound, id)
+	}
+	return g, nil
+}
+
+// appendMembers thêm userIDs chưa có trong members, giữ thứ tự thêm vào
+func appendMembers(members []int, userIDs ...int) []int {
+	seen := make(map[int]bool, len(members)+len(userIDs))
+	for _, id := range members {
+		seen[id] = true
+	}
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			members = append(members, id)
+		}
+	}
+	if members == nil {
+		members = []int{}
+	}
+	return members
+}
+
+// cloneGroup trả về bản sao để caller sửa MemberIDs không ảnh hưởng tới store
+func cloneGroup(g models.Group) models.Group {
+	g.MemberIDs = append([]int{}, g.MemberIDs...)
+	return g
+}
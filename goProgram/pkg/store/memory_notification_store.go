@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"kafka-notify/pkg/models"
+	"sync"
+	"time"
+)
+
+//RWMutex cho phép nhiều goroutine có thể đọc dữ liệu cùng một lúc mà không cần khóa (lock) hoặc chặn lẫn nhau.
+//Tuy nhiên, chỉ một goroutine duy nhất được phép ghi (write) dữ liệu vào khi một RWMutex đang được khóa cho ghi.
+
+type MemoryNotificationStore struct {
+	data map[int][]models.Notification
+	mu   sync.RWMutex
+}
+
+func NewMemoryNotificationStore() *MemoryNotificationStore {
+	return &MemoryNotificationStore{data: make(map[int][]models.Notification)}
+}
+
+func (s *MemoryNotificationStore) Store(_ context.Context, n models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[n.To.ID] = append(s.data[n.To.ID], n)
+	return nil
+}
+
+func (s *MemoryNotificationStore) FindByUserID(_ context.Context, toID int, filter NotificationFilter) ([]models.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if filter.matchesAll() {
+		return paginate(s.data[toID], filter.Limit, filter.Offset), nil
+	}
+	page, _ := applyFilter(s.data[toID], filter)
+	return page, nil
+}
+
+func (s *MemoryNotificationStore) CountByUserID(_ context.Context, toID int, filter NotificationFilter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if filter.matchesAll() {
+		return len(s.data[toID]), nil
+	}
+	_, total := applyFilter(s.data[toID], filter)
+	return total, nil
+}
+
+// FindByThreadID duyệt mọi user vì store chỉ được đánh chỉ mục theo người nhận.
+func (s *MemoryNotificationStore) FindByThreadID(_ context.Context, threadID string) ([]models.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	thread := []models.Notification{}
+	for _, notifications := range s.data {
+		for _, n := range notifications {
+			if n.ThreadID == threadID {
+				thread = append(thread, n)
+			}
+		}
+	}
+	sortByCreatedAt(thread)
+	return thread, nil
+}
+
+// Delete duyệt mọi user vì store chỉ được đánh chỉ mục theo người nhận.
+func (s *MemoryNotificationStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for toID, notifications := range s.data {
+		for i, n := range notifications {
+			if n.ID != id {
+				continue
+			}
+			// tạo slice mới để trang đã trả cho caller trước đó không bị thay đổi
+			s.data[toID] = append(append([]models.Notification(nil), notifications[:i]...), notifications[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotificationNotFound
+}
+
+// ExpiredBefore duyệt mọi user vì store chỉ được đánh chỉ mục theo người nhận.
+func (s *MemoryNotificationStore) ExpiredBefore(_ context.Context, t time.Time) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var ids []string
+	for _, notifications := range s.data {
+		for _, n := range notifications {
+			if n.ID != "" && !n.ExpiresAt.IsZero() && n.ExpiresAt.Before(t) {
+				ids = append(ids, n.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// paginate copy ra slice mới để caller không giữ tham chiếu tới dữ liệu đang được khoá.
+func paginate(notifications []models.Notification, limit, offset int) []models.Notification {
+	if offset >= len(notifications) {
+		return []models.Notification{}
+	}
+	end := len(notifications)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	page := make([]models.Notification, end-offset)
+	copy(page, notifications[offset:end])
+	return page
+}
@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/tenant"
+	"sync"
+)
+
+type MemoryPreferencesStore struct {
+	data map[userKey]models.UserPreferences
+	mu   sync.RWMutex
+}
+
+func NewMemoryPreferencesStore() *MemoryPreferencesStore {
+	return &MemoryPreferencesStore{data: make(map[userKey]models.UserPreferences)}
+}
+
+func (s *MemoryPreferencesStore) Get(ctx context.Context, userID int) (models.UserPreferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if p, ok := s.data[userKey{tenantID: tenant.FromContext(ctx), id: userID}]; ok {
+		return p, nil
+	}
+	return models.UserPreferences{UserID: userID}, nil
+}
+
+func (s *MemoryPreferencesStore) Save(ctx context.Context, p models.UserPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[userKey{tenantID: tenant.FromContext(ctx), id: p.UserID}] = p
+	return nil
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/tenant"
+	"sync"
+)
+
+type memoryChannel struct {
+	channel     models.Channel
+	subscribers []int
+}
+
+// MemorySubscriptionStore giữ channel trong bộ nhớ của một instance producer, key là tenant.Key(tenantID, name).
+type MemorySubscriptionStore struct {
+	channels       map[string]*memoryChannel
+	maxSubscribers int
+	mu             sync.RWMutex
+}
+
+// NewMemorySubscriptionStore tạo store giới hạn mỗi channel tối đa maxSubscribers subscriber.
+func NewMemorySubscriptionStore(maxSubscribers int) *MemorySubscriptionStore {
+	return &MemorySubscriptionStore{channels: make(map[string]*memoryChannel), maxSubscribers: maxSubscribers}
+}
+
+func (s *MemorySubscriptionStore) CreateChannel(ctx context.Context, c models.Channel) (models.Channel, error) {
+	if err := c.Validate(); err != nil {
+		return models.Channel{}, err
+	}
+	c.TenantID = tenant.FromContext(ctx)
+	key := tenant.Key(c.TenantID, c.Name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.channels[key]; ok {
+		return models.Channel{}, fmt.Errorf("%w: %s", ErrChannelExists, c.Name)
+	}
+	s.channels[key] = &memoryChannel{channel: c}
+	return c, nil
+}
+
+func (s *MemorySubscriptionStore) Channel(ctx context.Context, name string) (models.Channel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, err := s.get(ctx, name)
+	if err != nil {
+		return models.Channel{}, err
+	}
+	return c.channel, nil
+}
+
+func (s *MemorySubscriptionStore) Subscribe(ctx context.Context, name string, userID int) error {
+	if userID <= 0 {
+		return fmt.Errorf("%w: userID must be positive, got %d", models.ErrInvalidChannel, userID)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.get(ctx, name)
+	if err != nil {
+		return err
+	}
+	for _, id := range c.subscribers {
+		if id == userID {
+			return nil
+		}
+	}
+	if len(c.subscribers) >= s.maxSubscribers {
+		return fmt.Errorf("%w: limit is %d", ErrChannelFull, s.maxSubscribers)
+	}
+	c.subscribers = append(c.subscribers, userID)
+	return nil
+}
+
+func (s *MemorySubscriptionStore) Subscribers(ctx context.Context, name string) ([]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, err := s.get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return append([]int{}, c.subscribers...), nil
+}
+
+// get phải được gọi khi đã giữ mu
+func (s *MemorySubscriptionStore) get(ctx context.Context, name string) (*memoryChannel, error) {
+	c, ok := s.channels[tenant.Key(tenant.FromContext(ctx), name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrChannelNotFound, name)
+	}
+	return c, nil
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/tenant"
+	"sort"
+	"sync"
+)
+
+// MemoryUserStore lưu user trong map, dùng cho test và chạy local không cần database.
+// Mọi method đều an toàn khi gọi đồng thời: đọc dùng RLock, ghi dùng Lock.
+// Mỗi method chỉ thấy user thuộc tenant trong ctx (tenant.FromContext).
+type MemoryUserStore struct {
+	users map[userKey]models.User
+	mu    sync.RWMutex
+}
+
+// userKey là khoá của user, user ID chỉ duy nhất trong một tenant
+type userKey struct {
+	tenantID string
+	id       int
+}
+
+// NewMemoryUserStore tạo store với sẵn users, mỗi user thuộc tenant theo TenantID của nó.
+func NewMemoryUserStore(users ...models.User) *MemoryUserStore {
+	store := &MemoryUserStore{users: make(map[userKey]models.User, len(users))}
+	for _, user := range users {
+		store.users[userKey{tenantID: user.TenantID, id: user.ID}] = user
+	}
+	return store
+}
+
+func (s *MemoryUserStore) FindByID(ctx context.Context, id int) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.users[userKey{tenantID: tenant.FromContext(ctx), id: id}]
+	if !ok {
+		return models.User{}, &apperrors.ErrUserNotFound{ID: id}
+	}
+	return user, nil
+}
+
+// List trả về bản sao, caller sửa slice không ảnh hưởng tới store.
+func (s *MemoryUserStore) List(ctx context.Context) ([]models.User, error) {
+	tenantID := tenant.FromContext(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	users := make([]models.User, 0, len(s.users))
+	for key, user := range s.users {
+		if key.tenantID == tenantID {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// Create gán TenantID của u theo tenant trong ctx.
+func (s *MemoryUserStore) Create(ctx context.Context, u models.User) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+	u.TenantID = tenant.FromContext(ctx)
+	key := userKey{tenantID: u.TenantID, id: u.ID}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[key]; ok {
+		return ErrUserExists
+	}
+	s.users[key] = u
+	return nil
+}
+
+func (s *MemoryUserStore) Update(ctx context.Context, u models.User) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+	u.TenantID = tenant.FromContext(ctx)
+	key := userKey{tenantID: u.TenantID, id: u.ID}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[key]; !ok {
+		return &apperrors.ErrUserNotFound{ID: u.ID}
+	}
+	s.users[key] = u
+	return nil
+}
+
+func (s *MemoryUserStore) Delete(ctx context.Context, id int) error {
+	key := userKey{tenantID: tenant.FromContext(ctx), id: id}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[key]; !ok {
+		return &apperrors.ErrUserNotFound{ID: id}
+	}
+	delete(s.users, key)
+	return nil
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/tenant"
+	"sync"
+	"testing"
+)
+
+// TestMemoryUserStoreConcurrentAccess chạy Create và FindByID song song, chạy với go test -race.
+func TestMemoryUserStoreConcurrentAccess(t *testing.T) {
+	const writers, usersPerWriter = 8, 50
+	users := NewMemoryUserStore()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		w := w
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= usersPerWriter; i++ {
+				id := w*usersPerWriter + i
+				if err := users.Create(ctx, models.User{ID: id, Name: fmt.Sprintf("user-%d", id)}); err != nil {
+					t.Errorf("Create(%d) error = %v", id, err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= usersPerWriter; i++ {
+				id := w*usersPerWriter + i
+				// user có thể chưa được tạo, chỉ lỗi not found là hợp lệ
+				user, err := users.FindByID(ctx, id)
+				var notFound *apperrors.ErrUserNotFound
+				if err != nil && !errors.As(err, &notFound) {
+					t.Errorf("FindByID(%d) error = %v", id, err)
+				}
+				if err == nil && user.ID != id {
+					t.Errorf("FindByID(%d) returned user %d", id, user.ID)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	all, err := users.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(all) != writers*usersPerWriter {
+		t.Fatalf("List() returned %d users, want %d", len(all), writers*usersPerWriter)
+	}
+}
+
+func TestMemoryUserStoreIsolatesTenants(t *testing.T) {
+	users := NewMemoryUserStore(models.User{ID: 1, Name: "Alice"})
+	acme := tenant.WithID(context.Background(), "acme")
+
+	if _, err := users.FindByID(acme, 1); err == nil {
+		t.Fatal("FindByID() found a user of the default tenant in tenant acme")
+	}
+	if err := users.Create(acme, models.User{ID: 1, Name: "Alice (acme)"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	user, err := users.FindByID(acme, 1)
+	if err != nil || user.TenantID != "acme" {
+		t.Fatalf("FindByID() = %+v, %v, want user of tenant acme", user, err)
+	}
+	if err := users.Create(acme, models.User{ID: 1, Name: "again"}); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("Create() duplicate error = %v, want %v", err, ErrUserExists)
+	}
+}
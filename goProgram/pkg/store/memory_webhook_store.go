@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"sync"
+)
+
+type MemoryWebhookStore struct {
+	data map[string]models.Webhook
+	mu   sync.RWMutex
+}
+
+func NewMemoryWebhookStore() *MemoryWebhookStore {
+	return &MemoryWebhookStore{data: make(map[string]models.Webhook)}
+}
+
+func (s *MemoryWebhookStore) Save(_ context.Context, w models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[w.ID] = w
+	return nil
+}
+
+func (s *MemoryWebhookStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+	}
+	delete(s.data, id)
+	return nil
+}
+
+func (s *MemoryWebhookStore) FindByUserID(_ context.Context, userID int) ([]models.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	webhooks := []models.Webhook{}
+	for _, w := range s.data {
+		if w.UserID == userID {
+			webhooks = append(webhooks, w)
+		}
+	}
+	return webhooks, nil
+}
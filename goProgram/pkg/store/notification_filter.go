@@ -0,0 +1,73 @@
+package store
+
+import (
+	"kafka-notify/pkg/models"
+	"sort"
+)
+
+// Giá trị hợp lệ của NotificationFilter.SortBy và SortDir.
+// created_at là thứ tự consumer nhận được notification.
+const (
+	SortByCreatedAt = "created_at"
+	SortByPriority  = "priority"
+	SortAsc         = "asc"
+	SortDesc        = "desc"
+)
+
+// NotificationFilter là điều kiện lọc, sắp xếp và phân trang khi liệt kê notification.
+// Giá trị zero nghĩa là không lọc FromID/MinPriority, Limit 0 là không giới hạn.
+type NotificationFilter struct {
+	Limit       int
+	Offset      int
+	SortBy      string
+	SortDir     string
+	FromID      int
+	MinPriority int
+	// ExcludeIDs là các notification ID bị loại khỏi kết quả (ví dụ đã ack khi unread=true)
+	ExcludeIDs map[string]bool
+}
+
+// matchesAll là true khi filter không lọc hay đổi thứ tự, store có thể phân trang trực tiếp.
+func (f NotificationFilter) matchesAll() bool {
+	return f.FromID == 0 && f.MinPriority == 0 && len(f.ExcludeIDs) == 0 &&
+		(f.SortBy == "" || f.SortBy == SortByCreatedAt) && f.SortDir != SortDesc
+}
+
+func (f NotificationFilter) matches(n models.Notification) bool {
+	if f.FromID != 0 && n.From.ID != f.FromID {
+		return false
+	}
+	if f.ExcludeIDs[n.ID] {
+		return false
+	}
+	return n.Priority >= f.MinPriority
+}
+
+// applyFilter lọc và sắp xếp notifications (theo thứ tự nhận) rồi trả về trang
+// theo Limit/Offset cùng tổng số notification khớp filter.
+func applyFilter(notifications []models.Notification, f NotificationFilter) ([]models.Notification, int) {
+	matched := make([]models.Notification, 0, len(notifications))
+	for _, n := range notifications {
+		if f.matches(n) {
+			matched = append(matched, n)
+		}
+	}
+
+	if f.SortBy == SortByPriority {
+		// stable để các notification cùng priority giữ thứ tự nhận
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].Priority < matched[j].Priority })
+	}
+	if f.SortDir == SortDesc {
+		for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+			matched[i], matched[j] = matched[j], matched[i]
+		}
+	}
+	return paginate(matched, f.Limit, f.Offset), len(matched)
+}
+
+// sortByCreatedAt sắp xếp theo CreatedAt tăng dần, stable để notification cùng thời điểm giữ thứ tự nhận.
+func sortByCreatedAt(notifications []models.Notification) {
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.Before(notifications[j].CreatedAt)
+	})
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/models"
+	"time"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationStore lưu các notification consumer đã nhận, tra cứu theo người nhận (To.ID).
+type NotificationStore interface {
+	Store(ctx context.Context, n models.Notification) error
+	// FindByUserID trả về trang notification của toID theo filter.
+	FindByUserID(ctx context.Context, toID int, filter NotificationFilter) ([]models.Notification, error)
+	// CountByUserID đếm số notification của toID khớp filter, bỏ qua Limit/Offset.
+	CountByUserID(ctx context.Context, toID int, filter NotificationFilter) (int, error)
+	// FindByThreadID trả về mọi notification của thread, sắp xếp theo CreatedAt tăng dần.
+	FindByThreadID(ctx context.Context, threadID string) ([]models.Notification, error)
+	// Delete xoá notification theo ID, trả về ErrNotificationNotFound nếu không có.
+	Delete(ctx context.Context, id string) error
+	// ExpiredBefore trả về ID các notification có ExpiresAt khác zero và trước t.
+	// Notification không có ID không xoá được bằng Delete nên không được trả về.
+	ExpiredBefore(ctx context.Context, t time.Time) ([]string, error)
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"kafka-notify/pkg/models"
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func notificationStores() map[string]func(t *testing.T) NotificationStore {
+	return map[string]func(t *testing.T) NotificationStore{
+		"memory": func(t *testing.T) NotificationStore { return NewMemoryNotificationStore() },
+		"redis": func(t *testing.T) NotificationStore {
+			return NewRedisNotificationStore(redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}))
+		},
+	}
+}
+
+// storeNotifications lưu count notification cho user 2 (và một ít cho user 3), người gửi xen kẽ 1/2
+// và priority xoay vòng low..critical
+func storeNotifications(t *testing.T, notifications NotificationStore, count int) {
+	t.Helper()
+	ctx := context.Background()
+	for i := 0; i < count; i++ {
+		n := models.Notification{
+			ID:       "notification-" + strconv.Itoa(i),
+			From:     models.User{ID: 1 + i%2, Name: "sender"},
+			To:       models.User{ID: 2, Name: "Bob"},
+			Message:  "hello " + strconv.Itoa(i),
+			Priority: models.PriorityLow + i%4,
+		}
+		if err := notifications.Store(ctx, n); err != nil {
+			t.Fatalf("Store() error = %v", err)
+		}
+		if i%10 == 0 {
+			n.ID, n.To = "other-"+strconv.Itoa(i), models.User{ID: 3, Name: "Carol"}
+			if err := notifications.Store(ctx, n); err != nil {
+				t.Fatalf("Store() error = %v", err)
+			}
+		}
+	}
+}
+
+// pageAll đọc lần lượt từng trang, offset trang sau là offset trang trước cộng số phần tử đã nhận,
+// dừng khi gặp trang rỗng. Lỗi nếu một notification xuất hiện ở hai trang.
+func pageAll(t *testing.T, notifications NotificationStore, filter NotificationFilter) []models.Notification {
+	t.Helper()
+	var all []models.Notification
+	seen := make(map[string]int)
+	for page := 0; ; page++ {
+		if page > 1000 {
+			t.Fatal("paging did not terminate")
+		}
+		filter.Offset = len(all)
+		got, err := notifications.FindByUserID(context.Background(), 2, filter)
+		if err != nil {
+			t.Fatalf("FindByUserID(offset %d) error = %v", filter.Offset, err)
+		}
+		if len(got) > filter.Limit {
+			t.Fatalf("page %d has %d notifications, want at most %d", page, len(got), filter.Limit)
+		}
+		if len(got) == 0 {
+			return all
+		}
+		for _, n := range got {
+			if previous, ok := seen[n.ID]; ok {
+				t.Fatalf("%s is on page %d and page %d", n.ID, previous, page)
+			}
+			seen[n.ID] = page
+		}
+		all = append(all, got...)
+	}
+}
+
+func TestNotificationStorePagesDoNotOverlap(t *testing.T) {
+	const total = 150
+	for name, newStore := range notificationStores() {
+		t.Run(name, func(t *testing.T) {
+			notifications := newStore(t)
+			storeNotifications(t, notifications, total)
+
+			all := pageAll(t, notifications, NotificationFilter{Limit: 20, SortBy: SortByCreatedAt, SortDir: SortAsc})
+			if len(all) != total {
+				t.Fatalf("paged %d notifications, want %d", len(all), total)
+			}
+			for i, n := range all {
+				if n.ID != "notification-"+strconv.Itoa(i) {
+					t.Fatalf("notification %d = %s, want notification-%d", i, n.ID, i)
+				}
+			}
+			if count, err := notifications.CountByUserID(context.Background(), 2, NotificationFilter{}); err != nil || count != total {
+				t.Fatalf("CountByUserID() = %d, %v, want %d", count, err, total)
+			}
+		})
+	}
+}
+
+// trang của kết quả đã lọc và sắp xếp lại cũng không chồng lên nhau và giữ đúng thứ tự
+func TestNotificationStoreFilteredPagesDoNotOverlap(t *testing.T) {
+	const total = 150
+	filter := NotificationFilter{Limit: 7, SortBy: SortByPriority, SortDir: SortDesc, FromID: 1}
+	for name, newStore := range notificationStores() {
+		t.Run(name, func(t *testing.T) {
+			notifications := newStore(t)
+			storeNotifications(t, notifications, total)
+
+			all := pageAll(t, notifications, filter)
+			want, err := notifications.CountByUserID(context.Background(), 2, filter)
+			if err != nil {
+				t.Fatalf("CountByUserID() error = %v", err)
+			}
+			// người gửi 1 là các i chẵn, priority xen kẽ low và high
+			if want != total/2 || len(all) != want {
+				t.Fatalf("paged %d notifications, count %d, want %d", len(all), want, total/2)
+			}
+			for i, n := range all {
+				if n.From.ID != 1 {
+					t.Fatalf("%s from %d does not match the filter", n.ID, n.From.ID)
+				}
+				if i > 0 && n.Priority > all[i-1].Priority {
+					t.Fatalf("%s priority %d after %d, want descending", n.ID, n.Priority, all[i-1].Priority)
+				}
+			}
+		})
+	}
+}
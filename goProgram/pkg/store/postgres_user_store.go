@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	apperrors "kafka-notify/pkg/errors"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/tenant"
+
+	"github.com/lib/pq"
+)
+
+// UsersTableSchema là schema tối thiểu mà PostgresUserStore cần, user ID chỉ duy nhất trong một tenant.
+const UsersTableSchema = `CREATE TABLE IF NOT EXISTS users (
+	tenant_id TEXT    NOT NULL DEFAULT '',
+	id        INTEGER NOT NULL,
+	name      TEXT    NOT NULL,
+	PRIMARY KEY (tenant_id, id)
+)`
+
+// UsersTenantMigration thêm cột tenant_id cho bảng users tạo trước khi có multi-tenancy,
+// user có sẵn thuộc tenant mặc định. Primary key cũ (id) được giữ nguyên nên ID vẫn phải
+// duy nhất giữa các tenant cho tới khi đổi primary key thủ công.
+const UsersTenantMigration = `ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT ''`
+
+// mã lỗi unique_violation của PostgreSQL
+const pqUniqueViolation = "23505"
+
+type PostgresUserStore struct {
+	db *sql.DB
+}
+
+func NewPostgresUserStore(db *sql.DB) *PostgresUserStore {
+	return &PostgresUserStore{db: db}
+}
+
+// OpenPostgresUserStore mở kết nối tới databaseURL, kiểm tra kết nối và tạo bảng users nếu chưa có.
+func OpenPostgresUserStore(ctx context.Context, databaseURL string) (*PostgresUserStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, UsersTableSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create users table: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, UsersTenantMigration); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate users table: %w", err)
+	}
+	return NewPostgresUserStore(db), nil
+}
+
+func (s *PostgresUserStore) FindByID(ctx context.Context, id int) (models.User, error) {
+	user := models.User{TenantID: tenant.FromContext(ctx)}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, name FROM users WHERE tenant_id = $1 AND id = $2`, user.TenantID, id).Scan(&user.ID, &user.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, &apperrors.ErrUserNotFound{ID: id}
+	}
+	if err != nil {
+		return models.User{}, fmt.Errorf("failed to find user %d: %w", id, err)
+	}
+	return user, nil
+}
+
+func (s *PostgresUserStore) List(ctx context.Context) ([]models.User, error) {
+	tenantID := tenant.FromContext(ctx)
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name FROM users WHERE tenant_id = $1 ORDER BY id`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user := models.User{TenantID: tenantID}
+		if err := rows.Scan(&user.ID, &user.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (s *PostgresUserStore) Create(ctx context.Context, u models.User) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO users (tenant_id, id, name) VALUES ($1, $2, $3)`, tenant.FromContext(ctx), u.ID, u.Name)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation {
+		return ErrUserExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create user %d: %w", u.ID, err)
+	}
+	return nil
+}
+
+func (s *PostgresUserStore) Update(ctx context.Context, u models.User) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE users SET name = $3 WHERE tenant_id = $1 AND id = $2`, tenant.FromContext(ctx), u.ID, u.Name)
+	if err != nil {
+		return fmt.Errorf("failed to update user %d: %w", u.ID, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update user %d: %w", u.ID, err)
+	}
+	if affected == 0 {
+		return &apperrors.ErrUserNotFound{ID: u.ID}
+	}
+	return nil
+}
+
+func (s *PostgresUserStore) Delete(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE tenant_id = $1 AND id = $2`, tenant.FromContext(ctx), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user %d: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete user %d: %w", id, err)
+	}
+	if affected == 0 {
+		return &apperrors.ErrUserNotFound{ID: id}
+	}
+	return nil
+}
+
+// Close đóng connection pool bên dưới.
+func (s *PostgresUserStore) Close() error {
+	return s.db.Close()
+}
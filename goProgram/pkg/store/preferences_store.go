@@ -0,0 +1,13 @@
+package store
+
+import (
+	"context"
+	"kafka-notify/pkg/models"
+)
+
+// PreferencesStore lưu UserPreferences của từng người nhận. User chưa từng lưu
+// preferences thì Get trả về giá trị mặc định (không lọc gì). Preferences được tách theo tenant trong ctx.
+type PreferencesStore interface {
+	Get(ctx context.Context, userID int) (models.UserPreferences, error)
+	Save(ctx context.Context, p models.UserPreferences) error
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisNotificationStore lưu notification của mỗi user trong một Redis list
+// (key notifications:user:<toID>) theo thứ tự nhận được, hash notifications:owner
+// map ID notification sang toID để Delete biết list nào cần sửa. Notification có ThreadID
+// được ghi thêm vào list notifications:thread:<threadID>. Notification có ExpiresAt được ghi ID vào
+// sorted set notifications:expiry (score là Unix giây) để ExpiredBefore không phải duyệt mọi list.
+type RedisNotificationStore struct {
+	client *redis.Client
+}
+
+func NewRedisNotificationStore(client *redis.Client) *RedisNotificationStore {
+	return &RedisNotificationStore{client: client}
+}
+
+const (
+	notificationOwnerKey  = "notifications:owner"
+	notificationExpiryKey = "notifications:expiry"
+)
+
+func notificationsKey(toID int) string {
+	return "notifications:user:" + strconv.Itoa(toID)
+}
+
+func threadKey(threadID string) string {
+	return "notifications:thread:" + threadID
+}
+
+func (s *RedisNotificationStore) Store(ctx context.Context, n models.Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	pipe := s.client.TxPipeline()
+	pipe.RPush(ctx, notificationsKey(n.To.ID), payload)
+	if n.ID != "" {
+		pipe.HSet(ctx, notificationOwnerKey, n.ID, n.To.ID)
+	}
+	if n.ThreadID != "" {
+		pipe.RPush(ctx, threadKey(n.ThreadID), payload)
+	}
+	if n.ID != "" && !n.ExpiresAt.IsZero() {
+		pipe.ZAdd(ctx, notificationExpiryKey, redis.Z{Score: float64(n.ExpiresAt.Unix()), Member: n.ID})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store notification: %w", err)
+	}
+	return nil
+}
+
+// Delete tìm list của người nhận qua notifications:owner rồi LREM đúng phần tử có ID đó.
+// Notification lưu trước khi có owner hash không xoá được và trả về ErrNotificationNotFound.
+func (s *RedisNotificationStore) Delete(ctx context.Context, id string) error {
+	toID, err := s.client.HGet(ctx, notificationOwnerKey, id).Int()
+	if errors.Is(err, redis.Nil) {
+		return ErrNotificationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find notification owner: %w", err)
+	}
+	values, err := s.client.LRange(ctx, notificationsKey(toID), 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list notifications: %w", err)
+	}
+	for _, value := range values {
+		var n models.Notification
+		if err := json.Unmarshal([]byte(value), &n); err != nil || n.ID != id {
+			continue
+		}
+		pipe := s.client.TxPipeline()
+		pipe.LRem(ctx, notificationsKey(toID), 1, value)
+		pipe.HDel(ctx, notificationOwnerKey, id)
+		pipe.ZRem(ctx, notificationExpiryKey, id)
+		if n.ThreadID != "" {
+			pipe.LRem(ctx, threadKey(n.ThreadID), 1, value)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to delete notification: %w", err)
+		}
+		return nil
+	}
+	s.client.HDel(ctx, notificationOwnerKey, id)
+	s.client.ZRem(ctx, notificationExpiryKey, id)
+	return ErrNotificationNotFound
+}
+
+// ExpiredBefore đọc notifications:expiry, score nhỏ hơn t (không tính t).
+func (s *RedisNotificationStore) ExpiredBefore(ctx context.Context, t time.Time) ([]string, error) {
+	ids, err := s.client.ZRangeByScore(ctx, notificationExpiryKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: "(" + strconv.FormatInt(t.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired notifications: %w", err)
+	}
+	return ids, nil
+}
+
+// FindByUserID chỉ đọc đúng trang cần thiết khi filter không lọc hay sắp xếp lại,
+// ngược lại phải đọc cả list rồi lọc trong bộ nhớ.
+func (s *RedisNotificationStore) FindByUserID(ctx context.Context, toID int, filter NotificationFilter) ([]models.Notification, error) {
+	if !filter.matchesAll() {
+		all, err := s.lrange(ctx, toID, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		page, _ := applyFilter(all, filter)
+		return page, nil
+	}
+	return s.lrange(ctx, toID, filter.Limit, filter.Offset)
+}
+
+func (s *RedisNotificationStore) lrange(ctx context.Context, toID int, limit, offset int) ([]models.Notification, error) {
+	// LRANGE dùng chỉ số đóng hai đầu, stop = -1 nghĩa là tới cuối list
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(offset + limit - 1)
+	}
+	values, err := s.client.LRange(ctx, notificationsKey(toID), int64(offset), stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	notifications := make([]models.Notification, 0, len(values))
+	for _, value := range values {
+		var n models.Notification
+		if err := json.Unmarshal([]byte(value), &n); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, nil
+}
+
+func (s *RedisNotificationStore) FindByThreadID(ctx context.Context, threadID string) ([]models.Notification, error) {
+	values, err := s.client.LRange(ctx, threadKey(threadID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list thread: %w", err)
+	}
+	thread := make([]models.Notification, 0, len(values))
+	for _, value := range values {
+		var n models.Notification
+		if err := json.Unmarshal([]byte(value), &n); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal notification: %w", err)
+		}
+		thread = append(thread, n)
+	}
+	sortByCreatedAt(thread)
+	return thread, nil
+}
+
+func (s *RedisNotificationStore) CountByUserID(ctx context.Context, toID int, filter NotificationFilter) (int, error) {
+	if !filter.matchesAll() {
+		all, err := s.lrange(ctx, toID, 0, 0)
+		if err != nil {
+			return 0, err
+		}
+		_, total := applyFilter(all, filter)
+		return total, nil
+	}
+	count, err := s.client.LLen(ctx, notificationsKey(toID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	return int(count), nil
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/tenant"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPreferencesStore lưu preferences dạng JSON dưới key preferences:user:<userID>,
+// preferences của tenant khác tenant mặc định nằm dưới tenant:<tenantID>:preferences:user:<userID>.
+type RedisPreferencesStore struct {
+	client *redis.Client
+}
+
+func NewRedisPreferencesStore(client *redis.Client) *RedisPreferencesStore {
+	return &RedisPreferencesStore{client: client}
+}
+
+func preferencesKey(ctx context.Context, userID int) string {
+	return tenant.Key(tenant.FromContext(ctx), "preferences:user:"+strconv.Itoa(userID))
+}
+
+func (s *RedisPreferencesStore) Get(ctx context.Context, userID int) (models.UserPreferences, error) {
+	payload, err := s.client.Get(ctx, preferencesKey(ctx, userID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return models.UserPreferences{UserID: userID}, nil
+	}
+	if err != nil {
+		return models.UserPreferences{}, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	var p models.UserPreferences
+	if err := json.Unmarshal([]byte(payload), &p); err != nil {
+		return models.UserPreferences{}, fmt.Errorf("failed to unmarshal preferences: %w", err)
+	}
+	return p, nil
+}
+
+func (s *RedisPreferencesStore) Save(ctx context.Context, p models.UserPreferences) error {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal preferences: %w", err)
+	}
+	if err := s.client.Set(ctx, preferencesKey(ctx, p.UserID), payload, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisWebhookStore lưu webhook trong hash webhooks:data (ID -> JSON)
+// và set webhooks:user:<userID> chứa ID các webhook của user.
+type RedisWebhookStore struct {
+	client *redis.Client
+}
+
+const webhooksDataKey = "webhooks:data"
+
+func NewRedisWebhookStore(client *redis.Client) *RedisWebhookStore {
+	return &RedisWebhookStore{client: client}
+}
+
+func webhooksUserKey(userID int) string {
+	return "webhooks:user:" + strconv.Itoa(userID)
+}
+
+func (s *RedisWebhookStore) Save(ctx context.Context, w models.Webhook) error {
+	payload, err := json.Marshal(w)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, webhooksDataKey, w.ID, payload)
+		pipe.SAdd(ctx, webhooksUserKey(w.UserID), w.ID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisWebhookStore) Delete(ctx context.Context, id string) error {
+	payload, err := s.client.HGet(ctx, webhooksDataKey, id).Result()
+	if errors.Is(err, redis.Nil) {
+		return fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get webhook: %w", err)
+	}
+	var w models.Webhook
+	if err := json.Unmarshal([]byte(payload), &w); err != nil {
+		return fmt.Errorf("failed to unmarshal webhook: %w", err)
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, webhooksDataKey, id)
+		pipe.SRem(ctx, webhooksUserKey(w.UserID), id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisWebhookStore) FindByUserID(ctx context.Context, userID int) ([]models.Webhook, error) {
+	ids, err := s.client.SMembers(ctx, webhooksUserKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	webhooks := make([]models.Webhook, 0, len(ids))
+	if len(ids) == 0 {
+		return webhooks, nil
+	}
+	values, err := s.client.HMGet(ctx, webhooksDataKey, ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	for _, value := range values {
+		payload, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var w models.Webhook
+		if err := json.Unmarshal([]byte(payload), &w); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webhook: %w", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, nil
+}
@@ -0,0 +1,23 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/models"
+)
+
+var (
+	ErrChannelNotFound = errors.New("channel not found")
+	ErrChannelExists   = errors.New("channel already exists")
+	ErrChannelFull     = errors.New("channel exceeds MAX_FANOUT_SIZE subscribers")
+)
+
+// SubscriptionStore lưu các channel và subscriber của chúng, mỗi method chỉ thấy channel thuộc tenant trong ctx.
+type SubscriptionStore interface {
+	CreateChannel(ctx context.Context, c models.Channel) (models.Channel, error)
+	Channel(ctx context.Context, name string) (models.Channel, error)
+	// Subscribe bỏ qua user đã subscribe, trả về ErrChannelFull khi vượt giới hạn của store
+	Subscribe(ctx context.Context, name string, userID int) error
+	// Subscribers trả về user ID theo thứ tự subscribe
+	Subscribers(ctx context.Context, name string) ([]int, error)
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/models"
+)
+
+// user không tồn tại được trả về dạng *apperrors.ErrUserNotFound của pkg/errors
+var ErrUserExists = errors.New("user already exists")
+
+// UserStore là nơi lưu danh sách user mà producer dùng để tra cứu người gửi/người nhận.
+// User được tách theo tenant: mọi method chỉ đọc/ghi user thuộc tenant trong ctx (tenant.FromContext)
+// và user trả về có TenantID là tenant đó.
+type UserStore interface {
+	FindByID(ctx context.Context, id int) (models.User, error)
+	List(ctx context.Context) ([]models.User, error)
+	Create(ctx context.Context, u models.User) error
+	// Update thay thế user có cùng ID, trả về *apperrors.ErrUserNotFound nếu chưa tồn tại
+	Update(ctx context.Context, u models.User) error
+	Delete(ctx context.Context, id int) error
+}
+
+// OpenUserStore mở Postgres user store, nếu databaseURL rỗng thì dùng danh sách user mẫu
+// trong bộ nhớ cho tenant mặc định và từng tenant trong tenants. Hàm trả về dùng để đóng store khi shutdown.
+func OpenUserStore(ctx context.Context, databaseURL string, tenants ...string) (UserStore, func(), error) {
+	if databaseURL == "" {
+		var samples []models.User
+		for _, tenantID := range append([]string{""}, tenants...) {
+			samples = append(samples,
+				models.User{ID: 1, Name: "Emma", TenantID: tenantID},
+				models.User{ID: 2, Name: "Bruno", TenantID: tenantID},
+				models.User{ID: 3, Name: "Rick", TenantID: tenantID},
+				models.User{ID: 4, Name: "Lena", TenantID: tenantID},
+			)
+		}
+		return NewMemoryUserStore(samples...), func() {}, nil
+	}
+
+	users, err := OpenPostgresUserStore(ctx, databaseURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, func() { users.Close() }, nil
+}
@@ -0,0 +1,16 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/models"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// WebhookStore lưu các webhook đã đăng ký, tra cứu theo người nhận (UserID).
+type WebhookStore interface {
+	Save(ctx context.Context, w models.Webhook) error
+	Delete(ctx context.Context, id string) error
+	FindByUserID(ctx context.Context, userID int) ([]models.Webhook, error)
+}
@@ -0,0 +1,35 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"sync"
+)
+
+// MemoryStore dùng khi không có Redis, template chỉ tồn tại trong instance hiện tại.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	templates map[string]models.Template
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{templates: make(map[string]models.Template)}
+}
+
+func (s *MemoryStore) Save(_ context.Context, tpl models.Template) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.templates[tpl.ID] = tpl
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (models.Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tpl, ok := s.templates[id]
+	if !ok {
+		return models.Template{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return tpl, nil
+}
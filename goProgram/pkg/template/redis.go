@@ -0,0 +1,43 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "template:"
+
+// RedisStore lưu Body của mỗi template dưới key template:<id>, không hết hạn.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Save(ctx context.Context, tpl models.Template) error {
+	if err := s.client.Set(ctx, keyPrefix+tpl.ID, tpl.Body, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) (models.Template, error) {
+	body, err := s.client.Get(ctx, keyPrefix+id).Result()
+	if errors.Is(err, redis.Nil) {
+		return models.Template{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return models.Template{}, fmt.Errorf("failed to get template: %w", err)
+	}
+	return models.Template{ID: id, Body: body}, nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,49 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kafka-notify/pkg/models"
+	"strings"
+	texttemplate "text/template"
+)
+
+var (
+	ErrNotFound        = errors.New("template not found")
+	ErrInvalidTemplate = errors.New("invalid template")
+)
+
+// Store lưu template theo ID.
+type Store interface {
+	Save(ctx context.Context, tpl models.Template) error
+	Get(ctx context.Context, id string) (models.Template, error)
+}
+
+// Parse kiểm tra cú pháp của template trước khi lưu.
+// Placeholder không có trong data sẽ làm Render lỗi thay vì in ra "<no value>".
+func Parse(tpl models.Template) (*texttemplate.Template, error) {
+	if tpl.Body == "" {
+		return nil, fmt.Errorf("%w: body must not be empty", ErrInvalidTemplate)
+	}
+	parsed, err := texttemplate.New(tpl.ID).Option("missingkey=error").Parse(tpl.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return parsed, nil
+}
+
+// Render dùng text/template chứ không phải html/template: notification là plain text,
+// escape HTML sẽ làm hỏng nội dung (ví dụ "&" thành "&amp;"). Giá trị trong data được
+// in nguyên văn, không được đánh giá lại như template nên không bị inject action.
+func Render(tpl models.Template, data map[string]any) (string, error) {
+	parsed, err := Parse(tpl)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := parsed.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return out.String(), nil
+}
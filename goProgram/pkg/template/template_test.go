@@ -0,0 +1,95 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"kafka-notify/pkg/models"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tpl := models.Template{ID: "greeting", Body: "{{.From}} sent you a message, {{.To}}"}
+	got, err := Render(tpl, map[string]any{"From": "Alice", "To": "Bob"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "Alice sent you a message, Bob"; got != want {
+		t.Fatalf("Render() = %q, want %q", got, want)
+	}
+}
+
+// giá trị trong data được in nguyên văn: không escape HTML và không được đánh giá như template
+func TestRenderInjection(t *testing.T) {
+	tpl := models.Template{ID: "injection", Body: "From {{.From}} to {{.To}}"}
+	tests := []struct {
+		name string
+		from string
+		want string
+	}{
+		{name: "html tag", from: "<script>alert(1)</script>", want: "From <script>alert(1)</script> to Bob"},
+		{name: "ampersand and quotes", from: `Tom & "Jerry" 'O'`, want: `From Tom & "Jerry" 'O' to Bob`},
+		{name: "template action", from: "{{.To}}", want: "From {{.To}} to Bob"},
+		{name: "template function", from: `{{printf "%s" "pwned"}}`, want: `From {{printf "%s" "pwned"}} to Bob`},
+		{name: "template define", from: `{{define "x"}}pwned{{end}}{{template "x"}}`, want: `From {{define "x"}}pwned{{end}}{{template "x"}} to Bob`},
+		{name: "newline", from: "Alice\nto Mallory", want: "From Alice\nto Mallory to Bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tpl, map[string]any{"From": tt.from, "To": "Bob"})
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// chính body cũng là plain text, ký tự HTML trong body không bị escape
+func TestRenderBodyNotEscaped(t *testing.T) {
+	tpl := models.Template{ID: "html-body", Body: `<b>{{.From}}</b> & "{{.To}}"`}
+	got, err := Render(tpl, map[string]any{"From": "Alice", "To": "Bob"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := `<b>Alice</b> & "Bob"`; got != want {
+		t.Fatalf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		data map[string]any
+	}{
+		{name: "empty body", body: ""},
+		{name: "syntax error", body: "{{.From", data: map[string]any{"From": "Alice"}},
+		{name: "missing key", body: "{{.Subject}}", data: map[string]any{"From": "Alice"}},
+		{name: "unknown function", body: `{{exec "ls"}}`, data: map[string]any{"From": "Alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(models.Template{ID: "broken", Body: tt.body}, tt.data)
+			if !errors.Is(err, ErrInvalidTemplate) {
+				t.Fatalf("Render() = %q, %v, want ErrInvalidTemplate", got, err)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	templates := NewMemoryStore()
+	if _, err := templates.Get(ctx, "greeting"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() of missing template error = %v, want ErrNotFound", err)
+	}
+	tpl := models.Template{ID: "greeting", Body: "Hi {{.To}}"}
+	if err := templates.Save(ctx, tpl); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if got, err := templates.Get(ctx, "greeting"); err != nil || got != tpl {
+		t.Fatalf("Get() = %+v, %v, want %+v", got, err, tpl)
+	}
+}
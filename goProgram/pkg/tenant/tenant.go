@@ -0,0 +1,53 @@
+package tenant
+
+import "context"
+
+// HeaderTenantID là HTTP header chọn tenant của request, cũng là Kafka header
+// producer gắn vào message để consumer, DLQ và retry biết message thuộc tenant nào.
+const HeaderTenantID = "X-Tenant-ID"
+
+// ReasonTenantMismatch là lý do DLQ khi tenant trong payload khác header X-Tenant-ID.
+const ReasonTenantMismatch = "tenant mismatch"
+
+type contextKey struct{}
+
+// WithID gắn tenantID vào ctx để store và sender dùng, ID rỗng là tenant mặc định.
+func WithID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenantID)
+}
+
+// FromContext trả về tenant gắn bởi WithID, rỗng nếu không có.
+func FromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(contextKey{}).(string)
+	return tenantID
+}
+
+// Topic trả về topic của tenant, ví dụ "acme.notifications.high".
+// Tenant mặc định giữ nguyên tên topic để không phải đổi topic có sẵn.
+func Topic(tenantID, topic string) string {
+	if tenantID == "" {
+		return topic
+	}
+	return tenantID + "." + topic
+}
+
+// Topics trả về topics của tenant mặc định cộng với topics của từng tenant trong tenants.
+func Topics(tenants []string, topics []string) []string {
+	all := make([]string, 0, len(topics)*(len(tenants)+1))
+	all = append(all, topics...)
+	for _, tenantID := range tenants {
+		for _, topic := range topics {
+			all = append(all, Topic(tenantID, topic))
+		}
+	}
+	return all
+}
+
+// Key thêm namespace của tenant vào key lưu trữ (Redis, rate limiter),
+// tenant mặc định giữ nguyên key để đọc được dữ liệu cũ.
+func Key(tenantID, key string) string {
+	if tenantID == "" {
+		return key
+	}
+	return "tenant:" + tenantID + ":" + key
+}
@@ -0,0 +1,233 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// testPKI là CA tự ký cùng certificate server (127.0.0.1) và client do CA đó ký, sinh lúc chạy test
+type testPKI struct {
+	caFile, certFile, keyFile string
+	ca                        *x509.CertPool
+	server                    tls.Certificate
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+	caKey := newKey(t)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka-notify test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	pki := &testPKI{ca: x509.NewCertPool()}
+	pki.ca.AddCert(caCert)
+	pki.caFile = writePEM(t, dir, "ca.pem", "CERTIFICATE", caDER)
+
+	serverDER, serverKey := issue(t, caCert, caKey, 2, x509.ExtKeyUsageServerAuth)
+	pki.server = tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+
+	clientDER, clientKey := issue(t, caCert, caKey, 3, x509.ExtKeyUsageClientAuth)
+	keyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatalf("failed to marshal client key: %v", err)
+	}
+	pki.certFile = writePEM(t, dir, "client.pem", "CERTIFICATE", clientDER)
+	pki.keyFile = writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", keyDER)
+	return pki
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key
+}
+
+func issue(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, serial int64,
+	usage x509.ExtKeyUsage) ([]byte, *ecdsa.PrivateKey) {
+	t.Helper()
+	key := newKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to issue certificate: %v", err)
+	}
+	return der, key
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+// listen mở TLS listener dùng certificate server và bắt buộc client certificate do CA test ký
+func (pki *testPKI) listen(t *testing.T) net.Listener {
+	t.Helper()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{pki.server},
+		ClientCAs:    pki.ca,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	return listener
+}
+
+func TestBuildTLSConfigHandshake(t *testing.T) {
+	pki := newTestPKI(t)
+	config, err := BuildTLSConfig(pki.certFile, pki.keyFile, pki.caFile)
+	if err != nil {
+		t.Fatalf("BuildTLSConfig() error = %v", err)
+	}
+	if len(config.Certificates) != 1 || config.RootCAs == nil || config.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("config = %+v, want one client certificate, RootCAs and TLS 1.2", config)
+	}
+
+	listener := pki.listen(t)
+	defer listener.Close()
+	accepted := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			accepted <- err
+			return
+		}
+		defer conn.Close()
+		tlsConn := conn.(*tls.Conn)
+		if err := tlsConn.Handshake(); err != nil {
+			accepted <- err
+			return
+		}
+		if peers := tlsConn.ConnectionState().PeerCertificates; len(peers) == 0 {
+			accepted <- errors.New("client sent no certificate")
+			return
+		}
+		_, err = conn.Write([]byte("ok"))
+		accepted <- err
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), config)
+	if err != nil {
+		t.Fatalf("handshake with BuildTLSConfig() failed: %v", err)
+	}
+	defer conn.Close()
+	reply := make([]byte, 2)
+	if _, err := conn.Read(reply); err != nil || string(reply) != "ok" {
+		t.Fatalf("read %q, %v, want ok", reply, err)
+	}
+	if err := <-accepted; err != nil {
+		t.Fatalf("server side: %v", err)
+	}
+}
+
+// sarama kết nối được tới broker TLS yêu cầu client certificate khi dùng config của BuildTLSConfig
+func TestBuildTLSConfigWithSarama(t *testing.T) {
+	pki := newTestPKI(t)
+	broker := sarama.NewMockBrokerListener(t, 1, pki.listen(t))
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID()),
+	})
+
+	tlsConfig, err := BuildTLSConfig(pki.certFile, pki.keyFile, pki.caFile)
+	if err != nil {
+		t.Fatalf("BuildTLSConfig() error = %v", err)
+	}
+	config := sarama.NewConfig()
+	config.Net.TLS.Enable = true
+	config.Net.TLS.Config = tlsConfig
+	config.Metadata.Retry.Max = 0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatalf("sarama client over TLS failed: %v", err)
+	}
+	client.Close()
+}
+
+// không có client certificate thì broker bắt buộc mTLS từ chối handshake
+func TestBuildTLSConfigWithoutClientCertificate(t *testing.T) {
+	pki := newTestPKI(t)
+	config, err := BuildTLSConfig("", "", pki.caFile)
+	if err != nil {
+		t.Fatalf("BuildTLSConfig() error = %v", err)
+	}
+	if len(config.Certificates) != 0 {
+		t.Fatalf("%d client certificates, want none", len(config.Certificates))
+	}
+
+	listener := pki.listen(t)
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	conn, err := tls.Dial("tcp", listener.Addr().String(), config)
+	if err == nil {
+		// TLS 1.3 báo lỗi client certificate ở lần đọc đầu tiên
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	if err == nil {
+		t.Fatal("handshake without client certificate succeeded")
+	}
+}
+
+func TestBuildTLSConfigErrors(t *testing.T) {
+	pki := newTestPKI(t)
+	invalidCA := writePEM(t, t.TempDir(), "invalid.pem", "PRIVATE KEY", []byte("not a certificate"))
+
+	if _, err := BuildTLSConfig("", "", invalidCA); !errors.Is(err, ErrInvalidCA) {
+		t.Fatalf("invalid CA error = %v, want ErrInvalidCA", err)
+	}
+	if _, err := BuildTLSConfig("", "", filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Fatal("missing CA file: want error")
+	}
+	// key không khớp certificate
+	if _, err := BuildTLSConfig(pki.certFile, pki.caFile, ""); err == nil {
+		t.Fatal("mismatched key pair: want error")
+	}
+}
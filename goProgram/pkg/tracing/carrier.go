@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"github.com/IBM/sarama"
+)
+
+// KafkaHeaderCarrier cho phép Propagator.Inject ghi trace context và baggage vào header của message gửi đi,
+// Headers trỏ tới slice header của message (ví dụ &msg.Headers) để Set thêm được header mới.
+type KafkaHeaderCarrier struct {
+	Headers *[]sarama.RecordHeader
+}
+
+func (c KafkaHeaderCarrier) Get(key string) string {
+	for _, header := range *c.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Set ghi đè header đã có cùng key để message retry không bị lặp header.
+func (c KafkaHeaderCarrier) Set(key, value string) {
+	for i, header := range *c.Headers {
+		if string(header.Key) == key {
+			(*c.Headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.Headers = append(*c.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c KafkaHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.Headers))
+	for _, header := range *c.Headers {
+		keys = append(keys, string(header.Key))
+	}
+	return keys
+}
+
+// ConsumerMessageCarrier cho phép Propagator.Extract đọc trace context và baggage từ message nhận được.
+type ConsumerMessageCarrier struct {
+	Msg *sarama.ConsumerMessage
+}
+
+func (c ConsumerMessageCarrier) Get(key string) string {
+	for _, header := range c.Msg.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Set chỉ có để thoả mãn propagation.TextMapCarrier, message đã nhận thì không sửa header.
+func (c ConsumerMessageCarrier) Set(key, value string) {}
+
+func (c ConsumerMessageCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.Msg.Headers))
+	for _, header := range c.Msg.Headers {
+		keys = append(keys, string(header.Key))
+	}
+	return keys
+}
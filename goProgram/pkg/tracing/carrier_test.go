@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// consumed chuyển header của ProducerMessage sang ConsumerMessage như consumer nhận được
+func consumed(msg *sarama.ProducerMessage) *sarama.ConsumerMessage {
+	headers := make([]*sarama.RecordHeader, len(msg.Headers))
+	for i := range msg.Headers {
+		headers[i] = &msg.Headers[i]
+	}
+	return &sarama.ConsumerMessage{Topic: msg.Topic, Headers: headers}
+}
+
+func newTestBaggage(t *testing.T) baggage.Baggage {
+	t.Helper()
+	property, err := baggage.NewKeyValueProperty("source", "mobile")
+	if err != nil {
+		t.Fatalf("NewKeyValueProperty() error = %v", err)
+	}
+	var members []baggage.Member
+	for _, kv := range []struct {
+		key, value string
+		properties []baggage.Property
+	}{
+		{key: "tenant-id", value: "acme"},
+		{key: "experiment-id", value: "checkout-v2", properties: []baggage.Property{property}},
+		{key: "app-version", value: "2.1.0-beta_1"},
+	} {
+		member, err := baggage.NewMember(kv.key, kv.value, kv.properties...)
+		if err != nil {
+			t.Fatalf("NewMember(%s) error = %v", kv.key, err)
+		}
+		members = append(members, member)
+	}
+	bag, err := baggage.New(members...)
+	if err != nil {
+		t.Fatalf("baggage.New() error = %v", err)
+	}
+	return bag
+}
+
+func TestBaggageRoundTrip(t *testing.T) {
+	want := newTestBaggage(t)
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(baggage.ContextWithBaggage(context.Background(), want), spanContext)
+
+	msg := &sarama.ProducerMessage{
+		Topic:   "notifications.normal",
+		Headers: []sarama.RecordHeader{{Key: []byte("X-Correlation-ID"), Value: []byte("abc")}},
+	}
+	InjectProducerMessage(ctx, msg)
+	// producer retry inject lại vào cùng message, header không bị lặp
+	InjectProducerMessage(ctx, msg)
+	if len(msg.Headers) != 3 {
+		t.Fatalf("headers = %d, want correlation ID, traceparent and baggage", len(msg.Headers))
+	}
+
+	consumerCtx, span := StartConsumerSpan(context.Background(), consumed(msg))
+	defer span.End()
+	got := baggage.FromContext(consumerCtx)
+	if got.Len() != want.Len() {
+		t.Fatalf("baggage has %d members, want %d: %s", got.Len(), want.Len(), got)
+	}
+	for _, member := range want.Members() {
+		gotMember := got.Member(member.Key())
+		if gotMember.Value() != member.Value() || gotMember.String() != member.String() {
+			t.Errorf("baggage member %s = %q, want %q", member.Key(), gotMember.String(), member.String())
+		}
+	}
+	if got := trace.SpanContextFromContext(consumerCtx).TraceID(); got != spanContext.TraceID() {
+		t.Fatalf("consumer trace ID = %s, want %s", got, spanContext.TraceID())
+	}
+}
+
+func TestBaggageRoundTripEmpty(t *testing.T) {
+	msg := &sarama.ProducerMessage{Topic: "notifications.normal"}
+	InjectProducerMessage(context.Background(), msg)
+	if len(msg.Headers) != 0 {
+		t.Fatalf("headers = %v, want none without trace context or baggage", msg.Headers)
+	}
+	ctx, span := StartConsumerSpan(context.Background(), consumed(msg))
+	defer span.End()
+	if got := baggage.FromContext(ctx); got.Len() != 0 {
+		t.Fatalf("baggage = %s, want empty", got)
+	}
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartProducerSpan tạo span cho việc gửi message tới topic.
+func StartProducerSpan(ctx context.Context, topic string) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, topic+" send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("kafka"),
+			semconv.MessagingDestinationName(topic),
+		))
+}
+
+// StartConsumerSpan lấy trace context và baggage từ header của msg và tạo span con cho việc xử lý message,
+// nhờ vậy span của consumer nối tiếp span của producer trong cùng một trace và ctx trả về
+// có baggage giống hệt lúc gửi.
+func StartConsumerSpan(ctx context.Context, msg *sarama.ConsumerMessage) (context.Context, trace.Span) {
+	ctx = Propagator.Extract(ctx, ConsumerMessageCarrier{Msg: msg})
+	return otel.Tracer(TracerName).Start(ctx, msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("kafka"),
+			semconv.MessagingSourceName(msg.Topic),
+			semconv.MessagingKafkaSourcePartition(int(msg.Partition)),
+			semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+		))
+}
+
+// InjectProducerMessage ghi trace context và baggage của ctx vào header của msg.
+func InjectProducerMessage(ctx context.Context, msg *sarama.ProducerMessage) {
+	Propagator.Inject(ctx, KafkaHeaderCarrier{Headers: &msg.Headers})
+}
+
+// SetDelivered ghi lại partition/offset mà broker trả về sau khi gửi thành công.
+func SetDelivered(span trace.Span, partition int32, offset int64) {
+	span.SetAttributes(
+		semconv.MessagingKafkaDestinationPartition(int(partition)),
+		attribute.Int64("messaging.kafka.message.offset", offset),
+	)
+}
+
+// RecordError đánh dấu span là lỗi, err nil thì bỏ qua.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// TracerName là tên instrumentation dùng khi tạo span trong service.
+const TracerName = "kafka-notify"
+
+// Propagator ghi/đọc trace context (W3C traceparent) và baggage (W3C baggage, ví dụ tenant-id,
+// experiment-id) vào Kafka header.
+var Propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{}, propagation.Baggage{})
+
+// SetupTracer khởi tạo TracerProvider và đăng ký làm provider global.
+// Span được gửi qua OTLP/HTTP tới OTEL_EXPORTER_OTLP_ENDPOINT; nếu biến này không được set
+// thì span vẫn được tạo (để propagate qua Kafka) nhưng không export đi đâu cả.
+func SetupTracer(serviceName string) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+		// exporter tự đọc OTEL_EXPORTER_OTLP_ENDPOINT và các biến OTEL_* khác
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(Propagator)
+	return provider, nil
+}
@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// số message tối đa chờ trong channel của mỗi worker
+const defaultBuffer = 64
+
+// ProcessFunc xử lý một message, lỗi trả về sẽ dừng pool.
+type ProcessFunc func(msg *sarama.ConsumerMessage) error
+
+// WorkerPool xử lý message của một partition trên Size goroutine.
+// Message cùng key luôn vào cùng một worker nên thứ tự theo từng người nhận được giữ nguyên.
+type WorkerPool struct {
+	Size int
+	// Buffer là kích thước channel của mỗi worker, 0 dùng defaultBuffer
+	Buffer int
+}
+
+// Run đọc messages cho tới khi channel đóng, ctx bị huỷ hoặc process trả về lỗi,
+// rồi chờ các worker xử lý xong message đang giữ (WaitGroup) trước khi trả về.
+//
+// mark chỉ được gọi khi mọi message nhận trước đó đều đã xử lý xong, theo đúng thứ tự nhận,
+// nên offset commit không bao giờ vượt qua message chưa xử lý. process trả về nil nghĩa là
+// message đã xong (kể cả khi đã bị chuyển sang DLQ/retry), mark sẽ không được gọi cho message lỗi
+// và mọi message sau nó.
+func (p WorkerPool) Run(ctx context.Context, messages <-chan *sarama.ConsumerMessage,
+	process ProcessFunc, mark func(*sarama.ConsumerMessage)) error {
+	size := p.Size
+	if size < 1 {
+		size = 1
+	}
+	buffer := p.Buffer
+	if buffer <= 0 {
+		buffer = defaultBuffer
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tracker := &orderedMarker{mark: mark}
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	queues := make([]chan *pending, size)
+	for i := range queues {
+		queues[i] = make(chan *pending, buffer)
+		wg.Add(1)
+		go func(queue <-chan *pending) {
+			defer wg.Done()
+			for item := range queue {
+				if ctx.Err() != nil {
+					// pool đang dừng: bỏ qua, message chưa mark sẽ được đọc lại ở session sau
+					continue
+				}
+				if err := process(item.msg); err != nil {
+					errOnce.Do(func() { firstErr = err })
+					cancel()
+					continue
+				}
+				tracker.done(item)
+			}
+		}(queues[i])
+	}
+
+dispatch:
+	for {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case msg, ok := <-messages:
+			if !ok {
+				break dispatch
+			}
+			item := tracker.add(msg)
+			select {
+			case queues[workerFor(msg.Key, size)] <- item:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+	}
+
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func workerFor(key []byte, size int) int {
+	if size == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write(key)
+	return int(h.Sum32() % uint32(size))
+}
+
+type pending struct {
+	msg      *sarama.ConsumerMessage
+	finished bool
+}
+
+// orderedMarker giữ message theo thứ tự nhận và chỉ mark phần đầu đã xử lý xong liên tiếp.
+type orderedMarker struct {
+	mu    sync.Mutex
+	queue []*pending
+	mark  func(*sarama.ConsumerMessage)
+}
+
+func (m *orderedMarker) add(msg *sarama.ConsumerMessage) *pending {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item := &pending{msg: msg}
+	m.queue = append(m.queue, item)
+	return item
+}
+
+func (m *orderedMarker) done(item *pending) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item.finished = true
+	var last *sarama.ConsumerMessage
+	for len(m.queue) > 0 && m.queue[0].finished {
+		last = m.queue[0].msg
+		m.queue[0] = nil
+		m.queue = m.queue[1:]
+	}
+	// mark offset lớn nhất là đủ, Kafka coi mọi offset trước nó đã xử lý
+	if last != nil {
+		m.mark(last)
+	}
+}
@@ -0,0 +1,178 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+)
+
+const benchTopic = "notifications.normal"
+
+// messagesOf đưa count message (key là user 0..users-1 xoay vòng) vào channel đã đóng
+func messagesOf(count, users int) <-chan *sarama.ConsumerMessage {
+	messages := make(chan *sarama.ConsumerMessage, count)
+	for i := 0; i < count; i++ {
+		messages <- &sarama.ConsumerMessage{
+			Topic: benchTopic, Offset: int64(i), Key: []byte(strconv.Itoa(i % users)),
+		}
+	}
+	close(messages)
+	return messages
+}
+
+// offsetMarks ghi lại các offset được mark, lỗi nếu mark lùi hoặc trùng
+type offsetMarks struct {
+	t      *testing.T
+	mu     sync.Mutex
+	marked []int64
+}
+
+func (m *offsetMarks) mark(msg *sarama.ConsumerMessage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n := len(m.marked); n > 0 && msg.Offset <= m.marked[n-1] {
+		m.t.Errorf("marked offset %d after %d", msg.Offset, m.marked[n-1])
+	}
+	m.marked = append(m.marked, msg.Offset)
+}
+
+func (m *offsetMarks) last() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.marked) == 0 {
+		return -1
+	}
+	return m.marked[len(m.marked)-1]
+}
+
+// worker xong không theo thứ tự nhận nhưng offset chỉ được mark tăng dần, không vượt message chưa xong
+func TestWorkerPoolMarksOffsetsInOrder(t *testing.T) {
+	const count = 2000
+	var mu sync.Mutex
+	finished := make(map[int64]bool, count)
+	marks := &offsetMarks{t: t}
+	process := func(msg *sarama.ConsumerMessage) error {
+		time.Sleep(time.Duration(rand.Intn(200)) * time.Microsecond)
+		mu.Lock()
+		defer mu.Unlock()
+		finished[msg.Offset] = true
+		return nil
+	}
+	mark := func(msg *sarama.ConsumerMessage) {
+		mu.Lock()
+		for offset := int64(0); offset <= msg.Offset; offset++ {
+			if !finished[offset] {
+				t.Errorf("marked offset %d before offset %d finished", msg.Offset, offset)
+				break
+			}
+		}
+		mu.Unlock()
+		marks.mark(msg)
+	}
+
+	err := WorkerPool{Size: 10}.Run(context.Background(), messagesOf(count, 37), process, mark)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(finished) != count || marks.last() != count-1 {
+		t.Fatalf("processed %d messages, last marked %d, want %d and %d", len(finished), marks.last(), count, count-1)
+	}
+}
+
+// message cùng key được xử lý đúng thứ tự nhận
+func TestWorkerPoolKeepsOrderPerKey(t *testing.T) {
+	const count, users = 1000, 7
+	var mu sync.Mutex
+	lastByKey := make(map[string]int64)
+	process := func(msg *sarama.ConsumerMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if last, ok := lastByKey[string(msg.Key)]; ok && msg.Offset < last {
+			t.Errorf("key %s: offset %d processed after %d", msg.Key, msg.Offset, last)
+		}
+		lastByKey[string(msg.Key)] = msg.Offset
+		return nil
+	}
+	if err := (WorkerPool{Size: 4}).Run(context.Background(), messagesOf(count, users), process, func(*sarama.ConsumerMessage) {}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+// message lỗi dừng pool, offset của nó và mọi message sau không được mark
+func TestWorkerPoolStopsMarkingAtError(t *testing.T) {
+	const count, failAt = 500, 200
+	errProcess := errors.New("process failed")
+	marks := &offsetMarks{t: t}
+	process := func(msg *sarama.ConsumerMessage) error {
+		if msg.Offset == failAt {
+			return errProcess
+		}
+		return nil
+	}
+	err := WorkerPool{Size: 8}.Run(context.Background(), messagesOf(count, 13), process, marks.mark)
+	if !errors.Is(err, errProcess) {
+		t.Fatalf("Run() error = %v, want errProcess", err)
+	}
+	if last := marks.last(); last >= failAt {
+		t.Fatalf("marked offset %d, want below the failed offset %d", last, failAt)
+	}
+}
+
+// BenchmarkWorkerPool đọc 10.000 message từ topic giả (sarama/mocks) mỗi vòng,
+// mỗi message giả lập một lần gọi I/O ngắn như lưu store hoặc giao webhook.
+func BenchmarkWorkerPool(b *testing.B) {
+	const messages, users = 10000, 100
+	for _, size := range []int{1, 10, 50} {
+		b.Run("size="+strconv.Itoa(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				config := mocks.NewTestConfig()
+				// YieldMessage ghi thẳng vào channel của mock, buffer phải chứa đủ cả topic
+				config.ChannelBufferSize = messages
+				consumer := mocks.NewConsumer(b, config)
+				partition := consumer.ExpectConsumePartition(benchTopic, 0, sarama.OffsetOldest)
+				for offset := 0; offset < messages; offset++ {
+					partition.YieldMessage(&sarama.ConsumerMessage{Key: []byte(strconv.Itoa(offset % users)), Value: []byte("hello")})
+				}
+				claim, err := consumer.ConsumePartition(benchTopic, 0, sarama.OffsetOldest)
+				if err != nil {
+					b.Fatalf("ConsumePartition() error = %v", err)
+				}
+				// channel của mock không tự đóng, dừng sau khi nhận đủ message
+				in := make(chan *sarama.ConsumerMessage)
+				go func() {
+					defer close(in)
+					for received := 0; received < messages; received++ {
+						in <- <-claim.Messages()
+					}
+				}()
+				var last int64
+				b.StartTimer()
+
+				err = WorkerPool{Size: size}.Run(context.Background(), in, func(*sarama.ConsumerMessage) error {
+					time.Sleep(20 * time.Microsecond)
+					return nil
+				}, func(msg *sarama.ConsumerMessage) { last = msg.Offset })
+
+				b.StopTimer()
+				if err != nil {
+					b.Fatalf("Run() error = %v", err)
+				}
+				if last != messages-1 {
+					b.Fatalf("last marked offset = %d, want %d", last, messages-1)
+				}
+				claim.Close()
+				consumer.Close()
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(messages*b.N)/b.Elapsed().Seconds(), "msgs/s")
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package worker
+
+import "context"
+
+// Semaphore giới hạn số goroutine chạy cùng lúc, ví dụ số partition được xử lý song song.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore tạo semaphore cho tối đa size goroutine, size < 1 được tính là 1.
+func NewSemaphore(size int) *Semaphore {
+	if size < 1 {
+		size = 1
+	}
+	return &Semaphore{slots: make(chan struct{}, size)}
+}
+
+// Acquire chờ tới khi có chỗ trống hoặc ctx bị huỷ. Acquire thành công thì phải
+// gọi Release bằng defer để chỗ được trả lại cả khi goroutine panic.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Semaphore) Release() {
+	<-s.slots
+}
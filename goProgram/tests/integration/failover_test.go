@@ -0,0 +1,127 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	kafkautil "kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// embeddedCluster là hai MockBroker chạy ngay trong test, topic chỉ có partition 0 nên mọi message đi tới leader của nó
+type embeddedCluster struct {
+	brokers [2]*sarama.MockBroker
+	closers [2]sync.Once
+	topic   string
+}
+
+func newEmbeddedCluster(t *testing.T, topic string) *embeddedCluster {
+	t.Helper()
+	c := &embeddedCluster{topic: topic}
+	for i := range c.brokers {
+		c.brokers[i] = sarama.NewMockBroker(t, int32(i+1))
+		i := i
+		t.Cleanup(func() { c.kill(i) })
+	}
+	c.setLeader(t, c.brokers[0])
+	return c
+}
+
+func (c *embeddedCluster) addrs() []string {
+	return []string{c.brokers[0].Addr(), c.brokers[1].Addr()}
+}
+
+// kill tắt broker thứ i, MockBroker không cho Close hai lần
+func (c *embeddedCluster) kill(i int) {
+	c.closers[i].Do(c.brokers[i].Close)
+}
+
+// setLeader cập nhật metadata của các broker còn sống như khi controller bầu leader mới
+func (c *embeddedCluster) setLeader(t *testing.T, leader *sarama.MockBroker, alive ...*sarama.MockBroker) {
+	if len(alive) == 0 {
+		alive = c.brokers[:]
+	}
+	for _, broker := range alive {
+		metadata := sarama.NewMockMetadataResponse(t).SetLeader(c.topic, 0, leader.BrokerID())
+		for _, b := range alive {
+			metadata.SetBroker(b.Addr(), b.BrokerID())
+		}
+		broker.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": metadata,
+			"ProduceRequest":  sarama.NewMockProduceResponse(t).SetError(c.topic, 0, sarama.ErrNoError),
+		})
+	}
+}
+
+// produceRequests đếm số ProduceRequest broker đã nhận. Records của request không được export
+// nên chỉ đếm được, message nào đã tới được kiểm tra qua kết quả của sender.Send.
+func produceRequests(broker *sarama.MockBroker) int {
+	count := 0
+	for _, rr := range broker.History() {
+		if _, ok := rr.Request.(*sarama.ProduceRequest); ok {
+			count++
+		}
+	}
+	return count
+}
+
+// broker leader chết khi đang gửi message: producer retry, lấy lại metadata từ broker còn lại
+// và message vẫn tới leader mới nhờ KAFKA_BROKERS có đủ hai broker
+func TestProducerSurvivesBrokerFailure(t *testing.T) {
+	topic := config.PriorityTopic("notifications", models.PriorityNormal)
+	cluster := newEmbeddedCluster(t, topic)
+	t.Setenv("KAFKA_BROKERS", strings.Join(cluster.addrs(), ","))
+	t.Setenv("KAFKA_TOPIC_PREFIX", "notifications")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	producer, err := kafkautil.SetupProducer(cfg)
+	if err != nil {
+		t.Fatalf("failed to setup producer: %v", err)
+	}
+	defer producer.Close()
+	opts := sender.NewOptions(cfg, codec.JSONCodec{})
+
+	send := func(message string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		notification := models.Notification{
+			ID: message, From: models.User{ID: 1, Name: "Alice"}, To: models.User{ID: 2, Name: "Bob"},
+			Message: message, Priority: models.PriorityNormal,
+		}
+		_, _, err := sender.Send(ctx, producer, opts, notification)
+		return err
+	}
+	if err := send("before failure"); err != nil {
+		t.Fatalf("send before failure: %v", err)
+	}
+	if got := produceRequests(cluster.brokers[0]); got != 1 {
+		t.Fatalf("leader received %d produce requests, want 1", got)
+	}
+
+	// leader trả lời chậm để message thứ hai còn đang gửi khi broker bị tắt
+	oldLeader, newLeader := cluster.brokers[0], cluster.brokers[1]
+	oldLeader.SetLatency(time.Second)
+	result := make(chan error, 1)
+	go func() { result <- send("during failure") }()
+	time.Sleep(200 * time.Millisecond)
+	cluster.setLeader(t, newLeader, newLeader)
+	cluster.kill(0)
+
+	if err := <-result; err != nil {
+		t.Fatalf("send during failure: %v", err)
+	}
+	if got := produceRequests(newLeader); got != 1 {
+		t.Fatalf("new leader received %d produce requests, want 1", got)
+	}
+}
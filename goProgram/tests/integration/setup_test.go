@@ -0,0 +1,176 @@
+//go:build integration
+
+// Package integration chạy producer và consumer với Kafka thật trong container (testcontainers-go),
+// chỉ chạy khi bật tag: go test -tags integration ./tests/integration/...
+package integration
+
+import (
+	"context"
+	"kafka-notify/pkg/admin"
+	"kafka-notify/pkg/codec"
+	"kafka-notify/pkg/config"
+	kafkautil "kafka-notify/pkg/kafka"
+	"kafka-notify/pkg/models"
+	"kafka-notify/pkg/sender"
+	"kafka-notify/pkg/store"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/kafka"
+)
+
+const messageCount = 100
+
+// collector là ConsumerGroupHandler giải mã message giống consumer (codec theo Content-Type)
+// và gom notification cho tới khi đủ want.
+type collector struct {
+	want int
+	done chan struct{}
+	once sync.Once
+
+	mu            sync.Mutex
+	notifications map[string]models.Notification
+	errs          []error
+}
+
+func (*collector) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (*collector) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (c *collector) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		notificationCodec, err := codec.ForContentType(kafkautil.HeaderValue(msg.Headers, codec.HeaderContentType))
+		var notification models.Notification
+		if err == nil {
+			notification, err = notificationCodec.Unmarshal(msg.Value)
+		}
+
+		c.mu.Lock()
+		if err != nil {
+			c.errs = append(c.errs, err)
+		} else {
+			c.notifications[notification.Message] = notification
+		}
+		if len(c.notifications) == c.want {
+			// message có thể bị đọc lại sau rebalance, done chỉ được đóng một lần
+			c.once.Do(func() { close(c.done) })
+		}
+		c.mu.Unlock()
+		session.MarkMessage(msg, "")
+	}
+	return nil
+}
+
+func startKafka(t *testing.T) []string {
+	t.Helper()
+	ctx := context.Background()
+	container, err := kafka.RunContainer(ctx,
+		testcontainers.WithImage("confluentinc/confluent-local:7.5.0"),
+		kafka.WithClusterID("kafka-notify-integration"),
+	)
+	if err != nil {
+		t.Fatalf("failed to start kafka container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := container.Terminate(context.Background()); err != nil {
+			t.Errorf("failed to terminate kafka container: %v", err)
+		}
+	})
+	brokers, err := container.Brokers(ctx)
+	if err != nil {
+		t.Fatalf("failed to get kafka brokers: %v", err)
+	}
+	return brokers
+}
+
+func TestProducerToConsumer(t *testing.T) {
+	brokers := startKafka(t)
+	t.Setenv("KAFKA_BROKERS", strings.Join(brokers, ","))
+	t.Setenv("KAFKA_AUTO_CREATE_TOPICS", "true")
+	t.Setenv("KAFKA_TOPIC_PARTITIONS", "3")
+	t.Setenv("KAFKA_TOPIC_REPLICATION_FACTOR", "1")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if _, err := admin.EnsureConfiguredTopics(cfg); err != nil {
+		t.Fatalf("failed to create topics: %v", err)
+	}
+
+	producer, err := kafkautil.SetupProducer(cfg)
+	if err != nil {
+		t.Fatalf("failed to setup producer: %v", err)
+	}
+	defer producer.Close()
+
+	ctx := context.Background()
+	users, closeUsers, err := store.OpenUserStore(ctx, "")
+	if err != nil {
+		t.Fatalf("failed to open user store: %v", err)
+	}
+	defer closeUsers()
+	opts := sender.NewOptions(cfg, codec.JSONCodec{})
+
+	sent := make(map[string]models.Notification, messageCount)
+	for i := 0; i < messageCount; i++ {
+		fromID, toID := i%4+1, (i+1)%4+1
+		message := "integration message " + strconv.Itoa(i)
+		notification, err := sender.BuildNotification(ctx, users, fromID, toID, message, models.PriorityNormal)
+		if err != nil {
+			t.Fatalf("failed to build notification %d: %v", i, err)
+		}
+		if _, _, err := sender.Send(ctx, producer, opts, notification); err != nil {
+			t.Fatalf("failed to send notification %d: %v", i, err)
+		}
+		sent[message] = notification
+	}
+
+	consumerConfig := sarama.NewConfig()
+	consumerConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	group, err := sarama.NewConsumerGroup(brokers, "kafka-notify-integration", consumerConfig)
+	if err != nil {
+		t.Fatalf("failed to create consumer group: %v", err)
+	}
+	defer group.Close()
+
+	handler := &collector{want: messageCount, done: make(chan struct{}), notifications: make(map[string]models.Notification)}
+	consumeCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	go func() {
+		for consumeCtx.Err() == nil {
+			if err := group.Consume(consumeCtx, cfg.ConsumerTopics(), handler); err != nil && consumeCtx.Err() == nil {
+				t.Errorf("consume failed: %v", err)
+				return
+			}
+		}
+	}()
+	select {
+	case <-handler.done:
+	case <-consumeCtx.Done():
+	}
+	cancel()
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	for _, err := range handler.errs {
+		t.Errorf("failed to decode message: %v", err)
+	}
+	if len(handler.notifications) != messageCount {
+		t.Fatalf("received %d notifications, want %d", len(handler.notifications), messageCount)
+	}
+	for message, want := range sent {
+		got, ok := handler.notifications[message]
+		if !ok {
+			t.Errorf("notification %q was not received", message)
+			continue
+		}
+		if got.From.ID != want.From.ID || got.To.ID != want.To.ID || got.Message != want.Message {
+			t.Errorf("notification %q: got from %d to %d, want from %d to %d",
+				message, got.From.ID, got.To.ID, want.From.ID, want.To.ID)
+		}
+	}
+}